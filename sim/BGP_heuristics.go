@@ -1,19 +1,102 @@
-package main
+package sim
 
-import ("log"
+import ("fmt"
+    "log"
+    "strconv"
     "strings"
     tree "github.com/Emeline-1/anaximander_simulator/tree")
 
 /**
- * Array holding all heuristic functions
+ * Array holding all heuristic functions.
+ * A heuristic selects the best of the entries of a prefix (current_routing_entries_set, emptied
+ * afterwards), and returns the prefix and the selected entry (nil if all entries were discarded).
  */
-type apply_heuristic_fn func (*SafeSet, *SafeSet, []string)
+type apply_heuristic_fn func (*SafeSet, []string, *Heuristic_stats) (string, *Rib_entry)
+
+// Anomalies met by the heuristics, per collector
+type Heuristic_stats struct {
+    pivot_at_start int; // Prefixes with a pivot node starting one of its paths (entries skipped, see select_entry)
+}
 
 var apply_heuristic_fc []apply_heuristic_fn = []apply_heuristic_fn {
     apply_shortest_path_heuristic,
     apply_valley_free_heuristic,
 }
 
+// Names of the heuristics (-h), in the order of apply_heuristic_fc.
+var heuristic_names []string = []string {
+    "shortest",
+    "valley_free",
+}
+
+/**
+ * Returns the index in apply_heuristic_fc of a heuristic given by name or by number.
+ */
+func parse_heuristic (s string) (int, error) {
+    for i, name := range heuristic_names {
+        if s == name {
+            return i, nil
+        }
+    }
+    if i, err := strconv.Atoi (s); err == nil && i >= 0 && i < len (apply_heuristic_fc) {
+        return i, nil
+    }
+    return 0, fmt.Errorf ("unknown BGP heuristic %q (%s, or their number)", s, strings.Join (heuristic_names, ", "))
+}
+
+/* --- Tie-breaks --- */
+
+// Generates a tie-break for select_entry, or nil if it does not apply (e.g., outside pivot nodes).
+type tiebreak_generator func (pivot_node, max_next_hop string, nb int) heuristic_fn
+
+var tiebreak_generators map[string]tiebreak_generator = map[string]tiebreak_generator {
+    "popularity": func (pivot_node, max_next_hop string, nb int) heuristic_fn {
+        if pivot_node == "" { // Only defined among the paths going through a pivot node
+            return nil
+        }
+        return generate_next_hop_popularity_heuristic (pivot_node, max_next_hop, nb)
+    },
+    "shortest": func (pivot_node, max_next_hop string, nb int) heuristic_fn {
+        return generate_shortest_path_heuristic ()
+    },
+    "most_ases_interest": func (pivot_node, max_next_hop string, nb int) heuristic_fn {
+        return generate_most_ases_interest_heuristic ()
+    },
+}
+
+var default_tiebreaks []string = []string{"popularity", "shortest", "most_ases_interest"}
+
+/**
+ * Parses an ordered, comma-separated list of tie-breaks (-tiebreak). Unknown and repeated names are rejected.
+ * With the shortest-path heuristic, the shortest path always comes first (it is the heuristic itself),
+ * followed by the other tie-breaks in the given order.
+ */
+func parse_tiebreaks (s string, heuristic int) ([]string, error) {
+    tiebreaks := make ([]string, 0, len (tiebreak_generators))
+    seen := make (map[string]struct{})
+    for _, name := range strings.Split (s, ",") {
+        name = strings.TrimSpace (name)
+        if _, ok := tiebreak_generators[name]; !ok {
+            return nil, fmt.Errorf ("unknown tie-break %q (popularity, shortest, most_ases_interest)", name)
+        }
+        if _, ok := seen[name]; ok {
+            return nil, fmt.Errorf ("tie-break %q given twice", name)
+        }
+        seen[name] = struct{}{}
+        tiebreaks = append (tiebreaks, name)
+    }
+    if heuristic_names[heuristic] == "shortest" {
+        resolved := []string{"shortest"}
+        for _, name := range tiebreaks {
+            if name != "shortest" {
+                resolved = append (resolved, name)
+            }
+        }
+        tiebreaks = resolved
+    }
+    return tiebreaks, nil
+}
+
 /* ==================================== *\
         VALLEY FREE HEURISTIC
 \* ==================================== */
@@ -25,10 +108,46 @@ type Nodes struct {
     // value: key: one of the path going through that node.
     node_to_entries map[string]map[*Rib_entry]interface{}
     pivot_nodes map[string]struct{} // Nodes at which there is a split in the paths
+    entry_to_pivots map[*Rib_entry][]string // Reverse index: the pivot nodes of each path, once each (see index_pivots)
+    next_hops_count map[string]int // Next hop (relative to a pivot) -> number of paths going through it
 }
 
 func NewNodes () *Nodes {
-    return &Nodes{node_to_entries: make (map[string]map[*Rib_entry]interface{}), pivot_nodes:make (map[string]struct{})}
+    return &Nodes{node_to_entries: make (map[string]map[*Rib_entry]interface{}), pivot_nodes:make (map[string]struct{}),
+        entry_to_pivots: make (map[*Rib_entry][]string), next_hops_count: make (map[string]int)}
+}
+
+/**
+ * Indexes the paths by pivot node, once the tree is built: records the pivot nodes of each path, and
+ * the number of paths going through the next-hops of the pivot nodes.
+ * A pivot node first in one of its (reversed) paths is not a real pivot node, and is deleted:
+ * 1. X Y Z
+ * 2. W Y
+ * Y is considered a pivot node by construction of the tree, but the second path has no next hop at Y.
+ * The next hops of a deleted pivot node are not counted (they were before, depending on the order of the map).
+ */
+func (nodes *Nodes) index_pivots () {
+    next_hops := []string{}
+    for pivot_node, _ := range nodes.pivot_nodes {
+        next_hops = next_hops[:0]
+        for routing_entry, _ := range nodes.node_to_entries[pivot_node] {
+            index := find_index (routing_entry.as_path, pivot_node)
+            if index == 0 {
+                delete (nodes.pivot_nodes, pivot_node) // Safe to delete key while iterating
+                break
+            }
+            next_hops = append (next_hops, routing_entry.as_path[index-1])
+        }
+        if _, ok := nodes.pivot_nodes[pivot_node]; !ok {
+            continue
+        }
+        for _, next_hop := range next_hops {
+            nodes.next_hops_count[next_hop] = len (nodes.node_to_entries[next_hop])
+        }
+        for routing_entry, _ := range nodes.node_to_entries[pivot_node] {
+            nodes.entry_to_pivots[routing_entry] = append (nodes.entry_to_pivots[routing_entry], pivot_node)
+        }
+    }
 }
 
 /**
@@ -57,7 +176,7 @@ func generate_if_present (nodes *Nodes) func (string, interface{}) {
 
 /**
  * For a set of RIB entries for a given prefix, select the best one according to the valley free heuristic,
- * and record it in the routing_entries_set.
+ * and return it.
  * 
  * How it is done:
  *  If two or more path diverge at a given AS, select the paths based on the next-hop AS (customer > peer > provider).
@@ -80,42 +199,31 @@ func generate_if_present (nodes *Nodes) func (string, interface{}) {
  *   algorithm can handle two different roots.
  *   ex: bgpreader -t ribs -c rrc22 -w 1618876800,1618877100 -k 176.109.160.0/22
  */
-func apply_valley_free_heuristic (routing_entries_set, current_routing_entries_set *SafeSet, ases_interest []string) {
+func apply_valley_free_heuristic (current_routing_entries_set *SafeSet, ases_interest []string, stats *Heuristic_stats) (string, *Rib_entry) {
     
     /* --- Build the tree of path --- */
     _, nodes := build_tree (current_routing_entries_set)
 
-    /* --- Record the number of paths going through the next-hops (relative to pivots) --- */
-    next_hops_count := make (map[string]int)
-    for pivot_node,_ := range nodes.pivot_nodes {
-        for routing_entry, _ := range nodes.node_to_entries[pivot_node] { // Reversed path.
-            path := routing_entry.as_path
-
-            index := find_index (path, pivot_node)
-            if index == 0 { 
-                // This means that we are in a situation such that:
-                // 1. X Y Z
-                // 2. W Y
-                // Y will be considered a pivot node (by construction of the tree, but it's not really a pivot node -> Just delete it)
-                delete (nodes.pivot_nodes, pivot_node)
-                continue
-            }
-            next_hop := path[index-1]
-            next_hops_count[next_hop] = len(nodes.node_to_entries[next_hop])
-        }
-    }
+    /* --- Most popular next-hop (relative to pivots, see index_pivots) --- */
     // Convert to map of interface for compiling reasons.
-    next_hops_count_i := make (map[string]interface{}, len (next_hops_count))
-    for s,i := range next_hops_count {
+    next_hops_count_i := make (map[string]interface{}, len (nodes.next_hops_count))
+    for s,i := range nodes.next_hops_count {
         next_hops_count_i[s] = i
     }
     max_next_hop, nb := search_map (next_hops_count_i, MoreInt) // Get more popular next-hop
 
     /* --- Select entries among those going through pivot nodes --- */
     selected_entries := make (map[*Rib_entry]interface{})
+    pivot_at_start := false
     for pivot_node,_ := range nodes.pivot_nodes { // Loop over all pivot nodes
-        selected_entry := select_entry (pivot_node, nodes.node_to_entries[pivot_node], max_next_hop, nb)
-        selected_entries[selected_entry] = struct{}{}
+        selected_entry, skipped := select_entry (pivot_node, nodes.node_to_entries[pivot_node], max_next_hop, nb)
+        pivot_at_start = pivot_at_start || skipped
+        if selected_entry != nil { // All its entries may have been skipped
+            selected_entries[selected_entry] = struct{}{}
+        }
+    }
+    if pivot_at_start {
+        stats.pivot_at_start++
     }
 
     /* --- Take into account the paths that don't go through pivot nodes --- */
@@ -127,33 +235,19 @@ func apply_valley_free_heuristic (routing_entries_set, current_routing_entries_s
         }
 
         routing_entry := routing_entry_i.(*Rib_entry)
-        found := false
-        for pivot_node,_ := range nodes.pivot_nodes { 
-            for entry,_ := range nodes.node_to_entries[pivot_node] {
-                if entry == routing_entry {
-                    found = true
-                    break
-                }
-            }
-            if found {
-                break
-            }
-        }
-        if !found {
+        if _, found := nodes.entry_to_pivots[routing_entry]; !found {
             selected_entries[routing_entry] = struct{}{}
         }
     }
 
-    /* --- Add best routing entry to the rest --- */
-    s := select_entry ("", selected_entries, "", 0)
-    if s != nil { // If all entries have been deleted because of loops.
-        routing_entries_set.unsafe_add (prefix, s) // Choice on shortest path then most AS of interest.
-    }
+    /* --- Best routing entry (nil if all entries have been deleted because of loops) --- */
+    s, _ := select_entry ("", selected_entries, "", 0) // Choice on shortest path then most AS of interest.
 
     /* --- Delete all current entries --- */
     for k := range current_routing_entries_set.set {
         delete (current_routing_entries_set.set,k)
     }
+    return prefix, s
 }
 
 /**
@@ -212,45 +306,52 @@ func generate_shortest_path_heuristic () heuristic_fn {
 
 func generate_most_ases_interest_heuristic () heuristic_fn {
     return func (next_hop string, routing_entry *Rib_entry, selected_next_hop *string, selected_entry **Rib_entry) bool {
-        if len (routing_entry.as_path) == len ((*selected_entry).as_path) {
-            if len (routing_entry.as_to_next_hop_AS) > len ((*selected_entry).as_to_next_hop_AS) {
-                *selected_next_hop = next_hop
-                *selected_entry = routing_entry
-            }
-            return true
+        if len (routing_entry.as_to_next_hop_AS) == len ((*selected_entry).as_to_next_hop_AS) {
+            return false
         }
-        return false
+        if len (routing_entry.as_to_next_hop_AS) > len ((*selected_entry).as_to_next_hop_AS) {
+            *selected_next_hop = next_hop
+            *selected_entry = routing_entry
+        }
+        return true
     }
 }
 
 /**
  * Given a pivot node, browse all paths going through that pivot node and select the best one according to:
  * 1. Valley free heuristic
- * 2. Tie-break: most popular next-hop
- * 3. Tie-break: shortest AS path
- * 4. Tie-break: AS path with the most ASes of interest
+ * 2. The tie-breaks of g_args.tiebreaks, in order (default: most popular next-hop, shortest AS path,
+ *    AS path with the most ASes of interest). The most popular next-hop only applies at a pivot node.
+ * The entries in which the pivot node has no next hop (first in the reversed path, or absent) are skipped,
+ * and reported by the second return value. Returns nil if no entry could be selected.
  */
-func select_entry (pivot_node string, entries map[*Rib_entry]interface{}, max_next_hop string, nb int) *Rib_entry {
+func select_entry (pivot_node string, entries map[*Rib_entry]interface{}, max_next_hop string, nb int) (*Rib_entry, bool) {
     
     /* --- Select heuristics to apply --- */
-    heuristics := make ([]heuristic_fn, 0, 4)
+    heuristics := make ([]heuristic_fn, 0, 2 + len (g_args.tiebreaks))
     if pivot_node != "" {
         heuristics = append (heuristics, generate_valley_free_heuristic (pivot_node))
         heuristics = append (heuristics, generate_heuristic_check (pivot_node)) // Check for subsequent heuristics.
-        heuristics = append (heuristics, generate_next_hop_popularity_heuristic (pivot_node, max_next_hop, nb))
     }
-    heuristics = append (heuristics, generate_shortest_path_heuristic ())
-    heuristics = append (heuristics, generate_most_ases_interest_heuristic ())
+    for _, name := range g_args.tiebreaks {
+        if heuristic := tiebreak_generators[name] (pivot_node, max_next_hop, nb); heuristic != nil {
+            heuristics = append (heuristics, heuristic)
+        }
+    }
 
     /* --- Apply heuristics --- */
     var selected_entry *Rib_entry
     var selected_next_hop string
+    skipped := false
     for routing_entry, _ := range entries { // Loop over all the paths going through pivot_node
         path := routing_entry.as_path
         index := find_index (path, pivot_node)
         var next_hop string
-        if index == -1 {
+        if pivot_node == "" {
             next_hop = "" // Special case where we don"t care about the next hop
+        } else if index <= 0 { // No next hop towards the destination (see apply_valley_free_heuristic)
+            skipped = true
+            continue
         } else {
             next_hop = path[index-1]
         }
@@ -267,7 +368,7 @@ func select_entry (pivot_node string, entries map[*Rib_entry]interface{}, max_ne
             }
         }
     }
-    return selected_entry
+    return selected_entry, skipped
 }
 
 /**
@@ -296,6 +397,7 @@ func build_tree (current_routing_entries_set *SafeSet) (*tree.Tree, *Nodes) {
         reverse (routing_entry.as_path) // In place modification.
         path_tree.Add (routing_entry.as_path, f_absent, f_present, routing_entry)
     }
+    nodes.index_pivots ()
     return path_tree, nodes
 }
 
@@ -321,7 +423,7 @@ func append_rib (set *map[string]map[*Rib_entry]interface{}, args ...interface{}
         SHORTEST PATH HEURISTIC
 \* ==================================== */
 
-func apply_shortest_path_heuristic (routing_entries_set, current_routing_entries_set *SafeSet, ases_interest []string) {
+func apply_shortest_path_heuristic (current_routing_entries_set *SafeSet, ases_interest []string, stats *Heuristic_stats) (string, *Rib_entry) {
 
     // Get prefix
     var prefix string
@@ -331,14 +433,12 @@ func apply_shortest_path_heuristic (routing_entries_set, current_routing_entries
         selected_entries[entry.(*Rib_entry)] = struct{}{}
     }
 
-    /* --- Add best routing entry to the rest --- */
-    s := select_entry ("", selected_entries, "", 0)
-    if s != nil { // If all entries have been deleted because of loops.
-        routing_entries_set.unsafe_add (prefix, s) // Choice on shortest path then most AS of interest.
-    }
+    /* --- Best routing entry (nil if all entries have been deleted because of loops) --- */
+    s, _ := select_entry ("", selected_entries, "", 0) // Choice on shortest path then most AS of interest.
 
     /* --- Delete all current entries --- */
     for k := range current_routing_entries_set.set {
         delete (current_routing_entries_set.set,k)
     }
+    return prefix, s
 }
@@ -0,0 +1,138 @@
+package sim
+
+import (
+    "math/rand"
+    "path/filepath"
+    "reflect"
+    "strconv"
+    "strings"
+    "testing"
+    )
+
+/**
+ * The entries of a prefix announced by 100 peers, as in testdata/valley_free_bench: two thirds of the
+ * paths cross 1 to 4 ASes of a pool of 30 transit ASes, so that they meet at many pivot nodes; the
+ * others cross a single AS of their own, through no pivot node. The path of the first peer goes
+ * directly to the origin (45000): it is the shortest path.
+ */
+func hundred_paths_prefix (r *rand.Rand) *SafeSet {
+    set := create_safeset ()
+    pool := make ([]string, 30)
+    for i := range pool {
+        pool[i] = strconv.Itoa (40000 + i)
+    }
+    for i := 0; i < 100; i++ {
+        path := []string{strconv.Itoa (1000 + i)}
+        switch {
+            case i == 0:
+            case i % 3 == 1:
+                path = append (path, strconv.Itoa (41000 + i))
+            default:
+                for _, j := range r.Perm (len (pool))[:1 + r.Intn (4)] {
+                    path = append (path, pool[j])
+                }
+        }
+        set.add ("20.0.0.0/24_" + strconv.Itoa (i), &Rib_entry{as_path: append (path, "45000")})
+    }
+    return set
+}
+
+/**
+ * Loads the AS relationships of the synthetic universe of testdata/golden, restored with the test.
+ */
+func load_test_as_rel (t testing.TB) {
+    saved := as_neighbors
+    as_neighbors = read_as_rel (filepath.Join ("..", "testdata", "golden", "universe", "as_rel.txt"))
+    t.Cleanup (func () { as_neighbors = saved })
+}
+
+/**
+ * Among 100 paths meeting at many pivot nodes, the valley free heuristic selects the shortest one,
+ * whatever the paths of the other peers.
+ */
+func TestValleyFreeHundredPaths (t *testing.T) {
+    load_test_as_rel (t)
+    for seed := int64 (0); seed < 5; seed++ {
+        stats := &Heuristic_stats{}
+        prefix, entry := apply_valley_free_heuristic (hundred_paths_prefix (rand.New (rand.NewSource (seed))), nil, stats)
+        if prefix != "20.0.0.0/24" || entry == nil || !reflect.DeepEqual (entry.as_path, []string{"45000", "1000"}) { // Stored from the destination AS
+            t.Errorf ("seed %d: %s %v, want the path 45000 1000", seed, prefix, entry)
+        }
+    }
+}
+
+/**
+ * The valley free heuristic on a prefix of 100 paths (it runs about 900k times per collector):
+ *     go test -run - -bench ValleyFree -benchmem
+ */
+func BenchmarkValleyFree (b *testing.B) {
+    load_test_as_rel (b)
+    r := rand.New (rand.NewSource (831))
+    stats := &Heuristic_stats{}
+    for n := 0; n < b.N; n++ {
+        b.StopTimer ()
+        set := hundred_paths_prefix (r) // The heuristic empties the set
+        b.StartTimer ()
+        apply_valley_free_heuristic (set, nil, stats)
+    }
+}
+
+/**
+ * The paths "Y Z" and "X Y Z" (200 300 and 100 200 300, as in testdata/pivot): at Y, the shortest
+ * path is selected; at Z, first in both reversed paths, the entries have no next hop and are skipped
+ * instead of indexing before the path. The valley free heuristic selects "Y Z" without counting a
+ * pivot node at the start of a path (Z is not a pivot node, see index_pivots).
+ */
+func TestSelectEntryPivotFirst (t *testing.T) {
+    load_test_as_rel (t)
+    set := create_safeset ()
+    set.add ("20.0.0.0/16_0", &Rib_entry{as_path: []string{"200", "300"}})
+    set.add ("20.0.0.0/16_1", &Rib_entry{as_path: []string{"100", "200", "300"}})
+    _, nodes := build_tree (set)
+    if len (nodes.pivot_nodes) != 0 {
+        t.Errorf ("pivot nodes: %v, want none", nodes.pivot_nodes)
+    }
+
+    entry, skipped := select_entry ("200", nodes.node_to_entries["200"], "", 0)
+    if skipped || entry == nil || !reflect.DeepEqual (entry.as_path, []string{"300", "200"}) {
+        t.Errorf ("at 200: %v (skipped %t), want the path 300 200", entry, skipped)
+    }
+    if entry, skipped := select_entry ("300", nodes.node_to_entries["300"], "", 0); !skipped || entry != nil {
+        t.Errorf ("at 300: %v (skipped %t), want both entries skipped", entry, skipped)
+    }
+
+    set = create_safeset ()
+    set.add ("20.0.0.0/16_0", &Rib_entry{as_path: []string{"200", "300"}})
+    set.add ("20.0.0.0/16_1", &Rib_entry{as_path: []string{"100", "200", "300"}})
+    stats := &Heuristic_stats{}
+    prefix, entry := apply_valley_free_heuristic (set, nil, stats)
+    if prefix != "20.0.0.0/16" || entry == nil || !reflect.DeepEqual (entry.as_path, []string{"300", "200"}) {
+        t.Errorf ("%s %v, want the path 300 200", prefix, entry)
+    }
+    if stats.pivot_at_start != 0 {
+        t.Errorf ("%d prefixes with a pivot node at the start of a path, want 0", stats.pivot_at_start)
+    }
+}
+
+/**
+ * The prefixes of testdata/pivot, whose paths put a pivot node first, are selected by ribs_multi without
+ * any recovered panic, for both heuristics (AS of interest: 100):
+ *   20.0.0.0/16  200 300 | 100 200 300            (Y Z and X Y Z)
+ *   21.0.0.0/16  100 300 | 300 100                (two roots, tie: either path)
+ *   22.0.0.0/16  1 100 300 | 2 300 100 | 3 100    (100 pivot, and first in the reversed path of 3 100)
+ */
+func TestRibsMultiPivotFirst (t *testing.T) {
+    for heuristic, want := range map[int][]string {
+        0: {"20.0.0.0/16 200 300", "22.0.0.0/16 3 100"}, // Stored as announced
+        1: {"20.0.0.0/16 300 200", "22.0.0.0/16 100 3"}, // Stored from the destination AS (see build_tree)
+    } {
+        out, logs := run_ribs_multi (t, "pivot", "dump", heuristic)
+        if strings.Contains (logs, "runtime error") {
+            t.Errorf ("heuristic %d: recovered panic:\n%s", heuristic, logs)
+        }
+        table := sorted_lines (t, filepath.Join (out, "forwarding_tables", "rrc00.txt"))
+        if len (table) != 3 || table[0] != want[0] || !strings.HasPrefix (table[1], "21.0.0.0/16 ") || table[2] != want[1] {
+            t.Errorf ("heuristic %d: forwarding table %q, want %q and 21.0.0.0/16", heuristic, table, want)
+        }
+    }
+}
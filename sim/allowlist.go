@@ -0,0 +1,285 @@
+/* ==================================================================================== *\
+     allowlist.go
+
+     Allowlist of target ASes:
+     -------------------------
+     With -target_as_allowlist (a file of ASNs), the strategy step only targets the
+     prefixes of the listed ASes (and of the AS of interest, always allowed):
+       - the groups of the directed probes (direct neighbors, one-hop neighbors, others)
+         are restricted to the allowlist, and the ASes and prefixes excluded from each
+         group are reported (allowlist_excluded.txt),
+       - the targets of the strategies not built on these groups are checked when written
+         (group "written"); a target whose AS is unknown is excluded.
+     The allowlist and its hash are recorded in <output_dir>/strategy_metadata.json.
+
+     The simulation reads these metadata: if the strategy was built with an allowlist,
+     the targets are checked against it before the simulation starts (-ip2as needed),
+     and with -expect_allowlist, the simulation refuses to run on a strategy that was
+     not built with that very allowlist.
+\* ==================================================================================== */
+
+package sim
+
+import (
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "log"
+    "net"
+    "os"
+    "sort"
+    "strings"
+    )
+
+const strategy_metadata_file = "strategy_metadata.json"
+
+/**
+ * Allowed target ASes. Nil without -target_as_allowlist (every AS is allowed).
+ */
+var target_allowlist map[string]struct{}
+
+/**
+ * Metadata of a strategy output directory.
+ */
+type strategy_metadata struct {
+    Strategy string `json:"strategy"`;
+    Build map[string]string `json:"build"`;
+    Allowlist []string `json:"target_as_allowlist,omitempty"`;           // Sorted ASNs
+    Allowlist_hash string `json:"target_as_allowlist_sha256,omitempty"`; // See allowlist_hash
+}
+
+/**
+ * Reads an allowlist: ASNs separated by spaces or newlines. Lines starting with '#' are comments.
+ */
+func read_allowlist (filename string) (map[string]struct{}, error) {
+    content, err := os.ReadFile (filename)
+    if err != nil {
+        return nil, err
+    }
+    allowlist := make (map[string]struct{})
+    for _, line := range strings.Split (string (content), "\n") {
+        if strings.HasPrefix (strings.TrimSpace (line), "#") {
+            continue
+        }
+        for _, asn := range strings.Fields (line) {
+            allowlist[strings.TrimPrefix (strings.ToUpper (asn), "AS")] = struct{}{}
+        }
+    }
+    if len (allowlist) == 0 {
+        return nil, fmt.Errorf ("%s: empty allowlist", filename)
+    }
+    return allowlist, nil
+}
+
+/**
+ * Returns the sorted ASNs of an allowlist.
+ */
+func allowlist_ases (allowlist map[string]struct{}) []string {
+    ases := make ([]string, 0, len (allowlist))
+    for as := range allowlist {
+        ases = append (ases, as)
+    }
+    sort.Strings (ases)
+    return ases
+}
+
+/**
+ * Returns the SHA-256 of the sorted ASNs of an allowlist (one per line), so that the
+ * hash does not depend on the layout of the file.
+ */
+func allowlist_hash (allowlist map[string]struct{}) string {
+    sum := sha256.Sum256 ([]byte (strings.Join (allowlist_ases (allowlist), "\n") + "\n"))
+    return hex.EncodeToString (sum[:])
+}
+
+/**
+ * Returns true if the prefixes of 'as' may be targeted for the AS of interest.
+ */
+func as_allowed (as_interest, as string) bool {
+    if target_allowlist == nil || as == as_interest {
+        return true
+    }
+    _, ok := target_allowlist[as]
+    return ok
+}
+
+/**
+ * Returns the AS of a target (a /24 or a raw prefix), from the most specific prefix of
+ * ip2as containing it. Returns "-1" if unknown.
+ */
+func target_as (target string) string {
+    if as := lookup_as (target); as != "" {
+        return as
+    }
+    if as, ok := prefix_as[target]; ok {
+        return as
+    }
+    ip, network, err := net.ParseCIDR (target)
+    if err != nil {
+        return "-1"
+    }
+    ones, bits := network.Mask.Size ()
+    for mask := ones - 1; mask >= 0; mask-- {
+        m := net.CIDRMask (mask, bits)
+        if as, ok := prefix_as[(&net.IPNet{IP: ip.Mask (m), Mask: m}).String ()]; ok {
+            return as
+        }
+    }
+    return "-1"
+}
+
+/* ------------------------------------------------------------------------------- *\
+                                  Strategy step
+\* ------------------------------------------------------------------------------- */
+
+/**
+ * Removes the ASes outside the allowlist from the directed probes (grouped by AS) and from
+ * each group of ASes (group name -> ASes), and reports the number of ASes and prefixes
+ * excluded from each group.
+ */
+func apply_allowlist_to_groups (as_interest string, AS_probes map[string]map[string]interface{}, groups []string, group_ases []map[string]interface{}) {
+    if target_allowlist == nil {
+        return
+    }
+    for i, ases := range group_ases {
+        excluded_ases, excluded_prefixes := 0, 0
+        for as := range ases {
+            if as_allowed (as_interest, as) {
+                continue
+            }
+            excluded_ases++
+            excluded_prefixes += len (AS_probes[as])
+            delete (ases, as)
+        }
+        output_msg ("allowlist_excluded.txt", as_interest, groups[i], excluded_ases, excluded_prefixes)
+    }
+    for as := range AS_probes {
+        if !as_allowed (as_interest, as) {
+            delete (AS_probes, as)
+        }
+    }
+}
+
+/**
+ * Drops the targets outside the allowlist (in any group), and reports the number of ASes
+ * and targets dropped (group "written").
+ * Returns the remaining targets and limits.
+ */
+func enforce_allowlist (s []string, limits []*AS_limit, as_interest string) ([]string, []*AS_limit) {
+    if target_allowlist == nil {
+        return s, limits
+    }
+    excluded := make (map[string]struct{})
+    kept, new_limits, dropped := filter_targets (s, limits, func (target string) bool {
+        as := target_as (target)
+        if as_allowed (as_interest, as) {
+            return true
+        }
+        excluded[as] = struct{}{}
+        return false
+    })
+    output_msg ("allowlist_excluded.txt", as_interest, "written", len (excluded), dropped)
+    if dropped > 0 {
+        log.Println ("[enforce_allowlist]: AS", as_interest, ":", dropped, "targets outside the allowlist dropped")
+    }
+    return kept, new_limits
+}
+
+/**
+ * Writes the metadata of a strategy output directory.
+ */
+func write_strategy_metadata (output_dir string, strategy int) {
+    metadata := strategy_metadata{Strategy: strategies[strategy].name, Build: build_info ()}
+    if target_allowlist != nil {
+        metadata.Allowlist = allowlist_ases (target_allowlist)
+        metadata.Allowlist_hash = allowlist_hash (target_allowlist)
+    }
+    content, _ := json.MarshalIndent (metadata, "", "  ")
+    if err := os.WriteFile (output_dir + "/" + strategy_metadata_file, append (content, '\n'), 0644); err != nil {
+        log.Print ("[write_strategy_metadata]: ", err)
+    }
+}
+
+/* ------------------------------------------------------------------------------- *\
+                                 Simulation step
+\* ------------------------------------------------------------------------------- */
+
+/**
+ * Reads the metadata of a strategy output directory. Strategies built before the metadata
+ * were recorded have none (os.IsNotExist (err)).
+ */
+func read_strategy_metadata (dir string) (*strategy_metadata, error) {
+    content, err := os.ReadFile (dir + "/" + strategy_metadata_file)
+    if err != nil {
+        return nil, err
+    }
+    metadata := &strategy_metadata{}
+    if err := json.Unmarshal (content, metadata); err != nil {
+        return nil, fmt.Errorf ("%s/%s: %v", dir, strategy_metadata_file, err)
+    }
+    return metadata, nil
+}
+
+/**
+ * Checks the strategy (g_args.strategy) against its allowlist before the simulation:
+ * - with -expect_allowlist, the strategy must have been built with that allowlist,
+ * - if the strategy was built with an allowlist, every target of every AS of interest
+ *   must belong to an allowed AS.
+ * Exits on failure.
+ */
+func check_strategy_allowlist (ases_interest []string) {
+    metadata, err := read_strategy_metadata (g_args.strategy)
+    if err != nil && !os.IsNotExist (err) {
+        log.Fatal ("[check_strategy_allowlist]: ", err)
+    }
+
+    if g_args.expect_allowlist != "" {
+        expected, err := read_allowlist (g_args.expect_allowlist)
+        if err != nil {
+            log.Fatal ("[check_strategy_allowlist]: ", err)
+        }
+        if metadata == nil {
+            log.Fatal ("[check_strategy_allowlist]: ", g_args.strategy, ": no ", strategy_metadata_file, ", cannot check the allowlist (rebuild the strategy with -target_as_allowlist)")
+        }
+        if metadata.Allowlist_hash == "" {
+            log.Fatal ("[check_strategy_allowlist]: ", g_args.strategy, ": the strategy was built without an allowlist")
+        }
+        if hash := allowlist_hash (expected); hash != metadata.Allowlist_hash {
+            log.Fatal ("[check_strategy_allowlist]: ", g_args.strategy, ": the strategy was built with another allowlist (sha256 ", metadata.Allowlist_hash, ", expected ", hash, ")")
+        }
+    }
+    if metadata == nil || metadata.Allowlist_hash == "" {
+        return
+    }
+
+    /* --- Verify the targets --- */
+    target_allowlist = make (map[string]struct{}, len (metadata.Allowlist))
+    for _, as := range metadata.Allowlist {
+        target_allowlist[as] = struct{}{}
+    }
+    if hash := allowlist_hash (target_allowlist); hash != metadata.Allowlist_hash {
+        log.Fatal ("[check_strategy_allowlist]: ", g_args.strategy, ": the allowlist does not match its hash")
+    }
+    if g_args.ip2as_file == "" {
+        log.Fatal ("[check_strategy_allowlist]: ", g_args.strategy, ": the strategy was built with an allowlist, -ip2as is needed to check its targets")
+    }
+    if as_24prefixes == nil {
+        as_24prefixes, _, prefix_as = read_ip2as (g_args.ip2as_file)
+    }
+    checked := 0
+    for _, as_interest := range ases_interest {
+        records, err := read_prefix_records (g_args.strategy + "/" + as_interest + "/targets.txt")
+        if err != nil {
+            log.Fatal ("[check_strategy_allowlist]: AS ", as_interest, ": ", err)
+        }
+        for _, record := range records {
+            target := target_prefix (record.prefix)
+            if as := target_as (target); !as_allowed (as_interest, as) {
+                log.Fatal ("[check_strategy_allowlist]: AS ", as_interest, ": target ", record.prefix, " of AS ", as, " is outside the allowlist")
+            }
+            checked++
+        }
+    }
+    log.Println ("[check_strategy_allowlist]:", checked, "targets checked against the allowlist (sha256", metadata.Allowlist_hash + ")")
+}
@@ -0,0 +1,732 @@
+/* ==================================================================================== *\
+     anaximander_driver.go
+
+     Implements the _Anaximander Simulator_.
+
+     For each AS of interest, 'summary_<output_file>_XX.txt' gives the number of targets of the
+     strategy, the number of probes launched, of useful probes (probes that discovered something),
+     of missing traces and of false positives, the final discovery levels, and the wall-clock time
+     of the simulation (see write_probing_summary).
+
+\* ==================================================================================== */
+
+package sim
+
+import (
+        "context"
+        "bufio"
+        "strings"
+        "log"
+        "os"
+        "strconv"
+        "sync/atomic"
+        "path/filepath"
+            "time"
+        "fmt"
+        "errors"
+        )
+
+/* ============================================================ *\
+                   ANAXIMANDER SIMULATOR
+\* ============================================================ */
+
+/**
+ * Records a line of statistics in the file given by the first argument (the standard output is
+ * split afterwards). Safe to call from concurrent AS workers (see output_sink.go).
+ */
+func output_msg (args ...interface{}) {
+    output_msg_marked ("", args...)
+}
+
+/**
+ * Same as output_msg, with the marker of the run (if any, ex: the credit bound, see Options.Marker)
+ * appended to the name of the file.
+ */
+func output_msg_marked (marker string, args ...interface{}) {
+    if output_on {
+        if file, t := args[0].(string); t && marker != "" {
+            args[0] = trim_suffix (file, ".txt") + "_" + marker + ".txt"
+        }
+        get_output_sink ().write_line (fmt.Sprintln (args...))
+    }
+}
+
+/**
+ * Decides when the probing of a group of targets stops, from the changes of each of its probes.
+ * All the schedulers use the same stopper (see new_plateau_stopper).
+ */
+type Stopper interface {
+    Observe (new_adjs, new_addresses, new_routers bool) (discovery, stop bool); // After each probe of the group
+}
+
+/**
+ * The plateau of a group: a probe counts as a discovery according to the plateau metric (the
+ * results still report all metrics), and the group stops once the number of probes in a row
+ * without discovery exceeds the threshold (a fraction of the targets of the group).
+ */
+type plateau_stopper struct {
+    metric string;
+    threshold float64;
+    size int;   // Nb of targets of the group
+    length int; // Current length of the plateau
+}
+
+func new_plateau_stopper (opts Options, size int) Stopper {
+    return &plateau_stopper{metric: opts.PlateauMetric, threshold: opts.Threshold, size: size}
+}
+
+func (s *plateau_stopper) Observe (new_adjs, new_addresses, new_routers bool) (bool, bool) {
+    var discovery bool
+    switch s.metric {
+        case "adjs":
+            discovery = new_adjs
+        case "addresses":
+            discovery = new_addresses
+        case "routers":
+            discovery = new_routers
+        case "addresses+routers":
+            discovery = new_addresses || new_routers
+        default: // "any"
+            discovery = new_adjs || new_addresses || new_routers
+    }
+    if discovery {
+        s.length = 0
+        return true, false
+    }
+    s.length++
+    return false, float64 (s.length)/float64 (s.size) > s.threshold
+}
+
+/**
+ * Policies for the targets without trace (-missing-traces):
+ * - count: the target takes a probe that discovers nothing (it lengthens the plateau),
+ * - skip: the target is passed over when its turn comes (no probe, the plateau is unchanged),
+ * - drop: the target is removed from the strategy before the simulation (the groups shrink).
+ */
+const (
+    missing_count = "count"
+    missing_skip = "skip"
+    missing_drop = "drop"
+)
+
+/**
+ * Returns an error if the missing traces policy is unknown.
+ */
+func check_missing_traces_policy (policy string) error {
+    switch policy {
+        case missing_count, missing_skip, missing_drop:
+            return nil
+    }
+    return fmt.Errorf ("unknown missing traces policy: %s (count, skip or drop)", policy)
+}
+
+/**
+ * Removes the targets without trace (after credit, see get_trace) from a strategy, and moves the
+ * limits of its groups accordingly. Returns the new targets and limits, and the number of targets removed.
+ */
+func drop_missing_targets (ds *Datasets, targets []string, limits []*AS_limit, raw_prefixes map[string]string, opts Options) ([]string, []*AS_limit, int) {
+    traces := ds.Traces
+    credit := new_fractional_credit (ds, raw_prefixes, opts) // Not the credit of the simulation: its counters are left untouched
+    return filter_targets (targets, limits, func (target string) bool {
+        _, present := credit.get_trace (traces, target)
+        return present
+    })
+}
+
+/**
+ * Probe budget of an AS of interest (-budget): a number of probes ("50000"), or a fraction of the
+ * targets of its strategy ("0.5"). The zero value is no budget (see BudgetProbes and BudgetFraction).
+ */
+type ProbeBudget struct {
+    set bool;
+    probes int;       // If fraction is 0
+    fraction float64;
+}
+
+/**
+ * Returns a budget of a number of probes per AS.
+ */
+func BudgetProbes (probes int) ProbeBudget {
+    return ProbeBudget{set: true, probes: probes}
+}
+
+/**
+ * Returns a budget of a fraction (in (0, 1]) of the targets of the strategy of an AS.
+ */
+func BudgetFraction (fraction float64) ProbeBudget {
+    return ProbeBudget{set: true, fraction: fraction}
+}
+
+func (b *ProbeBudget) String () string {
+    if b == nil || !b.set {
+        return ""
+    }
+    if b.fraction != 0 {
+        return strconv.FormatFloat (b.fraction, 'f', -1, 64)
+    }
+    return strconv.Itoa (b.probes)
+}
+
+func (b *ProbeBudget) Set (s string) error {
+    if strings.Contains (s, ".") {
+        fraction, err := strconv.ParseFloat (s, 64)
+        if err != nil || fraction <= 0 || fraction > 1 {
+            return errors.New ("a fraction of the targets must be in (0, 1]")
+        }
+        *b = ProbeBudget{set: true, fraction: fraction}
+        return nil
+    }
+    probes, err := strconv.Atoi (s)
+    if err != nil || probes < 0 {
+        return errors.New ("neither a number of probes (e.g. 50000) nor a fraction of the targets (e.g. 0.5)")
+    }
+    *b = ProbeBudget{set: true, probes: probes}
+    return nil
+}
+
+/**
+ * Returns the maximum number of probes for a strategy of 'targets' targets (-1: no budget).
+ */
+func (b ProbeBudget) limit (targets int) int {
+    if !b.set {
+        return -1
+    }
+    if b.fraction != 0 {
+        return int (b.fraction * float64 (targets))
+    }
+    return b.probes
+}
+
+/**
+ * Values of the -t flag: one or several thresholds (separated by commas), simulated one after
+ * the other on the same datasets.
+ */
+type threshold_list []float64
+
+func (l *threshold_list) String () string {
+    if l == nil {
+        return ""
+    }
+    values := make ([]string, 0, len (*l))
+    for _, t := range *l {
+        values = append (values, strconv.FormatFloat (t, 'f', -1, 64))
+    }
+    return strings.Join (values, ",")
+}
+
+func (l *threshold_list) Set (s string) error {
+    values := make (threshold_list, 0)
+    for _, field := range strings.Split (s, ",") {
+        t, err := strconv.ParseFloat (strings.TrimSpace (field), 64)
+        if err != nil || t < 0 {
+            return errors.New ("expecting one or several thresholds separated by commas (e.g. 0.1,0.2,1)")
+        }
+        values = append (values, t)
+    }
+    *l = values
+    return nil
+}
+
+// -------------------------------------------------------------------------------
+/**
+ * Simulates an AS of interest with the Options of the command, and writes its results (see Simulate and
+ * write_result). Returns an error if its strategy could not be read, if it could not be simulated, or if
+ * its results could not be written: the other ASes go on.
+ */
+func simulate_as (ds *Datasets, as_interest string, output_file string, opts Options) error {
+    start := time.Now ()
+    timer := new_timer ()
+    defer timer.stop ()
+    timer.phase (phase_strategy_read)
+    strategy, err := LoadStrategy (ds, as_interest)
+    if err != nil {
+        return err
+    }
+    timer.phase (phase_simulation)
+    result, err := Simulate (ds, as_interest, strategy, opts)
+    if err != nil {
+        return err
+    }
+    timer.phase (phase_output_write)
+    if err := write_result (ds, result, output_file); err != nil {
+        return err
+    }
+    if result.Stats.Interrupted {
+        interrupt_partial ("AS " + as_interest + " (" + output_file + ")")
+    }
+    results_db.record (&db_run{as_interest: as_interest, threshold: opts.Threshold, credit_mode: opts.CreditMode, probes: result.Stats.Probes,
+        started: start, finished: time.Now (), output_file: output_file, curve: text_curve (result.Curve)})
+    return nil
+}
+
+// -------------------------------------------------------------------------------
+/**
+ * Launches the simulation in parrallel on the ASes of interest. Once ctx is cancelled (see interrupt.go),
+ * the simulations in progress stop and write their partial results, and no other AS is simulated.
+ * Returns the number of simulations of an AS that failed (see simulate_as).
+ */
+func launch_anaximander_simulation (ctx context.Context, break_len int, output_file string, simulation_mode int) int {
+    var failed int64
+
+    /* ---------------------------------------------------- *\
+       READING SIMULATION DATA and setting Global Variables
+    \* ---------------------------------------------------- */
+    seed := seed_random (g_args.seed)
+    manifest := &run_manifest{Command: "simulation", Scheduler: scheduler_names[simulation_mode], Seed: seed}
+    if metadata, err := read_strategy_metadata (g_args.strategy); err == nil {
+        manifest.Strategy = metadata.Strategy
+    }
+    if simulation_mode == 1 {
+        manifest.Weight_function = weight_functions[int (g_args.weight_parameters[0])].name
+    }
+    write_manifest (filepath.Dir (output_file), manifest, g_args.force || g_args.resume)
+    summary_begin ("simulation", g_args.summary_out)
+    summary_artifact (filepath.Join (filepath.Dir (output_file), manifest_file))
+    profile_begin ()
+    if g_args.sqlite_file != "" {
+        var err error
+        if results_db, err = open_results_db (g_args.sqlite_file, manifest.Strategy, manifest.Scheduler, manifest.Weight_function); err != nil {
+            log.Fatal ("[launch_anaximander_simulation]: ", g_args.sqlite_file, ": ", err)
+        }
+        defer results_db.close ()
+        summary_artifact (g_args.sqlite_file)
+    }
+    if g_args.checkpoint || g_args.resume {
+        summary_stage ("checkpoint")
+        checkpoint_begin (filepath.Dir (output_file), g_args.resume)
+    }
+    summary_stage ("warts")
+    timer := new_timer ()
+    timer.phase (phase_warts_parse)
+    start := time.Now()
+    cfg := config_from_args (seed)
+    if simulation_mode == 1 {
+        if spec, _ := weight_function_by_name (manifest.Weight_function, g_args.weight_parameters[1:]); spec.needs_cones { // The customer cones of the weighting function
+            cfg.Ip2asFile, cfg.PpdcFile = g_args.ip2as_file, g_args.ppdc_file
+        }
+    }
+    ds, err := LoadDatasets (cfg)
+    if err != nil {
+        log.Fatal ("[launch_anaximander_simulation]: ", err)
+    }
+    log.Printf("Parsing TNT data took %s", time.Since(start))
+    if ctx.Err () != nil { // The warts were not all parsed: nothing to simulate
+        log.Print ("[launch_anaximander_simulation]: interrupted while parsing the warts, no AS simulated")
+        return 0
+    }
+    if !g_args.deadline.IsZero () {
+        log.Print ("[deadline]: after parsing the warts: ", deadline_string ())
+    }
+    timer.stop ()
+    
+    /* ----------------------- *\
+             SIMULATION
+    \* ----------------------- */
+    ases_interest := ds.AsesInterest
+    check_strategy_allowlist (ases_interest)
+    
+    /* --- The ASes are simulated concurrently: each worker filters its own copy of the datasets --- */
+    nb_workers := cpu_jobs ()
+    if nb_workers > len (ases_interest) {
+        nb_workers = max (len (ases_interest), 1)
+    }
+
+    /* --- The caps of the VPs are read once, for all the ASes --- */
+    cost_model := get_cost_model ()
+
+    /* --- In fractional credit mode, both bounds are reported --- */
+    modes := []string{g_args.credit_mode}
+    if g_args.credit_mode == credit_fractional {
+        modes = []string{credit_pessimistic, credit_fractional}
+    }
+
+    /* --- Several thresholds: the datasets are reused, each threshold has its own directory --- */
+    for _, threshold := range g_args.thresholds {
+        if ctx.Err () != nil {
+            break
+        }
+        threshold_output_file, threshold_marker := output_file, ""
+        if len (g_args.thresholds) > 1 {
+            threshold_marker = "t_" + strconv.FormatFloat (threshold, 'f', -1, 64)
+            dir, filename := filepath.Split (output_file)
+            if err := os.MkdirAll (filepath.Join (dir, threshold_marker), 0755); err != nil {
+                log.Fatal ("[launch_anaximander_simulation]: ", err)
+            }
+            threshold_output_file = filepath.Join (dir, threshold_marker, filename)
+        }
+        for _, mode := range modes {
+            if ctx.Err () != nil {
+                break
+            }
+            opts := options_from_args (simulation_mode, threshold, mode)
+            opts.Ctx, opts.Marker, opts.CostModel = ctx, threshold_marker, cost_model
+            mode_output_file := threshold_output_file
+            if len (modes) > 1 { // Mark the output files with the bound they give
+                bound := map[string]string{credit_pessimistic: "pessimistic", credit_fractional: "optimistic"}[mode]
+                mode_output_file = trim_suffix (threshold_output_file, ".txt") + "_" + bound + ".txt"
+                opts.Marker = strings.TrimPrefix (threshold_marker + "_" + bound, "_")
+            }
+            g := func (as_interest string) {
+                as_output_file := trim_suffix (mode_output_file, ".txt") + "_" + as_interest + ".txt"
+                if checkpoint_completed (as_output_file) {
+                    log.Println ("[checkpoint]: AS", as_interest, "already simulated (" + as_output_file + ")")
+                } else if err := simulate_as (ds, as_interest, as_output_file, opts); err != nil { // Not marked as done: simulated again by -resume
+                    log.Print ("[launch_anaximander_simulation]: AS ", as_interest, ": ", err)
+                    atomic.AddInt64 (&failed, 1)
+                    summary_unit ("ASes", unit_failed)
+                    return
+                } else {
+                    checkpoint_done (as_output_file)
+                }
+                summary_artifact (as_output_file)
+                summary_unit ("ASes", unit_processed)
+            }
+            log.Println ("Launching simulation (" + mode + " credit, threshold " + strconv.FormatFloat (threshold, 'f', -1, 64) + ")...")
+            summary_stage (strings.TrimSuffix ("simulation_" + mode + "_" + threshold_marker, "_"))
+            launch_pool_progress ("simulation", "ASes", nb_workers, ases_interest, interrupt_guard (ctx, deadline_guard (g, opts.Marker)))
+        }
+
+        /* --- Gather limits file if any (also those of an interrupted run) --- */
+        gather_limits (filepath.Dir (threshold_output_file))
+    }
+    return int (failed)
+}
+
+/**
+ * Gathers the limits files of the ASes (<output_dir>/*limits_reduction.txt) into <output_dir>/all_reduction.txt.
+ * With a checkpoint, only the limits of the ASes whose simulation is complete are gathered: those of an
+ * interrupted AS stay in its file, replaced when -resume simulates it again, so that all_reduction.txt
+ * never holds two lines for the same simulation.
+ */
+func gather_limits (output_dir string) {
+    files, _ := filepath.Glob (output_dir + "/*limits_reduction.txt")
+    complete := files[:0]
+    for _, file := range files {
+        if checkpoint_active () && !checkpoint_completed (trim_suffix (file, "_limits_reduction.txt") + ".txt") {
+            log.Print ("[gather_limits]: ", file, ": simulation not complete, not gathered")
+            continue
+        }
+        complete = append (complete, file)
+    }
+    if len (complete) == 0 {
+        return // Nothing new (e.g., every AS was already simulated before a resume)
+    }
+    flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+    if g_args.resume { // The limits of the ASes simulated before the resume are already gathered
+        flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+    }
+    all, err := os.OpenFile (output_dir + "/all_reduction.txt", flags, 0644)
+    if err != nil {
+        log.Print ("[gather_limits]: ", err)
+        return
+    }
+    defer all.Close ()
+    for _, file := range complete {
+        content, err := os.ReadFile (file)
+        if err == nil {
+            _, err = all.Write (content)
+        }
+        if err != nil { // The file is kept, to be gathered by hand
+            log.Print ("[gather_limits]: ", err)
+            continue
+        }
+        os.Remove (file)
+    }
+    // Note: An attempt to fetch a map value with a key that is not present in the map will return the zero value 
+    // for the type of the entries in the map.
+    // This means that some neighbors (who don't have prefixes) will appear in the limit file as two equal consecutive values.
+}
+
+// -------------------------------------------------------------------------------
+/**
+ * Counters of the simulation of an AS of interest.
+ */
+type probing_summary struct {
+    targets int;         // Targets of the strategy
+    launched int;        // Probes launched
+    useful int;          // Probes that discovered something new
+    missing_traces int;
+    missing_removed int; // Targets without trace skipped or dropped (-missing-traces)
+    false_positives int; // Probes that discovered nothing in the AS of interest
+    final DiscoveryPoint; // Discovery levels at the end of the simulation
+    budget_exhausted bool; // The simulation was stopped by the probe budget (-budget)
+    interrupted bool;      // The simulation was stopped by SIGINT/SIGTERM: partial results (see interrupt.go)
+    duration time.Duration;
+}
+
+/**
+ * Writes the counters of the simulation of an AS (one "name value" per line) into
+ * 'summary_<output_file>' (same directory).
+ */
+func write_probing_summary (s *probing_summary, as_interest, output_file string) error {
+    dir, filename := filepath.Split (output_file)
+    err := write_file (dir + "summary_" + filename, func (w *bufio.Writer) {
+        fmt.Fprintln (w, "as_interest", as_interest)
+        fmt.Fprintln (w, "targets", s.targets)
+        fmt.Fprintln (w, "launched", s.launched)
+        fmt.Fprintln (w, "useful", s.useful)
+        fmt.Fprintln (w, "missing_traces", s.missing_traces)
+        fmt.Fprintln (w, "missing_traces_removed", s.missing_removed)
+        fmt.Fprintln (w, "false_positives", s.false_positives)
+        fmt.Fprintln (w, "adjs", strconv.FormatFloat (s.final.Adjs, 'f', 4, 32))
+        fmt.Fprintln (w, "multi_adjs", strconv.FormatFloat (s.final.MultiAdjs, 'f', 4, 32))
+        fmt.Fprintln (w, "addresses", strconv.FormatFloat (s.final.Addresses, 'f', 4, 32))
+        fmt.Fprintln (w, "routers", strconv.FormatFloat (s.final.Routers, 'f', 4, 32))
+        fmt.Fprintln (w, "budget_exhausted", s.budget_exhausted)
+        fmt.Fprintln (w, "interrupted", s.interrupted)
+        fmt.Fprintln (w, "seconds", strconv.FormatFloat (s.duration.Seconds (), 'f', 6, 64))
+    })
+    if err != nil {
+        return err
+    }
+    checkpoint_outcome (output_file, s)
+    return nil
+}
+
+/**
+ * Returns the discovery levels of an AS (fractions of its ground truth).
+ */
+func discovery_levels (discovered_adjs, discovered_multi_adjs, discovered_addresses, discovered_routers, adjs, multi_adjs, addresses, routers *SafeSet) DiscoveryPoint {
+    return DiscoveryPoint{
+        Adjs: float64 (discovered_adjs.Len ())/float64 (adjs.Len ()),
+        MultiAdjs: float64 (discovered_multi_adjs.Len ())/float64 (multi_adjs.Len ()),
+        Addresses: float64 (discovered_addresses.Len ())/float64 (addresses.Len ()),
+        Routers: float64 (discovered_routers.Len ())/float64 (routers.Len ()),
+    }
+}
+
+// -------------------------------------------------------------------------------
+var group_names = []string{"internal", "neighbors", "one_hop_neighbors", "others"}
+
+/**
+ * Returns the group of each AS of the limits of a strategy, from the AS relationships: the AS of
+ * interest itself ("internal"), its direct neighbors ("neighbors"), the neighbors of its neighbors
+ * ("one_hop_neighbors"), and the other ASes ("others"), as the groups of the directed probing
+ * strategies. Returns nil without AS relationships (neighbors, see read_as_rel).
+ */
+func as_groups (neighbors map[string]map[string]interface{}, as_interest string, limits []*AS_limit) map[string]string {
+    if neighbors == nil {
+        return nil
+    }
+    one_hop_neighbors := slice_to_map (one_hop_neighbors_of (neighbors, as_interest))
+    groups := make (map[string]string, len (limits))
+    for _, limit := range limits {
+        _, neighbor := neighbors[as_interest][limit.asn]
+        _, one_hop_neighbor := one_hop_neighbors[limit.asn]
+        switch {
+            case limit.asn == as_interest:
+                groups[limit.asn] = "internal"
+            case neighbor:
+                groups[limit.asn] = "neighbors"
+            case one_hop_neighbor:
+                groups[limit.asn] = "one_hop_neighbors"
+            default:
+                groups[limit.asn] = "others"
+        }
+    }
+    return groups
+}
+
+// -------------------------------------------------------------------------------
+/**
+ * The ground truth of each AS (adjs, multi_adjs, addresses and routers), as selected by filterAS,
+ * built once for all the ASes (see build_as_index) instead of scanning the whole ground truth for
+ * each AS of interest. Built by parse_warts (Datasets.index).
+ */
+type AS_index struct {
+    per_as map[string]*AS_ground_truth
+}
+
+type AS_ground_truth struct {
+    adjs, multi_adjs, addresses, routers *SafeSet
+}
+
+/**
+ * Indexes the ground truth of the datasets by AS. A link is indexed under the AS of each of its ends
+ * (and their conn_asn with -border conn_asn), an address under its AS, a router under its AS. Every AS
+ * gets its bucket, "-1" (no AS for bdrmapit) and "" (links with an unannotated end) included, so that
+ * each bucket is exactly what filterAS would select by scanning.
+ */
+func build_as_index (ds *Datasets) *AS_index {
+    index := &AS_index{per_as: make (map[string]*AS_ground_truth)}
+    bucket := func (as string) *AS_ground_truth {
+        sets, ok := index.per_as[as]
+        if !ok {
+            sets = &AS_ground_truth{create_safeset (), create_safeset (), create_safeset (), create_safeset ()}
+            index.per_as[as] = sets
+        }
+        return sets
+    }
+    index_links := func (links *SafeSet, sets_of func (*AS_ground_truth) *SafeSet) {
+        links.Range (func (addr1_addr2 string, _ interface{}) bool {
+            s := strings.Split (addr1_addr2, "_")
+            a, b := annotation_of (s[0], ds.AddrToAsn, ds.addr_to_conn_asn), annotation_of (s[1], ds.AddrToAsn, ds.addr_to_conn_asn)
+            ases := []string{a.asn, b.asn}
+            if ds.cfg.Border == border_conn_asn {
+                ases = append (ases, a.conn_asn, b.conn_asn)
+            }
+            for _, as := range ases {
+                sets_of (bucket (as)).unsafe_add (addr1_addr2)
+            }
+            return true
+        })
+    }
+    index_links (ds.Adjs, func (sets *AS_ground_truth) *SafeSet { return sets.adjs })
+    index_links (ds.MultiAdjs, func (sets *AS_ground_truth) *SafeSet { return sets.multi_adjs })
+    ds.Addresses.Range (func (addr string, _ interface{}) bool {
+        if as_i, ok := ds.AddrToAsn.get (addr); ok {
+            if as, t := as_i.(string); t {
+                bucket (as).addresses.unsafe_add (addr)
+            }
+        }
+        return true
+    })
+    ds.RouterToAsn.Range (func (router string, as_i interface{}) bool {
+        if as, t := as_i.(string); t {
+            bucket (as).routers.unsafe_add (router)
+        }
+        return true
+    })
+    return index
+}
+
+/**
+ * Returns the ground truth of an AS (adjs, multi_adjs, addresses, routers): from the index of the
+ * datasets when they were loaded by LoadDatasets, by scanning them otherwise (e.g., the Datasets
+ * built by an API user). The sets returned must not be modified (shared by the simulations of the AS).
+ */
+func filterAS (ds *Datasets, AS string) (*SafeSet, *SafeSet, *SafeSet, *SafeSet) {
+    if ds.index != nil {
+        sets, ok := ds.index.per_as[AS]
+        if !ok {
+            return create_safeset (), create_safeset (), create_safeset (), create_safeset ()
+        }
+        return sets.adjs, sets.multi_adjs, sets.addresses, sets.routers
+    }
+    return scan_AS (ds, AS)
+}
+
+func scan_AS (ds *Datasets, AS string) (*SafeSet, *SafeSet, *SafeSet, *SafeSet) {
+    adjs, multi_adjs, addresses, router_to_asn, addr_to_asn := ds.Adjs, ds.MultiAdjs, ds.Addresses, ds.RouterToAsn, ds.AddrToAsn
+    in_AS := func (addr1_addr2 string) bool { // Same links as process_trace
+        s := strings.Split (addr1_addr2, "_")
+        return link_in_AS (annotation_of (s[0], addr_to_asn, ds.addr_to_conn_asn), annotation_of (s[1], addr_to_asn, ds.addr_to_conn_asn), AS, ds.cfg.Border)
+    }
+    filtered_adjs := create_safeset ()
+    filtered_multi_adjs := create_safeset ()
+    filtered_addresses := create_safeset ()
+    filtered_routers := create_safeset ()
+
+    /* The ground truth is shared by the ASes simulated concurrently: read it under its read lock */
+    adjs.Range (func (addr1_addr2 string, _ interface{}) bool {
+        if in_AS (addr1_addr2) {
+            filtered_adjs.unsafe_add (addr1_addr2)
+        }
+        return true
+    })
+
+    multi_adjs.Range (func (addr1_addr2 string, _ interface{}) bool {
+        if in_AS (addr1_addr2) {
+            filtered_multi_adjs.unsafe_add (addr1_addr2)
+        }
+        return true
+    })
+
+    addresses.Range (func (addr string, _ interface{}) bool {
+        if as, _ := addr_to_asn.get (addr); as == AS {
+            filtered_addresses.unsafe_add (addr)
+        }
+        return true
+    })
+
+    router_to_asn.Range (func (router string, asn interface{}) bool {
+        if asn == AS {
+            filtered_routers.unsafe_add (router)
+        }
+        return true
+    })
+
+    return filtered_adjs, filtered_multi_adjs, filtered_addresses, filtered_routers
+}
+
+// -------------------------------------------------------------------------------
+/**
+ * Given a trace and an AS of interest, record the new discovered elements in their corresponding
+ * set. 
+ * Also returns the number of addresses that belonged to the AS of interest. This represents if the trace
+ * was successfull or not (and allows to sort them based on the number of addresses).
+ * A router is discovered once router_k of its addresses have been seen. border: see -border (link_in_AS).
+ * If discovery_log is not nil, the elements discovered for the first time are recorded in it, with the
+ * destination and the number of the probe (see log_discovery).
+ */
+func process_trace (trace_i interface{}, as_interest string, discovered_adjs, discovered_multi_adjs, discovered_addresses, discovered_routers, in_progress_discovered_routers *SafeSet, router_k int, border string, destination string, global_counter int, discovery_log *SafeSet) int {
+    if trace, t := trace_i.(*Trace); t {
+        discovery := 0
+        hops := trace.hops
+        /* --- Process trace --- */
+        for i, hop := range hops {
+            if hop.private { // A private address is never credited
+                continue
+            }
+            if hop.asn == as_interest {
+                discovery++
+                // --- Address
+                discovered_addresses.unsafe_add (hop.addr)
+                log_discovery (discovery_log, "address", hop.addr, destination, global_counter)
+                // --- Router
+                if hop.router != "" { // Address belongs to a router
+                    addresses_i, _ := in_progress_discovered_routers.unsafe_get (hop.router)
+                    addresses, _ := addresses_i.(map[string]struct{}) // Type assertion (nil if no address yet)
+                    seen := len (addresses)
+                    if seen < router_k {
+                        // Check the address is different from the ones we already recorded
+                        if _, ok := addresses[hop.addr]; !ok {
+                            in_progress_discovered_routers.unsafe_append (hop.router, hop.addr)
+                            if seen + 1 == router_k {
+                                discovered_routers.unsafe_add (hop.router)
+                                log_discovery (discovery_log, "router", hop.router, destination, global_counter)
+                            }
+                        }
+                    }
+                    // Note: we only need to store k of the addresses of the routers (reduce memory footprint).
+                }
+                
+            }
+            j := next_public_hop (hops, i)
+            if j == -1 { // Last hop
+                break
+            }
+            if !link_in_AS (hop, hops[j], as_interest, border) { // Take into account incoming links (see -border).
+                continue
+            }
+            /* --- Adjacencies (a multi-hop adjacency across private hops, see hop_distance) --- */
+            next_hop := hops[j]
+            distance := hop_distance (hops, i, j)
+            if distance == 1 {
+                discovered_adjs.unsafe_add (hop.addr+"_"+next_hop.addr)
+                log_discovery (discovery_log, "adj", hop.addr+"_"+next_hop.addr, destination, global_counter)
+            } 
+            if distance > 1 {
+                discovered_multi_adjs.unsafe_add (hop.addr+"_"+next_hop.addr)
+                log_discovery (discovery_log, "multi_adj", hop.addr+"_"+next_hop.addr, destination, global_counter)
+            }
+        }
+        return discovery
+    } else {
+        return 0
+    }
+}
+
+/**
+ * Records in the discovery log (if any) the probe that first discovered an element:
+ * "<kind> <element>" -> "<destination> <probe number>".
+ */
+func log_discovery (discovery_log *SafeSet, kind, element, destination string, global_counter int) {
+    if discovery_log == nil {
+        return
+    }
+    key := kind + " " + element
+    if !discovery_log.unsafe_contains (key) {
+        discovery_log.unsafe_add (key, destination + " " + strconv.Itoa (global_counter))
+    }
+}
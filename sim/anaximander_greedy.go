@@ -0,0 +1,145 @@
+/* ==================================================================================== *\
+    greedy_anaximander.go
+
+    Alternative scheduling for Anaximander:
+    ---------------------------------------
+    The simulation (for an AS of interest) is performed in parallel, i.e., all ASes at 
+    the same time. The exploration of the ASes is momentarily halted at the first useless
+    probes (or after -greedy-patience consecutive useless probes), to get back to it
+    at a later time.
+    
+    Note that the notion of parallelism here has nothing to do with code execution, but has
+    to do with the scheduling of the probes.
+
+    This scheduling performs worse to Anaximander's sequential scheduling.
+
+\* ==================================================================================== */
+package sim
+
+import (
+    "time"
+    )
+
+// -------------------------------------------------------------------------------
+/**
+ * Simulates the strategy of an AS of interest on the traces (see Simulate).
+ * The simulation is performed in parallel, i.e., all ASes at the same time. This allows to see how the real Anaximander performs in the wild.
+ */
+func simulate_greedy (ds *Datasets, as_interest string, strategy Strategy, opts Options) *Result {
+    start := time.Now ()
+    traces := ds.Traces
+    adjs, multi_adjs, addresses, routers := filterAS (ds, as_interest) // Keep only data relevant to AS of interest.
+    result := &Result{
+        AsInterest: as_interest,
+        Curve: make ([]DiscoveryPoint, 0),
+        Launched: make ([]string, 0, len (strategy.Targets)), // Targets in launching order (cost model)
+        Stats: AsStats{Adjs: len (adjs.set), MultiAdjs: len (multi_adjs.set), Addresses: len (addresses.set), Routers: len (routers.set)},
+    }
+
+    /* --- Probing strategy --- */
+    sorted_destinations, limits_neighbors := strategy.Targets, strategy.Limits
+    if opts.MissingTraces == missing_drop {
+        sorted_destinations, limits_neighbors, result.Stats.MissingRemoved = drop_missing_targets (ds, sorted_destinations, limits_neighbors, strategy.RawPrefixes, opts)
+    }
+    result.Stats.Targets = len (sorted_destinations)
+    credit := new_fractional_credit (ds, strategy.RawPrefixes, opts)
+    result.credit = credit
+    
+    /* --- Build the list of ASes to probe --- */
+    neighbor_start := 0
+    ases_status := make ([]*AS_status, 0, 10)
+    for i, AS := range limits_neighbors {
+        if AS.limit == neighbor_start {
+            continue
+        }
+        ases_status = append (ases_status, &AS_status {asn: AS.asn, start: neighbor_start, end: AS.limit, curr_probe:neighbor_start, stopper: new_plateau_stopper (opts, AS.limit - neighbor_start), stopped: false, position: i})
+        neighbor_start = AS.limit
+    }
+
+    /* --------------------------- *\
+               SIMULATION
+    \* --------------------------- */
+    discovered_adjs, discovered_multi_adjs, discovered_addresses, discovered_routers := create_safeset (), create_safeset (), create_safeset (), create_safeset ()
+    in_progress_discovered_routers := create_safeset () // A router is considered as discovered iif we have discovered at least k (opts.RouterK) of its addresses. In 'discovered_routers', we only store the routers with k or more addresses.
+    global_counter := 0
+    prev_adjs, prev_addresses, prev_routers := 0,0,0
+    stopped_ases := 0 // The number of ASes whose probing has stopped (either because we reached a plateau, or because the whole AS has been probed)
+    destination := ""
+    budget := opts.Budget.limit (len (sorted_destinations)) // -1: no budget
+    
+
+    iteration := 0
+    for stopped_ases != len (ases_status) && !result.Stats.BudgetExhausted && !result.Stats.Interrupted {
+        for _, as_status := range ases_status { // Loop over the ASes
+            if result.Stats.BudgetExhausted || result.Stats.Interrupted {
+                break
+            }
+            discovery := true
+
+            for discovery {
+                if opts.cancelled () { // Interrupted run (SIGINT): stop probing, the results so far are written
+                    result.Stats.Interrupted = true
+                    break
+                }
+                destination, stopped_ases = launch_as_probing (sorted_destinations, as_status, stopped_ases)
+                if destination == "" { // Nothing to probe for current AS, carry on to next AS (stopped AS, or AS completely probed)
+                    break
+                }
+                if budget >= 0 && len (result.Launched) >= budget { // Probe budget exhausted: stop probing
+                    result.Stats.BudgetExhausted = true
+                    break
+                }
+                trace, present := credit.get_trace (traces, destination) // Missing traces will be treated as traces that did not yield any discovery (unless skipped)
+                if !present {
+                    result.Stats.MissingTraces++
+                    if opts.MissingTraces == missing_skip {
+                        result.Stats.MissingRemoved++
+                        continue
+                    }
+                }
+                result.Launched = append (result.Launched, destination)
+            
+                if process_trace (trace, as_interest, discovered_adjs, discovered_multi_adjs, discovered_addresses, discovered_routers, in_progress_discovered_routers, opts.RouterK, ds.cfg.Border, destination, global_counter, nil) == 0 {
+                    result.Stats.FalsePositives++
+                }
+                
+                new_adjs, new_addresses, new_routers := len (discovered_adjs.set), len (discovered_addresses.set), len (discovered_routers.set)
+                credit.record_discoveries (new_adjs - prev_adjs, new_addresses - prev_addresses, new_routers - prev_routers)
+
+                changed_adjs, changed_addresses, changed_routers := new_adjs != prev_adjs, new_addresses != prev_addresses, new_routers != prev_routers
+                if changed_adjs || changed_addresses || changed_routers {
+                    /* --- Discovery --- */
+                    point := discovery_levels (discovered_adjs, discovered_multi_adjs, discovered_addresses, discovered_routers, adjs, multi_adjs, addresses, routers)
+                    point.Probe = global_counter
+                    result.Curve = append (result.Curve, point)
+                    prev_adjs, prev_addresses, prev_routers = new_adjs, new_addresses, new_routers
+                }
+                if found, stop := as_status.stopper.Observe (changed_adjs, changed_addresses, changed_routers); found {
+                    as_status.misses = 0
+                } else {
+                    as_status.misses++
+                    if as_status.position != 0 && as_status.misses >= opts.GreedyPatience { // Don't stop probing /24 internal prefixes.
+                        discovery = false
+                        as_status.misses = 0 // Patience is renewed when getting back to the AS
+                    }
+                    /* --- No discovery --- */
+                    if stop {
+                        if as_status.stopped == false { // Check if AS has not already been stopped because it was its last probe. In which case don't increment the number of stopped ASes, or it will be false.
+                            as_status.stopped = true
+                            stopped_ases++
+                        }
+                        break // To stop probing current batch.
+                    }
+                }
+                global_counter++
+            }
+        }
+        iteration++
+    }
+    result.Stats.Probes = len (result.Launched)
+    result.Stats.UsefulProbes = len (result.Curve)
+    result.Final = discovery_levels (discovered_adjs, discovered_multi_adjs, discovered_addresses, discovered_routers, adjs, multi_adjs, addresses, routers)
+    result.Final.Probe = len (result.Launched)
+    result.Duration = time.Since (start)
+    return result
+}
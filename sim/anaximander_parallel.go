@@ -0,0 +1,462 @@
+/* ==================================================================================== *\
+    parallel_anaximander.go
+
+    Alternative scheduling for Anaximander:
+    ---------------------------------------
+    The simulation (for an AS of interest) is performed in parallel, i.e., all ASes at 
+    the same time. The exploration of the ASes is done in successive batches, whose size
+    are determined by the weight attributed to an AS.
+    
+    Note that the notion of parallelism here has nothing to do with code execution, but has
+    to do with the scheduling of the probes.
+
+    This scheduling performs worse or equivalently to Anaximander's sequential scheduling.
+
+\* ==================================================================================== */
+package sim
+
+import (
+    "fmt"
+    "strings"
+    "strconv"
+    "time"
+    "math"
+    "log"
+    )
+
+type weight_function func (*AS_status, int) (int)
+
+// Note: y = p^{-1 +\frac{x}{max cc size}} increasing exponential function.
+
+/**
+ * Registry of the weighting functions (-w), in the order of their legacy numeric selectors.
+ * 
+ * These functions attribute a weight to an AS depending on different criteria. 
+ * 
+ * The weight represents the percentage of the AS address space that should be
+ * probed at once (in a batch). 
+ * The return value is the actual number of probes in the batch, i.e., weight * address_space_size
+ */
+type weight_spec struct {
+    name string;
+    generate func ([]float64, int, *Datasets) weight_function;
+    parameters []string;           // Description of each parameter (arity)
+    check func ([]float64) error;  // Range of the parameters
+    needs_cones bool;              // Needs the customer cones of the datasets (Config.PpdcFile)
+}
+
+var weight_functions = []weight_spec {
+    {"constant", generate_constant, []string{"batch size, in probes (>= 1)"}, func (p []float64) error {
+        return check_weight_range ("batch size", p[0], 1, math.Inf (1), true)
+    }, false},
+    {"inverse", generate_weight_inverse, []string{"weight of the last AS of the strategy, in (0,1)"}, check_desired_weight, false},
+    {"inverse_iteration", generate_weight_inverse_iteration_reduction, []string{"weight of the last AS of the strategy, in (0,1)", "slope of the reduction with the batches of an AS (> 0)"}, func (p []float64) error {
+        if err := check_desired_weight (p); err != nil {
+            return err
+        }
+        return check_weight_range ("slope", p[1], 0, math.Inf (1), false)
+    }, false},
+    {"cc_size", generate_weight_cc_size, []string{"weight of the AS with the largest customer cone, in (0,1)"}, check_desired_weight, true},
+    {"discovery_rate", generate_weight_discovery_rate, []string{"size of the first batch, and of a batch for a yield of 1 (>= 1)", "decay of the yield, in [0,1)"}, func (p []float64) error {
+        if err := check_weight_range ("factor", p[0], 1, math.Inf (1), true); err != nil {
+            return err
+        }
+        if p[1] < 0 || p[1] >= 1 {
+            return fmt.Errorf ("decay must be in [0,1), got %g", p[1])
+        }
+        return nil
+    }, false},
+}
+
+func check_desired_weight (p []float64) error {
+    return check_weight_range ("weight", p[0], 0, 1, false)
+}
+
+/**
+ * Returns an error if value is not in (min,max), or [min,max) if min_included.
+ */
+func check_weight_range (name string, value, min, max float64, min_included bool) error {
+    if value < min || (value == min && !min_included) || value >= max {
+        if min_included {
+            return fmt.Errorf ("%s must be >= %g, got %g", name, min, value)
+        }
+        return fmt.Errorf ("%s must be in (%g,%g), got %g", name, min, max, value)
+    }
+    return nil
+}
+
+/**
+ * Parses the weighting function of -w: either its name and parameters (e.g., inverse:0.05,
+ * discovery_rate:10,0.5) or its legacy numeric selector (e.g., 1-0.05). Returns the index of the
+ * function in weight_functions followed by its parameters, once their arity and range are checked.
+ */
+func parse_weight_selector (selector string) ([]float64, error) {
+    var index int
+    var values []string
+    if fields := strings.SplitN (selector, ":", 2); len (fields) == 2 || !is_numeric_selector (selector) {
+        name, parameters := fields[0], ""
+        if len (fields) == 2 {
+            parameters = fields[1]
+        }
+        index = -1
+        for i, spec := range weight_functions {
+            if spec.name == name {
+                index = i
+            }
+        }
+        if index < 0 {
+            return nil, fmt.Errorf ("unknown weighting function: %q (%s)", name, weight_function_names ())
+        }
+        if parameters != "" {
+            values = strings.Split (parameters, ",")
+        }
+    } else {
+        values = strings.Split (selector, "-")
+        n, err := strconv.Atoi (values[0])
+        if err != nil || n < 0 || n >= len (weight_functions) {
+            return nil, fmt.Errorf ("unknown weighting function: %q (%s)", values[0], weight_function_names ())
+        }
+        index, values = n, values[1:]
+    }
+
+    spec := weight_functions[index]
+    if len (values) != len (spec.parameters) {
+        return nil, fmt.Errorf ("weighting function %s expects %d parameter(s): %s", spec.name, len (spec.parameters), strings.Join (spec.parameters, "; "))
+    }
+    r := []float64{float64 (index)}
+    for i, value := range values {
+        f, err := strconv.ParseFloat (value, 64)
+        if err != nil {
+            return nil, fmt.Errorf ("weighting function %s: parameter %d (%s): %q is not a number", spec.name, i+1, spec.parameters[i], value)
+        }
+        r = append (r, f)
+    }
+    if _, err := weight_function_by_name (spec.name, r[1:]); err != nil {
+        return nil, err
+    }
+    return r, nil
+}
+
+/**
+ * Returns the weighting function of the given name (Options.WeightFunction), once the arity and the
+ * range of its parameters are checked.
+ */
+func weight_function_by_name (name string, parameters []float64) (weight_spec, error) {
+    for _, spec := range weight_functions {
+        if spec.name != name {
+            continue
+        }
+        if len (parameters) != len (spec.parameters) {
+            return spec, fmt.Errorf ("weighting function %s expects %d parameter(s): %s", spec.name, len (spec.parameters), strings.Join (spec.parameters, "; "))
+        }
+        if err := spec.check (parameters); err != nil {
+            return spec, fmt.Errorf ("weighting function %s: %v", spec.name, err)
+        }
+        return spec, nil
+    }
+    return weight_spec{}, fmt.Errorf ("unknown weighting function: %q (%s)", name, weight_function_names ())
+}
+
+func is_numeric_selector (selector string) bool {
+    _, err := strconv.Atoi (strings.SplitN (selector, "-", 2)[0])
+    return err == nil
+}
+
+/**
+ * Returns the available weighting functions with their parameters, for error messages.
+ */
+func weight_function_names () string {
+    names := make ([]string, 0, len (weight_functions))
+    for i, spec := range weight_functions {
+        names = append (names, fmt.Sprintf ("%d/%s: %s", i, spec.name, strings.Join (spec.parameters, "; ")))
+    }
+    return "available: " + strings.Join (names, " | ")
+}
+
+func generate_constant (parameters []float64, nb_ases int, _ *Datasets) weight_function {
+    if len (parameters) != 1 {
+        log.Fatal ("Wrong weighting parameters. Expecting 1 parameter.")
+    }
+    return func (as *AS_status, iteration int) int {
+        return max (int(parameters[0]),1)
+    }
+}
+
+/**
+ * Computes a parameter 'a' based on the number of ASes and the weight the last AS should have (ex: 0.01)
+ * a = (nb_as * desired_weight)/(1 - desired_weight)
+ * - Increase the desired weight to increase 'a'
+ * - Decrease the desired weight to decrease 'a'
+ * 
+ * Given the parameter 'a', generate the function:
+ * y = a/(x+a)
+ * 
+ * This function gives:
+ * - A decreasing function in the form 1/x, meaning that the first ASes will have a higher
+ *   weight (hence, be probed sooner) and the last ASes will have a lower weight (hence be
+ *   probed later).
+ * - y = 1 for x = 0, meaning the first AS will be probed fully (the first AS in
+ *   the list corresponds to the internal prefixes).
+ * - The 'a' parameter controls the slope of the function: 
+ *   ° Increase 'a' to get a smoother slope (weight decreases slower along the x axis),
+ *   ° Decrease 'a' to get a sharper slope (weight decreases faster along the x axis).
+ */
+func generate_weight_inverse(parameters []float64, nb_ases int, _ *Datasets) weight_function {
+    if len (parameters) != 1 {
+        log.Fatal ("Wrong weighting parameters. Expecting 1 parameter.")
+    }
+    desired_weight := parameters[0]
+    parameter := (desired_weight*float64(nb_ases))/(1-desired_weight) // Compute parameter based on the number of ASes and the user-defined desired_weight.
+    
+    return func (as *AS_status, iteration int) int {
+        weight := parameter/ (float64(as.position) + parameter)
+        batch_size := weight * float64(as.end - as.start)
+        return max (int(math.Ceil (batch_size)), 1)
+    }
+}
+
+/**
+ * Same as generate_weight_inverse, but this time the batch size decreases with the iteration, i.e., the number
+ * of times we already visited that AS.
+ */
+func generate_weight_inverse_iteration_reduction (parameters []float64, nb_ases int, _ *Datasets) weight_function {
+    if len (parameters) != 2 {
+        log.Fatal ("Wrong weighting parameters. Expecting 2 parameters.")
+    }
+    desired_weight := parameters[0]
+    second_parameter := parameters[1]
+    parameter := (desired_weight*float64(nb_ases))/(1-desired_weight) // Compute parameter based on the number of ASes and the user-defined desired_weight.
+    
+    return func (as *AS_status, iteration int) int {
+        weight := parameter/ (float64(as.position) + parameter)
+        batch_size := int (math.Ceil (weight * float64(as.end - as.start)))
+
+        /* weight depending on iteration */
+        iteration_weight := second_parameter/(float64 (iteration) + second_parameter)
+        batch_size = int (math.Ceil (iteration_weight * float64(batch_size))) // Pbm: how to tune? How many iteration will there be?
+
+        return max (batch_size, 1)
+    }
+}
+
+/**
+ * Same as function 'generate_weight_inverse' but on the customer cone size (of the datasets) instead of the relative order.
+ * => Bilan: Results are better than with the relative order of ASes. They are also slightly better than purely sequential
+ * but it's not much.
+ */
+func generate_weight_cc_size (parameters []float64, nb_ases int, ds *Datasets) weight_function {
+    if len (parameters) != 1 {
+        log.Fatal ("Wrong weighting parameters. Expecting 1 parameters.")
+    }
+    if len(ds.as_conesize) == 0 {
+        log.Fatal ("as_conesize not set")
+    }
+
+    desired_weight := parameters[0]
+    parameter := (desired_weight*float64(ds.max_conesize))/(1-desired_weight) 
+    
+    /* --- Weight function --- */
+    return func (as *AS_status, iteration int) int {
+        if as.position == 0 { // Special case of the internal prefixes.
+            return as.end - as.start
+        }
+        var cc_size int
+        var ok bool
+        if cc_size, ok = ds.as_conesize[as.asn]; !ok {
+            cc_size = 1
+        }
+        weight := parameter/ (float64(cc_size) + parameter)
+        batch_size := weight * float64(as.end - as.start)
+        return max (int(math.Ceil (batch_size)), 1)
+    }
+}
+
+/**
+ * Adaptive weight: the batch of an AS is proportional to the discovery yield of its previous
+ * batches (new adjacencies, addresses and routers per probe), so that the ASes still yielding
+ * are probed faster than the ones on a plateau.
+ * - parameters[0] (factor): size of the first batch of an AS, and size of a batch for a yield
+ *   of 1 element per probe (batch = ceil (factor * yield), at least 1 probe),
+ * - parameters[1] (decay, in [0,1)): weight of the past batches in the yield, i.e., the yield is
+ *   decay * previous yield + (1 - decay) * yield of the last batch (0: last batch only).
+ */
+func generate_weight_discovery_rate (parameters []float64, nb_ases int, _ *Datasets) weight_function {
+    if len (parameters) != 2 {
+        log.Fatal ("Wrong weighting parameters. Expecting 2 parameters.")
+    }
+    factor, decay := parameters[0], parameters[1]
+    yields := make (map[*AS_status]float64) // Decayed yield of each AS
+
+    return func (as *AS_status, iteration int) int {
+        if as.batches == 0 {
+            return int (math.Ceil (factor))
+        }
+        yield, ok := yields[as]
+        if !ok {
+            yield = as.last_yield
+        } else {
+            yield = decay * yield + (1 - decay) * as.last_yield
+        }
+        yields[as] = yield
+        return max (int (math.Ceil (factor * yield)), 1)
+    }
+}
+
+// -------------------------------------------------------------------------------
+/**
+ * Simulates the strategy of an AS of interest on the traces (see Simulate).
+ * The simulation is performed in parallel, i.e., all ASes at the same time. This allows to see how the real Anaximander performs in the wild.
+ */
+func simulate_parallel (ds *Datasets, as_interest string, strategy Strategy, opts Options) *Result {
+    start := time.Now ()
+    traces := ds.Traces
+    adjs, multi_adjs, addresses, routers := filterAS (ds, as_interest) // Keep only data relevant to AS of interest.
+    result := &Result{
+        AsInterest: as_interest,
+        Curve: make ([]DiscoveryPoint, 0),
+        Launched: make ([]string, 0, len (strategy.Targets)), // Targets in launching order (cost model)
+        Stats: AsStats{Adjs: len (adjs.set), MultiAdjs: len (multi_adjs.set), Addresses: len (addresses.set), Routers: len (routers.set)},
+    }
+
+    /* --- Probing strategy --- */
+    sorted_destinations, limits_neighbors := strategy.Targets, strategy.Limits
+    if opts.MissingTraces == missing_drop {
+        sorted_destinations, limits_neighbors, result.Stats.MissingRemoved = drop_missing_targets (ds, sorted_destinations, limits_neighbors, strategy.RawPrefixes, opts)
+    }
+    result.Stats.Targets = len (sorted_destinations)
+    credit := new_fractional_credit (ds, strategy.RawPrefixes, opts)
+    result.credit = credit
+    
+    /* --- Build the list of ASes to probe --- */
+    neighbor_start := 0
+    ases_status := make ([]*AS_status, 0, 10)
+    for i, AS := range limits_neighbors {
+        if AS.limit == neighbor_start {
+            continue
+        }
+        ases_status = append (ases_status, &AS_status {asn: AS.asn, start: neighbor_start, end: AS.limit, curr_probe:neighbor_start, stopper: new_plateau_stopper (opts, AS.limit - neighbor_start), stopped: false, position: i})
+        neighbor_start = AS.limit
+    }
+
+    /* --------------------------- *\
+               SIMULATION
+    \* --------------------------- */
+    discovered_adjs, discovered_multi_adjs, discovered_addresses, discovered_routers := create_safeset (), create_safeset (), create_safeset (), create_safeset ()
+    in_progress_discovered_routers := create_safeset () // A router is considered as discovered iif we have discovered at least k (opts.RouterK) of its addresses. In 'discovered_routers', we only store the routers with k or more addresses.
+    global_counter := 0
+    prev_adjs, prev_addresses, prev_routers := 0,0,0
+    stopped_ases := 0 // The number of ASes whose probing has stopped (either because we reached a plateau, or because the whole AS has been probed)
+    destination := ""
+    budget := opts.Budget.limit (len (sorted_destinations)) // -1: no budget
+    spec, _ := weight_function_by_name (opts.WeightFunction, opts.WeightParameters) // Checked by Simulate
+    weight_function := spec.generate (opts.WeightParameters, len (ases_status), ds)
+
+    iteration := 0
+    for stopped_ases != len (ases_status) && !result.Stats.BudgetExhausted && !result.Stats.Interrupted {
+        for _, as_status := range ases_status {
+            if result.Stats.BudgetExhausted || result.Stats.Interrupted {
+                break
+            }
+
+            batch_size := weight_function (as_status, iteration)
+            batch_probes, batch_discoveries := 0, 0
+            for i := 0; i < batch_size; i++ {
+                if opts.cancelled () { // Interrupted run (SIGINT): stop probing, the results so far are written
+                    result.Stats.Interrupted = true
+                    break
+                }
+                destination, stopped_ases = launch_as_probing (sorted_destinations, as_status, stopped_ases)
+                if destination == "" { // Nothing to probe for current AS, carry on to next AS
+                    break
+                }
+                if budget >= 0 && len (result.Launched) >= budget { // Probe budget exhausted: stop probing
+                    result.Stats.BudgetExhausted = true
+                    break
+                }
+                trace, present := credit.get_trace (traces, destination) // Missing traces will be treated as traces that did not yield any discovery (unless skipped)
+                if !present {
+                    result.Stats.MissingTraces++
+                    if opts.MissingTraces == missing_skip {
+                        result.Stats.MissingRemoved++
+                        i-- // The skipped target does not take a probe of the batch
+                        continue
+                    }
+                }
+                result.Launched = append (result.Launched, destination)
+            
+                if process_trace (trace, as_interest, discovered_adjs, discovered_multi_adjs, discovered_addresses, discovered_routers, in_progress_discovered_routers, opts.RouterK, ds.cfg.Border, destination, global_counter, nil) == 0 {
+                    result.Stats.FalsePositives++
+                }
+                
+                new_adjs, new_addresses, new_routers := len (discovered_adjs.set), len (discovered_addresses.set), len (discovered_routers.set)
+                credit.record_discoveries (new_adjs - prev_adjs, new_addresses - prev_addresses, new_routers - prev_routers)
+                batch_probes++
+                batch_discoveries += (new_adjs - prev_adjs) + (new_addresses - prev_addresses) + (new_routers - prev_routers)
+
+                changed_adjs, changed_addresses, changed_routers := new_adjs != prev_adjs, new_addresses != prev_addresses, new_routers != prev_routers
+                if changed_adjs || changed_addresses || changed_routers {
+                    /* --- Discovery --- */
+                    point := discovery_levels (discovered_adjs, discovered_multi_adjs, discovered_addresses, discovered_routers, adjs, multi_adjs, addresses, routers)
+                    point.Probe = global_counter
+                    result.Curve = append (result.Curve, point)
+                    prev_adjs, prev_addresses, prev_routers = new_adjs, new_addresses, new_routers
+                }
+                if _, stop := as_status.stopper.Observe (changed_adjs, changed_addresses, changed_routers); stop { // Plateau
+                    if as_status.stopped == false { // Check if AS has not already been stopped because it was its last probe. In which case don't increment the number of stopped ASes, or it will be false.
+                        as_status.stopped = true
+                        stopped_ases++
+                    }
+                    break // To stop probing current batch.
+                }
+                global_counter++
+            }
+            if batch_probes > 0 { // Yield of the batch (adaptive weight functions)
+                as_status.last_yield = float64 (batch_discoveries)/float64 (batch_probes)
+                as_status.batches++
+            }
+        }
+        iteration++
+    }
+    result.Stats.Probes = len (result.Launched)
+    result.Stats.UsefulProbes = len (result.Curve)
+    result.Final = discovery_levels (discovered_adjs, discovered_multi_adjs, discovered_addresses, discovered_routers, adjs, multi_adjs, addresses, routers)
+    result.Final.Probe = len (result.Launched)
+    result.Duration = time.Since (start)
+    return result
+}
+// -------------------------------------------------------------------------------
+/**
+ * For a given AS, returns the current target to probe, if the AS hasn't been stopped and if the AS hasn't
+ * been completely probed yet.
+ * Also increments the global number of stopped_ases when an AS has been completely probed.
+ */
+func launch_as_probing (sorted_destinations []string, as_status *AS_status, _stopped_ases int) (destination string, stopped_ases int) {
+    if as_status.stopped || as_status.curr_probe >= as_status.end {
+        destination = ""
+    } else {
+        destination = sorted_destinations[as_status.curr_probe]
+    }
+    as_status.curr_probe++
+    stopped_ases = _stopped_ases
+    if (as_status.curr_probe == as_status.end) && as_status.stopped == false{ 
+        // Even if an AS is stoped, we increment its counter. Therefore, we need to check the first condition, otherwise, each time we get back to a stopped AS, we increment the number of stopped ASes
+        // We also need to have the second condition, otherwise an AS that was stopped because of a plateau will be stopped two times when we reach the end of its addres space.
+        stopped_ases = _stopped_ases+1
+        as_status.stopped = true
+    }
+    return
+}
+
+// -------------------------------------------------------------------------------
+type AS_status struct {
+    asn string;           // The AS number
+    start int;            // The index of the beginning of this AS's probes
+    end int;              // The index of the end of this AS's probes
+    curr_probe int;       // The current probe
+    stopper Stopper;      // The plateau of this AS (see new_plateau_stopper)
+    stopped bool;         // Whether the probing of this AS has been stopped due to a plateau. curr_probe remains the current probe if we want to get back and continue probing
+    position int;         // The position of this AS in the as_limit file
+    batches int;          // The number of batches (with at least one probe) launched for this AS
+    last_yield float64;   // New elements (adjacencies, addresses, routers) per probe of the last batch
+    misses int;           // The current number of consecutive probes without discovery (greedy scheduling)
+} 
\ No newline at end of file
@@ -0,0 +1,168 @@
+/* ==================================================================================== *\
+   sequential_anaximander.go
+
+   Implementation of Anaximander's scheduling:
+   ------------------------------------------
+   The simulation (for an AS of interest) is performed sequentially, i.e., one AS after the other. 
+   This allows to see for plateaux between ASes.
+
+   See parallel_anaximander.go or greedy_anaximander.go for another type of scheduling.
+   
+\* ==================================================================================== */
+package sim
+
+import (
+    "time")
+
+// -------------------------------------------------------------------------------
+/**
+ * Simulates the strategy of an AS of interest on the traces (see Simulate).
+ * The simulation is performed sequentially, i.e., one AS after the other. This allows to see for plateaux between ASes.
+ */
+func simulate_sequential (ds *Datasets, as_interest string, strategy Strategy, opts Options) *Result {
+  start := time.Now ()
+  traces := ds.Traces
+  adjs, multi_adjs, addresses, routers := filterAS (ds, as_interest) // Keep only data relevant to AS of interest.
+  result := &Result{
+    AsInterest: as_interest,
+    Curve: make ([]DiscoveryPoint, 0),
+    GroupLimits: make ([]int, 0, len (strategy.Limits)),
+    Launched: make ([]string, 0, len (strategy.Targets)), // Targets in launching order (cost model)
+    Stats: AsStats{Adjs: len (adjs.set), MultiAdjs: len (multi_adjs.set), Addresses: len (addresses.set), Routers: len (routers.set), Targets: len (strategy.Targets)},
+    successful_traces: create_safeset (),
+  }
+  if opts.Attribution {
+    result.discovery_log = create_safeset ()
+  }
+  
+  /* --- Probing strategy --- */
+  sorted_destinations, limits_neighbors := strategy.Targets, strategy.Limits
+  if opts.MissingTraces == missing_drop {
+    sorted_destinations, limits_neighbors, result.Stats.MissingRemoved = drop_missing_targets (ds, sorted_destinations, limits_neighbors, strategy.RawPrefixes, opts)
+  }
+  credit := new_fractional_credit (ds, strategy.RawPrefixes, opts)
+  result.credit = credit
+  
+  /* --------------------------- *\
+             SIMULATION
+  \* --------------------------- */
+  discovered_adjs, discovered_multi_adjs, discovered_addresses, discovered_routers := create_safeset (), create_safeset (), create_safeset (), create_safeset ()
+  in_progress_discovered_routers := create_safeset () // A router is considered as discovered iif we have discovered at least k (opts.Router_k) of its addresses. In 'discovered_routers', we only store the routers with k or more addresses.
+
+  /* --- Contribution of each group of ASes (if known) --- */
+  groups := as_groups (ds.as_neighbors, as_interest, limits_neighbors)
+  contributions := make (map[string]*GroupContribution)
+  if groups != nil {
+    result.Groups = make ([]*GroupContribution, 0, len (group_names))
+    for _, name := range group_names {
+      contributions[name] = &GroupContribution{Group: name}
+      result.Groups = append (result.Groups, contributions[name])
+    }
+  }
+
+  budget := opts.Budget.limit (len (strategy.Targets)) // -1: no budget
+  global_counter := 0
+  prev_adjs, prev_addresses, prev_routers := 0,0,0
+
+  /* --- Loop over neighbors --- */
+  neighbor_start := 0
+  total_length := 0
+  for _, AS := range limits_neighbors {
+    neighbor_stop := AS.limit
+    if neighbor_stop == neighbor_start {
+      continue
+    }
+    if budget >= 0 && global_counter >= budget {
+      result.Stats.BudgetExhausted = true
+      break
+    }
+    if opts.cancelled () {
+      result.Stats.Interrupted = true
+      break
+    }
+    /* --- Time-boxed run: skip the remaining groups (results so far are kept) --- */
+    if !deadline_allows ("group") {
+      deadline_skip ("AS " + as_interest + ": groups from AS " + AS.asn)
+      break
+    }
+    group_start := time.Now ()
+    contribution := contributions[groups[AS.asn]] // nil if the groups are unknown
+    stopper := new_plateau_stopper (opts, neighbor_stop - neighbor_start)
+    stop := false
+    /* --- Loop over prefixes of neighbors --- */
+    k := neighbor_start
+    for ; k < neighbor_stop; k++ {
+      /* --- Probe budget: stop probing (the limit of the group is recorded) --- */
+      if budget >= 0 && global_counter >= budget {
+        result.Stats.BudgetExhausted = true
+        break
+      }
+      /* --- Interrupted run (SIGINT): stop probing, the results so far are written --- */
+      if opts.cancelled () {
+        result.Stats.Interrupted = true
+        break
+      }
+      destination := sorted_destinations[k]
+      trace, present := credit.get_trace (traces, destination)
+      if !present {
+        result.Stats.MissingTraces++ // Missing traces are treated as traces that did not yield any discovery (unless skipped).
+        if opts.MissingTraces == missing_skip {
+          result.Stats.MissingRemoved++
+          continue
+        }
+      }
+      result.Launched = append (result.Launched, destination)
+      prev_multi_adjs := len (discovered_multi_adjs.set)
+      discovery := process_trace (trace, as_interest, discovered_adjs, discovered_multi_adjs, discovered_addresses, discovered_routers, in_progress_discovered_routers, opts.RouterK, ds.cfg.Border, destination, global_counter, result.discovery_log)
+      if discovery != 0 {
+        result.successful_traces.unsafe_add (destination, discovery)
+      } else {
+        result.Stats.FalsePositives++
+      }
+
+      new_adjs, new_addresses, new_routers := len (discovered_adjs.set), len (discovered_addresses.set), len (discovered_routers.set)
+      credit.record_discoveries (new_adjs - prev_adjs, new_addresses - prev_addresses, new_routers - prev_routers)
+      if contribution != nil {
+        contribution.Probes++
+        contribution.Addresses += new_addresses - prev_addresses
+        contribution.Adjs += new_adjs - prev_adjs
+        contribution.MultiAdjs += len (discovered_multi_adjs.set) - prev_multi_adjs
+        contribution.Routers += new_routers - prev_routers
+      }
+
+      changed_adjs, changed_addresses, changed_routers := new_adjs != prev_adjs, new_addresses != prev_addresses, new_routers != prev_routers
+      if changed_adjs || changed_addresses || changed_routers {
+        /* --- Discovery --- */
+        point := discovery_levels (discovered_adjs, discovered_multi_adjs, discovered_addresses, discovered_routers, adjs, multi_adjs, addresses, routers)
+        point.Probe = global_counter
+        result.Curve = append (result.Curve, point)
+        prev_adjs, prev_addresses, prev_routers = new_adjs, new_addresses, new_routers
+      }
+      _, stop = stopper.Observe (changed_adjs, changed_addresses, changed_routers)
+      global_counter++
+      
+      /* --- Stop probing and go to next neighbor --- */
+      if stop {
+        k++ // Necessary when we break the loop, because in case of breaking, there is no k+1 that is done.
+        break
+      }
+    } // End of loop on the neighbor's prefixes - end of current neighbor
+
+    // Record neighbor's new limit
+    neighbor_total_length := k - neighbor_start // No k+1, because at end of loop, we already exceeded the limit by 1.
+    total_length += neighbor_total_length
+    result.GroupLimits = append (result.GroupLimits, total_length)
+    
+    neighbor_start = neighbor_stop
+    deadline_unit_done ("group", group_start)
+    if result.Stats.BudgetExhausted || result.Stats.Interrupted {
+      break
+    }
+  } // End of loop on neighbors
+  result.Stats.Probes = global_counter
+  result.Stats.UsefulProbes = len (result.Curve)
+  result.Final = discovery_levels (discovered_adjs, discovered_multi_adjs, discovered_addresses, discovered_routers, adjs, multi_adjs, addresses, routers)
+  result.Final.Probe = global_counter
+  result.Duration = time.Since (start)
+  return result
+}
@@ -0,0 +1,530 @@
+/* ============================================================ *\
+   anaximander_strategy.go
+
+   Implements the _Anaximander Strategy Step_ 
+
+   Reads the necessary datasets and apply a strategy. The
+   output can then be used to launch the _Anaximander Simulator_
+\* ============================================================ */
+
+package sim
+
+import (
+    "bufio"
+    "fmt"
+    "strings"
+    "strconv"
+    "log"
+    "math/rand"
+    "os"
+    "os/exec"
+    "net"
+    "sort"
+    "sync/atomic"
+    )
+
+// Beside targets.txt, the raw prefix in which each target was picked (format: IP raw_prefix), so that
+// targets.txt remains a bare list of addresses. Only the targets picked in a larger prefix are listed.
+const raw_prefixes_file = "targets_raw_prefixes.txt"
+
+/**
+ * A strategy_function takes as input:
+ * - A slice of all targets found in the warts files
+ * - The AS of interest
+ * - A target_to_vp mapping
+ * - The run of the strategy: its datasets, and the raw prefixes of the targets picked in a larger prefix (see strategy_run)
+ * 
+ * A strategy_function returns:
+ * - a slice of string: ordered list of targets
+ * - a slice of AS_limit: this gives, for each AS, the delimitation with the next AS in the oredered list of targets.
+ * - the groups of ASes, in probing order (nil if the strategy does not probe groups of ASes, see strategy_group).
+ */
+type strategy_function func ([]string, string, VP_mapper, *strategy_run) ([]string, []*AS_limit, []*strategy_group)
+
+/**
+ * The datasets the strategies order and reduce the targets with (see read_strategy_data). A strategy
+ * reads them from its run only: a copy with one of them replaced gives the order without that dataset
+ * (see dataset_influence.go).
+ */
+type strategy_datasets struct {
+    as_rel *as_graph;                               // AS relationships (-asrel)
+    as_conesize map[string]int;                     // Customer cone sizes (-ppdc)
+    overlays_file string;                           // Merged overlays (-overlays_file)
+    nexthop_dir string;                             // Merged next-hop ASes (-nexthop_dir)
+}
+
+/**
+ * A run of a strategy on an AS of interest: its datasets, its random numbers (derived from the seed and
+ * the AS of interest, see seeded_random), and the raw prefix in which each target was picked (filled by
+ * the strategy). Not safe for concurrent use (a run is sequential).
+ */
+type strategy_run struct {
+    ds *strategy_datasets;
+    rng *rand.Rand;
+    picks target_picks;
+}
+
+func new_strategy_run (ds *strategy_datasets, seed int64, as_interest string) *strategy_run {
+    return &strategy_run{ds: ds, rng: seeded_random (seed, as_interest), picks: make (target_picks)}
+}
+
+/**
+ * Picks a random /24 prefix in the probe, and records the raw prefix it was picked in (see target_picks).
+ */
+func (run *strategy_run) pick (probe string) string {
+    return run.picks.pick (run.rng, probe)
+}
+
+/**
+ * A probing strategy: its stable name (see -s), its function, a one-line description, and the input
+ * flags it needs besides the CAIDA files (ip2as, asrel and ppdc).
+ */
+type strategy_entry struct {
+    name string;
+    fn strategy_function;
+    description string;
+    inputs []string;
+}
+
+/**
+ * All probing strategies. The index of a strategy is still accepted by -s: the order must not change.
+ */
+var strategies = []strategy_entry {
+    {"random", random, "Targets of the warts data set in random order", []string{"warts", "vps", "bdr"}},
+    {"increasing_order", increasing_order, "Targets of the warts data set in increasing order", []string{"warts", "vps", "bdr"}},
+    {"direct_neighbors", direct_neighbors, "/24 prefixes of the direct neighbors (no ordering)", nil},
+    {"direct_neighbors_and_internal", direct_neighbors_and_internal, "/24 prefixes of the direct neighbors and internal prefixes (no ordering)", nil},
+    {"internal_and_direct_neighbors", internal_and_direct_neighbors, "Internal prefixes, then /24 prefixes of the direct neighbors", nil},
+    {"customer_cone_neighbors_decreasing", customer_cone_neighbors_decreasing, "Prefixes of the direct neighbors, by decreasing customer cone", nil},
+    {"customer_cone_neighbors_increasing", customer_cone_neighbors_increasing, "Prefixes of the direct neighbors, by increasing customer cone", nil},
+    // Rocketfuel and improvements
+    {"directed_probing", directed_probing, "Rocketfuel directed probing", []string{"dp_dir"}},
+    {"directed_probing_internal_neighbors_others", directed_probing_internal_neighbors_others, "Directed probing: internals, direct neighbors, others (no ordering)", []string{"dp_dir"}},
+    {"directed_probing_internal_neighbors_others_customercone", directed_probing_internal_neighbors_others_customercone, "Directed probing: internals, direct neighbors, others (increasing customer cone)", []string{"dp_dir"}},
+    {"directed_probing_internal_neighbors_others_mixed", directed_probing_internal_neighbors_others_mixed, "Directed probing: internals, then all other ASes mixed (increasing customer cone)", []string{"dp_dir"}},
+    {"directed_probing_internal_neighbors_onehopneighbors_others", directed_probing_internal_neighbors_onehopneighbors_others, "Directed probing: internals, direct neighbors, one-hop neighbors, others (increasing customer cone)", []string{"dp_dir"}},
+    // Rocketfuel directed probing, but the prefixes haven't been broken down to /24 prefixes.
+    {"directed_probing_no24", directed_probing_no24, "Rocketfuel directed probing, prefixes not broken down into /24", []string{"dp_dir"}},
+    {"directed_probing_internal_neighbors_onehopneighbors_others_no24", directed_probing_internal_neighbors_onehopneighbors_others_no24, "Strategy 11, directed probes not broken down into /24", []string{"dp_dir"}},
+    {"directed_probing_internal_neighbors_others_no24", directed_probing_internal_neighbors_others_no24, "Strategy 9, directed probes not broken down into /24", []string{"dp_dir"}},
+    // Direct neighbors replay without breaking down into /24
+    {"customer_cone_neighbors_increasing_no24", customer_cone_neighbors_increasing_no24, "Internals (/24), then direct neighbors (no /24) by increasing customer cone", nil},
+    // Rocketfuel best directed probes (prefixes not broken down into /24)
+    {"best_directed_probing_internal_neighbors_onehopneighbors_others_no24", best_directed_probing_internal_neighbors_onehopneighbors_others_no24, "Strategy 13 on the best directed probes", []string{"dp_dir"}},
+    {"overlays_reduction_global", overlays_reduction_global, "Best directed probes, reduction on overlays", []string{"dp_dir", "overlays_file"}},
+    // Rocketfuel next hop AS reduction
+    {"next_hop_as_reduction_global", next_hop_as_reduction_global, "Best directed probes, reduction on next-hop ASes", []string{"dp_dir", "nexthop_dir"}},
+    // Oracle strategy
+    {"oracle", oracle, "Oracle: the targets that yielded discovery in a previous simulation", []string{"oracle_dir"}},
+    {"overlays_reduction_global_relationships", overlays_reduction_global_relationships, "Strategy 17, direct neighbors grouped by relationship (Anaximander)", []string{"dp_dir", "overlays_file"}},
+    {"overlays_reduction_global_relationships_decreasing_cc", overlays_reduction_global_relationships_decreasing_cc, "Strategy 20, decreasing customer cone", []string{"dp_dir", "overlays_file"}},
+    // Ordering by probing cost
+    {"directed_probing_probe_count_decreasing", directed_probing_probe_count_decreasing, "Strategy 11, ASes by decreasing number of directed prefixes", []string{"dp_dir"}},
+    {"directed_probing_probe_count_increasing", directed_probing_probe_count_increasing, "Strategy 11, ASes by increasing number of directed prefixes", []string{"dp_dir"}},
+    // Ordering of the others by AS-level distance
+    {"directed_probing_others_by_distance", directed_probing_others_by_distance, "Strategy 11, others by increasing AS-level distance (then customer cone)", []string{"dp_dir"}},
+    // Rocketfuel overlays and next hop AS reductions
+    {"overlays_nexthop_reduction_global", overlays_nexthop_reduction_global, "Strategy 20, then reduction on next-hop ASes", []string{"dp_dir", "overlays_file", "nexthop_dir"}},
+    // Rocketfuel overlays reduction, per-VP overlays
+    {"overlays_reduction_per_vp", overlays_reduction_per_vp, "Strategy 20, overlays of the collector of each VP", []string{"warts", "vps", "bdr", "dp_dir", "overlays_dir", "vp_collectors"}},
+    // Organization-aware directed probing (siblings)
+    {"directed_probing_siblings", directed_probing_siblings, "Strategy 11, siblings of the AS of interest as internals, sibling neighbors merged (AS2Org)", []string{"dp_dir", "as2org"}},
+    // Rocketfuel egress reduction
+    {"egress_reduction_global", egress_reduction_global, "Best directed probes, one target per (ingress, next-hop AS) pair (egress reduction)", []string{"warts", "vps", "bdr", "dp_dir", "nexthop_dir"}},
+}
+
+/**
+ * Returns the index of the strategy given by its name (e.g., "overlays_reduction_global")
+ * or by its index in strategies.
+ */
+func strategy_index (s string) (int, error) {
+    if index, err := strconv.Atoi (s); err == nil {
+        if index < 0 || index >= len (strategies) {
+            return -1, fmt.Errorf ("unknown strategy %d (0 to %d)", index, len (strategies) - 1)
+        }
+        return index, nil
+    }
+    for index, entry := range strategies {
+        if entry.name == s {
+            return index, nil
+        }
+    }
+    return -1, fmt.Errorf ("unknown strategy %q (see './anaximander strategy list')", s)
+}
+
+/**
+ * Value of the -s flag: a strategy name or, for backward compatibility, an index.
+ */
+type strategy_value struct {
+    index *int;
+}
+
+func (v *strategy_value) String () string {
+    if v == nil || v.index == nil || *v.index < 0 {
+        return ""
+    }
+    return strategies[*v.index].name
+}
+
+func (v *strategy_value) Set (s string) error {
+    index, err := strategy_index (s)
+    if err != nil {
+        return err
+    }
+    if _, numeric := strconv.Atoi (s); numeric == nil {
+        log.Printf ("[strategy]: -s %s resolves to %s", s, strategies[index].name)
+    }
+    *v.index = index
+    return nil
+}
+
+/**
+ * Prints each strategy with its description and its required inputs.
+ */
+func list_strategies () {
+    for index, entry := range strategies {
+        inputs := []string{"ip2as", "asrel", "ppdc"}
+        inputs = append (inputs, entry.inputs...)
+        fmt.Printf ("%2d  %s\n", index, entry.name)
+        fmt.Printf ("      %s\n", entry.description)
+        fmt.Printf ("      inputs: -%s\n", strings.Join (inputs, " -"))
+    }
+}
+
+/**
+ * Structure to hold the ASN, as well as the index of their last probe in the global ordering of the probes.
+ */
+type AS_limit struct {
+    asn string;
+    limit int;
+}
+
+/* --- Groups of ASes of a strategy, and how their ASes are ordered --- */
+const (
+    group_internal = "internal"
+    group_neighbor = "neighbor"
+    group_one_hop = "one-hop"
+    group_other = "other"
+
+    order_none = "none"                   // ASN order
+    order_customer_cone = "customer_cone"
+    order_relationships = "relationships" // Customers, peers, then providers, each by customer cone
+    order_probe_count = "probe_count"
+    order_distance = "distance"           // AS-level distance, then customer cone
+    order_organization = "organization"   // Siblings merged, by combined customer cone
+)
+
+/**
+ * A group of ASes probed by a strategy (internal prefixes, neighbors, one hop neighbors, others, or a
+ * mix of them, e.g. "one-hop+other"), with its ASes in probing order and its number of targets before
+ * any reduction. The internal group holds the AS of interest (and the ASes probed along, e.g. its siblings).
+ */
+type strategy_group struct {
+    name string;
+    order string;
+    ases []string;
+    size int;
+}
+
+/**
+ * Returns the group of the ASes, sized by their probes in AS_probes (before they are reduced).
+ */
+func new_strategy_group (name, order string, ases []string, AS_probes map[string]map[string]interface{}) *strategy_group {
+    return &strategy_group{name: name, order: order, ases: ases, size: count_AS_probes (AS_probes, ases)}
+}
+
+/**
+ * Runs the strategy on every AS of interest. Returns the number of ASes whose strategy could not be written.
+ */
+func launch_anaximander_strategy (break_len int, strategy int, output_dir string) int {
+    seed := seed_random (g_args.seed)
+    write_manifest (output_dir, &run_manifest{Command: "strategy", Strategy: strategies[strategy].name, Seed: seed}, g_args.force)
+    summary_begin ("strategy", g_args.summary_out)
+    summary_artifact (output_dir + "/" + manifest_file)
+    profile_begin ()
+    summary_stage ("read_datasets")
+    ases_interest, target_to_vp, destinations, ds := read_strategy_data (break_len)
+
+    /* --- Launch Strategy --- */
+    log.Println ("Launch Anaximander Strategy...")
+    failed := new (int64)
+    f := generate_anaximander_strategy (strategy, ds, seed, output_dir, target_to_vp, destinations, failed)
+    summary_stage ("strategy")
+    launch_pool_progress ("strategy", "ASes", cpu_jobs (), ases_interest, deadline_guard (f, ""))
+    write_strategy_metadata (output_dir, strategy)
+    summary_artifact (output_dir + "/" + strategy_metadata_file)
+    return int (atomic.LoadInt64 (failed))
+}
+
+/**
+ * Reads the datasets needed by the strategies and sets the global variables.
+ * Returns the ASes of interest, the target_to_vp mapping, the destinations of the warts data set (if any),
+ * and the datasets the strategies order the targets with.
+ */
+func read_strategy_data (break_len int) ([]string, VP_mapper, []string, *strategy_datasets) {
+
+    /* --- Read data --- */
+    log.Println ("Reading data...")
+    ds := &strategy_datasets{as_rel: new_as_graph (read_as_rel (g_args.as_rel_file)), overlays_file: g_args.overlays_global_file, nexthop_dir: g_args.nexthop_as_dir_global}
+    as_24prefixes, as_prefixes, prefix_as = read_ip2as (g_args.ip2as_file)
+    set_as_to_prefixes (break_len)
+    ds.as_conesize = read_customer_cone (g_args.ppdc_file) // Must come afterwards.
+    ases_interest,_ := read_whitespace_delimited_file (g_args.ases_interest_file)
+
+    vps = []string{"my_VP"}
+    var target_to_vp VP_mapper = NewFakeVPMapper ("my_VP")
+    destinations := []string{}
+
+    /* --- To be able to record the stratagy for a given warts dataset --- */
+    if g_args.warts_directory != "" && g_args.vps_file != ""{
+        ds := parse_warts_args ()
+        target_to_vp = ds.TargetToVp
+        strategy_traces = ds.Traces
+        destinations = ds.Traces.Keys ()
+        sort.Strings (destinations) // The strategies don't depend on the map iteration order
+        vps,_ = read_vps_file (g_args.vps_file)
+    }
+
+    /* --- Allowed target ASes --- */
+    if g_args.target_as_allowlist != "" {
+        allowlist, err := read_allowlist (g_args.target_as_allowlist)
+        if err != nil {
+            log.Fatal ("[read_strategy_data]: ", err)
+        }
+        target_allowlist = allowlist
+        log.Println ("[read_strategy_data]:", len (allowlist), "ASes in the allowlist (sha256", allowlist_hash (allowlist) + ")")
+    }
+
+    /* --- Organizations (sibling-aware strategies) --- */
+    if g_args.as2org_file != "" {
+        as_to_org, org_to_ases = read_as2org (g_args.as2org_file)
+        log.Println ("[read_strategy_data]:", len (as_to_org), "ASes in", len (org_to_ases), "organizations")
+    }
+
+    /* --- Routing changes between two BGP snapshots (differential ordering) --- */
+    if g_args.diff_old_dir != "" && g_args.diff_new_dir != "" {
+        route_changes = compute_route_changes (g_args.diff_old_dir, g_args.diff_new_dir, ases_interest)
+    }
+    return ases_interest, target_to_vp, destinations, ds
+}
+
+func generate_anaximander_strategy (strategy int, ds *strategy_datasets, seed int64, output_dir string, target_to_vp VP_mapper, destinations []string, failed *int64) func (string){
+    return func (as_interest string) {
+        // build directory for the AS
+        output_dir_as := output_dir + "/" + as_interest
+        cmd_s := "mkdir " + output_dir_as
+        exec.Command("bash", "-c", cmd_s).Run()
+
+        if err := write_strategy (strategy, ds, seed, as_interest, target_to_vp, output_dir_as, destinations); err != nil {
+            log.Print ("[write_strategy]: AS ", as_interest, ": ", err)
+            atomic.AddInt64 (failed, 1)
+            summary_unit ("ASes", unit_failed)
+            return
+        }
+        summary_artifact (output_dir_as)
+        summary_unit ("ASes", unit_processed)
+    }
+}
+
+/**
+ * Writes a number of targets of the AS of interest in its directory. The name must differ from
+ * those of output_msg, split into the output directory (see split_output_by_first_column).
+ * A failed write is logged and reported in the summary of the run.
+ */
+func write_target_count (filename, as_interest string, n int) {
+    err := write_file (filename, func (w *bufio.Writer) {
+        w.WriteString (strconv.Itoa (n) + "\n")
+    })
+    if err != nil {
+        log.Print ("[write_strategy]: AS ", as_interest, ": ", err)
+        summary_warning ("AS " + as_interest + ": " + err.Error ())
+    }
+}
+
+/**
+ * Writes the Strategy Step output, i.e., a list of ordered targets and of AS delimitation.
+ * Returns the first error writing the targets, their annotations, the raw prefixes, the ledger or the
+ * AS limits: the strategy of the AS is then incomplete.
+ */
+func write_strategy (strategy int, ds *strategy_datasets, seed int64, as_interest string, target_to_vp VP_mapper, output_dir string, destinations []string) error {
+
+    /* --- Launch strategy --- */
+    run := new_strategy_run (ds, seed, as_interest)
+    sorted_destinations, limits_neighbors, _ := strategies[strategy].fn (destinations, as_interest, target_to_vp, run)
+
+    /* --- A target must appear once --- */
+    sorted_destinations, limits_neighbors, duplicates := dedup_targets (sorted_destinations, limits_neighbors)
+    output_msg ("duplicate_targets.txt", as_interest, duplicates)
+    if duplicates != 0 {
+        log.Println ("[write_strategy]: AS", as_interest, ":", duplicates, "targets listed several times, later occurrences dropped")
+    }
+    write_target_count (output_dir + "/nb_duplicate_targets.txt", as_interest, duplicates) // Probes the duplicates would have wasted
+
+    /* --- Only the allowed ASes are targeted --- */
+    sorted_destinations, limits_neighbors = enforce_allowlist (sorted_destinations, limits_neighbors, as_interest)
+
+    /* --- Only the targets with a trace in the warts data set --- */
+    if g_args.only_probed {
+        var unprobed int
+        sorted_destinations, limits_neighbors, unprobed = keep_probed_targets (sorted_destinations, limits_neighbors)
+        output_msg ("unprobed_targets.txt", as_interest, unprobed)
+        if unprobed != 0 {
+            log.Println ("[write_strategy]: AS", as_interest, ":", unprobed, "targets without trace dropped")
+        }
+        write_target_count (output_dir + "/nb_unprobed_targets.txt", as_interest, unprobed)
+    }
+
+    /* --- Targets whose routes changed first --- */
+    if route_changes != nil {
+        var counts map[string]int
+        sorted_destinations, limits_neighbors, counts = differential_order (sorted_destinations, limits_neighbors, as_interest)
+        values := []interface{}{"route_changes.txt", as_interest} // Nb of targets of each class (see route_classes)
+        for _, class := range route_classes {
+            values = append (values, counts[class])
+        }
+        output_msg (values...)
+    }
+    
+    /* --- Record results --- */
+    w, file := new_bufio_writer (output_dir + "/targets.txt")
+    raw_w, raw_file := new_bufio_writer (output_dir + "/" + raw_prefixes_file)
+    annotated, annotated_file := new_bufio_writer (output_dir + "/targets_annotated.txt")
+    annotated.WriteString ("# target prefix origin_prefix prefix_as group_as group cone_size\n")
+    groups := as_groups (ds.as_rel.neighbors, as_interest, limits_neighbors)
+    records := make ([]prefix_record, 0, len (sorted_destinations))
+    group := 0
+    for i, target := range sorted_destinations {
+        _, network, _ := net.ParseCIDR (target)
+        record := prefix_record{prefix: get_random_ip (run.rng, network).String ()}
+        origin := target
+        if raw, picked := run.picks.raw_prefix (target); picked { // Record the raw prefix in which the target was picked
+            raw_w.WriteString (record.prefix + " " + raw + "\n")
+            origin = raw
+        }
+        records = append (records, record)
+        w.WriteString (record.prefix + "\n")
+
+        /* --- Annotation: where the target comes from --- */
+        for group < len (limits_neighbors) && i >= limits_neighbors[group].limit {
+            group++
+        }
+        group_as, group_name := "-1", "others"
+        if group < len (limits_neighbors) {
+            group_as = limits_neighbors[group].asn
+        }
+        if groups != nil {
+            group_name = groups[group_as]
+        }
+        prefix_as := target_as (origin)
+        fmt.Fprintln (annotated, record.prefix, target, origin, prefix_as, group_as, group_name, ds.as_conesize[prefix_as])
+    }
+    for _, err := range []error{close_bufio_writer (w, file), close_bufio_writer (raw_w, raw_file), close_bufio_writer (annotated, annotated_file)} {
+        if err != nil {
+            return err
+        }
+    }
+    write_prefix_sidecar_if_enabled (output_dir + "/targets.txt", records)
+
+    /* --- Packets consumed per VP and per day --- */
+    if model := get_cost_model (); model != nil {
+        ledger, unassigned := model.ledger (sorted_destinations, target_to_vp, strategy_traces)
+        if err := write_ledger (ledger, unassigned, output_dir + "/packet_ledger.txt"); err != nil {
+            return err
+        }
+    }
+
+    return write_file (output_dir + "/as_limits.txt", func (w *bufio.Writer) {
+        previous := 0
+        for _, limit := range limits_neighbors {
+            if limit.limit != previous {
+                w.WriteString (strconv.Itoa (limit.limit) + " " + limit.asn + "\n")
+            }
+            previous = limit.limit
+        }
+    })
+}
+
+/**
+ * Reads the Strategy Step output (directory dir) of an AS of interest, and returns a list of ordered
+ * targets and of AS delimitation, as well as the raw prefix in which each target was picked (when known).
+ * length: the length of the IPv4 targets (see target_length).
+ */
+func read_strategy (dir, as_interest string, length int) ([]string, []*AS_limit, map[string]string, error) {
+    /* --- Read targets (format: IP, or IP raw_prefix in the strategies written before raw_prefixes_file) --- */
+    raw_prefixes := make (map[string]string)
+    records, err := read_prefix_records (dir + "/" + as_interest + "/targets.txt") // From the sidecar, if up to date
+    if err != nil {
+        return nil, nil, nil, err
+    }
+    targets := make ([]string, 0, len (records))
+    for _, record := range records {
+        target := target_prefix_of (record.prefix, length) // Must add /24 (/48 for IPv6)
+        targets = append (targets, target)
+        if record.raw != "" {
+            raw_prefixes[target] = record.raw
+        }
+    }
+    if err := read_raw_prefixes (dir + "/" + as_interest + "/" + raw_prefixes_file, raw_prefixes, length); err != nil {
+        return nil, nil, nil, err
+    }
+
+    /* --- Read AS delimitations --- */
+    as_limits := make ([]*AS_limit, 0, 10)
+    limit_file := dir + "/" + as_interest + "/as_limits.txt"
+    reader := NewCompressedReader (limit_file)
+    if err := reader.Open (); err != nil {
+        return nil, nil, nil, err
+    }
+    defer reader.Close ()
+    scanner := reader.Scanner ()
+    for scanner.Scan () {
+        line := strings.Fields (scanner.Text ())
+        if len(line) < 2 {
+            return nil, nil, nil, fmt.Errorf ("%s: missing ASN: %s", limit_file, scanner.Text ())
+        }
+        n,_ := strconv.Atoi (line[0])
+        asn := line[1]
+        as_limits = append (as_limits, &AS_limit{asn:asn, limit:n})
+    }
+    if err := scanner.Err (); err != nil {
+        return nil, nil, nil, fmt.Errorf ("%s: %v", limit_file, err)
+    }
+
+    /* --- Safety net: strategies written before the deduplication of the targets --- */
+    targets, as_limits, duplicates := dedup_targets (targets, as_limits)
+    if duplicates != 0 {
+        log.Println ("[read_strategy]: AS", as_interest, ":", duplicates, "targets listed several times, later occurrences dropped")
+    }
+
+    return targets, as_limits, raw_prefixes, nil
+}
+
+/**
+ * Reads the raw prefix in which each target was picked (format: IP raw_prefix) into raw_prefixes
+ * (target /24 -> raw prefix). A missing file is not an error (strategies written before it).
+ */
+func read_raw_prefixes (filename string, raw_prefixes map[string]string, length int) error {
+    if _, err := os.Stat (filename); os.IsNotExist (err) {
+        return nil
+    }
+    reader := NewCompressedReader (filename)
+    if err := reader.Open (); err != nil {
+        return err
+    }
+    defer reader.Close ()
+    scanner := reader.Scanner ()
+    for scanner.Scan () {
+        fields := strings.Fields (scanner.Text ())
+        if len (fields) == 0 {
+            continue
+        }
+        if len (fields) != 2 {
+            return fmt.Errorf ("%s: invalid line (IP raw_prefix): %s", filename, scanner.Text ())
+        }
+        raw_prefixes[target_prefix_of (fields[0], length)] = fields[1]
+    }
+    if err := scanner.Err (); err != nil {
+        return fmt.Errorf ("%s: %v", filename, err)
+    }
+    return nil
+}
@@ -0,0 +1,92 @@
+package sim
+
+import (
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+    )
+
+/**
+ * A count of targets is written in the directory of the AS, or reported in the summary if it
+ * cannot be written.
+ */
+func TestWriteTargetCount (t *testing.T) {
+    dir := t.TempDir ()
+    summary.mux.Lock ()
+    summary.path, summary.run = filepath.Join (dir, "summary.json"), run_summary{}
+    summary.mux.Unlock ()
+    defer func () {
+        summary.mux.Lock ()
+        summary.path, summary.run = "", run_summary{}
+        summary.mux.Unlock ()
+    }()
+
+    as_dir := filepath.Join (dir, "100")
+    if err := os.Mkdir (as_dir, 0755); err != nil {
+        t.Fatal (err)
+    }
+    write_target_count (filepath.Join (as_dir, "nb_duplicate_targets.txt"), "100", 3)
+    if content, err := os.ReadFile (filepath.Join (as_dir, "nb_duplicate_targets.txt")); err != nil || string (content) != "3\n" {
+        t.Errorf ("count: %q (%v)", content, err)
+    }
+    if len (summary.run.Warnings) != 0 {
+        t.Errorf ("warnings: %v", summary.run.Warnings)
+    }
+    write_target_count (filepath.Join (dir, "200", "nb_unprobed_targets.txt"), "200", 1) // No directory for the AS
+    if len (summary.run.Warnings) != 1 {
+        t.Errorf ("warnings: %v, want the failed count", summary.run.Warnings)
+    }
+}
+
+/**
+ * The strategy of an AS fails if one of its files cannot be created, flushed or closed: the error names
+ * the file, and the AS is counted as failed.
+ */
+func TestWriteStrategyErrors (t *testing.T) {
+    ds, target_to_vp, destinations := load_strategy_universe (t)
+    strategy, err := strategy_index ("directed_probing_internal_neighbors_onehopneighbors_others")
+    if err != nil {
+        t.Fatal (err)
+    }
+    dir := t.TempDir ()
+    if err := write_strategy (strategy, ds, 42, "100", target_to_vp, dir, destinations); err != nil {
+        t.Fatal (err)
+    }
+    for _, file := range []string{"targets.txt", "targets_annotated.txt", "as_limits.txt"} {
+        if content, err := os.ReadFile (filepath.Join (dir, file)); err != nil || len (content) == 0 {
+            t.Errorf ("%s: %q %v", file, content, err)
+        }
+    }
+
+    /* --- as_limits.txt cannot be created --- */
+    dir = t.TempDir ()
+    if err := os.Mkdir (filepath.Join (dir, "as_limits.txt"), 0755); err != nil {
+        t.Fatal (err)
+    }
+    if err := write_strategy (strategy, ds, 42, "100", target_to_vp, dir, destinations); err == nil || !strings.Contains (err.Error (), "as_limits.txt") {
+        t.Errorf ("as_limits.txt not created: %v", err)
+    }
+
+    /* --- The annotations cannot be flushed (no space left on the device) --- */
+    if _, err := os.Stat ("/dev/full"); err == nil {
+        dir = t.TempDir ()
+        if err := os.Symlink ("/dev/full", filepath.Join (dir, "targets_annotated.txt")); err != nil {
+            t.Fatal (err)
+        }
+        if err := write_strategy (strategy, ds, 42, "100", target_to_vp, dir, destinations); err == nil || !strings.Contains (err.Error (), "targets_annotated.txt") {
+            t.Errorf ("targets_annotated.txt not flushed: %v", err)
+        }
+    }
+
+    /* --- The AS is counted as failed --- */
+    dir = t.TempDir ()
+    if err := os.MkdirAll (filepath.Join (dir, "100", "as_limits.txt"), 0755); err != nil {
+        t.Fatal (err)
+    }
+    failed := new (int64)
+    generate_anaximander_strategy (strategy, ds, 42, dir, target_to_vp, destinations, failed) ("100")
+    if *failed != 1 {
+        t.Errorf ("%d failed ASes, want 1", *failed)
+    }
+}
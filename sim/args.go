@@ -0,0 +1,791 @@
+/* ==================================================================================== *\
+    args.go
+
+    Program arguments handling
+\* ==================================================================================== */
+
+package sim
+
+import (
+  "errors"
+  "flag"
+  "log"
+  "runtime"
+  "strconv"
+  "strings"
+  "os"
+  "time"
+) 
+
+/* --------------------------------------- *\
+ *          VALIDATION
+\* --------------------------------------- */
+
+/**
+ * Checks the arguments before any parsing starts: the required flags must be given, and the
+ * files and directories given to the input flags must be readable (directories must not be empty).
+ * All the invalid arguments are reported at once.
+ */
+func validate_args (cmd *flag.FlagSet, required []string, inputs ...string) {
+  if invalid := invalid_args (cmd, required, inputs); len (invalid) != 0 {
+    log.Fatal ("Invalid arguments:\n  ", strings.Join (invalid, "\n  "))
+  }
+}
+
+/**
+ * Returns the invalid arguments (see validate_args). A name that is not a flag of the command
+ * (e.g., misspelled in the caller) is reported as such.
+ */
+func invalid_args (cmd *flag.FlagSet, required, inputs []string) []string {
+  invalid := make ([]string, 0)
+  value := func (name string) (string, bool) {
+    f := cmd.Lookup (name)
+    if f == nil {
+      invalid = append (invalid, "-" + name + ": not a flag of " + cmd.Name ())
+      return "", false
+    }
+    return f.Value.String (), true
+  }
+  for _, name := range required {
+    if v, ok := value (name); ok && v == "" {
+      invalid = append (invalid, "-" + name + ": missing")
+    }
+  }
+  for _, name := range inputs {
+    filename, ok := value (name)
+    if !ok || filename == "" {
+      continue
+    }
+    if err := check_readable (filename); err != nil {
+      invalid = append (invalid, "-" + name + ": " + err.Error ())
+    }
+  }
+  return invalid
+}
+
+/**
+ * Checks the length into which the IPv4 prefixes are broken (0: no break), and sets it as the length of the targets.
+ * Prefixes longer than /24 are not kept (see check_prefix_validity).
+ */
+func apply_break_len (break_len int) {
+  if break_len != 0 && (break_len < 8 || break_len > 24) {
+    log.Fatal ("Invalid arguments:\n  -break-len: ", break_len, " (0, or between 8 and 24)")
+  }
+  g_args.break_len = break_len
+}
+
+/**
+ * Returns an error if the file cannot be read, or if the directory is empty or cannot be listed.
+ */
+func check_readable (filename string) error {
+  f, err := os.Open (filename)
+  if err != nil {
+    return err
+  }
+  defer f.Close ()
+  info, err := f.Stat ()
+  if err != nil {
+    return err
+  }
+  if info.IsDir () {
+    if _, err := f.Readdirnames (1); err != nil {
+      return errors.New ("empty directory " + filename)
+    }
+  }
+  return nil
+}
+
+/* --------------------------------------- *\
+ *          RIB PARSING
+\* --------------------------------------- */
+
+/** 
+ * Handle the args for the Anaximander RIB parsing (count mode).
+ */
+func handle_args_rib_parsing_count (args []string) (_outputfile, _start, _end string) {
+  if len (args) <= 0 {
+    println ("Missing arguments")
+    os.Exit (-1)
+  }
+  cmd := flag.NewFlagSet(args[0], flag.ExitOnError)
+
+  cmd.StringVar(&_outputfile, "o", "", "The output file")
+  cmd.StringVar(&_start, "s", "", "The timestamp for the start of the interval at which to retrieve the BGP tables")
+  cmd.StringVar(&_end, "e", "", "The timestamp for the end of the interval at which to retrieve the BGP tables")
+
+  jobs_flags (cmd, "The number of workers of the pools", "ribs")
+  cmd.Parse(args[1:])
+  check_jobs ()
+  validate_args (cmd, []string{"o", "s", "e"})
+  return
+}
+
+/** 
+ * Handle the args for selecting the sound BGP collectors.
+ */
+func handle_args_rib_parsing_select (args []string) (_countsfile, _outputfile, _start, _end string, _min_entries int) {
+  if len (args) <= 0 {
+    println ("Missing arguments")
+    os.Exit (-1)
+  }
+  cmd := flag.NewFlagSet(args[0], flag.ExitOnError)
+
+  cmd.StringVar(&_countsfile, "i", "", "The output of 'rib_parsing count' (if not given, the entries are counted first, see -s and -e)")
+  cmd.StringVar(&_outputfile, "o", "", "The output file (sound collectors); the rejected ones are written in <output>_rejected.txt")
+  cmd.StringVar(&_start, "s", "", "The timestamp for the start of the interval at which to retrieve the BGP tables (without -i)")
+  cmd.StringVar(&_end, "e", "", "The timestamp for the end of the interval at which to retrieve the BGP tables (without -i)")
+  cmd.IntVar(&_min_entries, "min-entries", default_min_entries, "A collector is sound if its table has at least this many entries")
+
+  cmd.Parse(args[1:])
+  if _countsfile != "" {
+    validate_args (cmd, []string{"o"}, "i")
+  } else {
+    validate_args (cmd, []string{"o", "s", "e"})
+  }
+  return
+}
+
+/** 
+ * Handle the args for the Anaximander RIB parsing (multi mode).
+ */
+func handle_args_rib_parsing_multi (args []string) (_ases, _collectors, _outputdir, _start, _end string, _heuristic int) {
+  if len (args) <= 0 {
+    println ("Missing arguments")
+    os.Exit (-1)
+  }
+  cmd := flag.NewFlagSet(args[0], flag.ExitOnError)
+
+  cmd.StringVar(&_ases, "a", "", "The file containing the ASes of interest (one line, space separated)")
+  cmd.StringVar(&_collectors, "c", "", "The file containing the BGP collectors")
+  cmd.StringVar(&_outputdir, "o", "", "The output directory where to store results")
+  cmd.StringVar(&_start, "s", "", "The timestamp for the start of the interval at which to retrieve the BGP table")
+  cmd.StringVar(&_end, "e", "", "The timestamp for the end of the interval at which to retrieve the BGP table")
+
+  var heuristic, tiebreaks string
+  cmd.StringVar(&heuristic, "h", "valley_free", "The BGP decision process heuristic to apply: " + strings.Join (heuristic_names, ", ") + " (or their number)")
+  cmd.StringVar(&tiebreaks, "tiebreak", strings.Join (default_tiebreaks, ","), "Ordered, comma-separated tie-breaks between the routes left by the heuristic: popularity (valley_free only), shortest, most_ases_interest")
+  cmd.StringVar(&g_args.as_rel_file, "asrel", "", "CAIDA file containing the relationships between ASes")
+  cmd.Float64Var(&g_args.overlay_max_fraction, "overlay_warn", 0.01, "Warn when an overlay group contains more than this fraction of all prefixes")
+  cmd.StringVar(&g_args.bogon_asn_policy, "bogon_asn", "strip", "What to do with reserved ASNs (0, private, documentation...) in AS paths: strip them, or drop the entry")
+  cmd.IntVar(&g_args.max_as_path_length, "max_path_len", 64, "Entries whose AS path (prepending collapsed) is longer are dropped (0: no limit)")
+  cmd.BoolVar(&g_args.keep_prepending, "keep-prepending", false, "Do not collapse AS prepending before extracting the next/previous-hop ASes (reproduces the outputs of older versions)")
+  cmd.BoolVar(&g_args.ipv6, "6", false, "Also parse the IPv6 prefixes (/16 to /48, IPv6 reserved prefixes excluded)")
+  cmd.StringVar(&g_args.mrt_dir, "mrt-dir", "", "Read the RIB dumps from this directory (one sub-directory per collector, RouteViews rib.* or RIS bview.* files) instead of bgpreader")
+
+  cmd.IntVar(&g_args.rib_window, "rib_window", 100000, "The entries of a prefix are gathered until the prefix has not been seen for this many records (dumps not grouped by prefix)")
+  cmd.IntVar(&g_args.min_entries, "min-entries", 0, "If > 0, -c is an output of 'rib_parsing count' (or select_collectors), and the collectors with fewer entries are skipped")
+  cmd.BoolVar(&g_args.compress, "compress", false, "Write the forwarding tables, overlays and next/previous-hop ASes of each collector gzip-compressed (.gz)")
+  cmd.StringVar(&g_args.fetch_dir, "fetch", "", "Download the RIB dumps from the RouteViews/RIS archives into this cache directory, then read them (see -mrt-dir)")
+  cmd.BoolVar(&g_args.per_peer, "per-peer", false, "Also write a forwarding table and its overlays per BGP peer of each collector (forwarding_tables/<collector>/<peer>.txt, overlays/<collector>/<peer>.txt)")
+  cmd.BoolVar(&g_args.resume, "resume", false, "Do not parse again the collectors completed by a previous run in the output directory (see rib_checkpoint.go)")
+  cmd.BoolVar(&g_args.keep_records, "keep-records", false, "Also keep the RIB records of each collector (records/<collector>.txt.gz), so that add_as can add ASes of interest without the dumps")
+  summary_flag (cmd)
+
+  jobs_flags (cmd, "The number of workers of the pools", "ribs")
+  cmd.Parse(args[1:])
+  check_jobs ()
+  if g_args.fetch_dir != "" && g_args.mrt_dir != "" {
+    log.Fatal ("-fetch and -mrt-dir are exclusive (-fetch reads the dumps from its cache directory)")
+  }
+  if g_args.mrt_dir != "" { // -s and -e only select the dump among the files of a collector
+    validate_args (cmd, []string{"a", "c", "o"}, "a", "c", "asrel", "mrt-dir")
+  } else {
+    validate_args (cmd, []string{"a", "c", "o", "s", "e"}, "a", "c", "asrel")
+  }
+  if g_args.bogon_asn_policy != "strip" && g_args.bogon_asn_policy != "drop" {
+    log.Fatal ("Unknown -bogon_asn policy: ", g_args.bogon_asn_policy, " (strip or drop)")
+  }
+  var err error
+  if _heuristic, err = parse_heuristic (heuristic); err != nil {
+    log.Fatal (err)
+  }
+  if g_args.tiebreaks, err = parse_tiebreaks (tiebreaks, _heuristic); err != nil {
+    log.Fatal (err)
+  }
+  log.Printf ("BGP heuristic: %s, tie-breaks: %s", heuristic_names[_heuristic], strings.Join (g_args.tiebreaks, ","))
+  default_summary_out (_outputdir + "/summary.json")
+  return
+}
+
+/** 
+ * Handle the args for building the BDP.
+ */
+func handle_args_rib_parsing_build (args []string) (_outputdir, _ases, _collectors, _datadir string) {
+  if len (args) <= 0 {
+    println ("Missing arguments")
+    os.Exit (-1)
+  }
+  cmd := flag.NewFlagSet(args[0], flag.ExitOnError)
+
+  cmd.StringVar(&_ases, "a", "", "The file containing the ASes of interest (one line, space separated)")
+  cmd.StringVar(&_collectors, "c", "", "The file containing the BGP collectors")
+  cmd.StringVar(&_outputdir, "o", "", "The output directory where to store results")
+  cmd.StringVar(&_datadir, "d", "", "The directory where to find the necessary information for building the BDP (output directory of previous step)")
+  cmd.BoolVar(&g_args.write_sidecars, "bin", false, "Also write a binary sidecar (.bin) of the directed prefixes, faster to load")
+  cmd.BoolVar(&g_args.ipv6, "6", false, "Also keep the IPv6 prefixes in the directed prefixes")
+  summary_flag (cmd)
+
+  cmd.Parse(args[1:])
+  validate_args (cmd, []string{"a", "c", "o", "d"}, "a", "c", "d")
+  default_summary_out (_outputdir + "/summary.json")
+  return
+}
+
+/** 
+ * Handle the args for adding ASes of interest to the output of ribs_multi.
+ */
+func handle_args_rib_parsing_add_as (args []string) (_dir string, _ases []string, _bdp_dir string) {
+  if len (args) <= 0 {
+    println ("Missing arguments")
+    os.Exit (-1)
+  }
+  cmd := flag.NewFlagSet(args[0], flag.ExitOnError)
+
+  var ases string
+  cmd.StringVar(&_dir, "d", "", "The output directory of ribs_multi, run with -keep-records (its heuristic and parameters are used again)")
+  cmd.StringVar(&ases, "as", "", "The new ASes of interest (comma or space separated)")
+  cmd.StringVar(&_bdp_dir, "o", "", "If given, the output directory where to write the directed prefixes of the new ASes")
+  cmd.BoolVar(&g_args.write_sidecars, "bin", false, "Also write a binary sidecar (.bin) of the directed prefixes, faster to load")
+  cmd.BoolVar(&g_args.ipv6, "6", false, "Also keep the IPv6 prefixes in the directed prefixes")
+
+  jobs_flags (cmd, "The number of workers of the pools", "ribs")
+  cmd.Parse(args[1:])
+  check_jobs ()
+  validate_args (cmd, []string{"d", "as"}, "d")
+  _ases = strings.FieldsFunc (ases, func (r rune) bool { return r == ',' || r == ' ' })
+  return
+}
+
+/* --- MISC. ---*/
+
+func handle_args_rib_parsing_ribs (args []string) (_ases, _collectors, _outputfile string, _break_len int, _start, _end string) {
+  if len (args) <= 0 {
+    println ("Missing arguments")
+    os.Exit (-1)
+  }
+  cmd := flag.NewFlagSet(args[0], flag.ExitOnError)
+
+  cmd.StringVar(&_ases, "a", "", "The AS of interest")
+  cmd.StringVar(&_collectors, "c", "", "The file containing the BGP collectors")
+  cmd.StringVar(&_outputfile, "o", "", "The output file")
+  cmd.IntVar (&_break_len, "break-len", 0, "Break RIB's IPv4 prefixes into prefixes of this length (e.g. 24; 0: no break). IPv6 prefixes are broken into /48")
+  cmd.BoolVar(&g_args.ipv6, "6", false, "Also parse the IPv6 prefixes (/16 to /48, IPv6 reserved prefixes excluded)")
+  cmd.StringVar(&_start, "s", "", "The timestamp for the start of the interval at which to retrieve the BGP table")
+  cmd.StringVar(&_end, "e", "", "The timestamp for the end of the interval at which to retrieve the BGP table")
+  cmd.BoolVar(&g_args.aspath_prefilter, "aspath-prefilter", false, "Pre-filter the entries with a regex on the AS path (bgpreader -A), faster; the AS path is matched exactly anyway")
+  jobs_flags (cmd, "The number of workers of the pools", "ribs")
+  cmd.Parse(args[1:])
+  check_jobs ()
+  validate_args (cmd, []string{"a", "c", "o", "s", "e"}, "c")
+  apply_break_len (_break_len)
+  return
+}
+
+func handle_args_rib_parsing_analyser (args []string) (_outputfile, _collectors_file, _relfile, _start, _end string) {
+  if len (args) <= 0 {
+    println ("Missing arguments")
+    os.Exit (-1)
+  }
+  cmd := flag.NewFlagSet(args[0], flag.ExitOnError)
+
+  cmd.StringVar(&_outputfile, "o", "", "The output file")
+  cmd.StringVar(&_start, "s", "", "The timestamp for the start of the interval at which to retrieve the BGP table")
+  cmd.StringVar(&_end, "e", "", "The timestamp for the end of the interval at which to retrieve the BGP table")
+  cmd.StringVar(&_collectors_file, "c", "", "The file containing all collectors")
+  cmd.StringVar(&_relfile, "r", "", "The file containing all ASes relationships")
+
+  jobs_flags (cmd, "The number of workers of the pools", "ribs")
+  cmd.Parse(args[1:])
+  check_jobs ()
+  validate_args (cmd, []string{"o", "s", "e", "c", "r"}, "c", "r")
+  return
+}
+
+func handle_args_fib_parsing_analyser (args []string) (_datadir, _collectors_file, _relfile, _outputfile string) {
+  if len (args) <= 0 {
+    println ("Missing arguments")
+    os.Exit (-1)
+  }
+  cmd := flag.NewFlagSet(args[0], flag.ExitOnError)
+
+  cmd.StringVar(&_outputfile, "o", "", "The output file")
+  cmd.StringVar(&_collectors_file, "c", "", "The file containing all collectors")
+  cmd.StringVar(&_relfile, "r", "", "The file containing all ASes relationships")
+  cmd.StringVar(&_datadir, "d", "", "The data dir")
+  cmd.Parse(args[1:])
+  validate_args (cmd, []string{"o", "c", "r", "d"}, "c", "r", "d")
+  return
+}
+
+/* --------------------------------------- *\
+ *          COST MODEL
+\* --------------------------------------- */
+
+/**
+ * Registers the flags of the campaign cost model (shared by the strategy and the simulation).
+ */
+func cost_model_flags (cmd *flag.FlagSet) {
+  cmd.IntVar (&g_args.attempts, "attempts", 2, "The number of attempts per hop of a traceroute (cost model)")
+  cmd.IntVar (&g_args.max_ttl, "max_ttl", 30, "The path length assumed for targets without trace (cost model)")
+  cmd.StringVar (&g_args.vp_caps_file, "vp_caps", "", "File giving the daily packet cap of each VP (format: VP_IP cap). If set, a per-VP per-day packet ledger is written")
+}
+
+/**
+ * Adds the -summary_out flag (see summary.go).
+ */
+func summary_flag (cmd *flag.FlagSet) {
+  cmd.StringVar(&g_args.summary_out, "summary_out", "", "Where to write the machine-readable summary of the run (default: summary.json in the output directory)")
+}
+
+/**
+ * Adds the -force flag (see manifest.go).
+ */
+func force_flag (cmd *flag.FlagSet) {
+  cmd.BoolVar(&g_args.force, "force", false, "Write the results in the output directory even if it holds the results of another run (manifest.json)")
+}
+
+/**
+ * Adds the -profile and -pprof flags (see profile.go).
+ */
+func profile_flags (cmd *flag.FlagSet) {
+  cmd.StringVar(&g_args.profile_dir, "profile", "", "Write the CPU profile of the run and a heap profile at the end of each stage in this directory (see 'go tool pprof')")
+  cmd.StringVar(&g_args.pprof_addr, "pprof", "", "Serve the profiles of net/http/pprof on this address while the run goes on (e.g. localhost:6060)")
+}
+
+/**
+ * Adds -j, the number of workers of the pools, and its overrides for the given phases: "warts" (-j-warts)
+ * and "ribs" (-j-ribs), whose workers each run an external process (sc_tnt, bgpreader). Adds -quiet,
+ * which disables the progress of the pools (see progress.go), and for "ribs" the timeout and the retries
+ * of the collectors (see collector_retry.go).
+ */
+func jobs_flags (cmd *flag.FlagSet, jobs_help string, phases ...string) {
+  cmd.IntVar (&g_args.jobs, "j", 0, jobs_help + " (0: one per CPU, see GOMAXPROCS)")
+  cmd.BoolVar (&g_args.quiet, "quiet", false, "Do not print the progress of the long-running phases on stderr")
+  for _, phase := range phases {
+    switch phase {
+      case "warts":
+        cmd.IntVar (&g_args.jobs_warts, "j-warts", 0, "The number of warts files parsed at the same time (0: -j if given, otherwise " + strconv.Itoa (default_external_jobs) + ", as many sc_tnt processes)")
+      case "ribs":
+        cmd.IntVar (&g_args.jobs_ribs, "j-ribs", 0, "The number of collectors parsed at the same time (0: -j if given, otherwise " + strconv.Itoa (default_external_jobs) + ", as many bgpreader processes)")
+        cmd.DurationVar (&g_args.collector_timeout, "collector-timeout", 2 * time.Hour, "bgpreader is killed after this time on a collector, which is then retried (0: no timeout)")
+        cmd.IntVar (&g_args.collector_retries, "collector-retries", 2, "The number of times a failed collector is read again, with a backoff (" + collector_backoff.String () + ", doubled at each retry)")
+    }
+  }
+}
+
+func check_jobs () {
+  if g_args.jobs < 0 || g_args.jobs_warts < 0 || g_args.jobs_ribs < 0 {
+    log.Fatal ("-j, -j-warts and -j-ribs must be >= 0")
+  }
+  if g_args.collector_timeout < 0 || g_args.collector_retries < 0 {
+    log.Fatal ("-collector-timeout and -collector-retries must be >= 0")
+  }
+}
+
+const default_external_jobs = 16 // The pools whose workers run an external process (sc_tnt, bgpreader)
+
+/**
+ * Returns the number of workers of a pool: the override of its phase if given (phase_jobs, e.g.,
+ * -j-warts), otherwise -j if given, otherwise default_jobs.
+ */
+func nb_jobs (phase_jobs, default_jobs int) int {
+  if phase_jobs > 0 {
+    return phase_jobs
+  }
+  if g_args.jobs > 0 {
+    return g_args.jobs
+  }
+  return default_jobs
+}
+
+/**
+ * Returns the number of workers of a CPU-bound pool (-j, by default one per CPU).
+ */
+func cpu_jobs () int {
+  return nb_jobs (0, runtime.GOMAXPROCS (0))
+}
+
+func warts_jobs () int {
+  return nb_jobs (g_args.jobs_warts, default_external_jobs)
+}
+
+func ribs_jobs () int {
+  return nb_jobs (g_args.jobs_ribs, default_external_jobs)
+}
+
+/**
+ * Sets the path of the summary, if not given with -summary_out.
+ */
+func default_summary_out (path string) {
+  if g_args.summary_out == "" {
+    g_args.summary_out = path
+  }
+}
+
+/**
+ * Exits if the value of -trace-format is unknown.
+ */
+func check_trace_format () {
+  switch g_args.trace_format {
+    case trace_format_auto, trace_format_tnt, trace_format_json:
+    default:
+      log.Fatal ("Unknown -trace-format: ", g_args.trace_format, " (auto, tnt or json)")
+  }
+}
+
+func check_loops () {
+  switch g_args.loops {
+    case loops_truncate, loops_remove, loops_keep:
+    default:
+      log.Fatal ("Unknown -loops: ", g_args.loops, " (truncate-at-loop, remove-loop or keep)")
+  }
+}
+
+func check_trace_vp () {
+  switch g_args.trace_vp {
+    case trace_vp_any, trace_vp_assigned:
+    default:
+      log.Fatal ("Unknown -trace_vp: ", g_args.trace_vp, " (any or assigned)")
+  }
+}
+
+func check_border () {
+  switch g_args.border {
+    case border_asn, border_conn_asn:
+    default:
+      log.Fatal ("Unknown -border: ", g_args.border, " (asn or conn_asn)")
+  }
+}
+
+/* --------------------------------------- *\
+ *          ANAXIMANDER STRATEGY
+\* --------------------------------------- */
+
+func handle_args_strategy (args []string) (break_len int, strategy int, output_dir string) {
+  //output_on = false
+  if len (args) <= 1 {
+    println ("Missing arguments")
+    os.Exit (-1)
+  }
+  cmd := flag.NewFlagSet(args[0], flag.ExitOnError)
+
+  strategy = -1
+  cmd.Var(&strategy_value{&strategy}, "s", "The probing strategy: name or index (see './anaximander strategy list')")
+  strategy_flags (cmd, &break_len, &output_dir)
+  cmd.BoolVar(&g_args.write_sidecars, "bin", false, "Also write a binary sidecar (.bin) of the targets, faster to load")
+  cmd.Var(&deadline_value{&g_args.deadline}, "deadline", "Skip the ASes that would not complete before this deadline: a duration (e.g. 3h30m) or a time (e.g. 2026-01-02T15:04)")
+  cmd.StringVar(&g_args.diff_old_dir, "diff_old", "", "Output directory of a previous ribs_multi: with -diff_new, the targets whose routes changed come first")
+  cmd.StringVar(&g_args.diff_new_dir, "diff_new", "", "Output directory of the current ribs_multi (see -diff_old)")
+  cmd.StringVar(&g_args.target_as_allowlist, "target_as_allowlist", "", "File of the ASNs whose prefixes may be targeted (the AS of interest is always allowed)")
+  cmd.BoolVar(&g_args.only_probed, "only-probed", false, "Only write the targets that have a trace in the warts data set (needs -warts, -vps and -bdr)")
+  jobs_flags (cmd, "The number of ASes of interest processed concurrently, and of workers of the other pools ", "warts")
+  summary_flag (cmd)
+  profile_flags (cmd)
+  force_flag (cmd)
+
+  dump := parse_args_with_config (cmd, args[1:])
+  apply_break_len (break_len)
+  if strategy < 0 {
+    log.Fatal ("Missing strategy -s (see './anaximander strategy list')")
+  }
+  required := append ([]string{"ases", "asrel", "ppdc", "ip2as", "o"}, strategies[strategy].inputs...)
+  if g_args.diff_old_dir != "" || g_args.diff_new_dir != "" { // Both snapshots are needed
+    required = append (required, "diff_old", "diff_new")
+  }
+  if g_args.only_probed { // The traces come from the warts data set
+    required = append (required, "warts", "vps", "bdr")
+  }
+  validate_args (cmd, required, append ([]string{"diff_old", "diff_new", "target_as_allowlist"}, strategy_input_flags...)...)
+  if g_args.vp_collectors_file != "" {
+    check_vp_collectors ()
+  }
+  if dump {
+    dump_config (cmd, output_dir + "/run_config.json")
+  }
+  check_trace_format ()
+  check_loops ()
+  check_trace_vp ()
+  check_jobs ()
+  default_summary_out (output_dir + "/summary.json")
+  return
+}
+
+/**
+ * Checks that every VP of -vps has a collector in -vp_collectors, before the warts are parsed.
+ */
+func check_vp_collectors () {
+  vp_collectors, err := read_vp_collectors (g_args.vp_collectors_file)
+  if err != nil {
+    log.Fatal ("Invalid arguments:\n  -vp_collectors: ", err)
+  }
+  if g_args.vps_file == "" {
+    return
+  }
+  vps_list, err := read_vps_file (g_args.vps_file)
+  if err != nil {
+    log.Fatal ("Invalid arguments:\n  -vps: ", err)
+  }
+  if missing := vps_without_collector (vps_list, vp_collectors); len (missing) != 0 {
+    log.Fatal ("Invalid arguments:\n  -vp_collectors: no collector assigned to the VPs: ", strings.Join (missing, " "))
+  }
+}
+
+/**
+ * Handle the args for explaining the position of an AS in the probing order of a strategy.
+ */
+func handle_args_explain (args []string) (break_len int, strategy int, as_interest, asn string) {
+  if len (args) <= 1 {
+    println ("Missing arguments")
+    os.Exit (-1)
+  }
+  cmd := flag.NewFlagSet(args[0], flag.ExitOnError)
+
+  strategy = -1
+  cmd.Var(&strategy_value{&strategy}, "s", "The probing strategy: name or index (see './anaximander strategy list')")
+  cmd.StringVar(&as_interest, "as", "", "The AS of interest")
+  cmd.StringVar(&asn, "x", "", "The AS whose position in the probing order must be explained")
+  var output_dir string
+  strategy_flags (cmd, &break_len, &output_dir)
+
+  cmd.Parse(args[1:])
+  apply_break_len (break_len)
+  if strategy < 0 {
+    log.Fatal ("Missing strategy -s (see './anaximander strategy list')")
+  }
+  validate_args (cmd, append ([]string{"as", "x", "asrel", "ppdc", "ip2as"}, strategies[strategy].inputs...), strategy_input_flags...)
+  return
+}
+
+/**
+ * Handle the args for measuring the influence of each dataset on the order of the targets.
+ */
+func handle_args_influence (args []string) (break_len int, strategy int, as_interest string) {
+  if len (args) <= 1 {
+    println ("Missing arguments")
+    os.Exit (-1)
+  }
+  cmd := flag.NewFlagSet(args[0], flag.ExitOnError)
+
+  strategy = -1
+  cmd.Var(&strategy_value{&strategy}, "s", "The probing strategy: name or index (see './anaximander strategy list')")
+  cmd.StringVar(&as_interest, "as", "", "The AS of interest")
+  var output_dir string
+  strategy_flags (cmd, &break_len, &output_dir)
+
+  cmd.Parse(args[1:])
+  apply_break_len (break_len)
+  if strategy < 0 {
+    log.Fatal ("Missing strategy -s (see './anaximander strategy list')")
+  }
+  validate_args (cmd, append ([]string{"as", "asrel", "ppdc", "ip2as"}, strategies[strategy].inputs...), strategy_input_flags...)
+  return
+}
+
+/**
+ * Handle the args for aggregating the discovery curves of a simulation (see curves_analysis.go).
+ */
+func handle_args_aggregate_curves (args []string) (results_dir, output_prefix, axis string, points int) {
+  if len (args) <= 1 {
+    println ("Missing arguments")
+    os.Exit (-1)
+  }
+  cmd := flag.NewFlagSet(args[0], flag.ExitOnError)
+
+  cmd.StringVar(&results_dir, "d", "", "The directory of the results of the simulation (sorted_<output_file>_<AS>.txt)")
+  cmd.StringVar(&output_prefix, "o", "", "The prefix of the output files (<prefix>_curves.csv and <prefix>_auc.csv)")
+  cmd.StringVar(&axis, "axis", axis_fraction, "The probe axis: fraction (of the probes of each AS, in [0,1]) or probes (absolute number of probes)")
+  cmd.IntVar(&points, "points", 101, "The number of points of the common grid")
+
+  cmd.Parse(args[1:])
+  validate_args (cmd, []string{"d", "o"}, "d")
+  if axis != axis_fraction && axis != axis_probes {
+    log.Fatal ("-axis must be fraction or probes")
+  }
+  if points < 1 {
+    log.Fatal ("-points must be >= 1")
+  }
+  return
+}
+
+/**
+ * Handle the args for comparing two simulation runs (see compare_runs.go).
+ */
+func handle_args_compare_runs (args []string) (dir_a, dir_b, output_prefix, strategy_a, strategy_b string, tolerance float64) {
+  cmd := flag.NewFlagSet(args[0], flag.ExitOnError)
+
+  cmd.StringVar(&output_prefix, "o", "", "The prefix of the CSV outputs (<prefix>.csv and <prefix>_limits.csv), none by default")
+  cmd.StringVar(&strategy_a, "strategy_a", "", "The strategy directory of run A, to name the groups of the limits after their AS")
+  cmd.StringVar(&strategy_b, "strategy_b", "", "The strategy directory of run B (see -strategy_a)")
+  cmd.Float64Var(&tolerance, "tol", 0.01, "An AS regressed if run B is worse than run A by more than this on a final level or an AUC")
+
+  cmd.Parse(args[1:])
+  if cmd.NArg () != 2 {
+    log.Fatal ("Usage: ./anaximander analysis compare_runs [flags] <dir_a> <dir_b>")
+  }
+  dir_a, dir_b = cmd.Arg (0), cmd.Arg (1)
+  if tolerance < 0 {
+    log.Fatal ("-tol must be >= 0")
+  }
+  return
+}
+
+/**
+ * Handle the args for reporting the partial BGP peers of the collectors.
+ */
+func handle_args_peer_coverage (args []string) (input, output_file string, min_prefixes int, fraction float64) {
+  cmd := flag.NewFlagSet(args[0], flag.ExitOnError)
+
+  cmd.StringVar(&output_file, "o", "", "The file where the partial peers are written ([collector peer_asn peer_ip n_prefixes]), none by default")
+  cmd.IntVar(&min_prefixes, "min", 0, "A peer that contributed fewer prefixes is partial")
+  cmd.Float64Var(&fraction, "fraction", 0.5, "A peer that contributed fewer than this fraction of the prefixes of the largest peer of its collector is partial")
+
+  cmd.Parse(args[1:])
+  if cmd.NArg () != 1 {
+    log.Fatal ("Usage: ./anaximander analysis peer_coverage [flags] <ribs_dir | peers_file>")
+  }
+  input = cmd.Arg (0)
+  if min_prefixes < 0 || fraction < 0 || fraction > 1 {
+    log.Fatal ("-min must be >= 0, and -fraction in [0, 1]")
+  }
+  return
+}
+
+// The input files and directories of the commands applying strategies
+var strategy_input_flags = []string{"ases", "asrel", "ppdc", "ip2as", "dp_dir", "overlays_file", "overlays_dir", "vp_collectors", "nexthop_dir", "as2org", "oracle_dir", "bdr", "warts", "vps", "vp_caps"}
+
+/**
+ * Registers the flags shared by all the commands applying strategies.
+ */
+func strategy_flags (cmd *flag.FlagSet, break_len *int, output_dir *string) {
+  cmd.IntVar (break_len, "break-len", 0, "Break the prefixes of ip2as into prefixes of this length, which is also the length of the targets (e.g. 24; 0: no break, /24 targets)")
+  cmd.Int64Var (&g_args.seed, "seed", 0, "The seed of the random numbers, to replay a run (0: chosen from the clock and logged)")
+
+  cmd.StringVar(&g_args.ases_interest_file, "ases", "", "The file containing the ASes of interest (one line, space separated)")
+  cmd.StringVar(&g_args.as_rel_file, "asrel", "", "CAIDA file containing the relationships between ASes")
+  cmd.StringVar(&g_args.ppdc_file, "ppdc", "", "CAIDA file containing the customer cones of ASes")
+  cmd.StringVar(&g_args.ip2as_file, "ip2as", "", "Output of ip2as.py CAIDA script.")
+  cmd.StringVar(&g_args.directed_prefixes_dir, "dp_dir", "", "The directory containing the directed prefixes (output of rib_parsing)")
+  cmd.StringVar(&g_args.overlays_global_file, "overlays_file", "", "The file containing all merged overlays (output of rib_parsing)")
+  cmd.StringVar(&g_args.overlays_dir, "overlays_dir", "", "The directory containing the overlays of each collector (overlays_<collector>.txt, output of rib_parsing)")
+  cmd.StringVar(&g_args.vp_collectors_file, "vp_collectors", "", "The file giving the collector of each VP (format: VP_IP collector), for the per-VP overlays of -overlays_dir")
+  cmd.StringVar(&g_args.nexthop_as_dir_global, "nexthop_dir", "", "The directory containing the merged next-hop ASes (merged_next_AS_<AS>.txt)")
+  cmd.StringVar(&g_args.as2org_file, "as2org", "", "CAIDA AS2Org file (as-org2info format), needed by the sibling-aware strategies")
+  cmd.StringVar(&g_args.oracle_prefixes_dir, "oracle_dir", "", "The directory containing the successful traces of a previous simulation (successful_traces_<AS>.txt)")
+  cmd.StringVar(output_dir, "o", "", "The output directory where to write the list of targets and the delimitations between ASes")
+  
+  /* Apply the strategy to a given warts data set (not mandatory) */
+  cmd.StringVar(&g_args.bdrmapit_file, "bdr", "", "bdrmapit annotation file (sqlite, or .csv/.csv.gz)")
+  cmd.StringVar(&g_args.warts_directory, "warts", "", "The directory containing the warts")
+  cmd.StringVar (&g_args.vps_file, "vps", "", "The file containing all VPs and their characteristics")
+  cmd.StringVar (&g_args.duplicate_destinations, "dup_dest", "keep_last", "The policy when several traces target the same /24 (keep_last or keep_lowest_rtt)")
+  cmd.StringVar (&g_args.trace_format, "trace-format", trace_format_auto, "The format of the trace files: tnt (warts, decoded with sc_tnt), json (output of sc_warts2json), or auto (json for .json and .json.gz files)")
+  cmd.StringVar (&g_args.trace_vp, "trace_vp", trace_vp_any, "The trace of a /24 probed by several VPs: any (the one kept by -dup_dest) or assigned (the one of the VP the /24 is assigned to, the first of its VPs as in the packet ledger)")
+  cmd.BoolVar (&g_args.no_cache, "no-cache", false, "Parse the warts again instead of loading the result of a previous run with the same inputs from the cache (the cache is neither read nor written)")
+  cmd.StringVar (&g_args.loops, "loops", loops_truncate, "The routing loops of the traces (an address seen again after other addresses): truncate-at-loop (the trace stops before the loop), remove-loop (the hops of the loop are removed) or keep (historical behavior)")
+  cmd.StringVar (&g_args.overlay_metric, "overlay_metric", "any", "How to select the representative of an overlay group (any, or rtt to keep the target with the lowest RTT to the AS of interest)")
+  cost_model_flags (cmd)
+}
+
+/* --------------------------------------- *\
+ *          ANAXIMANDER SIMULATION
+\* --------------------------------------- */
+
+func handle_args_simulation (args []string) (break_len int, output_file string, simulation_mode int){
+  if len (args) <= 1 {
+    println ("Missing arguments")
+    os.Exit (-1)
+  }
+  cmd := flag.NewFlagSet(args[0], flag.ExitOnError)
+
+  /* --- Simulation data --- */
+  cmd.StringVar(&g_args.ases_interest_file, "ases", "", "The file containing the ASes of interest (one line, space separated)")
+  cmd.StringVar(&g_args.bdrmapit_file, "bdr", "", "The output of bdrmapit (sqlite, or .csv/.csv.gz)")
+  cmd.StringVar(&g_args.warts_directory, "warts", "", "The directory containing the warts")
+  cmd.StringVar (&g_args.duplicate_destinations, "dup_dest", "keep_last", "The policy when several traces target the same /24 (keep_last or keep_lowest_rtt)")
+  cmd.StringVar (&g_args.trace_format, "trace-format", trace_format_auto, "The format of the trace files: tnt (warts, decoded with sc_tnt), json (output of sc_warts2json), or auto (json for .json and .json.gz files)")
+  cmd.StringVar (&g_args.trace_vp, "trace_vp", trace_vp_any, "The trace of a /24 probed by several VPs: any (the one kept by -dup_dest) or assigned (the one of the VP the /24 is assigned to, the first of its VPs as in the packet ledger)")
+  cmd.BoolVar (&g_args.no_cache, "no-cache", false, "Parse the warts again instead of loading the result of a previous run with the same inputs from the cache (the cache is neither read nor written)")
+  cmd.StringVar (&g_args.loops, "loops", loops_truncate, "The routing loops of the traces (an address seen again after other addresses): truncate-at-loop (the trace stops before the loop), remove-loop (the hops of the loop are removed) or keep (historical behavior)")
+  cmd.StringVar (&g_args.border, "border", border_asn, "The inter-AS links: asn (the bdrmapit ASes of both ends differ, as in the published results) or conn_asn (also the links bdrmapit connects to another AS, credited to both ASes)")
+    
+  /* --- Simulation parameters --- */
+  cmd.StringVar (&g_args.strategy, "strategy", "", "The directory where to find the targets and the AS delimitations for each AS of interest")
+  cmd.StringVar(&output_file, "o", "", "Output file")
+  g_args.thresholds = threshold_list{1}
+  cmd.Var (&g_args.thresholds, "t", "The threshold (tau) to apply. With several thresholds separated by commas (e.g. 0.1,0.2,1), the datasets are read once and the results of each threshold go to t_<threshold>/ next to the output file")
+  cmd.Int64Var (&g_args.seed, "seed", 0, "The seed of the random numbers, to replay a run (0: chosen from the clock and logged)")
+  cmd.StringVar (&g_args.plateau_metric, "plateau_metric", "any", "Which discoveries reset the plateau: any, adjs, addresses, routers, or addresses+routers (the results still report all metrics)")
+  cmd.StringVar (&g_args.missing_traces, "missing-traces", missing_count, "Targets without trace: count (a probe without discovery), skip (no probe, the plateau is unchanged) or drop (removed from the strategy beforehand)")
+  cmd.StringVar (&g_args.credit_mode, "credit_mode", credit_pessimistic, "The credit of targets without trace: pessimistic (no discovery), fractional (trace of another traced /24 of the same raw prefix, reported alongside the pessimistic bound), or nearest_sibling (trace of the nearest traced /24 of the same raw prefix, see -sibling_distance)")
+  cmd.IntVar (&g_args.sibling_distance, "sibling_distance", 1, "In nearest_sibling credit mode, the maximum distance (in /24s) of the traced /24 whose trace is inherited")
+  jobs_flags (cmd, "The number of ASes of interest simulated concurrently, and of workers of the other pools", "warts")
+  cmd.IntVar (&g_args.router_k, "router-k", 2, "A router is discovered once this many of its addresses have been seen (1: any address)")
+  cmd.Var (&g_args.budget, "budget", "The maximum number of probes per AS of interest: a number of probes (e.g. 50000) or a fraction of the targets of its strategy (e.g. 0.5). The plateau may stop the probing before")
+  cmd.BoolVar (&g_args.checkpoint, "checkpoint", false, "Record the ASes whose simulation is complete in <output_dir>/checkpoint.json, so that an interrupted run can be resumed (-resume)")
+  cmd.BoolVar (&g_args.resume, "resume", false, "Skip the ASes whose simulation is recorded as complete in <output_dir>/checkpoint.json (refused if an input file or a parameter of the simulation changed)")
+  
+  /* --- Other simulations mode --- */
+  cmd.IntVar (&break_len, "break-len", 0, "The length of the targets of the strategy (see strategy -break-len; 0: /24), and of the prefixes of ip2as in the greedy and parallel modes (0: no break)")
+  cmd.BoolVar (&succesfull_traces_on, "", false, "True to record succesfull traces, False to not record them. (use form -flag=x for boolean flags)")
+  cmd.BoolVar (&discovery_attribution_on, "attribution", false, "Record which probe first discovered each address, adjacency and router (sequential simulation, memory hungry)")
+  cmd.IntVar (&simulation_mode, "m", 0, "The simulation mode (sequential, parallel, or greedy)")
+  cmd.StringVar(&g_args.as_rel_file, "asrel", "", "CAIDA file containing the relationships between ASes")
+  cmd.StringVar(&g_args.ppdc_file, "ppdc", "", "CAIDA file containing the customer cones of ASes")
+  cmd.StringVar(&g_args.ip2as_file, "ip2as", "", "Output of ip2as.py CAIDA script.")
+  var w_string string
+  cmd.StringVar (&w_string, "w", "", "The weighting function to use and its parameters (parallel simulation), by name (ex: -w inverse:0.05, -w discovery_rate:10,0.5) or by number (ex: -w 2-0.1-0.2 is to use function 2 with parameters 0.1 and 0.2)")
+  cmd.IntVar (&g_args.greedy_patience, "greedy-patience", 1, "Greedy simulation: move on to the next AS after this many consecutive probes without discovery")
+  cost_model_flags (cmd)
+  summary_flag (cmd)
+  profile_flags (cmd)
+  force_flag (cmd)
+  cmd.StringVar(&g_args.sqlite_file, "sqlite", "", "Also write the results (one run per AS, and its discovery curve) in this SQLite database, created if needed and shared by several runs")
+  cmd.StringVar(&g_args.expect_allowlist, "expect_allowlist", "", "Refuse to run unless the strategy was built with this allowlist of target ASes (see -target_as_allowlist)")
+  cmd.Var(&deadline_value{&g_args.deadline}, "deadline", "Skip the ASes (and groups) that would not complete before this deadline: a duration (e.g. 3h30m) or a time (e.g. 2026-01-02T15:04)")
+  
+  dump := parse_args_with_config (cmd, args[1:])
+  apply_break_len (break_len)
+  required := []string{"ases", "bdr", "warts", "strategy", "o"}
+  if simulation_mode != 0 { // The alternative schedulers need the CAIDA files
+    required = append (required, "asrel", "ppdc", "ip2as")
+  }
+  validate_args (cmd, required, "ases", "bdr", "warts", "strategy", "asrel", "ppdc", "ip2as", "vp_caps", "expect_allowlist")
+  if dump {
+    dump_config (cmd, output_file + "_run_config.json")
+  }
+  default_summary_out (output_file + "_summary.json")
+  check_trace_format ()
+  check_loops ()
+  check_trace_vp ()
+  check_border ()
+  if err := check_plateau_metric (g_args.plateau_metric); err != nil {
+    log.Fatal (err)
+  }
+  if err := check_missing_traces_policy (g_args.missing_traces); err != nil {
+    log.Fatal (err)
+  }
+  if err := check_credit_mode (g_args.credit_mode); err != nil {
+    log.Fatal (err)
+  }
+  if g_args.sibling_distance < 0 {
+    log.Fatal ("-sibling_distance must be >= 0")
+  }
+  check_jobs ()
+  if g_args.router_k < 1 {
+    log.Fatal ("-router-k must be >= 1")
+  }
+  if g_args.greedy_patience < 1 {
+    log.Fatal ("-greedy-patience must be >= 1")
+  }
+  if simulation_mode == 1 { // Parallel scheduling: check the weighting function before parsing the warts
+    if w_string == "" {
+      log.Fatal ("-w is required with -m 1 (", weight_function_names (), ")")
+    }
+    var err error
+    if g_args.weight_parameters, err = parse_weight_selector (w_string); err != nil {
+      log.Fatal (err)
+    }
+  }
+  
+  return
+}
\ No newline at end of file
@@ -0,0 +1,23 @@
+package sim
+
+import (
+    "flag"
+    "path/filepath"
+    "reflect"
+    "testing"
+    )
+
+func TestInvalidArgs (t *testing.T) {
+    cmd := flag.NewFlagSet ("strategy", flag.ContinueOnError)
+    cmd.String ("a", "", "")
+    cmd.String ("w", "", "")
+    missing := filepath.Join (t.TempDir (), "none.txt")
+    if err := cmd.Parse ([]string{"-w", missing}); err != nil {
+        t.Fatal (err)
+    }
+    invalid := invalid_args (cmd, []string{"a", "asrel"}, []string{"w", "warts"})
+    want := []string{"-a: missing", "-asrel: not a flag of strategy", "-w: open " + missing + ": no such file or directory", "-warts: not a flag of strategy"}
+    if !reflect.DeepEqual (invalid, want) {
+        t.Errorf ("got %q, want %q", invalid, want)
+    }
+}
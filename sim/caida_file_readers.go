@@ -0,0 +1,531 @@
+/* ==================================================================================== *\
+     caida_file_readers.go
+
+     Reads CAIDA data files:
+     - as-rel files
+     - ppdc files
+     - ip2as files
+     - as-org2info files (AS2Org)
+
+     Also read aliases file, and output some stats on the AS of interest.
+\* ==================================================================================== */
+
+package sim
+
+import (
+        "strings"
+        "log"
+        "net"
+        "sort"
+        "sync"
+        radix "github.com/Emeline-1/radix"
+        )
+
+var ( // Read-only variables (set only once)
+    as_neighbors map[string]map[string]interface{}; // From CAIDA AS rel file
+    max_conesize int;
+    // Breaking down into /24
+    as_24prefixes *Prefixes_24; // From CAIDA ip2as file (see lookup_as)
+    // Not broken down into /24
+    as_prefixes map[string]map[string]interface{}; // From CAIDA ip2as file
+    prefix_as map[string]string; // From CAIDA ip2as file;
+    // Organizations (siblings)
+    as_to_org map[string]string; // From CAIDA as-org2info file
+    org_to_ases map[string][]string; // From CAIDA as-org2info file
+)
+
+const (
+    Customer = iota
+    Peer
+    Provider
+    Unknown
+)
+
+/**
+  * Given the AS of interest and an AS, returns the relationship of the AS
+  * of interest with that AS in the form: 
+  *         AS is a [peer/customer/provider] of AS of interest
+  * 0: customer
+  * 1: peer
+  * 2: provider
+  */
+ func get_relationship (AS_interest, AS string) int {
+    if len (as_neighbors) == 0 {
+        log.Fatal ("[Warning]: as_neighbors is empty")
+    }
+    if neighbors, ok := as_neighbors[AS_interest]; ok {
+        if rel, ok2 := neighbors[AS]; ok2 {
+            return rel.(int)
+        }
+    }
+    // In case we cannot determine the AS relationships, always prefer the ASes for which we know
+    // 3 is superior to all three other values and will be discarded.
+    return Unknown 
+ }
+
+/* ------------------------------------------------------------------------------- *\
+                             Readers
+\* ------------------------------------------------------------------------------- */
+
+/**
+ * Returns a mapping of an AS and all its neighbors.
+ * Format:
+ * <provider-as>|<customer-as>|-1
+ * <peer-as>|<peer-as>|0
+ */
+func read_as_rel (filename string) map[string]map[string]interface{} {
+    r := NewCompressedReader (filename)
+    if err := r.Open (); err != nil {
+        log.Fatal ("[read_as_rel]: ", err)
+    }
+    scanner := r.Scanner ()
+    defer r.Close ()
+
+    neighbor_ases := make (map[string]map[string]interface{})
+    for scanner.Scan() {
+        line := scanner.Text ()
+        if !strings.Contains(line, "#") {
+            s := strings.Split(line, "|")
+            if s[2] == "0" {
+                append_prefix (&neighbor_ases, s[0], s[1], Peer)
+                append_prefix (&neighbor_ases, s[1], s[0], Peer)
+            }
+            if s[2] == "-1" {
+                append_prefix (&neighbor_ases, s[0], s[1], Customer) // s[1] is a customer of s[0]
+                append_prefix (&neighbor_ases, s[1], s[0], Provider) // s[0] is a provider of s[1]
+            }
+        }
+    }
+    return neighbor_ases
+}
+
+/**
+ * Returns a mapping of an AS and its organization, and of an organization and its ASes (in file order).
+ * Format (as-org2info), two sections announced by their format line:
+ * # format:aut|changed|aut_name|org_id|opaque_id|source
+ * # format:org_id|changed|name|country|source
+ * Only the first section (ASes) is needed.
+ */
+func read_as2org (filename string) (map[string]string, map[string][]string) {
+    r := NewCompressedReader (filename)
+    if err := r.Open (); err != nil {
+        log.Fatal ("[read_as2org]: ", err)
+    }
+    scanner := r.Scanner ()
+    defer r.Close ()
+
+    _as_to_org := make (map[string]string)
+    _org_to_ases := make (map[string][]string)
+    ases_section := false
+    for scanner.Scan() {
+        line := scanner.Text ()
+        if strings.HasPrefix (line, "#") {
+            if strings.HasPrefix (line, "# format:") {
+                ases_section = strings.HasPrefix (line, "# format:aut|")
+            }
+            continue
+        }
+        s := strings.Split(line, "|")
+        if !ases_section || len (s) < 4 || s[3] == "" {
+            continue
+        }
+        _as_to_org[s[0]] = s[3]
+        _org_to_ases[s[3]] = append (_org_to_ases[s[3]], s[0])
+    }
+    return _as_to_org, _org_to_ases
+}
+
+/**
+ * Returns a set of all Tiers1 
+ */
+func read_providers (filename string) map[string]interface{} {
+
+    all_ases := make (map[string]interface{})
+    customers := make (map[string]interface{})
+
+    r := NewCompressedReader (filename)
+    if err := r.Open (); err != nil {
+        log.Fatal ("[read_providers]: ", err)
+    }
+    scanner := r.Scanner ()
+    defer r.Close ()
+
+    for scanner.Scan() {
+        line := scanner.Text ()
+        if !strings.Contains(line, "#") {
+            s := strings.Split(line, "|")
+            rel := s[2]
+            if rel == "-1" { // Only interest in p2c relationships
+                customers[s[1]] = struct{}{}
+            }
+            all_ases[s[0]] = struct{}{}
+            all_ases[s[1]] = struct{}{}
+        }
+
+    }
+
+    log.Println ("Nb customers:", len (customers))
+    tiers1 := difference (all_ases, customers)
+    log.Println ("Nb tiers1: ", len (tiers1))
+    return slice_to_map (tiers1)
+}
+
+// -------------------------------------------------------------------------------
+/**
+ * Returns the /24 prefixes (or of the length of the targets, see target_length) of the ip2as file, and
+ * a mapping of an AS and its associated raw prefixes (and back).
+ * Note: In the ip2as file of CAIDA, there can be negative ASes. This corresponds, I think, to IXP prefixes.
+ */
+func read_ip2as (filename string) (*Prefixes_24, map[string]map[string]interface{}, map[string]string) {
+    defer recovery_function_fatal ()
+
+    /* --- Read file --- */
+    r := NewCompressedReader (filename)
+    if err := r.Open (); err != nil {
+        log.Fatal ("[read_ip2as]: ", err)
+    }
+    scanner := r.Scanner ()
+    defer r.Close ()
+    
+    _as_prefixes := make (map[string]map[string]interface{})
+    _prefix_as := make (map[string]string)
+    for scanner.Scan() {
+        line := scanner.Text ()
+        if line == "" || strings.Contains (line, "#") || strings.Contains (line, ":"){ // IPv6 address
+            continue
+        }
+        s := strings.Fields (line)
+        prefix := s[0]
+        AS := s[1]
+        if AS == "-1" {
+            continue
+        }
+        append_prefix (&_as_prefixes, AS, prefix)
+        _prefix_as[prefix] = AS
+    }
+
+    /* --- Longest prefix match instead of the /24 prefixes of every prefix (tens of millions for a full table) --- */
+    tree := radix.New ()
+    for prefix, as := range _prefix_as {
+        if _, _, err := net.ParseCIDR (prefix); err != nil {
+            panic ("[read_ip2as]: " + prefix + ": " + err.Error ())
+        }
+        tree.Insert (get_binary_string (prefix), as)
+    }
+    prefixes_24 := &Prefixes_24{tree: tree, as_prefixes: _as_prefixes, length: target_length (), materialized: make (map[string]map[string]interface{})}
+    return prefixes_24, _as_prefixes, _prefix_as
+}
+
+/**
+ * The /24 prefixes of the ip2as file (or of the length of the targets, see target_length):
+ * - the AS of a /24 is the AS of the most specific prefix overlapping it (see lookup_as);
+ * - the /24 prefixes of an AS (of all its prefixes, including those also covered by a more
+ *   specific prefix of another AS) are only broken down when an AS is requested (see of), as
+ *   the strategies only need those of a few ASes.
+ */
+type Prefixes_24 struct {
+    tree *radix.Tree                                // Binary prefix -> AS (see get_binary_string)
+    as_prefixes map[string]map[string]interface{}   // AS -> raw prefixes
+    length int                                      // Length of the prefixes (/24)
+    mux sync.Mutex
+    materialized map[string]map[string]interface{} // AS -> its /24 prefixes, for the ASes requested so far
+}
+
+/**
+ * Returns the AS of a /24 prefix (of the length of the targets): the AS of the longest prefix of the
+ * ip2as file either containing it, or contained in it (a /25 makes its /24 belong to its AS).
+ * Returns "" if no prefix of the ip2as file overlaps it.
+ */
+func lookup_as (prefix string) string {
+    return as_24prefixes.lookup_as (prefix)
+}
+
+func (p *Prefixes_24) lookup_as (prefix string) string {
+    if p == nil || strings.Contains (prefix, ":") || !strings.Contains (prefix, "/") {
+        return ""
+    }
+    key := get_binary_string (prefix)
+    as := ""
+    if _, as_i, ok := p.tree.LongestPrefix (key); ok {
+        as, _ = as_i.(string)
+    }
+    /* --- More specifics (the longest one, the first of them in case of a tie) --- */
+    longest := -1
+    p.tree.WalkPrefix (key, func (sub string, as_i interface{}) bool {
+        if len (sub) > longest {
+            longest = len (sub)
+            as, _ = as_i.(string)
+        }
+        return false
+    })
+    return as
+}
+
+/**
+ * Returns the /24 prefixes of an AS (nil if the AS has no prefix). The returned set must not be modified.
+ */
+func (p *Prefixes_24) of (as string) map[string]interface{} {
+    if p == nil { // ip2as file not read
+        return nil
+    }
+    p.mux.Lock ()
+    defer p.mux.Unlock ()
+    if prefixes, ok := p.materialized[as]; ok {
+        return prefixes
+    }
+    var prefixes map[string]interface{}
+    for prefix := range p.as_prefixes[as] {
+        _, network, err := net.ParseCIDR (prefix)
+        if err != nil {
+            panic ("PANIC")
+        }
+        for_each_subnet (network, p.length, func (subnet net.IPNet) bool {
+            if prefixes == nil {
+                prefixes = make (map[string]interface{})
+            }
+            prefixes[subnet.String ()] = struct{}{}
+            return true
+        })
+    }
+    p.materialized[as] = prefixes
+    return prefixes
+}
+
+/**
+ * Returns the number of distinct /24 prefixes of a set of ASes (see of), without breaking them down:
+ * each prefix is a range of /24 prefixes, the ranges are merged.
+ */
+func (p *Prefixes_24) count (ases map[string]interface{}) int {
+    type span struct{ first, last uint64 }
+    spans := make ([]span, 0, len (ases))
+    for as := range ases {
+        for prefix := range p.as_prefixes[as] {
+            _, network, err := net.ParseCIDR (prefix)
+            if err != nil || network.IP.To4 () == nil {
+                continue
+            }
+            l, _ := network.Mask.Size ()
+            first := uint64 (ip_to_uint32 (&network.IP)) >> uint (IPv4PrefixLen - p.length)
+            last := first
+            if l < p.length {
+                last = first + (uint64 (1) << uint (p.length - l)) - 1
+            }
+            spans = append (spans, span{first, last})
+        }
+    }
+    sort.Slice (spans, func (i, j int) bool { return spans[i].first < spans[j].first })
+    count := uint64 (0)
+    for i := 0; i < len (spans); {
+        first, last := spans[i].first, spans[i].last
+        for i++; i < len (spans) && spans[i].first <= last; i++ {
+            if spans[i].last > last {
+                last = spans[i].last
+            }
+        }
+        count += last - first + 1
+    }
+    return int (count)
+}
+
+// -------------------------------------------------------------------------------
+/**
+ * Reads a CAIDA customer cone file.
+ * Returns a mapping of an AS and the size of its customer cone (nb prefixes in the customer cone of the AS),
+ * and sets max_conesize (see customer_cone_sizes).
+ */
+func read_customer_cone (filename string) map[string]int {
+    if as_24prefixes == nil {
+        log.Fatal ("as_24prefix not set")
+    }
+    var as_cc_size map[string]int
+    as_cc_size, max_conesize = customer_cone_sizes (filename, as_24prefixes)
+    return as_cc_size
+}
+
+/**
+ * Reads a CAIDA customer cone file, and returns the size of the customer cone of each AS (in prefixes
+ * of the ip2as file) and the largest one.
+ */
+func customer_cone_sizes (filename string, prefixes *Prefixes_24) (map[string]int, int) {
+    /* --- Read file --- */
+    r := NewCompressedReader (filename)
+    if err := r.Open (); err != nil {
+        log.Fatal ("[read_customer_cone]: ", err)
+    }
+    scanner := r.Scanner ()
+    defer r.Close ()
+
+    _as_customers := make (map[string]map[string]interface{})
+    for scanner.Scan() {
+        line := scanner.Text ()
+        if line == "" || strings.Contains (line, "#"){
+            continue
+        }
+        s := strings.Split (line," ")
+        for _,customer := range s[1:] {
+            append_prefix (&_as_customers, s[0], customer)
+        }
+    }
+    if e := scanner.Err (); e != nil{
+        log.Fatal (e.Error ())
+    }
+
+    /* --- Customer cone size --- */
+    // key: an AS
+    // value: the number of /24 prefixes of its customer ASes (ASes without any are left out)
+    // Note: no need to purge cone on AS of interest.
+    min_size := MaxInt
+    max_size := 0
+    as_cc_size := make (map[string]int)
+    for as, customers := range _as_customers {
+        size := prefixes.count (customers)
+        if size == 0 {
+            continue
+        }
+        as_cc_size[as] = size
+        min_size = min (as_cc_size[as], min_size)
+        max_size = max (as_cc_size[as], max_size)
+    }
+    return as_cc_size, max_size
+}
+
+// -------------------------------------------------------------------------------
+func append_prefix (set *map[string]map[string]interface{}, args ...interface{}) {
+    /* --- Check nb args --- */
+    var l interface{}
+    switch len (args) {
+        case 2: l = struct{}{}
+        case 3: l = args[2]
+        default: log.Fatal ("Wrong number of arguments to function [append_prefix]")
+    }
+    as,_ := args[0].(string)
+    prefix,_ := args[1].(string)
+
+    if _, ok := (*set)[as]; ok {
+        (*set)[as][prefix] = l
+    } else {
+        (*set)[as] = map[string]interface{}{prefix: l}
+    }
+}
+
+/* ------------------------------------------------------------------------------- *\
+                                 MISC.
+\* ------------------------------------------------------------------------------- */
+
+/**
+ * For each AS, outputs:
+ * - a file containing all addresses of that AS (new line separated)
+ * - a file containing all routers of that AS, with their addresses (one router per line)
+ * - a file containing the routers that could not be mapped to a single AS, among which that AS (see router_majority_as)
+ * The routers none of whose addresses is annotated by bdrmapit are only counted.
+ */
+func ases_main_stats (ases_interest_file, bdrmapit_file, alias_file, output_dir string) {
+    /* --- Read files --- */
+    addr_to_asn,_,_,_, err := ReadAnnotations (bdrmapit_file)
+    if err != nil {
+        log.Fatal ("[ases_main_stats]: ", err)
+    }
+    router_addresses := read_aliases (alias_file)
+    ases_interest,_ := read_whitespace_delimited_file (ases_interest_file)
+
+    /* --- Routers --- */
+    AS_routers := make (map[string]map[string]interface{})
+    AS_unknown_routers := make (map[string]map[string]interface{}) // AS -> routers tied between that AS and others
+    unannotated := 0
+    for router, addresses := range router_addresses {
+        AS, tied := router_majority_as (addresses, addr_to_asn)
+        switch {
+            case AS != "":
+                append_prefix (&AS_routers, AS, router, addresses)
+            case len (tied) != 0:
+                for _, tied_AS := range tied {
+                    append_prefix (&AS_unknown_routers, tied_AS, router, addresses)
+                }
+            default:
+                unannotated++
+        }
+    }
+    for _, AS := range ases_interest {
+        to_print := create_safeset ()
+        to_print.set = AS_routers[AS]
+        to_print.write_to_file (output_dir + "/routers_"+ AS + ".txt")
+        unknown := create_safeset ()
+        unknown.set = AS_unknown_routers[AS]
+        unknown.write_to_file (output_dir + "/unknown_routers_"+ AS + ".txt")
+        log.Println ("AS", AS, "- routers:", len (AS_routers[AS]), "- unknown routers (tied with other ASes):", len (AS_unknown_routers[AS]))
+    }
+    log.Println ("Routers without any address annotated by bdrmapit:", unannotated, "out of", len (router_addresses))
+
+
+    /* --- Addresses --- */
+    AS_addresses := make (map[string]map[string]interface{})
+    for addr, asn := range addr_to_asn.set {
+        append_prefix (&AS_addresses, asn, addr)
+    }
+    for _, AS := range ases_interest {
+        to_print := create_safeset ()
+        to_print.set = AS_addresses[AS]
+        to_print.write_to_file (output_dir + "/addresses_"+ AS + ".txt")
+    }
+}
+
+/**
+ * Returns the AS of a router: the AS of most of its addresses. The addresses missing from bdrmapit,
+ * or without AS (-1), do not count. If several ASes are tied, returns no AS and the tied ASes (sorted);
+ * if none of the addresses has an AS, returns neither.
+ */
+func router_majority_as (addresses []string, addr_to_asn *SafeSet) (string, []string) {
+    votes := make (map[string]int)
+    for _, addr := range addresses {
+        asn_i, present := addr_to_asn.unsafe_get (addr)
+        if asn, _ := asn_i.(string); present && asn != "-1" {
+            votes[asn]++
+        }
+    }
+    best := 0
+    var majority []string
+    for asn, n := range votes {
+        if n > best {
+            best = n
+            majority = []string{asn}
+        } else if n == best {
+            majority = append (majority, asn)
+        }
+    }
+    if len (majority) == 1 {
+        return majority[0], nil
+    }
+    sort.Strings (majority)
+    return "", majority
+}
+
+// -------------------------------------------------------------------------------
+/**
+ * Input file format:
+        node Ni:  ip1 ip2 ... ipn
+ */
+func read_aliases (alias_file string) map[string][]string {
+    /* --- Read file --- */
+    r := NewCompressedReader (alias_file)
+    if err := r.Open (); err != nil {
+        log.Fatal ("[read_aliases]: ", err)
+    }
+    scanner := r.Scanner ()
+    defer r.Close ()
+
+    router_addresses := make (map[string][]string)
+    for scanner.Scan() {
+        line := scanner.Text ()
+        if line == "" || strings.Contains (line, "#"){
+            continue
+        }
+        s := strings.Fields (line)
+        router := s[1]
+        addresses := s[2:]
+        router_addresses[router] = addresses
+    }
+    if e := scanner.Err (); e != nil{
+        log.Fatal (e.Error ())
+    }
+    return router_addresses
+}
@@ -0,0 +1,65 @@
+package sim
+
+import (
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+    "testing"
+    )
+
+/**
+ * A router is mapped to the AS of most of its annotated addresses, whichever address comes first;
+ * the addresses missing from bdrmapit or without AS do not vote.
+ */
+func TestRouterMajorityAS (t *testing.T) {
+    addr_to_asn := create_safeset ()
+    for addr, asn := range map[string]string{"192.0.2.2": "100", "192.0.2.3": "100", "192.0.2.4": "200", "192.0.2.5": "-1", "192.0.2.6": "300"} {
+        addr_to_asn.add (addr, asn)
+    }
+    for _, c := range []struct {
+        addresses []string;
+        as string;
+        tied []string;
+    } {
+        {[]string{"192.0.2.1", "192.0.2.2", "192.0.2.3"}, "100", nil}, // First address not annotated
+        {[]string{"192.0.2.2", "192.0.2.4", "192.0.2.3"}, "100", nil},
+        {[]string{"192.0.2.5", "192.0.2.5", "192.0.2.4"}, "200", nil}, // No AS: no vote
+        {[]string{"192.0.2.6", "192.0.2.2", "192.0.2.4"}, "", []string{"100", "200", "300"}},
+        {[]string{"192.0.2.1", "192.0.2.5"}, "", nil},
+        {nil, "", nil},
+    } {
+        as, tied := router_majority_as (c.addresses, addr_to_asn)
+        if as != c.as || strings.Join (tied, " ") != strings.Join (c.tied, " ") {
+            t.Errorf ("%v: %q %v, want %q %v", c.addresses, as, tied, c.as, c.tied)
+        }
+    }
+}
+
+/**
+ * The routers of each AS of interest, and the routers tied between that AS and others, on the
+ * fixture of testdata/ases_main_stats. The routers without any annotated address are counted.
+ */
+func TestASesMainStats (t *testing.T) {
+    dir, out := filepath.Join ("..", "testdata", "ases_main_stats"), t.TempDir ()
+    logs := capture_log (t)
+    ases_main_stats (filepath.Join (dir, "ases.txt"), filepath.Join (dir, "annotations.csv"), filepath.Join (dir, "aliases.txt"), out)
+    for _, name := range []string{"routers_100", "routers_200", "unknown_routers_100", "unknown_routers_200"} {
+        content, err := os.ReadFile (filepath.Join (out, name + ".txt"))
+        if err != nil {
+            t.Fatal (err)
+        }
+        expected, err := os.ReadFile (filepath.Join (dir, "expected_" + name + ".txt"))
+        if err != nil {
+            t.Fatal (err)
+        }
+        lines := strings.Split (strings.TrimSpace (string (content)), "\n")
+        sort.Strings (lines)
+        if got, want := strings.Join (lines, "\n"), strings.TrimSpace (string (expected)); got != want {
+            t.Errorf ("%s.txt:\n%s\nwant:\n%s", name, got, want)
+        }
+    }
+    if !strings.Contains (logs.String (), "Routers without any address annotated by bdrmapit: 1 out of 6") {
+        t.Errorf ("1 router without annotated address expected:\n%s", logs)
+    }
+}
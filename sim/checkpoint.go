@@ -0,0 +1,288 @@
+/* ==================================================================================== *\
+     checkpoint.go
+
+     Checkpoints of the simulation:
+     ------------------------------
+     With -checkpoint (or -resume), once the simulation of an AS of interest is complete
+     (all its files written), its outcome is recorded in <output_dir>/checkpoint.json, along
+     with the fingerprint of the input files (SHA-256 of the files, and of the listing of the
+     directories, see listing_hash) and the parameters of the simulation. With -resume, the
+     ASes recorded as complete are skipped (neither their strategy is read nor are they
+     simulated again): only the remaining ones are simulated. A resume refuses to continue if
+     an input file or a parameter changed since the checkpoint.
+
+     The warts are still parsed on resume. The statistics of the standard output
+     (raw.txt, missing_traces.txt, ...) of the skipped ASes are those of the previous run.
+     The limits of the groups of an AS are only gathered into all_reduction.txt once its
+     simulation is complete (see gather_limits).
+\* ==================================================================================== */
+
+package sim
+
+import (
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "flag"
+    "io"
+    "log"
+    "os"
+    "path/filepath"
+    "sort"
+    "strconv"
+    "sync"
+    )
+
+const checkpoint_file = "checkpoint.json"
+
+/**
+ * Outcome of the simulation of an AS (see probing_summary). Discovery levels are formatted
+ * as in the results (they may be NaN).
+ */
+type as_outcome struct {
+    Launched int `json:"launched"`;
+    Useful int `json:"useful"`;
+    Missing_traces int `json:"missing_traces"`;
+    False_positives int `json:"false_positives"`;
+    Adjs string `json:"adjs"`;
+    Multi_adjs string `json:"multi_adjs"`;
+    Addresses string `json:"addresses"`;
+    Routers string `json:"routers"`;
+    Budget_exhausted bool `json:"budget_exhausted"`;
+    Seconds float64 `json:"seconds"`;
+}
+
+/**
+ * Flags of the simulation that do not change its results, hence not recorded in the checkpoint:
+ * every other flag is, so that the ASes simulated before and after a resume are simulated alike
+ * (the input files are recorded by their fingerprint).
+ */
+var checkpoint_ignored = map[string]bool{"o": true, "j": true, "j-warts": true, "quiet": true, "checkpoint": true, "resume": true,
+    "force": true, "profile": true, "pprof": true, "summary_out": true, "sqlite": true, "deadline": true, "no-cache": true,
+    "expect_allowlist": true, "config": true, "dump-config": true}
+
+type checkpoint struct {
+    Inputs map[string]string `json:"inputs"`;   // Flag -> SHA-256 of the input file (or of the listing of the directory)
+    Parameters map[string]string `json:"parameters"`; // Flag -> value
+    Done map[string]*as_outcome `json:"done"`;  // Output file of an AS (one per threshold and credit bound, relative to the output directory) -> outcome
+}
+
+var sim_checkpoint = struct {
+    mux sync.Mutex;
+    path string;                      // Empty: no checkpoint (all the functions below are no-ops)
+    c checkpoint;
+    pending map[string]*as_outcome;   // Outcomes of the ASes whose files are being written
+}{}
+
+/**
+ * Returns the SHA-256 of a file.
+ */
+func input_hash (filename string) (string, error) {
+    f, err := os.Open (filename)
+    if err != nil {
+        return "", err
+    }
+    defer f.Close ()
+    h := sha256.New ()
+    if _, err := io.Copy (h, f); err != nil {
+        return "", err
+    }
+    return hex.EncodeToString (h.Sum (nil)), nil
+}
+
+/**
+ * Returns the fingerprint of an input: the SHA-256 of a file, or of the listing of a directory
+ * (the warts are not read again).
+ */
+func input_fingerprint (filename string) (string, error) {
+    info, err := os.Stat (filename)
+    if err != nil {
+        return "", err
+    }
+    if info.IsDir () {
+        hash, _, err := listing_hash (filename)
+        return hash, err
+    }
+    return input_hash (filename)
+}
+
+/**
+ * Returns the keys of two maps, sorted.
+ */
+func checkpoint_keys (a, b map[string]string) []string {
+    keys := make ([]string, 0, len (a))
+    for key := range a {
+        keys = append (keys, key)
+    }
+    for key := range b {
+        if _, ok := a[key]; !ok {
+            keys = append (keys, key)
+        }
+    }
+    sort.Strings (keys)
+    return keys
+}
+
+/**
+ * Starts the checkpoint of the simulation in output_dir. With resume, the previous checkpoint
+ * is read, and the run exits if one of the input files or of the parameters changed since.
+ */
+func checkpoint_begin (output_dir string, resume bool) {
+    inputs := map[string]string{
+        "ases": g_args.ases_interest_file,
+        "bdr": g_args.bdrmapit_file,
+        "warts": g_args.warts_directory,
+        "strategy": g_args.strategy,
+        "asrel": g_args.as_rel_file,
+        "ppdc": g_args.ppdc_file,
+        "ip2as": g_args.ip2as_file,
+        "vp_caps": g_args.vp_caps_file,
+        "vps": g_args.vps_file,
+    }
+    c := checkpoint{Inputs: make (map[string]string), Parameters: make (map[string]string), Done: make (map[string]*as_outcome)}
+    for flag, filename := range inputs {
+        if filename == "" {
+            continue
+        }
+        hash, err := input_fingerprint (filename)
+        if err != nil {
+            log.Fatal ("[checkpoint]: ", err)
+        }
+        c.Inputs[flag] = hash
+    }
+    c.Parameters = checkpoint_parameters (run_flags, inputs)
+    path := filepath.Join (output_dir, checkpoint_file)
+
+    if resume {
+        content, err := os.ReadFile (path)
+        if err != nil {
+            log.Fatal ("[checkpoint]: cannot resume: ", err)
+        }
+        previous := checkpoint{}
+        if err := json.Unmarshal (content, &previous); err != nil {
+            log.Fatal ("[checkpoint]: cannot resume: ", path, ": ", err)
+        }
+        for _, flag := range checkpoint_keys (c.Inputs, previous.Inputs) {
+            if c.Inputs[flag] != previous.Inputs[flag] {
+                log.Fatal ("[checkpoint]: cannot resume: the input -", flag, " changed since the checkpoint (", path, ")")
+            }
+        }
+        for _, flag := range checkpoint_keys (c.Parameters, previous.Parameters) {
+            if c.Parameters[flag] != previous.Parameters[flag] {
+                log.Fatal ("[checkpoint]: cannot resume: -", flag, " is ", c.Parameters[flag], ", it was ", previous.Parameters[flag], " in the checkpoint (", path, ")")
+            }
+        }
+        if previous.Done != nil {
+            c.Done = previous.Done
+        }
+        log.Println ("[checkpoint]: resuming,", len (c.Done), "simulations of ASes already complete")
+    }
+
+    sim_checkpoint.mux.Lock ()
+    defer sim_checkpoint.mux.Unlock ()
+    sim_checkpoint.path, sim_checkpoint.c = path, c
+    sim_checkpoint.pending = make (map[string]*as_outcome)
+    write_checkpoint ()
+}
+
+/**
+ * Returns the value of every flag of cmd that changes the results of the simulation, but the input
+ * files (fingerprinted instead).
+ */
+func checkpoint_parameters (cmd *flag.FlagSet, inputs map[string]string) map[string]string {
+    parameters := make (map[string]string)
+    if cmd == nil {
+        return parameters
+    }
+    cmd.VisitAll (func (f *flag.Flag) {
+        if _, input := inputs[f.Name]; !input && !checkpoint_ignored[f.Name] {
+            parameters[f.Name] = f.Value.String ()
+        }
+    })
+    return parameters
+}
+
+/**
+ * Returns the key of the simulation of an AS in the checkpoint: its output file, relative to the
+ * output directory (lock held).
+ */
+func checkpoint_key (output_file string) string {
+    if rel, err := filepath.Rel (filepath.Dir (sim_checkpoint.path), output_file); err == nil {
+        return rel
+    }
+    return output_file
+}
+
+/**
+ * Returns true if the ASes whose simulation is complete are recorded (-checkpoint, -resume).
+ */
+func checkpoint_active () bool {
+    sim_checkpoint.mux.Lock ()
+    defer sim_checkpoint.mux.Unlock ()
+    return sim_checkpoint.path != ""
+}
+
+/**
+ * Returns true if the simulation of an AS (given by its output file) is recorded as complete.
+ */
+func checkpoint_completed (output_file string) bool {
+    sim_checkpoint.mux.Lock ()
+    defer sim_checkpoint.mux.Unlock ()
+    _, ok := sim_checkpoint.c.Done[checkpoint_key (output_file)]
+    return ok
+}
+
+/**
+ * Records the outcome of the simulation of an AS (given by its output file), until it is complete.
+ */
+func checkpoint_outcome (output_file string, s *probing_summary) {
+    sim_checkpoint.mux.Lock ()
+    defer sim_checkpoint.mux.Unlock ()
+    if sim_checkpoint.path == "" || s.interrupted { // An interrupted AS is simulated again by -resume
+        return
+    }
+    sim_checkpoint.pending[checkpoint_key (output_file)] = &as_outcome{
+        Launched: s.launched,
+        Useful: s.useful,
+        Missing_traces: s.missing_traces,
+        False_positives: s.false_positives,
+        Adjs: strconv.FormatFloat (s.final.Adjs, 'f', 4, 32),
+        Multi_adjs: strconv.FormatFloat (s.final.MultiAdjs, 'f', 4, 32),
+        Addresses: strconv.FormatFloat (s.final.Addresses, 'f', 4, 32),
+        Routers: strconv.FormatFloat (s.final.Routers, 'f', 4, 32),
+        Budget_exhausted: s.budget_exhausted,
+        Seconds: s.duration.Seconds (),
+    }
+}
+
+/**
+ * Marks the simulation of an AS (given by its output file) as complete, and writes the checkpoint.
+ */
+func checkpoint_done (output_file string) {
+    sim_checkpoint.mux.Lock ()
+    defer sim_checkpoint.mux.Unlock ()
+    key := checkpoint_key (output_file)
+    outcome, ok := sim_checkpoint.pending[key]
+    if sim_checkpoint.path == "" || !ok {
+        return
+    }
+    delete (sim_checkpoint.pending, key)
+    sim_checkpoint.c.Done[key] = outcome
+    write_checkpoint ()
+}
+
+/**
+ * Writes the checkpoint (lock held). The file is replaced atomically, so that a crash
+ * never leaves a truncated checkpoint.
+ */
+func write_checkpoint () {
+    content, _ := json.MarshalIndent (sim_checkpoint.c, "", "  ")
+    tmp := sim_checkpoint.path + ".tmp"
+    if err := os.WriteFile (tmp, append (content, '\n'), 0644); err != nil {
+        log.Print ("[checkpoint]: ", err)
+        return
+    }
+    if err := os.Rename (tmp, sim_checkpoint.path); err != nil {
+        log.Print ("[checkpoint]: ", err)
+    }
+}
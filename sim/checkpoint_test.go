@@ -0,0 +1,35 @@
+package sim
+
+import (
+    "flag"
+    "testing"
+    )
+
+/**
+ * Every flag that changes the results is recorded, but the input files and the flags of the run.
+ */
+func TestCheckpointParameters (t *testing.T) {
+    cmd := flag.NewFlagSet ("simulation", flag.ContinueOnError)
+    for _, name := range []string{"loops", "border", "dup_dest", "trace_vp", "trace-format", "credit_mode", "bdr", "o", "j"} {
+        cmd.String (name, "", "")
+    }
+    for _, name := range []string{"sibling_distance", "attempts", "max_ttl"} {
+        cmd.Int (name, 0, "")
+    }
+    parameters := checkpoint_parameters (cmd, map[string]string{"bdr": "bdrmapit.db"})
+    for _, name := range []string{"loops", "border", "dup_dest", "trace_vp", "trace-format", "credit_mode", "sibling_distance", "attempts", "max_ttl"} {
+        if _, ok := parameters[name]; !ok {
+            t.Errorf ("-%s not recorded", name)
+        }
+    }
+    for _, name := range []string{"bdr", "o", "j"} {
+        if _, ok := parameters[name]; ok {
+            t.Errorf ("-%s recorded", name)
+        }
+    }
+
+    cmd.Set ("loops", loops_keep)
+    if changed := checkpoint_parameters (cmd, nil); changed["loops"] == parameters["loops"] {
+        t.Error ("a change of -loops is not detected")
+    }
+}
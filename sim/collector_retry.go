@@ -0,0 +1,90 @@
+/* ==================================================================================== *\
+     collector_retry.go
+
+     Retries of the collectors:
+     --------------------------
+     Each bgpreader process is given -collector-timeout to read the table of its collector,
+     after which it is killed (see read_rib_records): a hung collector does not hold a worker
+     of the pool forever. A collector whose reading failed (bgpreader error or timeout) is
+     read again up to -collector-retries times, after a backoff that doubles at each retry.
+     Each attempt starts from scratch: the results of a collector are only merged with those
+     of the others once its table is read completely. The collectors that never succeeded
+     are listed at the end of the parsing (failed_collectors.txt with ribs_multi), so that
+     the coverage of the run is explicit.
+\* ==================================================================================== */
+
+package sim
+
+import (
+    "log"
+    "os"
+    "sort"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+    )
+
+const collector_backoff = 30 * time.Second // Wait before the first retry of a collector
+
+var failed_collectors = struct {
+    mux sync.Mutex;
+    names []string;
+}{}
+
+/**
+ * Returns the worker function of a pool of collectors: attempt reads the table of a collector,
+ * and returns false if it failed. A failed collector is read again (see the header of the file).
+ */
+func collector_attempts (attempt func (string) bool) func (string) {
+    return func (collector_name string) {
+        backoff := collector_backoff
+        for i := 0; ; i++ {
+            if attempt (collector_name) {
+                return
+            }
+            if i >= g_args.collector_retries || run_interrupted () {
+                break
+            }
+            log.Printf ("[collector_attempts]: %s: attempt %d failed, retrying in %s", collector_name, i + 1, backoff)
+            select {
+                case <-time.After (backoff):
+                case <-run_context.Done ():
+            }
+            backoff *= 2
+        }
+        log.Printf ("[collector_attempts]: %s: failed, the collector is left out", collector_name)
+        summary_unit ("collectors", unit_failed)
+        failed_collectors.mux.Lock ()
+        failed_collectors.names = append (failed_collectors.names, collector_name)
+        failed_collectors.mux.Unlock ()
+    }
+}
+
+/**
+ * Returns the collectors that never succeeded, sorted.
+ */
+func get_failed_collectors () []string {
+    failed_collectors.mux.Lock ()
+    defer failed_collectors.mux.Unlock ()
+    names := append ([]string (nil), failed_collectors.names...)
+    sort.Strings (names)
+    return names
+}
+
+/**
+ * Writes the collectors that never succeeded in filename, one per line (empty if all succeeded).
+ */
+func write_failed_collectors (filename string) {
+    names := get_failed_collectors ()
+    content := ""
+    if len (names) != 0 {
+        content = strings.Join (names, "\n") + "\n"
+        summary_warning (strconv.Itoa (len (names)) + " collectors failed (see " + filename + ")")
+    }
+    if err := os.WriteFile (filename, []byte (content), 0644); err != nil {
+        log.Print ("[write_failed_collectors]: ", err)
+        return
+    }
+    summary_artifact (filename)
+}
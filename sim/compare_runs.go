@@ -0,0 +1,357 @@
+/* ==================================================================================== *\
+     compare_runs.go
+
+     Comparison of two simulation runs:
+     ----------------------------------
+     ./anaximander analysis compare_runs [-tol t] [-o output_prefix] [-strategy_a dir -strategy_b dir] <dir_a> <dir_b>
+     Pairs the discovery curves of both runs (sorted_<output_file>_<AS>.txt) by AS of
+     interest, and computes for each AS and each metric (addresses, adjs, routers):
+       - the final discovery levels, and their delta (B - A),
+       - the probes needed to reach 90% of the final level of run A (p90), in each run,
+       - the area under the curve in each run, on the same probe axis (0 to the largest
+         number of probes of both runs), and its delta.
+     An AS regressed when run B is worse than run A by more than the tolerance (-tol) on the
+     final level or on the AUC of a metric. The ASes are printed from the worst delta to
+     the best, and written in <output_prefix>.csv.
+     The limits of the groups (all_reduction.txt and *_limits_reduction.txt, sequential
+     simulation) tell how many probes each group (neighbor AS) of the strategy got before
+     its plateau: the groups cut earlier or later in run B are printed, and written in
+     <output_prefix>_limits.csv. The groups are named after their AS with the strategies of
+     both runs (-strategy_a, -strategy_b), and paired by position otherwise.
+\* ==================================================================================== */
+
+package sim
+
+import (
+    "encoding/csv"
+    "fmt"
+    "log"
+    "math"
+    "os"
+    "path/filepath"
+    "sort"
+    "strconv"
+    "strings"
+    )
+
+/**
+ * Returns the final level of the metric (0 without discovery).
+ */
+func (c *as_curve) final (metric int) float64 {
+    if len (c.probes) == 0 {
+        return 0
+    }
+    return c.levels[metric][len (c.probes) - 1]
+}
+
+/**
+ * Returns the number of probes after which the metric reaches the level (NaN if it never does).
+ */
+func (c *as_curve) probes_to (metric int, level float64) float64 {
+    if level <= 0 {
+        return 0
+    }
+    for i, x := range c.probes {
+        if c.levels[metric][i] >= level {
+            return x
+        }
+    }
+    return math.NaN ()
+}
+
+/**
+ * Comparison of an AS in both runs.
+ */
+type as_comparison struct {
+    as string;
+    a, b *as_curve;
+    final_a, final_b, p90_a, p90_b, auc_a, auc_b []float64; // Per metric (curve_metrics)
+    worst float64;  // Lowest delta (final or AUC) over the metrics
+    regressed bool;
+}
+
+/**
+ * Reads the curves of a run, by AS of interest (the last field of their name).
+ */
+func read_run_curves (dir string) map[string]*as_curve {
+    files, _ := filepath.Glob (filepath.Join (dir, "sorted_*.txt"))
+    gz_files, _ := filepath.Glob (filepath.Join (dir, "sorted_*.txt.gz"))
+    curves := make (map[string]*as_curve)
+    for _, file := range append (files, gz_files...) {
+        c, err := read_as_curve (file)
+        if err != nil {
+            log.Fatal ("[compare_runs]: ", err)
+        }
+        as := c.name[strings.LastIndex (c.name, "_") + 1:]
+        if previous, ok := curves[as]; ok {
+            log.Fatal ("[compare_runs]: ", dir, ": several curves for AS ", as, " (", previous.name, ", ", c.name, "): compare the directory of a single threshold and credit bound")
+        }
+        curves[as] = c
+    }
+    if len (curves) == 0 {
+        log.Fatal ("[compare_runs]: no sorted_*.txt file in ", dir)
+    }
+    return curves
+}
+
+/**
+ * Reads the limits of the groups of a run (all_reduction.txt and *_limits_reduction.txt): AS of
+ * interest -> number of probes launched at the end of each group (cumulative). The last line
+ * of an AS wins (resumed runs).
+ */
+func read_run_limits (dir string) map[string][]int {
+    limits := make (map[string][]int)
+    files, _ := filepath.Glob (filepath.Join (dir, "*limits_reduction.txt"))
+    for _, file := range append ([]string{filepath.Join (dir, "all_reduction.txt")}, files...) {
+        content, err := os.ReadFile (file)
+        if err != nil {
+            continue
+        }
+        for _, line := range strings.Split (string (content), "\n") {
+            fields := strings.Fields (line)
+            if len (fields) == 0 {
+                continue
+            }
+            values := make ([]int, 0, len (fields) - 1)
+            for _, field := range fields[1:] {
+                if v, err := strconv.Atoi (field); err == nil {
+                    values = append (values, v)
+                }
+            }
+            limits[fields[0]] = values
+        }
+    }
+    return limits
+}
+
+/**
+ * Returns the AS of each non-empty group of the strategy of an AS of interest (<strategy_dir>/<AS>/as_limits.txt),
+ * in the order of the groups of the limits files. Returns nil if the strategy cannot be read.
+ */
+func read_group_ases (strategy_dir, as_interest string) []string {
+    if strategy_dir == "" {
+        return nil
+    }
+    r := NewCompressedReader (filepath.Join (strategy_dir, as_interest, "as_limits.txt"))
+    if err := r.Open (); err != nil {
+        log.Print ("[compare_runs]: ", err)
+        return nil
+    }
+    defer r.Close ()
+    scanner := r.Scanner ()
+    ases, previous := []string{}, 0
+    for scanner.Scan () {
+        fields := strings.Fields (scanner.Text ())
+        if len (fields) < 2 {
+            continue
+        }
+        limit, _ := strconv.Atoi (fields[0])
+        if limit != previous { // The empty groups are not simulated
+            ases = append (ases, fields[1])
+        }
+        previous = limit
+    }
+    return ases
+}
+
+/**
+ * Probes received by a group in both runs.
+ */
+type group_comparison struct {
+    as_interest, group string;
+    probes_a, probes_b int;
+}
+
+/**
+ * Returns the groups of an AS of interest that received a different number of probes in both runs.
+ * The groups are paired by AS if the ASes of the groups are known in both runs, by position otherwise.
+ */
+func compare_limits (as_interest string, limits_a, limits_b []int, ases_a, ases_b []string) []group_comparison {
+    probes := func (limits []int, ases []string) ([]string, map[string]int) {
+        order, spent := []string{}, make (map[string]int)
+        previous := 0
+        for i, limit := range limits {
+            group := "#" + strconv.Itoa (i + 1)
+            if ases != nil && i < len (ases) {
+                group = ases[i]
+            }
+            order = append (order, group)
+            spent[group] = limit - previous
+            previous = limit
+        }
+        return order, spent
+    }
+    if ases_a == nil || ases_b == nil {
+        ases_a, ases_b = nil, nil
+    }
+    order_a, spent_a := probes (limits_a, ases_a)
+    order_b, spent_b := probes (limits_b, ases_b)
+    for _, group := range order_b {
+        if _, ok := spent_a[group]; !ok {
+            order_a = append (order_a, group)
+        }
+    }
+    changes := []group_comparison{}
+    for _, group := range order_a {
+        if spent_a[group] != spent_b[group] { // Absent: no probe
+            changes = append (changes, group_comparison{as_interest, group, spent_a[group], spent_b[group]})
+        }
+    }
+    return changes
+}
+
+/**
+ * Compares two simulation runs (see the header of the file).
+ */
+func compare_runs (dir_a, dir_b, output_prefix, strategy_a, strategy_b string, tolerance float64) {
+    curves_a, curves_b := read_run_curves (dir_a), read_run_curves (dir_b)
+    only_a, only_b := []string{}, []string{}
+    comparisons := []*as_comparison{}
+    for as, a := range curves_a {
+        b, ok := curves_b[as]
+        if !ok {
+            only_a = append (only_a, as)
+            continue
+        }
+        end := math.Max (a.total, b.total)
+        n := len (curve_metrics)
+        c := &as_comparison{as: as, a: a, b: b, worst: math.Inf (1), final_a: make ([]float64, n), final_b: make ([]float64, n), p90_a: make ([]float64, n), p90_b: make ([]float64, n), auc_a: make ([]float64, n), auc_b: make ([]float64, n)}
+        for m := range curve_metrics {
+            c.final_a[m], c.final_b[m] = a.final (m), b.final (m)
+            target := 0.9 * c.final_a[m]
+            c.p90_a[m], c.p90_b[m] = a.probes_to (m, target), b.probes_to (m, target)
+            c.auc_a[m], c.auc_b[m] = a.auc (m, end), b.auc (m, end)
+            for _, delta := range []float64{c.final_b[m] - c.final_a[m], c.auc_b[m] - c.auc_a[m]} {
+                if math.IsNaN (delta) {
+                    continue
+                }
+                c.worst = math.Min (c.worst, delta)
+                if delta < -tolerance {
+                    c.regressed = true
+                }
+            }
+        }
+        if math.IsInf (c.worst, 1) { // No level to compare
+            c.worst = 0
+        }
+        comparisons = append (comparisons, c)
+    }
+    for as := range curves_b {
+        if _, ok := curves_a[as]; !ok {
+            only_b = append (only_b, as)
+        }
+    }
+    sort.Strings (only_a)
+    sort.Strings (only_b)
+    sort.Slice (comparisons, func (i, j int) bool {
+        if comparisons[i].worst != comparisons[j].worst {
+            return comparisons[i].worst < comparisons[j].worst
+        }
+        return comparisons[i].as < comparisons[j].as
+    })
+
+    /* --- Human-readable table, worst first --- */
+    format := func (v float64) string {
+        if math.IsNaN (v) {
+            return "-"
+        }
+        return strconv.FormatFloat (v, 'f', 4, 64)
+    }
+    format_probes := func (v float64) string {
+        if math.IsNaN (v) {
+            return "never"
+        }
+        return strconv.FormatFloat (v, 'f', -1, 64)
+    }
+    fmt.Printf ("Run A: %s\nRun B: %s\n%d ASes compared, tolerance %g\n\n", dir_a, dir_b, len (comparisons), tolerance)
+    fmt.Printf ("%-10s %-9s %16s %16s %16s %16s %16s %16s %14s\n", "AS", "status", "final_addresses", "final_adjs", "final_routers", "auc_addresses", "auc_adjs", "auc_routers", "p90_adjs A->B")
+    nb_regressed := 0
+    for _, c := range comparisons {
+        status := "ok"
+        if c.regressed {
+            status = "REGRESSED"
+            nb_regressed++
+        } else if c.worst > tolerance {
+            status = "improved"
+        }
+        fmt.Printf ("%-10s %-9s", c.as, status)
+        for m := range curve_metrics {
+            fmt.Printf (" %16s", fmt.Sprintf ("%+.4f", c.final_b[m] - c.final_a[m]))
+        }
+        for m := range curve_metrics {
+            fmt.Printf (" %16s", fmt.Sprintf ("%+.4f", c.auc_b[m] - c.auc_a[m]))
+        }
+        fmt.Printf (" %14s\n", format_probes (c.p90_a[1]) + "->" + format_probes (c.p90_b[1]))
+    }
+    fmt.Printf ("\n%d ASes regressed\n", nb_regressed)
+    if len (only_a) != 0 {
+        fmt.Println ("Only in run A:", strings.Join (only_a, " "))
+    }
+    if len (only_b) != 0 {
+        fmt.Println ("Only in run B:", strings.Join (only_b, " "))
+    }
+
+    /* --- Groups cut earlier or later --- */
+    limits_a, limits_b := read_run_limits (dir_a), read_run_limits (dir_b)
+    changes := []group_comparison{}
+    for _, c := range comparisons {
+        la, ok_a := limits_a[c.as]
+        lb, ok_b := limits_b[c.as]
+        if !ok_a || !ok_b {
+            continue
+        }
+        changes = append (changes, compare_limits (c.as, la, lb, read_group_ases (strategy_a, c.as), read_group_ases (strategy_b, c.as))...)
+    }
+    if len (limits_a) == 0 || len (limits_b) == 0 {
+        fmt.Println ("\nNo limits of the groups in one of the runs (sequential simulation only)")
+    } else {
+        fmt.Printf ("\n%d groups cut earlier or later in run B\n", len (changes))
+        for _, g := range changes {
+            when := "later"
+            if g.probes_b < g.probes_a {
+                when = "earlier"
+            }
+            fmt.Printf ("AS %-10s group %-10s cut %-7s %d -> %d probes\n", g.as_interest, g.group, when, g.probes_a, g.probes_b)
+        }
+    }
+
+    if output_prefix == "" {
+        return
+    }
+
+    /* --- Machine-readable outputs --- */
+    write_csv := func (filename string, rows [][]string) {
+        f, err := os.Create (filename)
+        if err != nil {
+            log.Fatal ("[compare_runs]: ", err)
+        }
+        defer f.Close ()
+        w := csv.NewWriter (f)
+        w.WriteAll (rows)
+        if err := w.Error (); err != nil {
+            log.Fatal ("[compare_runs]: ", filename, ": ", err)
+        }
+    }
+    header := []string{"as", "regressed", "worst_delta", "probes_a", "probes_b"}
+    for _, metric := range curve_metrics {
+        for _, column := range []string{"final_a", "final_b", "final_delta", "p90_a", "p90_b", "auc_a", "auc_b", "auc_delta"} {
+            header = append (header, metric.name + "_" + column)
+        }
+    }
+    rows := [][]string{header}
+    for _, c := range comparisons {
+        row := []string{c.as, strconv.FormatBool (c.regressed), format (c.worst), strconv.FormatFloat (c.a.total, 'f', -1, 64), strconv.FormatFloat (c.b.total, 'f', -1, 64)}
+        for m := range curve_metrics {
+            row = append (row, format (c.final_a[m]), format (c.final_b[m]), format (c.final_b[m] - c.final_a[m]), format_probes (c.p90_a[m]), format_probes (c.p90_b[m]),
+                format (c.auc_a[m]), format (c.auc_b[m]), format (c.auc_b[m] - c.auc_a[m]))
+        }
+        rows = append (rows, row)
+    }
+    write_csv (output_prefix + ".csv", rows)
+    rows = [][]string{{"as", "group", "probes_a", "probes_b"}}
+    for _, g := range changes {
+        rows = append (rows, []string{g.as_interest, g.group, strconv.Itoa (g.probes_a), strconv.Itoa (g.probes_b)})
+    }
+    write_csv (output_prefix + "_limits.csv", rows)
+    log.Println ("[compare_runs]: wrote", output_prefix + ".csv and", output_prefix + "_limits.csv")
+}
@@ -0,0 +1,84 @@
+/* ==================================================================================== *\
+    config.go
+
+    Configuration files for the strategy and simulation modes:
+    ----------------------------------------------------------
+    Instead of a dozen flags, a run can be described by a JSON file whose keys are
+    the flag names, e.g.:
+      {"ases": "ases.txt", "warts": "warts/", "strategy": "strategy/", "t": 0.5, "m": 1}
+    Flags given on the command line override the values of the file.
+\* ==================================================================================== */
+
+package sim
+
+import (
+  "bytes"
+  "encoding/json"
+  "flag"
+  "fmt"
+  "log"
+  "os"
+)
+
+/**
+ * Parses the command line (without the command name). If -config is given, the flags
+ * are first set from the configuration file, then overridden by the command line.
+ * Returns true if the effective configuration must be dumped (-dump-config).
+ */
+func parse_args_with_config (cmd *flag.FlagSet, args []string) bool {
+  config_file := cmd.String ("config", "", "JSON configuration file (keys are flag names). Flags given on the command line override its values")
+  dump := cmd.Bool ("dump-config", false, "Write the effective configuration of the run (JSON) next to its output")
+  cmd.Parse (args)
+  run_flags = cmd // For the manifest of the run
+
+  if *config_file != "" {
+    content, err := os.ReadFile (*config_file)
+    if err != nil {
+      log.Fatal ("[config]: ", err)
+    }
+    values := make (map[string]interface{})
+    decoder := json.NewDecoder (bytes.NewReader (content))
+    decoder.UseNumber () // Numbers as written (as float64, 1234567 would be given to the flag as 1.234567e+06)
+    if err := decoder.Decode (&values); err != nil {
+      log.Fatal ("[config]: ", *config_file, ": ", err)
+    }
+
+    /* --- Command line flags take precedence --- */
+    given := make (map[string]bool)
+    cmd.Visit (func (f *flag.Flag) { given[f.Name] = true })
+    for name, value := range values {
+      if name == "build" { // Build metadata of a dumped configuration
+        continue
+      }
+      if cmd.Lookup (name) == nil || name == "config" {
+        log.Fatal ("[config]: ", *config_file, ": unknown field: ", name)
+      }
+      if given[name] {
+        continue
+      }
+      if err := cmd.Set (name, fmt.Sprint (value)); err != nil {
+        log.Fatal ("[config]: ", *config_file, ": field ", name, ": ", err)
+      }
+    }
+  }
+  return *dump
+}
+
+/**
+ * Writes the effective configuration (the value of every flag) in JSON, along with
+ * the build metadata of the binary (field "build").
+ * The file can be given back to -config to reproduce the run.
+ */
+func dump_config (cmd *flag.FlagSet, filename string) {
+  values := make (map[string]interface{})
+  cmd.VisitAll (func (f *flag.Flag) {
+    if f.Name != "config" && f.Name != "dump-config" {
+      values[f.Name] = f.Value.String ()
+    }
+  })
+  values["build"] = build_info ()
+  content, _ := json.MarshalIndent (values, "", "  ") // Keys are sorted
+  if err := os.WriteFile (filename, append (content, '\n'), 0644); err != nil {
+    log.Print ("[dump_config]: ", err)
+  }
+}
@@ -0,0 +1,196 @@
+/* ==================================================================================== *\
+     cost_model.go
+
+     Campaign cost model:
+     --------------------
+     Beyond counting probes, a campaign is constrained by packets: each traceroute costs
+     roughly (path length x attempts) packets, and each VP has a daily packet cap.
+
+     Given an ordered list of targets (the schedule), the packets consumed by each VP are
+     accounted for day after day. When a target does not fit in the remaining budget of
+     the VP for the current day, it is spilled over to the next day.
+\* ==================================================================================== */
+
+package sim
+
+import (
+    "bufio"
+    "log"
+    "sort"
+    "strings"
+    "strconv"
+    )
+
+/**
+ * Parameters of the cost model.
+ */
+type Cost_model struct {
+    attempts int;           // Number of attempts per hop
+    max_ttl int;            // Path length used when no trace is available for a target
+    caps map[string]int;    // VP -> daily packet cap (VPs absent from the map are not capped)
+}
+
+/**
+ * One line of the ledger: the packets consumed by a VP on a given day.
+ */
+type Ledger_entry struct {
+    vp string;
+    day int;
+    targets int;    // Nb of targets scheduled that day
+    packets int;    // Nb of packets consumed that day
+    cap int;        // Daily packet cap of the VP (-1 if not capped)
+    spilled int;    // Nb of targets that did not fit that day and were spilled over to the next day
+    exceeded bool;  // A single target exceeds the daily cap of the VP
+}
+
+/**
+ * Builds the cost model from the program arguments.
+ * Returns nil if no cap file was given (no ledger is computed in that case).
+ */
+func get_cost_model () *Cost_model {
+    if g_args.vp_caps_file == "" {
+        return nil
+    }
+    if g_args.attempts < 1 || g_args.max_ttl < 1 {
+        log.Fatal ("[get_cost_model]: attempts and max_ttl must be positive")
+    }
+    return &Cost_model{attempts: g_args.attempts, max_ttl: g_args.max_ttl, caps: read_vp_caps (g_args.vp_caps_file)}
+}
+
+/**
+ * Reads a file of daily packet caps, in the format:
+ *    VP_source_IP daily_packet_cap
+ */
+func read_vp_caps (filename string) map[string]int {
+    r := NewCompressedReader (filename)
+    if err := r.Open (); err != nil {
+        log.Fatal (err)
+    }
+    defer r.Close ()
+    scanner := r.Scanner ()
+
+    caps := make (map[string]int)
+    for scanner.Scan () {
+        line := strings.Fields (scanner.Text ())
+        if len (line) == 0 || strings.HasPrefix (line[0], "#") {
+            continue
+        }
+        if len (line) < 2 {
+            log.Fatal ("[read_vp_caps]: missing cap in ", filename, ": ", line)
+        }
+        cap, err := strconv.Atoi (line[1])
+        if err != nil || cap <= 0 {
+            log.Fatal ("[read_vp_caps]: invalid cap in ", filename, ": ", line)
+        }
+        caps[line[0]] = cap
+    }
+    return caps
+}
+
+/**
+ * Returns the number of packets needed to trace towards the target.
+ * The path length is taken from the replayed trace when available (TTL of the last
+ * responsive hop, plus the destination), otherwise it is max_ttl.
+ */
+func (model *Cost_model) packets (target string, traces *SafeSet) int {
+    path_length := model.max_ttl
+    if traces != nil {
+        if trace_i, ok := traces.get (target); ok {
+            if trace, t := trace_i.(*Trace); t && len (trace.hops) != 0 {
+                path_length = min (trace.hops[len (trace.hops)-1].probe_ttl + 1, model.max_ttl)
+            }
+        }
+    }
+    return path_length * model.attempts
+}
+
+/**
+ * Accounts, for each VP and each day, the packets consumed by the targets, taken in the
+ * order of the schedule. The VP of a target is given by target_to_vp (the first of its VPs).
+ * A target without VP (e.g., no trace towards its /24) cannot be charged to any daily cap: it is
+ * left out of the ledger, and only counted (unassigned).
+ *
+ * Spillover: when a target does not fit in what remains of the VP daily cap, the day is
+ * closed and the target (as well as all subsequent targets of that VP) moves to the next day.
+ * A target whose cost alone exceeds the cap is scheduled on an empty day, which is flagged.
+ */
+func (model *Cost_model) ledger (targets []string, target_to_vp VP_mapper, traces *SafeSet) (ledger []*Ledger_entry, unassigned int) {
+    ledger = make ([]*Ledger_entry, 0, 10)
+    current := make (map[string]*Ledger_entry) // VP -> entry of its current day
+
+    for _, target := range targets {
+        target_vps, _ := target_to_vp.Get (target)
+        if len (target_vps) == 0 {
+            unassigned++
+            continue
+        }
+        vp := target_vps[0] // A target probed by several VPs is charged to the first one
+        cap, capped := model.caps[vp]
+        if !capped {
+            cap = -1
+        }
+        cost := model.packets (target, traces)
+
+        entry, ok := current[vp]
+        if !ok {
+            entry = &Ledger_entry{vp: vp, day: 0, cap: cap}
+            ledger = append (ledger, entry)
+            current[vp] = entry
+        }
+        /* --- Spillover to the next day --- */
+        if capped && entry.targets != 0 && entry.packets + cost > cap {
+            entry.spilled++
+            entry = &Ledger_entry{vp: vp, day: entry.day + 1, cap: cap}
+            ledger = append (ledger, entry)
+            current[vp] = entry
+        }
+        entry.targets++
+        entry.packets += cost
+        if capped && entry.packets > cap {
+            entry.exceeded = true
+        }
+    }
+    return ledger, unassigned
+}
+
+/**
+ * Writes the ledger, one line per VP and per day:
+ *    VP day nb_targets nb_packets cap status
+ * where status is 'ok', 'spillover' (the next targets of the VP were moved to the next day)
+ * or 'exceeded' (a single target exceeds the daily cap). The targets without VP are counted on
+ * a last comment line. Returns an error if the file could not be written.
+ */
+func write_ledger (ledger []*Ledger_entry, unassigned int, output_file string) error {
+    sort.SliceStable (ledger, func (i, j int) bool {
+        if ledger[i].vp != ledger[j].vp {
+            return ledger[i].vp < ledger[j].vp
+        }
+        return ledger[i].day < ledger[j].day
+    })
+    return write_file (output_file, func (w *bufio.Writer) {
+        for _, entry := range ledger {
+            status := "ok"
+            if entry.exceeded {
+                status = "exceeded"
+            } else if entry.spilled != 0 {
+                status = "spillover"
+            }
+            w.WriteString (entry.vp + " " + strconv.Itoa (entry.day) + " " + strconv.Itoa (entry.targets) + " " + strconv.Itoa (entry.packets) + " " + strconv.Itoa (entry.cap) + " " + status + "\n")
+        }
+        if unassigned != 0 {
+            w.WriteString ("# " + strconv.Itoa (unassigned) + " targets without VP, not charged\n")
+        }
+    })
+}
+
+/**
+ * Writes the ledger of the targets launched by a simulation (in launching order), next
+ * to the simulation output file. Does nothing without cost model (see Options.CostModel).
+ */
+func write_simulation_ledger (model *Cost_model, launched []string, target_to_vp VP_mapper, traces *SafeSet, output_file string) error {
+    if model != nil {
+        ledger, unassigned := model.ledger (launched, target_to_vp, traces)
+        return write_ledger (ledger, unassigned, trim_suffix (output_file, ".txt") + "_packet_ledger.txt")
+    }
+    return nil
+}
@@ -0,0 +1,37 @@
+package sim
+
+import (
+    "os"
+    "path/filepath"
+    "testing"
+    )
+
+/**
+ * A target without VP is not charged to a made-up VP at max_ttl, but counted apart.
+ */
+func TestLedgerUnassignedTargets (t *testing.T) {
+    set := create_safeset ()
+    set.append ("10.0.0.0/24", "192.0.2.1")
+    set.append ("10.0.1.0/24", "192.0.2.1")
+    model := &Cost_model{attempts: 2, max_ttl: 30, caps: map[string]int{"192.0.2.1": 100}}
+    ledger, unassigned := model.ledger ([]string{"10.0.0.0/24", "10.0.2.0/24", "10.0.1.0/24", "10.0.3.0/24"}, NewSafeSetVPMapper (set), nil)
+    if unassigned != 2 {
+        t.Errorf ("%d targets unassigned, want 2", unassigned)
+    }
+    if len (ledger) != 2 || ledger[0].vp != "192.0.2.1" || ledger[0].targets != 1 || ledger[0].spilled != 1 || ledger[1].targets != 1 {
+        t.Fatalf ("ledger: %+v", ledger)
+    }
+
+    output := filepath.Join (t.TempDir (), "packet_ledger.txt")
+    if err := write_ledger (ledger, unassigned, output); err != nil {
+        t.Fatal (err)
+    }
+    content, err := os.ReadFile (output)
+    if err != nil {
+        t.Fatal (err)
+    }
+    want := "192.0.2.1 0 1 60 100 spillover\n192.0.2.1 1 1 60 100 ok\n# 2 targets without VP, not charged\n"
+    if string (content) != want {
+        t.Errorf ("ledger file:\n%s\nwant:\n%s", content, want)
+    }
+}
@@ -0,0 +1,227 @@
+/* ==================================================================================== *\
+     credit.go
+
+     Credit given to a target by the simulator:
+     ------------------------------------------
+     The simulator follows a hit-or-miss model: a target /24 either has a trace in the
+     warts data set, or it contributes nothing (pessimistic). This understates the
+     strategies that pick an unlucky /24 inside a large raw prefix.
+
+     With the fractional credit mode, a target picked in a raw prefix and without
+     trace receives instead the trace of a traced /24 of the same raw prefix (chosen
+     randomly, but reproducibly). This is an optimistic bound: the real performance
+     lies between both modes.
+
+     With the nearest_sibling credit mode, adjacent /24s of a raw prefix being likely
+     to share their routes, such a target receives the trace of the nearest traced /24
+     of its raw prefix (by numeric /24 distance, the lower /24 on ties), but only if it
+     is at most -sibling_distance /24s away. The discoveries of inherited traces are
+     counted separately, to report how much coverage came from inheritance.
+\* ==================================================================================== */
+
+package sim
+
+import (
+    "fmt"
+    "log"
+    "net"
+    "sort"
+    )
+
+const (
+    credit_pessimistic = "pessimistic"
+    credit_fractional = "fractional"
+    credit_nearest_sibling = "nearest_sibling"
+)
+
+/**
+ * Traces to credit to the targets of an AS of interest in fractional or nearest_sibling mode.
+ * A nil *fractional_credit gives the pessimistic (hit-or-miss) credit.
+ */
+type fractional_credit struct {
+    traces *SafeSet;
+    raw_prefixes map[string]string; // Target (/24) -> raw prefix it was picked from
+    mode string;                    // credit_fractional or credit_nearest_sibling
+    sibling_distance int;           // See -sibling_distance
+    seed int64;                     // The seed of the run (see seeded_random)
+    length int;                     // Length of the IPv4 targets (see target_length)
+    traced map[string][]string;     // Raw prefix -> its traced /24 prefixes (sorted)
+    siblings map[string][]sibling;  // Raw prefix -> its traced /24 prefixes (sorted by address, nearest_sibling mode)
+    credited int;                   // Nb of targets that received the trace of another /24
+    too_far int;                    // Nb of targets whose nearest traced sibling was too far (nearest_sibling mode)
+    last_inherited bool;            // Whether the last trace returned by get_trace was the trace of another /24
+    inherited [3]int;               // Nb of adjacencies, addresses and routers discovered by inherited traces
+}
+
+/**
+ * A traced /24 of a raw prefix, with its index (its address divided by 256).
+ */
+type sibling struct {
+    index uint64;
+    prefix string;
+}
+
+/**
+ * Returns an error if the credit mode is unknown.
+ */
+func check_credit_mode (mode string) error {
+    switch mode {
+        case "", credit_pessimistic, credit_fractional, credit_nearest_sibling:
+            return nil
+    }
+    return fmt.Errorf ("unknown credit mode: %s (pessimistic, fractional or nearest_sibling)", mode)
+}
+
+/**
+ * Returns the credit to apply during the simulation of an AS of interest on the datasets
+ * (nil in pessimistic mode). The credit mode must be known (see check_credit_mode).
+ */
+func new_fractional_credit (ds *Datasets, raw_prefixes map[string]string, opts Options) *fractional_credit {
+    switch opts.CreditMode {
+        case "", credit_pessimistic:
+            return nil
+        case credit_fractional, credit_nearest_sibling:
+            return &fractional_credit{traces: ds.Traces, raw_prefixes: raw_prefixes, mode: opts.CreditMode, sibling_distance: opts.SiblingDistance, seed: ds.cfg.Seed, length: ds.cfg.target_length (),
+                traced: make (map[string][]string), siblings: make (map[string][]sibling)}
+    }
+    log.Fatal ("[new_fractional_credit]: ", check_credit_mode (opts.CreditMode))
+    return nil
+}
+
+/**
+ * Returns the trace towards the destination, or, if the destination was not traced, the trace
+ * towards another traced /24 of its raw prefix (fractional and nearest_sibling modes).
+ */
+func (credit *fractional_credit) get_trace (traces *SafeSet, destination string) (interface{}, bool) {
+    trace, present := traces.get (destination)
+    if credit == nil {
+        return trace, present
+    }
+    credit.last_inherited = false
+    if present {
+        return trace, present
+    }
+    raw, ok := credit.raw_prefixes[destination]
+    if !ok {
+        return trace, present
+    }
+    if credit.mode == credit_nearest_sibling {
+        nearest, found := credit.nearest_sibling (raw, destination, credit.sibling_distance)
+        if !found {
+            return trace, present
+        }
+        credit.credited++
+        credit.last_inherited = true
+        return traces.get (nearest)
+    }
+    candidates := credit.traced_subnets (raw)
+    if len (candidates) == 0 {
+        return trace, present
+    }
+    /* --- Seeded by the seed of the run and the destination, so that runs are reproducible --- */
+    r := seeded_random (credit.seed, destination)
+    credit.credited++
+    credit.last_inherited = true
+    return traces.get (candidates[r.Intn (len (candidates))])
+}
+
+/**
+ * Returns the index of a /24 (its address divided by 256), and false if it is not an IPv4 prefix.
+ */
+func subnet_index (prefix string) (uint64, bool) {
+    _, network, err := net.ParseCIDR (prefix)
+    if err != nil {
+        return 0, false
+    }
+    ip := network.IP.To4 ()
+    if ip == nil {
+        return 0, false
+    }
+    return uint64 (ip[0]) << 16 | uint64 (ip[1]) << 8 | uint64 (ip[2]), true
+}
+
+/**
+ * Returns the traced /24 of the raw prefix nearest to the destination, if it is at most
+ * max_distance /24s away. Between two equidistant /24s, the lower one is chosen.
+ */
+func (credit *fractional_credit) nearest_sibling (raw, destination string, max_distance int) (string, bool) {
+    index, ok := subnet_index (destination)
+    if !ok {
+        return "", false
+    }
+    siblings, ok := credit.siblings[raw]
+    if !ok {
+        siblings = make ([]sibling, 0)
+        for _, prefix := range credit.traced_subnets (raw) {
+            if i, ok := subnet_index (prefix); ok {
+                siblings = append (siblings, sibling{i, prefix})
+            }
+        }
+        sort.Slice (siblings, func (i, j int) bool { return siblings[i].index < siblings[j].index })
+        credit.siblings[raw] = siblings
+    }
+    if len (siblings) == 0 {
+        return "", false
+    }
+
+    /* --- The nearest /24s are on both sides of the destination --- */
+    i := sort.Search (len (siblings), func (i int) bool { return siblings[i].index >= index })
+    best, distance := "", uint64 (0)
+    if i > 0 { // Lower sibling first: it wins the ties
+        best, distance = siblings[i-1].prefix, index - siblings[i-1].index
+    }
+    if i < len (siblings) && (best == "" || siblings[i].index - index < distance) {
+        best, distance = siblings[i].prefix, siblings[i].index - index
+    }
+    if distance > uint64 (max_distance) {
+        credit.too_far++
+        return "", false
+    }
+    return best, true
+}
+
+/**
+ * Records the new adjacencies, addresses and routers discovered by the last trace returned by
+ * get_trace, if it was inherited from another /24.
+ */
+func (credit *fractional_credit) record_discoveries (adjs, addresses, routers int) {
+    if credit == nil || !credit.last_inherited {
+        return
+    }
+    credit.inherited[0] += adjs
+    credit.inherited[1] += addresses
+    credit.inherited[2] += routers
+}
+
+/**
+ * Reports the targets credited with the trace of another /24, the targets whose nearest
+ * sibling was too far, and the adjacencies, addresses and routers discovered by inherited
+ * traces (credited_traces.txt, with the marker of the run, see output_msg_marked).
+ */
+func (credit *fractional_credit) report (as_interest, marker string) {
+    if credit == nil {
+        return
+    }
+    output_msg_marked (marker, "credited_traces.txt", as_interest, credit.credited, credit.too_far, credit.inherited[0], credit.inherited[1], credit.inherited[2])
+}
+
+/**
+ * Returns the /24 prefixes of the raw prefix that have a trace (cached).
+ */
+func (credit *fractional_credit) traced_subnets (raw string) []string {
+    if candidates, ok := credit.traced[raw]; ok {
+        return candidates
+    }
+    candidates := make ([]string, 0)
+    if _, network, err := net.ParseCIDR (raw); err == nil {
+        for_each_subnet (network, credit.length, func (subnet net.IPNet) bool {
+            if prefix := subnet.String (); credit.traces.contains (prefix) {
+                candidates = append (candidates, prefix)
+            }
+            return true
+        })
+    }
+    sort.Strings (candidates)
+    credit.traced[raw] = candidates
+    return candidates
+}
@@ -0,0 +1,94 @@
+package sim
+
+import (
+    "testing"
+    )
+
+/**
+ * Credit of the nearest_sibling mode, where the raw prefix 192.0.4.0/22 has two traced /24s,
+ * 192.0.4.0/24 and 192.0.6.0/24, and the raw prefix 192.0.8.0/22 has none.
+ */
+func sibling_credit (distance int) (*fractional_credit, *SafeSet) {
+    traces := create_safeset ()
+    traces.add ("192.0.4.0/24", "trace 4")
+    traces.add ("192.0.6.0/24", "trace 6")
+    traces.add ("198.51.100.0/24", "trace outside") // Traced, but not in the raw prefix
+    raw_prefixes := map[string]string{"192.0.5.0/24": "192.0.4.0/22", "192.0.7.0/24": "192.0.4.0/22", "192.0.8.0/24": "192.0.8.0/22"}
+    return &fractional_credit{traces: traces, raw_prefixes: raw_prefixes, mode: credit_nearest_sibling, sibling_distance: distance, length: 24,
+        traced: make (map[string][]string), siblings: make (map[string][]sibling)}, traces
+}
+
+func TestNearestSiblingCredit (t *testing.T) {
+    credit, traces := sibling_credit (1)
+
+    /* --- Within the distance: 192.0.5.0 is between 192.0.4.0 and 192.0.6.0, the lower one wins --- */
+    if trace, ok := credit.get_trace (traces, "192.0.5.0/24"); !ok || trace != "trace 4" || !credit.last_inherited {
+        t.Errorf ("192.0.5.0/24: %v %v, want the trace of 192.0.4.0/24 (tie)", trace, ok)
+    }
+    if trace, ok := credit.get_trace (traces, "192.0.7.0/24"); !ok || trace != "trace 6" {
+        t.Errorf ("192.0.7.0/24: %v %v, want the trace of 192.0.6.0/24", trace, ok)
+    }
+    credit.record_discoveries (2, 3, 1)
+
+    /* --- A traced target is not inherited --- */
+    if trace, ok := credit.get_trace (traces, "192.0.6.0/24"); !ok || trace != "trace 6" || credit.last_inherited {
+        t.Errorf ("192.0.6.0/24: %v %v inherited %v", trace, ok, credit.last_inherited)
+    }
+    credit.record_discoveries (5, 5, 5) // Not counted: not inherited
+
+    /* --- No traced sibling in the raw prefix --- */
+    if _, ok := credit.get_trace (traces, "192.0.8.0/24"); ok {
+        t.Error ("192.0.8.0/24: a trace is inherited from outside its raw prefix")
+    }
+    if credit.credited != 2 || credit.inherited != [3]int{2, 3, 1} {
+        t.Errorf ("%d targets credited, discoveries %v", credit.credited, credit.inherited)
+    }
+}
+
+func TestNearestSiblingTooFar (t *testing.T) {
+    credit, traces := sibling_credit (0)
+    if _, ok := credit.get_trace (traces, "192.0.7.0/24"); ok || credit.last_inherited {
+        t.Error ("192.0.7.0/24: a trace is inherited beyond the distance")
+    }
+    if credit.credited != 0 || credit.too_far != 1 {
+        t.Errorf ("%d targets credited, %d too far, want 0 and 1", credit.credited, credit.too_far)
+    }
+
+    /* --- Distance 2: the lower sibling still wins the tie --- */
+    credit, _ = sibling_credit (2)
+    if nearest, ok := credit.nearest_sibling ("192.0.4.0/22", "192.0.5.0/24", 2); !ok || nearest != "192.0.4.0/24" {
+        t.Errorf ("nearest sibling of 192.0.5.0/24: %s %v", nearest, ok)
+    }
+}
+
+/**
+ * In fractional mode, the trace inherited is that of a traced /24 of the raw prefix, the same
+ * from one run to the other with the same seed.
+ */
+func TestFractionalCredit (t *testing.T) {
+    credit, traces := sibling_credit (0)
+    credit.mode, credit.seed = credit_fractional, 42
+    first, ok := credit.get_trace (traces, "192.0.7.0/24")
+    if !ok || (first != "trace 4" && first != "trace 6") {
+        t.Fatalf ("192.0.7.0/24: %v %v", first, ok)
+    }
+    for i := 0; i < 5; i++ {
+        again, _ := sibling_credit (0)
+        again.mode, again.seed = credit_fractional, 42
+        if trace, _ := again.get_trace (traces, "192.0.7.0/24"); trace != first {
+            t.Fatalf ("run %d: %v, want %v", i, trace, first)
+        }
+    }
+}
+
+func TestPessimisticCredit (t *testing.T) {
+    var credit *fractional_credit
+    _, traces := sibling_credit (1)
+    if _, ok := credit.get_trace (traces, "192.0.5.0/24"); ok {
+        t.Error ("a trace is inherited in pessimistic mode")
+    }
+    credit.record_discoveries (1, 1, 1) // Must not panic
+    if err := check_credit_mode ("optimistic"); err == nil {
+        t.Error ("unknown credit mode accepted")
+    }
+}
@@ -0,0 +1,248 @@
+/* ==================================================================================== *\
+     curves_analysis.go
+
+     Aggregate discovery curves of the ASes of interest:
+     ---------------------------------------------------
+     ./anaximander analysis aggregate_curves -d <results_dir> -o <output_prefix> [-axis fraction|probes] [-points n]
+     Reads the discovery curves of a simulation (sorted_<output_file>_<AS>.txt, possibly
+     gzip-compressed), and writes:
+       - <output_prefix>_curves.csv: for the addresses, the adjacencies and the routers, the
+         mean and the deciles (p10 ... p90) of the curves of the ASes on a common grid of
+         'points' points, one line per metric and point of the grid,
+       - <output_prefix>_auc.csv: the area under the curve of each AS (in [0,1]), a single
+         number to compare the strategies.
+     A curve is a step function: the level of an AS after x probes is the level of its last
+     discovery before x probes (its final level after its last probe). With -axis fraction
+     (default), the probe axis of each AS goes from 0 to the number of probes it launched
+     (launched, in summary_<output_file>_<AS>.txt, otherwise the last discovery), normalized to
+     [0,1]. With -axis probes, the grid goes from 0 to the largest number of probes of an AS,
+     in absolute probes, and the AUC is normalized by that number.
+     An AS without element of a kind (e.g., no router) has no level for it (NaN): it is left
+     out of the mean and the deciles of that metric.
+\* ==================================================================================== */
+
+package sim
+
+import (
+    "encoding/csv"
+    "fmt"
+    "log"
+    "math"
+    "os"
+    "path/filepath"
+    "sort"
+    "strconv"
+    "strings"
+    )
+
+const (
+    axis_fraction = "fraction"
+    axis_probes = "probes"
+)
+
+/* --- The aggregated metrics, and their column in the sorted results --- */
+var curve_metrics = []struct{ name string; column int }{{"addresses", 3}, {"adjs", 1}, {"routers", 4}}
+
+/**
+ * Discovery curve of an AS: level of each metric after each discovery.
+ */
+type as_curve struct {
+    name string;     // File name, without sorted_ and the extension
+    probes []float64; // Number of probes launched once each discovery is made (probe number + 1), increasing
+    levels [][]float64; // Metric (curve_metrics) -> level at each discovery
+    total float64;    // Number of probes launched
+}
+
+/**
+ * Returns the level of the given metric after x probes.
+ */
+func (c *as_curve) level (metric int, x float64) float64 {
+    i := sort.Search (len (c.probes), func (i int) bool { return c.probes[i] > x }) // First discovery after x
+    if i == 0 {
+        if len (c.probes) != 0 && math.IsNaN (c.levels[metric][0]) {
+            return math.NaN ()
+        }
+        return 0
+    }
+    return c.levels[metric][i-1]
+}
+
+/**
+ * Returns the area under the curve of the metric between 0 and 'end' probes, divided by 'end'.
+ */
+func (c *as_curve) auc (metric int, end float64) float64 {
+    if end <= 0 {
+        return math.NaN ()
+    }
+    area := 0.0
+    for i, x := range c.probes {
+        if x >= end {
+            break
+        }
+        next := end
+        if i + 1 < len (c.probes) && c.probes[i+1] < end {
+            next = c.probes[i+1]
+        }
+        area += c.levels[metric][i] * (next - x)
+    }
+    return area / end
+}
+
+/**
+ * Reads the curve of an AS (sorted results), and its number of probes from its summary if any.
+ */
+func read_as_curve (filename string) (*as_curve, error) {
+    base := filepath.Base (filename)
+    stem := trim_suffix (trim_suffix (base, ".gz"), ".txt")
+    c := &as_curve{name: strings.TrimPrefix (stem, "sorted_"), levels: make ([][]float64, len (curve_metrics))}
+
+    r := NewCompressedReader (filename)
+    if err := r.Open (); err != nil {
+        return nil, err
+    }
+    defer r.Close ()
+    scanner := r.Scanner ()
+    for scanner.Scan () {
+        fields := strings.Fields (scanner.Text ())
+        if len (fields) == 0 {
+            continue
+        }
+        if len (fields) < 5 {
+            return nil, fmt.Errorf ("%s: expecting 'probe adjs multi_adjs addresses routers': %s", filename, scanner.Text ())
+        }
+        probe, err := strconv.ParseFloat (fields[0], 64)
+        if err != nil {
+            return nil, fmt.Errorf ("%s: invalid probe number: %s", filename, fields[0])
+        }
+        if n := len (c.probes); n != 0 && probe + 1 < c.probes[n-1] {
+            return nil, fmt.Errorf ("%s: the probe numbers are not sorted (%s)", filename, fields[0])
+        }
+        c.probes = append (c.probes, probe + 1) // The probe number is the number of probes launched before
+        for m, metric := range curve_metrics {
+            level, err := strconv.ParseFloat (fields[metric.column], 64)
+            if err != nil {
+                return nil, fmt.Errorf ("%s: invalid level: %s", filename, fields[metric.column])
+            }
+            c.levels[m] = append (c.levels[m], level)
+        }
+    }
+    if err := scanner.Err (); err != nil {
+        return nil, fmt.Errorf ("%s: %v", filename, err)
+    }
+
+    /* --- Number of probes: summary of the AS, otherwise the last discovery --- */
+    if n := len (c.probes); n != 0 {
+        c.total = c.probes[n-1]
+    }
+    summary := filepath.Join (filepath.Dir (filename), "summary_" + strings.TrimPrefix (trim_suffix (base, ".gz"), "sorted_"))
+    if content, err := os.ReadFile (summary); err == nil {
+        for _, line := range strings.Split (string (content), "\n") {
+            if fields := strings.Fields (line); len (fields) == 2 && fields[0] == "launched" {
+                if launched, err := strconv.ParseFloat (fields[1], 64); err == nil && launched >= c.total {
+                    c.total = launched
+                }
+            }
+        }
+    }
+    return c, nil
+}
+
+/**
+ * Aggregates the discovery curves of the results directory (see the header of the file).
+ */
+func aggregate_curves (results_dir, output_prefix, axis string, points int) {
+    files, _ := filepath.Glob (filepath.Join (results_dir, "sorted_*.txt"))
+    gz_files, _ := filepath.Glob (filepath.Join (results_dir, "sorted_*.txt.gz"))
+    files = append (files, gz_files...)
+    sort.Strings (files)
+    if len (files) == 0 {
+        log.Fatal ("[aggregate_curves]: no sorted_*.txt file in ", results_dir)
+    }
+    curves := make ([]*as_curve, 0, len (files))
+    max_total := 0.0
+    for _, file := range files {
+        c, err := read_as_curve (file)
+        if err != nil {
+            log.Fatal ("[aggregate_curves]: ", err)
+        }
+        curves = append (curves, c)
+        max_total = math.Max (max_total, c.total)
+    }
+    log.Println ("[aggregate_curves]:", len (curves), "curves, at most", max_total, "probes")
+
+    /* --- x of the grid, for an AS: absolute probes, or fraction of its probes --- */
+    probe_at := func (c *as_curve, x float64) float64 {
+        if axis == axis_fraction {
+            return x * c.total
+        }
+        return x
+    }
+    end := 1.0
+    if axis == axis_probes {
+        end = max_total
+    }
+
+    /* --- Mean and deciles on the grid --- */
+    out := output_prefix + "_curves.csv"
+    f, err := os.Create (out)
+    if err != nil {
+        log.Fatal ("[aggregate_curves]: ", err)
+    }
+    w := csv.NewWriter (f)
+    header := []string{"metric", "x", "ases", "mean"}
+    for d := 10; d < 100; d += 10 {
+        header = append (header, "p" + strconv.Itoa (d))
+    }
+    w.Write (header)
+    format := func (v float64) string { return strconv.FormatFloat (v, 'f', 6, 64) }
+    for m, metric := range curve_metrics {
+        for p := 0; p < points; p++ {
+            x := end
+            if points > 1 {
+                x = end * float64 (p) / float64 (points - 1)
+            }
+            levels := make (DataFloat64, 0, len (curves))
+            for _, c := range curves {
+                if level := c.level (m, probe_at (c, x)); !math.IsNaN (level) {
+                    levels = append (levels, level)
+                }
+            }
+            row := []string{metric.name, format (x), strconv.Itoa (len (levels)), format (levels.Mean ())}
+            for d := 10; d < 100; d += 10 {
+                row = append (row, format (levels.Percentile (float64 (d))))
+            }
+            w.Write (row)
+        }
+    }
+    w.Flush ()
+    if err := w.Error (); err != nil {
+        log.Fatal ("[aggregate_curves]: ", out, ": ", err)
+    }
+    f.Close ()
+
+    /* --- Area under the curve of each AS --- */
+    out = output_prefix + "_auc.csv"
+    f, err = os.Create (out)
+    if err != nil {
+        log.Fatal ("[aggregate_curves]: ", err)
+    }
+    w = csv.NewWriter (f)
+    header = []string{"curve", "probes"}
+    for _, metric := range curve_metrics {
+        header = append (header, "auc_" + metric.name)
+    }
+    w.Write (header)
+    for _, c := range curves {
+        row := []string{c.name, strconv.FormatFloat (c.total, 'f', -1, 64)}
+        for m := range curve_metrics {
+            row = append (row, format (c.auc (m, probe_at (c, end))))
+        }
+        w.Write (row)
+    }
+    w.Flush ()
+    if err := w.Error (); err != nil {
+        log.Fatal ("[aggregate_curves]: ", out, ": ", err)
+    }
+    f.Close ()
+    log.Println ("[aggregate_curves]: wrote", output_prefix + "_curves.csv and", out)
+}
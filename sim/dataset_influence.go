@@ -0,0 +1,195 @@
+/* ==================================================================================== *\
+     dataset_influence.go
+
+     How much each input dataset shaped the final order of the targets of an AS:
+     ---------------------------------------------------------------------------
+     The strategy is applied once with all datasets, then once per dataset with that
+     dataset neutralized (no AS relationships, uniform customer cones, no overlays, no
+     next-hop ASes). For each dataset, the rank correlation (Spearman) between both orders
+     tells how much the dataset influences the order (1: no influence), and, for the
+     reductions, the fraction of targets they removed.
+
+     The ip2as and warts datasets cannot be neutralized: they define the targets themselves.
+\* ==================================================================================== */
+
+package sim
+
+import (
+    "fmt"
+    "log"
+    "os"
+    )
+
+const influence_seed int64 = 1 // All runs draw the same random /24 prefixes as far as possible
+
+/**
+ * The influence of a dataset on the order of the targets.
+ */
+type dataset_influence struct {
+    dataset string;
+    applicable bool;  // False if the dataset was not given (or cannot be neutralized)
+    nb_targets int;   // Nb of targets once the dataset is neutralized
+    removed float64;  // Fraction of targets removed by the dataset (reductions only)
+    spearman float64; // Rank correlation between the final order and the order without the dataset
+}
+
+/**
+ * Applies the strategy to the AS of interest with the datasets, and returns the ordered targets, and
+ * its groups of ASes.
+ */
+func influence_targets (strategy int, ds *strategy_datasets, as_interest string, target_to_vp VP_mapper, destinations []string) ([]string, []*strategy_group) {
+    s := make ([]string, len (destinations)) // Some strategies sort the destinations in place.
+    copy (s, destinations)
+    targets, _, groups := strategies[strategy].fn (s, as_interest, target_to_vp, new_strategy_run (ds, influence_seed, as_interest))
+    return targets, groups
+}
+
+/**
+ * Returns the Spearman rank correlation between two orders, computed on their common targets.
+ */
+func spearman (order, other []string) float64 {
+    in_other := make (map[string]struct{}, len (other))
+    for _, target := range other {
+        in_other[target] = struct{}{}
+    }
+    ranks := make (map[string]int)
+    for _, target := range order {
+        if _, ok := in_other[target]; ok {
+            if _, seen := ranks[target]; !seen {
+                ranks[target] = len (ranks)
+            }
+        }
+    }
+    n := len (ranks)
+    if n < 2 {
+        return 1
+    }
+    sum, rank := 0.0, 0
+    seen := make (map[string]struct{}, n)
+    for _, target := range other {
+        if r, ok := ranks[target]; ok {
+            if _, dup := seen[target]; dup {
+                continue
+            }
+            seen[target] = struct{}{}
+            d := float64 (r - rank)
+            sum += d * d
+            rank++
+        }
+    }
+    return 1 - 6*sum/(float64 (n)*(float64 (n)*float64 (n) - 1))
+}
+
+/**
+ * Returns the fraction of the probes of the groups of a strategy (see strategy_group) whose group is
+ * ordered by AS relationships, by customer cone, by another criterion, or not ordered (internal
+ * prefixes), before any reduction. Returns false if the strategy does not probe groups of ASes.
+ */
+func group_ordering_fractions (groups []*strategy_group) (relationships, cone, other, internals float64, ok bool) {
+    if groups == nil {
+        return 0, 0, 0, 0, false
+    }
+    sizes := make (map[string]int)
+    total := 0
+    for _, group := range groups {
+        order := group.order
+        if group.name == group_internal {
+            order = group_internal
+        }
+        sizes[order] += group.size
+        total += group.size
+    }
+    if total == 0 {
+        return 0, 0, 0, 0, true
+    }
+    fraction := func (n int) float64 { return float64 (n)/float64 (total) }
+    other_orders := total - sizes[order_relationships] - sizes[order_customer_cone] - sizes[group_internal]
+    return fraction (sizes[order_relationships]), fraction (sizes[order_customer_cone]), fraction (other_orders), fraction (sizes[group_internal]), true
+}
+
+/**
+ * Computes the influence of each dataset on the order of the targets of the AS of interest. Each
+ * dataset is neutralized in a copy of the datasets: 'ds' is left untouched.
+ */
+func compute_dataset_influence (strategy int, ds *strategy_datasets, as_interest string, target_to_vp VP_mapper, destinations []string) []*dataset_influence {
+    final, _ := influence_targets (strategy, ds, as_interest, target_to_vp, destinations)
+
+    /* --- Neutralizes a dataset in the copy, and returns the function cleaning up after it --- */
+    neutralizers := []struct {
+        dataset string;
+        used bool;
+        reduction bool;
+        neutralize func (without *strategy_datasets) func ();
+    }{
+        {"as-rel", true, false, func (without *strategy_datasets) func () {
+            without.as_rel = new_as_graph (make (map[string]map[string]interface{}))
+            return func () {}
+        }},
+        {"ppdc", true, false, func (without *strategy_datasets) func () {
+            without.as_conesize = make (map[string]int)
+            return func () {}
+        }},
+        {"overlays", ds.overlays_file != "", true, func (without *strategy_datasets) func () {
+            without.overlays_file = os.DevNull
+            return func () {}
+        }},
+        {"next-hop", ds.nexthop_dir != "", true, func (without *strategy_datasets) func () {
+            dir, err := os.MkdirTemp ("", "anaximander_nexthop")
+            if err != nil {
+                log.Fatal (err)
+            }
+            if err := os.WriteFile (dir + "/merged_next_AS_" + as_interest + ".txt", nil, 0644); err != nil {
+                log.Fatal (err)
+            }
+            without.nexthop_dir = dir
+            return func () { os.RemoveAll (dir) }
+        }},
+    }
+
+    influences := make ([]*dataset_influence, 0, len (neutralizers) + 2)
+    for _, n := range neutralizers {
+        influence := &dataset_influence{dataset: n.dataset, applicable: n.used}
+        influences = append (influences, influence)
+        if !n.used {
+            continue
+        }
+        without := *ds
+        clean_up := n.neutralize (&without)
+        targets, _ := influence_targets (strategy, &without, as_interest, target_to_vp, destinations)
+        clean_up ()
+
+        influence.nb_targets = len (targets)
+        influence.spearman = spearman (final, targets)
+        if n.reduction && len (targets) != 0 {
+            influence.removed = float64 (len (targets) - len (final))/float64 (len (targets))
+        }
+    }
+    influences = append (influences, &dataset_influence{dataset: "ip2as"}, &dataset_influence{dataset: "warts"})
+    return influences
+}
+
+/**
+ * Prints the influence of each dataset on the order of the targets of the AS of interest.
+ */
+func launch_dataset_influence (break_len int, strategy int, as_interest string) {
+    if strategy < 0 || strategy >= len (strategies) {
+        log.Fatal ("[launch_dataset_influence]: unknown strategy ", strategy)
+    }
+    _, target_to_vp, destinations, ds := read_strategy_data (break_len)
+    output_on = false
+
+    final, groups := influence_targets (strategy, ds, as_interest, target_to_vp, destinations)
+    fmt.Printf ("Strategy %d (%s), AS of interest %s: %d targets\n", strategy, strategies[strategy].name, as_interest, len (final))
+    if relationships, cone, other, internals, ok := group_ordering_fractions (groups); ok {
+        fmt.Printf ("Group ordering (before reductions): %.4f by AS relationships, %.4f by customer cone, %.4f by another criterion, %.4f internal\n", relationships, cone, other, internals)
+    }
+
+    fmt.Printf ("%-10s %10s %10s %10s\n", "dataset", "targets", "removed", "spearman")
+    for _, influence := range compute_dataset_influence (strategy, ds, as_interest, target_to_vp, destinations) {
+        if !influence.applicable {
+            fmt.Printf ("%-10s %10s %10s %10s\n", influence.dataset, "n/a", "n/a", "n/a")
+            continue
+        }
+        fmt.Printf ("%-10s %10d %10.4f %10.4f\n", influence.dataset, influence.nb_targets, influence.removed, influence.spearman)
+    }
+}
@@ -0,0 +1,32 @@
+package sim
+
+import (
+    "reflect"
+    "testing"
+    )
+
+/**
+ * Without the customer cones, the neighbors are no longer ordered 300, 200, 400, and the datasets
+ * of the run are left untouched.
+ */
+func TestDatasetInfluenceCopy (t *testing.T) {
+    ds, target_to_vp, destinations := load_strategy_universe (t)
+    strategy, err := strategy_index ("directed_probing_internal_neighbors_onehopneighbors_others")
+    if err != nil {
+        t.Fatal (err)
+    }
+    final, _ := influence_targets (strategy, ds, "100", target_to_vp, destinations)
+    influences := compute_dataset_influence (strategy, ds, "100", target_to_vp, destinations)
+    for _, influence := range influences {
+        if influence.dataset == "ppdc" && (!influence.applicable || influence.spearman >= 1) {
+            t.Errorf ("ppdc: %+v, want an order changed without the customer cones", influence)
+        }
+    }
+
+    if len (ds.as_conesize) == 0 || len (ds.as_rel.neighbors) == 0 {
+        t.Fatal ("datasets of the run neutralized")
+    }
+    if again, _ := influence_targets (strategy, ds, "100", target_to_vp, destinations); !reflect.DeepEqual (again, final) {
+        t.Errorf ("order changed after the influence: %v, was %v", again, final)
+    }
+}
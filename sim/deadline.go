@@ -0,0 +1,201 @@
+/* ==================================================================================== *\
+     deadline.go
+
+     Time-boxed runs:
+     ----------------
+     With -deadline (a duration, e.g. 3h30m, or a time, e.g. 2026-01-02T15:04), the
+     strategy and simulation steps check the remaining time at stage boundaries (after the
+     warts parsing, between ASes of interest, between the groups of the sequential
+     scheduler). When the remaining time is shorter than the mean duration of the units
+     of that kind observed so far, the remaining units are skipped: the results produced
+     so far are written as usual, the skipped units are listed, the run manifest is marked
+     as "deadline_truncated", and the process exits with exit_warnings.
+\* ==================================================================================== */
+
+package sim
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "log"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+    "sync"
+    "time"
+    )
+
+const exit_warnings = 2 // The run completed, but its results are partial
+
+/**
+ * Value of the -deadline flag: a duration from now, or an absolute (local) time.
+ */
+type deadline_value struct {
+    deadline *time.Time;
+}
+
+func (v *deadline_value) String () string {
+    if v == nil || v.deadline == nil || v.deadline.IsZero () {
+        return ""
+    }
+    return v.deadline.Format (time.RFC3339)
+}
+
+func (v *deadline_value) Set (s string) error {
+    if d, err := time.ParseDuration (s); err == nil {
+        *v.deadline = time.Now ().Add (d)
+        return nil
+    }
+    for _, layout := range []string{time.RFC3339, "2006-01-02T15:04", "2006-01-02 15:04"} {
+        if t, err := time.ParseInLocation (layout, s, time.Local); err == nil {
+            *v.deadline = t
+            return nil
+        }
+    }
+    return errors.New ("neither a duration (e.g. 3h30m) nor a time (e.g. 2026-01-02T15:04)")
+}
+
+/* ------------------------------------------------------------------------------- *\
+                                 Stage boundaries
+\* ------------------------------------------------------------------------------- */
+
+type unit_durations struct {
+    count int;
+    total time.Duration;
+}
+
+var deadline_state = struct {
+    mux sync.Mutex;
+    units map[string]*unit_durations; // Kind of unit ("AS", "group") -> observed durations
+    skipped []string;                 // Skipped units, in the order they were skipped
+}{units: make (map[string]*unit_durations)}
+
+/**
+ * Returns true if the remaining time allows to process one more unit of the given kind,
+ * estimated from the mean duration of the units of that kind processed so far.
+ * Always true without -deadline.
+ */
+func deadline_allows (kind string) bool {
+    if g_args.deadline.IsZero () {
+        return true
+    }
+    deadline_state.mux.Lock ()
+    defer deadline_state.mux.Unlock ()
+    var estimate time.Duration
+    if u, ok := deadline_state.units[kind]; ok && u.count != 0 {
+        estimate = u.total / time.Duration (u.count)
+    }
+    return time.Until (g_args.deadline) > estimate
+}
+
+/**
+ * Records the duration of a unit of the given kind, started at 'start'.
+ */
+func deadline_unit_done (kind string, start time.Time) {
+    deadline_state.mux.Lock ()
+    defer deadline_state.mux.Unlock ()
+    u, ok := deadline_state.units[kind]
+    if !ok {
+        u = &unit_durations{}
+        deadline_state.units[kind] = u
+    }
+    u.count++
+    u.total += time.Since (start)
+}
+
+/**
+ * Records a unit skipped because of the deadline (e.g., "AS 3356").
+ */
+func deadline_skip (unit string) {
+    deadline_state.mux.Lock ()
+    defer deadline_state.mux.Unlock ()
+    deadline_state.skipped = append (deadline_state.skipped, unit)
+}
+
+/**
+ * Wraps the processing of an AS of interest: the AS is skipped if the deadline does not
+ * allow it, and its duration is recorded otherwise. The marker of the run (if any, see
+ * Options.Marker) is added to the skipped ASes.
+ */
+func deadline_guard (f func (string), marker string) func (string) {
+    if g_args.deadline.IsZero () {
+        return f
+    }
+    return func (as_interest string) {
+        if !deadline_allows ("AS") {
+            unit := "AS " + as_interest
+            if marker != "" {
+                unit += " (" + marker + ")"
+            }
+            deadline_skip (unit)
+            summary_unit ("ASes", unit_skipped)
+            return
+        }
+        start := time.Now ()
+        f (as_interest)
+        deadline_unit_done ("AS", start)
+    }
+}
+
+/* ------------------------------------------------------------------------------- *\
+                                    Reporting
+\* ------------------------------------------------------------------------------- */
+
+/**
+ * If units were skipped because of the deadline, lists them in <prefix>deadline_truncated.txt,
+ * marks the manifest of the run (output_dir, see manifest.go) and the dumped configuration (if any,
+ * see -dump-config) as "deadline_truncated", and returns true.
+ */
+func report_deadline_truncation (prefix, output_dir, run_config string) bool {
+    deadline_state.mux.Lock ()
+    skipped := append ([]string (nil), deadline_state.skipped...)
+    deadline_state.mux.Unlock ()
+    if len (skipped) == 0 {
+        return false
+    }
+    sort.Strings (skipped)
+    summary_warning (fmt.Sprintf ("deadline %s reached, %d units skipped", g_args.deadline.Format (time.RFC3339), len (skipped)))
+    log.Printf ("[deadline]: deadline %s reached, %d units skipped (see %sdeadline_truncated.txt)", g_args.deadline.Format (time.RFC3339), len (skipped), prefix)
+    if err := os.WriteFile (prefix + "deadline_truncated.txt", []byte (strings.Join (skipped, "\n") + "\n"), 0644); err != nil {
+        log.Print ("[deadline]: ", err)
+    }
+
+    /* --- Mark the manifest (always written) and the configuration (if dumped) --- */
+    if err := mark_deadline_truncated (filepath.Join (output_dir, manifest_file), skipped); err != nil {
+        log.Print ("[deadline]: ", err)
+    }
+    if err := mark_deadline_truncated (run_config, skipped); err != nil && !errors.Is (err, os.ErrNotExist) {
+        log.Print ("[deadline]: ", err)
+    }
+    return true
+}
+
+/**
+ * Adds "deadline_truncated" and the skipped units to a JSON file.
+ */
+func mark_deadline_truncated (filename string, skipped []string) error {
+    content, err := os.ReadFile (filename)
+    if err != nil {
+        return err
+    }
+    values := make (map[string]interface{})
+    if err := json.Unmarshal (content, &values); err != nil {
+        return fmt.Errorf ("%s: %w", filename, err)
+    }
+    values["deadline_truncated"] = true
+    values["deadline_skipped"] = skipped
+    content, _ = json.MarshalIndent (values, "", "  ")
+    return os.WriteFile (filename, append (content, '\n'), 0644)
+}
+
+/**
+ * Returns a one-line description of the remaining time, for the logs.
+ */
+func deadline_string () string {
+    if g_args.deadline.IsZero () {
+        return "no deadline"
+    }
+    return fmt.Sprintf ("deadline %s (in %s)", g_args.deadline.Format (time.RFC3339), time.Until (g_args.deadline).Round (time.Second))
+}
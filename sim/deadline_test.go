@@ -0,0 +1,79 @@
+package sim
+
+import (
+    "encoding/json"
+    "os"
+    "path/filepath"
+    "testing"
+    "time"
+    )
+
+/**
+ * Sets a deadline already reached, until the end of the test.
+ */
+func reached_deadline (t *testing.T) {
+    g_args.deadline = time.Now ().Add (-time.Second)
+    t.Cleanup (func () {
+        g_args.deadline = time.Time{}
+        deadline_state.mux.Lock ()
+        deadline_state.skipped, deadline_state.units = nil, make (map[string]*unit_durations)
+        deadline_state.mux.Unlock ()
+    })
+}
+
+func TestDeadlineTruncationManifest (t *testing.T) {
+    reached_deadline (t)
+    simulated := 0
+    f := deadline_guard (func (string) { simulated++ }, "t_0.5")
+    f ("100")
+    f ("200")
+    if simulated != 0 {
+        t.Fatalf ("%d ASes simulated after the deadline", simulated)
+    }
+
+    /* --- The manifest is marked, even without a dumped configuration --- */
+    dir := t.TempDir ()
+    content, _ := json.Marshal (&run_manifest{Command: "simulation", Seed: 7})
+    if err := os.WriteFile (filepath.Join (dir, manifest_file), content, 0644); err != nil {
+        t.Fatal (err)
+    }
+    prefix := filepath.Join (dir, "simulation_")
+    if !report_deadline_truncation (prefix, dir, filepath.Join (dir, "simulation.txt_run_config.json")) {
+        t.Fatal ("run not reported as truncated")
+    }
+    content, err := os.ReadFile (filepath.Join (dir, manifest_file))
+    if err != nil {
+        t.Fatal (err)
+    }
+    var m run_manifest
+    if err := json.Unmarshal (content, &m); err != nil {
+        t.Fatal (err)
+    }
+    if !m.Deadline_truncated || len (m.Deadline_skipped) != 2 || m.Deadline_skipped[0] != "AS 100 (t_0.5)" || m.Command != "simulation" || m.Seed != 7 {
+        t.Errorf ("manifest: %+v", m)
+    }
+    if _, err := os.Stat (prefix + "deadline_truncated.txt"); err != nil {
+        t.Error (err)
+    }
+    if _, err := os.Stat (filepath.Join (dir, "simulation.txt_run_config.json")); err == nil {
+        t.Error ("configuration written without -dump-config")
+    }
+}
+
+func TestDeadlineSkipsGroups (t *testing.T) {
+    ds := load_test_datasets (t)
+    reached_deadline (t)
+    r := simulate_test_as (t, ds, "100", Options{Threshold: 1})
+    if r.Stats.Probes != 0 || len (r.GroupLimits) != 0 {
+        t.Errorf ("%d probes, limits %v after the deadline", r.Stats.Probes, r.GroupLimits)
+    }
+    if len (deadline_state.skipped) != 1 {
+        t.Errorf ("skipped: %v", deadline_state.skipped)
+    }
+}
+
+func TestNoDeadlineNoTruncation (t *testing.T) {
+    if report_deadline_truncation (filepath.Join (t.TempDir (), "x_"), t.TempDir (), "") {
+        t.Error ("run reported as truncated without deadline")
+    }
+}
@@ -0,0 +1,262 @@
+/* ==================================================================================== *\
+     differential.go
+
+     Differential ordering of the targets between two BGP snapshots:
+     ---------------------------------------------------------------
+     For continuous mapping, a new campaign first probes the parts of the AS of interest
+     whose routing changed. Given two ribs_multi output directories (-diff_old, -diff_new),
+     each directed probe of the AS of interest in the new snapshot is classified as:
+       - new:      the prefix is absent from all the old forwarding tables
+       - back:     the prefix was in the old tables, but its routes did not go through
+                   the AS of interest (withdrawn from the AS, and back)
+       - next_hop: the next-hop AS of the AS of interest changed on at least one collector
+       - path:     the best AS path through the AS of interest changed on at least one collector
+       - unchanged
+     (the first matching class is kept). The targets of the base strategy whose prefix changed
+     are then moved before the others, each class keeping the order and the groups of the base
+     strategy (i.e., the usual cone rules within each group).
+
+     Both snapshots must have been built with the same heuristic (the valley free heuristic
+     stores the AS paths reversed). Targets outside the directed probes of the new snapshot
+     are considered unchanged.
+\* ==================================================================================== */
+
+package sim
+
+import (
+    "log"
+    "net"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+    pool "github.com/Emeline-1/pool"
+    )
+
+/* --- Classes of routing changes, in priority order --- */
+const (
+    route_new = "new"
+    route_back = "back"
+    route_next_hop = "next_hop"
+    route_path = "path"
+    route_unchanged = "unchanged"
+)
+
+var route_classes = []string{route_new, route_back, route_next_hop, route_path, route_unchanged}
+
+/**
+ * Routing changes of each AS of interest: AS -> prefix -> class (changed prefixes only).
+ * Nil without -diff_old/-diff_new.
+ */
+var route_changes map[string]map[string]string
+
+/**
+ * The routes of a ribs_multi output directory, restricted to the ASes of interest.
+ */
+type route_snapshot struct {
+    prefixes map[string]struct{};           // All the prefixes of the forwarding tables
+    next_hops map[string]map[string]string; // AS of interest -> prefix -> next-hop AS on each collector
+    paths map[string]map[string]string;     // AS of interest -> prefix -> AS path through the AS on each collector
+}
+
+/**
+ * Returns the collectors of a ribs_multi output directory (from its forwarding tables), sorted.
+ * The directories of the tables per peer (-per-peer) are skipped.
+ */
+func snapshot_collectors (dir string) []string {
+    collectors := make ([]string, 0)
+    if files := pool.Get_directory_files (dir + "/forwarding_tables"); files != nil {
+        for _, file := range *files {
+            if info, err := os.Stat (file); err == nil && info.IsDir () {
+                continue
+            }
+            collectors = append (collectors, trim_suffix (trim_suffix (filepath.Base (file), ".gz"), ".txt"))
+        }
+    }
+    sort.Strings (collectors)
+    return collectors
+}
+
+/**
+ * Opens a file of a ribs_multi output directory, possibly compressed.
+ */
+func open_snapshot_file (filename string) (*CompressedReader, error) {
+    if _, err := os.Stat (filename); err != nil {
+        filename += ".gz"
+    }
+    reader := NewCompressedReader (filename)
+    if err := reader.Open (); err != nil {
+        return nil, err
+    }
+    return reader, nil
+}
+
+/**
+ * Reads the forwarding tables ([prefix as_path]) and the next-hop ASes ([prefix AS next_hop_AS])
+ * of a ribs_multi output directory. Only the routes through the ASes of interest are kept.
+ * The collectors are read in the same (sorted) order for both snapshots, so that the routes
+ * of a prefix can be compared as strings.
+ */
+func read_route_snapshot (dir string, ases_interest []string) *route_snapshot {
+    snapshot := &route_snapshot{
+        prefixes: make (map[string]struct{}),
+        next_hops: make (map[string]map[string]string),
+        paths: make (map[string]map[string]string),
+    }
+    for _, as := range ases_interest {
+        snapshot.next_hops[as] = make (map[string]string)
+        snapshot.paths[as] = make (map[string]string)
+    }
+
+    for _, collector := range snapshot_collectors (dir) {
+        /* --- Best AS paths --- */
+        reader, err := open_snapshot_file (dir + "/forwarding_tables/" + collector + ".txt")
+        if err != nil {
+            log.Print ("[read_route_snapshot]: ", err)
+            continue
+        }
+        scanner := reader.Scanner ()
+        for scanner.Scan () {
+            prefix, as_path, ok := parse_forwarding_table_line (scanner.Text ())
+            if !ok {
+                continue
+            }
+            snapshot.prefixes[prefix] = struct{}{}
+            for _, as := range as_path {
+                if paths, ok := snapshot.paths[as]; ok {
+                    paths[prefix] += collector + ":" + strings.Join (as_path, "_") + " "
+                }
+            }
+        }
+        if err := scanner.Err (); err != nil {
+            log.Print ("[read_route_snapshot]: WARNING: ", collector, ": forwarding table: ", err)
+        }
+        reader.Close ()
+
+        /* --- Next-hop ASes --- */
+        reader, err = open_snapshot_file (dir + "/next-hop_AS/" + collector + "/next_hop_AS_" + collector + ".txt")
+        if err != nil {
+            log.Print ("[read_route_snapshot]: ", err)
+            continue
+        }
+        scanner = reader.Scanner ()
+        for scanner.Scan () {
+            line := strings.Fields (scanner.Text ())
+            if len (line) < 3 {
+                continue
+            }
+            if next_hops, ok := snapshot.next_hops[line[1]]; ok {
+                next_hops[line[0]] += collector + ":" + line[2] + " "
+            }
+        }
+        if err := scanner.Err (); err != nil {
+            log.Print ("[read_route_snapshot]: WARNING: ", collector, ": next-hop ASes: ", err)
+        }
+        reader.Close ()
+    }
+    return snapshot
+}
+
+/**
+ * Classifies the directed probe 'prefix' of the AS of interest in the new snapshot ('after') (see route_classes).
+ */
+func classify_route (before, after *route_snapshot, as_interest, prefix string) string {
+    if _, ok := before.prefixes[prefix]; !ok {
+        return route_new
+    }
+    next_hops, ok := before.next_hops[as_interest][prefix]
+    if !ok {
+        return route_back
+    }
+    if next_hops != after.next_hops[as_interest][prefix] {
+        return route_next_hop
+    }
+    if before.paths[as_interest][prefix] != after.paths[as_interest][prefix] {
+        return route_path
+    }
+    return route_unchanged
+}
+
+/**
+ * Computes the routing changes of each AS of interest between two ribs_multi output directories.
+ */
+func compute_route_changes (old_dir, new_dir string, ases_interest []string) map[string]map[string]string {
+    before, after := read_route_snapshot (old_dir, ases_interest), read_route_snapshot (new_dir, ases_interest)
+    changes := make (map[string]map[string]string, len (ases_interest))
+    for _, as := range ases_interest {
+        changes[as] = make (map[string]string)
+        for prefix := range after.next_hops[as] { // The directed probes of the AS (see write_directed_prefixes)
+            if class := classify_route (before, after, as, prefix); class != route_unchanged {
+                changes[as][prefix] = class
+            }
+        }
+        log.Println ("[compute_route_changes]: AS", as, ":", len (changes[as]), "of", len (after.next_hops[as]), "directed probes changed")
+    }
+    return changes
+}
+
+/**
+ * Returns the class of the routing change of a target: the class of the most specific changed
+ * prefix containing it (a target may be a /24 picked in a directed probe).
+ */
+func route_class (changes map[string]string, target string) string {
+    if class, ok := changes[target]; ok {
+        return class
+    }
+    ip, network, err := net.ParseCIDR (target)
+    if err != nil {
+        return route_unchanged
+    }
+    ones, bits := network.Mask.Size ()
+    for mask := ones - 1; mask >= 0; mask-- {
+        m := net.CIDRMask (mask, bits)
+        if class, ok := changes[(&net.IPNet{IP: ip.Mask (m), Mask: m}).String ()]; ok {
+            return class
+        }
+    }
+    return route_unchanged
+}
+
+/**
+ * Moves the targets whose routes changed before the others. Within each of both parts, the
+ * targets keep the order and the groups of the base strategy: each group (AS limit) is split
+ * in its changed and its unchanged targets.
+ * Returns the reordered targets and limits, and the number of targets of each class.
+ */
+func differential_order (s []string, limits []*AS_limit, as_interest string) ([]string, []*AS_limit, map[string]int) {
+    changes := route_changes[as_interest]
+    counts := make (map[string]int, len (route_classes))
+    changed, unchanged := make ([]string, 0, len (s)), make ([]string, 0, len (s))
+    changed_limits, unchanged_limits := make ([]*AS_limit, 0, len (limits)), make ([]*AS_limit, 0, len (limits))
+
+    split := func (targets []string) {
+        for _, target := range targets {
+            class := route_class (changes, target)
+            counts[class]++
+            if class == route_unchanged {
+                unchanged = append (unchanged, target)
+            } else {
+                changed = append (changed, target)
+            }
+        }
+    }
+    start := 0
+    for _, limit := range limits {
+        stop := limit.limit
+        if stop > len (s) {
+            stop = len (s)
+        }
+        if stop > start {
+            split (s[start:stop])
+            start = stop
+        }
+        changed_limits = append (changed_limits, &AS_limit{asn: limit.asn, limit: len (changed)})
+        unchanged_limits = append (unchanged_limits, &AS_limit{asn: limit.asn, limit: len (unchanged)})
+    }
+    split (s[start:]) // Targets after the last limit (none for the existing strategies)
+
+    for _, limit := range unchanged_limits {
+        limit.limit += len (changed)
+    }
+    return append (changed, unchanged...), append (changed_limits, unchanged_limits...), counts
+}
@@ -0,0 +1,239 @@
+package sim
+
+import (
+    "bufio"
+    "bytes"
+    "crypto/sha256"
+    "database/sql"
+    "encoding/hex"
+    "os"
+    "os/exec"
+    "path/filepath"
+    "strings"
+    "testing"
+    )
+
+/**
+ * Checks every strategy against its golden outputs on the synthetic universe: each strategy of
+ * 'anaximander strategy list' is run with the inputs it lists (the strategies on a warts data set get
+ * the traces of two VPs, traces/ and vps.txt) and a fixed seed, and its targets and AS limits are
+ * compared with testdata/golden/expected/<strategy>/<AS>. No strategy is skipped.
+ * Each strategy runs in a process of its own, as from the command line (the strategies set
+ * package variables). Set ANAXIMANDER_UPDATE_GOLDEN=1 to regenerate the golden files on purpose.
+ */
+func TestGolden (t *testing.T) {
+    if testing.Short () {
+        t.Skip ("runs every strategy (-short)")
+    }
+    golden, _ := filepath.Abs (filepath.Join ("..", "testdata", "golden"))
+    u, out := filepath.Join (golden, "universe"), t.TempDir ()
+    t.Setenv ("XDG_CACHE_HOME", filepath.Join (out, "cache")) // The warts cache of the runs
+
+    /* --- bdrmapit database, from its SQL dump --- */
+    bdrmapit := filepath.Join (out, "bdrmapit.db")
+    dump, err := os.ReadFile (filepath.Join (u, "bdrmapit.sql"))
+    if err != nil {
+        t.Fatal (err)
+    }
+    db, err := sql.Open ("sqlite3", bdrmapit)
+    if err != nil {
+        t.Fatal (err)
+    }
+    if _, err := db.Exec (string (dump)); err != nil {
+        t.Fatal (err)
+    }
+    db.Close ()
+
+    bin := build_anaximander (t)
+    input := map[string]string {
+        "-ip2as": filepath.Join (u, "ip2as.txt"), "-asrel": filepath.Join (u, "as_rel.txt"), "-ppdc": filepath.Join (u, "ppdc.txt"),
+        "-warts": filepath.Join (u, "traces"), "-vps": filepath.Join (u, "vps.txt"), "-bdr": bdrmapit,
+        "-dp_dir": filepath.Join (u, "directed_prefixes"), "-overlays_file": filepath.Join (u, "overlays.txt"), "-overlays_dir": filepath.Join (u, "overlays"),
+        "-vp_collectors": filepath.Join (u, "vp_collectors.txt"), "-nexthop_dir": filepath.Join (u, "next_hop_AS"), "-as2org": filepath.Join (u, "as2org.txt"),
+        "-oracle_dir": filepath.Join (u, "oracle"),
+    }
+    ases, err := read_whitespace_delimited_file (filepath.Join (u, "ases.txt"))
+    if err != nil {
+        t.Fatal (err)
+    }
+
+    /* --- Strategies, by three lines: index and name, description, inputs --- */
+    list, err := exec.Command (bin, "strategy", "list").Output ()
+    if err != nil {
+        t.Fatal ("strategy list: ", err)
+    }
+    lines := strings.Split (strings.TrimRight (string (list), "\n"), "\n")
+    if len (lines) == 0 || len (lines) % 3 != 0 {
+        t.Fatalf ("strategy list: %d lines", len (lines))
+    }
+    update := os.Getenv ("ANAXIMANDER_UPDATE_GOLDEN") != ""
+    for i := 0; i < len (lines); i += 3 {
+        fields := strings.Fields (lines[i])
+        strategy, name := fields[0], fields[1]
+        t.Run (strategy + "_" + name, func (t *testing.T) {
+            args := []string{"strategy", "-s", strategy, "-seed", "42", "-no-cache", "-ases", filepath.Join (u, "ases.txt")}
+            for _, flag := range strings.Fields (strings.TrimPrefix (strings.TrimSpace (lines[i+2]), "inputs:")) {
+                file, ok := input[flag]
+                if !ok {
+                    t.Fatalf ("no fixture for input %s", flag)
+                }
+                args = append (args, flag, file)
+            }
+            dir := filepath.Join (out, strategy)
+            if err := os.Mkdir (dir, 0755); err != nil {
+                t.Fatal (err)
+            }
+            if output, err := exec.Command (bin, append (args, "-o", dir)...).CombinedOutput (); err != nil {
+                t.Fatalf ("%v\n%s", err, last_lines (string (output), 3))
+            }
+            for _, as := range ases {
+                for _, file := range []string{"targets.txt", "targets_raw_prefixes.txt", "as_limits.txt"} {
+                    got, err := os.ReadFile (filepath.Join (dir, as, file))
+                    if err != nil {
+                        t.Fatal (err)
+                    }
+                    expected := filepath.Join (golden, "expected", strategy, as, file)
+                    if update {
+                        if err := os.MkdirAll (filepath.Dir (expected), 0755); err != nil {
+                            t.Fatal (err)
+                        }
+                        if err := os.WriteFile (expected, got, 0644); err != nil {
+                            t.Fatal (err)
+                        }
+                        continue
+                    }
+                    want, err := os.ReadFile (expected)
+                    if err != nil {
+                        t.Fatal (err)
+                    }
+                    if line, g, w, diverged := first_divergence (got, want); diverged {
+                        t.Errorf ("AS %s, %s diverged at line %d: %q, want %q", as, file, line, g, w)
+                    }
+                }
+            }
+        })
+    }
+}
+
+/**
+ * Builds the command (the strategies set package variables: each one runs in a process of its own).
+ */
+func build_anaximander (t *testing.T) string {
+    t.Helper ()
+    bin := filepath.Join (t.TempDir (), "anaximander")
+    if output, err := exec.Command ("go", "build", "-o", bin, "..").CombinedOutput (); err != nil {
+        t.Fatalf ("go build: %v\n%s", err, output)
+    }
+    return bin
+}
+
+/**
+ * Runs the strategy overlays_reduction_global_relationships on the golden universe, with the extra
+ * flags, and compares the given files of its output directory with testdata/<fixture>/expected (by
+ * their base names). Set ANAXIMANDER_UPDATE_GOLDEN=1 to regenerate the expected files on purpose.
+ * Returns the output directory.
+ */
+func run_golden_strategy (t *testing.T, fixture string, files []string, flags ...string) string {
+    t.Helper ()
+    if testing.Short () {
+        t.Skip ("runs a strategy (-short)")
+    }
+    u, out := filepath.Join ("..", "testdata", "golden", "universe"), t.TempDir ()
+    t.Setenv ("XDG_CACHE_HOME", filepath.Join (out, "cache"))
+    args := []string{"strategy", "-s", "overlays_reduction_global_relationships", "-seed", "1", "-ases", filepath.Join (u, "ases.txt"),
+        "-asrel", filepath.Join (u, "as_rel.txt"), "-ppdc", filepath.Join (u, "ppdc.txt"), "-ip2as", filepath.Join (u, "ip2as.txt"),
+        "-dp_dir", filepath.Join (u, "directed_prefixes"), "-overlays_file", filepath.Join (u, "overlays.txt"), "-o", out}
+    stdout, err := os.Create (filepath.Join (out, "output.txt")) // Split per first column at the end of the run
+    if err != nil {
+        t.Fatal (err)
+    }
+    defer stdout.Close ()
+    var stderr bytes.Buffer
+    cmd := exec.Command (build_anaximander (t), append (args, flags...)...)
+    cmd.Stdout, cmd.Stderr = stdout, &stderr
+    if err := cmd.Run (); err != nil {
+        t.Fatalf ("%v\n%s", err, last_lines (stderr.String (), 3))
+    }
+    for _, file := range files {
+        got, err := os.ReadFile (filepath.Join (out, file))
+        if err != nil {
+            t.Fatal (err)
+        }
+        expected := filepath.Join ("..", "testdata", fixture, "expected", filepath.Base (file))
+        if os.Getenv ("ANAXIMANDER_UPDATE_GOLDEN") != "" {
+            if err := os.WriteFile (expected, got, 0644); err != nil {
+                t.Fatal (err)
+            }
+            continue
+        }
+        want, err := os.ReadFile (expected)
+        if err != nil {
+            t.Fatal (err)
+        }
+        if line, g, w, diverged := first_divergence (got, want); diverged {
+            t.Errorf ("%s diverged at line %d: %q, want %q", file, line, g, w)
+        }
+    }
+    return out
+}
+
+/**
+ * The allowlist of target ASes (-target_as_allowlist) on the golden universe: AS 400, a direct neighbor
+ * (provider) of AS 100, and AS 600 are not allowed, so their prefixes (14.0.0.0/8 and 16.0.0.0/8) never
+ * reach the targets, and the strategy metadata record the hash of the allowlist.
+ */
+func TestGoldenAllowlist (t *testing.T) {
+    out := run_golden_strategy (t, "allowlist", []string{"100/targets.txt", "100/targets_raw_prefixes.txt", "100/as_limits.txt", "allowlist_excluded.txt"},
+        "-target_as_allowlist", filepath.Join ("..", "testdata", "allowlist", "allowlist.txt"))
+    targets, err := os.ReadFile (filepath.Join (out, "100", "targets.txt"))
+    if err != nil {
+        t.Fatal (err)
+    }
+    for _, target := range strings.Fields (string (targets)) {
+        if strings.HasPrefix (target, "14.") || strings.HasPrefix (target, "16.") {
+            t.Errorf ("target %s outside the allowlist", target)
+        }
+    }
+    metadata, err := os.ReadFile (filepath.Join (out, "strategy_metadata.json"))
+    if err != nil {
+        t.Fatal (err)
+    }
+    hash := sha256.Sum256 ([]byte ("200\n300\n500\n700\n"))
+    if !strings.Contains (string (metadata), `"target_as_allowlist_sha256": "` + hex.EncodeToString (hash[:]) + `"`) {
+        t.Errorf ("hash of the allowlist missing from strategy_metadata.json:\n%s", metadata)
+    }
+}
+
+/**
+ * The differential ordering (-diff_old/-diff_new) on the golden universe: between both ribs_multi snapshots
+ * of testdata/differential, only the next-hop AS of 15.0.0.0/24 changed, so its target comes first.
+ */
+func TestGoldenDifferential (t *testing.T) {
+    d := filepath.Join ("..", "testdata", "differential")
+    run_golden_strategy (t, "differential", []string{"100/targets.txt", "100/targets_raw_prefixes.txt", "100/as_limits.txt", "route_changes.txt"},
+        "-diff_old", filepath.Join (d, "old"), "-diff_new", filepath.Join (d, "new"))
+}
+
+/**
+ * Returns the first line (from 1) where got and want differ, and both lines ("" past the end).
+ */
+func first_divergence (got, want []byte) (int, string, string, bool) {
+    g, w := bufio.NewScanner (bytes.NewReader (got)), bufio.NewScanner (bytes.NewReader (want))
+    for line := 1; ; line++ {
+        more_g, more_w := g.Scan (), w.Scan ()
+        if !more_g && !more_w {
+            return 0, "", "", false
+        }
+        if more_g != more_w || g.Text () != w.Text () {
+            return line, g.Text (), w.Text (), true
+        }
+    }
+}
+
+func last_lines (s string, n int) string {
+    lines := strings.Split (strings.TrimRight (s, "\n"), "\n")
+    if len (lines) > n {
+        lines = lines[len (lines) - n:]
+    }
+    return strings.Join (lines, "\n")
+}
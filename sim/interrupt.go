@@ -0,0 +1,102 @@
+/* ==================================================================================== *\
+     interrupt.go
+
+     Graceful shutdown:
+     ------------------
+     The simulation and the RIB parsing catch SIGINT and SIGTERM: the first signal cancels
+     the context of the run. The pools stop handing out their items, the simulation of each
+     AS in progress stops its probing loop at the next probe and writes what it has (marked
+     "interrupted" in its summary, and not recorded in the checkpoint, so that -resume
+     simulates it again), the limits files gathered so far are flushed, the bgpreader
+     processes are killed, and the process exits with exit_interrupted. A second signal
+     exits at once.
+\* ==================================================================================== */
+
+package sim
+
+import (
+    "context"
+    "log"
+    "os"
+    "os/signal"
+    "sync"
+    "syscall"
+    )
+
+const exit_interrupted = 130 // As a shell reports a process killed by SIGINT (128 + 2)
+
+/**
+ * Context of the run, cancelled by the first SIGINT or SIGTERM once interrupt_begin is called.
+ */
+var run_context = context.Background ()
+
+var interruption = struct {
+    mux sync.Mutex;
+    partial []string; // Units whose results are partial, in the order they were interrupted
+}{}
+
+/**
+ * Installs the signal handler, and returns the context of the run.
+ */
+func interrupt_begin () context.Context {
+    ctx, cancel := context.WithCancel (context.Background ())
+    run_context = ctx
+    signals := make (chan os.Signal, 2)
+    signal.Notify (signals, syscall.SIGINT, syscall.SIGTERM)
+    go func () {
+        sig := <-signals
+        log.Print ("[interrupt]: ", sig, " received: stopping, the partial results are written (again to exit at once)")
+        summary_warning ("signal received (" + sig.String () + "): the results are partial")
+        cancel ()
+        sig = <-signals
+        log.Print ("[interrupt]: ", sig, " received again: exiting")
+        os.Exit (exit_interrupted)
+    } ()
+    return ctx
+}
+
+/**
+ * Returns true once the run has been interrupted.
+ */
+func run_interrupted () bool {
+    return run_context.Err () != nil
+}
+
+/**
+ * Records a unit whose results are partial because of the interruption (e.g., "AS 3356").
+ */
+func interrupt_partial (unit string) {
+    interruption.mux.Lock ()
+    defer interruption.mux.Unlock ()
+    interruption.partial = append (interruption.partial, unit)
+    summary_warning (unit + ": interrupted, partial results")
+}
+
+/**
+ * Wraps the processing of an AS of interest: once the run is interrupted, the ASes that the
+ * pool has already handed out are skipped.
+ */
+func interrupt_guard (ctx context.Context, f func (string)) func (string) {
+    return func (as_interest string) {
+        if ctx.Err () != nil {
+            summary_unit ("ASes", unit_skipped)
+            return
+        }
+        f (as_interest)
+    }
+}
+
+/**
+ * Exits with exit_interrupted if the run was interrupted (once its results are written). The shared
+ * outputs are flushed first, as os.Exit skips the deferred calls.
+ */
+func exit_if_interrupted () {
+    if !run_interrupted () {
+        return
+    }
+    interruption.mux.Lock ()
+    log.Println ("[interrupt]: run interrupted,", len (interruption.partial), "partial units")
+    interruption.mux.Unlock ()
+    close_output ()
+    os.Exit (exit_interrupted)
+}
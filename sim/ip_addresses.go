@@ -0,0 +1,382 @@
+package sim
+
+import (
+    "net"
+    "encoding/binary"
+    "math/big"
+    "math/rand"
+    "strings"
+    "C"
+    "fmt"
+    "strconv"
+)
+
+/**
+ * Reminder on the types found in package 'net'
+ * IPNet is a struct containing IP IP and Mask IPMask
+ * IP is type []byte
+ * IPMask is type []byte
+ */
+const (
+    IPv4PrefixLen = 8 * net.IPv4len // max prefix length in bits (32)
+    IPv6PrefixLen = 8 * net.IPv6len // max prefix length in bits (128)
+    max_subnets_bits6 = 16 // An IPv6 prefix is not broken down into more than 2^16 subnets
+)
+
+/**
+ * Returns the mask length to which prefixes are broken down: the length of the targets for IPv4
+ * (see target_length), /48 for IPv6.
+ */
+func break_length (network *net.IPNet) int {
+    if network.IP.To4 () == nil {
+        return 48
+    }
+    return target_length ()
+}
+
+/**
+ * Returns the mask length of the IPv4 targets: the -break-len argument, or /24 if prefixes are not broken down.
+ */
+func target_length () int {
+    if g_args.break_len > 0 {
+        return g_args.break_len
+    }
+    return 24
+}
+
+/**
+ * Returns the IPv4 prefix of the given length containing the address (e.g., 1.2.3.0/24).
+ */
+func mask_ipv4 (address net.IP, length int) string {
+    return address.Mask (net.CIDRMask (length, IPv4PrefixLen)).String () + "/" + strconv.Itoa (length)
+}
+
+/**
+ * Given a net.IPNet and a mask length, returns a slice containing all subnets of length 'mask length' contained in 'subnet'.
+ * ex: 118.174.128.0/22, with mask length 24, gives:
+ * - 118.174.128.0/24
+ * - 118.174.129.0/24
+ * - 118.174.130.0/24
+ * - 118.174.131.0/24
+ * When 'mask length' is inferior to the mask length of 'subnet', returns the subnet with the new mask length.
+ * ex: 118.174.128.0/26, with mask length 24, gives 118.174.128.0/24
+ */
+func get_subnets (subnet *net.IPNet, mask_length int) []net.IPNet{
+    if subnet.IP.To4 () == nil {
+        return get_subnets6 (subnet, mask_length)
+    }
+    l,_ := subnet.Mask.Size ()
+    diff := mask_length - l
+
+    var subnets []net.IPNet
+    /* --- Requested mask length inferior to given subnet --- */
+    if diff <= 0 {
+        subnets = make ([]net.IPNet, 1)
+
+        m := net.CIDRMask(mask_length, IPv4PrefixLen) 
+        subnets[0] = *subnet
+        subnets[0].IP = subnet.IP.Mask (m) //returns the result of masking the IP address ip with mask
+        subnets[0].Mask = m
+        
+    } else { /* --- Requested mask length superior to given subnet --- */
+        nb_subnets := 1<<uint(diff)
+        subnets = make ([]net.IPNet, nb_subnets)
+
+        ip := ip_to_uint32 (&subnet.IP) 
+        host_length := IPv4PrefixLen - mask_length
+
+        for i := 0; i < nb_subnets; i++ {
+            new_ip := ip | uint32 (i << host_length)
+
+            subnets[i] = *new (net.IPNet) // * is to get the value
+            subnets[i].IP = *uint32_to_ip (new_ip)
+            subnets[i].Mask = net.CIDRMask(mask_length, IPv4PrefixLen)
+        }
+
+    }
+    return subnets
+}
+
+/**
+ * Same as get_subnets, without building the slice: calls fn on each subnet, until fn returns false
+ * (a /8 broken down into /24 gives 65,536 subnets).
+ * For IPv4, the IP of the subnet given to fn is reused for the next one: it must be copied to be
+ * kept after fn returns (its String () can be kept).
+ */
+func for_each_subnet (subnet *net.IPNet, mask_length int, fn func (net.IPNet) bool) {
+    if subnet.IP.To4 () == nil {
+        for _, s := range get_subnets6 (subnet, mask_length) {
+            if !fn (s) {
+                return
+            }
+        }
+        return
+    }
+    l,_ := subnet.Mask.Size ()
+    diff := mask_length - l
+    m := net.CIDRMask (mask_length, IPv4PrefixLen)
+
+    /* --- Requested mask length inferior to given subnet --- */
+    if diff <= 0 {
+        fn (net.IPNet{IP: subnet.IP.Mask (m), Mask: m})
+        return
+    }
+    /* --- Requested mask length superior to given subnet --- */
+    ip := ip_to_uint32 (&subnet.IP)
+    host_length := IPv4PrefixLen - mask_length
+    current := make (net.IP, net.IPv4len)
+    for i := 0; i < 1<<uint (diff); i++ {
+        binary.BigEndian.PutUint32 (current, ip | uint32 (i << host_length))
+        if !fn (net.IPNet{IP: current, Mask: m}) {
+            return
+        }
+    }
+}
+
+/**
+ * Same as get_subnets, for an IPv6 subnet. As an IPv6 prefix may span a huge number of
+ * subnets (a /16 has 2^32 /48), the subnet is not broken down if it would yield more than
+ * 2^max_subnets_bits6 subnets: it is returned as is.
+ */
+func get_subnets6 (subnet *net.IPNet, mask_length int) []net.IPNet {
+    l,_ := subnet.Mask.Size ()
+    diff := mask_length - l
+    m := net.CIDRMask (mask_length, IPv6PrefixLen)
+    if diff <= 0 {
+        return []net.IPNet{net.IPNet{IP: subnet.IP.Mask (m), Mask: m}}
+    }
+    if diff > max_subnets_bits6 {
+        return []net.IPNet{*subnet}
+    }
+
+    nb_subnets := 1<<uint(diff)
+    subnets := make ([]net.IPNet, nb_subnets)
+    host_length := IPv6PrefixLen - mask_length
+    for i := 0; i < nb_subnets; i++ {
+        ip := make (net.IP, net.IPv6len)
+        copy (ip, subnet.IP.To16 ())
+        /* --- OR the subnet index at bit host_length (at most max_subnets_bits6 bits, over 3 bytes) --- */
+        carry := uint32 (i) << uint (host_length % 8)
+        for b := net.IPv6len - 1 - host_length / 8; b >= 0 && carry != 0; b-- {
+            ip[b] |= byte (carry)
+            carry >>= 8
+        }
+        subnets[i] = net.IPNet{IP: ip, Mask: m}
+    }
+    return subnets
+}
+
+//export get_subnets_string
+func get_subnets_string (subnet string, mask_length int, p **C.char){
+    _, network, err := net.ParseCIDR (subnet)
+        if err != nil {
+            *p = C.CString("")
+        }
+    subnets := get_subnets (network, mask_length)
+
+    // Concatenate all subnets, separated by a dash
+    var subnets_string strings.Builder
+    sep := ""
+    for _, subnet := range subnets {
+        subnets_string.WriteString (sep + subnet.String ())
+        sep = "-"
+    }
+    *p = C.CString(subnets_string.String ())
+}
+
+//export test_return_string
+func test_return_string (p **C.char) {
+    *p = C.CString("Hello from go!")
+}
+
+func uint32_to_ip (ip uint32) *net.IP {
+    b := make ([]byte, net.IPv4len)
+    binary.BigEndian.PutUint32(b, ip)
+    n := net.IP (b)
+    return &n
+}
+
+func ip_to_uint32(ip *net.IP) (ret uint32) {
+    return uint32(binary.BigEndian.Uint32(ip.To4()))
+}
+
+func string_to_ip (prefix string) *net.IP {
+    ip, _, err := net.ParseCIDR (prefix) //network is *IPNet, //ip is IP
+    if err != nil {
+        panic ("[string_to_ip]: Error while parsing prefix: " + err.Error ())
+    }
+    return &ip
+}
+
+func string_to_net (prefix string) *net.IPNet {
+    _, network, err := net.ParseCIDR (prefix) //network is *IPNet, //ip is IP
+    if err != nil {
+        panic ("[string_to_net]: Error while parsing prefix: " + err.Error ())
+    }
+    return network
+}
+
+/**
+ * Given a subnet, returns a routable address picked randomly into the subnet.
+ * Yields only routable addresses (no host address or network address)
+ */
+func get_random_ip (r *rand.Rand, subnet *net.IPNet) *net.IP {
+    if subnet.IP.To4 () == nil {
+        return get_random_ip6 (r, subnet)
+    }
+    mask_length,_ := subnet.Mask.Size ()
+    host_length := IPv4PrefixLen - mask_length
+
+    min := 1 // Host address
+    max := (1 << uint(host_length)) - 2 // Network address
+    n := r.Intn(max - min + 1) + min
+
+    ip := ip_to_uint32 (&subnet.IP) 
+    ip = ip | uint32 (n)
+
+    return uint32_to_ip (ip)
+}
+
+/**
+ * Same as get_random_ip, for an IPv6 subnet (the host part is up to 128 bits long).
+ * The first and last addresses of the subnet are never picked either.
+ */
+func get_random_ip6 (r *rand.Rand, subnet *net.IPNet) *net.IP {
+    mask_length,_ := subnet.Mask.Size ()
+    host_length := IPv6PrefixLen - mask_length
+
+    max := new (big.Int).Lsh (big.NewInt (1), uint (host_length))
+    max.Sub (max, big.NewInt (2)) // Nb of addresses, minus the first and the last
+    n := new (big.Int).Rand (r, max)
+    n.Add (n, big.NewInt (1))
+
+    ip := new (big.Int).SetBytes (subnet.IP.To16 ())
+    ip.Or (ip, n)
+    b := make (net.IP, net.IPv6len)
+    ip_bytes := ip.Bytes ()
+    copy (b[net.IPv6len - len (ip_bytes):], ip_bytes)
+    return &b
+}
+
+/**
+ * Returns the prefix as a binary string.
+ * The binary string is cut at mask length.
+ * ex: 1.0.4.0/22 -> "0000000100000000000001"
+ */
+func get_binary_string (prefix string) string {
+
+    ip := strings.Split (prefix, "/")[0]
+    ip_byte := net.ParseIP (ip)
+
+    var ip_string string
+    if len (ip_byte) == 4 {
+        ip_string = fmt.Sprintf("%08b%08b%08b%08b", ip_byte[0], ip_byte[1], ip_byte[2], ip_byte[3])
+    } else if ip_byte.To4 () != nil {
+        ip_string = fmt.Sprintf("%08b%08b%08b%08b", ip_byte[12], ip_byte[13], ip_byte[14], ip_byte[15])
+    } else { // IPv6: 128 bits
+        var b strings.Builder
+        for _, octet := range ip_byte {
+            fmt.Fprintf (&b, "%08b", octet)
+        }
+        ip_string = b.String ()
+    }
+    
+    l,_ := strconv.Atoi (strings.Split (prefix, "/")[1])
+    return ip_string[:l]
+}
+
+/**
+ * Given a probe under the form x.x.x.x/y, picks a random /24 prefix in it
+ * (or a prefix of the length of the targets, see target_length).
+ */
+func _get_24_prefix (r *rand.Rand, probe string) string {
+    if strings.Contains (probe, ":") { // IPv6 probe
+        return _get_48_prefix (r, probe)
+    }
+    length := target_length ()
+    if strings.HasSuffix (probe, "/" + strconv.Itoa (length)) {
+        return probe
+    }
+    _, network, _ := net.ParseCIDR (probe)
+    return mask_ipv4 (*get_random_ip (r, network), length)
+}
+
+/**
+ * Given an IPv6 probe under the form x:x::/y, picks a random /48 prefix in it
+ * (the IPv6 analogue of _get_24_prefix).
+ */
+func _get_48_prefix (r *rand.Rand, probe string) string {
+    if strings.HasSuffix (probe, "/48") {
+        return probe
+    }
+    _, network, _ := net.ParseCIDR (probe)
+    ip_address := get_random_ip (r, network)
+    return ip_address.Mask (net.CIDRMask (48, IPv6PrefixLen)).String () + "/48"
+}
+
+/**
+ * Returns the /24 (IPv4, or the length of the targets, see target_length) or the /48 (IPv6) containing the target address.
+ */
+func target_prefix (address string) string {
+    return target_prefix_of (address, target_length ())
+}
+
+/**
+ * Returns the IPv4 prefix of the given length, or the /48 (IPv6), containing the target address.
+ */
+func target_prefix_of (address string, length int) string {
+    if strings.Contains (address, ":") {
+        return net.ParseIP (address).Mask (net.CIDRMask (48, IPv6PrefixLen)).String () + "/48"
+    }
+    return mask_ipv4 (net.ParseIP (address), length)
+}
+
+func _get_raw_prefix (probe string) string {
+    return probe
+}
+
+/**
+ * Does the reverse operation of get_binary_string (ipv6: the address is 128 bits long)
+ */
+func get_prefix_from_binary (binary string, ipv6 bool) string {
+    mask := len (binary)
+    if ipv6 {
+        rest := get_0_string (IPv6PrefixLen - mask)
+        binary += rest
+        ip := make (net.IP, net.IPv6len)
+        for i := range ip {
+            c,_ := strconv.ParseUint(binary[8*i:8*i+8], 2, 8)
+            ip[i] = byte (c)
+        }
+        return ip.String () + "/" + strconv.Itoa (mask)
+    }
+    rest := get_0_string (IPv4PrefixLen - mask)
+    binary += rest
+
+    r := ""
+    for start := 0; start <= 24; start += 8 {
+        c,_ := strconv.ParseUint(binary[start:start+8], 2, 8)
+        r += strconv.Itoa (int (c)) + "."
+    }
+    return r[:len(r)-1] + "/" + strconv.Itoa (mask)
+}
+
+func get_0_string (n int) string {
+    b := make([]rune, n)
+  for i := range b {
+      b[i] = '0'
+  }
+  return string(b)
+}
+
+// For building shared library
+//func main(){}
+
+/**
+ * Returns true if the string is a literal IPv4 address in dotted-quad notation
+ * (not a hostname, nor an IPv6 address, even IPv4-mapped).
+ */
+func is_ipv4_literal (addr string) bool {
+    ip := net.ParseIP (addr)
+    return ip != nil && ip.To4 () != nil && !strings.Contains (addr, ":")
+}
@@ -0,0 +1,93 @@
+package sim
+
+import (
+    "net"
+    "reflect"
+    "testing"
+    )
+
+/**
+ * Returns the subnets given by for_each_subnet, as strings.
+ */
+func each_subnet (t *testing.T, prefix string, mask_length int) []string {
+    t.Helper ()
+    _, network, err := net.ParseCIDR (prefix)
+    if err != nil {
+        t.Fatal (err)
+    }
+    subnets := make ([]string, 0)
+    for_each_subnet (network, mask_length, func (subnet net.IPNet) bool {
+        subnets = append (subnets, subnet.String ())
+        return true
+    })
+    return subnets
+}
+
+func TestForEachSubnetShorterMask (t *testing.T) {
+    for _, c := range []struct {
+        prefix string;
+        mask_length int;
+        want string;
+    } {
+        {"192.0.2.128/25", 24, "192.0.2.0/24"}, // diff < 0: the /24 containing the prefix
+        {"192.0.2.0/24", 24, "192.0.2.0/24"},   // diff == 0
+        {"2001:db8:1:2::/64", 32, "2001:db8::/32"},
+    } {
+        if subnets := each_subnet (t, c.prefix, c.mask_length); !reflect.DeepEqual (subnets, []string{c.want}) {
+            t.Errorf ("%s: %v, want %s", c.prefix, subnets, c.want)
+        }
+    }
+}
+
+/**
+ * A /8 gives its 65,536 /24s, in order, the same as get_subnets.
+ */
+func TestForEachSubnetSlash8 (t *testing.T) {
+    subnets := each_subnet (t, "10.0.0.0/8", 24)
+    if len (subnets) != 65536 || subnets[0] != "10.0.0.0/24" || subnets[1] != "10.0.1.0/24" || subnets[65535] != "10.255.255.0/24" {
+        t.Fatalf ("%d subnets, from %s to %s", len (subnets), subnets[0], subnets[len (subnets) - 1])
+    }
+    _, network, _ := net.ParseCIDR ("10.0.0.0/8")
+    for i, subnet := range get_subnets (network, 24) {
+        if subnet.String () != subnets[i] {
+            t.Fatalf ("subnet %d: %s, get_subnets gives %s", i, subnets[i], subnet.String ())
+        }
+    }
+}
+
+func TestForEachSubnetStop (t *testing.T) {
+    _, network, _ := net.ParseCIDR ("10.0.0.0/8")
+    n := 0
+    for_each_subnet (network, 24, func (net.IPNet) bool {
+        n++
+        return n < 3
+    })
+    if n != 3 {
+        t.Errorf ("%d subnets given after fn returned false, want 3", n)
+    }
+}
+
+/**
+ * The allocations of breaking down a /8 into /24s, with and without the slice:
+ *     go test -run - -bench Subnets -benchmem
+ */
+func BenchmarkGetSubnets (b *testing.B) {
+    _, network, _ := net.ParseCIDR ("10.0.0.0/8")
+    b.ReportAllocs ()
+    for n := 0; n < b.N; n++ {
+        for _, subnet := range get_subnets (network, 24) {
+            _ = subnet.IP[2]
+        }
+    }
+}
+
+func BenchmarkForEachSubnet (b *testing.B) {
+    _, network, _ := net.ParseCIDR ("10.0.0.0/8")
+    b.ReportAllocs ()
+    for n := 0; n < b.N; n++ {
+        for_each_subnet (network, 24, func (subnet net.IPNet) bool {
+            _ = subnet.IP[2]
+            return true
+        })
+    }
+}
@@ -0,0 +1,149 @@
+/* ==================================================================================== *\
+     json_traces.go
+
+     Traces in the JSON format of scamper:
+     -------------------------------------
+     Without sc_tnt, the warts files can be converted with sc_warts2json (shipped with
+     scamper): one JSON object per line, the traces being the objects of type "trace".
+     Their hops are turned into the same Trace/Hop structures as the output of sc_tnt,
+     and committed with commit_trace. As in the output of sc_tnt -d2, a single reply is
+     kept per TTL (the first one), the destination is not a hop, and the reserved
+     addresses are private hops.
+
+     The format is chosen on the extension of the files (.json, .json.gz), or forced
+     with -trace-format.
+\* ==================================================================================== */
+
+package sim
+
+import (
+    "encoding/json"
+    "log"
+    "net"
+    "sort"
+    "strings"
+    )
+
+/* --- Formats of the trace files --- */
+const (
+    trace_format_auto = "auto"
+    trace_format_tnt = "tnt"   // Warts files, decoded with sc_tnt
+    trace_format_json = "json" // Output of sc_warts2json
+)
+
+/**
+ * A trace object of sc_warts2json (the fields used only).
+ */
+type json_trace struct {
+    Type string `json:"type"`;
+    Src string `json:"src"`;
+    Dst string `json:"dst"`;
+    Hops []struct {
+        Addr string `json:"addr"`;
+        Probe_ttl int `json:"probe_ttl"`;
+        Rtt *float64 `json:"rtt"`;
+    } `json:"hops"`;
+}
+
+/**
+ * Returns the format of a trace file: the one given (-trace-format), or else the one of its extension.
+ */
+func trace_file_format (filename, format string) string {
+    if format != "" && format != trace_format_auto {
+        return format
+    }
+    if strings.HasSuffix (filename, ".json") || strings.HasSuffix (filename, ".json.gz") {
+        return trace_format_json
+    }
+    return trace_format_tnt
+}
+
+/**
+ * Returns true if the address is reserved (private, shared, loopback...), as the "rsvd" hops of sc_tnt.
+ */
+func is_reserved_address (addr string) bool {
+    ip := net.ParseIP (addr)
+    if ip == nil {
+        return false
+    }
+    for _, reserved := range reserved_prefixes {
+        if reserved.Contains (ip) {
+            return true
+        }
+    }
+    return false
+}
+
+/**
+ * Reads the traces of a sc_warts2json file (possibly gzip compressed) and commits them, as
+ * generate_warts_parser does for the output of sc_tnt.
+ * Returns false if the file could not be read entirely (the traces read before are kept).
+ */
+func read_json_traces (file_name string, p *trace_parser, traces, vp_traces, adjs, multi_adjs, addresses, target_to_vp *ShardedSet, skipped, private, looped *int) bool {
+    reader := NewCompressedReader (file_name)
+    if err := reader.Open (); err != nil {
+        log.Print ("[read_json_traces]: WARNING: ", err, ", file skipped")
+        return false
+    }
+    defer reader.Close ()
+    scanner := reader.Scanner ()
+    scanner.Buffer (make ([]byte, 64 * 1024), 16 * 1024 * 1024) // A trace is a single line
+
+    malformed := 0
+    for scanner.Scan () {
+        line := scanner.Bytes ()
+        if len (line) == 0 {
+            continue
+        }
+        var object json_trace
+        if err := json.Unmarshal (line, &object); err != nil {
+            malformed++
+            continue
+        }
+        if object.Type != "trace" { // cycle-start, cycle-stop, list...
+            continue
+        }
+        if !is_ipv4_literal (object.Src) || !is_ipv4_literal (object.Dst) {
+            (*skipped)++
+            continue
+        }
+
+        /* --- Hops, a single reply per TTL --- */
+        sort.SliceStable (object.Hops, func (i, j int) bool { return object.Hops[i].Probe_ttl < object.Hops[j].Probe_ttl })
+        trace := NewTrace ()
+        previous_ttl := -1
+        for _, reply := range object.Hops {
+            if reply.Probe_ttl == previous_ttl || reply.Addr == "" {
+                continue
+            }
+            previous_ttl = reply.Probe_ttl
+            rtt := -1.0
+            if reply.Rtt != nil {
+                rtt = *reply.Rtt
+            }
+            if is_reserved_address (reply.Addr) { // Private address: kept for the TTL distances (see hop_distance)
+                trace.hops = append (trace.hops, Hop{addr: reply.Addr, probe_ttl: reply.Probe_ttl, rtt: rtt, private: true})
+                continue
+            }
+            if reply.Addr == object.Dst {
+                continue
+            }
+            addresses.add (reply.Addr)
+            trace.hops = append (trace.hops, p.annotated_hop (reply.Addr, reply.Probe_ttl, rtt))
+        }
+        if trace.has_private_hops () {
+            (*private)++
+        }
+        if p.commit_trace (object.Src, object.Dst, trace, traces, vp_traces, adjs, multi_adjs, target_to_vp) {
+            (*looped)++
+        }
+    }
+    if malformed != 0 {
+        log.Printf ("[read_json_traces]: WARNING: %s: %d malformed lines skipped", file_name, malformed)
+    }
+    if err := scanner.Err (); err != nil {
+        log.Print ("[read_json_traces]: WARNING: ", file_name, ": ", err, ", the traces of the file may be incomplete")
+        return false
+    }
+    return true
+}
@@ -0,0 +1,407 @@
+package sim
+
+import (
+    "fmt"
+    "log"
+    "os"
+    "path"
+    "time"
+) 
+
+// Global structure holding all necessary data files.
+type Args struct{
+    /* simulation-data */
+    as_rel_file string; 
+    ppdc_file string; 
+    ip2as_file string; 
+    bdrmapit_file string;
+    warts_directory string;
+    /* ribs-data */
+    directed_prefixes_dir string; 
+    oracle_prefixes_dir string; 
+    overlays_global_file string; 
+    nexthop_as_dir_global string;
+    as2org_file string;       // CAIDA as-org2info file (sibling-aware strategies)
+    only_probed bool;         // Only write the targets with a trace in the warts data set (strategy)
+    break_len int;            // Length of the IPv4 targets and of the broken down prefixes (0: no break, /24 targets)
+    overlays_dir string;      // Per-collector overlays (overlays_<collector>.txt, output of rib_parsing)
+    vp_collectors_file string; // VP -> collector whose overlays apply to it
+    /* AS specifics */
+    vps_file string; 
+    collectors_file string; 
+    ases_interest_file string;
+    /* simulation-parameters */
+    thresholds threshold_list; // Thresholds to simulate (-t), one after the other
+    weight_parameters []float64; 
+    credit_mode string; // Credit of the targets without trace ("pessimistic", "fractional" or "nearest_sibling")
+    sibling_distance int; // In nearest_sibling credit mode, the maximum distance (in /24s) of the inherited trace
+    seed int64; // Seed of the random numbers (0: chosen from the clock)
+    plateau_metric string; // Which discoveries reset the plateau ("any", "adjs", "addresses", "routers" or "addresses+routers")
+    jobs int; // Nb of workers of the pools, e.g., of ASes of interest simulated concurrently (0: one per CPU, see cpu_jobs)
+    jobs_warts int; // Nb of warts files parsed at the same time (0: -j, or default_external_jobs)
+    jobs_ribs int; // Nb of collectors parsed at the same time (0: -j, or default_external_jobs)
+    collector_timeout time.Duration; // bgpreader is killed after this time on a collector (0: never, see collector_retry.go)
+    collector_retries int; // Nb of retries of a failed collector
+    quiet bool; // No progress of the pools on stderr (see progress.go)
+    router_k int; // A router is discovered once this many of its addresses have been seen
+    budget ProbeBudget; // Maximum number of probes per AS of interest (none by default)
+    checkpoint bool; // Record the ASes whose simulation is complete in the checkpoint of the output directory
+    resume bool; // Skip the ASes whose simulation is complete in the checkpoint of the output directory
+    greedy_patience int; // Greedy scheduling: consecutive non-discovering probes before moving on to the next AS
+    missing_traces string; // Policy for the targets without trace (count, skip or drop)
+    /* cost-model */
+    attempts int; // Nb of attempts per hop of a traceroute
+    max_ttl int; // Path length assumed when no trace is available
+    vp_caps_file string; // File with the daily packet cap of each VP
+    /* ribs-sanitation */
+    bogon_asn_policy string; // What to do with reserved ASNs in AS paths ("strip" or "drop" the entry)
+    max_as_path_length int; // Entries whose AS path (prepending collapsed) is longer are dropped
+    keep_prepending bool; // Extract the next/previous-hop ASes from the raw AS path, prepending included (outputs prior to the collapse)
+    ipv6 bool; // Also accept IPv6 prefixes (mixed tables: each prefix is checked by the rules of its family)
+    mrt_dir string; // If set, RIBs are read from local MRT dumps (<mrt_dir>/<collector>/) instead of bgpreader
+    fetch_dir string; // If set, the RIB dumps are downloaded from the archives into this cache (same layout as mrt_dir)
+    min_entries int; // If > 0, the collectors file holds the number of entries of each collector, and the collectors with fewer are skipped
+    rib_window int; // The entries of a prefix are gathered until it has not been seen for this many records
+    tiebreaks []string; // Ordered tie-breaks of the BGP heuristics (see select_entry)
+    compress bool; // Write the per-collector outputs of ribs_multi gzip-compressed (.gz)
+    aspath_prefilter bool; // Pre-filter the RIB entries of the dependent prefixes with a regex on the AS path (bgpreader -A)
+    per_peer bool; // ribs_multi also writes a forwarding table (and its overlays) per BGP peer of each collector (see rib_per_peer.go)
+    keep_records bool; // ribs_multi also keeps the RIB records of each collector, replayed by add_as (see rib_records.go)
+    records_dir string; // If set, the RIB records kept by ribs_multi in this directory are replayed instead of reading the dumps (add_as)
+    /* overlays */
+    overlay_max_fraction float64; // Warn when an overlay group gathers more than this fraction of all prefixes
+    /* Strategy */
+    strategy string; 
+    overlay_metric string; // How the representative of an overlay group is selected ("any" or "rtt")
+    diff_old_dir string; // If set (with diff_new_dir), the targets whose routes changed between both ribs_multi outputs come first
+    diff_new_dir string;
+    target_as_allowlist string; // If set, only the prefixes of the ASes of this file (and of the AS of interest) are targeted
+    expect_allowlist string;    // If set, the simulation refuses a strategy not built with this allowlist
+    /* sidecars */
+    write_sidecars bool; // Also write the binary sidecar (.bin) of the directed prefixes and targets
+    /* end-of-run summary */
+    summary_out string; // Where the top-level commands write the summary of their run
+    force bool; // Overwrite the results of another run in the output directory (see manifest.go)
+    sqlite_file string; // If set, the results of the simulation are also written in this SQLite database (see results_db.go)
+    /* time-boxed runs */
+    deadline time.Time; // If set, the strategy/simulation skip the units that would not complete before it
+    /* warts-parsing */
+    duplicate_destinations string; // Policy when several traces target the same /24 ("keep_last" or "keep_lowest_rtt")
+    trace_format string; // Format of the trace files ("auto": from their extension, "tnt" or "json")
+    border string; // Definition of the inter-AS links ("asn" or "conn_asn", see is_border_link)
+    trace_vp string; // Trace of a /24 probed by several VPs ("any" or "assigned", see select_assigned_traces)
+    no_cache bool; // Parse the warts again instead of loading the cache of a previous run (see warts_cache.go)
+    /* profiling */
+    profile_dir string; // If set, the CPU and heap profiles of the run are written in this directory (see profile.go)
+    pprof_addr string; // If set, the profiles of net/http/pprof are served on this address
+    loops string; // Handling of the routing loops of the traces ("truncate-at-loop", "remove-loop" or "keep", see prune_loops)
+}
+
+var ( // Global Parameters
+    g_args Args = Args{overlay_max_fraction: 0.01, bogon_asn_policy: "strip", max_as_path_length: 64, rib_window: 100000, tiebreaks: default_tiebreaks}
+)
+
+var ( // Output mode
+    output_on bool = true;
+    succesfull_traces_on bool = false;
+    discovery_attribution_on bool = false; // Record which probe first discovered each element (sequential simulation)
+)
+
+func output_mode () {
+    o, _ := os.Stdout.Stat()
+    if (o.Mode() & os.ModeCharDevice) == os.ModeCharDevice { //Terminal
+        log.Fatal ("\n /!\\ Please redirect output to a file to get some statistics on Anaximander's run /!\\ \n")
+    } else { //It is not the terminal
+        // Display info to a pipe
+    }
+}
+
+func usage () {
+    println ("\nUsage of Anaximander:\n")
+    println ("Anaximander has several modes:")
+    println ("  - rib_parsing: to parse RIBs and collect all necessary information for either the strategy or the simulation.")
+    println ("  - strategy: to output the ordered list of targets built by Anaximander.")
+    println ("  - simulation: to simulate Anaximander on a warts dataset.")
+    println ("  - version: to print the version of the binary.\n")
+    println ("Type")
+    println ("  ./anaximander [mode] -h")
+    println ("for further information on each mode.\n")
+}
+
+func Main () {
+    log.SetFlags(0)
+    defer close_output () // Flush the shared outputs on every return path
+    if len (os.Args) == 1 {
+        usage ()
+        return
+    }
+    switch command := os.Args[1]; command {
+
+        /* --------------------------- *\
+                  RIB PARSING
+        \* --------------------------- */
+        case "rib_parsing":
+            launch_rib_parsing (os.Args[2:])
+
+        /* --------------------------- *\
+            Anaximander Strategy Step
+        \* --------------------------- */
+        case "strategy":
+            if len (os.Args) > 2 && os.Args[2] == "list" { // ./anaximander strategy list
+                list_strategies ()
+                return
+            }
+            if len (os.Args) > 2 && os.Args[2] == "explain" { // ./anaximander strategy explain -s strategy -as AS_interest -x AS [datasets]
+                launch_strategy_explain (handle_args_explain (os.Args[2:]))
+                return
+            }
+            break_len, strategy, output_dir := handle_args_strategy (os.Args[1:])
+            output_mode () // Check redirection
+            log_version ()
+            failed := launch_anaximander_strategy (break_len, strategy, output_dir)
+            output_failed := split_output ("strategy", output_dir + "/output.txt")
+            truncated := report_deadline_truncation (output_dir + "/", output_dir, output_dir + "/run_config.json")
+            exit_on_summary (truncated)
+            if failed != 0 {
+                log.Print ("[strategy]: the strategy of ", failed, " AS(es) could not be written")
+                os.Exit (1)
+            }
+            if output_failed {
+                os.Exit (1)
+            }
+        /* --------------------------- *\
+              Anaximander Simulator
+        \* --------------------------- */
+        case "simulation":
+            break_len, output_file, simulation_mode := handle_args_simulation (os.Args[1:])
+            output_mode () // Check redirection
+            log_version ()
+            failed := launch_anaximander_simulation (interrupt_begin (), break_len, output_file, simulation_mode)
+            output_failed := split_output ("simulation", path.Dir (output_file) + "/output.txt")
+            truncated := report_deadline_truncation (trim_suffix (output_file, ".txt") + "_", path.Dir (output_file), output_file + "_run_config.json")
+            exit_on_summary (truncated)
+            if failed != 0 {
+                log.Print ("[simulation]: the results of ", failed, " AS(es) could not be written")
+                os.Exit (1)
+            }
+            if output_failed {
+                os.Exit (1)
+            }
+            
+        /* --------------------------- *\
+              Rocketfuel Simulator
+        \* --------------------------- */
+        /* --- Partial simulation of Rocketfuel Path Reduction techniques. --- */
+        case "rocketfuel_simulation":
+            rocketfuel_simulation (os.Args[2:])
+
+        /* --------------------------- *\
+                      Misc.
+        \* --------------------------- */
+        /* --- Various analysis and processing of the data. --- */
+        case "analysis":
+            analysis (os.Args[2:])
+        case "version", "-version", "--version":
+            fmt.Println (version_string ())
+        case "-h":
+            usage ()
+        case "--help":
+            usage ()
+        default:
+            log.Println("Unknown command:", command)
+            log.Println("Type './anaximander -h' for help:")
+    }
+}
+
+// --------------------------------------------------------------------------------
+/**
+ * Flushes the shared outputs, and splits output.txt (see split_output_by_first_column).
+ * Returns true if an output could not be written: the run must then exit with 1.
+ */
+func split_output (command, output string) bool {
+    failed := false
+    if err := close_output (); err != nil {
+        log.Print ("[", command, "]: standard output: ", err)
+        summary_warning ("standard output not written: " + err.Error ())
+        failed = true
+    }
+    // To split the information into different files based on the first column value.
+    if err := split_output_by_first_column (output); err != nil {
+        log.Print ("[", command, "]: ", err)
+        summary_warning ("output.txt not split: " + err.Error ())
+        failed = true
+    }
+    return failed
+}
+
+// --------------------------------------------------------------------------------
+/**
+ * Writes the end-of-run summary, and exits with 1 if the run failed, with exit_interrupted
+ * if it was interrupted (SIGINT, SIGTERM), or with exit_warnings if it was truncated by the deadline.
+ */
+func exit_on_summary (truncated bool) {
+    status := summary_end ()
+    profile_end ()
+    if status == status_failed {
+        os.Exit (1)
+    }
+    exit_if_interrupted ()
+    if truncated {
+        os.Exit (exit_warnings)
+    }
+}
+
+// --------------------------------------------------------------------------------
+func launch_rib_parsing (args []string) {
+    usage_rib_parsing_f := func () {
+        println ("Usage of rib_parsing:")
+        println ("")
+        println ("  ./anaximader rib_parsing count: Step1 - for each collector, count the number of entries, in order to determine which collectors are sound (nb entries > 800k)")
+        println ("  ./anaximader rib_parsing select_collectors: Step1 bis - select the sound collectors from the counts (nb entries >= -min-entries)")
+        println ("  ./anaximader rib_parsing ribs_multi: Step2 - parse RIBs from all (sound) collectors and outputs several information from them.")
+        println ("  ./anaximader rib_parsing build_best_directed_probes: Step3 - build the BDP from the parsing of the RIBs")
+        println ("  ./anaximader rib_parsing add_as: add ASes of interest to the output of Step2, from its forwarding tables")
+        println ("\nType")
+        println ("  ./anaximander rib_parsing [sub_mode] -h")
+        println ("for further information on each sub mode.\n")
+    }
+
+    if len (args) == 0 {
+        usage_rib_parsing_f ()
+        return
+    }
+    interrupt_begin () // The bgpreader processes are killed on SIGINT (see start_and_wait)
+    defer exit_if_interrupted ()
+    switch command := args[0]; command {
+        /**
+         * Step1: For each collector, count the number of entries, in order to determine which collectors are sound (nb entries > 800k)
+         */
+        case "count":
+            count_ribs (handle_args_rib_parsing_count (args))
+        /**
+         * Step1 bis: Select the sound collectors from the counts of Step1 (or count them first).
+         */
+        case "select_collectors":
+            select_collectors (handle_args_rib_parsing_select (args))
+        /**
+         * Step2: Parse RIBs from all (valid) collectors and outputs several information from them.
+         *
+         * To get a single RIB at a given time, specify the time interval for which you want to retrieve the table.
+         * Route Views collectors output a RIB every 2 hours whereas RIPE RIS collectors output a RIB every 8 hours
+         * (both aligned to midnight).
+         * As RIB dumps are not made atomically, you should specify a window of a few minutes ((e.g., 00:00 -> 00:05)
+         *  - Cycle 141
+         *   start=1601856000
+         end=  1601856300 
+         *  - Cycle 176
+         *   start=1618876800
+         *   end=  1618877100 
+             */
+        case "ribs_multi":
+            parse_ribs (handle_args_rib_parsing_multi (args))
+            exit_on_summary (false)
+        /**
+         * Step3: Build the BDP.
+         */
+        case "build_best_directed_probes": 
+            build_best_path_directed_probes (handle_args_rib_parsing_build (args))
+            exit_on_summary (false)
+        /**
+         * Add ASes of interest to the output of Step2 without parsing the RIBs again.
+         */
+        case "add_as":
+            add_ases_to_ribs (handle_args_rib_parsing_add_as (args))
+
+        /* --------------------------- *\
+                      Misc.
+        \* --------------------------- */
+        case "analyse_rib":
+            analyse_ribs (handle_args_rib_parsing_analyser (args))
+        case "analyse_fib":
+            analyse_fibs (handle_args_fib_parsing_analyser (args))
+        case "-h":
+            usage_rib_parsing_f ()
+        default:
+            log.Println ("Unknown sub-command:", command)
+    }
+}
+
+// --------------------------------------------------------------------------------
+func rocketfuel_simulation (args []string) {
+    if len (args) == 0 {
+        println ("Missing arguments")
+        return
+    }
+    switch command := args[0]; command {
+        /**
+         * Ingress Reduction
+         */
+        case "ingress_reduction": // ./anaximander read <ases_file> <sqlite_file> <warts_directory> <output_dir>
+        g_args.bdrmapit_file, g_args.warts_directory = args[2], args[3]
+            ingress_reduction (args[1], args[4])
+        /**
+         * Next-AS Reduction
+         */
+        case "nextAS": // ./anaximander analyse_next_hops (outdir, ases_file, collectors_file, dir string) //the directory where next-AS are found
+            analyse_next_hops (args[1], args[2], args[3], args[4])
+        case "merge_nextAS": // ./anaximander merge_nextAS (outdir, ases_file, collectors_file, dir string [disagreements]) //the directory where next-AS are found
+            merge_next_hops (args[1], args[2], args[3], args[4], len (args) > 5 && args[5] == "disagreements")
+        /**
+         * Directed probing and Egress reduction
+         * Parse RIBs from all (valid) collectors looking for a particular AS in the AS path.
+         * Output all prefixes for which the AS was seen in the AS path, with an annotation of dependent or up/down prefixes 
+         * (see RocketFuel paper)
+         */
+        case "directed_prefixes": // (as, collectors_file, output_filename string, break_len int, start, end string)
+            parse_ribs_dependent (handle_args_rib_parsing_ribs (args))
+        default:
+            log.Println ("Unknown sub-command:", command)
+    }
+}
+
+// --------------------------------------------------------------------------------
+func analysis (args []string) {
+    if len (args) == 0 {
+        println ("Missing arguments")
+        return
+    }
+    switch command := args[0]; command {
+        /* ---------------------- *\
+            Overlays processing
+        \* ---------------------- */
+        case "overlays":
+            analyse_overlays (args[1:])
+        case "analyse_merged_overlays": // ./anaximander analyse_merged_overlays merged_overlays all_forwarding_tables
+            analyse_merged_overlays (args[1], args[2:])
+        case "overlays_repartition_vp": // ./anaximander overlays_repartition_vp overlay_file forwarding_table
+            analyse_overlays_repartition_vp (args[1], args[2])
+        case "merge_overlays": // ./anaximander dir
+            build_merge_overlays (args[1])
+        case "build_overlays_per_AS": // ./anaximander ases_file, all_overlays_file, directed_prefixes_dir, outdir string
+            build_overlays_per_AS (args[1], args[2], args[3], args[4])
+        /* ---------------------- *\
+            Datasets
+        \* ---------------------- */
+        case "trace_rtt": // ./anaximander analysis trace_rtt ases_file bdrmapit_file warts_directory outdir
+            g_args.bdrmapit_file, g_args.warts_directory = args[2], args[3]
+            trace_rtt (args[1], args[4])
+        case "ases_main_stats": // ./anaximander analysis ases_main_stats ases_file bdrmapit_file alias_file outdir
+            ases_main_stats (args[1], args[2], args[3], args[4])
+        case "dataset_influence": // ./anaximander analysis dataset_influence -s strategy -as AS_interest [datasets]
+            launch_dataset_influence (handle_args_influence (args))
+        /* ---------------------- *\
+            Simulation results
+        \* ---------------------- */
+        case "aggregate_curves": // ./anaximander analysis aggregate_curves -d results_dir -o output_prefix [-axis fraction|probes] [-points n]
+            aggregate_curves (handle_args_aggregate_curves (args))
+        case "compare_runs": // ./anaximander analysis compare_runs [-tol t] [-o output_prefix] [-strategy_a dir -strategy_b dir] dir_a dir_b
+            compare_runs (handle_args_compare_runs (args))
+        /* ---------------------- *\
+            BGP data
+        \* ---------------------- */
+        case "peer_coverage": // ./anaximander analysis peer_coverage [-min n] [-fraction f] [-o output_file] ribs_dir|peers_file
+            peer_coverage (handle_args_peer_coverage (args))
+        default:
+            log.Println ("Unknown sub-command:", command)
+    }
+}
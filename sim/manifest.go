@@ -0,0 +1,139 @@
+/* ==================================================================================== *\
+     manifest.go
+
+     Run manifest:
+     -------------
+     At the start of the strategy and of the simulation, manifest.json is written in the
+     output directory, to know afterwards which inputs and parameters produced its results:
+       - the resolved value of every flag (command line, -config and defaults),
+       - the SHA-256 of every input file, and for the input directories (warts, strategy,
+         ...) the SHA-256 of their listing (name, size and modification time of each file),
+       - the strategy, the scheduler and the weight function, the random seed,
+       - the build metadata of the binary.
+     A run refuses to write its manifest over the one of another run (results of several
+     runs mixed in one directory), unless -force is given. A resumed simulation (-resume)
+     continues the run of the directory, and rewrites its manifest.
+\* ==================================================================================== */
+
+package sim
+
+import (
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "flag"
+    "fmt"
+    "log"
+    "os"
+    "path/filepath"
+    "time"
+    )
+
+const manifest_file = "manifest.json"
+
+var manifest_outputs = map[string]bool{"o": true, "summary_out": true, "profile": true} // Flags naming outputs, not inputs
+
+/**
+ * Flags of the run, recorded by parse_args_with_config: their values are read when the manifest
+ * is written, once resolved.
+ */
+var run_flags *flag.FlagSet
+
+type manifest_input struct {
+    Path string `json:"path"`;
+    Sha256 string `json:"sha256,omitempty"`;                 // Input file
+    Listing_sha256 string `json:"listing_sha256,omitempty"`; // Input directory
+    Files int `json:"files,omitempty"`;                      // Input directory: number of files
+}
+
+type run_manifest struct {
+    Command string `json:"command"`;
+    Created string `json:"created"`;
+    Arguments map[string]string `json:"arguments"`;      // Flag -> resolved value
+    Inputs map[string]*manifest_input `json:"inputs"`;   // Flag -> input file or directory
+    Strategy string `json:"strategy,omitempty"`;
+    Scheduler string `json:"scheduler,omitempty"`;       // Simulation
+    Weight_function string `json:"weight_function,omitempty"`; // Parallel simulation
+    Seed int64 `json:"seed"`;                            // The seed actually used (see seed_random)
+    Build map[string]string `json:"build"`;
+    Deadline_truncated bool `json:"deadline_truncated,omitempty"`; // Added at the end of a run truncated by -deadline (see report_deadline_truncation)
+    Deadline_skipped []string `json:"deadline_skipped,omitempty"`;
+}
+
+/**
+ * Returns the SHA-256 of the listing of a directory (relative path, size and modification time
+ * of each file, in lexical order), and its number of files.
+ */
+func listing_hash (dir string) (string, int, error) {
+    h := sha256.New ()
+    files := 0
+    err := filepath.Walk (dir, func (path string, info os.FileInfo, err error) error {
+        if err != nil || info.IsDir () {
+            return err
+        }
+        rel, _ := filepath.Rel (dir, path)
+        fmt.Fprintln (h, rel, info.Size (), info.ModTime ().UnixNano ())
+        files++
+        return nil
+    })
+    if err != nil {
+        return "", 0, err
+    }
+    return hex.EncodeToString (h.Sum (nil)), files, nil
+}
+
+/**
+ * Returns the resolved value of every flag of the run, and the fingerprint of those naming an
+ * input file or directory.
+ */
+func manifest_arguments () (map[string]string, map[string]*manifest_input, error) {
+    arguments, inputs := make (map[string]string), make (map[string]*manifest_input)
+    if run_flags == nil {
+        return arguments, inputs, nil
+    }
+    var err error
+    run_flags.VisitAll (func (f *flag.Flag) {
+        if f.Name == "dump-config" || err != nil {
+            return
+        }
+        value := f.Value.String ()
+        arguments[f.Name] = value
+        if value == "" || manifest_outputs[f.Name] {
+            return
+        }
+        info, stat_err := os.Stat (value)
+        if stat_err != nil { // Not a path
+            return
+        }
+        input := &manifest_input{Path: value}
+        if info.IsDir () {
+            input.Listing_sha256, input.Files, err = listing_hash (value)
+        } else {
+            input.Sha256, err = input_hash (value)
+        }
+        inputs[f.Name] = input
+    })
+    return arguments, inputs, err
+}
+
+/**
+ * Writes the manifest of the run in output_dir. Exits if the directory already holds the manifest
+ * of a run, unless overwrite is set (-force, -resume). To be called before anything is written
+ * in the directory.
+ */
+func write_manifest (output_dir string, m *run_manifest, overwrite bool) {
+    path := filepath.Join (output_dir, manifest_file)
+    if _, err := os.Stat (path); err == nil && !overwrite {
+        log.Fatal ("[manifest]: ", path, " exists: the directory holds the results of another run (use -force to overwrite them)")
+    }
+    var err error
+    if m.Arguments, m.Inputs, err = manifest_arguments (); err != nil {
+        log.Fatal ("[manifest]: ", err)
+    }
+    m.Created = time.Now ().Format (time.RFC3339)
+    m.Build = build_info ()
+    content, _ := json.MarshalIndent (m, "", "  ")
+    if err := os.WriteFile (path, append (content, '\n'), 0644); err != nil {
+        log.Fatal ("[manifest]: ", err)
+    }
+}
@@ -1,4 +1,4 @@
-package main
+package sim
 
 import ("strings"
         "sort"
@@ -6,13 +6,13 @@ import ("strings"
         "regexp"
         "bufio"
         "os"
+        "fmt"
         "math"
         "strconv")
 
 var (
     ip_string = `(\d{1,3}\.\d{1,3}\.\d{1,3}\.\d{1,3})`
     net_string = `(\d{1,3}\.\d{1,3}\.\d{1,3}\.\d{1,3})/\d{1,2}`
-    re_source_dest = regexp.MustCompile (ip_string + `\s*to\s*` + ip_string)
     re_ip = regexp.MustCompile (ip_string)
     re_net = regexp.MustCompile (net_string)
     MaxInt = int(^uint(0) >> 1)
@@ -178,6 +178,15 @@ func get_keys (mymap *map[string]interface{}) []string {
     return keys
 }
 
+/**
+ * Returns the keys of the map, sorted (so that the strategies don't depend on the map iteration order).
+ */
+func sorted_keys (mymap *map[string]interface{}) []string {
+    keys := get_keys (mymap)
+    sort.Strings (keys)
+    return keys
+}
+
 func slice_to_map (s []string) map[string]interface{} {
     m := make (map[string]interface{})
     for _, x := range s {
@@ -337,6 +346,20 @@ func (data DataFloat64) Variance () float64 {
     return vars.Mean ()
 }
 
+/**
+ * Returns the p-th percentile (0 < p <= 100) of the data, using the nearest-rank method.
+ */
+func (data DataFloat64) Percentile (p float64) float64 {
+    if len (data) == 0 {
+        return math.NaN ()
+    }
+    sorted := make (DataFloat64, len (data))
+    copy (sorted, data)
+    sort.Float64s (sorted)
+    rank := int (math.Ceil (p/100 * float64 (len (sorted))))
+    return sorted[max (min (rank, len (sorted)), 1) - 1]
+}
+
 func stringSlice_to_floatSlice (a []string) (r []float64) {
     r = make ([]float64,0,len (a))
     for _, e := range a {
@@ -354,6 +377,35 @@ func new_bufio_writer (output_file string) (*bufio.Writer, *os.File) {
     return bufio.NewWriter(file), file
 }
 
+/**
+ * Creates output_file and writes it with write. Returns the first error of the creation, the
+ * writes (kept by the bufio.Writer until its flush), the flush or the closing of the file.
+ */
+func write_file (output_file string, write func (*bufio.Writer)) error {
+    file, err := os.Create (output_file)
+    if err != nil {
+      return err
+    }
+    w := bufio.NewWriter (file)
+    write (w)
+    return close_bufio_writer (w, file)
+}
+
+/**
+ * Flushes w and closes its file (see new_bufio_writer). Returns the first error of the writes (kept
+ * by the bufio.Writer until its flush), the flush or the closing of the file.
+ */
+func close_bufio_writer (w *bufio.Writer, file *os.File) error {
+    err := w.Flush ()
+    if e := file.Close (); err == nil {
+      err = e
+    }
+    if err != nil {
+      return fmt.Errorf ("%s: %w", file.Name (), err)
+    }
+    return nil
+}
+
 func trim_suffix (file, suffix string) string {
     if strings.HasSuffix(file, suffix) {
         file = file[:len(file)-len(suffix)]
@@ -0,0 +1,365 @@
+/* ==================================================================================== *\
+     mrt_reader.go
+
+     Native reader of MRT RIB dumps (RFC 6396):
+     ------------------------------------------
+     Reads a local RIB dump (RouteViews rib.YYYYMMDD.HHMM.bz2, RIS bview.YYYYMMDD.HHMM.gz)
+     instead of spawning bgpreader, and produces, for every RIB entry, the same
+     pipe-delimited record as 'bgpreader -t ribs':
+       R|R|<time>|<project>|<collector>|||<peer-ASn>|<peer-IP>|<prefix>|<next-hop>|<AS-path>|<origin-AS>|||
+     Only the TABLE_DUMP_V2 records are decoded (PEER_INDEX_TABLE and the unicast RIBs,
+     with or without ADD-PATH). The other records are skipped.
+
+     The dumps of a collector are looked for in <mrt_dir>/<collector>/ (see find_mrt_dump).
+\* ==================================================================================== */
+
+package sim
+
+import (
+    "bufio"
+    "encoding/binary"
+    "errors"
+    "fmt"
+    "io"
+    "net"
+    "os"
+    "path/filepath"
+    "regexp"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+    )
+
+const (
+    mrt_table_dump_v2 = 13
+
+    /* --- TABLE_DUMP_V2 subtypes --- */
+    mrt_peer_index_table = 1
+    mrt_rib_ipv4_unicast = 2
+    mrt_rib_ipv6_unicast = 4
+    mrt_rib_ipv4_unicast_addpath = 8
+    mrt_rib_ipv6_unicast_addpath = 10
+
+    /* --- BGP path attributes --- */
+    bgp_attr_as_path = 2
+    bgp_attr_next_hop = 3
+    bgp_attr_mp_reach_nlri = 14
+
+    mrt_max_record_length = 1 << 24 // Sanity check on the length of a record
+)
+
+var re_mrt_dump = regexp.MustCompile (`^(rib|bview)\.(\d{8})\.(\d{4})`)
+
+/**
+ * A BGP peer of the collector (PEER_INDEX_TABLE).
+ */
+type mrt_peer struct {
+    ip string;
+    asn string;
+}
+
+/* ------------------------------------------------------------------------------- *\
+                                   Dump files
+\* ------------------------------------------------------------------------------- */
+
+/**
+ * Returns the RIB dump of the collector in mrt_dir/collector/, taken in the [start, end]
+ * interval (UNIX timestamps, as for bgpreader). If several dumps fall in the interval, the
+ * earliest one is returned. A directory holding a single dump whose name carries no date
+ * is accepted as is.
+ */
+func find_mrt_dump (mrt_dir, collector, start, end string) (string, error) {
+    files, err := os.ReadDir (filepath.Join (mrt_dir, collector))
+    if err != nil {
+        return "", err
+    }
+    from, err_from := strconv.ParseInt (start, 10, 64)
+    to, err_to := strconv.ParseInt (end, 10, 64)
+
+    dumps := make ([]string, 0, len (files))
+    undated := make ([]string, 0, 1)
+    for _, file := range files {
+        if file.IsDir () {
+            continue
+        }
+        m := re_mrt_dump.FindStringSubmatch (file.Name ())
+        if m == nil {
+            undated = append (undated, file.Name ())
+            continue
+        }
+        t, err := time.Parse ("200601021504", m[2] + m[3])
+        if err != nil {
+            continue
+        }
+        if (err_from == nil && t.Unix () < from) || (err_to == nil && t.Unix () > to) {
+            continue
+        }
+        dumps = append (dumps, file.Name ())
+    }
+    if len (dumps) == 0 && len (undated) == 1 {
+        dumps = undated
+    }
+    if len (dumps) == 0 {
+        return "", fmt.Errorf ("no RIB dump of %s in %s between %s and %s", collector, mrt_dir, start, end)
+    }
+    sort.Strings (dumps) // Same prefix (rib or bview) per collector: the earliest first
+    return filepath.Join (mrt_dir, collector, dumps[0]), nil
+}
+
+/* ------------------------------------------------------------------------------- *\
+                                    Decoding
+\* ------------------------------------------------------------------------------- */
+
+/**
+ * Reads the RIB dump (compressed or not, see CompressedReader) and calls process with the
+ * bgpreader-like record of every RIB entry, in the order of the dump (grouped by prefix).
+ * If filter is not nil, only the entries whose AS path matches it are processed.
+ */
+func read_mrt_dump (filename, collector string, filter *regexp.Regexp, process func (string)) error {
+    reader := NewCompressedReader (filename)
+    if err := reader.Open (); err != nil {
+        return err
+    }
+    defer reader.Close ()
+    r := bufio.NewReaderSize (reader.decompressed, 1 << 20)
+
+    project := "routeviews"
+    if strings.HasPrefix (collector, "rrc") {
+        project = "ris"
+    }
+
+    var peers []mrt_peer
+    header := make ([]byte, 12)
+    body := make ([]byte, 0, 4096)
+    for {
+        if _, err := io.ReadFull (r, header); err != nil {
+            if err == io.EOF {
+                return nil
+            }
+            return fmt.Errorf ("[read_mrt_dump]: %s: truncated header: %w", filename, err)
+        }
+        timestamp := binary.BigEndian.Uint32 (header[0:])
+        mrt_type := binary.BigEndian.Uint16 (header[4:])
+        subtype := binary.BigEndian.Uint16 (header[6:])
+        length := binary.BigEndian.Uint32 (header[8:])
+        if length > mrt_max_record_length {
+            return fmt.Errorf ("[read_mrt_dump]: %s: record too long (%d bytes)", filename, length)
+        }
+        if cap (body) < int (length) {
+            body = make ([]byte, length)
+        }
+        body = body[:length]
+        if _, err := io.ReadFull (r, body); err != nil {
+            return fmt.Errorf ("[read_mrt_dump]: %s: truncated record: %w", filename, err)
+        }
+        if mrt_type != mrt_table_dump_v2 {
+            continue
+        }
+
+        switch subtype {
+            case mrt_peer_index_table:
+                p, err := decode_peer_index_table (body)
+                if err != nil {
+                    return fmt.Errorf ("[read_mrt_dump]: %s: %w", filename, err)
+                }
+                peers = p
+            case mrt_rib_ipv4_unicast, mrt_rib_ipv6_unicast, mrt_rib_ipv4_unicast_addpath, mrt_rib_ipv6_unicast_addpath:
+                ipv6 := subtype == mrt_rib_ipv6_unicast || subtype == mrt_rib_ipv6_unicast_addpath
+                addpath := subtype == mrt_rib_ipv4_unicast_addpath || subtype == mrt_rib_ipv6_unicast_addpath
+                prefix := ""
+                err := decode_rib_entries (body, ipv6, addpath, func (p string, peer_index uint16, attributes []byte) error {
+                    prefix = p
+                    if int (peer_index) >= len (peers) {
+                        return fmt.Errorf ("unknown peer index %d", peer_index)
+                    }
+                    as_path, origin, next_hop, err := decode_bgp_attributes (attributes, ipv6)
+                    if err != nil {
+                        return err
+                    }
+                    if filter != nil && !filter.MatchString (as_path) {
+                        return nil
+                    }
+                    peer := peers[peer_index]
+                    process (strings.Join ([]string{"R", "R", strconv.FormatUint (uint64 (timestamp), 10), project, collector, "", "",
+                        peer.asn, peer.ip, prefix, next_hop, as_path, origin, "", "", ""}, "|"))
+                    return nil
+                })
+                if err != nil {
+                    return fmt.Errorf ("[read_mrt_dump]: %s: prefix %s: %w", filename, prefix, err)
+                }
+        }
+    }
+}
+
+/**
+ * Decodes a PEER_INDEX_TABLE record.
+ */
+func decode_peer_index_table (b []byte) ([]mrt_peer, error) {
+    if len (b) < 6 {
+        return nil, errors.New ("truncated peer index table")
+    }
+    view_length := int (binary.BigEndian.Uint16 (b[4:]))
+    b = b[6:]
+    if len (b) < view_length + 2 {
+        return nil, errors.New ("truncated peer index table")
+    }
+    b = b[view_length:]
+    count := int (binary.BigEndian.Uint16 (b))
+    b = b[2:]
+
+    peers := make ([]mrt_peer, 0, count)
+    for i := 0; i < count; i++ {
+        if len (b) < 1 {
+            return nil, errors.New ("truncated peer entry")
+        }
+        peer_type := b[0]
+        ip_length, as_length := 4, 2
+        if peer_type & 0x01 != 0 {
+            ip_length = 16
+        }
+        if peer_type & 0x02 != 0 {
+            as_length = 4
+        }
+        if len (b) < 1 + 4 + ip_length + as_length {
+            return nil, errors.New ("truncated peer entry")
+        }
+        ip := net.IP (append ([]byte (nil), b[5:5 + ip_length]...))
+        var asn uint32
+        if as_length == 4 {
+            asn = binary.BigEndian.Uint32 (b[5 + ip_length:])
+        } else {
+            asn = uint32 (binary.BigEndian.Uint16 (b[5 + ip_length:]))
+        }
+        peers = append (peers, mrt_peer{ip: ip.String (), asn: strconv.FormatUint (uint64 (asn), 10)})
+        b = b[1 + 4 + ip_length + as_length:]
+    }
+    return peers, nil
+}
+
+/**
+ * Decodes a RIB_IPV4_UNICAST or RIB_IPV6_UNICAST record (optionally with ADD-PATH), and
+ * calls entry for each of its RIB entries.
+ */
+func decode_rib_entries (b []byte, ipv6, addpath bool, entry func (prefix string, peer_index uint16, attributes []byte) error) error {
+    if len (b) < 5 {
+        return errors.New ("truncated RIB record")
+    }
+    prefix_length := int (b[4])
+    address_length := 4
+    if ipv6 {
+        address_length = 16
+    }
+    if prefix_length > 8 * address_length {
+        return fmt.Errorf ("invalid prefix length %d", prefix_length)
+    }
+    nb_bytes := (prefix_length + 7) / 8
+    b = b[5:]
+    if len (b) < nb_bytes + 2 {
+        return errors.New ("truncated RIB record")
+    }
+    ip := make (net.IP, address_length)
+    copy (ip, b[:nb_bytes])
+    prefix := (&net.IPNet{IP: ip, Mask: net.CIDRMask (prefix_length, 8 * address_length)}).String ()
+    b = b[nb_bytes:]
+
+    count := int (binary.BigEndian.Uint16 (b))
+    b = b[2:]
+    header_length := 8 // peer index (2), originated time (4), attributes length (2)
+    if addpath {
+        header_length += 4 // path identifier
+    }
+    for i := 0; i < count; i++ {
+        if len (b) < header_length {
+            return errors.New ("truncated RIB entry")
+        }
+        peer_index := binary.BigEndian.Uint16 (b)
+        attributes_length := int (binary.BigEndian.Uint16 (b[header_length - 2:]))
+        if len (b) < header_length + attributes_length {
+            return errors.New ("truncated RIB entry")
+        }
+        if err := entry (prefix, peer_index, b[header_length:header_length + attributes_length]); err != nil {
+            return err
+        }
+        b = b[header_length + attributes_length:]
+    }
+    return nil
+}
+
+/**
+ * Decodes the BGP path attributes of a RIB entry and returns the AS path (bgpreader
+ * format: space separated, AS sets as {AS1,AS2}), the origin AS, and the next-hop.
+ * In TABLE_DUMP_V2, the AS numbers of the AS_PATH attribute are always 4 bytes long.
+ */
+func decode_bgp_attributes (b []byte, ipv6 bool) (as_path, origin, next_hop string, err error) {
+    for len (b) != 0 {
+        if len (b) < 3 {
+            return "", "", "", errors.New ("truncated attribute")
+        }
+        flags, attribute := b[0], b[1]
+        var length, offset int
+        if flags & 0x10 != 0 { // Extended length
+            if len (b) < 4 {
+                return "", "", "", errors.New ("truncated attribute")
+            }
+            length, offset = int (binary.BigEndian.Uint16 (b[2:])), 4
+        } else {
+            length, offset = int (b[2]), 3
+        }
+        if len (b) < offset + length {
+            return "", "", "", errors.New ("truncated attribute")
+        }
+        value := b[offset:offset + length]
+        b = b[offset + length:]
+
+        switch attribute {
+            case bgp_attr_as_path:
+                if as_path, origin, err = decode_as_path (value); err != nil {
+                    return "", "", "", err
+                }
+            case bgp_attr_next_hop:
+                if len (value) == 4 {
+                    next_hop = net.IP (value).String ()
+                }
+            case bgp_attr_mp_reach_nlri: // In TABLE_DUMP_V2: next-hop length (1) and next-hop only
+                if ipv6 && len (value) >= 17 {
+                    next_hop = net.IP (value[1:17]).String ()
+                }
+        }
+    }
+    return as_path, origin, next_hop, nil
+}
+
+/**
+ * Decodes an AS_PATH attribute (4-byte AS numbers).
+ */
+func decode_as_path (b []byte) (string, string, error) {
+    segments := make ([]string, 0, 8)
+    origin := ""
+    for len (b) != 0 {
+        if len (b) < 2 {
+            return "", "", errors.New ("truncated AS path")
+        }
+        segment_type, count := b[0], int (b[1])
+        if len (b) < 2 + 4 * count {
+            return "", "", errors.New ("truncated AS path")
+        }
+        ases := make ([]string, count)
+        for i := range ases {
+            ases[i] = strconv.FormatUint (uint64 (binary.BigEndian.Uint32 (b[2 + 4*i:])), 10)
+        }
+        b = b[2 + 4 * count:]
+
+        switch segment_type {
+            case 1, 4: // AS_SET, AS_CONFED_SET
+                origin = "{" + strings.Join (ases, ",") + "}"
+                segments = append (segments, origin)
+            default: // AS_SEQUENCE, AS_CONFED_SEQUENCE
+                if count != 0 {
+                    origin = ases[count - 1]
+                }
+                segments = append (segments, ases...)
+        }
+    }
+    return strings.Join (segments, " "), origin, nil
+}
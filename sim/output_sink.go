@@ -0,0 +1,167 @@
+/* ==================================================================================== *\
+     output_sink.go
+
+     Single writer for the outputs shared by all the AS workers:
+     ------------------------------------------------------------
+     The per-AS outputs (simulation results, limits, successful traces, packet ledger)
+     each have their own file, written by the single worker of that AS.
+     The shared outputs (the statistics of output_msg, split afterwards into raw.txt,
+     missing_traces.txt, ... by split_output_by_first_column) are written by all the
+     workers: they go through a channel, and a single goroutine writes them, so that
+     a line is never interleaved with another one.
+\* ==================================================================================== */
+
+package sim
+
+import (
+    "bufio"
+    "fmt"
+    "io"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+    "sync"
+    )
+
+type OutputSink struct {
+    lines chan string;
+    done chan struct{};
+    err error; // First error of the writer (read once done is closed)
+}
+
+var (
+    output_sink *OutputSink
+    output_sink_once sync.Once
+)
+
+/**
+ * Starts the goroutine writing the lines sent to the sink into w.
+ */
+func NewOutputSink (w io.Writer) *OutputSink {
+    sink := &OutputSink{lines: make (chan string, 1024), done: make (chan struct{})}
+    go func () {
+        writer := bufio.NewWriter (w)
+        for line := range sink.lines {
+            if _, err := writer.WriteString (line); err != nil && sink.err == nil {
+                sink.err = err
+            }
+        }
+        if err := writer.Flush (); err != nil && sink.err == nil {
+            sink.err = err
+        }
+        close (sink.done)
+    }()
+    return sink
+}
+
+/**
+ * Sends a complete line to the sink (the line must end with '\n').
+ */
+func (sink *OutputSink) write_line (line string) {
+    sink.lines <- line
+}
+
+/**
+ * Waits until all lines sent to the sink have been written, and returns the first error of the writer.
+ * The sink cannot be used afterwards.
+ */
+func (sink *OutputSink) close () error {
+    close (sink.lines)
+    <-sink.done
+    return sink.err
+}
+
+/**
+ * Returns the sink of the standard output (started on first use).
+ */
+func get_output_sink () *OutputSink {
+    output_sink_once.Do (func () {
+        output_sink = NewOutputSink (os.Stdout)
+    })
+    return output_sink
+}
+
+/**
+ * Flushes the shared outputs, and returns the first error writing them. Must be called once all workers
+ * are done, before post-processing the standard output (e.g., splitting it with split_output_by_first_column).
+ */
+func close_output () error {
+    if output_sink == nil {
+        return nil
+    }
+    err := output_sink.close ()
+    output_sink = nil
+    output_sink_once = sync.Once{}
+    return err
+}
+
+/**
+ * Splits the statistics redirected to a file (e.g., output.txt) into one file per first column,
+ * in the same directory. The first column is removed from the lines, e.g.:
+ *   raw.txt 3356 10 2 50 30  ->  " 3356 10 2 50 30" in raw.txt
+ * Same output as: awk '{outfile=$1; $1=""; print>outfile}' output.txt
+ * Returns every error met (reading, writing, flushing or closing a file), not only the first one.
+ * Nothing is split if the standard output was redirected elsewhere (no such file).
+ */
+func split_output_by_first_column (path string) error {
+    f, err := os.Open (path)
+    if os.IsNotExist (err) {
+        return nil
+    }
+    if err != nil {
+        return err
+    }
+    defer f.Close ()
+
+    dir := filepath.Dir (path)
+    files := make (map[string]*os.File)
+    writers := make (map[string]*bufio.Writer)
+    var errs []string
+    record := func (err error) {
+        if err != nil {
+            errs = append (errs, err.Error ())
+        }
+    }
+
+    scanner := bufio.NewScanner (f)
+    scanner.Buffer (make ([]byte, 0, 64*1024), 16*1024*1024) // Some lines list a whole set
+    for scanner.Scan () {
+        fields := strings.Fields (scanner.Text ())
+        if len (fields) == 0 {
+            continue
+        }
+        w, ok := writers[fields[0]]
+        if !ok {
+            file, err := os.Create (filepath.Join (dir, fields[0]))
+            if err != nil {
+                record (err)
+                break
+            }
+            files[fields[0]] = file
+            w = bufio.NewWriter (file)
+            writers[fields[0]] = w
+        }
+        fields[0] = ""
+        if _, err := w.WriteString (strings.Join (fields, " ") + "\n"); err != nil {
+            record (err)
+            break
+        }
+    }
+    record (scanner.Err ())
+
+    /* --- Flush and close every file, in name order --- */
+    names := make ([]string, 0, len (files))
+    for name := range files {
+        names = append (names, name)
+    }
+    sort.Strings (names)
+    for _, name := range names {
+        record (writers[name].Flush ())
+        record (files[name].Close ())
+    }
+    if len (errs) != 0 {
+        return fmt.Errorf ("%s", strings.Join (errs, "; "))
+    }
+    return nil
+}
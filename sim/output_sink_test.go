@@ -0,0 +1,101 @@
+package sim
+
+import (
+    "bytes"
+    "errors"
+    "fmt"
+    "os"
+    "path/filepath"
+    "strings"
+    "sync"
+    "testing"
+    )
+
+func TestSplitOutputByFirstColumn (t *testing.T) {
+    dir := t.TempDir ()
+    output := filepath.Join (dir, "output.txt")
+    if err := os.WriteFile (output, []byte ("raw.txt 3356 10 2\nmissing.txt 100 1\n\nraw.txt 174 3 1\n"), 0644); err != nil {
+        t.Fatal (err)
+    }
+    if err := split_output_by_first_column (output); err != nil {
+        t.Fatal (err)
+    }
+    for file, want := range map[string]string{"raw.txt": " 3356 10 2\n 174 3 1\n", "missing.txt": " 100 1\n"} {
+        if content, err := os.ReadFile (filepath.Join (dir, file)); err != nil || string (content) != want {
+            t.Errorf ("%s: %q (%v), want %q", file, content, err, want)
+        }
+    }
+
+    /* --- Not redirected to output.txt: nothing to split --- */
+    if err := split_output_by_first_column (filepath.Join (dir, "none", "output.txt")); err != nil {
+        t.Errorf ("no output.txt: %v", err)
+    }
+}
+
+/**
+ * A file that cannot be written is reported, and the files already opened are still written.
+ */
+func TestSplitOutputErrors (t *testing.T) {
+    dir := t.TempDir ()
+    output := filepath.Join (dir, "output.txt")
+    if err := os.WriteFile (output, []byte ("raw.txt 1\nblocked.txt 2\n"), 0644); err != nil {
+        t.Fatal (err)
+    }
+    if err := os.Mkdir (filepath.Join (dir, "blocked.txt"), 0755); err != nil {
+        t.Fatal (err)
+    }
+    err := split_output_by_first_column (output)
+    if err == nil || !strings.Contains (err.Error (), "blocked.txt") {
+        t.Errorf ("error: %v, want the error on blocked.txt", err)
+    }
+    if content, _ := os.ReadFile (filepath.Join (dir, "raw.txt")); string (content) != " 1\n" {
+        t.Errorf ("raw.txt: %q", content)
+    }
+}
+
+type failing_writer struct{}
+
+func (failing_writer) Write (p []byte) (int, error) {
+    return 0, errors.New ("disk full")
+}
+
+func TestOutputSinkError (t *testing.T) {
+    sink := NewOutputSink (failing_writer{})
+    sink.write_line ("raw.txt 1\n")
+    if err := sink.close (); err == nil {
+        t.Error ("no error from a failing writer")
+    }
+}
+
+/**
+ * Lines sent by concurrent workers are never interleaved (run with -race).
+ */
+func TestOutputSinkConcurrent (t *testing.T) {
+    var buf bytes.Buffer
+    sink := NewOutputSink (&buf)
+    var wg sync.WaitGroup
+    for w := 0; w < 16; w++ {
+        wg.Add (1)
+        go func (w int) {
+            defer wg.Done ()
+            for i := 0; i < 500; i++ {
+                sink.write_line (fmt.Sprintf ("raw.txt %d %d %s\n", w, i, strings.Repeat ("x", 100)))
+            }
+        }(w)
+    }
+    wg.Wait ()
+    if err := sink.close (); err != nil {
+        t.Fatal (err)
+    }
+    seen := make (map[string]struct{})
+    for _, line := range strings.Split (strings.TrimSuffix (buf.String (), "\n"), "\n") {
+        fields := strings.Fields (line)
+        if len (fields) != 4 || fields[0] != "raw.txt" || len (fields[3]) != 100 {
+            t.Fatalf ("interleaved line: %q", line)
+        }
+        seen[fields[1] + " " + fields[2]] = struct{}{}
+    }
+    if len (seen) != 16*500 {
+        t.Errorf ("%d distinct lines, want %d", len (seen), 16*500)
+    }
+}
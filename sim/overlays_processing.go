@@ -0,0 +1,397 @@
+package sim
+
+import (
+    "fmt"
+    "log"
+    "math/rand"
+    "net"
+    "strings"
+    "sort"
+    radix "github.com/Emeline-1/radix"
+    graph "github.com/Emeline-1/basic_graph")
+
+/* =============================================== *\
+                Overlay Computation
+\* =============================================== */
+
+/**
+ * Input: a forwarding table file (one entry per prefix, see print_rib_entry)
+ * Output: a set containing the overlays and their aggregate
+ *
+ * The overlays don't have to span the aggregate exactly, they can be isolated.
+ */
+func process_overlays (forwarding_table string) *SafeSet {
+    // Note: If I have 4 more specifics that span an aggregate, but that the aggregate is not
+    // in the table, then the overlays won't be found.
+    // In the probing, 4 probes are sent that could be reduced to 1.
+    
+    /* --- Build a Radix tree per address family from forwarding table, recording AS path of each entry --- */
+    tree, tree6 := radix.New(), radix.New()
+    nb_entries, malformed := 0, 0
+    reader := NewCompressedReader (forwarding_table)
+    if err := reader.Open (); err != nil {
+        log.Print ("[process_overlays]: ", err)
+        return create_safeset ()
+    }
+    scanner := reader.Scanner ()
+    for scanner.Scan () {
+        fields := strings.Fields (scanner.Text ())
+        if len (fields) == 0 {
+            continue
+        }
+        prefix, as_path := fields[0], strings.Join (fields[1:], " ")
+        network, valid := parse_overlay_prefix (prefix)
+        if !valid { // get_binary_string expects a prefix
+            malformed++
+            continue
+        }
+        nb_entries++
+        radix_prefix := get_binary_string (prefix)
+        if network.IP.To4 () == nil {
+            tree6.Insert (radix_prefix, as_path)
+        } else {
+            tree.Insert (radix_prefix, as_path)
+        }
+    }
+    if err := scanner.Err (); err != nil {
+        log.Print ("[process_overlays]: WARNING: ", forwarding_table, ": ", err)
+    }
+    reader.Close ()
+    if malformed != 0 {
+        log.Print ("[process_overlays]: WARNING: ", forwarding_table, ": ", malformed, " lines without a valid prefix skipped")
+    }
+
+    /* --- Walk radix trees, recording overlays (parent and direct children) --- */
+    overlays := create_safeset ()
+    tree.Walk_post (generate_walk_radix_tree (overlays, false))
+    tree6.Walk_post (generate_walk_radix_tree (overlays, true))
+
+    /* --- Compute transitive closure of overlays thanks to graphs connected components --- */
+    g := graph.New ()
+    rejected := 0
+    for aggregate, overlays_i := range overlays.set {
+        overlays_v, _ := overlays_i.(map[string]struct{})
+        overlays_l := make ([]string, 0, len (overlays_v))
+        for overlay, _ := range overlays_v {
+            overlays_l = append (overlays_l, overlay)
+        }
+        rejected += add_overlay_edges (g, aggregate, overlays_l)
+    }
+    if rejected != 0 {
+        log.Println ("[process_overlays]: rejected", rejected, "overlay tokens")
+    }
+
+    overlays_closure := create_safeset ()
+    g.Set_iterator ()
+    for g.Next_connected_component () {
+        connected_component := g.Connected_component ()
+        overlays_closure.unsafe_add (connected_component[0], connected_component[1:])
+    }
+    report_overlay_components (overlays_closure, nb_entries, "process_overlays")
+    return overlays_closure
+}
+
+/* =============================================== *\
+                Overlay Validation
+\* =============================================== */
+
+/**
+ * Parses an overlay token. A token is valid iif it is a prefix in its canonical
+ * form (e.g., 1.2.3.0/24, not 1.2.3.4/24 nor a stray header field).
+ * Contrary to check_prefix_validity, nothing is logged: rejected tokens are counted by the caller.
+ */
+func parse_overlay_prefix (token string) (*net.IPNet, bool) {
+    _, network, err := net.ParseCIDR (token)
+    if err != nil || network.String () != token {
+        return nil, false
+    }
+    return network, true
+}
+
+/**
+ * Returns true if both prefixes belong to the same address family.
+ */
+func same_family (n1, n2 *net.IPNet) bool {
+    return (n1.IP.To4 () == nil) == (n2.IP.To4 () == nil)
+}
+
+/**
+ * Adds to the graph the edges between an aggregate and its overlays, after having
+ * validated every token. An invalid node must never enter the graph, as it could bridge
+ * otherwise-unrelated overlay groups.
+ * Returns the number of rejected tokens (if the aggregate itself is invalid, the whole group is rejected).
+ */
+func add_overlay_edges (g *graph.Graph, aggregate string, overlays []string) int {
+    aggregate_net, valid := parse_overlay_prefix (aggregate)
+    if !valid {
+        return 1 + len (overlays)
+    }
+    rejected := 0
+    for _, overlay := range overlays {
+        overlay_net, valid := parse_overlay_prefix (overlay)
+        if !valid || !same_family (aggregate_net, overlay_net) {
+            rejected++
+            continue
+        }
+        g.Add_edge (aggregate, overlay)
+    }
+    return rejected
+}
+
+/**
+ * Logs the size of the largest overlay groups of the closure, and warns when a group
+ * gathers more than g_args.overlay_max_fraction of all prefixes (nb_prefixes), which
+ * usually means that unrelated groups were merged.
+ */
+func report_overlay_components (overlays_closure *SafeSet, nb_prefixes int, source string) {
+    if nb_prefixes == 0 {
+        return
+    }
+    sizes := make ([]int, 0, len (overlays_closure.set))
+    for _, members_i := range overlays_closure.set {
+        members, _ := members_i.([]string)
+        sizes = append (sizes, len (members) + 1)
+    }
+    sort.Sort (sort.Reverse (sort.IntSlice (sizes)))
+    if len (sizes) > 5 {
+        sizes = sizes[:5]
+    }
+    log.Println ("[" + source + "]: largest overlay groups:", sizes, "out of", nb_prefixes, "prefixes")
+    if len (sizes) != 0 && float64 (sizes[0]) > g_args.overlay_max_fraction * float64 (nb_prefixes) {
+        log.Printf ("[%s]: WARNING: an overlay group contains %d prefixes (more than %.2f%% of all prefixes)", source, sizes[0], 100 * g_args.overlay_max_fraction)
+    }
+}
+
+/**
+ * Function performing an action during the post-order walk of a radix tree.
+ * - overlays: key: the aggregate prefix
+ *             value: all its overlays.
+ */
+func generate_walk_radix_tree (overlays *SafeSet, ipv6 bool) radix.WalkFnPost {
+    return func (parent *radix.LeafNode, children []*radix.LeafNode) {
+        aggregate_prefix := get_prefix_from_binary (parent.Key, ipv6)
+        aggregate_aspath,_ := parent.Val.(string)
+
+        marked_prefixes := make ([]string, 0, len (children))
+        marked_ases := make ([]string, 0, len (children))
+        for _, more_specific := range children {
+            more_specific_aspath,_ := more_specific.Val.(string)
+            if more_specific_aspath == aggregate_aspath {
+                overlays.unsafe_append (aggregate_prefix, get_prefix_from_binary (more_specific.Key, ipv6))
+            } else {
+                marked_prefixes = append (marked_prefixes, more_specific.Key) 
+                marked_ases = append (marked_ases, more_specific.Val.(string))
+            }
+        }
+
+        /* --- Detect implicit aggregates of overlays --- */
+        // Among the more specifics with the same AS path, each block they tile exactly (possibly smaller than the parent)
+        groups := make (map[string][]string) // AS path -> marked prefixes
+        for i, prefix := range marked_prefixes {
+            groups[marked_ases[i]] = append (groups[marked_ases[i]], prefix)
+        }
+        for _, members := range groups {
+            if len (members) < 2 {
+                continue
+            }
+            for block, block_members := range implicit_aggregates (len (parent.Key), members) {
+                for _, prefix := range block_members {
+                    overlays.unsafe_append (get_prefix_from_binary (block, ipv6), get_prefix_from_binary (prefix, ipv6))
+                }
+            }
+        }
+    }
+}
+
+/**
+ * Given disjoint prefixes (binary keys) below a parent of length parent_len, returns the maximal
+ * blocks they tile exactly (binary key -> the prefixes in the block). A block is at least as long as
+ * the parent, and contains at least two prefixes. Prefixes in no such block are left out.
+ * ex: 00, 01 and 10 below the parent "" -> {0: [00, 01]}
+ */
+func implicit_aggregates (parent_len int, prefixes []string) map[string][]string {
+    /* --- Blocks covered by the prefixes: merge sibling blocks, longest first --- */
+    covered := make (map[string]struct{}, len (prefixes))
+    by_length := make (map[int][]string)
+    max_len := 0
+    for _, prefix := range prefixes {
+        covered[prefix] = struct{}{}
+        by_length[len (prefix)] = append (by_length[len (prefix)], prefix)
+        if len (prefix) > max_len {
+            max_len = len (prefix)
+        }
+    }
+    for l := max_len; l > parent_len; l-- {
+        for _, block := range by_length[l] {
+            sibling := block[:l-1] + "1"
+            if block[l-1] == '1' {
+                sibling = block[:l-1] + "0"
+            }
+            if _, ok := covered[sibling]; !ok {
+                continue
+            }
+            if _, ok := covered[block[:l-1]]; !ok {
+                covered[block[:l-1]] = struct{}{}
+                by_length[l-1] = append (by_length[l-1], block[:l-1])
+            }
+        }
+    }
+
+    /* --- Largest block of each prefix --- */
+    aggregates := make (map[string][]string)
+    for _, prefix := range prefixes {
+        for l := parent_len; l < len (prefix); l++ {
+            if _, ok := covered[prefix[:l]]; ok {
+                aggregates[prefix[:l]] = append (aggregates[prefix[:l]], prefix)
+                break
+            }
+        }
+    }
+    return aggregates
+}
+
+/* =============================================== *\
+                Overlay Reduction
+\* =============================================== */
+
+/**
+ * Returns the VPs of vps that have no collector in vp_collectors.
+ */
+func vps_without_collector (vps []string, vp_collectors map[string]string) []string {
+    missing := make ([]string, 0)
+    for _, vp := range vps {
+        if _, ok := vp_collectors[vp]; !ok {
+            missing = append (missing, vp)
+        }
+    }
+    return missing
+}
+
+/**
+ * Given:
+ * - vps: the VPs
+ * - vp_collectors: a mapping between a VP and its collector
+ * - overlays_dir: the per-collector overlays (overlays_<collector>.txt, output of rib_parsing)
+ * returns the overlays seen by each VP, i.e. those of its own collector. Each collector file is read once,
+ * and the VPs of a same collector share its overlays.
+ */
+func read_per_vp_overlays (vps []string, vp_collectors map[string]string, overlays_dir string) (map[string]map[string]map[string]interface{}, error) {
+    if missing := vps_without_collector (vps, vp_collectors); len (missing) != 0 {
+        return nil, fmt.Errorf ("no collector assigned to the VPs: %s", strings.Join (missing, " "))
+    }
+
+    collector_overlays := make (map[string]map[string]map[string]interface{})
+    overlays := make (map[string]map[string]map[string]interface{})
+    for _, vp := range vps {
+        collector := vp_collectors[vp]
+        if _, ok := collector_overlays[collector]; !ok {
+            filename := plain_or_gz (overlays_dir + "/overlays_" + collector + ".txt")
+            if err := check_readable (filename); err != nil {
+                return nil, fmt.Errorf ("VP %s: overlays of collector %s: %v", vp, collector, err)
+            }
+            collector_overlays[collector] = read_overlay_file (filename)
+        }
+        overlays[vp] = collector_overlays[collector]
+    }
+    return overlays, nil
+}
+
+/**
+ * Given: 
+ * - AS_probes: a mapping between an AS and all its probes (must be raw probes)
+ * - ases: the ases for which the overlay reduction must be applied
+ * - target_to_vp: a mapping between a target (/24) and the VPs that launched it
+ * - overlays: the overlays per collector/VP (raw, come directly from the forwarding tables)
+ * only record one prefix (/24) per overlay group.
+ *
+ * Post: overlay reduction has been applied to AS_probes
+ *
+ * The issue is that we are working on a raw (no /24) level, but that to get the VP, we need to pick a /24 randomly from the
+ * raw prefix (drawn from r).
+ * Because of this, some targets will be reduced, some not, depending on the VP that we get. But we cannot control everything,
+ * because of TNT data.
+ *
+ * When a /24 was probed by several VPs, the reduction is applied from the viewpoint of each of them: a probe is
+ * removed only if, for every VP that probed it, a probe of its overlay group was already kept for that same VP.
+ */
+func remove_overlays (AS_probes map[string]map[string]interface{}, ases []string, target_to_vp VP_mapper, overlays map[string]map[string]map[string]interface{}, as_interest string, r *rand.Rand) {
+    
+    /* --- Range over the ASes --- */
+    for _, AS := range ases {
+        seen := make (map[string]map[string]interface{})
+        s := make (map[string]interface{})
+
+        /* --- Range over the probes of the ASes --- */
+        for _, p := range order_overlay_candidates (AS_probes[AS], as_interest, r) {
+            probe, probe_24 := p.probe, p.probe_24
+            probe_vps, _ := target_to_vp.Get (probe_24)
+            if len (probe_vps) == 0 { // some directed probes are not in the traces. Simply add it in the probes (in order not to count that
+                // as an overlay reduction. And it will be ignored by the simulation engine anyway).
+                s[probe_24] = struct{}{}
+                continue
+            }
+
+            covered := true
+            for _, VP := range probe_vps {
+                if _, present := seen[VP][probe]; !present {
+                    covered = false
+                    break
+                }
+            }
+            if covered {
+                continue
+            }
+            s[probe_24] = struct{}{} // Record probe
+            // Record all other probes in its overlay group, for every VP that probed it
+            for _, VP := range probe_vps {
+                append_overlays (seen, VP, overlays[VP][probe])
+            }
+        }
+
+        /* --- Update the probes of the AS with the overlay reduction --- */
+        AS_probes[AS] = s
+    }
+}
+
+type overlay_candidate struct {
+    probe string;    // The raw probe
+    probe_24 string; // The /24 picked in the raw probe
+    rtt float64;     // The RTT to the AS of interest of the trace towards probe_24 (-1 if unknown)
+}
+
+/**
+ * Returns the probes of an AS in the order in which they are considered as representative
+ * of their overlay group (the first probe of a group seen is kept, the others are reduced).
+ * - With the "any" overlay metric, the order is arbitrary.
+ * - With the "rtt" overlay metric, the probes whose trace enters the AS of interest with the
+ *   lowest RTT come first, and probes without RTT come last.
+ */
+func order_overlay_candidates (probes map[string]interface{}, as_interest string, r *rand.Rand) []*overlay_candidate {
+    candidates := make ([]*overlay_candidate, 0, len (probes))
+    for _, probe := range sorted_keys (&probes) {
+        c := &overlay_candidate{probe: probe, probe_24: _get_24_prefix (r, probe), rtt: -1}
+        if g_args.overlay_metric == "rtt" && strategy_traces != nil {
+            if trace_i, ok := strategy_traces.get (c.probe_24); ok {
+                c.rtt = trace_i.(*Trace).entry_rtt (as_interest)
+            }
+        }
+        candidates = append (candidates, c)
+    }
+    if g_args.overlay_metric == "rtt" {
+        sort.SliceStable (candidates, func (i, j int) bool {
+            if candidates[i].rtt < 0 || candidates[j].rtt < 0 {
+                return candidates[j].rtt < 0 && candidates[i].rtt >= 0
+            }
+            return candidates[i].rtt < candidates[j].rtt
+        })
+    }
+    return candidates
+}
+
+func append_overlays (seen map[string]map[string]interface{}, vp string, overlays map[string]interface{}) {
+    if already_seen, present := seen[vp]; present {
+        seen[vp] = merge_maps (already_seen, overlays)
+    } else {
+        seen[vp] = merge_maps_new (overlays, nil) // Make a copy
+    }
+}
\ No newline at end of file
@@ -0,0 +1,178 @@
+package sim
+
+import (
+    "math/rand"
+    "os"
+    "path/filepath"
+    "reflect"
+    "sort"
+    "strings"
+    "testing"
+    graph "github.com/Emeline-1/basic_graph")
+
+/**
+ * IPv4 and IPv6 prefixes of a forwarding table are walked in their own radix tree, and a line
+ * without a valid prefix is skipped.
+ */
+func TestProcessOverlaysFamilies (t *testing.T) {
+    table := filepath.Join (t.TempDir (), "rrc00.txt")
+    content := "10.0.0.0/16 1 2 3\n10.0.1.0/24 1 2 3\n10.0.2.0/24 1 2 4\n" +
+        "2001:db8::/32 5 6\n2001:db8:1::/48 5 6\n2001:db8:2::/48 5 7\n" +
+        "not_a_prefix 1 2\n10.0.3.0 1 2 3\n\n"
+    if err := os.WriteFile (table, []byte (content), 0644); err != nil {
+        t.Fatal (err)
+    }
+    closure := process_overlays (table)
+    groups := make (map[string][]string)
+    for aggregate, overlays_i := range closure.set {
+        group := append ([]string{aggregate}, overlays_i.([]string)...)
+        sort.Strings (group)
+        groups[group[0]] = group
+    }
+    want := map[string][]string {
+        "10.0.0.0/16": {"10.0.0.0/16", "10.0.1.0/24"},
+        "2001:db8:1::/48": {"2001:db8:1::/48", "2001:db8::/32"},
+    }
+    if !reflect.DeepEqual (groups, want) {
+        t.Errorf ("overlays: %v, want %v", groups, want)
+    }
+}
+
+func TestParseOverlayPrefix (t *testing.T) {
+    for token, want := range map[string]bool {
+        "10.0.0.0/24": true,
+        "2001:db8::/32": true,
+        "10.0.0.1/24": false, // Not canonical
+        "10.0.0.0": false,
+        "#": false,
+        "": false,
+        "prefix": false,
+    } {
+        if _, valid := parse_overlay_prefix (token); valid != want {
+            t.Errorf ("%q: valid %v, want %v", token, valid, want)
+        }
+    }
+}
+
+/**
+ * An invalid token or a prefix of the other address family never enters the graph, so that it
+ * cannot bridge two overlay groups.
+ */
+func TestAddOverlayEdgesNoBridge (t *testing.T) {
+    g := graph.New ()
+    rejected := add_overlay_edges (g, "10.0.0.0/16", []string{"10.0.1.0/24", "garbage", "2001:db8::/48"})
+    rejected += add_overlay_edges (g, "192.0.2.0/23", []string{"192.0.2.0/24", "garbage"})
+    rejected += add_overlay_edges (g, "2001:db8::/32", []string{"2001:db8::/48", "10.0.1.0/24"})
+    rejected += add_overlay_edges (g, "header", []string{"10.0.1.0/24", "192.0.2.0/24"}) // Invalid aggregate: whole line rejected
+    if rejected != 7 {
+        t.Errorf ("%d tokens rejected, want 7", rejected)
+    }
+    components := 0
+    g.Set_iterator ()
+    for g.Next_connected_component () {
+        if component := g.Connected_component (); len (component) != 2 {
+            t.Errorf ("component %v, want 2 prefixes", component)
+        }
+        components++
+    }
+    if components != 3 {
+        t.Errorf ("%d components, want 3", components)
+    }
+}
+
+/**
+ * A /24 probed by several VPs is reduced only if its overlay group was already probed from each of
+ * its VPs, whatever the order in which its VPs were recorded.
+ */
+func TestRemoveOverlaysSeveralVPs (t *testing.T) {
+    group := func (prefixes ...string) map[string]interface{} {
+        m := make (map[string]interface{})
+        for _, prefix := range prefixes {
+            m[prefix] = struct{}{}
+        }
+        return m
+    }
+    ab, cd := group ("10.0.0.0/24", "10.0.1.0/24"), group ("10.0.2.0/24", "10.0.3.0/24")
+    overlays := make (map[string]map[string]map[string]interface{})
+    for _, vp := range []string{"vp1", "vp2"} {
+        overlays[vp] = map[string]map[string]interface{}{"10.0.0.0/24": ab, "10.0.1.0/24": ab, "10.0.2.0/24": cd, "10.0.3.0/24": cd}
+    }
+    for _, order := range [][]string{{"vp1", "vp2"}, {"vp2", "vp1"}} {
+        set := create_safeset ()
+        set.append ("10.0.0.0/24", "vp1")
+        for _, vp := range order {
+            set.append ("10.0.1.0/24", vp) // Not covered for vp2: kept
+            set.append ("10.0.2.0/24", vp)
+        }
+        set.append ("10.0.3.0/24", "vp2") // Covered by 10.0.2.0/24 for vp2: reduced
+        set.append ("10.0.4.0/24", "vp1") // No overlay
+        AS_probes := map[string]map[string]interface{}{"200": group ("10.0.0.0/24", "10.0.1.0/24", "10.0.2.0/24", "10.0.3.0/24", "10.0.4.0/24", "10.0.5.0/24")}
+        remove_overlays (AS_probes, []string{"200"}, NewSafeSetVPMapper (set), overlays, "100", rand.New (rand.NewSource (1)))
+        got, want := AS_probes["200"], group ("10.0.0.0/24", "10.0.1.0/24", "10.0.2.0/24", "10.0.4.0/24", "10.0.5.0/24") // 10.0.5.0/24: no VP, kept
+        if !reflect.DeepEqual (got, want) {
+            t.Errorf ("VPs recorded in the order %v: %v, want %v", order, sorted_keys (&got), sorted_keys (&want))
+        }
+    }
+}
+
+/**
+ * The overlays of the forwarding table written from a routing entries set (*Rib_entry values):
+ * two prefixes with identical AS paths end up in one overlay group.
+ */
+func TestProcessOverlaysRoutingEntries (t *testing.T) {
+    routing_entries_set := create_safeset ()
+    routing_entries_set.add ("10.0.0.0/16", &Rib_entry{as_path: []string{"1", "2", "3"}})
+    routing_entries_set.add ("10.0.1.0/24", &Rib_entry{as_path: []string{"1", "2", "3"}})
+    routing_entries_set.add ("10.0.2.0/24", &Rib_entry{as_path: []string{"1", "2", "4"}})
+    table := filepath.Join (t.TempDir (), "rrc00.txt")
+    routing_entries_set.write_to_file (table, print_rib_entry)
+
+    closure := process_overlays (table)
+    if len (closure.set) != 1 {
+        t.Fatalf ("%d overlay groups, want 1: %v", len (closure.set), closure.set)
+    }
+    for aggregate, overlays_i := range closure.set {
+        group := append ([]string{aggregate}, overlays_i.([]string)...)
+        sort.Strings (group)
+        if !reflect.DeepEqual (group, []string{"10.0.0.0/16", "10.0.1.0/24"}) {
+            t.Errorf ("overlay group: %v", group)
+        }
+    }
+}
+
+/**
+ * The overlay groups of a file, one per line: the prefixes of each group sorted, and the groups sorted.
+ */
+func overlay_groups (t *testing.T, filename string) []string {
+    t.Helper ()
+    groups := sorted_lines (t, filename)
+    for i, group := range groups {
+        prefixes := strings.Fields (group)
+        sort.Strings (prefixes)
+        groups[i] = strings.Join (prefixes, " ")
+    }
+    sort.Strings (groups)
+    return groups
+}
+
+/**
+ * The overlay groups computed by ribs_multi on the dump of testdata/overlays, for both heuristics.
+ * Paths: P = 1 100 300, A = 1 100 400, B = 1 100 500, C = 1 100 600.
+ *   20.0.0.0/16 P, 20.0.0.0/17 P                          -> one group (same AS path as the aggregate)
+ *   21.0.0.0/16 P, 21.0.0.0/17 A                          -> no overlay
+ *   23.0.0.0/22 P, its /24s A A B C                       -> implicit 23.0.0.0/23 (the two A)
+ *   24.0.0.0/22 P, its /24s A A B B                       -> implicit 24.0.0.0/23 and 24.0.2.0/23
+ *   25.0.0.0/22 P, 25.0.0.0/23 A, 25.0.2.0/24 A, .3/24 C  -> no overlay (the A do not tile a block)
+ *   26.0.0.0/23 P, its /24s A A                           -> implicit aggregate of the size of the parent
+ */
+func TestRibsMultiOverlays (t *testing.T) {
+    expected := overlay_groups (t, filepath.Join ("..", "testdata", "overlays", "expected_overlays.txt"))
+    for heuristic := 0; heuristic < 2; heuristic++ {
+        out, _ := run_ribs_multi (t, "overlays", "dump", heuristic, "-overlay_warn", "1")
+        for _, file := range []string{"overlays_rrc00.txt", "all_overlays.txt"} {
+            if groups := overlay_groups (t, filepath.Join (out, "overlays", file)); !reflect.DeepEqual (groups, expected) {
+                t.Errorf ("heuristic %d: %s: %q, want %q", heuristic, file, groups, expected)
+            }
+        }
+    }
+}
@@ -0,0 +1,141 @@
+/* ==================================================================================== *\
+     peer_coverage.go
+
+     Coverage of the BGP peers:
+     --------------------------
+     ./anaximander analysis peer_coverage [-min n] [-fraction f] [-o output_file] <ribs_dir | peers_file>
+     ribs_multi records, for each collector, each BGP peer (ASN and IP) and the number of
+     valid prefixes it contributed (collectors/all_BGP_peers.txt):
+       [collector peer_asn peer_ip n_prefixes]
+     A peer is partial when it contributed fewer than -min prefixes, or fewer than -fraction
+     of the prefixes of the largest peer of its collector (the full feeds). The partial peers
+     are printed per collector, and written in output_file in the format of the input, so
+     that they can be pruned before the heuristics run.
+\* ==================================================================================== */
+
+package sim
+
+import (
+    "fmt"
+    "log"
+    "os"
+    "path/filepath"
+    "sort"
+    "strconv"
+    "strings"
+    )
+
+/**
+ * A BGP peer of a collector.
+ */
+type bgp_peer struct {
+    collector, asn, ip string;
+    nb_prefixes int;
+}
+
+func (p bgp_peer) String () string {
+    return p.collector + " " + p.asn + " " + p.ip + " " + strconv.Itoa (p.nb_prefixes)
+}
+
+/**
+ * Reads the BGP peers written by ribs_multi (see print_bgp_peer). Lines in another format (e.g.,
+ * the peer ASNs alone, from older versions) are counted and skipped.
+ */
+func read_bgp_peers (filename string) ([]bgp_peer, int) {
+    reader := NewCompressedReader (filename)
+    if err := reader.Open (); err != nil {
+        log.Fatal ("[read_bgp_peers]: ", err)
+    }
+    defer reader.Close ()
+    peers := []bgp_peer{}
+    malformed := 0
+    scanner := reader.Scanner ()
+    for scanner.Scan () {
+        fields := strings.Fields (scanner.Text ())
+        if len (fields) == 0 {
+            continue
+        }
+        if len (fields) != 4 {
+            malformed++
+            continue
+        }
+        n, err := strconv.Atoi (fields[3])
+        if err != nil {
+            malformed++
+            continue
+        }
+        peers = append (peers, bgp_peer{collector: fields[0], asn: fields[1], ip: fields[2], nb_prefixes: n})
+    }
+    if err := scanner.Err (); err != nil {
+        log.Fatal ("[read_bgp_peers]: ", filename, ": ", err)
+    }
+    return peers, malformed
+}
+
+/**
+ * Reports the partial peers of each collector (see the header of the file).
+ */
+func peer_coverage (input, output_file string, min_prefixes int, fraction float64) {
+    if info, err := os.Stat (input); err == nil && info.IsDir () {
+        input = filepath.Join (input, "collectors", "all_BGP_peers.txt")
+    }
+    peers, malformed := read_bgp_peers (input)
+    if malformed != 0 {
+        log.Printf ("[peer_coverage]: %d lines skipped (not [collector peer_asn peer_ip n_prefixes], see ribs_multi)", malformed)
+    }
+
+    /* --- Largest peer of each collector --- */
+    by_collector := make (map[string][]bgp_peer)
+    largest := make (map[string]int)
+    for _, p := range peers {
+        by_collector[p.collector] = append (by_collector[p.collector], p)
+        if p.nb_prefixes > largest[p.collector] {
+            largest[p.collector] = p.nb_prefixes
+        }
+    }
+    collectors := make ([]string, 0, len (by_collector))
+    for collector := range by_collector {
+        collectors = append (collectors, collector)
+    }
+    sort.Strings (collectors)
+
+    /* --- Partial peers, fewest prefixes first --- */
+    fmt.Printf ("%d peers on %d collectors (partial: fewer than %d prefixes, or than %g of the largest peer of the collector)\n\n", len (peers), len (collectors), min_prefixes, fraction)
+    fmt.Printf ("%-16s %-8s %-10s %-40s %12s %9s\n", "collector", "partial", "peer_asn", "peer_ip", "n_prefixes", "fraction")
+    partial := []bgp_peer{}
+    for _, collector := range collectors {
+        collector_peers := by_collector[collector]
+        sort.Slice (collector_peers, func (i, j int) bool {
+            if collector_peers[i].nb_prefixes != collector_peers[j].nb_prefixes {
+                return collector_peers[i].nb_prefixes < collector_peers[j].nb_prefixes
+            }
+            return collector_peers[i].asn + "@" + collector_peers[i].ip < collector_peers[j].asn + "@" + collector_peers[j].ip
+        })
+        nb_partial := 0
+        for _, p := range collector_peers {
+            share := 0.0
+            if largest[collector] > 0 {
+                share = float64 (p.nb_prefixes) / float64 (largest[collector])
+            }
+            if p.nb_prefixes >= min_prefixes && share >= fraction {
+                continue
+            }
+            nb_partial++
+            partial = append (partial, p)
+            fmt.Printf ("%-16s %-8s %-10s %-40s %12d %9.4f\n", collector, "", p.asn, p.ip, p.nb_prefixes, share)
+        }
+        fmt.Printf ("%-16s %-8d of %d peers, largest peer %d prefixes\n", collector, nb_partial, len (collector_peers), largest[collector])
+    }
+    fmt.Printf ("\n%d partial peers\n", len (partial))
+
+    if output_file == "" {
+        return
+    }
+    var content strings.Builder
+    for _, p := range partial {
+        content.WriteString (p.String () + "\n")
+    }
+    if err := os.WriteFile (output_file, []byte (content.String ()), 0644); err != nil {
+        log.Fatal ("[peer_coverage]: ", err)
+    }
+}
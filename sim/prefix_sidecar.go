@@ -0,0 +1,345 @@
+/* ==================================================================================== *\
+     prefix_sidecar.go
+
+     Binary sidecars of the directed prefixes and of the targets:
+     ------------------------------------------------------------
+     The directed prefixes (directed_prefixes_<AS>.txt) and the targets of the strategy
+     step (targets.txt) are re-parsed by every strategy/simulation run. With -bin, a
+     compact binary copy (same name, .bin extension) is written alongside the text file,
+     and the readers prefer it when it is not older than the text file. Otherwise (no
+     sidecar, stale or unreadable sidecar), they transparently read the text file.
+
+     Format:
+       - magic "AXPF", then a version byte (sidecar_version)
+       - records, each prefixed by its length (uvarint):
+           network (uint32, big endian) | mask (byte) | annotation (byte) | [raw prefix]
+       - mask_address is the mask of a single address (a target, written without mask).
+       - the annotation is the kind of a directed prefix (dependent or up/down prefix, see
+         generate_print_collectors), annotation_none otherwise.
+       - the optional raw prefix (network + mask, length 11 records) is the prefix in which
+         the target address was picked (second column of the targets.txt of older
+         strategies, see raw_prefixes_file).
+
+     Only IPv4 is supported: a file with another kind of line gets no sidecar.
+\* ==================================================================================== */
+
+package sim
+
+import (
+    "encoding/binary"
+    "errors"
+    "fmt"
+    "log"
+    "net"
+    "os"
+    "strconv"
+    "strings"
+    )
+
+const (
+    sidecar_magic = "AXPF"
+    sidecar_version byte = 2
+    mask_address byte = 0xff // A single address, written without mask
+)
+
+/* --- Annotation of a record: the kind of a directed prefix --- */
+const (
+    annotation_none byte = iota
+    annotation_dependent       // "d": seen through the AS of interest from every collector
+    annotation_up_down         // "u/d": seen through the AS of interest from some collectors only
+)
+
+var annotation_names = map[string]byte{"d": annotation_dependent, "u/d": annotation_up_down}
+
+/**
+ * A line of a directed prefixes file or of a targets file.
+ */
+type prefix_record struct {
+    prefix string;     // Prefix (a.b.c.d/m) or address (a.b.c.d)
+    raw string;        // Raw prefix in which the address was picked, if any
+    annotation byte;   // Kind of a directed prefix (annotation_none for the targets)
+}
+
+/**
+ * Returns the sidecar of a text file: same name, .bin extension.
+ */
+func sidecar_path (filename string) string {
+    return strings.TrimSuffix (filename, ".txt") + ".bin"
+}
+
+/* ------------------------------------------------------------------------------- *\
+                                     Encoding
+\* ------------------------------------------------------------------------------- */
+
+func _parse_ipv4_prefix (prefix string) (uint32, byte, error) {
+    address, network, err := net.ParseCIDR (prefix)
+    if err != nil {
+        return 0, 0, err
+    }
+    ip := address.To4 () // As written in the file (not masked), so that the text is preserved
+    ones, bits := network.Mask.Size ()
+    if ip == nil || bits != 32 {
+        return 0, 0, fmt.Errorf ("not an IPv4 prefix: %s", prefix)
+    }
+    return binary.BigEndian.Uint32 (ip), byte (ones), nil
+}
+
+/**
+ * Appends the dotted notation of the address, and the mask if it is not negative.
+ */
+func _append_ipv4 (buf []byte, network uint32, mask int) []byte {
+    for shift := 24; shift >= 0; shift -= 8 {
+        buf = strconv.AppendUint (buf, uint64 (network >> uint (shift) & 0xff), 10)
+        if shift != 0 {
+            buf = append (buf, '.')
+        }
+    }
+    if mask >= 0 {
+        buf = append (buf, '/')
+        buf = strconv.AppendInt (buf, int64 (mask), 10)
+    }
+    return buf
+}
+
+func _append_uint32 (buf []byte, v uint32) []byte {
+    var b [4]byte
+    binary.BigEndian.PutUint32 (b[:], v)
+    return append (buf, b[:]...)
+}
+
+/**
+ * Appends the encoding of the record (without its length) to buf.
+ */
+func encode_prefix_record (buf []byte, record prefix_record) ([]byte, error) {
+    var network uint32
+    var mask byte
+    if record.annotation > annotation_up_down {
+        return nil, fmt.Errorf ("unknown annotation: %d", record.annotation)
+    }
+    if strings.Contains (record.prefix, "/") {
+        if record.raw != "" {
+            return nil, fmt.Errorf ("raw prefix on a prefix: %s", record.prefix)
+        }
+        n, m, err := _parse_ipv4_prefix (record.prefix)
+        if err != nil {
+            return nil, err
+        }
+        network, mask = n, m
+    } else {
+        ip := net.ParseIP (record.prefix).To4 ()
+        if ip == nil || !is_ipv4_literal (record.prefix) {
+            return nil, fmt.Errorf ("not an IPv4 address: %s", record.prefix)
+        }
+        network, mask = binary.BigEndian.Uint32 (ip), mask_address
+    }
+    buf = _append_uint32 (buf, network)
+    buf = append (buf, mask, record.annotation)
+    if record.raw != "" {
+        raw_network, raw_mask, err := _parse_ipv4_prefix (record.raw)
+        if err != nil {
+            return nil, err
+        }
+        buf = _append_uint32 (buf, raw_network)
+        buf = append (buf, raw_mask)
+    }
+    return buf, nil
+}
+
+/**
+ * Decodes a record (without its length). scratch is a buffer reused between calls.
+ */
+func decode_prefix_record (buf []byte, scratch *[]byte) (prefix_record, error) {
+    if len (buf) < 6 {
+        return prefix_record{}, errors.New ("truncated record")
+    }
+    network, mask, annotation := binary.BigEndian.Uint32 (buf), buf[4], buf[5]
+    if mask > 32 && mask != mask_address {
+        return prefix_record{}, fmt.Errorf ("invalid mask: %d", mask)
+    }
+    if annotation > annotation_up_down {
+        return prefix_record{}, fmt.Errorf ("unknown annotation: %d", annotation)
+    }
+    record := prefix_record{annotation: annotation}
+    if mask == mask_address {
+        *scratch = _append_ipv4 ((*scratch)[:0], network, -1)
+    } else {
+        *scratch = _append_ipv4 ((*scratch)[:0], network, int (mask))
+    }
+    record.prefix = string (*scratch)
+    switch len (buf) {
+        case 6:
+        case 11: // Raw prefix of a target address
+            if mask != mask_address || buf[10] > 32 {
+                return prefix_record{}, errors.New ("invalid raw prefix")
+            }
+            *scratch = _append_ipv4 ((*scratch)[:0], binary.BigEndian.Uint32 (buf[6:]), int (buf[10]))
+            record.raw = string (*scratch)
+        default:
+            return prefix_record{}, fmt.Errorf ("invalid record length: %d", len (buf))
+    }
+    return record, nil
+}
+
+/* ------------------------------------------------------------------------------- *\
+                                 Writing and Reading
+\* ------------------------------------------------------------------------------- */
+
+/**
+ * Writes the sidecar of the text file 'filename'. The sidecar is written in a temporary
+ * file first, so that a reader never sees a partial sidecar.
+ */
+func write_prefix_sidecar (filename string, records []prefix_record) error {
+    buf := append ([]byte (sidecar_magic), sidecar_version)
+    record := make ([]byte, 0, 16)
+    var length [binary.MaxVarintLen64]byte
+    for _, r := range records {
+        var err error
+        if record, err = encode_prefix_record (record[:0], r); err != nil {
+            return fmt.Errorf ("[write_prefix_sidecar]: %s: %w", filename, err)
+        }
+        n := binary.PutUvarint (length[:], uint64 (len (record)))
+        buf = append (buf, length[:n]...)
+        buf = append (buf, record...)
+    }
+    tmp := sidecar_path (filename) + ".tmp"
+    if err := os.WriteFile (tmp, buf, 0644); err != nil {
+        return err
+    }
+    return os.Rename (tmp, sidecar_path (filename))
+}
+
+/**
+ * Writes the sidecar of the text file if -bin was given, and logs the failures
+ * (the readers then fall back to the text file).
+ */
+func write_prefix_sidecar_if_enabled (filename string, records []prefix_record) {
+    if !g_args.write_sidecars {
+        return
+    }
+    if err := write_prefix_sidecar (filename, records); err != nil {
+        log.Print (err)
+        os.Remove (sidecar_path (filename)) // Do not leave a stale sidecar behind
+    }
+}
+
+/**
+ * Reads the sidecar of the text file 'filename'.
+ * Returns false if there is no sidecar, if it is older than the text file, or if it
+ * cannot be decoded (logged).
+ */
+func read_prefix_sidecar (filename string) ([]prefix_record, bool) {
+    sidecar := sidecar_path (filename)
+    sidecar_info, err := os.Stat (sidecar)
+    if err != nil {
+        return nil, false
+    }
+    if text_info, err := os.Stat (filename); err == nil && sidecar_info.ModTime ().Before (text_info.ModTime ()) {
+        return nil, false
+    }
+
+    content, err := os.ReadFile (sidecar)
+    if err != nil {
+        log.Print ("[read_prefix_sidecar]: ", err, ", reading ", filename)
+        return nil, false
+    }
+    if len (content) <= len (sidecar_magic) || string (content[:len (sidecar_magic)]) != sidecar_magic {
+        log.Print ("[read_prefix_sidecar]: ", sidecar, ": not a sidecar, reading ", filename)
+        return nil, false
+    }
+    if version := content[len (sidecar_magic)]; version != sidecar_version {
+        log.Print ("[read_prefix_sidecar]: ", sidecar, ": unsupported version ", version, ", reading ", filename)
+        return nil, false
+    }
+
+    content = content[len (sidecar_magic) + 1:]
+    records := make ([]prefix_record, 0, len (content)/7)
+    scratch := make ([]byte, 0, 40)
+    for len (content) != 0 {
+        length, n := binary.Uvarint (content)
+        if n <= 0 || uint64 (len (content) - n) < length {
+            log.Print ("[read_prefix_sidecar]: ", sidecar, ": truncated, reading ", filename)
+            return nil, false
+        }
+        record, err := decode_prefix_record (content[n:n + int (length)], &scratch)
+        if err != nil {
+            log.Print ("[read_prefix_sidecar]: ", sidecar, ": ", err, ", reading ", filename)
+            return nil, false
+        }
+        records = append (records, record)
+        content = content[n + int (length):]
+    }
+    return records, true
+}
+
+/**
+ * Reads a file of prefixes or targets (format: prefix [raw_prefix], or prefix d|u/d [collectors]
+ * for the directed prefixes of rocketfuel_simulation), from its sidecar if it is up to date, from
+ * the text file otherwise.
+ */
+func read_prefix_records (filename string) ([]prefix_record, error) {
+    if records, ok := read_prefix_sidecar (filename); ok {
+        return records, nil
+    }
+    reader := NewCompressedReader (filename)
+    if err := reader.Open (); err != nil {
+        return nil, err
+    }
+    defer reader.Close ()
+    scanner := reader.Scanner ()
+
+    records := make ([]prefix_record, 0, 43)
+    for scanner.Scan () {
+        line := strings.Fields (scanner.Text ())
+        if len (line) == 0 {
+            continue
+        }
+        record := prefix_record{prefix: line[0]}
+        if len (line) > 1 {
+            if annotation, ok := annotation_names[line[1]]; ok {
+                record.annotation = annotation
+            } else {
+                record.raw = line[1]
+            }
+        }
+        records = append (records, record)
+    }
+    return records, scanner.Err ()
+}
+
+/**
+ * Writes a file of prefixes (one prefix per line) and, with -bin, its sidecar, both from the
+ * same records (in the same order).
+ */
+func write_prefix_records (filename string, records []prefix_record) error {
+    w, file := new_bufio_writer (filename)
+    for _, record := range records {
+        w.WriteString (record.prefix)
+        for name, annotation := range annotation_names {
+            if annotation == record.annotation {
+                w.WriteString (" " + name)
+            }
+        }
+        w.WriteString ("\n")
+    }
+    err := w.Flush ()
+    if e := file.Close (); err == nil {
+        err = e
+    }
+    if err != nil {
+        return fmt.Errorf ("[write_prefix_records]: %s: %w", filename, err)
+    }
+    write_prefix_sidecar_if_enabled (filename, records)
+    return nil
+}
+
+/**
+ * Reads a directed prefixes file (one prefix per line), from its sidecar if it is up to date.
+ */
+func read_directed_prefixes (filename string) ([]string, error) {
+    records, err := read_prefix_records (filename)
+    prefixes := make ([]string, 0, len (records))
+    for _, record := range records {
+        prefixes = append (prefixes, record.prefix)
+    }
+    return prefixes, err
+}
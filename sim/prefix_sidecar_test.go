@@ -0,0 +1,153 @@
+package sim
+
+import (
+    "fmt"
+    "os"
+    "path/filepath"
+    "reflect"
+    "testing"
+    "time"
+    )
+
+func TestPrefixRecordRoundTrip (t *testing.T) {
+    records := []prefix_record {
+        {prefix: "10.0.0.0/8"},
+        {prefix: "192.0.2.0/24", annotation: annotation_dependent},
+        {prefix: "198.51.100.0/22", annotation: annotation_up_down},
+        {prefix: "203.0.113.7"},
+        {prefix: "203.0.113.8", raw: "203.0.112.0/20"},
+        {prefix: "0.0.0.0/0"},
+        {prefix: "255.255.255.255/32"},
+    }
+    scratch := make ([]byte, 0, 40)
+    for _, record := range records {
+        buf, err := encode_prefix_record (nil, record)
+        if err != nil {
+            t.Fatalf ("%+v: %v", record, err)
+        }
+        decoded, err := decode_prefix_record (buf, &scratch)
+        if err != nil {
+            t.Fatalf ("%+v: %v", record, err)
+        }
+        if decoded != record {
+            t.Errorf ("got %+v, want %+v", decoded, record)
+        }
+    }
+}
+
+func TestPrefixRecordErrors (t *testing.T) {
+    for _, record := range []prefix_record {
+        {prefix: "2001:db8::/32"},
+        {prefix: "10.0.0.0/8", raw: "10.0.0.0/8"},
+        {prefix: "10.0.0.1", raw: "not a prefix"},
+        {prefix: "10.0.0.0/8", annotation: 7},
+    } {
+        if _, err := encode_prefix_record (nil, record); err == nil {
+            t.Errorf ("%+v: no error", record)
+        }
+    }
+    scratch := make ([]byte, 0, 40)
+    for _, buf := range [][]byte {
+        {10, 0, 0, 0, 8},           // Truncated
+        {10, 0, 0, 0, 33, 0},       // Invalid mask
+        {10, 0, 0, 0, 8, 9},        // Unknown annotation
+        {10, 0, 0, 0, 8, 0, 10, 0}, // Invalid length
+    } {
+        if _, err := decode_prefix_record (buf, &scratch); err == nil {
+            t.Errorf ("%v: no error", buf)
+        }
+    }
+}
+
+/**
+ * The sidecar and the text file give the same records, in the same order.
+ */
+func TestPrefixSidecarRoundTrip (t *testing.T) {
+    g_args.write_sidecars = true
+    defer func () { g_args.write_sidecars = false }()
+    file := filepath.Join (t.TempDir (), "directed_prefixes_100.txt")
+    records := []prefix_record {
+        {prefix: "192.0.2.0/24", annotation: annotation_dependent},
+        {prefix: "10.0.0.0/8"},
+        {prefix: "198.51.100.0/22", annotation: annotation_up_down},
+    }
+    if err := write_prefix_records (file, records); err != nil {
+        t.Fatal (err)
+    }
+    from_sidecar, ok := read_prefix_sidecar (file)
+    if !ok || !reflect.DeepEqual (from_sidecar, records) {
+        t.Fatalf ("sidecar: %+v (%v), want %+v", from_sidecar, ok, records)
+    }
+    os.Remove (sidecar_path (file))
+    from_text, err := read_prefix_records (file)
+    if err != nil || !reflect.DeepEqual (from_text, records) {
+        t.Fatalf ("text: %+v (%v), want %+v", from_text, err, records)
+    }
+}
+
+func TestPrefixSidecarFallback (t *testing.T) {
+    g_args.write_sidecars = true
+    defer func () { g_args.write_sidecars = false }()
+    dir := t.TempDir ()
+    file := filepath.Join (dir, "targets.txt")
+    if err := write_prefix_records (file, []prefix_record{{prefix: "10.0.0.1"}}); err != nil {
+        t.Fatal (err)
+    }
+
+    /* --- Stale sidecar: the text file is read --- */
+    if err := os.WriteFile (file, []byte ("10.0.0.2 10.0.0.0/24\n"), 0644); err != nil {
+        t.Fatal (err)
+    }
+    old := time.Now ().Add (-time.Hour)
+    os.Chtimes (sidecar_path (file), old, old)
+    records, err := read_prefix_records (file)
+    if err != nil || len (records) != 1 || records[0] != (prefix_record{prefix: "10.0.0.2", raw: "10.0.0.0/24"}) {
+        t.Errorf ("stale sidecar: %+v (%v)", records, err)
+    }
+
+    /* --- Unknown version or corrupted sidecar: the text file is read --- */
+    for _, content := range []string{sidecar_magic + "\x01", sidecar_magic + "\x02\x09"} {
+        if err := os.WriteFile (sidecar_path (file), []byte (content), 0644); err != nil {
+            t.Fatal (err)
+        }
+        if records, err := read_prefix_records (file); err != nil || len (records) != 1 || records[0].prefix != "10.0.0.2" {
+            t.Errorf ("%q: %+v (%v)", content, records, err)
+        }
+    }
+
+    /* --- No text file --- */
+    if _, err := read_directed_prefixes (filepath.Join (dir, "none.txt")); err == nil {
+        t.Error ("no error without the file")
+    }
+}
+
+/**
+ * Loading 1M directed prefixes from the text file and from the sidecar.
+ */
+func BenchmarkReadPrefixes (b *testing.B) {
+    records := make ([]prefix_record, 0, 1 << 20)
+    for i := 0; i < 1 << 20; i++ {
+        records = append (records, prefix_record{prefix: fmt.Sprintf ("%d.%d.%d.0/24", 1 + i >> 16, i >> 8 & 0xff, i & 0xff)})
+    }
+    dir := b.TempDir ()
+    text, binary := filepath.Join (dir, "text.txt"), filepath.Join (dir, "binary.txt")
+    g_args.write_sidecars = false
+    if err := write_prefix_records (text, records); err != nil {
+        b.Fatal (err)
+    }
+    g_args.write_sidecars = true
+    defer func () { g_args.write_sidecars = false }()
+    if err := write_prefix_records (binary, records); err != nil {
+        b.Fatal (err)
+    }
+    for _, file := range []string{text, binary} {
+        b.Run (filepath.Base (file), func (b *testing.B) {
+            for i := 0; i < b.N; i++ {
+                prefixes, err := read_directed_prefixes (file)
+                if err != nil || len (prefixes) != len (records) {
+                    b.Fatal (len (prefixes), err)
+                }
+            }
+        })
+    }
+}
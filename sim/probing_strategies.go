@@ -0,0 +1,1067 @@
+/* ==================================================================================== *\
+     probing_strategies.go
+
+     Implements the various probing strategies, from most simplest to Anaximander's
+     final strategy.
+\* ==================================================================================== */
+
+package sim 
+
+import (
+        "math/rand"
+        "strings"
+        "sort"
+        "strconv"
+        "log"
+        "net"
+        )
+
+var ( // Read-only variables (set only once in anaximander_driver.go)
+    as_to_prefixes func (string) map[string]interface{}; // The prefixes of an AS: as_24prefixes or as_prefixes depending on if we break down prefixes or not (see set_as_to_prefixes)
+)
+
+/**
+ * Sets as_to_prefixes, once the ip2as file is read.
+ */
+func set_as_to_prefixes (break_len int) {
+    if break_len > 0 {
+        as_to_prefixes = as_24prefixes.of
+    } else {
+        as_to_prefixes = func (as string) map[string]interface{} { return as_prefixes[as] }
+    }
+}
+
+/**
+ * Returns the prefixes of each of the ASes (given by as_to_prefixes or as_24prefixes.of), leaving
+ * out the ASes without prefixes.
+ */
+func ases_prefixes (ases []string, prefixes_of func (string) map[string]interface{}) map[string]map[string]interface{} {
+    AS_probes := make (map[string]map[string]interface{}, len (ases))
+    for _, as := range ases {
+        if prefixes := prefixes_of (as); len (prefixes) != 0 {
+            AS_probes[as] = prefixes
+        }
+    }
+    return AS_probes
+}
+
+var ( // Read-only variables (set only once in anaximander_driver.go)
+    vps []string; // The source IP addresses of the VPs.
+    strategy_traces *SafeSet; // The traces of the warts data set, when the strategy is recorded for a given warts data set (nil otherwise).
+)
+
+/**
+ * Raw prefix from which each target (/24) was picked (only for the targets picked in a larger prefix),
+ * for one run of a strategy on an AS of interest: the same /24 may be picked in different raw prefixes
+ * by different runs. Not safe for concurrent use (a run is sequential).
+ */
+type target_picks map[string]string
+
+/**
+ * Picks a random /24 prefix in the probe (see _get_24_prefix), and records the raw prefix it was picked in.
+ */
+func (picks target_picks) pick (r *rand.Rand, probe string) string {
+    prefix_24 := _get_24_prefix (r, probe)
+    if prefix_24 != probe {
+        picks[prefix_24] = probe
+    }
+    return prefix_24
+}
+
+/**
+ * Returns the raw prefix in which the target was picked: recorded by pick, or the target itself
+ * when a strategy keeps a raw prefix larger than a /24 (/48 for IPv6) as a target.
+ */
+func (picks target_picks) raw_prefix (target string) (string, bool) {
+    if raw, ok := picks[target]; ok {
+        return raw, true
+    }
+    _, network, err := net.ParseCIDR (target)
+    if err != nil {
+        return "", false
+    }
+    length := target_length ()
+    if network.IP.To4 () == nil {
+        length = 48
+    }
+    if ones, _ := network.Mask.Size (); ones < length {
+        return target, true
+    }
+    return "", false
+}
+
+/* ------------------------------------------------------------------------------- *\
+                             Probing strategies
+\* ------------------------------------------------------------------------------- */
+
+/**
+ * 0. Sort the targets in random order
+ */
+func random (s []string, as_interest string, target_to_vp VP_mapper, run *strategy_run) ([]string, []*AS_limit, []*strategy_group) {
+    if len (s) == 0 {
+        log.Fatal ("Cannot apply strategy without warts data set")
+    }
+
+    run.rng.Shuffle(len(s), func(i, j int) {
+        s[i], s[j] = s[j], s[i]
+    })
+    return s, []*AS_limit{&AS_limit{asn:"0", limit:len (s)}}, nil
+}
+
+// -------------------------------------------------------------------------------
+/**
+ * 1. Sort the targets in increasing order
+ */
+func increasing_order (s []string, as_interest string, target_to_vp VP_mapper, run *strategy_run) ([]string, []*AS_limit, []*strategy_group) {
+    if len (s) == 0 {
+        log.Fatal ("Cannot apply strategy without warts data set")
+    }
+
+    sort.Strings(s)
+    return s, []*AS_limit{&AS_limit{asn:"0", limit:len (s)}}, nil
+}
+
+// -------------------------------------------------------------------------------
+/**
+ * 2. Limit the targets to the /24 prefixes of direct neighbors (no ordering)
+ */
+func direct_neighbors (_ []string, as_interest string, target_to_vp VP_mapper, run *strategy_run) ([]string, []*AS_limit, []*strategy_group) {
+
+    neighbors := run.ds.as_rel.neighbors[as_interest]
+    s := make ([]string, 0, 10)
+    limits := make ([]*AS_limit, 0, len (neighbors))
+    neighbors_list := sorted_keys (&neighbors)
+    s, limits = add_AS_probes (s, neighbors_list, limits, ases_prefixes (neighbors_list, as_24prefixes.of), run.pick)
+
+    return s, limits, nil
+}
+
+// -------------------------------------------------------------------------------
+/**
+ * 3. Limit the targets to the /24 prefixes of the direct neighbors and
+ * the internal prefixes of the AS (no ordering inside respective groups)
+ */
+func direct_neighbors_and_internal (_ []string, as_interest string, target_to_vp VP_mapper, run *strategy_run) ([]string, []*AS_limit, []*strategy_group) {
+    neighbors := _direct_neighbors (run.ds, as_interest)
+    internals := _internals (as_interest)
+    s := make ([]string, len (neighbors)+ len (internals))
+    copy(s, neighbors)
+    copy(s[len(neighbors):], internals)
+
+    return s, []*AS_limit{&AS_limit{asn:"0", limit:len (neighbors)}, &AS_limit{asn:"1", limit:len (s)}}, nil
+}
+
+// -------------------------------------------------------------------------------
+/**
+ * 4. Limit the targets to the /24 prefixes of the direct neighbors and
+ * the internal prefixes of the AS. Order: first internals, then neighbors.
+ * (no ordering inside respective groups)
+ */
+func internal_and_direct_neighbors (_ []string, as_interest string, target_to_vp VP_mapper, run *strategy_run) ([]string, []*AS_limit, []*strategy_group) {
+    neighbors := _direct_neighbors (run.ds, as_interest)
+    internals := _internals (as_interest)
+    s := make ([]string, len (neighbors)+ len (internals))
+    copy(s, internals)
+    copy(s[len(internals):], neighbors)
+
+    return s, []*AS_limit{&AS_limit{asn:"0", limit:len (internals)}, &AS_limit{asn:"1", limit:len (s)}}, nil
+}
+
+// -------------------------------------------------------------------------------
+/**
+ * 5. Limit the targets to the prefixes of direct neighbors.
+ * The prefixes are raw or broken down into /24 prefixes according to the break_prefix arg.
+ * Sort the neighbors according to their customer cone (decreasing order)
+ */
+func customer_cone_neighbors_decreasing (_ []string, as_interest string, target_to_vp VP_mapper, run *strategy_run) ([]string, []*AS_limit, []*strategy_group) {
+    return _customer_cone_neighbors (nil, as_interest, true, run)
+}
+
+// -------------------------------------------------------------------------------
+/**
+ * 6. Limit the targets to the prefixes of direct neighbors.
+ * The prefixes are raw or broken down into /24 prefixes according to the break_prefix arg.
+ * Sort the neighbors according to their customer cone (increasing order)
+ */
+func customer_cone_neighbors_increasing (_ []string, as_interest string, target_to_vp VP_mapper, run *strategy_run) ([]string, []*AS_limit, []*strategy_group) {
+    return _customer_cone_neighbors (nil, as_interest, false, run)
+}
+
+// -------------------------------------------------------------------------------
+func _customer_cone_neighbors (_ []string, as_interest string, reverse bool, run *strategy_run) ([]string, []*AS_limit, []*strategy_group) {
+
+    ordered_neighbors := _get_neighbors_ordered_customer_cone (run.ds, as_interest, reverse)
+
+    s := make ([]string, 0, len (ordered_neighbors))
+    limits := make ([]*AS_limit, 0, len (ordered_neighbors))
+    AS_probes := ases_prefixes (ordered_neighbors, as_to_prefixes)
+    groups := []*strategy_group{new_strategy_group (group_neighbor, order_customer_cone, ordered_neighbors, AS_probes)}
+    s, limits = add_AS_probes (s, ordered_neighbors, limits, AS_probes, run.pick)
+
+    return s, limits, groups
+}
+
+// -------------------------------------------------------------------------------
+/**
+ * 7. Rocketfuel directed probing
+ */
+func directed_probing (_ []string, as_interest string, target_to_vp VP_mapper, run *strategy_run) ([]string, []*AS_limit, []*strategy_group) {
+    
+    prefixes := get_directed_probes (as_interest, run)
+    return prefixes, []*AS_limit{&AS_limit{asn:"0", limit:len (prefixes)}}, nil
+}
+
+// -------------------------------------------------------------------------------
+/**
+ * 8. Directed probing in three groups:
+ *     - Internal prefixes
+ *     - Direct neighbors (no order)
+ *     - Others (grouped by AS, but no order between ASes).
+ */
+func directed_probing_internal_neighbors_others (_ []string, as_interest string, target_to_vp VP_mapper, run *strategy_run) ([]string, []*AS_limit, []*strategy_group) {
+    return _directed_probing_internal_neighbors_others (nil, as_interest, false, run)
+}
+
+// -------------------------------------------------------------------------------
+/**
+ * 9. Directed probing in three groups:
+ *     - Internal prefixes
+ *     - Direct neighbors (ordered by increasing customer cone)
+ *     - Others (ordered by increasing customer cone).
+ */
+func directed_probing_internal_neighbors_others_customercone (_ []string, as_interest string, target_to_vp VP_mapper, run *strategy_run) ([]string, []*AS_limit, []*strategy_group) {
+    return _directed_probing_internal_neighbors_others (nil, as_interest, true, run)
+}
+
+// -------------------------------------------------------------------------------
+func _directed_probing_internal_neighbors_others (_ []string, as_interest string, ordered bool, run *strategy_run) ([]string, []*AS_limit, []*strategy_group) {
+    AS_probes, neighbors_map, one_hop_neighbors_map, other_AS_map, nb_probes := get_directed_probes_and_groups (as_interest, run)
+
+    s := make ([]string, 0, nb_probes)
+    limits := make ([]*AS_limit, 0, len (neighbors_map) + len (one_hop_neighbors_map) + len (other_AS_map) + 1)
+
+    /* --- Group 1: internal prefixes --- */
+    internals := AS_probes[as_interest]
+    s = append (s, sorted_keys (&internals)...)
+    limits = append (limits, &AS_limit{asn: as_interest, limit: len (s)})
+    group_1 := len (s)
+
+    /* --- Group 2: the neighbors --- */
+    var neighbors []string
+    order := order_none
+    if ordered {
+        neighbors = order_by_customer_cone (run.ds, neighbors_map, as_interest, false)
+        order = order_customer_cone
+    }
+    s, limits = add_AS_probes (s, neighbors, limits, AS_probes, run.pick)
+    group_2 := len (s)
+
+    /* --- Group 3: the one hop neighbors and the others --- */
+    //mixed := append (one_hop_neighbors_map, other_AS_map) // Mix both groups
+    tmp := merge_maps (one_hop_neighbors_map, other_AS_map)
+    mixed := sorted_keys (&tmp)
+    if ordered {
+        mixed = order_by_customer_cone (run.ds, tmp, as_interest, false) 
+    }
+    s, limits = add_AS_probes (s, mixed, limits, AS_probes, run.pick)
+    group_3 := len (s)
+    groups := []*strategy_group {
+        {name: group_internal, order: order_none, ases: []string{as_interest}, size: group_1},
+        new_strategy_group (group_neighbor, order, neighbors, AS_probes),
+        new_strategy_group (group_one_hop + "+" + group_other, order, mixed, AS_probes),
+    }
+    output_msg ("main_groups_limits.txt", as_interest, group_1, group_2, group_3)
+    //Note: those delimitation are only valid if there is NO reduction!!!
+
+    return s, limits, groups
+}
+
+// -------------------------------------------------------------------------------
+/**
+ * 10. Directed probing in two groups:
+ *     - Internal prefixes
+ *     - Direct neighbors, one hope neighbors and others 
+ *              (ordered by increasing customer cone - no distinction between three groups)
+ */
+func directed_probing_internal_neighbors_others_mixed (_ []string, as_interest string, target_to_vp VP_mapper, run *strategy_run) ([]string, []*AS_limit, []*strategy_group) {
+
+    AS_probes, neighbors_map, one_hop_neighbors_map, other_AS_map, nb_probes := get_directed_probes_and_groups (as_interest, run)
+    
+    s := make ([]string, 0, nb_probes)
+    limits := make ([]*AS_limit, 0, len (neighbors_map) + len (one_hop_neighbors_map) + len (other_AS_map) + 1)
+
+    /* --- Group 1: internal prefixes --- */
+    internals := AS_probes[as_interest]
+    s = append (s, sorted_keys (&internals)...)
+    limits = append (limits, &AS_limit{asn: as_interest, limit: len (s)})
+    group_1 := len (s)
+
+    /* --- Group 2: the neighbors, the one hope neighbors, and the others mixed together --- */
+    mixed := merge_maps (neighbors_map, one_hop_neighbors_map)
+    mixed = merge_maps (mixed, other_AS_map) // Mix three groups together
+    mixed_slice := order_by_customer_cone (run.ds, mixed, as_interest, false)
+    s, limits = add_AS_probes (s, mixed_slice, limits, AS_probes, run.pick)
+    group_2 := len (s)
+    groups := []*strategy_group {
+        {name: group_internal, order: order_none, ases: []string{as_interest}, size: group_1},
+        new_strategy_group (group_neighbor + "+" + group_one_hop + "+" + group_other, order_customer_cone, mixed_slice, AS_probes),
+    }
+
+    output_msg ("main_groups_limits.txt", as_interest, group_1, group_2)
+    return s, limits, groups
+}
+
+// -------------------------------------------------------------------------------
+/**
+ * 11. Directed probing into 4 groups:
+ *     - Internal prefixes
+ *     - Direct neighbors
+ *       - One hope neighbors
+ *       - Others 
+ *              (all groups ordered by increasing customer cone)
+ */
+func directed_probing_internal_neighbors_onehopneighbors_others (_ []string, as_interest string, target_to_vp VP_mapper, run *strategy_run) ([]string, []*AS_limit, []*strategy_group) {
+    AS_probes, neighbors_map, one_hop_neighbors_map, other_AS_map, nb_probes := get_directed_probes_and_groups (as_interest, run)
+
+    s := make ([]string, 0, nb_probes)
+    limits := make ([]*AS_limit, 0, len (neighbors_map) + len (one_hop_neighbors_map) + len (other_AS_map) + 1)
+
+    /* --- Group 1: internal prefixes --- */
+    internals := AS_probes[as_interest]
+    s = append (s, sorted_keys (&internals)...)
+    limits = append (limits, &AS_limit{asn: as_interest, limit: len (s)})
+    group_1 := len (s)
+
+    /* --- Group 2: the neighbors --- */
+    neighbors := order_by_customer_cone (run.ds, neighbors_map, as_interest, false)
+    s, limits = add_AS_probes (s, neighbors, limits, AS_probes, run.pick)
+    group_2 := len (s)
+
+    /* --- Group 3: the one hop neighbors --- */
+    one_hop_neighbors := order_by_customer_cone (run.ds, one_hop_neighbors_map, as_interest, false)
+    s, limits = add_AS_probes (s, one_hop_neighbors, limits, AS_probes, run.pick)
+    group_3 := len (s)
+
+    /* --- Group 4: the others --- */
+    other_AS := order_by_customer_cone (run.ds, other_AS_map, as_interest, false)
+    s, limits = add_AS_probes (s, other_AS, limits, AS_probes, run.pick)
+    group_4 := len (s)
+    groups := []*strategy_group {
+        {name: group_internal, order: order_none, ases: []string{as_interest}, size: group_1},
+        new_strategy_group (group_neighbor, order_customer_cone, neighbors, AS_probes),
+        new_strategy_group (group_one_hop, order_customer_cone, one_hop_neighbors, AS_probes),
+        new_strategy_group (group_other, order_customer_cone, other_AS, AS_probes),
+    }
+
+
+    output_msg ("main_groups_limits.txt", as_interest, group_1, group_2, group_3, group_4)
+    return s, limits, groups
+}
+
+/* ============================================================================== *\
+                            No /24 probes
+\* ============================================================================== */
+
+// -------------------------------------------------------------------------------
+/**
+ * 12. Rocketfuel's directed probe without breaking them down in /24 prefixes.
+ */
+func directed_probing_no24 (_ []string, as_interest string, target_to_vp VP_mapper, run *strategy_run) ([]string, []*AS_limit, []*strategy_group) {
+    return directed_probing (nil, as_interest, nil, run)
+}
+
+// -------------------------------------------------------------------------------
+/**
+ * 13. 
+ *  - Internal prefixes (/24)
+ *  - Directed probe (no /24 prefixes) as:
+ *    - Direct neighbors
+ *    - One hope neighbors
+ *    - Others 
+ *        (all groups ordered by increasing customer cone)
+ */
+func directed_probing_internal_neighbors_onehopneighbors_others_no24 (_ []string, as_interest string, target_to_vp VP_mapper, run *strategy_run) ([]string, []*AS_limit, []*strategy_group) {
+    AS_probes, neighbors_map, one_hop_neighbors_map, other_AS_map, nb_probes := get_directed_probes_and_groups (as_interest, run)
+
+    s := make ([]string, 0, nb_probes)
+    limits := make ([]*AS_limit, 0, len (neighbors_map) + len (one_hop_neighbors_map) + len (other_AS_map) + 1)
+
+    /* --- Group 1: internal prefixes (/24) --- */
+    s = append (s, _internals (as_interest)...) // Would be better to have Rocketfuel /24, but not straightforward at this point
+    limits = append (limits, &AS_limit{asn: as_interest, limit: len (s)})
+    group_1 := len (s)
+
+    /* --- Group 2: the neighbors --- */
+    neighbors := order_by_customer_cone (run.ds, neighbors_map, as_interest, false)
+    s, limits = add_AS_probes (s, neighbors, limits, AS_probes, run.pick)
+    group_2 := len (s)
+
+    /* --- Group 3: the one hop neighbors --- */
+    one_hop_neighbors := order_by_customer_cone (run.ds, one_hop_neighbors_map, as_interest, false)
+    s, limits = add_AS_probes (s, one_hop_neighbors, limits, AS_probes, run.pick)
+    group_3 := len (s)
+
+    /* --- Group 4: the others --- */
+    other_AS := order_by_customer_cone (run.ds, other_AS_map, as_interest, false)
+    s, limits = add_AS_probes (s, other_AS, limits, AS_probes, run.pick)
+    group_4 := len (s)
+    groups := []*strategy_group {
+        {name: group_internal, order: order_none, ases: []string{as_interest}, size: group_1},
+        new_strategy_group (group_neighbor, order_customer_cone, neighbors, AS_probes),
+        new_strategy_group (group_one_hop, order_customer_cone, one_hop_neighbors, AS_probes),
+        new_strategy_group (group_other, order_customer_cone, other_AS, AS_probes),
+    }
+
+    output_msg ("main_groups_limits.txt", as_interest, group_1, group_2, group_3, group_4)
+    return s, limits, groups
+}
+
+// -------------------------------------------------------------------------------
+/**
+ * 14. 
+ *  - Internal prefixes (/24)
+ *  - Directed probe (no /24 prefixes) as:
+ *    - Direct neighbors
+ *    - Others 
+ *        (all groups ordered by increasing customer cone)
+ */
+func directed_probing_internal_neighbors_others_no24 (_ []string, as_interest string, target_to_vp VP_mapper, run *strategy_run) ([]string, []*AS_limit, []*strategy_group) {
+    AS_probes, neighbors_map, one_hop_neighbors_map, other_AS_map, nb_probes := get_directed_probes_and_groups (as_interest, run)
+
+    s := make ([]string, 0, nb_probes)
+    limits := make ([]*AS_limit, 0, len (neighbors_map) + len (one_hop_neighbors_map) + len (other_AS_map) + 1)
+
+    /* --- Group 1: internal prefixes (/24) --- */
+    s = append (s, _internals (as_interest)...) // Would be better to have Rocketfuel /24, but not straightforward at this point
+    limits = append (limits, &AS_limit{asn: as_interest, limit: len (s)})
+    group_1 := len (s)
+
+    /* --- Group 2: the neighbors --- */
+    neighbors := order_by_customer_cone (run.ds, neighbors_map, as_interest, false)
+    s, limits = add_AS_probes (s, neighbors, limits, AS_probes, run.pick)
+    group_2 := len (s)
+
+    /* --- Group 3: the one hop neighbors and the others --- */
+    mixed := merge_maps (one_hop_neighbors_map, other_AS_map) // Mix both groups
+    mixed_slice := order_by_customer_cone (run.ds, mixed, as_interest, false) 
+    s, limits = add_AS_probes (s, mixed_slice, limits, AS_probes, run.pick)
+    group_3 := len (s)
+    groups := []*strategy_group {
+        {name: group_internal, order: order_none, ases: []string{as_interest}, size: group_1},
+        new_strategy_group (group_neighbor, order_customer_cone, neighbors, AS_probes),
+        new_strategy_group (group_one_hop + "+" + group_other, order_customer_cone, mixed_slice, AS_probes),
+    }
+
+    output_msg ("main_groups_limits.txt", as_interest, group_1, group_2, group_3)
+    return s, limits, groups
+}
+
+// -------------------------------------------------------------------------------
+/**
+ * 15. Internals (/24) + direct neighbors (no /24)
+ * Sort the neighbors according to their customer cone (increasing order)
+ * 
+ * Same results as mode 13, where se stop right after the neighbors. We have exactly the same
+     level of discovery (as expected)
+ */
+func customer_cone_neighbors_increasing_no24 (s []string, as_interest string, target_to_vp VP_mapper, run *strategy_run) ([]string, []*AS_limit, []*strategy_group) {
+
+    s = make ([]string, 0, len (s))
+    limits := make ([]*AS_limit, 0, len (s))
+
+    /* --- Group 1: internal prefixes (/24) --- */
+    s = append (s, _internals (as_interest)...) // Would be better to have Rocketfuel /24, but not straightforward at this point
+    limits = append (limits, &AS_limit{asn: as_interest, limit: len (s)})
+    group_1 := len (s)
+
+    /* --- Group 2: direct neighbors --- */
+    neighbors := _get_neighbors_ordered_customer_cone (run.ds, as_interest, false)
+
+    // Build the mapping between an AS and its prefixes
+    AS_probes := make (map[string]map[string]interface{})
+    for _,as := range neighbors {
+        for prefix,_ := range as_to_prefixes (as) {
+            append_prefix (&AS_probes, as, prefix)
+        }
+    }
+
+    s, limits = add_AS_probes (s, neighbors, limits, AS_probes, run.pick) 
+    group_2 := len (s)
+    groups := []*strategy_group {
+        {name: group_internal, order: order_none, ases: []string{as_interest}, size: group_1},
+        new_strategy_group (group_neighbor, order_customer_cone, neighbors, AS_probes),
+    }
+
+    output_msg ("main_groups_limits.txt", as_interest, group_1, group_2)
+    
+    return s, limits, groups
+}
+
+// -------------------------------------------------------------------------------
+/**
+ * 16. Same as mode 13, except that we simulate on the BEST directed probes.
+ */ 
+func best_directed_probing_internal_neighbors_onehopneighbors_others_no24 (_ []string, as_interest string, target_to_vp VP_mapper, run *strategy_run) ([]string, []*AS_limit, []*strategy_group) {
+    return directed_probing_internal_neighbors_onehopneighbors_others_no24 (nil, as_interest, target_to_vp, run)
+}
+
+// -------------------------------------------------------------------------------
+/**
+ * 17. Rocketfuel's BEST directed probes (not broken down into /24)
+ *     Reduction on overlays.
+ *       Same as 16, but reduction on overlays.
+ */
+func overlays_reduction_global (_ []string, as_interest string, target_to_vp VP_mapper, run *strategy_run) ([]string, []*AS_limit, []*strategy_group) {
+
+    /* --- Read the global overlay file --- */
+    // key: the VP
+    // value: key: a target
+    //        value: all its overlays.
+    overlays := make (map[string]map[string]map[string]interface{})
+    global_overlays := read_overlay_file (run.ds.overlays_file)
+    for _, vp := range vps {
+        overlays[vp] = global_overlays // All VPs points towards the same overlays (as we have a global overlay file)
+    }
+
+    return _overlays_reduction (nil, as_interest, target_to_vp, overlays, false, false, run)
+}
+
+// -------------------------------------------------------------------------------
+/**
+ * 20. Rocketfuel's BEST directed probes (not broken down into /24)
+ *     Reduction on overlays.
+ *       Same as 17, but direct neighbors are grouped by their relationships and then ordered by customer cone.
+ */
+func overlays_reduction_global_relationships (_ []string, as_interest string, target_to_vp VP_mapper, run *strategy_run) ([]string, []*AS_limit, []*strategy_group) {
+
+    /* --- Read the global overlay file --- */
+    // key: the VP
+    // value: key: a target
+    //        value: all its overlays.
+    overlays := make (map[string]map[string]map[string]interface{})
+    global_overlays := read_overlay_file (run.ds.overlays_file)
+    for _, vp := range vps {
+        overlays[vp] = global_overlays // All VPs points towards the same overlays (as we have a global overlay file)
+    }
+
+    return _overlays_reduction (nil, as_interest, target_to_vp, overlays, true, false, run)
+}
+
+// -------------------------------------------------------------------------------
+/**
+ * 21. Rocketfuel's BEST directed probes (not broken down into /24)
+ *     Reduction on overlays.
+ *       Same as 17, but reverse order of customer cone
+ */
+func overlays_reduction_global_relationships_decreasing_cc (_ []string, as_interest string, target_to_vp VP_mapper, run *strategy_run) ([]string, []*AS_limit, []*strategy_group) {
+
+    /* --- Read the global overlay file --- */
+    // key: the VP
+    // value: key: a target
+    //        value: all its overlays.
+    overlays := make (map[string]map[string]map[string]interface{})
+    global_overlays := read_overlay_file (run.ds.overlays_file)
+    for _, vp := range vps {
+        overlays[vp] = global_overlays // All VPs points towards the same overlays (as we have a global overlay file)
+    }
+
+    return _overlays_reduction (nil, as_interest, target_to_vp, overlays, true, true, run)
+}
+
+func _overlays_reduction (_ []string, as_interest string, target_to_vp VP_mapper, overlays map[string]map[string]map[string]interface{}, relationships bool, reverse bool, run *strategy_run) ([]string, []*AS_limit, []*strategy_group) {
+
+    /* --- Get Rocketfuel directod probes --- */
+    AS_probes, neighbors_map, one_hop_neighbors_map, other_AS_map, nb_probes := get_directed_probes_and_groups (as_interest, run)
+
+    s := make ([]string, 0, nb_probes)
+    limits := make ([]*AS_limit, 0, len (neighbors_map) + len (one_hop_neighbors_map) + len (other_AS_map) + 1)
+
+    /* --- Group 1: internal prefixes (/24) --- */
+    s = append (s, _internals (as_interest)...) // Would be better to have Rocketfuel /24, but not straightforward at this point
+    limits = append (limits, &AS_limit{asn: as_interest, limit: len (s)})
+    group_1 := len (s)
+
+    groups := []*strategy_group{{name: group_internal, order: order_none, ases: []string{as_interest}, size: group_1}}
+
+    /* --- Group 2: the neighbors --- */
+    var neighbors []string
+    if relationships {
+        neighbors = group_by_relationships (run.ds, AS_probes, as_interest, reverse)
+        groups = append (groups, new_strategy_group (group_neighbor, order_relationships, neighbors, AS_probes))
+    } else {
+        neighbors = order_by_customer_cone (run.ds, neighbors_map, as_interest, reverse)
+        groups = append (groups, new_strategy_group (group_neighbor, order_customer_cone, neighbors, AS_probes))
+    }
+    remove_overlays (AS_probes, neighbors, target_to_vp, overlays, as_interest, run.rng)
+    s, limits = add_AS_probes (s, neighbors, limits, AS_probes, run.pick)
+    group_2 := len (s)
+
+    /* --- Group 3: the one hop neighbors --- */
+    one_hop_neighbors := order_by_customer_cone (run.ds, one_hop_neighbors_map, as_interest, reverse)
+    groups = append (groups, new_strategy_group (group_one_hop, order_customer_cone, one_hop_neighbors, AS_probes))
+    remove_overlays (AS_probes, one_hop_neighbors, target_to_vp, overlays, as_interest, run.rng)
+    s, limits = add_AS_probes (s, one_hop_neighbors, limits, AS_probes, run.pick)
+    group_3 := len (s)
+
+    /* --- Group 4: the others --- */
+    other_AS := order_by_customer_cone (run.ds, other_AS_map, as_interest, reverse)
+    groups = append (groups, new_strategy_group (group_other, order_customer_cone, other_AS, AS_probes))
+    remove_overlays (AS_probes, other_AS, target_to_vp, overlays, as_interest, run.rng)
+    s, limits = add_AS_probes (s, other_AS, limits, AS_probes, run.pick)
+    group_4 := len (s)
+
+    output_msg ("main_groups_limits.txt", as_interest, group_1, group_2, group_3, group_4)
+
+    /* --- Group 3: the one hop neighbors and the others --- */
+    //mixed := append (one_hop_neighbors, other_AS...) // Mix both groups
+    //mixed = order_by_customer_cone (run.ds, slice_to_map (mixed), as_interest, false)
+    //remove_overlays (AS_probes, mixed, target_to_vp, overlays)
+    //s, limits = add_AS_probes (s, mixed, limits, AS_probes, run.pick)
+    //group_3 := len (s)
+
+    //output_msg ("main_groups_limits.txt", as_interest, group_1, group_2, group_3)
+    
+    return s, limits, groups
+}
+
+// -------------------------------------------------------------------------------
+/**
+ * 25. Rocketfuel's BEST directed probes (not broken down into /24)
+ *     Reduction on overlays, then reduction on next-hop ASes.
+ *       Same as 20, but the targets kept by the overlay reduction are further reduced to one
+ *       target per next-hop AS (global nextAS file, as in 18), the first in probing order.
+ */
+func overlays_nexthop_reduction_global (_ []string, as_interest string, target_to_vp VP_mapper, run *strategy_run) ([]string, []*AS_limit, []*strategy_group) {
+
+    /* --- Read the global overlay and nextAS files --- */
+    overlays := make (map[string]map[string]map[string]interface{})
+    global_overlays := read_overlay_file (run.ds.overlays_file)
+    for _, vp := range vps {
+        overlays[vp] = global_overlays // All VPs points towards the same overlays (as we have a global overlay file)
+    }
+    prefix_to_nextAS, _ := read_nextAS_file (run.ds.nexthop_dir + "/merged_next_AS_"+as_interest+".txt")
+
+    /* --- Get Rocketfuel directod probes --- */
+    AS_probes, neighbors_map, one_hop_neighbors_map, other_AS_map, nb_probes := get_directed_probes_and_groups (as_interest, run)
+
+    s := make ([]string, 0, nb_probes)
+    limits := make ([]*AS_limit, 0, len (neighbors_map) + len (one_hop_neighbors_map) + len (other_AS_map) + 1)
+    reduced, removed_overlays, removed_nextAS := 0, 0, 0 // Directed probes of the reduced groups, and the probes removed by each reduction
+    nextAS := new_nextAS_reduction (prefix_to_nextAS, as_interest, run.picks)
+    reduce := func (ases []string) {
+        before := count_AS_probes (AS_probes, ases)
+        reduced += before
+        remove_overlays (AS_probes, ases, target_to_vp, overlays, as_interest, run.rng)
+        after_overlays := count_AS_probes (AS_probes, ases)
+        nextAS.reduce (AS_probes, ases, target_to_vp)
+        removed_overlays += before - after_overlays
+        removed_nextAS += after_overlays - count_AS_probes (AS_probes, ases)
+    }
+
+    /* --- Group 1: internal prefixes (/24) --- */
+    s = append (s, _internals (as_interest)...)
+    limits = append (limits, &AS_limit{asn: as_interest, limit: len (s)})
+    group_1 := len (s)
+
+    groups := []*strategy_group{{name: group_internal, order: order_none, ases: []string{as_interest}, size: group_1}}
+
+    /* --- Group 2: the neighbors --- */
+    neighbors := group_by_relationships (run.ds, AS_probes, as_interest, false)
+    groups = append (groups, new_strategy_group (group_neighbor, order_relationships, neighbors, AS_probes))
+    reduce (neighbors)
+    s, limits = add_AS_probes (s, neighbors, limits, AS_probes, run.pick)
+    group_2 := len (s)
+
+    /* --- Group 3: the one hop neighbors --- */
+    one_hop_neighbors := order_by_customer_cone (run.ds, one_hop_neighbors_map, as_interest, false)
+    groups = append (groups, new_strategy_group (group_one_hop, order_customer_cone, one_hop_neighbors, AS_probes))
+    reduce (one_hop_neighbors)
+    s, limits = add_AS_probes (s, one_hop_neighbors, limits, AS_probes, run.pick)
+    group_3 := len (s)
+
+    /* --- Group 4: the others --- */
+    other_AS := order_by_customer_cone (run.ds, other_AS_map, as_interest, false)
+    groups = append (groups, new_strategy_group (group_other, order_customer_cone, other_AS, AS_probes))
+    reduce (other_AS)
+    s, limits = add_AS_probes (s, other_AS, limits, AS_probes, run.pick)
+    group_4 := len (s)
+
+    output_msg ("main_groups_limits.txt", as_interest, group_1, group_2, group_3, group_4)
+    output_msg ("overlays_nextAS_reduction.txt", as_interest, reduced - removed_overlays - removed_nextAS, reduced, removed_overlays, removed_nextAS) // Groups 2 to 4: targets kept, directed probes, and probes removed by each reduction
+    return s, limits, groups
+}
+
+func count_AS_probes (AS_probes map[string]map[string]interface{}, ases []string) int {
+    n := 0
+    for _, AS := range ases {
+        n += len (AS_probes[AS])
+    }
+    return n
+}
+
+/**
+ * Next-hop AS reduction across the ASes of a strategy, once their probes have been broken down
+ * into /24 (e.g., by remove_overlays): in probing order, a /24 is removed if, for every VP that
+ * probed it, a /24 kept before (in any AS) has the same next-hop AS (that of the raw prefix it
+ * was picked in). The AS of interest is never reduced as a next-hop AS (see 18).
+ */
+type nextAS_reduction struct {
+    prefix_to_nextAS map[string]string;
+    as_interest string;
+    seen map[string]map[string]interface{}; // VP -> next-hop ASes of the probes kept so far
+    picks target_picks;                     // Raw prefix in which each /24 was picked
+}
+
+func new_nextAS_reduction (prefix_to_nextAS map[string]string, as_interest string, picks target_picks) *nextAS_reduction {
+    return &nextAS_reduction{prefix_to_nextAS: prefix_to_nextAS, as_interest: as_interest, seen: make (map[string]map[string]interface{}), picks: picks}
+}
+
+/**
+ * Applies the reduction to the probes of the ASes (in this order), after those already reduced.
+ */
+func (r *nextAS_reduction) reduce (AS_probes map[string]map[string]interface{}, ases []string, target_to_vp VP_mapper) {
+    for _, AS := range ases {
+        probes := AS_probes[AS]
+        s := make (map[string]interface{})
+        for _, probe_24 := range sorted_keys (&probes) {
+            raw := probe_24
+            if picked, ok := r.picks[probe_24]; ok {
+                raw = picked
+            }
+            nextAS, ok := r.prefix_to_nextAS[raw]
+            probe_vps, _ := target_to_vp.Get (probe_24)
+            if !ok || nextAS == r.as_interest || len (probe_vps) == 0 { // Not reduced (see remove_overlays)
+                s[probe_24] = struct{}{}
+                continue
+            }
+
+            covered := true
+            for _, VP := range probe_vps {
+                if _, present := r.seen[VP][nextAS]; !present {
+                    covered = false
+                    break
+                }
+            }
+            if covered {
+                continue
+            }
+            s[probe_24] = struct{}{}
+            for _, VP := range probe_vps {
+                append_prefix (&r.seen, VP, nextAS)
+            }
+        }
+        AS_probes[AS] = s
+    }
+}
+
+// -------------------------------------------------------------------------------
+/**
+ * 26. Rocketfuel's BEST directed probes (not broken down into /24)
+ *     Reduction on overlays, each VP seeing only the overlays of its own collector.
+ *       Same as 20, but with per-VP overlays instead of the global overlay file.
+ */
+func overlays_reduction_per_vp (_ []string, as_interest string, target_to_vp VP_mapper, run *strategy_run) ([]string, []*AS_limit, []*strategy_group) {
+
+    /* --- Read the overlays of the collector of each VP --- */
+    vp_collectors, err := read_vp_collectors (g_args.vp_collectors_file)
+    if err != nil {
+        log.Fatal ("[overlays_reduction_per_vp]: ", err)
+    }
+    overlays, err := read_per_vp_overlays (vps, vp_collectors, g_args.overlays_dir)
+    if err != nil {
+        log.Fatal ("[overlays_reduction_per_vp]: ", err)
+    }
+
+    return _overlays_reduction (nil, as_interest, target_to_vp, overlays, true, false, run)
+}
+
+/* ============================================================================== *\
+                            Next-hop AS Reduction
+\* ============================================================================== */
+
+/**
+ * 18. Rocketfuel's Next Hop AS reduction (on global file)
+ */
+func next_hop_as_reduction_global (_ []string, as_interest string, target_to_vp VP_mapper, run *strategy_run) ([]string, []*AS_limit, []*strategy_group) {
+
+    /* --- Read global nextAS file --- */
+    prefix_to_nextAS, nextAS_to_prefixes := read_nextAS_file (run.ds.nexthop_dir + "/merged_next_AS_"+as_interest+".txt")
+    prefix_to_prefixes := make (map[string]map[string]interface{})
+    for prefix, nextAS := range prefix_to_nextAS {
+        if nextAS == as_interest { // The AS of interest is actually the next-hop -> Don't apply nextAS reduction on the AS of interest itself.
+            continue
+        }
+        prefix_to_prefixes[prefix] = nextAS_to_prefixes[nextAS]
+    }
+
+    vp_prefix_to_prefixes := make (map[string]map[string]map[string]interface{})
+    for _, vp := range vps {
+        vp_prefix_to_prefixes[vp] = prefix_to_prefixes // All VPs points towards the same nextASes (as we have a global file)
+    }
+
+    /* --- Get Rocketfuel directed prefixes --- */
+    directed_probes := get_directed_probes (as_interest, run)
+    AS_probes := make (map[string]map[string]interface{})
+    AS_probes["."] = slice_to_map (directed_probes)
+
+    remove_overlays (AS_probes, []string{"."}, target_to_vp, vp_prefix_to_prefixes, as_interest, run.rng)
+
+    reduced := AS_probes["."]
+    s := sorted_keys (&reduced)
+
+    output_msg ("nextAS_reduction.txt", as_interest, len (s), len (directed_probes))
+    
+    return s, []*AS_limit{&AS_limit{asn: "0", limit: len (s)}}, nil
+}
+
+// -------------------------------------------------------------------------------
+/**
+ * 28. Rocketfuel's Egress reduction (on global file)
+ *     Same as 18, but a target is only reduced if a target with the same next-hop AS was kept for the same
+ *     ingress into the AS of interest, i.e., one target per (ingress, next-hop AS) pair. The ingress of a target
+ *     is the one of the VPs that probed it (see vp_ingresses).
+ */
+func egress_reduction_global (_ []string, as_interest string, target_to_vp VP_mapper, run *strategy_run) ([]string, []*AS_limit, []*strategy_group) {
+
+    /* --- Read global nextAS file --- */
+    prefix_to_nextAS, nextAS_to_prefixes := read_nextAS_file (run.ds.nexthop_dir + "/merged_next_AS_"+as_interest+".txt")
+    prefix_to_prefixes := make (map[string]map[string]interface{})
+    for prefix, nextAS := range prefix_to_nextAS {
+        if nextAS == as_interest { // The AS of interest is actually the next-hop -> Don't apply nextAS reduction on the AS of interest itself.
+            continue
+        }
+        prefix_to_prefixes[prefix] = nextAS_to_prefixes[nextAS]
+    }
+
+    /* --- Resolve the ingress of each VP --- */
+    mapper := &ingress_mapper{target_to_vp: target_to_vp, ingresses: vp_ingresses (as_interest)}
+    ingress_prefix_to_prefixes := make (map[string]map[string]map[string]interface{})
+    for _, vp := range vps {
+        ingress_prefix_to_prefixes[mapper.ingress (vp)] = prefix_to_prefixes // All ingresses points towards the same nextASes (as we have a global file)
+    }
+
+    /* --- Get Rocketfuel directed prefixes --- */
+    directed_probes := get_directed_probes (as_interest, run)
+    AS_probes := make (map[string]map[string]interface{})
+    AS_probes["."] = slice_to_map (directed_probes)
+
+    remove_overlays (AS_probes, []string{"."}, mapper, ingress_prefix_to_prefixes, as_interest, run.rng)
+
+    reduced := AS_probes["."]
+    s := sorted_keys (&reduced)
+
+    output_msg ("egress_reduction.txt", as_interest, len (s), len (directed_probes), len (ingress_prefix_to_prefixes))
+
+    return s, []*AS_limit{&AS_limit{asn: "0", limit: len (s)}}, nil
+}
+
+// -------------------------------------------------------------------------------
+/**
+ * 19. Look at the traces that yielded discovery (from run on mode 0).
+ */
+func oracle (_ []string, as_interest string, target_to_vp VP_mapper, run *strategy_run) ([]string, []*AS_limit, []*strategy_group) {
+
+    oracle_prefixes_file := g_args.oracle_prefixes_dir + "/successful_traces_" + as_interest + ".txt"
+
+    /* --- Read file --- */
+    reader := NewCompressedReader (oracle_prefixes_file)
+    if err := reader.Open (); err != nil {
+        log.Fatal ("[oracle]: ", err)
+    }
+    defer reader.Close ()
+    scanner := reader.Scanner ()
+
+    /* --- Sort prefixes according to the level of discovery --- */
+    prefixes := make (AS_weights, 0, 100)
+    for scanner.Scan () {
+        line := strings.Fields (scanner.Text ())
+        w,_ := strconv.Atoi (line[1])
+        prefixes = append (prefixes, &AS_weight{name: line[0], weight: w})
+    }
+    sort.Stable (sort.Reverse (ByWeight{prefixes})) // Ties keep the order of the file
+
+    // Build the slice of prefixes
+    s := make ([]string, 0, len (prefixes))
+    for _,as_weight := range prefixes {
+        s = append (s, as_weight.name)
+    }
+
+    return s, []*AS_limit{&AS_limit{asn: "0", limit: len (s)}}, nil
+}
+
+// -------------------------------------------------------------------------------
+/**
+ * 22. Strategy 11, all groups ordered by decreasing number of directed prefixes
+ *     (the ASes costing the most probes first).
+ */
+func directed_probing_probe_count_decreasing (_ []string, as_interest string, target_to_vp VP_mapper, run *strategy_run) ([]string, []*AS_limit, []*strategy_group) {
+    return _directed_probing_probe_count (as_interest, true, run)
+}
+
+// -------------------------------------------------------------------------------
+/**
+ * 23. Strategy 11, all groups ordered by increasing number of directed prefixes
+ *     (the ASes costing the fewest probes first).
+ */
+func directed_probing_probe_count_increasing (_ []string, as_interest string, target_to_vp VP_mapper, run *strategy_run) ([]string, []*AS_limit, []*strategy_group) {
+    return _directed_probing_probe_count (as_interest, false, run)
+}
+
+func _directed_probing_probe_count (as_interest string, reverse bool, run *strategy_run) ([]string, []*AS_limit, []*strategy_group) {
+    AS_probes, neighbors_map, one_hop_neighbors_map, other_AS_map, nb_probes := get_directed_probes_and_groups (as_interest, run)
+
+    s := make ([]string, 0, nb_probes)
+    limits := make ([]*AS_limit, 0, len (neighbors_map) + len (one_hop_neighbors_map) + len (other_AS_map) + 1)
+
+    /* --- Group 1: internal prefixes --- */
+    internals := AS_probes[as_interest]
+    s = append (s, sorted_keys (&internals)...)
+    limits = append (limits, &AS_limit{asn: as_interest, limit: len (s)})
+    group_1 := len (s)
+
+    /* --- Group 2: the neighbors --- */
+    neighbors := order_by_probe_count (neighbors_map, AS_probes, reverse)
+    s, limits = add_AS_probes (s, neighbors, limits, AS_probes, run.pick)
+    group_2 := len (s)
+
+    /* --- Group 3: the one hop neighbors --- */
+    one_hop_neighbors := order_by_probe_count (one_hop_neighbors_map, AS_probes, reverse)
+    s, limits = add_AS_probes (s, one_hop_neighbors, limits, AS_probes, run.pick)
+    group_3 := len (s)
+
+    /* --- Group 4: the others --- */
+    other_AS := order_by_probe_count (other_AS_map, AS_probes, reverse)
+    s, limits = add_AS_probes (s, other_AS, limits, AS_probes, run.pick)
+    group_4 := len (s)
+    groups := []*strategy_group {
+        {name: group_internal, order: order_none, ases: []string{as_interest}, size: group_1},
+        new_strategy_group (group_neighbor, order_probe_count, neighbors, AS_probes),
+        new_strategy_group (group_one_hop, order_probe_count, one_hop_neighbors, AS_probes),
+        new_strategy_group (group_other, order_probe_count, other_AS, AS_probes),
+    }
+
+    output_msg ("main_groups_limits.txt", as_interest, group_1, group_2, group_3, group_4)
+    return s, limits, groups
+}
+
+// -------------------------------------------------------------------------------
+/**
+ * 24. Strategy 11, but the others are ordered by increasing AS-level distance from the AS of
+ *     interest (distance 3, 4, ..., then the ASes unreachable in the AS relationships), and by
+ *     increasing customer cone within a distance.
+ *     The start of each distance ring is recorded in distance_rings.txt.
+ */
+func directed_probing_others_by_distance (_ []string, as_interest string, target_to_vp VP_mapper, run *strategy_run) ([]string, []*AS_limit, []*strategy_group) {
+    AS_probes, neighbors_map, one_hop_neighbors_map, other_AS_map, nb_probes := get_directed_probes_and_groups (as_interest, run)
+
+    s := make ([]string, 0, nb_probes)
+    limits := make ([]*AS_limit, 0, len (neighbors_map) + len (one_hop_neighbors_map) + len (other_AS_map) + 1)
+
+    /* --- Group 1: internal prefixes --- */
+    internals := AS_probes[as_interest]
+    s = append (s, sorted_keys (&internals)...)
+    limits = append (limits, &AS_limit{asn: as_interest, limit: len (s)})
+    group_1 := len (s)
+
+    /* --- Group 2: the neighbors --- */
+    neighbors := order_by_customer_cone (run.ds, neighbors_map, as_interest, false)
+    s, limits = add_AS_probes (s, neighbors, limits, AS_probes, run.pick)
+    group_2 := len (s)
+
+    /* --- Group 3: the one hop neighbors --- */
+    one_hop_neighbors := order_by_customer_cone (run.ds, one_hop_neighbors_map, as_interest, false)
+    s, limits = add_AS_probes (s, one_hop_neighbors, limits, AS_probes, run.pick)
+    group_3 := len (s)
+
+    /* --- Group 4: the others, ring by ring --- */
+    other_AS, distances := order_by_distance (run.ds, other_AS_map, as_interest)
+    rings := []interface{}{"distance_rings.txt", as_interest} // <distance>:<index of its first probe> (-1: unreachable)
+    for i, as := range other_AS {
+        if i == 0 || distances[i] != distances[i-1] {
+            rings = append (rings, strconv.Itoa (distances[i]) + ":" + strconv.Itoa (len (s)))
+        }
+        s, limits = add_AS_probes (s, []string{as}, limits, AS_probes, run.pick)
+    }
+    group_4 := len (s)
+    groups := []*strategy_group {
+        {name: group_internal, order: order_none, ases: []string{as_interest}, size: group_1},
+        new_strategy_group (group_neighbor, order_customer_cone, neighbors, AS_probes),
+        new_strategy_group (group_one_hop, order_customer_cone, one_hop_neighbors, AS_probes),
+        new_strategy_group (group_other, order_distance, other_AS, AS_probes),
+    }
+
+    output_msg ("main_groups_limits.txt", as_interest, group_1, group_2, group_3, group_4)
+    output_msg (rings...)
+    return s, limits, groups
+}
+
+// -------------------------------------------------------------------------------
+/**
+ * 27. Strategy 11, aware of the organizations (CAIDA AS2Org):
+ *     - the siblings of the AS of interest are probed with its internal prefixes
+ *     - the sibling neighbors are merged into a single group, ordered by their combined customer cone
+ *     The number of siblings of the AS of interest and of merged neighbor groups is recorded in sibling_groups.txt.
+ */
+func directed_probing_siblings (_ []string, as_interest string, target_to_vp VP_mapper, run *strategy_run) ([]string, []*AS_limit, []*strategy_group) {
+    AS_probes, neighbors_map, one_hop_neighbors_map, other_AS_map, nb_probes := get_directed_probes_and_groups (as_interest, run)
+
+    s := make ([]string, 0, nb_probes)
+    limits := make ([]*AS_limit, 0, len (neighbors_map) + len (one_hop_neighbors_map) + len (other_AS_map) + 1)
+
+    /* --- Group 1: internal prefixes, and those of the siblings --- */
+    internals := AS_probes[as_interest]
+    s = append (s, sorted_keys (&internals)...)
+    internal_ases := []string{as_interest}
+    internal_siblings := 0
+    for _, sibling := range siblings_of (as_interest) {
+        probes, ok := AS_probes[sibling]
+        if !ok {
+            continue
+        }
+        for _, probe := range sorted_keys (&probes) {
+            s = append (s, run.pick (probe))
+        }
+        delete (neighbors_map, sibling)
+        delete (one_hop_neighbors_map, sibling)
+        delete (other_AS_map, sibling)
+        internal_ases = append (internal_ases, sibling)
+        internal_siblings++
+    }
+    limits = append (limits, &AS_limit{asn: as_interest, limit: len (s)})
+    group_1 := len (s)
+
+    /* --- Group 2: the neighbors, siblings merged --- */
+    neighbors := order_by_organization (run.ds, neighbors_map, as_interest, false)
+    s, limits = add_organization_probes (run.ds, s, neighbors, limits, AS_probes, run.pick)
+    group_2 := len (s)
+    merged := 0
+    for _, group := range neighbors {
+        if len (group) > 1 {
+            merged++
+        }
+    }
+
+    /* --- Group 3: the one hop neighbors --- */
+    one_hop_neighbors := order_by_customer_cone (run.ds, one_hop_neighbors_map, as_interest, false)
+    s, limits = add_AS_probes (s, one_hop_neighbors, limits, AS_probes, run.pick)
+    group_3 := len (s)
+
+    /* --- Group 4: the others --- */
+    other_AS := order_by_customer_cone (run.ds, other_AS_map, as_interest, false)
+    s, limits = add_AS_probes (s, other_AS, limits, AS_probes, run.pick)
+    group_4 := len (s)
+    organizations := make ([]string, 0, len (neighbors_map))
+    for _, group := range neighbors {
+        organizations = append (organizations, group...)
+    }
+    groups := []*strategy_group {
+        {name: group_internal, order: order_none, ases: internal_ases, size: group_1},
+        new_strategy_group (group_neighbor, order_organization, organizations, AS_probes),
+        new_strategy_group (group_one_hop, order_customer_cone, one_hop_neighbors, AS_probes),
+        new_strategy_group (group_other, order_customer_cone, other_AS, AS_probes),
+    }
+
+    output_msg ("main_groups_limits.txt", as_interest, group_1, group_2, group_3, group_4)
+    output_msg ("sibling_groups.txt", as_interest, internal_siblings, merged)
+    return s, limits, groups
+}
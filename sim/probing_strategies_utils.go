@@ -0,0 +1,554 @@
+/* ==================================================================================== *\
+     probing_strategies_utils.go
+
+     Utility functions to group the directed probes by AS and get the different
+     groups (internals, direct neighbors, one hop neighbors, others).
+     Utility functions to sort the groups and the ASes according to various criteria.
+\* ==================================================================================== */
+
+package sim
+
+import (
+        "log"
+        "strings"
+        "sort"
+        "sync"
+        "strconv"
+        pool "github.com/Emeline-1/pool"
+        )
+
+/* ------------------------------------------------------------------------------- *\
+                           Directed Probes and Grouping
+\* ------------------------------------------------------------------------------- */
+
+/**
+ * Reads the targets in the file corresponding to the AS of interest.
+ * 
+ * The targets returned are always "valid" targets for Anaximander's simulator,
+ * in the sense they are /24 prefix (a different mask length would never produce a hit, 
+    * due to simulator's implementation).
+ * 
+ * ex: if there is a prefix x.x.0.0/16, a random /24 prefix will be picked from the initial prefix.
+ */
+func get_directed_probes (as_interest string, run *strategy_run) []string {
+    
+    /* --- Get AS directed prefix file --- */
+    files := pool.Get_directory_files (g_args.directed_prefixes_dir)
+    var as_file string
+    for _,file := range *files {
+        if strings.HasSuffix (file, ".bin") || strings.HasSuffix (file, ".tmp") { // Sidecars, see read_directed_prefixes
+            continue
+        }
+        if strings.Contains (file, as_interest) {
+            as_file = file
+        }
+    }
+
+    /* --- Read file --- */
+    if as_file == "" {
+        log.Print ("[get_directed_probes]: no directed prefixes for AS ", as_interest, " in ", g_args.directed_prefixes_dir)
+        return nil
+    }
+    prefixes, err := read_directed_prefixes (as_file)
+    if err != nil {
+        log.Fatal ("[get_directed_probes]: ", err)
+    }
+
+    /* --- Pick a /24 prefix randomly within the larger prefix --- */
+    directed_prefixes := make ([]string, 0, len (prefixes))
+    for _, prefix := range prefixes {
+        directed_prefixes = append (directed_prefixes, run.pick (prefix))
+    }
+    return directed_prefixes
+}
+
+/**
+ * Returns:
+ * - The directed probes, grouped by their AS.
+ * - The directed neighbors of the AS of interest (slice of ASes)
+ * - The one hop neighbors of the AS of interest (slice of ASes)
+ * - The other ASes of the directed probes, that are not part of the neighbors nor the one hop neighbors (slice of ASes)
+ * - The number of directed probes.
+ * 
+ * The slices of ASes returned never contain the AS of interest
+ */
+func get_directed_probes_and_groups (as_interest string, run *strategy_run) (map[string]map[string]interface{}, map[string]interface{}, map[string]interface{}, map[string]interface{}, int) {
+    /* --- Get Directed Probes --- */
+    directed_probes := get_directed_probes (as_interest, run)
+
+    /* --- Group directed probes by the AS they belong to --- */
+    AS_probes := make (map[string]map[string]interface{})
+    missing_prefixes := 0
+    for _, probe := range directed_probes {
+        AS := lookup_as (probe)
+        if AS == "" {
+            missing_prefixes++
+            AS = "-1" // Default AS for prefixes for which we can't attribute an AS. 
+        }
+        append_prefix (&AS_probes, AS, probe)
+    }
+    output_msg ("missing_prefixes.txt", as_interest, missing_prefixes)
+
+    // Build a set of the ASes present in the directed probes (and remove the AS of interest at the same time)
+    AS_probes_map := make (map[string]interface{})
+    for AS,_ := range AS_probes { 
+        if AS == as_interest {
+            continue
+        }
+        AS_probes_map[AS] = struct{}{}
+    }
+
+    /* --- Get the neighbors --- */
+    neighbors_map := run.ds.as_rel.neighbors[as_interest]
+    neighbors_map = filter_on_directedProbes (neighbors_map, AS_probes_map) // Remove ASes not present in the directed probes
+    
+    /* --- Get the one hop neighbors --- */
+    one_hop_neighbors_slice := one_hop_neighbors_of (run.ds.as_rel.neighbors, as_interest)
+    one_hop_neighbors_map := filter_on_directedProbes (slice_to_map (one_hop_neighbors_slice), AS_probes_map) // Remove ASes not present in the directed probes
+    
+    /* --- Get the ASes that are not part of the neighbors nor the one hop neighbors --- */
+    other_AS_map := slice_to_map (difference (AS_probes_map, merge_maps_new (neighbors_map, one_hop_neighbors_map)))  //Remove the neighbors and the one hop neighbors
+
+    /* --- Restrict the groups to the allowed ASes (if any) --- */
+    apply_allowlist_to_groups (as_interest, AS_probes, []string{"neighbors", "one_hop_neighbors", "others"}, []map[string]interface{}{neighbors_map, one_hop_neighbors_map, other_AS_map})
+
+    return AS_probes, neighbors_map, one_hop_neighbors_map, other_AS_map, len (directed_probes)
+}
+
+// -------------------------------------------------------------------------------
+/**
+ * Given a set of AS and a reference set of AS, keep only the ASes that are present in the reference set.
+ */
+func filter_on_directedProbes (to_filter, reference map[string]interface{}) map[string]interface{} {
+    s := make (map[string]interface{})
+
+    for AS,_ := range to_filter {
+        if _, ok := reference[AS]; ok {
+            s[AS] = struct{}{}
+        }
+    }
+    return s
+}
+
+// -------------------------------------------------------------------------------
+/**
+ * Given the AS relationships and an AS of interest, returns its one hop neighbors (excluding direct
+ * neighbors, and the AS of interest itself)
+ */
+func one_hop_neighbors_of (as_rel map[string]map[string]interface{}, as_interest string) []string {
+
+    /* --- Get the direct neighbors of the AS of interest --- */
+    neighbors := as_rel[as_interest]
+
+    /* --- Get the neighbors of the neighbors --- */
+    one_hop_neighbors := make (map[string]interface{})
+    for neighbor,_ := range neighbors {
+        neighbor_neighbors := as_rel[neighbor]
+        for n,_ := range neighbor_neighbors {
+            if n == as_interest {
+                continue
+            }
+            one_hop_neighbors[n] = struct{}{}
+        }
+    }
+
+    /* --- Remove direct neighbors from list --- */
+    return difference (one_hop_neighbors, neighbors)
+}
+
+// -------------------------------------------------------------------------------
+/**
+ * Given a slice of ASes, add the probes of thoses ASes to the target list.
+ * 
+ * Input:
+ *  - s: the slice where to add the probes
+ *  - ases: the ASes whose probes must be added to the slice
+ *  - limits: the slice where to record the separation between the ASes.
+ *  - AS_probes: the probes corresponding each AS
+ *  - get_probe: a function that take a prefix as input and returns a probe.
+ * Output:
+ *  - s: the slice where the probes have been added
+ *  - limits: the separations between the ASes
+ */
+func add_AS_probes (s, ases []string, limits []*AS_limit, AS_probes map[string]map[string]interface{}, get_probe func (string) string) ([]string, []*AS_limit) {
+    for _,AS := range ases {
+        if probes, ok := AS_probes[AS]; ok {
+            for _, probe := range sorted_keys (&probes) {
+                s = append (s, get_probe (probe))
+            }
+            limits = append (limits, &AS_limit{asn: AS, limit: len (s)})
+        }
+    }
+    return s, limits
+}
+
+/**
+ * Invariant of the ordered targets of an AS of interest: a target appears once. A /24 may
+ * otherwise be listed twice, e.g., once among the internal prefixes and once for another AS
+ * when the /24 picked in a prefix of a sibling AS falls in an internal prefix, or twice in a
+ * group when the same /24 is picked in two of its prefixes (see _get_24_prefix).
+ *
+ * Keeps the earliest occurrence of each target, drops the later ones, and shifts the AS
+ * limits accordingly (an AS whose targets were all dropped ends up with the same limit as
+ * the previous AS, and is then not written, see write_strategy).
+ * Returns the deduplicated targets and limits, and the number of targets dropped.
+ */
+func dedup_targets (s []string, limits []*AS_limit) ([]string, []*AS_limit, int) {
+    seen := make (map[string]bool, len (s))
+    return filter_targets (s, limits, func (target string) bool {
+        if seen[target] {
+            return false
+        }
+        seen[target] = true
+        return true
+    })
+}
+
+/**
+ * Keeps the targets of s that have a trace in the warts data set (strategy_traces), in the same order,
+ * and recomputes the AS limits accordingly.
+ * Returns the targets kept, the new limits, and the number of targets dropped.
+ */
+func keep_probed_targets (s []string, limits []*AS_limit) ([]string, []*AS_limit, int) {
+    return filter_targets (s, limits, strategy_traces.contains)
+}
+
+/**
+ * Keeps the targets of s for which keep returns true (called once per target, in order), and shifts
+ * the AS limits accordingly: an AS whose targets were all dropped ends up with the same limit as the
+ * previous AS. s and limits are returned as they are if no target is dropped.
+ * Returns the targets kept, the new limits, and the number of targets dropped.
+ */
+func filter_targets (s []string, limits []*AS_limit, keep func (string) bool) ([]string, []*AS_limit, int) {
+    kept := make ([]string, 0, len (s))
+    kept_before := make ([]int, len (s) + 1) // Number of targets kept among s[:i]
+    for i, target := range s {
+        if keep (target) {
+            kept = append (kept, target)
+        }
+        kept_before[i+1] = len (kept)
+    }
+    if len (kept) == len (s) {
+        return s, limits, 0
+    }
+
+    new_limits := make ([]*AS_limit, 0, len (limits))
+    for _, limit := range limits {
+        l := limit.limit
+        if l > len (s) {
+            l = len (s)
+        }
+        new_limits = append (new_limits, &AS_limit{asn: limit.asn, limit: kept_before[l]})
+    }
+    return kept, new_limits, len (s) - len (kept)
+}
+
+/* ------------------------------------------------------------------------------- *\
+                             Sorting & Scheduling
+\* ------------------------------------------------------------------------------- */
+
+/**
+ * Group the direct neighbors of the AS of interest in customers > peers > providers and within each group,
+ * order them by their customer cone (increasing or decreasing).
+ * Returns a slice of ASes.
+ */
+func group_by_relationships (ds *strategy_datasets, AS_probes map[string]map[string]interface{}, as_interest string, reverse bool) []string {
+
+    /* --- Get ASes based on their relationships and order them --- */
+    c_p_p := map[int]map[string]interface{}{Customer: make (map[string]interface{}), Peer: make (map[string]interface{}), Provider: make (map[string]interface{})}
+    for as, neighbors := range ds.as_rel.neighbors {
+        if as == as_interest {
+            for neighbor, rel := range neighbors { // 'neighbor' is a [customer/peer/provider] of 'as'
+                c_p_p[rel.(int)][neighbor] = struct{}{}
+            }
+        }
+    }
+    customers := c_p_p[Customer]
+    providers := c_p_p[Provider]
+    peers := c_p_p[Peer]
+
+    // Build a set of the ASes present in the directed probes (and remove the AS of interest at the same time)
+    AS_probes_map := make (map[string]interface{})
+    for AS,_ := range AS_probes { 
+        if AS == as_interest {
+            continue
+        }
+        AS_probes_map[AS] = struct{}{}
+    }
+
+    customers = filter_on_directedProbes (customers, AS_probes_map)
+    providers =filter_on_directedProbes (providers, AS_probes_map)
+    peers =filter_on_directedProbes (peers, AS_probes_map)
+
+    ordered_customers := order_by_customer_cone (ds, customers, as_interest, reverse)
+    ordered_providers := order_by_customer_cone (ds, providers, as_interest, reverse)
+    ordered_peers := order_by_customer_cone (ds, peers, as_interest, reverse)
+
+    // Build slice
+    r := make ([]string, 0, len (ordered_peers) + len (ordered_customers)+ len (ordered_providers))
+
+    // several orders are possible: change in the code here to test different orders
+    for _, AS := range ordered_customers {
+        r = append (r, AS)
+    }
+    for _, AS := range ordered_peers {
+        r = append (r, AS)
+    }
+    for _, AS := range ordered_providers {
+        r = append (r, AS)
+    }
+    return r
+}
+
+/**
+ * Given a set of ASes, order them by their customer cone (increasing or decreasing)
+ */
+func order_by_customer_cone (ds *strategy_datasets, ases map[string]interface{}, as_interest string, reverse bool) []string {
+    
+    // Build a slice of (AS,weight)
+    as_customersWeight := make (AS_weights, 0, len (ases))
+    for _, as := range sorted_keys (&ases) {
+        as_customersWeight = append (as_customersWeight, &AS_weight{name: as, weight: ds.as_conesize[as]})
+    }
+
+    /* --- Sort neighbors according to their weight (ties are kept in ASN order) --- */
+    if reverse {
+        sort.Stable (sort.Reverse (ByWeight{as_customersWeight}))
+    } else {
+        sort.Stable (ByWeight{as_customersWeight})
+    }
+    // Build a slice of (AS)
+    r := make ([]string, 0, len (as_customersWeight))
+    for _,as_weight := range as_customersWeight {
+        r = append (r, as_weight.name)
+    }
+    return r
+}
+
+/**
+ * Returns the siblings of the AS (the other ASes of its organization, see read_as2org), in ASN order.
+ */
+func siblings_of (as string) []string {
+    org, ok := as_to_org[as]
+    if !ok {
+        return nil
+    }
+    siblings := make ([]string, 0, len (org_to_ases[org]))
+    for _, sibling := range org_to_ases[org] {
+        if sibling != as {
+            siblings = append (siblings, sibling)
+        }
+    }
+    sort.Strings (siblings)
+    return siblings
+}
+
+/**
+ * Given a set of ASes, merges the siblings (ASes of a same organization, see read_as2org) into a single
+ * group, and orders the groups by the combined customer cone of their ASes (increasing, or decreasing if reverse).
+ * Within a group, the ASes are ordered by customer cone. An AS without organization is a group on its own.
+ * Returns the groups, in order.
+ */
+func order_by_organization (ds *strategy_datasets, ases map[string]interface{}, as_interest string, reverse bool) [][]string {
+    groups := make (map[string][]string)
+    groups_weight := make (AS_weights, 0, len (ases))
+    for _, as := range order_by_customer_cone (ds, ases, as_interest, reverse) {
+        org, ok := as_to_org[as]
+        if !ok {
+            org = "AS" + as // Not an organization ID of as-org2info
+        }
+        if _, seen := groups[org]; !seen {
+            groups_weight = append (groups_weight, &AS_weight{name: org})
+        }
+        groups[org] = append (groups[org], as)
+    }
+    for _, group := range groups_weight {
+        for _, as := range groups[group.name] {
+            group.weight += ds.as_conesize[as]
+        }
+    }
+
+    /* --- Sort groups according to their combined weight (ties are kept in the order of their first AS) --- */
+    if reverse {
+        sort.Stable (sort.Reverse (ByWeight{groups_weight}))
+    } else {
+        sort.Stable (ByWeight{groups_weight})
+    }
+    r := make ([][]string, 0, len (groups_weight))
+    for _, group := range groups_weight {
+        r = append (r, groups[group.name])
+    }
+    return r
+}
+
+/**
+ * Same as add_AS_probes, for groups of sibling ASes (see order_by_organization): the probes of the ASes
+ * of a group are delimited as a whole, by a single limit named after the AS of the group with the largest customer cone.
+ */
+func add_organization_probes (ds *strategy_datasets, s []string, groups [][]string, limits []*AS_limit, AS_probes map[string]map[string]interface{}, get_probe func (string) string) ([]string, []*AS_limit) {
+    for _, group := range groups {
+        start := len (s)
+        largest := group[0]
+        for _, AS := range group {
+            if probes, ok := AS_probes[AS]; ok {
+                for _, probe := range sorted_keys (&probes) {
+                    s = append (s, get_probe (probe))
+                }
+            }
+            if ds.as_conesize[AS] > ds.as_conesize[largest] {
+                largest = AS
+            }
+        }
+        if len (s) != start {
+            limits = append (limits, &AS_limit{asn: largest, limit: len (s)})
+        }
+    }
+    return s, limits
+}
+
+/**
+ * Given a set of ASes, order them by their number of directed prefixes, i.e., their probing
+ * cost (increasing, or decreasing if reverse). Ties are kept in ASN order.
+ */
+func order_by_probe_count (ases map[string]interface{}, AS_probes map[string]map[string]interface{}, reverse bool) []string {
+    as_probesWeight := make (AS_weights, 0, len (ases))
+    for _, as := range sorted_keys (&ases) {
+        as_probesWeight = append (as_probesWeight, &AS_weight{name: as, weight: len (AS_probes[as])})
+    }
+    if reverse {
+        sort.Stable (sort.Reverse (ByWeight{as_probesWeight}))
+    } else {
+        sort.Stable (ByWeight{as_probesWeight})
+    }
+    r := make ([]string, 0, len (as_probesWeight))
+    for _, as_weight := range as_probesWeight {
+        r = append (r, as_weight.name)
+    }
+    return r
+}
+
+/**
+ * The AS relationships (see read_as_rel), and the AS-level distances already computed on them (see
+ * distances), per AS of interest and limit. The graph owns its distances: another graph (e.g. a
+ * neutralized copy, see dataset_influence.go) starts with none.
+ */
+type as_graph struct {
+    neighbors map[string]map[string]interface{};
+    mux sync.Mutex;
+    distances_cache map[string]map[string]int;
+}
+
+func new_as_graph (neighbors map[string]map[string]interface{}) *as_graph {
+    return &as_graph{neighbors: neighbors, distances_cache: make (map[string]map[string]int)}
+}
+
+/**
+ * Returns the shortest AS-level distance from the AS of interest to the ASes reachable in the
+ * AS relationships, by a breadth-first search: 1 for the direct neighbors, 2 for the one hop
+ * neighbors, and so on. The search stops at distance 'limit' (no limit if <= 0).
+ * The AS of interest is at distance 0, the unreachable ASes are absent.
+ * The distances are memoized (the AS graph is large): the returned map must not be modified.
+ */
+func (g *as_graph) distances (as_interest string, limit int) map[string]int {
+    key := as_interest + "|" + strconv.Itoa (limit)
+    g.mux.Lock ()
+    defer g.mux.Unlock ()
+    if distances, ok := g.distances_cache[key]; ok {
+        return distances
+    }
+
+    distances := map[string]int{as_interest: 0}
+    frontier := []string{as_interest}
+    for distance := 1; len (frontier) != 0 && (limit <= 0 || distance <= limit); distance++ {
+        next := make ([]string, 0)
+        for _, as := range frontier {
+            for neighbor := range g.neighbors[as] {
+                if _, seen := distances[neighbor]; !seen {
+                    distances[neighbor] = distance
+                    next = append (next, neighbor)
+                }
+            }
+        }
+        frontier = next
+    }
+    g.distances_cache[key] = distances
+    return distances
+}
+
+/**
+ * Given a set of ASes, order them by increasing AS-level distance from the AS of interest, and
+ * within a distance by increasing customer cone (ties in ASN order). The unreachable ASes come last.
+ * Returns the ordered ASes and the distance of each of them (-1: unreachable).
+ */
+func order_by_distance (ds *strategy_datasets, ases map[string]interface{}, as_interest string) ([]string, []int) {
+    distances := ds.as_rel.distances (as_interest, 0)
+    distance_of := func (as string) int {
+        if d, ok := distances[as]; ok {
+            return d
+        }
+        return -1
+    }
+    r := order_by_customer_cone (ds, ases, as_interest, false)
+    sort.SliceStable (r, func (i, j int) bool {
+        di, dj := distance_of (r[i]), distance_of (r[j])
+        if di == -1 || dj == -1 {
+            return di != -1 && dj == -1
+        }
+        return di < dj
+    })
+    ring := make ([]int, 0, len (r))
+    for _, as := range r {
+        ring = append (ring, distance_of (as))
+    }
+    return r, ring
+}
+
+/**
+ * Sorting of neighbors by weight
+ */
+type AS_weight struct {
+    name     string
+    weight   int
+}
+type AS_weights []*AS_weight
+
+func (s AS_weights) Len() int      { return len(s) }
+func (s AS_weights) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+
+type ByWeight struct{AS_weights}
+func (s ByWeight) Less(i, j int) bool { return s.AS_weights[i].weight < s.AS_weights[j].weight }
+
+/* ------------------------------------------------------------------------------- *\
+                          Simple Group Access
+\* ------------------------------------------------------------------------------- */
+
+/**
+ * Returns a slice of all the prefixes (/24) of the direct neighbors of the AS of interest.
+ */
+func _direct_neighbors (ds *strategy_datasets, as_interest string) []string {
+    neighbors := ds.as_rel.neighbors[as_interest]
+
+    s := make ([]string, 0, 10)
+    for _, neighbor := range sorted_keys (&neighbors) {
+        prefixes := as_24prefixes.of (neighbor)
+        s = append (s, sorted_keys (&prefixes)...)
+    }
+    return s
+}
+
+// -------------------------------------------------------------------------------
+/**
+ * Returns a slice of all the prefixes (/24) of the AS of interest.
+ */
+func _internals (as_interest string) []string {
+    prefixes := as_24prefixes.of (as_interest)
+    return sorted_keys (&prefixes)
+}
+
+/**
+ * Returns the neighbors of the AS of interest ordered by their customer cone.
+ */
+func _get_neighbors_ordered_customer_cone (ds *strategy_datasets, as_interest string, reverse bool) []string {
+    neighbors := ds.as_rel.neighbors[as_interest]
+    return order_by_customer_cone (ds, neighbors, as_interest, reverse)
+}
@@ -0,0 +1,93 @@
+package sim
+
+import (
+    "strings"
+    "testing"
+    )
+
+/**
+ * The distances are memoized per graph: another graph with the same AS of interest gets its own.
+ */
+func TestASGraphDistances (t *testing.T) {
+    line := new_as_graph (map[string]map[string]interface{} {
+        "1": {"2": Customer},
+        "2": {"1": Provider, "3": Customer},
+        "3": {"2": Provider},
+    })
+    if d := line.distances ("1", 0); d["3"] != 2 || len (d) != 3 {
+        t.Fatalf ("line: %v", d)
+    }
+    if d := line.distances ("1", 1); len (d) != 2 {
+        t.Errorf ("line, limit 1: %v", d)
+    }
+    empty := new_as_graph (make (map[string]map[string]interface{}))
+    if d := empty.distances ("1", 0); len (d) != 1 || d["1"] != 0 {
+        t.Errorf ("empty graph: %v, want the AS of interest only", d)
+    }
+}
+
+/**
+ * A /24 listed in two groups is kept in the earliest only, and the limits of the ASes after the
+ * duplicate are shifted back; an AS whose targets were all dropped ends with the previous limit.
+ */
+func TestDedupTargets (t *testing.T) {
+    s := []string{"192.0.2.0/24", "198.51.100.0/24", "192.0.2.0/24", "203.0.113.0/24", "198.51.100.0/24"}
+    limits := []*AS_limit{{asn: "100", limit: 2}, {asn: "200", limit: 3}, {asn: "300", limit: 4}, {asn: "400", limit: 5}}
+    kept, new_limits, duplicates := dedup_targets (s, limits)
+    if duplicates != 2 {
+        t.Errorf ("%d duplicates, want 2", duplicates)
+    }
+    if strings.Join (kept, " ") != "192.0.2.0/24 198.51.100.0/24 203.0.113.0/24" {
+        t.Errorf ("targets: %v", kept)
+    }
+    for i, want := range []int{2, 2, 3, 3} {
+        if new_limits[i].asn != limits[i].asn || new_limits[i].limit != want {
+            t.Errorf ("AS %s: limit %d, want %d", new_limits[i].asn, new_limits[i].limit, want)
+        }
+    }
+    if limits[1].limit != 3 {
+        t.Error ("the limits given are modified")
+    }
+
+    /* --- No duplicate: the targets and limits are returned as is --- */
+    if kept, new_limits, duplicates := dedup_targets (kept, new_limits); duplicates != 0 || len (kept) != 3 || new_limits[3].limit != 3 {
+        t.Errorf ("without duplicate: %v %d", kept, duplicates)
+    }
+}
+
+/**
+ * filter_targets calls keep once per target in order, clamps a limit past the end of the targets,
+ * and returns the targets and limits given when nothing is dropped.
+ */
+func TestFilterTargets (t *testing.T) {
+    s := []string{"10.0.0.0/24", "10.0.1.0/24", "10.0.2.0/24", "10.0.3.0/24"}
+    limits := []*AS_limit{{asn: "100", limit: 1}, {asn: "200", limit: 3}, {asn: "300", limit: 9}}
+    for _, test := range []struct {
+        name string;
+        drop string;    // Targets for which keep returns false
+        kept string;
+        limits []int;
+    }{
+        {"none", "", "10.0.0.0/24 10.0.1.0/24 10.0.2.0/24 10.0.3.0/24", []int{1, 3, 9}},
+        {"first", "10.0.0.0/24", "10.0.1.0/24 10.0.2.0/24 10.0.3.0/24", []int{0, 2, 3}},
+        {"group", "10.0.1.0/24 10.0.2.0/24", "10.0.0.0/24 10.0.3.0/24", []int{1, 1, 2}},
+        {"all", "10.0.0.0/24 10.0.1.0/24 10.0.2.0/24 10.0.3.0/24", "", []int{0, 0, 0}},
+    } {
+        var called []string
+        kept, new_limits, dropped := filter_targets (s, limits, func (target string) bool {
+            called = append (called, target)
+            return !strings.Contains (test.drop, target)
+        })
+        if strings.Join (called, " ") != strings.Join (s, " ") {
+            t.Errorf ("%s: keep called on %v", test.name, called)
+        }
+        if strings.Join (kept, " ") != test.kept || dropped != len (s) - len (kept) {
+            t.Errorf ("%s: kept %v, %d dropped", test.name, kept, dropped)
+        }
+        for i, want := range test.limits {
+            if new_limits[i].asn != limits[i].asn || new_limits[i].limit != want {
+                t.Errorf ("%s: AS %s: limit %d, want %d", test.name, new_limits[i].asn, new_limits[i].limit, want)
+            }
+        }
+    }
+}
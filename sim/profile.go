@@ -0,0 +1,214 @@
+/* ==================================================================================== *\
+     profile.go
+
+     Profiling and timing of a run:
+     ------------------------------
+     With -profile <dir>, the strategy and the simulation write a CPU profile of the whole
+     run (<dir>/cpu.pprof), and a heap profile at the end of each stage of the summary
+     (<dir>/heap_<n>_<stage>.pprof), to be read with 'go tool pprof'. With -pprof <addr>
+     (e.g., localhost:6060), the profiles of net/http/pprof are served while the run goes on.
+
+     The phases of the simulation (warts parse, CAIDA parse, and for each AS the strategy
+     read, the simulation and the output writing) are timed with a Timer, the same way by
+     all the schedulers. The times are gathered by phase (number of times the phase ran, total
+     and maximum durations), and reported in the summary of the run ("timings").
+\* ==================================================================================== */
+
+package sim
+
+import (
+    "fmt"
+    "log"
+    "net/http"
+    _ "net/http/pprof" // Registers the /debug/pprof handlers
+    "os"
+    "path/filepath"
+    "runtime"
+    "runtime/pprof"
+    "sort"
+    "sync"
+    "time"
+    )
+
+var profiling = struct {
+    mux sync.Mutex;
+    dir string;       // Empty: no profile written
+    cpu_file *os.File;
+    nb_heaps int;
+}{}
+
+/**
+ * Starts the profiling of the run, as asked by -profile and -pprof.
+ */
+func profile_begin () {
+    if g_args.pprof_addr != "" {
+        go func () {
+            log.Print ("[profile]: ", http.ListenAndServe (g_args.pprof_addr, nil))
+        } ()
+        log.Println ("[profile]: pprof served on http://" + g_args.pprof_addr + "/debug/pprof/")
+    }
+    if g_args.profile_dir == "" {
+        return
+    }
+    if err := os.MkdirAll (g_args.profile_dir, 0755); err != nil {
+        log.Fatal ("[profile_begin]: ", err)
+    }
+    f, err := os.Create (filepath.Join (g_args.profile_dir, "cpu.pprof"))
+    if err != nil {
+        log.Fatal ("[profile_begin]: ", err)
+    }
+    if err := pprof.StartCPUProfile (f); err != nil {
+        log.Fatal ("[profile_begin]: ", err)
+    }
+    profiling.mux.Lock ()
+    profiling.dir, profiling.cpu_file = g_args.profile_dir, f
+    profiling.mux.Unlock ()
+}
+
+/**
+ * Writes the heap profile at the end of a stage.
+ */
+func profile_stage_end (stage string) {
+    profiling.mux.Lock ()
+    defer profiling.mux.Unlock ()
+    if profiling.dir == "" || stage == "" {
+        return
+    }
+    profiling.nb_heaps++
+    f, err := os.Create (filepath.Join (profiling.dir, fmt.Sprintf ("heap_%02d_%s.pprof", profiling.nb_heaps, stage)))
+    if err != nil {
+        log.Print ("[profile_stage_end]: ", err)
+        return
+    }
+    defer f.Close ()
+    runtime.GC () // Up-to-date statistics of the live objects
+    if err := pprof.WriteHeapProfile (f); err != nil {
+        log.Print ("[profile_stage_end]: ", err)
+    }
+}
+
+/**
+ * Stops the CPU profile.
+ */
+func profile_end () {
+    profiling.mux.Lock ()
+    defer profiling.mux.Unlock ()
+    if profiling.cpu_file == nil {
+        return
+    }
+    pprof.StopCPUProfile ()
+    if err := profiling.cpu_file.Close (); err != nil {
+        log.Print ("[profile_end]: ", err)
+    }
+    log.Println ("[profile]: profiles written in", profiling.dir)
+    profiling.cpu_file, profiling.dir = nil, ""
+}
+
+/* --- Timing of the phases of the simulation (in the order of the report) --- */
+const (
+    phase_warts_parse = "warts_parse"
+    phase_strategy_read = "strategy_read" // Per AS
+    phase_simulation = "simulation"       // Per AS
+    phase_output_write = "output_write"   // Per AS
+)
+
+var timing_phases = []string{phase_warts_parse, phase_strategy_read, phase_simulation, phase_output_write}
+
+type phase_timing struct {
+    Phase string `json:"phase"`;
+    Count int `json:"count"`;           // Number of times the phase ran (e.g., number of ASes)
+    Seconds float64 `json:"seconds"`;   // Total duration
+    Max_seconds float64 `json:"max_seconds"`;
+}
+
+var timings = struct {
+    mux sync.Mutex;
+    phases []*phase_timing; // In the order of timing_phases, then of their first occurrence
+}{}
+
+/**
+ * Times the phases of a unit of work (e.g., the simulation of an AS): the time of each phase is
+ * summed over the unit (a phase can be entered several times), and recorded once the unit stops.
+ */
+type Timer struct {
+    current string;
+    start time.Time;
+    order []string;
+    durations map[string]time.Duration;
+}
+
+func new_timer () *Timer {
+    return &Timer{durations: make (map[string]time.Duration)}
+}
+
+/**
+ * Ends the current phase (if any), and starts the given one.
+ */
+func (t *Timer) phase (name string) {
+    now := time.Now ()
+    if t.current != "" {
+        t.durations[t.current] += now.Sub (t.start)
+    }
+    if _, ok := t.durations[name]; !ok {
+        t.order = append (t.order, name)
+        t.durations[name] = 0
+    }
+    t.current, t.start = name, now
+}
+
+/**
+ * Ends the current phase, and records the time of each phase of the unit.
+ */
+func (t *Timer) stop () {
+    if t.current != "" {
+        t.durations[t.current] += time.Since (t.start)
+        t.current = ""
+    }
+    timings.mux.Lock ()
+    defer timings.mux.Unlock ()
+    for _, name := range t.order {
+        var timing *phase_timing
+        for _, p := range timings.phases {
+            if p.Phase == name {
+                timing = p
+                break
+            }
+        }
+        if timing == nil {
+            timing = &phase_timing{Phase: name}
+            timings.phases = append (timings.phases, timing)
+            sort.SliceStable (timings.phases, func (i, j int) bool {
+                return phase_rank (timings.phases[i].Phase) < phase_rank (timings.phases[j].Phase)
+            })
+        }
+        seconds := t.durations[name].Seconds ()
+        timing.Count++
+        timing.Seconds += seconds
+        if seconds > timing.Max_seconds {
+            timing.Max_seconds = seconds
+        }
+    }
+    t.order, t.durations = nil, make (map[string]time.Duration)
+}
+
+func phase_rank (phase string) int {
+    for i, p := range timing_phases {
+        if p == phase {
+            return i
+        }
+    }
+    return len (timing_phases)
+}
+
+/**
+ * Returns the times recorded so far, by phase.
+ */
+func timing_report () []phase_timing {
+    timings.mux.Lock ()
+    defer timings.mux.Unlock ()
+    report := make ([]phase_timing, len (timings.phases))
+    for i, p := range timings.phases {
+        report[i] = *p
+    }
+    return report
+}
@@ -0,0 +1,100 @@
+/* ==================================================================================== *\
+     progress.go
+
+     Progress of the long-running phases:
+     ------------------------------------
+     The pools parsing many items (warts files, collectors, ASes of interest) are given a
+     Progress: their worker function is wrapped so that each item processed ticks a shared
+     counter, and a goroutine prints on stderr, every progress_interval, e.g.:
+       warts: 1234/5678 files (22%)
+     then the duration of the phase once the pool is done. stdout is left to the outputs of
+     the commands (see output_mode). Disabled with -quiet.
+\* ==================================================================================== */
+
+package sim
+
+import (
+    "fmt"
+    "os"
+    pool "github.com/Emeline-1/pool"
+    "sync/atomic"
+    "time"
+    )
+
+const progress_interval = 10 * time.Second
+
+type Progress struct {
+    name, unit string;    // E.g., "warts", "files"
+    total int64;
+    done int64;           // Items processed, atomic
+    start time.Time;
+    stop chan struct{};
+    stopped chan struct{};
+}
+
+/**
+ * Starts reporting the progress of a phase of 'total' items. Returns nil with -quiet.
+ */
+func progress_begin (name, unit string, total int) *Progress {
+    if g_args.quiet {
+        return nil
+    }
+    p := &Progress{name: name, unit: unit, total: int64 (total), start: time.Now (), stop: make (chan struct{}), stopped: make (chan struct{})}
+    go func () {
+        defer close (p.stopped)
+        ticker := time.NewTicker (progress_interval)
+        defer ticker.Stop ()
+        for {
+            select {
+                case <-ticker.C:
+                    p.print ()
+                case <-p.stop:
+                    return
+            }
+        }
+    } ()
+    return p
+}
+
+func (p *Progress) print () {
+    done := atomic.LoadInt64 (&p.done)
+    percent := int64 (100)
+    if p.total > 0 {
+        percent = 100 * done / p.total
+    }
+    fmt.Fprintf (os.Stderr, "%s: %d/%d %s (%d%%)\n", p.name, done, p.total, p.unit, percent)
+}
+
+/**
+ * Returns the worker function f of a pool, ticking the progress after each item.
+ */
+func (p *Progress) wrap (f func (string)) func (string) {
+    if p == nil {
+        return f
+    }
+    return func (item string) {
+        f (item)
+        atomic.AddInt64 (&p.done, 1)
+    }
+}
+
+/**
+ * Stops the reporting, and prints the duration of the phase.
+ */
+func (p *Progress) end () {
+    if p == nil {
+        return
+    }
+    close (p.stop)
+    <-p.stopped
+    fmt.Fprintf (os.Stderr, "%s: %d/%d %s done in %s\n", p.name, atomic.LoadInt64 (&p.done), p.total, p.unit, time.Since (p.start).Round (time.Millisecond))
+}
+
+/**
+ * Launches the pool on the items, reporting its progress.
+ */
+func launch_pool_progress (name, unit string, nb_workers int, items []string, f func (string)) {
+    p := progress_begin (name, unit, len (items))
+    pool.Launch_pool (nb_workers, items, p.wrap (f))
+    p.end ()
+}
@@ -0,0 +1,70 @@
+/* ==================================================================================== *\
+     random.go
+
+     Random numbers of the whole program:
+     ------------------------------------
+     A run can be replayed with -seed. Each unit of work (the strategy of an AS of
+     interest, the credit of a destination) draws from a source of its own, derived
+     from the seed and the unit (see seeded_random): its draws depend neither on the
+     other units nor on the number of workers. The other draws go through a single
+     source, safe for concurrent use.
+\* ==================================================================================== */
+
+package sim
+
+import (
+    "hash/fnv"
+    "log"
+    "math/rand"
+    "sync"
+    "time"
+    )
+
+type locked_source struct {
+    lock sync.Mutex;
+    src rand.Source64;
+}
+
+func (s *locked_source) Int63 () int64 {
+    s.lock.Lock ()
+    defer s.lock.Unlock ()
+    return s.src.Int63 ()
+}
+
+func (s *locked_source) Uint64 () uint64 {
+    s.lock.Lock ()
+    defer s.lock.Unlock ()
+    return s.src.Uint64 ()
+}
+
+func (s *locked_source) Seed (seed int64) {
+    s.lock.Lock ()
+    defer s.lock.Unlock ()
+    s.src.Seed (seed)
+}
+
+var rng = rand.New (&locked_source{src: rand.NewSource (time.Now ().UnixNano ()).(rand.Source64)})
+
+/**
+ * Seeds the random numbers of the whole program. If the seed is 0, it is chosen from the
+ * clock and logged, so that the run can be replayed.
+ * Returns the seed used.
+ */
+func seed_random (seed int64) int64 {
+    if seed == 0 {
+        seed = time.Now ().UnixNano ()
+        log.Println ("[seed_random]: random seed", seed, "(replay the run with -seed", seed, ")")
+    }
+    rng.Seed (seed)
+    return seed
+}
+
+/**
+ * Returns the source of random numbers of a unit of work (e.g. an AS of interest), derived from the
+ * seed and the key of the unit. Not safe for concurrent use.
+ */
+func seeded_random (seed int64, key string) *rand.Rand {
+    h := fnv.New64a ()
+    h.Write ([]byte (key))
+    return rand.New (rand.NewSource (seed ^ int64 (h.Sum64 ())))
+}
@@ -0,0 +1,44 @@
+package sim
+
+import (
+    "reflect"
+    "sync"
+    "testing"
+    )
+
+/**
+ * The targets of an AS only depend on the seed and the AS: not on the other ASes processed
+ * concurrently, nor on the order in which they are processed.
+ */
+func TestStrategyRunSeeded (t *testing.T) {
+    ds, target_to_vp, destinations := load_strategy_universe (t)
+    strategy, err := strategy_index ("directed_probing_internal_neighbors_onehopneighbors_others")
+    if err != nil {
+        t.Fatal (err)
+    }
+    targets := func (seed int64, as_interest string) []string {
+        s, _, _ := strategies[strategy].fn (destinations, as_interest, target_to_vp, new_strategy_run (ds, seed, as_interest))
+        return s
+    }
+    alone := targets (7, "100")
+
+    var wg sync.WaitGroup
+    concurrent := make ([][]string, 8)
+    for i := range concurrent {
+        wg.Add (1)
+        go func (i int) {
+            defer wg.Done ()
+            targets (7, "200")
+            concurrent[i] = targets (7, "100")
+        }(i)
+    }
+    wg.Wait ()
+    for i, s := range concurrent {
+        if !reflect.DeepEqual (s, alone) {
+            t.Fatalf ("worker %d: %v, alone: %v", i, s, alone)
+        }
+    }
+    if reflect.DeepEqual (targets (8, "100"), alone) {
+        t.Error ("same targets with another seed")
+    }
+}
@@ -0,0 +1,1356 @@
+/* ============================================================= *\
+   readers.go
+
+   - Readers objects to read warts files and bdrmapit annotations (sqlite or CSV files).
+   - Methods to process warts files and sqlite files.
+   - Misc functions to read diverse files.
+\* ============================================================= */
+package sim
+
+import (
+  "strings"
+  "bufio"
+  "bytes"
+  "os/exec"
+  "os"
+  "log"
+  "database/sql"
+  "encoding/csv"
+  "strconv"
+  "fmt"
+  "io"
+  "errors"
+  "sync/atomic"
+  "compress/bzip2"
+  "compress/gzip"
+  _ "github.com/mattn/go-sqlite3"
+  pool "github.com/Emeline-1/pool")
+// the underscore import is used for the side-effect of registering the sqlite3 driver 
+// as a database driver in the init() function, without importing any other functions
+
+/* ------------------------------------------------------- *\
+ *                     WARTS READER
+\* ------------------------------------------------------- */
+type WartsReader struct{
+  filename string;
+  cmd *exec.Cmd;         // sc_tnt, decoding the warts file as it is read
+  output io.ReadCloser;  // Standard output of sc_tnt
+  input io.Closer;       // The warts file (gzip compressed files are decompressed on the fly)
+  stderr bytes.Buffer;   // Standard error of sc_tnt, to report its failures
+}
+
+func NewWartsReader (filename string) *WartsReader {
+  return &WartsReader{
+    filename: filename,
+  }
+}
+
+/**
+ * Starts decoding the warts file with sc_tnt. The decoded text is streamed to the scanner,
+ * so the whole file is never held in memory.
+ */
+func (r *WartsReader) Open () error {
+  file, err := os.Open (r.filename)
+  if err != nil {
+    return err
+  }
+  r.cmd = exec.Command ("sc_tnt", "-d2")
+  r.cmd.Stdin = file
+  if strings.HasSuffix (r.filename, ".gz") {
+    gz, err := gzip.NewReader (file)
+    if err != nil {
+      file.Close ()
+      return fmt.Errorf ("%s: %v", r.filename, err)
+    }
+    r.cmd.Stdin = gz
+  }
+  r.input = file
+  r.cmd.Stderr = &r.stderr
+  if r.output, err = r.cmd.StdoutPipe (); err != nil {
+    file.Close ()
+    return fmt.Errorf ("%s: %v", r.filename, err)
+  }
+  if err := r.cmd.Start (); err != nil {
+    file.Close ()
+    return fmt.Errorf ("%s: sc_tnt: %v", r.filename, err)
+  }
+  return nil
+}
+
+func (r *WartsReader) Scanner () *bufio.Scanner {
+  return new_scanner (r.output, default_max_line)
+}
+
+/**
+ * Waits for sc_tnt to exit, and returns an error if the warts file could not be decoded
+ * entirely (e.g., corrupt or truncated file).
+ */
+func (r *WartsReader) Close () error {
+  if r.cmd == nil {
+    return nil
+  }
+  io.Copy (io.Discard, r.output) // The rest of the output, if the scanner stopped early (sc_tnt would block)
+  err := r.cmd.Wait ()
+  r.input.Close ()
+  r.cmd = nil
+  if err != nil {
+    return fmt.Errorf ("%s: sc_tnt: %v: %s", r.filename, err, strings.TrimSpace (r.stderr.String ()))
+  }
+  return nil
+}
+
+type Trace struct {
+  vp string; // The source IP address of the VP that launched the trace.
+  hops []Hop;
+  entry_rtts map[string]float64; // ASN -> minimum RTT (ms) of the hops where the trace first enters that AS (computed in commit_trace).
+}
+
+func NewTrace () *Trace {
+  return &Trace{hops: make ([]Hop, 0, 16)} // 16 default trace length approximately.
+}
+
+func (t Trace) String () string {
+  var r string
+  for _,hop := range t.hops {
+    r+= fmt.Sprintf ("%v", hop) + "\n"
+  }
+  return r
+}
+
+/**
+ * Returns true if the trace went through private ("rsvd") hops.
+ */
+func (trace *Trace) has_private_hops () bool {
+  for _, hop := range trace.hops {
+    if hop.private {
+      return true
+    }
+  }
+  return false
+}
+
+/* --- Handling of the routing loops of the traces (-loops) --- */
+const (
+  loops_truncate = "truncate-at-loop" // The trace stops before the first address seen again
+  loops_remove = "remove-loop"        // The hops of the loop are removed, the trace goes on after it
+  loops_keep = "keep"                 // The trace is kept as is (historical behavior)
+)
+
+/**
+ * Returns the trace without its routing loops (a public address seen again after other addresses,
+ * e.g., A B C B D), according to policy (see the loops_ constants, truncate-at-loop if empty), and whether it had one.
+ * With remove-loop, A B C B D gives A B D (B and D are not adjacent, see hop_distance).
+ * Must be called after prune_dups, so that a repeated address is not taken as a loop.
+ */
+func (trace Trace) prune_loops (policy string) (*Trace, bool) {
+  looped := false
+  new_trace := &Trace{vp: trace.vp, hops: make ([]Hop, 0, len (trace.hops))}
+  position := make (map[string]int) // Address -> its index in new_trace.hops
+  for _, hop := range trace.hops {
+    if hop.private {
+      new_trace.hops = append (new_trace.hops, hop)
+      continue
+    }
+    p, seen := position[hop.addr]
+    if !seen {
+      position[hop.addr] = len (new_trace.hops)
+      new_trace.hops = append (new_trace.hops, hop)
+      continue
+    }
+    looped = true
+    switch policy {
+      case loops_keep:
+        new_trace.hops = append (new_trace.hops, hop)
+      case loops_remove:
+        for _, removed := range new_trace.hops[p+1:] {
+          delete (position, removed.addr)
+        }
+        new_trace.hops = new_trace.hops[:p+1]
+      default: // loops_truncate, also when no policy was given
+        return new_trace, looped
+    }
+  }
+  return new_trace, looped
+}
+
+func (trace Trace) prune_dups () *Trace {
+  prev := ""
+  new_trace := &Trace{vp: trace.vp, hops: make ([]Hop, 0, len (trace.hops))}
+  for _, hop := range trace.hops {
+    if prev != hop.addr {
+      new_trace.hops = append (new_trace.hops, hop)
+    }
+    prev = hop.addr
+  }
+  return new_trace
+}
+
+/**
+ * Returns the minimum RTT at which the trace first enters the given AS,
+ * or -1 if the trace never enters that AS (or if no RTT was recorded for those hops).
+ */
+func (trace *Trace) entry_rtt (asn string) float64 {
+  if rtt, ok := trace.entry_rtts[asn]; ok {
+    return rtt
+  }
+  return -1
+}
+
+/**
+ * Computes, for each AS crossed by the trace, the minimum RTT over the hops
+ * of the first contiguous segment of the trace in that AS.
+ */
+func (trace *Trace) compute_entry_rtts () {
+  trace.entry_rtts = make (map[string]float64)
+  done := make (map[string]struct{}) // ASes whose first segment is over.
+  prev_asn := ""
+  for _, hop := range trace.hops {
+    if hop.private { // Belongs to no AS
+      continue
+    }
+    if prev_asn != "" && prev_asn != hop.asn {
+      done[prev_asn] = struct{}{}
+    }
+    prev_asn = hop.asn
+    if _, ok := done[hop.asn]; ok || hop.rtt < 0 {
+      continue
+    }
+    if rtt, ok := trace.entry_rtts[hop.asn]; !ok || hop.rtt < rtt {
+      trace.entry_rtts[hop.asn] = hop.rtt
+    }
+  }
+}
+
+type Hop struct {
+  addr string; // IP address
+  asn string; // The ASN assigned by bdrmapit to that address.
+  conn_asn string; // The ASN on the other side of the link of that address (bdrmapit conn_asn), asn if internal or unknown.
+  probe_ttl int; // The TTL of the traceroute probe
+  rtt float64; // The RTT of the reply in ms (-1 when absent from the warts output).
+  ingress bool;
+  egress bool; //If neither ingress nor egress is set, this is a hop inside the AS.
+  router string; // The router identifier this address belongs to.
+  private bool; // Private ("rsvd") address: kept for the TTL distances, but never an address, an adjacency end, or a discovery.
+}
+
+func (h Hop) String() string {
+  return fmt.Sprintf("[%v (AS: %v) - Ingress:%v - Hop:%v - RTT:%v - Private:%v ]", h.addr, h.asn, h.ingress, h.probe_ttl, h.rtt, h.private)
+}
+
+/**
+ * Returns the index of the first public hop after hops[i], or -1 if there is none.
+ */
+func next_public_hop (hops []Hop, i int) int {
+  for j := i + 1; j < len (hops); j++ {
+    if !hops[j].private {
+      return j
+    }
+  }
+  return -1
+}
+
+/**
+ * Returns the TTL distance between the hops hops[i] and hops[j] (i < j). The private hops between
+ * two public hops are routers as well: A priv B are not adjacent (distance 2), as A * B (unresponsive
+ * hop).
+ */
+func hop_distance (hops []Hop, i, j int) int {
+  return hops[j].probe_ttl - hops[i].probe_ttl
+}
+
+/**
+ * Extracts the RTT of a hop line (fields following the address), e.g.:
+ *    3  192.0.2.1  12.345 ms
+ * Returns -1 if the line has no RTT field.
+ */
+func get_hop_rtt (fields []string) float64 {
+  for i := 2; i < len (fields); i++ {
+    field := strings.TrimSuffix (fields[i], "ms")
+    if rtt, err := strconv.ParseFloat (field, 64); err == nil && rtt >= 0 {
+      return rtt
+    }
+  }
+  return -1
+}
+
+/**
+ * Parse warts files, and annotates hops with their AS and router thanks to bdrmapit output (sqlite or CSV).
+ * Returns the traces and the ground truth (not the VPs nor the ASes of interest, see LoadDatasets).
+ * The Config must hold its defaults (see Config.with_defaults).
+ */
+func parse_warts (cfg *Config) (*Datasets, error) {
+  files := pool.Get_directory_files (cfg.WartsDirectory)
+  if files == nil {
+    return nil, fmt.Errorf ("%s: problem while parsing the warts directory", cfg.WartsDirectory)
+  }
+
+  var ases_interest []string
+  if cfg.AsesInterestFile != "" {
+    ases_interest,_ = read_whitespace_delimited_file (cfg.AsesInterestFile)
+  }
+  ds := &Datasets{cfg: *cfg}
+
+  /* --- Parsed before with the same inputs: load the cache (see warts_cache.go), unless -no-cache --- */
+  var err error
+  cache_path := ""
+  if !cfg.NoCache {
+    cache_path, err = warts_cache_path (cfg, *files, ases_interest)
+    if err != nil {
+      log.Print ("[parse_warts]: WARNING: ", err, ", no cache")
+      cache_path = ""
+    }
+  }
+  if cache_path != "" {
+    sets, stats, err := load_warts_cache (cache_path)
+    if err == nil {
+      log.Println ("Warts parsed before, loaded from the cache:", cache_path, "(-no-cache to parse them again)")
+      ds.Traces, ds.vp_traces, ds.Adjs, ds.MultiAdjs, ds.Addresses, ds.AddrToAsn, ds.RouterToAsn, ds.addr_to_conn_asn = sets[0], sets[1], sets[2], sets[3], sets[4], sets[6], sets[7], sets[8]
+      ds.TargetToVp = NewSafeSetVPMapper (sets[5])
+      for i := 0; i < stats.Files; i++ {
+        summary_unit ("warts files", unit_processed)
+      }
+      log_warts_stats (ds, stats, ases_interest)
+      ds.index = build_as_index (ds)
+      return ds, nil
+    }
+    if !os.IsNotExist (err) {
+      log.Print ("[parse_warts]: WARNING: ", err, ", cache ignored")
+    }
+  }
+
+  /* --- Read bdrmapit sqlite file --- */
+  p := &trace_parser{cfg: cfg, keep_trace: get_duplicate_policy (cfg.DuplicateDestinations, ases_interest)}
+  p.addr_to_asn, ds.RouterToAsn, p.addr_to_router, p.addr_to_conn_asn, err = ReadAnnotations (cfg.BdrmapitFile)
+  if err != nil { // Without the annotations, no trace can be used
+    return nil, err
+  }
+  ds.AddrToAsn, ds.addr_to_conn_asn = p.addr_to_asn, p.addr_to_conn_asn
+
+  /* --- Read warts --- */
+  /* Written by every hop of every worker: sharded, so that the workers do not wait for each other */
+  sharded := []*ShardedSet{create_sharded_set (), create_sharded_set (), create_sharded_set (), create_sharded_set (), create_sharded_set (), create_sharded_set ()}
+  stats := warts_stats{Files: len (*files)}
+  warts_parser := generate_warts_parser (p, sharded[0], sharded[1], sharded[2], sharded[3], sharded[4], sharded[5], &stats.Skipped_traces, &stats.Private_traces, &stats.Looped_traces, &stats.Failed_files)
+  log.Println ("Reading warts files...")
+  launch_pool_progress ("warts", "files", cfg.Jobs, *files, warts_parser)
+  ds.Traces, ds.vp_traces, ds.Adjs, ds.MultiAdjs, ds.Addresses = sharded[0].merge (), sharded[1].merge (), sharded[2].merge (), sharded[3].merge (), sharded[4].merge ()
+  target_to_vp := sharded[5].merge ()
+  ds.TargetToVp = NewSafeSetVPMapper (target_to_vp)
+
+  if cfg.TraceVp == trace_vp_assigned {
+    log.Println ("Traces of the assigned VPs (-trace_vp assigned) replacing the trace of another VP: ", select_assigned_traces (ds.Traces, ds.vp_traces, target_to_vp))
+  }
+  log_warts_stats (ds, stats, ases_interest)
+
+  /* --- Cache for the next runs (not with -no-cache), unless some files failed (they may succeed next time) --- */
+  if cache_path != "" && stats.Failed_files == 0 {
+    if err := save_warts_cache (cache_path, ds.Traces, ds.vp_traces, ds.Adjs, ds.MultiAdjs, ds.Addresses, target_to_vp, ds.AddrToAsn, ds.RouterToAsn, ds.addr_to_conn_asn, stats); err != nil {
+      log.Print ("[parse_warts]: WARNING: cache not written: ", err)
+    }
+  }
+  ds.index = build_as_index (ds) // See filterAS
+  return ds, nil
+}
+
+/**
+ * Logs the statistics of the bdrmapit annotations and of the parsed warts.
+ */
+func log_warts_stats (ds *Datasets, stats warts_stats, ases_interest []string) {
+  log.Println (" ---- Bdrmapit stats ---- ")
+  log.Println ("Nb of addresses: ", len (ds.AddrToAsn.set))
+  log.Println (" ---- Warts stats ---- ")
+  log.Printf ("Number of warts files: %d (failed, skipped or incomplete: %d)", stats.Files, stats.Failed_files)
+  log.Println ("Number of traces: ", len (ds.Traces.set), ", of traces per VP: ", len (ds.vp_traces.set))
+  log.Println ("Number of skipped traces (source or destination not an IPv4 address): ", stats.Skipped_traces)
+  log.Println ("Number of traces with private hops: ", stats.Private_traces)
+  log.Println ("Number of traces with a routing loop (-loops " + ds.cfg.Loops + "): ", stats.Looped_traces)
+  log.Println ("Number of adjs: ", len (ds.Adjs.set))
+  log.Println ("Number of multi_adjs: ", len (ds.MultiAdjs.set))
+  log.Println ("Number of addresses (excluding private addresses): ", len (ds.Addresses.set))
+  log.Println ("Number of routers: ", len (ds.RouterToAsn.set))
+  log_rtt_stats (ds.Traces, ases_interest)
+}
+
+/**
+ * Generate a fonction to parse a warts file
+ * OUTPUT:
+ * - traces: map of the form: "dest_24" -> Trace{} (one trace per /24, see -dup_dest and -trace_vp)
+ * - vp_traces: map of the form: "vp_dest_24" -> Trace{} (the trace of each VP that probed the /24)
+ * - adjs: set of all adjacencies in the form "ip1_ip2" (usefull for percentage of discovered links/IPs) 
+ * - multi_adjs: set of all multiple hops adjencies in the form "ip1_ip2" (same)
+ * - addresses: set of all encountered valid routable addresses (usefull for percentage of discovered addresses for simulation)
+ *
+ * INPUT:
+ * - p: the Config and the bdrmapit annotations (see trace_parser).
+ * - skipped_traces: incremented by the number of traces whose source or destination is not an IPv4 address.
+ * - private_traces: incremented by the number of traces with private hops.
+ * - looped_traces: incremented by the number of traces with a routing loop (see -loops).
+ * - failed_files: incremented by the number of files that could not be read, or only partially.
+ */
+func generate_warts_parser (p *trace_parser, traces, vp_traces, adjs, multi_adjs, addresses, target_to_vp *ShardedSet, skipped_traces, private_traces, looped_traces, failed_files *int64) func (string) {
+  
+  return func (file_name string) {
+    skipped, private, looped := 0, 0, 0
+    failed := func () {
+      atomic.AddInt64 (failed_files, 1)
+      summary_unit ("warts files", unit_failed)
+    }
+    defer func () {
+      if skipped != 0 {
+        log.Printf ("[warts_parser]: %s: %d traces skipped (source or destination not an IPv4 address)", file_name, skipped)
+        atomic.AddInt64 (skipped_traces, int64 (skipped))
+      }
+      atomic.AddInt64 (private_traces, int64 (private))
+      atomic.AddInt64 (looped_traces, int64 (looped))
+    }()
+    defer func () { // A malformed file must not stop the other workers, but must not go unnoticed either
+      if r := recover (); r != nil {
+        log.Print ("[warts_parser]: WARNING: ", file_name, ": ", r, ", the traces of the file may be incomplete")
+        failed ()
+      }
+    }()
+
+      if trace_file_format (file_name, p.cfg.TraceFormat) == trace_format_json { // Output of sc_warts2json
+        if read_json_traces (file_name, p, traces, vp_traces, adjs, multi_adjs, addresses, target_to_vp, &skipped, &private, &looped) {
+          summary_unit ("warts files", unit_processed)
+        } else {
+          failed ()
+        }
+        return
+      }
+
+      reader := NewWartsReader (file_name)
+      if err := reader.Open (); err != nil {
+        log.Print ("[warts_parser]: WARNING: ", err, ", file skipped")
+        failed ()
+        return
+      }
+      defer reader.Close () // If a malformed line panics
+      scanner := reader.Scanner ()
+
+      var source, dest string
+      var trace *Trace
+      valid := false
+      for scanner.Scan() {
+      line := scanner.Text()
+      
+      if strings.Contains (line, "#") || strings.Contains (line, "DUMP"){
+        continue
+      }
+      /* --- End of trace --- */
+      if line == "" {
+        if valid {
+          if trace.has_private_hops () {
+            private++
+          }
+          if p.commit_trace (source, dest, trace, traces, vp_traces, adjs, multi_adjs, target_to_vp) {
+            looped++
+          }
+        }
+        valid = false
+      } else if strings.Contains (line, "from"){ /* --- New trace --- */
+        source, dest, valid = get_source_dest (line)
+        if !valid {
+          skipped++
+        }
+        trace = NewTrace ()
+      } else if !valid { // Hops of a skipped trace
+        continue
+      } else {
+        split := strings.Fields (line)
+        probe_ttl,_ := strconv.Atoi (split[0])
+        addr := split[1]
+        if strings.Contains (line, "rsvd") { // Private address: kept for the TTL distances (see hop_distance)
+          trace.hops = append (trace.hops, Hop{addr: addr, probe_ttl: probe_ttl, rtt: get_hop_rtt (split), private: true})
+          continue
+        }
+        if addr == "*" { // Unresponsive hops
+          continue
+        }
+        if addr == dest { 
+          continue
+        }
+        addresses.add (addr) 
+        trace.hops = append (trace.hops, p.annotated_hop (addr, probe_ttl, get_hop_rtt (split)))
+      }
+    }
+    if err := scanner.Err (); err != nil { // The traces decoded before the error are kept
+      log.Print ("[warts_parser]: WARNING: ", file_name, ": ", err, ", the traces of the file may be incomplete")
+      failed ()
+      return
+    }
+    if err := reader.Close (); err != nil { // The traces decoded before the error are kept
+      log.Print ("[warts_parser]: WARNING: ", err, ", the traces of the file may be incomplete")
+      failed ()
+      return
+    }
+    summary_unit ("warts files", unit_processed)
+  }
+}
+
+/**
+ * Parameters of the parsing of the traces, shared by the workers (read only).
+ */
+type trace_parser struct {
+  cfg *Config;
+  addr_to_asn, addr_to_router, addr_to_conn_asn *SafeSet; // bdrmapit annotations
+  keep_trace duplicate_policy;                            // See -dup_dest
+}
+
+/**
+ * Returns the hop of a public address, annotated with its AS and its router (bdrmapit).
+ */
+func (p *trace_parser) annotated_hop (addr string, probe_ttl int, rtt float64) Hop {
+  /* Get AS of address */
+  asn_i, ok := p.addr_to_asn.unsafe_get (addr)
+  var asn string
+  var t bool
+  if !ok {
+    asn = "-1"
+  } else {
+    asn, t = asn_i.(string)
+    if !t {
+      log.Fatal ("[generate_warts_parser]: unexpected type:", fmt.Sprintf("%T", asn_i))
+    }
+  }
+  /* Get router of address */
+  router_i, ok := p.addr_to_router.unsafe_get (addr)
+  var router string
+  if !ok {
+    router = "-1" // Address not present in bdrmapit output
+  } else {
+    router,_ = router_i.(string)
+  }
+  return Hop{
+    addr: addr,
+    asn: asn, 
+    conn_asn: hop_conn_asn (addr, asn, p.addr_to_conn_asn),
+    probe_ttl: probe_ttl,
+    rtt: rtt,
+    ingress: false,
+    egress: false,
+    router: router,
+  }
+}
+
+/**
+ * Function called at the end of the parsing of a trace, to sanitize the trace and commit it.
+ * - Skip the trace if its source or destination is not an IPv4 address (nothing is committed)
+ * - Prune duplicates
+ * - Prune loops
+ * - Create adjs and multiple adjs.
+ * - Assign ingresses and egresses.
+ *
+ * Those traces will be kept in a map "dest_24" -> Trace{} (one per /24), and in a map "vp_dest_24" -> Trace{}
+ * (one per VP and /24), for the simulation where we launch probes ourselves that will follow those traces.
+ * Returns true if the trace had a routing loop (see prune_loops and -loops), false if it was skipped.
+ */
+func (p *trace_parser) commit_trace (source, dest string, trace *Trace, traces, vp_traces, adjs, multi_adjs, target_to_vp *ShardedSet) bool {
+  /* --- Before anything is added to the ground truth --- */
+  if !is_ipv4_literal (source) || !is_ipv4_literal (dest) {
+    log.Print ("[commit_trace]: source or destination is not an IPv4 address: '", source, "' to '", dest, "', trace skipped")
+    return false
+  }
+  trace, looped := trace.prune_dups ().prune_loops (p.cfg.Loops)
+  hops := trace.hops
+  for i, hop := range hops {
+    if hop.private { // Never an adjacency end (see hop_distance)
+      continue
+    }
+    j := next_public_hop (hops, i)
+    if j == -1 {
+      break
+    }
+    /* --- Adjencies --- */
+    next_hop := hops[j]
+    distance := hop_distance (hops, i, j)
+    if distance == 1 {
+      adjs.add (hop.addr+"_"+next_hop.addr)
+    } 
+    if distance > 1 {
+      multi_adjs.add (hop.addr+"_"+next_hop.addr)
+    }
+    /* --- AS borders (also across private hops) --- */
+    if is_border_link (hop, next_hop, p.cfg.Border) {
+      hops[i].egress = true
+      hops[j].ingress = true
+    } 
+  }
+  trace.vp = source
+  trace.compute_entry_rtts ()
+  dest_24 := target_prefix_of (dest, p.cfg.target_length ()) // Same length as the targets of the strategy
+  /* --- Several traces towards the same /24: apply the duplicate destination policy --- */
+  traces.add_if (dest_24, trace, p.keep_trace, nil)
+  vp_traces.add_if (vp_trace_key (source, dest_24), trace, p.keep_trace, nil) // Among the traces of the same VP
+  /* --- Record every VP that probed the /24, whichever trace is kept --- */
+  target_to_vp.append (dest_24, source)
+  return looped
+}
+
+/* --- Definitions of the inter-AS links (-border) --- */
+const (
+  border_asn = "asn"           // The ASes of both ends differ (published results)
+  border_conn_asn = "conn_asn" // Also the links that bdrmapit puts between two ASes (conn_asn)
+)
+
+/**
+ * Returns the conn_asn of an address, or its AS (asn) if it is not on an inter-AS link
+ * (addr_to_conn_asn: the addresses that bdrmapit puts on an inter-AS link).
+ */
+func hop_conn_asn (addr, asn string, addr_to_conn_asn *SafeSet) string {
+  if addr_to_conn_asn != nil {
+    if conn_i, ok := addr_to_conn_asn.unsafe_get (addr); ok {
+      conn, _ := conn_i.(string)
+      return conn
+    }
+  }
+  return asn
+}
+
+/**
+ * Returns the hop of an address with its annotations only (AS and conn_asn), to test its links.
+ */
+func annotation_of (addr string, addr_to_asn, addr_to_conn_asn *SafeSet) Hop {
+  asn_i, _ := addr_to_asn.unsafe_get (addr)
+  asn, _ := asn_i.(string)
+  return Hop{addr: addr, asn: asn, conn_asn: hop_conn_asn (addr, asn, addr_to_conn_asn)}
+}
+
+/**
+ * Returns true if the link between two hops is an inter-AS link: the ASes of its ends differ or,
+ * with -border conn_asn, the link belongs to several ASes (see link_in_AS).
+ */
+func is_border_link (a, b Hop, border string) bool {
+  if a.asn != b.asn {
+    return true
+  }
+  return border == border_conn_asn && ((a.conn_asn != "" && a.conn_asn != a.asn) || (b.conn_asn != "" && b.conn_asn != b.asn))
+}
+
+/**
+ * Returns true if the link between two hops belongs to an AS: one of its ends is in the AS or,
+ * with -border conn_asn, bdrmapit connects one of its ends to the AS.
+ */
+func link_in_AS (a, b Hop, as, border string) bool {
+  if a.asn == as || b.asn == as {
+    return true
+  }
+  return border == border_conn_asn && (a.conn_asn == as || b.conn_asn == as)
+}
+
+/* --- Trace of a target probed by several VPs (-trace_vp) --- */
+const (
+  trace_vp_any = "any"           // The trace kept by the duplicate destination policy, whichever its VP
+  trace_vp_assigned = "assigned" // The trace of the VP the target is assigned to (the first of its VPs, as in the packet ledger)
+)
+
+/**
+ * Returns the key of the trace of a VP towards a /24 in Datasets.vp_traces.
+ */
+func vp_trace_key (vp, dest_24 string) string {
+  return vp + "_" + dest_24
+}
+
+/**
+ * Replaces the trace of each /24 by the trace of the VP it is assigned to (the first of its VPs,
+ * see Cost_model.ledger). Returns the number of /24s whose trace changed.
+ */
+func select_assigned_traces (traces, vp_traces, target_to_vp *SafeSet) int {
+  mapper := NewSafeSetVPMapper (target_to_vp)
+  changed := 0
+  for dest_24, trace := range traces.set {
+    target_vps, _ := mapper.Get (dest_24)
+    if len (target_vps) == 0 {
+      continue
+    }
+    if assigned, ok := vp_traces.set[vp_trace_key (target_vps[0], dest_24)]; ok && assigned != trace {
+      traces.set[dest_24] = assigned
+      changed++
+    }
+  }
+  return changed
+}
+
+/**
+ * A duplicate_policy decides whether a new trace towards an already traced /24
+ * should replace the trace already recorded.
+ */
+type duplicate_policy func (old, new interface{}) bool
+
+/**
+ * Returns the duplicate destination policy corresponding to 'policy':
+ * - "keep_last": the last trace committed is kept (historical behavior, nondeterministic across warts files).
+ * - "keep_lowest_rtt": the trace with the lowest RTT to one of the ASes of interest is kept (traces that never
+ *   reach an AS of interest always lose against traces that do).
+ */
+func get_duplicate_policy (policy string, ases_interest []string) duplicate_policy {
+  switch policy {
+    case "", "keep_last":
+      return func (old, new interface{}) bool {
+        return true
+      }
+    case "keep_lowest_rtt":
+      return func (old_i, new_i interface{}) bool {
+        old, _ := old_i.(*Trace)
+        new, _ := new_i.(*Trace)
+        new_rtt := interest_rtt (new, ases_interest)
+        if new_rtt < 0 {
+          return false
+        }
+        old_rtt := interest_rtt (old, ases_interest)
+        return old_rtt < 0 || new_rtt < old_rtt
+      }
+    default:
+      log.Fatal ("[get_duplicate_policy]: unknown duplicate destination policy: ", policy)
+  }
+  return nil
+}
+
+/**
+ * Returns the lowest RTT at which the trace enters one of the ASes of interest, -1 if it enters none.
+ */
+func interest_rtt (trace *Trace, ases_interest []string) float64 {
+  rtt := -1.0
+  if trace == nil {
+    return rtt
+  }
+  for _, as := range ases_interest {
+    if r := trace.entry_rtt (as); r >= 0 && (rtt < 0 || r < rtt) {
+      rtt = r
+    }
+  }
+  return rtt
+}
+
+/**
+ * Logs the percentiles of the RTTs at which the traces enter each AS of interest.
+ */
+func log_rtt_stats (traces *SafeSet, ases_interest []string) {
+  for _, as := range ases_interest {
+    rtts := make (DataFloat64, 0, 100)
+    for _, trace_i := range traces.set {
+      if trace, t := trace_i.(*Trace); t {
+        if rtt := trace.entry_rtt (as); rtt >= 0 {
+          rtts = append (rtts, rtt)
+        }
+      }
+    }
+    if len (rtts) == 0 {
+      log.Println ("RTT to AS", as, ": no trace with RTT")
+      continue
+    }
+    log.Printf ("RTT to AS %s (%d traces): p10 %.2f ms, p50 %.2f ms, p90 %.2f ms", as, len (rtts), rtts.Percentile (10), rtts.Percentile (50), rtts.Percentile (90))
+  }
+}
+
+/* ------------------------------------------------------- *\
+ *                    SQLITE READER
+\* ------------------------------------------------------- */
+type SqliteReader struct{
+  filename string;
+  database *sql.DB;
+  rows *sql.Rows
+}
+
+func NewSqliteReader (filename string) *SqliteReader {
+  return &SqliteReader{
+    filename: filename,
+  }
+}
+
+/**
+ * Queries the annotations of the bdrmapit file. The file must exist: the sqlite driver
+ * would otherwise create an empty database.
+ */
+func (r *SqliteReader) Open () error {
+  if _, err := os.Stat (r.filename); err != nil {
+    return fmt.Errorf ("[SqliteReader]: %v", err)
+  }
+  database, err := sql.Open ("sqlite3", r.filename)
+  if err != nil {
+    return fmt.Errorf ("[SqliteReader]: %s: %v", r.filename, err)
+  }
+  rows, err := database.Query ("SELECT * FROM annotation")
+  if err != nil {
+    database.Close ()
+    return fmt.Errorf ("[SqliteReader]: %s: %v", r.filename, err)
+  }
+  r.database, r.rows = database, rows
+  return nil
+}
+
+func (r *SqliteReader) Scanner () *sql.Rows{
+  return r.rows
+}
+
+/**
+ * Closes the rows and the database. Safe to call if Open failed.
+ */
+func (r *SqliteReader) Close () {
+  if r.rows != nil {
+    r.rows.Close ()
+    r.rows = nil
+  }
+  if r.database != nil {
+    r.database.Close ()
+    r.database = nil
+  }
+}
+
+/**
+ * Reads the annotations of bdrmapit: the AS of each address, the AS of each router, the router of
+ * each address, and the conn_asn of the addresses on inter-AS links (see add_annotation).
+ * Returns an error (naming the file) if the file cannot be read entirely.
+ */
+func ReadSqlite (filename string) (*SafeSet, *SafeSet, *SafeSet, *SafeSet, error){
+  reader := NewSqliteReader (filename)
+  defer reader.Close ()
+  if err := reader.Open (); err != nil {
+    return nil, nil, nil, nil, err
+  }
+  rows := reader.Scanner ()
+
+  columns, err := rows.Columns ()
+  if err != nil {
+    return nil, nil, nil, nil, fmt.Errorf ("[ReadSqlite]: %s: %v", filename, err)
+  }
+  nb_columns := len (columns)
+  if nb_columns != 10 && nb_columns != 8 {
+    return nil, nil, nil, nil, fmt.Errorf ("[ReadSqlite]: %s: wrong file format (%d columns)", filename, nb_columns)
+  }
+
+  addr_to_asn := create_safeset ()
+  router_to_asn := create_safeset ()
+  addr_to_router := create_safeset ()
+  addr_to_conn_asn := create_safeset ()
+
+  var addr string
+  var router string
+  var asn int
+  var org string
+  var conn_asn int
+  var conn_org string 
+  var rtype int 
+  var itype int
+  var prouter string 
+  var pasn int 
+  // Attributes: addr - router - asn - org - conn_asn - conn_org - rtype - itype
+  // New attributes: addr - router - asn - org - conn_asn - conn_org - rtype - itype - prouter - pasn
+  // prouter is the preceding router
+  // pasn is the ASN attributed to this router
+  // pasn should always be equal to conn_asn, or there is something wrong somewhere
+  cnt, unreadable := 0, 0
+  for rows.Next() {
+    if nb_columns == 10 {
+      err = rows.Scan(&addr, &router, &asn, &org, &conn_asn, &conn_org, &rtype, &itype, &prouter, &pasn)
+    } else {
+      err = rows.Scan(&addr, &router, &asn, &org, &conn_asn, &conn_org, &rtype, &itype)
+    }
+    if err != nil { // e.g., NULL field
+      if unreadable == 0 {
+        log.Print ("[ReadSqlite]: WARNING: ", filename, ": ", err, ", row skipped")
+      }
+      unreadable++
+      continue
+    }
+
+    add_annotation (addr, router, asn, strconv.Itoa (conn_asn), addr_to_asn, router_to_asn, addr_to_router, addr_to_conn_asn)
+    if asn == -1 {
+      cnt++
+    }
+  }
+  if err := rows.Err (); err != nil {
+    return nil, nil, nil, nil, fmt.Errorf ("[ReadSqlite]: %s: %v", filename, err)
+  }
+  log.Println ("There are", cnt, "addresses for which an AS wasn't found.")
+  if unreadable != 0 {
+    log.Println ("There are", unreadable, "rows that could not be read (skipped).")
+  }
+  return addr_to_asn, router_to_asn, addr_to_router, addr_to_conn_asn, nil
+}
+
+/**
+ * Records the AS and the router of an address annotated by bdrmapit. Its conn_asn is recorded
+ * only if it is known and differs from its AS (the address is on an inter-AS link), to keep the set small.
+ */
+func add_annotation (addr, router string, asn int, conn_asn string, addr_to_asn, router_to_asn, addr_to_router, addr_to_conn_asn *SafeSet) {
+  addr_to_asn.unsafe_add (addr, strconv.Itoa (asn))
+  if conn_asn != "" && conn_asn != "-1" && conn_asn != "0" && conn_asn != strconv.Itoa (asn) {
+    addr_to_conn_asn.unsafe_add (addr, conn_asn)
+  }
+  m := re_ip.FindStringSubmatch (router)
+  if m == nil { // We check field 'router' is not an IP address, in which case it means this address wasn't matched to a router.
+    router_to_asn.unsafe_add (router, strconv.Itoa (asn))
+    addr_to_router.unsafe_add (addr, router)
+  } else {
+    addr_to_router.unsafe_add (addr, "")
+  }
+}
+
+/**
+ * Reads the bdrmapit annotations, either from the sqlite database of bdrmapit, or from
+ * a CSV file (.csv or .csv.gz) with the same columns. Both give the same sets for the same annotations.
+ */
+func ReadAnnotations (path string) (*SafeSet, *SafeSet, *SafeSet, *SafeSet, error) {
+  if strings.HasSuffix (path, ".csv") || strings.HasSuffix (path, ".csv.gz") {
+    return ReadAnnotationsCsv (path)
+  }
+  return ReadSqlite (path) // .sqlite, .db, or any other name, as before
+}
+
+/**
+ * Reads bdrmapit annotations from a CSV file, possibly gzip compressed.
+ * The columns are those of the annotation table (addr, router, asn, org, conn_asn, ...): if the first
+ * line is a header, the columns addr, router, asn and conn_asn are found by name (in any order, conn_asn
+ * being optional), otherwise they are the first three and the fifth.
+ * Lines starting with '#' are ignored. The file is read as it is parsed.
+ */
+func ReadAnnotationsCsv (filename string) (*SafeSet, *SafeSet, *SafeSet, *SafeSet, error) {
+  reader := NewCompressedReader (filename)
+  if err := reader.Open (); err != nil {
+    return nil, nil, nil, nil, err
+  }
+  defer reader.Close ()
+  records := csv.NewReader (reader.decompressed)
+  records.Comment = '#'
+  records.FieldsPerRecord = -1 // The columns after asn are not used
+  records.ReuseRecord = true
+
+  addr_to_asn := create_safeset ()
+  router_to_asn := create_safeset ()
+  addr_to_router := create_safeset ()
+  addr_to_conn_asn := create_safeset ()
+
+  col_addr, col_router, col_asn, col_conn_asn := 0, 1, 2, 4
+  first := true
+  cnt, unreadable := 0, 0
+  for {
+    record, err := records.Read ()
+    if err == io.EOF {
+      break
+    }
+    if err != nil {
+      if _, parse_error := err.(*csv.ParseError); !parse_error {
+        return nil, nil, nil, nil, fmt.Errorf ("[ReadAnnotationsCsv]: %s: %v", filename, err)
+      }
+      if unreadable == 0 {
+        log.Print ("[ReadAnnotationsCsv]: WARNING: ", filename, ": ", err, ", line skipped")
+      }
+      unreadable++
+      continue
+    }
+    if first {
+      first = false
+      if header := column_indexes (record); header != nil {
+        var ok bool
+        if col_addr, ok = header["addr"]; !ok {
+          return nil, nil, nil, nil, fmt.Errorf ("[ReadAnnotationsCsv]: %s: no addr column in header", filename)
+        }
+        if col_router, ok = header["router"]; !ok {
+          return nil, nil, nil, nil, fmt.Errorf ("[ReadAnnotationsCsv]: %s: no router column in header", filename)
+        }
+        if col_asn, ok = header["asn"]; !ok {
+          return nil, nil, nil, nil, fmt.Errorf ("[ReadAnnotationsCsv]: %s: no asn column in header", filename)
+        }
+        if col_conn_asn, ok = header["conn_asn"]; !ok {
+          col_conn_asn = -1
+        }
+        continue
+      }
+    }
+    if col_addr >= len (record) || col_router >= len (record) || col_asn >= len (record) {
+      unreadable++
+      continue
+    }
+    asn, err := strconv.Atoi (strings.TrimSpace (record[col_asn]))
+    if err != nil { // e.g., empty field (NULL in the database)
+      if unreadable == 0 {
+        log.Print ("[ReadAnnotationsCsv]: WARNING: ", filename, ": ", err, ", line skipped")
+      }
+      unreadable++
+      continue
+    }
+    conn_asn := ""
+    if col_conn_asn >= 0 && col_conn_asn < len (record) {
+      conn_asn = strings.TrimSpace (record[col_conn_asn])
+    }
+    add_annotation (strings.TrimSpace (record[col_addr]), strings.TrimSpace (record[col_router]), asn, conn_asn, addr_to_asn, router_to_asn, addr_to_router, addr_to_conn_asn)
+    if asn == -1 {
+      cnt++
+    }
+  }
+  log.Println ("There are", cnt, "addresses for which an AS wasn't found.")
+  if unreadable != 0 {
+    log.Println ("There are", unreadable, "lines that could not be read (skipped).")
+  }
+  return addr_to_asn, router_to_asn, addr_to_router, addr_to_conn_asn, nil
+}
+
+/**
+ * Returns the index of each column of a CSV header, or nil if the record is not a header
+ * (none of its fields is named "addr").
+ */
+func column_indexes (record []string) map[string]int {
+  columns := make (map[string]int, len (record))
+  for i, name := range record {
+    columns[strings.TrimSpace (name)] = i
+  }
+  if _, header := columns["addr"]; !header {
+    return nil
+  }
+  return columns
+}
+
+/* ------------------------------------------------------- *\
+ *               Compressed File Reader
+\* ------------------------------------------------------- */
+
+type CompressedReader struct{
+  filename string;
+  fp io.ReadCloser;
+  decompressed io.Reader;
+  to_close io.ReadCloser; // All because bzip2.Reader has no Close method --'
+}
+
+func NewCompressedReader (filename string) *CompressedReader {
+  return &CompressedReader{
+    filename: filename,
+  }
+}
+
+/**
+ * Opens the file. A .gz file must start with a valid gzip header (an empty file is an error);
+ * the errors found later in a compressed stream (truncated .gz or .bz2) are reported by scanner.Err ().
+ */
+func (r *CompressedReader) Open () error {
+  var err error
+  r.fp, err = os.Open(r.filename) // Read only
+  if err != nil {
+    r.fp = nil
+    return errors.New ("[CompressedReader]: " + err.Error() + " " + r.filename)
+  }
+
+  if strings.HasSuffix(r.filename, ".gz") {
+    gz, err := gzip.NewReader (r.fp)
+    if err != nil {
+      r.fp.Close ()
+      r.fp = nil
+      return errors.New ("[CompressedReader]: gzip: " + err.Error() + " " + r.filename)
+    }
+    r.to_close = gz
+    r.decompressed = r.to_close
+  } else if strings.HasSuffix (r.filename, ".bz2"){
+    r.decompressed = bzip2.NewReader (r.fp)
+  } else {
+    r.decompressed = r.fp
+  }
+  return nil
+}
+
+func (r *CompressedReader) Scanner () *bufio.Scanner {
+  return new_scanner (r.decompressed, default_max_line)
+}
+
+/**
+ * Closes the file. Safe to call if Open failed, or twice.
+ */
+func (r *CompressedReader) Close () {
+  if r.to_close != nil {
+    r.to_close.Close ()
+    r.to_close = nil
+  }
+  if r.fp != nil {
+    r.fp.Close ()
+    r.fp = nil
+  }
+}
+
+// Longest line accepted by the scanners (a merged overlay group is a single, possibly huge, line)
+const default_max_line = 8 * 1024 * 1024
+
+/**
+ * Returns a Scanner of the lines of r, accepting lines up to max_line bytes (bufio's default is 64 KiB).
+ * A longer line stops the scan with bufio.ErrTooLong: check scanner.Err () after the loop.
+ */
+func new_scanner (r io.Reader, max_line int) *bufio.Scanner {
+  scanner := bufio.NewScanner (r)
+  scanner.Buffer (make ([]byte, 0, 64 * 1024), max_line)
+  return scanner
+}
+
+/**
+ * Returns filename, or filename.gz if only the compressed file exists (see -compress of ribs_multi).
+ */
+func plain_or_gz (filename string) string {
+  if _, err := os.Stat (filename); err != nil {
+    if _, err := os.Stat (filename + ".gz"); err == nil {
+      return filename + ".gz"
+    }
+  }
+  return filename
+}
+
+/**
+ * Returns filename.gz if the outputs are compressed (g_args.compress), filename otherwise.
+ */
+func output_filename (filename string) string {
+  if g_args.compress {
+    return filename + ".gz"
+  }
+  return filename
+}
+
+/* ------------------------------------------------------- *\
+ *               Compressed File Writer
+\* ------------------------------------------------------- */
+
+/**
+ * Counterpart of CompressedReader: the file is gzip-compressed if its name ends with ".gz".
+ * Writes go through the embedded bufio.Writer, whose errors are sticky.
+ * Close flushes the buffer, then the gzip stream, then closes the file: if any of them fails
+ * (or Abort was called), the file is removed (or truncated back to its size before Open, when
+ * appending), so that no truncated file is left behind.
+ */
+type CompressedWriter struct{
+  filename string;
+  append bool; // Append to the file (a new gzip member for a compressed file, read as one stream)
+  size int64;  // Size of the file before Open (append)
+  fp *os.File;
+  gz *gzip.Writer;
+  aborted bool;
+  *bufio.Writer
+}
+
+func NewCompressedWriter (filename string, append bool) *CompressedWriter {
+  return &CompressedWriter{
+    filename: filename,
+    append: append,
+  }
+}
+
+func (w *CompressedWriter) Open () error {
+  var err error
+  if w.append {
+    w.fp, err = os.OpenFile (w.filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+    if err == nil {
+      var info os.FileInfo
+      if info, err = w.fp.Stat (); err == nil {
+        w.size = info.Size ()
+      } else {
+        w.fp.Close ()
+      }
+    }
+  } else {
+    w.fp, err = os.Create (w.filename) // If the file already exists, it is truncated
+  }
+  if err != nil {
+    return errors.New ("[CompressedWriter]: " + err.Error() + " " + w.filename)
+  }
+
+  if strings.HasSuffix (w.filename, ".gz") {
+    w.gz = gzip.NewWriter (w.fp)
+    w.Writer = bufio.NewWriter (w.gz)
+  } else {
+    w.Writer = bufio.NewWriter (w.fp)
+  }
+  return nil
+}
+
+/**
+ * Marks the file as failed: Close removes it.
+ */
+func (w *CompressedWriter) Abort () {
+  w.aborted = true
+}
+
+func (w *CompressedWriter) Close () error {
+  err := w.Writer.Flush ()
+  if w.gz != nil {
+    if gz_err := w.gz.Close (); err == nil {
+      err = gz_err
+    }
+  }
+  if close_err := w.fp.Close (); err == nil {
+    err = close_err
+  }
+  if err == nil && w.aborted {
+    err = errors.New ("aborted")
+  }
+  if err != nil {
+    if w.append {
+      os.Truncate (w.filename, w.size)
+      return errors.New ("[CompressedWriter]: " + err.Error() + ", " + w.filename + " left as before")
+    }
+    os.Remove (w.filename)
+    return errors.New ("[CompressedWriter]: " + err.Error() + ", " + w.filename + " removed")
+  }
+  return nil
+}
+
+/* ------------------------------------------------------- *\
+ *                          Misc.
+\* ------------------------------------------------------- */
+
+func read_whitespace_delimited_file (filename string) ([]string, error) {
+  r := NewCompressedReader (filename)
+  err := r.Open ()
+  if err != nil {
+    return []string{}, err
+  }
+  scanner := r.Scanner ()
+  defer r.Close ()
+
+  scanner.Scan ()
+  line := scanner.Text ()
+  if err := scanner.Err (); err != nil {
+    return []string{}, fmt.Errorf ("%s: %v", filename, err)
+  }
+
+  return strings.Fields (line), nil
+}
+
+/**
+ * Returns the lines of nexline-delimited file, selecting the corresponding field.
+ */
+func read_newline_delimited_file (filename string, field int) ([]string, error) {
+  r := NewCompressedReader (filename)
+  err := r.Open ()
+  if err != nil {
+    return []string{}, err
+  }
+  scanner := r.Scanner ()
+  defer r.Close ()
+
+  s := make ([]string, 0, 43)
+  for scanner.Scan () {
+    s = append (s, strings.Fields (scanner.Text ())[field])
+  }
+  if err := scanner.Err (); err != nil {
+    return []string{}, fmt.Errorf ("%s: %v", filename, err)
+  }
+  return s, nil
+}
+
+/**
+ * Returns a slice of the source IP addresses of the different VPs.
+ * File format: VP_name source_IP AS
+ */
+func read_vps_file (filename string) ([]string, error) {
+  return read_newline_delimited_file (filename, 1)
+}
+
+/**
+ * Reads the collector of each VP (format: VP_IP collector, one VP per line).
+ */
+func read_vp_collectors (filename string) (map[string]string, error) {
+  r := NewCompressedReader (filename)
+  if err := r.Open (); err != nil {
+    return nil, err
+  }
+  scanner := r.Scanner ()
+  defer r.Close ()
+
+  m := make (map[string]string)
+  for line := 1; scanner.Scan (); line++ {
+    fields := strings.Fields (scanner.Text ())
+    if len (fields) == 0 || strings.HasPrefix (fields[0], "#") {
+      continue
+    }
+    if len (fields) != 2 {
+      return nil, fmt.Errorf ("%s:%d: expected 'VP_IP collector', got %q", filename, line, scanner.Text ())
+    }
+    m[fields[0]] = fields[1]
+  }
+  if err := scanner.Err (); err != nil {
+    return nil, fmt.Errorf ("%s: %v", filename, err)
+  }
+  return m, nil
+}
+
+/**
+ * Given a collector file containg all its overlays (overlays are new-line separated,
+ * prefixes in an overlay are white-space separated):
+ *
+ * ex:
+ *    ip1 ip2 ip3 ip4
+ *    ip5 ip6
+ *
+ * Build the following map:
+ *
+ * ip1 -> (ip1, ip2, ip3, ip4)
+ * ip2 -> (ip1, ip2, ip3, ip4)
+ * ip3 -> (ip1, ip2, ip3, ip4)
+ * ip4 -> (ip1, ip2, ip3, ip4)
+ * ip5 -> (ip5, ip6)
+ * ip6 -> (ip5, ip6)
+ *
+ * In the map returned, there are as many keys as there are prefixes in the file.
+ * There are as many values as there are overlays in the file (not copies)
+ */
+func read_overlay_file (filename string) map[string]map[string]interface{} {
+  r := NewCompressedReader (plain_or_gz (filename))
+  if err := r.Open (); err != nil {
+    log.Fatal ("[read_overlay_file]: ", err)
+  }
+  scanner := r.Scanner ()
+  defer r.Close ()
+
+  m := make (map[string]map[string]interface{})
+  for scanner.Scan () {
+    overlays := strings.Fields (scanner.Text ())
+    overlays_map := slice_to_map (overlays)
+
+    for _, overlay := range overlays {
+      m[overlay] = overlays_map
+    }
+  }
+  if err := scanner.Err (); err != nil {
+    log.Print ("[read_overlay_file]: WARNING: ", r.filename, ": ", err, ", overlays read so far kept")
+  }
+  return m
+}
+
+/**
+ * Reads a nextAS file in the format:
+ *   prefix next_AS
+ * and returns a prefix to next-AS mapping and a next-AS to prefixes mapping.
+ */
+func read_nextAS_file (filename string) (map[string]string, map[string]map[string]interface{}) {
+  r := NewCompressedReader (filename)
+  if err := r.Open (); err != nil {
+    log.Fatal ("[read_nextAS_file]: ", err)
+  }
+  scanner := r.Scanner ()
+  defer r.Close ()
+
+  prefix_to_nextAS := make (map[string]string)
+  nextAS_to_prefixes := make (map[string]map[string]interface{})
+
+  for scanner.Scan () {
+    line := strings.Fields (scanner.Text ())
+    prefix_to_nextAS[line[0]] = line[1]
+    append_prefix (&nextAS_to_prefixes, line[1], line[0])
+  }
+  if err := scanner.Err (); err != nil {
+    log.Print ("[read_nextAS_file]: WARNING: ", filename, ": ", err, ", next-hop ASes read so far kept")
+  }
+  return prefix_to_nextAS, nextAS_to_prefixes
+}
+
+/**
+ * Returns the source and the destination of the first line of a trace ("... from <source> to <dest> ...").
+ * Both must be literal IPv4 addresses (hostnames are not resolved, IPv6 is not supported):
+ * otherwise, returns false and the trace must be skipped.
+ */
+func get_source_dest (line string) (source, dest string, ok bool) {
+  tokens := strings.Fields (line)
+  for i, token := range tokens {
+    if token == "to" && i > 0 && i+1 < len (tokens) {
+      source, dest = tokens[i-1], tokens[i+1]
+      break
+    }
+  }
+  return source, dest, is_ipv4_literal (source) && is_ipv4_literal (dest)
+}
+
+
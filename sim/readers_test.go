@@ -0,0 +1,234 @@
+package sim
+
+import (
+    "os"
+    "path/filepath"
+    "strconv"
+    "strings"
+    "testing"
+    )
+
+/**
+ * Trace A - private - B, A and B in different ASes, then B - C one hop further.
+ */
+func private_hop_trace () *Trace {
+    trace := NewTrace ()
+    trace.hops = append (trace.hops,
+        Hop{addr: "192.0.2.1", asn: "100", probe_ttl: 1, rtt: 1},
+        Hop{addr: "10.0.0.1", asn: "-1", probe_ttl: 2, rtt: 2, private: true},
+        Hop{addr: "198.51.100.1", asn: "200", probe_ttl: 3, rtt: 3},
+        Hop{addr: "198.51.100.2", asn: "200", probe_ttl: 4, rtt: 4})
+    return trace
+}
+
+func TestHopDistancePrivate (t *testing.T) {
+    hops := private_hop_trace ().hops
+    if j := next_public_hop (hops, 0); j != 2 {
+        t.Fatalf ("next public hop of A: %d, want 2", j)
+    }
+    if d := hop_distance (hops, 0, 2); d != 2 {
+        t.Errorf ("A priv B: distance %d, want 2 (the TTL distance)", d)
+    }
+    if d := hop_distance (hops, 2, 3); d != 1 {
+        t.Errorf ("B C: distance %d, want 1", d)
+    }
+}
+
+func TestCommitTracePrivateHop (t *testing.T) {
+    p := &trace_parser{cfg: &Config{Loops: loops_truncate, Border: border_asn}, keep_trace: get_duplicate_policy ("keep_last", nil)}
+    traces, vp_traces, adjs, multi_adjs, target_to_vp := create_sharded_set (), create_sharded_set (), create_sharded_set (), create_sharded_set (), create_sharded_set ()
+    trace := private_hop_trace ()
+    p.commit_trace ("203.0.113.1", "198.51.100.2", trace, traces, vp_traces, adjs, multi_adjs, target_to_vp)
+
+    if adjs.contains ("192.0.2.1_198.51.100.1") {
+        t.Error ("A priv B counted as a direct adjacency")
+    }
+    if !multi_adjs.contains ("192.0.2.1_198.51.100.1") {
+        t.Error ("A priv B not counted as a multi-hop adjacency")
+    }
+    if !adjs.contains ("198.51.100.1_198.51.100.2") {
+        t.Error ("B C not counted as an adjacency")
+    }
+    for _, key := range []string{"192.0.2.1_10.0.0.1", "10.0.0.1_198.51.100.1"} {
+        if adjs.contains (key) || multi_adjs.contains (key) {
+            t.Errorf ("%s: the private hop is an adjacency end", key)
+        }
+    }
+
+    /* --- The AS border is still detected across the private hop --- */
+    kept, _ := traces.get ("198.51.100.0/24")
+    hops := kept.(*Trace).hops
+    if !hops[0].egress || !hops[2].ingress || hops[1].ingress || hops[1].egress {
+        t.Errorf ("border across the private hop: %v", hops)
+    }
+}
+
+/**
+ * A source or destination that is not an IPv4 address: nothing is committed, not even the adjacencies.
+ */
+func TestCommitTraceInvalidEnds (t *testing.T) {
+    p := &trace_parser{cfg: &Config{Loops: loops_truncate, Border: border_asn}, keep_trace: get_duplicate_policy ("keep_last", nil)}
+    for _, ends := range [][2]string{{"vp.example.net", "198.51.100.2"}, {"203.0.113.1", "2001:db8::2"}, {"203.0.113.1", ""}} {
+        traces, vp_traces, adjs, multi_adjs, target_to_vp := create_sharded_set (), create_sharded_set (), create_sharded_set (), create_sharded_set (), create_sharded_set ()
+        trace := private_hop_trace ()
+        if p.commit_trace (ends[0], ends[1], trace, traces, vp_traces, adjs, multi_adjs, target_to_vp) {
+            t.Errorf ("%q to %q: skipped trace reported as looped", ends[0], ends[1])
+        }
+        for _, set := range []*ShardedSet{traces, vp_traces, adjs, multi_adjs, target_to_vp} {
+            if keys := set.merge ().Keys (); len (keys) != 0 {
+                t.Errorf ("%q to %q: %v committed", ends[0], ends[1], keys)
+            }
+        }
+        if trace.hops[0].egress || trace.hops[2].ingress {
+            t.Errorf ("%q to %q: borders set on a skipped trace", ends[0], ends[1])
+        }
+    }
+}
+
+func TestProcessTracePrivateHop (t *testing.T) {
+    trace := private_hop_trace ()
+    trace.hops[0].asn = "200" // The whole trace in the AS of interest
+    discovered_adjs, discovered_multi_adjs, discovered_addresses, discovered_routers, in_progress := create_safeset (), create_safeset (), create_safeset (), create_safeset (), create_safeset ()
+    process_trace (trace, "200", discovered_adjs, discovered_multi_adjs, discovered_addresses, discovered_routers, in_progress, 2, border_asn, "198.51.100.0/24", 0, nil)
+    if discovered_adjs.contains ("192.0.2.1_198.51.100.1") || !discovered_multi_adjs.contains ("192.0.2.1_198.51.100.1") {
+        t.Errorf ("A priv B: adjs %v, multi-hop adjs %v", discovered_adjs.Keys (), discovered_multi_adjs.Keys ())
+    }
+    if discovered_addresses.contains ("10.0.0.1") {
+        t.Error ("the private address is discovered")
+    }
+}
+
+/**
+ * Puts on the PATH an sc_tnt running the shell script (its input, the warts file, is discarded),
+ * and returns a warts file to parse.
+ */
+func fake_sc_tnt (t *testing.T, script string) string {
+    t.Helper ()
+    bin := t.TempDir ()
+    if err := os.WriteFile (filepath.Join (bin, "sc_tnt"), []byte ("#!/bin/sh\ncat > /dev/null\n" + script), 0755); err != nil {
+        t.Fatal (err)
+    }
+    t.Setenv ("PATH", bin + string (os.PathListSeparator) + os.Getenv ("PATH"))
+    file := filepath.Join (t.TempDir (), "trace.warts")
+    if err := os.WriteFile (file, []byte ("warts"), 0644); err != nil {
+        t.Fatal (err)
+    }
+    return file
+}
+
+/**
+ * A warts file whose decoded text cannot be scanned (here, a line longer than default_max_line) is
+ * counted as failed, even though sc_tnt exits successfully.
+ */
+func TestWartsParserScannerError (t *testing.T) {
+    file := fake_sc_tnt (t, "head -c " + strconv.Itoa (default_max_line + 1) + " /dev/zero | tr '\\\\0' x\necho\n")
+    p := &trace_parser{cfg: &Config{Loops: loops_truncate, Border: border_asn, TraceFormat: trace_format_tnt}, keep_trace: get_duplicate_policy ("keep_last", nil)}
+    var skipped, private, looped, failed int64
+    parser := generate_warts_parser (p, create_sharded_set (), create_sharded_set (), create_sharded_set (), create_sharded_set (), create_sharded_set (), create_sharded_set (), &skipped, &private, &looped, &failed)
+    parser (file)
+    if failed != 1 {
+        t.Errorf ("%d files failed, want 1", failed)
+    }
+}
+
+func TestGetHopRTT (t *testing.T) {
+    for line, want := range map[string]float64 {
+        "3  192.0.2.1  12.345 ms": 12.345,
+        "3  192.0.2.1  0.5ms": 0.5,
+        "3  192.0.2.1": -1,
+        "3  192.0.2.1  * ms": -1,
+    } {
+        if rtt := get_hop_rtt (strings.Fields (line)); rtt != want {
+            t.Errorf ("%q: %v, want %v", line, rtt, want)
+        }
+    }
+}
+
+/**
+ * The entry RTT of an AS is the minimum over its first segment only; a hop without RTT is ignored.
+ */
+func TestEntryRTT (t *testing.T) {
+    trace := NewTrace ()
+    trace.hops = append (trace.hops,
+        Hop{addr: "192.0.2.1", asn: "100", probe_ttl: 1, rtt: 5},
+        Hop{addr: "198.51.100.1", asn: "200", probe_ttl: 2, rtt: -1},
+        Hop{addr: "198.51.100.2", asn: "200", probe_ttl: 3, rtt: 9},
+        Hop{addr: "198.51.100.3", asn: "200", probe_ttl: 4, rtt: 7},
+        Hop{addr: "10.0.0.1", asn: "-1", probe_ttl: 5, rtt: 1, private: true},
+        Hop{addr: "203.0.113.1", asn: "300", probe_ttl: 6, rtt: 11},
+        Hop{addr: "198.51.100.4", asn: "200", probe_ttl: 7, rtt: 2}) // Second segment in AS 200
+    trace.compute_entry_rtts ()
+    for as, want := range map[string]float64{"100": 5, "200": 7, "300": 11, "400": -1} {
+        if rtt := trace.entry_rtt (as); rtt != want {
+            t.Errorf ("AS %s: %v, want %v", as, rtt, want)
+        }
+    }
+    if rtt := interest_rtt (trace, []string{"300", "200"}); rtt != 7 {
+        t.Errorf ("interest RTT: %v, want 7", rtt)
+    }
+    if rtt := interest_rtt (nil, []string{"200"}); rtt != -1 {
+        t.Errorf ("interest RTT without trace: %v", rtt)
+    }
+}
+
+func TestKeepLowestRTT (t *testing.T) {
+    trace := func (rtt float64) *Trace {
+        trace := NewTrace ()
+        trace.hops = append (trace.hops, Hop{addr: "192.0.2.1", asn: "100", probe_ttl: 1, rtt: rtt})
+        trace.compute_entry_rtts ()
+        return trace
+    }
+    replace := get_duplicate_policy ("keep_lowest_rtt", []string{"100"})
+    if !replace (trace (10), trace (5)) || replace (trace (5), trace (10)) {
+        t.Error ("the trace with the lowest RTT is not kept")
+    }
+    if replace (trace (5), trace (-1)) || !replace (trace (-1), trace (8)) {
+        t.Error ("a trace without RTT to the AS of interest wins")
+    }
+}
+
+func TestGetSourceDest (t *testing.T) {
+    for _, c := range []struct {
+        line, source, dest string;
+        ok bool;
+    } {
+        {"traceroute from 192.0.2.1 to 198.51.100.7", "192.0.2.1", "198.51.100.7", true},
+        {"trace [paris-udp] from 192.0.2.1 to 198.51.100.7 , 30 hops max", "192.0.2.1", "198.51.100.7", true},
+        {"traceroute from vp1.example.net to 198.51.100.7", "vp1.example.net", "198.51.100.7", false}, // Hostname
+        {"traceroute from 192.0.2.1 to target.example.net", "192.0.2.1", "target.example.net", false},
+        {"traceroute from 2001:db8::1 to 2001:db8:1::7", "2001:db8::1", "2001:db8:1::7", false},         // IPv6
+        {"traceroute from ::ffff:192.0.2.1 to 198.51.100.7", "::ffff:192.0.2.1", "198.51.100.7", false}, // IPv4-mapped IPv6
+        {"traceroute from 192.0.2.1", "", "", false},
+    } {
+        source, dest, ok := get_source_dest (c.line)
+        if source != c.source || dest != c.dest || ok != c.ok {
+            t.Errorf ("%q: %q %q %v, want %q %q %v", c.line, source, dest, ok, c.source, c.dest, c.ok)
+        }
+    }
+}
+
+/**
+ * The traces from or to a hostname or an IPv6 address are skipped and counted, instead of being
+ * committed under a garbage /24.
+ */
+func TestWartsParserSkipsHostnameAndIPv6 (t *testing.T) {
+    file := fake_sc_tnt (t, "printf '" +
+        "traceroute from vp1.example.net to 198.51.100.7\\n 1  192.0.2.10  1.000 ms\\n\\n" +
+        "traceroute from 2001:db8::1 to 2001:db8:1::7\\n 1  2001:db8::a  1.000 ms\\n\\n" +
+        "traceroute from 192.0.2.1 to 198.51.100.7\\n 1  192.0.2.10  1.000 ms\\n 2  198.51.100.1  2.000 ms\\n\\n'\n")
+    p := &trace_parser{cfg: &Config{Loops: loops_truncate, Border: border_asn, TraceFormat: trace_format_tnt}, keep_trace: get_duplicate_policy ("keep_last", nil),
+        addr_to_asn: create_safeset (), addr_to_router: create_safeset (), addr_to_conn_asn: create_safeset ()}
+    traces, addresses := create_sharded_set (), create_sharded_set ()
+    var skipped, private, looped, failed int64
+    parser := generate_warts_parser (p, traces, create_sharded_set (), create_sharded_set (), create_sharded_set (), addresses, create_sharded_set (), &skipped, &private, &looped, &failed)
+    parser (file)
+    if skipped != 2 || failed != 0 {
+        t.Errorf ("%d traces skipped, %d files failed, want 2 and 0", skipped, failed)
+    }
+    if keys := traces.merge ().Keys (); len (keys) != 1 || keys[0] != "198.51.100.0/24" {
+        t.Errorf ("traces: %v, want 198.51.100.0/24 only", keys)
+    }
+    if addresses.contains ("2001:db8::a") || !addresses.contains ("198.51.100.1") {
+        t.Errorf ("addresses: %v", addresses.merge ().Keys ())
+    }
+}
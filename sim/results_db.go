@@ -0,0 +1,194 @@
+/* ==================================================================================== *\
+     results_db.go
+
+     SQLite output of the simulation:
+     --------------------------------
+     With -sqlite <results.db>, the results of the simulation are also written in a SQLite
+     database, so that many runs (strategies x thresholds x ASes) can be compared with SQL
+     queries instead of thousands of text files. The database can be shared by several runs:
+     each simulation of an AS adds a row to the table runs, and its discovery curve (the
+     same points as sorted_<output_file>_XX.txt) to the table curve:
+       runs (run_id, as_interest, strategy, scheduler, weight_function, threshold,
+             credit_mode, probes, started, finished, output_file)
+       curve (run_id, probe_index, adjs, multi_adjs, addresses, routers)
+     The AS workers send their results to a single goroutine, the only writer of the
+     database, which inserts the results waiting in its queue in a single transaction.
+\* ==================================================================================== */
+
+package sim
+
+import (
+    "database/sql"
+    "log"
+    "strconv"
+    "time"
+    )
+
+const results_db_schema = `
+CREATE TABLE IF NOT EXISTS runs (
+    run_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    as_interest TEXT NOT NULL,
+    strategy TEXT,
+    scheduler TEXT,
+    weight_function TEXT,
+    threshold REAL,
+    credit_mode TEXT,
+    probes INTEGER,
+    started TEXT,
+    finished TEXT,
+    output_file TEXT
+);
+CREATE TABLE IF NOT EXISTS curve (
+    run_id INTEGER NOT NULL REFERENCES runs (run_id),
+    probe_index INTEGER NOT NULL,
+    adjs REAL,
+    multi_adjs REAL,
+    addresses REAL,
+    routers REAL,
+    PRIMARY KEY (run_id, probe_index)
+);`
+
+const results_db_batch = 64 // Maximum number of simulations of ASes inserted in one transaction
+
+/**
+ * The simulation of an AS, as sent to the writer.
+ */
+type db_run struct {
+    as_interest string;
+    threshold float64;
+    credit_mode string;
+    probes int;
+    started, finished time.Time;
+    output_file string;
+    curve []DiscoveryPoint;
+}
+
+type ResultsDB struct {
+    db *sql.DB;
+    strategy, scheduler, weight_function string; // The same for all the ASes of the run
+    runs chan *db_run;
+    done chan struct{};
+}
+
+var results_db *ResultsDB // nil: no -sqlite
+
+/**
+ * Opens (or creates) the database, and starts its writer.
+ */
+func open_results_db (filename, strategy, scheduler, weight_function string) (*ResultsDB, error) {
+    db, err := sql.Open ("sqlite3", filename)
+    if err != nil {
+        return nil, err
+    }
+    if _, err := db.Exec (results_db_schema); err != nil {
+        db.Close ()
+        return nil, err
+    }
+    rdb := &ResultsDB{db: db, strategy: strategy, scheduler: scheduler, weight_function: weight_function, runs: make (chan *db_run, results_db_batch), done: make (chan struct{})}
+    go rdb.writer ()
+    return rdb, nil
+}
+
+/**
+ * Sends the results of the simulation of an AS to the writer (no-op without -sqlite).
+ */
+func (rdb *ResultsDB) record (run *db_run) {
+    if rdb == nil {
+        return
+    }
+    rdb.runs <- run
+}
+
+/**
+ * Inserts the runs received, those already waiting in the queue in the same transaction.
+ */
+func (rdb *ResultsDB) writer () {
+    defer close (rdb.done)
+    for run := range rdb.runs {
+        batch := []*db_run{run}
+        for pending := true; pending && len (batch) < results_db_batch; {
+            select {
+                case run, ok := <-rdb.runs:
+                    if !ok {
+                        pending = false
+                        break
+                    }
+                    batch = append (batch, run)
+                default:
+                    pending = false
+            }
+        }
+        if err := rdb.insert (batch); err != nil {
+            log.Print ("[results_db]: ", err)
+            summary_warning ("sqlite: " + err.Error ())
+        }
+    }
+}
+
+func (rdb *ResultsDB) insert (batch []*db_run) error {
+    tx, err := rdb.db.Begin ()
+    if err != nil {
+        return err
+    }
+    insert_run, err := tx.Prepare ("INSERT INTO runs (as_interest, strategy, scheduler, weight_function, threshold, credit_mode, probes, started, finished, output_file) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+    if err != nil {
+        tx.Rollback ()
+        return err
+    }
+    defer insert_run.Close ()
+    insert_point, err := tx.Prepare ("INSERT INTO curve (run_id, probe_index, adjs, multi_adjs, addresses, routers) VALUES (?, ?, ?, ?, ?, ?)")
+    if err != nil {
+        tx.Rollback ()
+        return err
+    }
+    defer insert_point.Close ()
+    for _, run := range batch {
+        res, err := insert_run.Exec (run.as_interest, rdb.strategy, rdb.scheduler, rdb.weight_function, run.threshold, run.credit_mode, run.probes,
+            run.started.Format (time.RFC3339Nano), run.finished.Format (time.RFC3339Nano), run.output_file)
+        if err != nil {
+            tx.Rollback ()
+            return err
+        }
+        run_id, err := res.LastInsertId ()
+        if err != nil {
+            tx.Rollback ()
+            return err
+        }
+        for _, point := range run.curve {
+            if _, err := insert_point.Exec (run_id, point.Probe, point.Adjs, point.MultiAdjs, point.Addresses, point.Routers); err != nil {
+                tx.Rollback ()
+                return err
+            }
+        }
+    }
+    return tx.Commit ()
+}
+
+/**
+ * Waits until the writer has inserted all the runs, and closes the database (no-op without -sqlite).
+ */
+func (rdb *ResultsDB) close () {
+    if rdb == nil {
+        return
+    }
+    close (rdb.runs)
+    <-rdb.done
+    if err := rdb.db.Close (); err != nil {
+        log.Print ("[results_db]: ", err)
+    }
+}
+
+/**
+ * Returns the curve with the precision of the text files (see write_result).
+ */
+func text_curve (curve []DiscoveryPoint) []DiscoveryPoint {
+    level := func (x float64) float64 {
+        rounded, _ := strconv.ParseFloat (strconv.FormatFloat (x, 'f', 4, 32), 64)
+        return rounded
+    }
+    rounded := make ([]DiscoveryPoint, len (curve))
+    for i, point := range curve {
+        rounded[i] = DiscoveryPoint{Probe: point.Probe, Adjs: level (point.Adjs), MultiAdjs: level (point.MultiAdjs), Addresses: level (point.Addresses), Routers: level (point.Routers)}
+    }
+    return rounded
+}
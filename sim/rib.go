@@ -0,0 +1,585 @@
+/* ============================================================= *\
+   rib.go
+
+   Functions to parse the RIBs from the RouteViews and
+   RIRs projects.
+\* ============================================================= */
+
+package sim
+
+import ("log"
+      "context"
+      "net"
+      "os"
+      "path/filepath"
+      "strings"
+      "strconv"
+      "io/ioutil"
+      "net/http"
+      "encoding/json"
+      "fmt"
+      "io"
+      "sync"
+      "time"
+      "sort"
+      pool "github.com/Emeline-1/pool")
+
+/** 
+ * Read RIB tables and count the numbr of prefixes per collector in order to determine
+ * which collectors are sound (see select_collectors)
+ */
+func count_ribs (output_filename, start, end string) {
+   set := create_safeset ()
+
+   /* --- With collectors --- */
+   log.Print ("Retrieving collectors... ")
+   collectors := broker_get_collectors ()
+   log.Print ("Done")
+   if collectors == nil {
+      return
+   }
+   
+   bgp_dump_counter := generate_dump_counter (set, start, end)
+   launch_pool_progress ("ribs", "collectors", ribs_jobs (), collectors, bgp_dump_counter)
+   if failed := get_failed_collectors (); len (failed) != 0 {
+      log.Print ("[count_ribs]: WARNING: collectors not counted (failed): ", strings.Join (failed, " "))
+   }
+
+   log.Print ("Writing to file")
+   log.Print ("Number of elements: " + strconv.Itoa (len (set.set)))
+   set.write_to_file (output_filename)
+}
+
+
+const default_min_entries = 800000 // A collector is sound if its table has at least this many entries
+
+type collector_count struct {
+   collector string;
+   entries int;
+}
+
+/**
+ * Reads the output of count_ribs ([collector nb_entries]).
+ */
+func read_collector_counts (filename string) ([]collector_count, error) {
+   reader := NewCompressedReader (filename)
+   if err := reader.Open (); err != nil {
+      return nil, err
+   }
+   defer reader.Close ()
+   counts := make ([]collector_count, 0)
+   scanner := reader.Scanner ()
+   for scanner.Scan () {
+      line := strings.Fields (scanner.Text ())
+      if len (line) == 0 {
+         continue
+      }
+      if len (line) < 2 {
+         return nil, fmt.Errorf ("%s: missing number of entries: %s", filename, scanner.Text ())
+      }
+      entries, err := strconv.Atoi (line[1])
+      if err != nil {
+         return nil, fmt.Errorf ("%s: bad number of entries: %s", filename, scanner.Text ())
+      }
+      counts = append (counts, collector_count{line[0], entries})
+   }
+   if err := scanner.Err (); err != nil {
+      return nil, fmt.Errorf ("%s: %v", filename, err)
+   }
+   return counts, nil
+}
+
+/**
+ * Splits the collectors in the sound ones (at least min_entries entries) and the rejected ones,
+ * both by decreasing number of entries.
+ */
+func split_collectors (counts []collector_count, min_entries int) ([]collector_count, []collector_count) {
+   sort.Slice (counts, func (i, j int) bool {
+      if counts[i].entries != counts[j].entries {
+         return counts[i].entries > counts[j].entries
+      }
+      return counts[i].collector < counts[j].collector
+   })
+   selected, rejected := make ([]collector_count, 0, len (counts)), make ([]collector_count, 0)
+   for _, count := range counts {
+      if count.entries >= min_entries {
+         selected = append (selected, count)
+      } else {
+         rejected = append (rejected, count)
+      }
+   }
+   return selected, rejected
+}
+
+/**
+ * Writes collector counts, one [collector nb_entries] per line.
+ */
+func write_collector_counts (filename string, counts []collector_count) {
+   w, file := new_bufio_writer (filename)
+   for _, count := range counts {
+      w.WriteString (count.collector + " " + strconv.Itoa (count.entries) + "\n")
+   }
+   w.Flush ()
+   file.Close ()
+}
+
+/**
+ * Selects the sound collectors (at least min_entries entries) from the output of count_ribs
+ * (counts_filename), or from a new count if counts_filename is empty (the counts are then
+ * kept in <output>_counts.txt).
+ * The sound collectors are written in output_filename ([collector nb_entries], by decreasing
+ * number of entries), which can be given as is to ribs_multi (-c), and the rejected ones
+ * in <output>_rejected.txt.
+ */
+func select_collectors (counts_filename, output_filename, start, end string, min_entries int) {
+   stem := trim_suffix (output_filename, ".txt")
+   if counts_filename == "" {
+      counts_filename = stem + "_counts.txt"
+      count_ribs (counts_filename, start, end)
+   }
+   counts, err := read_collector_counts (counts_filename)
+   if err != nil {
+      log.Fatal ("[select_collectors]: ", err)
+   }
+   selected, rejected := split_collectors (counts, min_entries)
+   write_collector_counts (output_filename, selected)
+   write_collector_counts (stem + "_rejected.txt", rejected)
+   log.Println ("[select_collectors]:", len (selected), "sound collectors (>=", min_entries, "entries),", len (rejected), "rejected (see", stem + "_rejected.txt)")
+}
+
+/**
+ * Returns the collectors of the file given to ribs_multi: one collector per line, optionally
+ * followed by its number of entries (output of count_ribs or select_collectors). With
+ * min_entries > 0, the collectors with fewer entries are skipped.
+ */
+func read_collectors_file (filename string, min_entries int) []string {
+   if min_entries <= 0 {
+      collectors,_ := read_newline_delimited_file (filename, 0)
+      return collectors
+   }
+   counts, err := read_collector_counts (filename)
+   if err != nil {
+      log.Fatal ("[read_collectors_file]: ", err)
+   }
+   selected, rejected := split_collectors (counts, min_entries)
+   for _, count := range rejected {
+      log.Println ("[read_collectors_file]: collector", count.collector, "skipped:", count.entries, "entries <", min_entries)
+      summary_unit ("collectors", unit_skipped)
+   }
+   collectors := make ([]string, 0, len (selected))
+   for _, count := range selected {
+      collectors = append (collectors, count.collector)
+   }
+   return collectors
+}
+
+/**
+ * Launch the multi parsing of the RIBs
+ */
+func parse_ribs (ases_interest_file, collectors_file, output_dir, start, end string, heuristic int) {
+   ases_interest,_ := read_whitespace_delimited_file (ases_interest_file)
+   make_ribs_dirs (output_dir)
+   summary_begin ("ribs_multi", g_args.summary_out)
+   summary_stage ("read_datasets")
+
+   /* --- Heuristic specific processing --- */
+   if heuristic == 1 {
+      as_neighbors = read_as_rel (g_args.as_rel_file)
+   }
+   if g_args.keep_records {
+      write_rib_parameters (output_dir, heuristic)
+   }
+
+   collectors_checkpoint_begin (output_dir, g_args.resume)
+   f := generate_RIB_parser  (ases_interest, output_dir, start, end, heuristic)
+   
+   collectors := read_collectors_file (collectors_file, g_args.min_entries)
+   if g_args.fetch_dir != "" { // Download the dumps, then read them as local MRT dumps
+      summary_stage ("fetch")
+      collectors = fetch_ribs (collectors, start, end, g_args.fetch_dir)
+      g_args.mrt_dir = g_args.fetch_dir
+   }
+   log.Println ("Collectors: ", len (collectors))
+   summary_stage ("parse")
+   launch_pool_progress ("ribs", "collectors", ribs_jobs (), collectors_to_parse (output_dir, collectors), f) // -resume: the collectors already parsed are skipped
+   write_failed_collectors (output_dir + "/failed_collectors.txt") // Coverage of the run (see collector_retry.go)
+
+   /* --- Post Processing (all RIBs have been parsed) --- */
+   summary_stage ("post_processing")
+   write_ases_used (output_dir, ases_interest)
+   gather_ribs (output_dir, collectors)
+   for _, artifact := range []string{"forwarding_tables", "next-hop_AS", "prev-hop_AS", "overlays/all_overlays.txt", "collectors/origin_ases.txt", "collectors/all_BGP_peers.txt", "collectors/add_path.txt", "ases_used.txt"} {
+      summary_artifact (output_dir + "/" + artifact)
+   }
+}
+
+/**
+ * Creates the directories of a ribs_multi output directory.
+ */
+func make_ribs_dirs (output_dir string) {
+   dirs := []string{"overlays", "forwarding_tables", "next-hop_AS", "prev-hop_AS", "collectors"}
+   if g_args.keep_records {
+      dirs = append (dirs, rib_records_dir)
+   }
+   for _, dir := range dirs {
+      if err := os.MkdirAll (output_dir + "/" + dir, 0755); err != nil {
+         log.Fatal (err)
+      }
+   }
+}
+
+/**
+ * Gathers the files of the collectors once all RIBs have been parsed: origin ASes, merged
+ * overlays, BGP peers and ADD-PATH counters.
+ */
+func gather_ribs (output_dir string, collectors []string) {
+   gather_collector_origins (output_dir, collectors).write_to_file (output_dir + "/collectors/origin_ases.txt") // Collectors of this run and of the previous ones (-resume)
+   build_merge_overlays (output_dir)
+
+   // Gather all collectors' peers into one file
+   gather_collector_files (output_dir, collectors, collector_peers_file, output_dir + "/collectors/all_BGP_peers.txt")
+   gather_collector_files (output_dir, collectors, collector_add_path_file, output_dir + "/collectors/add_path.txt")
+}
+
+/**
+ * Records the ASes of interest covered by the next-hop files of a ribs_multi output directory.
+ */
+func write_ases_used (output_dir string, ases_interest []string) {
+   if err := os.WriteFile (output_dir + "/ases_used.txt", []byte (strings.Join (ases_interest, " ") + "\n"), 0644); err != nil {
+      log.Print ("[write_ases_used]: ", err)
+   }
+}
+
+/**
+ * Adds ASes of interest to an existing ribs_multi output directory, without parsing the RIB dumps again:
+ * the records kept by ribs_multi -keep-records are replayed with the parameters of the run (see
+ * rib_records.go) and the ASes of interest of ases_used.txt followed by the new ones. The best route of
+ * a prefix may change (most_ases_interest tie-break), so all the files are written again, and are
+ * those of a full run on the ASes of interest.
+ * The files are written in <dir>/.add_as, then moved into dir, and ases_used.txt is updated last: an
+ * interrupted run leaves the ASes to be added again.
+ * - bdp_dir: if not empty, where to write the directed prefixes of the new ASes
+ */
+func add_ases_to_ribs (dir string, new_ases []string, bdp_dir string) {
+   ases_used,_ := read_whitespace_delimited_file (dir + "/ases_used.txt")
+   ases := make ([]string, 0, len (new_ases))
+   for _, as := range new_ases {
+      if find_index (ases_used, as) != -1 || find_index (ases, as) != -1 {
+         log.Println ("[add_ases_to_ribs]: AS", as, "already covered, skipped")
+         continue
+      }
+      ases = append (ases, as)
+   }
+   if len (ases) == 0 {
+      return
+   }
+
+   /* --- Parameters of the ribs_multi run --- */
+   directed_ipv6 := g_args.ipv6 // -6 of add_as, for the directed prefixes
+   heuristic, err := apply_rib_parameters (dir)
+   if err != nil {
+      log.Fatal ("[add_ases_to_ribs]: ", err, " (the best routes can only be selected again from the records of ribs_multi -keep-records)")
+   }
+   if heuristic == 1 {
+      as_neighbors = read_as_rel (g_args.as_rel_file)
+   }
+   collectors := snapshot_collectors (dir)
+   log.Println ("Collectors: ", len (collectors))
+
+   /* --- Replay of the records --- */
+   ases_interest := append (append ([]string{}, ases_used...), ases...)
+   tmp_dir := dir + "/.add_as"
+   if err := os.RemoveAll (tmp_dir); err != nil { // Left by an interrupted run
+      log.Fatal ("[add_ases_to_ribs]: ", err)
+   }
+   make_ribs_dirs (tmp_dir)
+   collectors_checkpoint_begin (tmp_dir, false)
+   g_args.records_dir = dir
+   launch_pool_progress ("ribs", "collectors", ribs_jobs (), collectors, generate_RIB_parser (ases_interest, tmp_dir, "", "", heuristic))
+   if failed := get_failed_collectors (); len (failed) != 0 {
+      log.Fatal ("[add_ases_to_ribs]: collectors failed: ", strings.Join (failed, " "), ", ", dir, " left as before")
+   }
+   gather_ribs (tmp_dir, collectors)
+
+   /* --- Move the files into dir --- */
+   if err := move_tree (tmp_dir, dir); err != nil {
+      log.Fatal ("[add_ases_to_ribs]: ", err, " (run add_as again)")
+   }
+   os.RemoveAll (tmp_dir)
+   write_ases_used (dir, ases_interest)
+   if bdp_dir != "" {
+      g_args.ipv6 = directed_ipv6
+      write_directed_prefixes (bdp_dir, ases, collectors, dir)
+   }
+}
+
+/**
+ * Moves the files of the source tree to the same paths under destination, replacing the existing files.
+ */
+func move_tree (source, destination string) error {
+   return filepath.Walk (source, func (path string, info os.FileInfo, err error) error {
+      if err != nil || info.IsDir () {
+         return err
+      }
+      relative, err := filepath.Rel (source, path)
+      if err != nil {
+         return err
+      }
+      target := filepath.Join (destination, relative)
+      if err := os.MkdirAll (filepath.Dir (target), 0755); err != nil {
+         return err
+      }
+      return os.Rename (path, target)
+   })
+}
+
+/* ------------------------------------------------- *\
+            Collectors operations
+\* ------------------------------------------------- */
+
+/* --- Download of the RIB dumps from the archives --- */
+
+const fetch_workers = 4 // Concurrent downloads (be nice to the archives)
+
+/**
+ * Returns the URL of the first RIB dump of the collector taken in the [start, end] interval
+ * (UNIX timestamps), and the name of the dump:
+ * - RIS (rrcXX): https://data.ris.ripe.net/<collector>/YYYY.MM/bview.YYYYMMDD.HHMM.gz, every 8 hours
+ * - RouteViews: http://archive.routeviews.org/[<collector>/]bgpdata/YYYY.MM/RIBS/rib.YYYYMMDD.HHMM.bz2,
+ *   every 2 hours (route-views2 is at the root of the archive)
+ */
+func rib_archive_url (collector, start, end string) (url, name string, err error) {
+   from, err := strconv.ParseInt (start, 10, 64)
+   if err != nil {
+      return "", "", fmt.Errorf ("invalid start timestamp %q", start)
+   }
+   to, err := strconv.ParseInt (end, 10, 64)
+   if err != nil {
+      return "", "", fmt.Errorf ("invalid end timestamp %q", end)
+   }
+
+   period, ris := int64 (2*3600), strings.HasPrefix (collector, "rrc")
+   if ris {
+      period = 8*3600
+   }
+   t := (from + period - 1)/period*period // First dump at or after start (dumps are aligned on midnight UTC)
+   if t > to {
+      return "", "", fmt.Errorf ("no RIB dump of %s between %s and %s (one every %dh)", collector, start, end, period/3600)
+   }
+   date := time.Unix (t, 0).UTC ()
+
+   if ris {
+      name = "bview." + date.Format ("20060102.1504") + ".gz"
+      return "https://data.ris.ripe.net/" + collector + "/" + date.Format ("2006.01") + "/" + name, name, nil
+   }
+   root := "http://archive.routeviews.org/" + collector + "/"
+   if collector == "route-views2" {
+      root = "http://archive.routeviews.org/"
+   }
+   name = "rib." + date.Format ("20060102.1504") + ".bz2"
+   return root + "bgpdata/" + date.Format ("2006.01") + "/RIBS/" + name, name, nil
+}
+
+var (
+   // The client of the downloads: the connection and the response headers are bounded in time, but not
+   // the whole download (a RIB dump takes minutes), see download_stall_timeout.
+   download_client = &http.Client{Transport: &http.Transport{
+      Proxy: http.ProxyFromEnvironment,
+      DialContext: (&net.Dialer{Timeout: 30*time.Second}).DialContext,
+      TLSHandshakeTimeout: 30*time.Second,
+      ResponseHeaderTimeout: time.Minute,
+   }}
+   download_stall_timeout = 2*time.Minute // No byte received for so long: the download is interrupted
+)
+
+/**
+ * Reads the body of a download, and cancels it once no byte was received for download_stall_timeout.
+ */
+type stall_reader struct {
+   body io.Reader;
+   timer *time.Timer;
+}
+
+func (r *stall_reader) Read (p []byte) (int, error) {
+   n, err := r.body.Read (p)
+   if n > 0 {
+      r.timer.Reset (download_stall_timeout)
+   }
+   return n, err
+}
+
+/**
+ * Downloads url into filename. The download is written in filename.part, resumed from there if a
+ * previous download was interrupted (or stalled, see download_stall_timeout), and only renamed to
+ * filename once its size matches the size announced by the server.
+ */
+func download_file (url, filename string) error {
+   part := filename + ".part"
+   offset := int64 (0)
+   if info, err := os.Stat (part); err == nil {
+      offset = info.Size ()
+   }
+
+   ctx, cancel := context.WithCancel (context.Background ())
+   defer cancel ()
+   stall := time.AfterFunc (download_stall_timeout, cancel)
+   defer stall.Stop ()
+   req, err := http.NewRequestWithContext (ctx, "GET", url, nil)
+   if err != nil {
+      return err
+   }
+   if offset > 0 {
+      req.Header.Set ("Range", "bytes=" + strconv.FormatInt (offset, 10) + "-")
+   }
+   resp, err := download_client.Do (req)
+   if err != nil {
+      return err
+   }
+   defer resp.Body.Close ()
+
+   flags := os.O_CREATE|os.O_WRONLY
+   switch resp.StatusCode {
+      case http.StatusPartialContent: // Resume
+         flags |= os.O_APPEND
+      case http.StatusOK: // Range not supported, or new download
+         flags |= os.O_TRUNC
+         offset = 0
+      case http.StatusRequestedRangeNotSatisfiable: // Stale partial download
+         os.Remove (part)
+         return fmt.Errorf ("%s: partial download does not match, restart", url)
+      default:
+         return fmt.Errorf ("%s: %s", url, resp.Status)
+   }
+   expected := int64 (-1)
+   if resp.ContentLength >= 0 {
+      expected = offset + resp.ContentLength
+   }
+
+   file, err := os.OpenFile (part, flags, 0644)
+   if err != nil {
+      return err
+   }
+   written, err := io.Copy (file, &stall_reader{body: resp.Body, timer: stall})
+   if cerr := file.Close (); err == nil {
+      err = cerr
+   }
+   if err != nil {
+      return fmt.Errorf ("%s: interrupted after %d bytes (resumed on the next run): %w", url, offset + written, err)
+   }
+   if expected >= 0 && offset + written != expected {
+      return fmt.Errorf ("%s: size mismatch: %d bytes instead of %d", url, offset + written, expected)
+   }
+   return os.Rename (part, filename)
+}
+
+/**
+ * Downloads the RIB dump of each collector taken in the [start, end] interval into cachedir/<collector>/
+ * (the layout of -mrt-dir), unless it is already there. A collector whose dump cannot be downloaded
+ * (e.g., 404) is skipped with a warning.
+ * Returns the collectors whose dump is available, in their original order.
+ */
+func fetch_ribs (collectors []string, start, end, cachedir string) []string {
+   var mux sync.Mutex
+   available := make (map[string]struct{}, len (collectors))
+
+   f := func (collector string) {
+      url, name, err := rib_archive_url (collector, start, end)
+      if err != nil {
+         log.Print ("[fetch_ribs]: WARNING: ", err, ", collector skipped")
+         return
+      }
+      dir := filepath.Join (cachedir, collector)
+      filename := filepath.Join (dir, name)
+      if _, err := os.Stat (filename); err != nil { // Not in the cache yet
+         if err := os.MkdirAll (dir, 0755); err != nil {
+            log.Print ("[fetch_ribs]: WARNING: ", err, ", collector skipped")
+            return
+         }
+         log.Print ("[fetch_ribs]: downloading ", url)
+         if err := download_file (url, filename); err != nil {
+            log.Print ("[fetch_ribs]: WARNING: ", err, ", collector ", collector, " skipped")
+            return
+         }
+      }
+      mux.Lock ()
+      available[collector] = struct{}{}
+      mux.Unlock ()
+   }
+   pool.Launch_pool (fetch_workers, collectors, f)
+
+   fetched := make ([]string, 0, len (available))
+   for _, collector := range collectors {
+      if _, ok := available[collector]; ok {
+         fetched = append (fetched, collector)
+      } else {
+         summary_unit ("collectors", unit_skipped)
+      }
+   }
+   log.Printf ("[fetch_ribs]: %d of %d collectors available in %s", len (fetched), len (collectors), cachedir)
+   return fetched
+}
+
+/**
+ * Queries the CAIDA Broker HTTP API to retrieve meta-data about data available from different data providers.
+ * More precisely, we query which collectors are available (ris and routeviews project only), and return them as a slice.
+ * In case of error, returns a nil slice.
+ */
+func broker_get_collectors () (collectors []string) {
+
+   /* --- Recover from panic in case the json has an unexpected format ---*/
+   defer func() {
+        if r := recover(); r != nil {
+            log.Print ("[broker_get_collectors]: Problem while parsing response JSON")
+            collectors = nil // With named return value, return value will be nil, which is what we expect.
+            return
+        }
+    }()
+
+   /* --- Query data broker --- */
+   resp, err := download_client.Get("https://broker.bgpstream.caida.org/v2/meta/collectors")
+   if err != nil {
+      log.Print ("[broker_get_collectors]: " + err.Error ())
+      return collectors //nil slice
+   } 
+   defer resp.Body.Close()
+   body, err := ioutil.ReadAll(resp.Body) //resp.Body is an io.ReadCloser
+   if err != nil {
+      log.Print ("[broker_get_collectors]: " + err.Error ())
+      return collectors //nil slice
+   }
+
+   /* --- Traverse the json --- */
+   var result map[string]interface{}
+   json.Unmarshal([]byte(body), &result)
+
+   object := result["data"]
+   result = object.(map[string]interface{}) // Type assertion (Acces to underlying data of the interface)
+   object = result["collectors"]
+   result = object.(map[string]interface{})
+
+   for collector, value := range result {
+      collector_data := value.(map[string]interface{})
+      project := collector_data["project"]
+      project_name := project.(string)
+      if project_name == "routeviews" || project_name == "ris"{
+         collectors = append (collectors, collector)
+      }
+   }
+   return collectors
+}
+
+/* --------------------------------------- *\
+ *             MISC.
+\* --------------------------------------- */
+
+/**
+ * Given a slice of collectors, assign a number to each one of them and return
+ * the corresponding mapping. 
+ */
+func assign_numbers (collectors []string) map[string]int {
+   m := make (map[string]int, len (collectors))
+   for i,c := range collectors {
+      m[c] = i
+   }
+   return m
+}
\ No newline at end of file
@@ -9,11 +9,14 @@
      -> Functions starting with 'analyse'
 \* ============================================================= */
 
-package main
+package sim
 
 import ("log"
       "strconv"
+      "os"
       "os/exec"
+      "path/filepath"
+      "sort"
       "strings"
       "fmt"
       graph "github.com/Emeline-1/basic_graph"
@@ -33,9 +36,17 @@ import ("log"
  * - dir: the directory where to find the parsing results of 'rib_multi'
  */
 func build_best_path_directed_probes (outdir, ases_file, collectors_file, dir string) {
+    summary_begin ("build_best_directed_probes", g_args.summary_out)
     collectors,_ := read_newline_delimited_file (collectors_file, 0)
     ases_interest,_ := read_whitespace_delimited_file (ases_file)
+    write_directed_prefixes (outdir, ases_interest, collectors, dir)
+}
 
+/**
+ * Writes the directed prefixes of the ASes of interest (directed_prefixes_<AS>.txt), from the
+ * next-hop files of the collectors (dir: the output directory of ribs_multi).
+ */
+func write_directed_prefixes (outdir string, ases_interest, collectors []string, dir string) {
     /* --- Data struct initialization --- */
     as_targets := make (map[string]map[string]interface{})
     for _, AS := range ases_interest {
@@ -43,24 +54,47 @@ func build_best_path_directed_probes (outdir, ases_file, collectors_file, dir st
     }
 
     /* --- Reading of forwarding table --- */
+    summary_stage ("read")
     for _, collector := range collectors {
-        file := dir + "/next-hop_AS/" + collector + "/next_hop_AS_" + collector + ".txt" // Forwarding table (format: prefix as_interest next_as)
+        file := plain_or_gz (dir + "/next-hop_AS/" + collector + "/next_hop_AS_" + collector + ".txt") // Forwarding table (format: prefix as_interest next_as)
 
         reader := NewCompressedReader (file)
-        reader.Open ()
+        if err := reader.Open (); err != nil {
+            log.Print ("[write_directed_prefixes]: WARNING: ", err, ", collector ", collector, " skipped")
+            summary_unit ("collectors", unit_failed)
+            continue
+        }
+        summary_unit ("collectors", unit_processed)
         scanner := reader.Scanner ()
         for scanner.Scan () {
             line := strings.Fields (scanner.Text ())
-            as_targets[line[1]][line[0]] = struct{}{} //Note: could keep track on which collector it was seen. Later maybe.
+            if !g_args.ipv6 && strings.Contains (line[0], ":") { // IPv6 prefix of a mixed table (see -6)
+                continue
+            }
+            if targets, ok := as_targets[line[1]]; ok { // The file may cover other ASes (see add_ases_to_ribs)
+                targets[line[0]] = struct{}{} //Note: could keep track on which collector it was seen. Later maybe.
+            }
+        }
+        if err := scanner.Err (); err != nil {
+            log.Print ("[write_directed_prefixes]: WARNING: ", file, ": ", err, ", prefixes read so far kept")
         }
         reader.Close ()
     }
 
     /* --- Write directed probes to file --- */
+    summary_stage ("write")
     for AS, targets := range as_targets {
-        s := create_safeset ()
-        s.set = targets
-        s.write_to_file (outdir + "/directed_prefixes_" + AS + ".txt")
+        prefixes := get_keys (&targets)
+        sort.Strings (prefixes) // The same order in the text file and in its sidecar, whatever the run
+        records := make ([]prefix_record, 0, len (prefixes))
+        for _, prefix := range prefixes {
+            records = append (records, prefix_record{prefix: prefix})
+        }
+        if err := write_prefix_records (outdir + "/directed_prefixes_" + AS + ".txt", records); err != nil {
+            log.Fatal (err)
+        }
+        summary_unit ("ASes", unit_processed)
+        summary_artifact (outdir + "/directed_prefixes_" + AS + ".txt")
     }
 }
 
@@ -106,25 +140,52 @@ func analyse_directed_probes_per_collector (ases_file, collectors_file, dir stri
  * - dir: the directory where to find the parsing results of 'rib_multi'
  */
 func build_merge_overlays (dir string) {
-    /* --- Get all files in dir --- */
-    overlay_files := pool.Get_directory_files (dir + "/overlays/")
-    if overlay_files == nil {
+    /* --- Get all files in dir, and in the directories of the peers of each collector (-per-peer) --- */
+    overlay_files := []string{}
+    err := filepath.Walk (dir + "/overlays", func (path string, info os.FileInfo, err error) error {
+        if err == nil && !info.IsDir () {
+            overlay_files = append (overlay_files, path)
+        }
+        return err
+    })
+    if err != nil {
+        log.Print ("[build_merge_overlays]: " + err.Error())
         return
     }
 
     /* --- Compute transitive closure of overlays thanks to graphs connected components --- */
     g := graph.New ()
-    for _, file := range *overlay_files {
+    prefixes := make (map[string]struct{})
+    for _, file := range overlay_files {
+        if strings.HasSuffix (file, "all_overlays.txt") { // Output of a previous merge
+            continue
+        }
         reader := NewCompressedReader (file)
-        reader.Open ()
+        if err := reader.Open (); err != nil {
+            log.Print ("[build_merge_overlays]: WARNING: ", err, ", file skipped")
+            continue
+        }
         scanner := reader.Scanner ()
+        rejected := 0
         for scanner.Scan () {
             overlays := strings.Fields (scanner.Text ())
-            for _, overlay := range overlays[1:] {
-                g.Add_edge (overlays[0], overlay)
+            if len (overlays) == 0 {
+                continue
             }
+            rejected += add_overlay_edges (g, overlays[0], overlays[1:])
+            for _, prefix := range overlays {
+                if _, valid := parse_overlay_prefix (prefix); valid {
+                    prefixes[prefix] = struct{}{}
+                }
+            }
+        }
+        if err := scanner.Err (); err != nil {
+            log.Print ("[build_merge_overlays]: WARNING: ", file, ": ", err, ", overlays read so far kept")
         }
         reader.Close ()
+        if rejected != 0 {
+            log.Println ("[build_merge_overlays]:", file + ": rejected", rejected, "overlay tokens")
+        }
     }
 
     /* --- Record merged overlays --- */
@@ -134,6 +195,7 @@ func build_merge_overlays (dir string) {
         connected_component := g.Connected_component ()
         overlays_closure.unsafe_add (connected_component[0], connected_component[1:])
     }
+    report_overlay_components (overlays_closure, len (prefixes), "build_merge_overlays")
     overlays_closure.write_to_file (dir + "/overlays/all_overlays.txt")
 }
 
@@ -203,7 +265,9 @@ func analyse_merged_overlays (all_overlay_file string, forwarding_tables []strin
 func _analyse_overlay (overlay_file string, nb_vp int) (int, int) {
     // Reading the overlays
     reader := NewCompressedReader (overlay_file)
-    reader.Open ()
+    if err := reader.Open (); err != nil {
+        log.Fatal ("[_analyse_overlay]: ", err)
+    }
     scanner := reader.Scanner ()
 
     reduction := 0 // the nb of prefixes you keep
@@ -213,6 +277,9 @@ func _analyse_overlay (overlay_file string, nb_vp int) (int, int) {
         total += nb_overlays
         reduction += min (nb_vp, nb_overlays)
     }
+    if err := scanner.Err (); err != nil {
+        log.Print ("[_analyse_overlay]: WARNING: ", overlay_file, ": ", err)
+    }
     reader.Close ()
     return reduction, total
 }
@@ -258,7 +325,10 @@ func build_overlays_per_AS (ases_file, all_overlays_file, directed_prefixes_dir,
 
     /* --- Loop over ASes of interest --- */
     for _, AS := range ases {
-        directed_prefixes,_ := read_newline_delimited_file (directed_prefixes_dir + "/directed_prefixes_"+AS+".txt", 0)
+        directed_prefixes, err := read_directed_prefixes (directed_prefixes_dir + "/directed_prefixes_"+AS+".txt")
+        if err != nil {
+            log.Fatal ("[build_overlays_per_AS]: ", err)
+        }
 
         overlays_per_AS := create_safeset ()
         /* --- Parse directed prefixes --- */
@@ -293,7 +363,7 @@ func analyse_ribs (output_filename, collectors_file, relfile, start, end string)
     /* Read RIBs */
     log.Println ("Reading RIBs...")
     bgp_dump_analyser := generate_RIB_as_path_analyser (set, tiers1, start, end)
-    pool.Launch_pool (32, collectors[0:1], bgp_dump_analyser)
+    pool.Launch_pool (ribs_jobs (), collectors[0:1], bgp_dump_analyser)
     set.write_to_file (output_filename)
 }
 
@@ -311,7 +381,7 @@ func analyse_fibs (data_dir, collectors_file, relfile, output_file string) {
     set := create_safeset ()
     values := make (DataFloat64, 0, 100)
     for _, collector := range collectors {
-        file := data_dir + "/" + collector + ".txt"
+        file := plain_or_gz (data_dir + "/" + collector + ".txt")
         log.Println (file)
 
         reader := NewCompressedReader (file)
@@ -331,6 +401,9 @@ func analyse_fibs (data_dir, collectors_file, relfile, output_file string) {
                 nb_path += r1
             }
         }
+        if err := scanner.Err (); err != nil {
+            log.Print ("[analyse_fibs]: WARNING: ", file, ": ", err)
+        }
         reader.Close ()
 
         set.unsafe_append (collector, strconv.Itoa (nb_path))
@@ -383,8 +456,9 @@ func ases_stats (traces *SafeSet) {
             log.Fatal ("[ases_stats]: unexpected type:", fmt.Sprintf("%T", trace_i))
         }
         trace = trace_v
-        for i, hop := range *trace {
-            if hop.asn != "-1" {
+        hops := trace.hops
+        for i, hop := range hops {
+            if hop.private || hop.asn != "-1" {
                 continue
             }
             if i == 0 && hop.probe_ttl != 1 { // Special case
@@ -392,11 +466,11 @@ func ases_stats (traces *SafeSet) {
             }
             if hop.probe_ttl == 1 {
                 first_position++
-            } else if i == len (*trace)-1 {
+            } else if i == len (hops)-1 {
                 last_position++
-            } else if (*trace)[i-1].asn != (*trace)[i+1].asn {
+            } else if hops[i-1].asn != hops[i+1].asn {
                 in_between_diff++
-                if (*trace)[i+1].probe_ttl - (*trace)[i-1].probe_ttl == 2 { // A -1 B
+                if hops[i+1].probe_ttl - hops[i-1].probe_ttl == 2 { // A -1 B
                     diff_adjs++
                 } else {
                     diff_multi_adjs++
@@ -404,9 +478,9 @@ func ases_stats (traces *SafeSet) {
                     // A * -1 B
                     // A * -1 * B
                 }
-            } else if (*trace)[i-1].asn == (*trace)[i+1].asn {
+            } else if hops[i-1].asn == hops[i+1].asn {
                 in_between_same++
-                if (*trace)[i+1].probe_ttl - (*trace)[i-1].probe_ttl == 2 { // A -1 A
+                if hops[i+1].probe_ttl - hops[i-1].probe_ttl == 2 { // A -1 A
                     same_adjs++
                 } else {
                     same_multi_adjs++
@@ -0,0 +1,134 @@
+package sim
+
+import (
+    "bytes"
+    "compress/gzip"
+    "fmt"
+    "os"
+    "os/exec"
+    "path/filepath"
+    "sort"
+    "strings"
+    "testing"
+    )
+
+/**
+ * Returns s gzip-compressed.
+ */
+func gzipped (t *testing.T, s string) []byte {
+    t.Helper ()
+    var b bytes.Buffer
+    w := gzip.NewWriter (&b)
+    if _, err := w.Write ([]byte (s)); err != nil {
+        t.Fatal (err)
+    }
+    if err := w.Close (); err != nil {
+        t.Fatal (err)
+    }
+    return b.Bytes ()
+}
+
+func write_test_file (t *testing.T, filename string, content []byte) {
+    t.Helper ()
+    if err := os.MkdirAll (filepath.Dir (filename), 0755); err != nil {
+        t.Fatal (err)
+    }
+    if err := os.WriteFile (filename, content, 0644); err != nil {
+        t.Fatal (err)
+    }
+}
+
+/**
+ * An overlay group on a single line longer than bufio's default limit (64 KiB): 80000 /24s (about
+ * 1.2 MB), merged by merge_overlays, then read back by build_overlays_per_AS.
+ */
+func TestOverlaysLongLine (t *testing.T) {
+    const n = 80000
+    dir := t.TempDir ()
+    prefixes := make ([]string, n)
+    for i := range prefixes {
+        prefixes[i] = fmt.Sprintf ("%d.%d.%d.0/24", 20 + i / 65536, (i / 256) % 256, i % 256)
+    }
+    write_test_file (t, filepath.Join (dir, "ribs", "overlays", "overlays_rrc00.txt"), []byte (strings.Join (prefixes, " ") + "\n"))
+    write_test_file (t, filepath.Join (dir, "bdp", "directed_prefixes_100.txt"), []byte ("20.0.0.0/24\n21.0.1.0/24\n"))
+    write_test_file (t, filepath.Join (dir, "ases.txt"), []byte ("100\n"))
+    if err := os.Mkdir (filepath.Join (dir, "per_as"), 0755); err != nil {
+        t.Fatal (err)
+    }
+    logs := capture_log (t)
+
+    build_merge_overlays (filepath.Join (dir, "ribs"))
+    merged := sorted_lines (t, filepath.Join (dir, "ribs", "overlays", "all_overlays.txt"))
+    if len (merged) != 1 || len (strings.Fields (merged[0])) != n {
+        t.Errorf ("all_overlays.txt: %d lines, want a single group of %d prefixes", len (merged), n)
+    }
+    build_overlays_per_AS (filepath.Join (dir, "ases.txt"), filepath.Join (dir, "ribs", "overlays", "all_overlays.txt"), filepath.Join (dir, "bdp"), filepath.Join (dir, "per_as"))
+    for _, line := range sorted_lines (t, filepath.Join (dir, "per_as", "overlays_100.txt")) {
+        if fields := len (strings.Fields (line)); fields != n + 1 { // The directed prefix, then its group
+            t.Errorf ("overlays_100.txt: a line of %d fields, want %d", fields, n + 1)
+        }
+    }
+    if strings.Contains (logs.String (), "token too long") {
+        t.Errorf ("log:\n%s", logs)
+    }
+}
+
+/**
+ * merge_overlays skips an empty .gz and a .gz with a truncated header (with a warning naming the file),
+ * keeps the overlays read from a .gz truncated mid-stream (with a warning), and reads a valid .bz2.
+ * An empty all_overlays .gz cannot be opened by build_overlays_per_AS: the error names the file.
+ */
+func TestMergeOverlaysCompressedErrors (t *testing.T) {
+    dir := t.TempDir ()
+    overlays := filepath.Join (dir, "ribs", "overlays")
+    groups := []string{"22.0.0.0/24 22.0.1.0/24"}
+    if bzip2, err := exec.LookPath ("bzip2"); err == nil {
+        cmd := exec.Command (bzip2)
+        cmd.Stdin = strings.NewReader ("20.0.0.0/24 20.0.1.0/24\n")
+        compressed, err := cmd.Output ()
+        if err != nil {
+            t.Fatal (err)
+        }
+        write_test_file (t, filepath.Join (overlays, "overlays_rrc00.txt.bz2"), compressed)
+        groups = append (groups, "20.0.0.0/24 20.0.1.0/24")
+    } else {
+        t.Log ("no bzip2 to compress the .bz2 overlay file: only the .gz files are checked")
+    }
+    write_test_file (t, filepath.Join (overlays, "overlays_rrc01.txt.gz"), nil)
+    write_test_file (t, filepath.Join (overlays, "overlays_rrc02.txt.gz"), gzipped (t, "21.0.0.0/24 21.0.1.0/24\n")[:5])
+    var stream strings.Builder
+    stream.WriteString ("22.0.0.0/24 22.0.1.0/24\n")
+    for i := 0; i < 20000; i++ {
+        fmt.Fprintf (&stream, "23.%d.%d.0/24 23.%d.%d.1/32\n", i / 256, i % 256, i / 256, i % 256)
+    }
+    full := gzipped (t, stream.String ())
+    write_test_file (t, filepath.Join (overlays, "overlays_rrc03.txt.gz"), full[:len (full) / 2])
+    logs := capture_log (t)
+
+    build_merge_overlays (filepath.Join (dir, "ribs"))
+    for _, file := range []string{"overlays_rrc01.txt.gz", "overlays_rrc02.txt.gz"} {
+        if !strings.Contains (logs.String (), file + ", file skipped") {
+            t.Errorf ("no warning for %s:\n%s", file, logs)
+        }
+    }
+    if !strings.Contains (logs.String (), "overlays_rrc03.txt.gz: ") || !strings.Contains (logs.String (), "overlays read so far kept") {
+        t.Errorf ("no warning for the truncated stream of overlays_rrc03.txt.gz:\n%s", logs)
+    }
+    merged := make (map[string]bool)
+    for _, group := range sorted_lines (t, filepath.Join (overlays, "all_overlays.txt")) {
+        prefixes := strings.Fields (group)
+        sort.Strings (prefixes)
+        merged[strings.Join (prefixes, " ")] = true
+    }
+    for _, group := range groups {
+        if !merged[group] {
+            t.Errorf ("all_overlays.txt: missing group %s", group)
+        }
+    }
+
+    empty := filepath.Join (dir, "empty_overlays.txt.gz")
+    write_test_file (t, empty, nil)
+    if err := NewCompressedReader (plain_or_gz (empty)).Open (); err == nil || !strings.Contains (err.Error (), "empty_overlays.txt.gz") {
+        t.Errorf ("empty all_overlays .gz: %v, want an error naming the file", err)
+    }
+}
@@ -0,0 +1,157 @@
+/* ==================================================================================== *\
+     rib_checkpoint.go
+
+     Resuming a RIB parsing:
+     -----------------------
+     Once all the output files of a collector are written (overlays, forwarding table,
+     next-hop and previous-hop ASes), ribs_multi writes the marker <output_dir>/.done_<collector>.
+     The origin ASes, the BGP peers and the ADD-PATH counters of the collector are kept in
+     <output_dir>/.collectors/, instead of in memory: the post-processing (origin_ases.txt,
+     all_BGP_peers.txt, add_path.txt, the merge of the overlays) reads the files of all the
+     collectors, parsed by this run or a previous one. With -resume, the collectors whose
+     marker exists are not parsed again. Without -resume, the markers of a previous run are
+     removed first.
+\* ==================================================================================== */
+
+package sim
+
+import (
+    "log"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+    )
+
+const collectors_state_dir = ".collectors" // Origin ASes and BGP peers of each collector
+
+func collector_marker (output_dir, collector_name string) string {
+    return filepath.Join (output_dir, ".done_" + collector_name)
+}
+
+func collector_origins_file (output_dir, collector_name string) string {
+    return filepath.Join (output_dir, collectors_state_dir, "origin_ases_" + collector_name + ".txt")
+}
+
+func collector_peers_file (output_dir, collector_name string) string {
+    return filepath.Join (output_dir, collectors_state_dir, "BGP_peers_" + collector_name + ".txt")
+}
+
+func collector_add_path_file (output_dir, collector_name string) string {
+    return filepath.Join (output_dir, collectors_state_dir, "add_path_" + collector_name + ".txt")
+}
+
+/**
+ * Prepares the state of the collectors in output_dir: without resume, the state of a previous
+ * run is removed.
+ */
+func collectors_checkpoint_begin (output_dir string, resume bool) {
+    if !resume {
+        markers, _ := filepath.Glob (filepath.Join (output_dir, ".done_*"))
+        for _, marker := range markers {
+            os.Remove (marker)
+        }
+        os.RemoveAll (filepath.Join (output_dir, collectors_state_dir))
+    }
+    if err := os.MkdirAll (filepath.Join (output_dir, collectors_state_dir), 0755); err != nil {
+        log.Fatal ("[collectors_checkpoint_begin]: ", err)
+    }
+}
+
+/**
+ * Returns true if the collector was parsed completely (by this run or a previous one).
+ */
+func collector_completed (output_dir, collector_name string) bool {
+    _, err := os.Stat (collector_marker (output_dir, collector_name))
+    return err == nil
+}
+
+/**
+ * Writes the marker of a collector once all its files exist: a file missing (error while writing,
+ * see write_to_file) leaves the collector to be parsed again.
+ */
+func collector_done (output_dir, collector_name string, files ...string) bool {
+    for _, file := range files {
+        if _, err := os.Stat (file); err != nil {
+            log.Print ("[collector_done]: ", collector_name, ": ", err, ", the collector is not marked as done")
+            return false
+        }
+    }
+    if err := os.WriteFile (collector_marker (output_dir, collector_name), nil, 0644); err != nil {
+        log.Print ("[collector_done]: ", err)
+        return false
+    }
+    return true
+}
+
+/**
+ * Returns the collectors not parsed completely yet, and logs the others.
+ */
+func collectors_to_parse (output_dir string, collectors []string) []string {
+    todo := make ([]string, 0, len (collectors))
+    for _, collector_name := range collectors {
+        if collector_completed (output_dir, collector_name) {
+            log.Println ("[resume]: collector", collector_name, "already parsed")
+            summary_unit ("collectors", unit_processed)
+            continue
+        }
+        todo = append (todo, collector_name)
+    }
+    return todo
+}
+
+/**
+ * Gathers the origin ASes of the completed collectors (origin AS -> prefixes).
+ */
+func gather_collector_origins (output_dir string, collectors []string) *SafeSet {
+    origin_set := create_safeset ()
+    for _, collector_name := range collectors {
+        if !collector_completed (output_dir, collector_name) {
+            continue
+        }
+        reader := NewCompressedReader (collector_origins_file (output_dir, collector_name))
+        if err := reader.Open (); err != nil {
+            log.Print ("[gather_collector_origins]: WARNING: ", err, ", collector skipped")
+            continue
+        }
+        scanner := reader.Scanner ()
+        for scanner.Scan () {
+            fields := strings.Fields (scanner.Text ())
+            if len (fields) < 2 {
+                continue
+            }
+            for _, prefix := range fields[1:] {
+                origin_set.unsafe_append (fields[0], prefix)
+            }
+        }
+        if err := scanner.Err (); err != nil {
+            log.Print ("[gather_collector_origins]: WARNING: ", collector_name, ": ", err)
+        }
+        reader.Close ()
+    }
+    return origin_set
+}
+
+/**
+ * Concatenates a file of the completed collectors (e.g., collector_peers_file) into filename, in
+ * the lexical order of the collectors.
+ */
+func gather_collector_files (output_dir string, collectors []string, collector_file func (string, string) string, filename string) {
+    sorted := append ([]string (nil), collectors...)
+    sort.Strings (sorted)
+    var content []byte
+    for _, collector_name := range sorted {
+        if !collector_completed (output_dir, collector_name) {
+            continue
+        }
+        file, err := os.ReadFile (collector_file (output_dir, collector_name))
+        if err != nil {
+            log.Print ("[gather_collector_files]: WARNING: ", err, ", collector skipped")
+            continue
+        }
+        content = append (content, file...)
+    }
+    if err := os.WriteFile (filename, content, 0644); err != nil {
+        log.Print ("[gather_collector_files]: ", err)
+    }
+}
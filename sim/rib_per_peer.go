@@ -0,0 +1,141 @@
+/* ==================================================================================== *\
+     rib_per_peer.go
+
+     Forwarding tables per BGP peer (-per-peer):
+     -------------------------------------------
+     The best entry of a prefix hides the routing diversity among the peers of a collector.
+     With -per-peer, ribs_multi also writes the table of each peer of a collector (peer_ASN@
+     peer_IP), in the format of the forwarding tables:
+       forwarding_tables/<collector>/<peer>.txt  [prefix as_path]
+       overlays/<collector>/<peer>.txt            overlays of the table of the peer
+     A peer announces a single path per prefix, so no heuristic is applied: with several
+     paths (ADD-PATH), the shortest is kept (the first one among equals). The AS paths are
+     kept as announced, whatever the heuristic. The tables are fed with the entries of each
+     prefix as soon as it is selected (see pending_prefixes).
+     The overlays of the peers are merged with those of the collectors (see build_merge_overlays).
+\* ==================================================================================== */
+
+package sim
+
+import (
+    "log"
+    "os"
+    "path/filepath"
+    )
+
+/**
+ * Tables of the BGP peers of a collector.
+ */
+type peer_tables struct {
+    tables_dir, overlays_dir string;    // forwarding_tables/<collector>, overlays/<collector>
+    files map[string]*CompressedWriter; // Peer -> its table (nil if it could not be opened), while open
+    peers []string;                     // Peers, in the order their table was opened
+}
+
+/**
+ * Returns the tables of the peers of the collector (nil without -per-peer). The tables of a previous
+ * attempt are removed.
+ */
+func new_peer_tables (output_dir, collector_name string) *peer_tables {
+    if !g_args.per_peer {
+        return nil
+    }
+    t := &peer_tables{
+        tables_dir: filepath.Join (output_dir, "forwarding_tables", collector_name),
+        overlays_dir: filepath.Join (output_dir, "overlays", collector_name),
+        files: make (map[string]*CompressedWriter),
+    }
+    for _, dir := range []string{t.tables_dir, t.overlays_dir} {
+        os.RemoveAll (dir)
+        if err := os.MkdirAll (dir, 0755); err != nil {
+            log.Print ("[peer_tables]: ", err)
+        }
+    }
+    return t
+}
+
+func (t *peer_tables) table_file (peer string) string {
+    return output_filename (filepath.Join (t.tables_dir, peer + ".txt"))
+}
+
+/**
+ * Returns the entry of each peer with the shortest AS path (the first one among equals).
+ */
+func shortest_per_peer (entries []*Rib_entry) map[string]*Rib_entry {
+    best := make (map[string]*Rib_entry)
+    for _, entry := range entries {
+        if b, ok := best[entry.peer]; !ok || len (entry.as_path) < len (b.as_path) {
+            best[entry.peer] = entry
+        }
+    }
+    return best
+}
+
+/**
+ * Writes the entry of each peer for the prefix (the on_group of pending_prefixes).
+ * Errors are sticky, reported by close.
+ */
+func (t *peer_tables) write (prefix string, entries []*Rib_entry) {
+    for peer, entry := range shortest_per_peer (entries) {
+        f, ok := t.files[peer]
+        if !ok {
+            f = NewCompressedWriter (t.table_file (peer), false)
+            if err := f.Open (); err != nil {
+                log.Print ("[peer_tables]: ", err)
+                f = nil
+            }
+            t.files[peer] = f
+            t.peers = append (t.peers, peer)
+        }
+        if f != nil {
+            print_rib_entry (f.Writer, prefix, entry)
+        }
+    }
+}
+
+/**
+ * Closes the tables. A table whose writing failed is removed (see CompressedWriter).
+ */
+func (t *peer_tables) close () {
+    if t == nil {
+        return
+    }
+    for _, f := range t.files {
+        if f == nil {
+            continue
+        }
+        if err := f.Close (); err != nil {
+            log.Print ("[peer_tables]: ", err)
+        }
+    }
+    t.files = nil
+}
+
+/**
+ * Removes the tables (failed reading of the collector).
+ */
+func (t *peer_tables) abort () {
+    if t == nil {
+        return
+    }
+    t.close ()
+    os.RemoveAll (t.tables_dir)
+    os.RemoveAll (t.overlays_dir)
+}
+
+/**
+ * Computes and writes the overlays of the table of each peer.
+ */
+func (t *peer_tables) write_overlays () {
+    if t == nil {
+        return
+    }
+    for _, peer := range t.peers {
+        table := t.table_file (peer)
+        if _, err := os.Stat (table); err != nil {
+            continue // Not written (see close)
+        }
+        process_overlays (table).write_to_file (output_filename (filepath.Join (t.overlays_dir, peer + ".txt")))
+    }
+    log.Printf ("[peer_tables]: %s: %d peers", filepath.Base (t.tables_dir), len (t.peers))
+}
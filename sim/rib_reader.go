@@ -0,0 +1,1134 @@
+/* ============================================================= *\
+     rib_reader.go
+
+     Specifics of parsing the RIBs from the RouteViews and 
+     RIRs projects.
+     - Can count the number of prefixes per collector to determine
+       which collectors are valid or not.
+     - Can output a file of prefixes for which an AS of interest
+       was seen in the AS path, and indicate if this prefix is a
+       dependent prefix or an up/down prefix (RocketFuel Directed 
+       Probes and Egress Reduction evaluation).
+       Note: select valid collectors before doing that operation.
+     - Other functions are available as well, check the file for
+       further details.
+\* ============================================================= */
+
+package sim
+
+import (
+    "context"
+    "io"
+    "log"
+    "strings"
+    "bufio"
+    "os"
+    "os/exec"
+    "net"
+    "strconv"
+    "regexp"
+    "sort"
+    pool "github.com/Emeline-1/pool")
+
+var reserved_prefixes [15]net.IPNet = [15]net.IPNet{
+    *string_to_net ("0.0.0.0/8"),
+    *string_to_net ("10.0.0.0/8"),
+    *string_to_net ("100.64.0.0/10"),
+    *string_to_net ("127.0.0.0/8"),
+    *string_to_net ("169.254.0.0/16"),
+    *string_to_net ("172.16.0.0/12"),
+    *string_to_net ("192.0.0.0/24"),
+    *string_to_net ("192.0.2.0/24"),
+    *string_to_net ("192.88.99.0/24"),
+    *string_to_net ("192.168.0.0/16"),
+    *string_to_net ("198.18.0.0/15"),
+    *string_to_net ("198.51.100.0/24"),
+    *string_to_net ("203.0.113.0/24"),
+    *string_to_net ("224.0.0.0/4"),
+    *string_to_net ("240.0.0.0/4"),
+}
+
+var reserved_prefixes6 [12]net.IPNet = [12]net.IPNet{
+    *string_to_net ("::/8"),          // Unspecified, loopback, IPv4-mapped and -compatible
+    *string_to_net ("100::/64"),      // Discard-only
+    *string_to_net ("2001::/32"),     // Teredo
+    *string_to_net ("2001:2::/48"),   // Benchmarking
+    *string_to_net ("2001:10::/28"),  // ORCHID
+    *string_to_net ("2001:db8::/32"), // Documentation
+    *string_to_net ("2002::/16"),     // 6to4
+    *string_to_net ("3ffe::/16"),     // Former 6bone
+    *string_to_net ("fc00::/7"),      // Unique local
+    *string_to_net ("fe80::/10"),     // Link-local
+    *string_to_net ("fec0::/10"),     // Site-local (deprecated)
+    *string_to_net ("ff00::/8"),      // Multicast
+}
+
+const (
+    min_mask_length6 = 16 // Sound BGP entries (IPv6)
+    max_mask_length6 = 48
+)
+
+/**
+ * Returns the network of the prefix, and false if the prefix is not a sound BGP entry.
+ * IPv6 prefixes are only accepted with -6 (g_args.ipv6): in a mixed table, each prefix
+ * is checked by the validator of its own family.
+ */
+func check_prefix_validity (prefix string) (*net.IPNet, bool) {
+    ip, network, err := net.ParseCIDR (prefix)
+    if err != nil {
+        log.Print ("[check_prefix_validity]: " + err.Error() + ": " + prefix)
+        return nil, false
+    }
+    if network.IP.To4 () == nil {
+        if !g_args.ipv6 {
+            return nil, false
+        }
+        return check_prefix_validity6 (ip, network)
+    }
+    return check_prefix_validity4 (ip, network)
+}
+
+func check_prefix_validity4 (ip net.IP, network *net.IPNet) (*net.IPNet, bool) {
+    /* --- Sound BGP entries --- */
+    l,_ := network.Mask.Size ()
+    if l < 8 || l > 24 {
+        return nil,false
+    }
+    /* --- Reserved address --- */
+    for _, reserved := range reserved_prefixes {
+        if reserved.Contains (ip) {
+            return nil,false
+        }
+    }
+    return network, true
+}
+
+func check_prefix_validity6 (ip net.IP, network *net.IPNet) (*net.IPNet, bool) {
+    /* --- Sound BGP entries --- */
+    l,_ := network.Mask.Size ()
+    if l < min_mask_length6 || l > max_mask_length6 {
+        return nil,false
+    }
+    /* --- Reserved address --- */
+    for _, reserved := range reserved_prefixes6 {
+        if reserved.Contains (ip) {
+            return nil,false
+        }
+    }
+    return network, true
+}
+
+/**
+ * Returns true if the network is an IPv6 network.
+ */
+func is_ipv6_network (network *net.IPNet) bool {
+    return network.IP.To4 () == nil
+}
+
+/**
+ * Starts a command and wait until it is completed.
+ * The done channel is to receive a signal when the processing of the command is completed. (This is different from the cmd that
+    * is completed. For example, if the processing takes more time than the execution of the command itself).
+ * Returns true if no errors, false otherwise
+ */
+func start_and_wait (cmd *exec.Cmd, done chan struct{}) bool {
+    err := cmd.Start() // Non blocking
+    if err != nil {
+        log.Print ("[start_and_wait]: Start: " +  err.Error())
+        return false
+    }
+    
+    <-done // Wait for the whole file to be processed
+
+    err = cmd.Wait() // Wait for the command to finish
+    if err != nil {
+        log.Print ("[start_and_wait]: Wait: " + err.Error())
+        return false
+    }
+    return true
+}
+
+/**
+ * Calls process with each record of the RIB of the collector, in the format of
+ * 'bgpreader -t ribs', in the order of the dump.
+ * The records come from bgpreader or, with -mrt-dir (g_args.mrt_dir), from the local
+ * RIB dump of the collector (see read_mrt_dump), or from the records kept by ribs_multi
+ * (g_args.records_dir, see replay_rib_records; not filtered).
+ * If filter_ases is not empty, the entries whose AS path does not match generate_aspath_regex are skipped
+ * (pre-filter only: bgpreader -A, or the regex on the decoded path; the caller matches exactly).
+ * bgpreader is killed after -collector-timeout, or if the run is interrupted (see interrupt.go), so that
+ * it is not left orphan.
+ * Returns true if no errors, false otherwise.
+ */
+func read_rib_records (collector_name, start, end string, filter_ases []string, process func (string)) bool {
+    if g_args.records_dir != "" {
+        return replay_rib_records (g_args.records_dir, collector_name, process)
+    }
+    if g_args.mrt_dir != "" {
+        dump, err := find_mrt_dump (g_args.mrt_dir, collector_name, start, end)
+        if err != nil {
+            log.Print ("[read_rib_records]: " + err.Error ())
+            return false
+        }
+        var filter *regexp.Regexp
+        if len (filter_ases) != 0 {
+            filter = regexp.MustCompile (generate_aspath_regex (filter_ases))
+        }
+        if err := read_mrt_dump (dump, collector_name, filter, process); err != nil {
+            log.Print (err)
+            return false
+        }
+        return true
+    }
+
+    args := []string{"-t", "ribs", "-c", collector_name, "-w", start+","+end}
+    if len (filter_ases) != 0 { // Filtering on specific ASes in the AS path
+        args = append (args, "-A", generate_aspath_regex (filter_ases))
+    }
+    ctx, cancel := context.WithCancel (run_context)
+    if g_args.collector_timeout > 0 {
+        ctx, cancel = context.WithTimeout (run_context, g_args.collector_timeout)
+    }
+    defer cancel ()
+    cmd := exec.CommandContext(ctx, "bgpreader", args...) // Killed once ctx is done
+    r, _ := cmd.StdoutPipe() // Get a pipe to read from standard output
+    go func () {
+        <-ctx.Done ()
+        r.Close () // The reading ends even if a child of bgpreader still holds its output
+    } ()
+    scanner := new_scanner (r, default_max_line) // Create a scanner which scans the output line-by-line
+
+    // Channel for communication when the goroutine is done parsing the whole file
+    done := make(chan struct{}) // An empty struct takes up no memory space
+    go func() {
+        // Read line by line and process it
+        for scanner.Scan() {
+            process (scanner.Text())
+        }
+        if err := scanner.Err (); err != nil {
+            log.Print ("[read_rib_records]: WARNING: ", collector_name, ": ", err, ", the table is incomplete")
+            io.Copy (io.Discard, r) // Do not leave bgpreader blocked on a full pipe
+        }
+        done <- struct{}{} // We're all done, unblock the channel
+    }()
+
+    // Actually start the bgpreader command
+    ok := start_and_wait (cmd, done)
+    if ctx.Err () == context.DeadlineExceeded {
+        log.Print ("[read_rib_records]: ", collector_name, ": bgpreader killed after ", g_args.collector_timeout, " (-collector-timeout)")
+        return false
+    }
+    return ok
+}
+
+type Rib_entry struct{
+    as_path       []string
+    as_to_next_hop_AS       map[string]string
+    as_to_prev_hop_AS       map[string]string // The AS before the AS of interest (towards the collector)
+    ipv6          bool // Address family of the prefix (mixed tables, see -6)
+    peer          string // BGP peer of the collector that announced the entry (peer_ASN@peer_IP)
+}
+
+/**
+ * Print each and every routing entry as
+ * [prefix as_path]
+ */
+func print_rib_entry (w *bufio.Writer, key string, v interface{}) error {
+    var err error
+    if value, ok := v.(*Rib_entry); ok {
+        _, err = w.WriteString(key + " " + strings.Join (value.as_path, " ") + "\n")
+    } else {
+        log.Fatalf ("Unexpected type: %T", v)
+    }
+    return err
+}
+
+/**
+ * Parses a line of a forwarding table written by print_rib_entry:
+ * [prefix as_path]
+ * Returns false if the line is malformed.
+ */
+func parse_forwarding_table_line (line string) (string, []string, bool) {
+    fields := strings.Fields (line)
+    if len (fields) < 2 {
+        return "", nil, false
+    }
+    return fields[0], fields[1:], true
+}
+
+/**
+ * Print a routing entry only if an AS of interest is in the path, as:
+ * [prefix AS_interest next-hop_AS]
+ */
+func print_next_as (w *bufio.Writer, key string, v interface{}) error {
+    var err error
+    if value, ok := v.(*Rib_entry); ok {
+        if len (value.as_to_next_hop_AS) != 0 {
+            for as, next_hop_AS := range value.as_to_next_hop_AS {
+                _, err = w.WriteString(key + " " + as + " " + next_hop_AS + "\n")
+            }
+        }
+    } else {
+        log.Fatalf ("Unexpected type: %T", v)
+    }
+    return err
+}           
+
+/**
+ * Print a routing entry only if an AS of interest is in the path, as:
+ * [prefix AS_interest previous-hop_AS]
+ */
+func print_prev_as (w *bufio.Writer, key string, v interface{}) error {
+    var err error
+    if value, ok := v.(*Rib_entry); ok {
+        if len (value.as_to_prev_hop_AS) != 0 {
+            for as, prev_hop_AS := range value.as_to_prev_hop_AS {
+                _, err = w.WriteString(key + " " + as + " " + prev_hop_AS + "\n")
+            }
+        }
+    } else {
+        log.Fatalf ("Unexpected type: %T", v)
+    }
+    return err
+}
+
+/**
+ * Returns the function printing each BGP peer of a collector (peer_ASN@peer_IP -> nb of prefixes) as:
+ * [collector peer_asn peer_ip n_prefixes]
+ */
+func print_bgp_peer (collector_name string) PrintFn {
+    return func (w *bufio.Writer, key string, v interface{}) error {
+        n, ok := v.(int)
+        if !ok {
+            log.Fatalf ("Unexpected type: %T", v)
+        }
+        asn, ip := key, ""
+        if i := strings.Index (key, "@"); i >= 0 {
+            asn, ip = key[:i], key[i+1:]
+        }
+        _, err := w.WriteString (collector_name + " " + asn + " " + ip + " " + strconv.Itoa (n) + "\n")
+        return err
+    }
+}
+
+/* --- AS path sanitation --- */
+
+/**
+ * Counts, for a collector, the AS paths modified or dropped by the sanitation.
+ */
+type Path_sanitation_stats struct {
+    stripped int;      // Paths from which reserved ASNs were stripped
+    dropped_bogon int; // Entries dropped because of a reserved ASN (drop policy, or only reserved ASNs)
+    dropped_long int;  // Entries dropped because their path is too long
+}
+
+/**
+ * Returns true if the ASN is reserved (AS 0, AS_TRANS, documentation, private use, last ASNs).
+ * Non numeric ASNs (e.g., AS sets) are not considered as reserved.
+ */
+func is_reserved_asn (asn string) bool {
+    n, err := strconv.ParseUint (asn, 10, 32)
+    if err != nil {
+        return false
+    }
+    return n == 0 || n == 23456 ||
+        (n >= 64496 && n <= 131071) || // Documentation, private use, 65535, documentation, reserved
+        n >= 4200000000 // Private use, 4294967295
+}
+
+/**
+ * Sanitizes an AS path before it is used by the heuristics:
+ * - reserved ASNs are stripped, or the entry is dropped (g_args.bogon_asn_policy: "strip" or "drop");
+ *   a path of reserved ASNs only is dropped whatever the policy
+ * - the entry is dropped if its path, once prepending is collapsed, is longer than g_args.max_as_path_length.
+ * Returns the sanitized path, and false if the entry must be dropped.
+ * Runs before any loop detection, so that stripped ASNs cannot mask a loop.
+ */
+func sanitize_as_path (ases []string, stats *Path_sanitation_stats) ([]string, bool) {
+    sanitized := make ([]string, 0, len (ases))
+    for _, as := range ases {
+        if is_reserved_asn (as) {
+            if g_args.bogon_asn_policy == "drop" {
+                stats.dropped_bogon++
+                return nil, false
+            }
+            continue
+        }
+        sanitized = append (sanitized, as)
+    }
+    if len (sanitized) == 0 && len (ases) != 0 { // Nothing left once stripped: a reserved ASN drop, counted once
+        stats.dropped_bogon++
+        return nil, false
+    }
+    if len (sanitized) != len (ases) {
+        stats.stripped++
+    }
+
+    /* --- Path length, once prepending is collapsed --- */
+    length := 0
+    for i, as := range sanitized {
+        if i == 0 || as != sanitized[i-1] {
+            length++
+        }
+    }
+    if length == 0 || (g_args.max_as_path_length > 0 && length > g_args.max_as_path_length) {
+        stats.dropped_long++
+        return nil, false
+    }
+    return sanitized, true
+}
+
+/**
+ * Returns a routing entry composed of:
+ * - the AS path (sanitized, see sanitize_as_path)
+ * - If one or more of the ASes of interest are present in the AS path, a mapping between
+ *   the AS of interest and its next-hop AS, and a mapping between the AS of interest and its previous-hop AS.
+ *   Both are taken on the path with prepending collapsed (see hop_path), so that a prepended AS
+ *   is never its own next or previous hop.
+ * as_path format: AS1 AS2 ... ASn
+ * Returns nil if the entry must be dropped.
+ */
+func get_Rib_entry (as_path string, ases_interest []string, stats *Path_sanitation_stats) *Rib_entry {
+    ases, keep := sanitize_as_path (strings.Fields (as_path), stats)
+    if !keep {
+        return nil
+    }
+    return new_Rib_entry (ases, ases_interest)
+}
+
+/**
+ * Returns the RIB entry of an AS path already sanitized (as announced, from the collector to the origin).
+ */
+func new_Rib_entry (ases []string, ases_interest []string) *Rib_entry {
+    r := &Rib_entry{as_path: ases, as_to_next_hop_AS: make (map[string]string), as_to_prev_hop_AS: make (map[string]string)}
+
+    hops := hop_path (ases)
+    for _,as_interest := range ases_interest {
+        if next := get_prev_or_next_as (as_interest, hops, 1); next != "" {
+            r.as_to_next_hop_AS[as_interest] = next
+            r.as_to_prev_hop_AS[as_interest] = get_prev_or_next_as (as_interest, hops, -1)
+        }
+    }
+    return r
+}
+
+/**
+ * Returns the AS path on which the next and previous-hop ASes are taken: consecutive
+ * duplicates (prepending) are collapsed, unless g_args.keep_prepending is set.
+ * The AS path of the routing entry itself is left untouched (the shortest-path heuristic counts prepending).
+ */
+func hop_path (ases []string) []string {
+    if g_args.keep_prepending {
+        return ases
+    }
+    return remove_duplicates (ases)
+}
+
+/**
+ * Given an AS of interest, an AS path, and a direction,
+ * returns the previous (if direction = -1) or the next (if direction = +1) AS
+ * of the AS of interest.
+ * Return "" if the AS of interest is not in the path.
+ * 
+ * Note: direction can only be +1 or -1.
+ */
+func get_prev_or_next_as (AS_interest string, as_path []string, direction int) string {
+    for i,as := range as_path { // Loop over the AS path
+        if as == AS_interest {
+            // as of interest is first or last in the path.
+            // Depending on whether we want next or previous AS, do not access illegal cells.
+            if (i+direction < 0) || (i+direction) >= len (as_path) { 
+                return AS_interest // To keep track of all prefixes where an AS of interest was seen in the path
+            } else {
+                return as_path[i+direction]
+            }
+        }
+    }
+    return ""
+}
+
+/* ------------------------------------------------- *\
+            BGP multi parser
+\* ------------------------------------------------- */
+
+/**
+ * The RIB entries of a prefix are normally grouped together in a dump (verified for the 44 valid
+ * collectors on April 20th, 2021), but nothing guarantees it: see pending_prefixes.
+ * 
+ * OUTPUTS:
+ * - A file per collector and per AS of interest, giving for each prefix of the table, the next-hop AS in the format:
+ *   [prefix nexthop_AS]
+ *
+ * - The same files for the previous-hop AS (the AS before the AS of interest, towards the collector):
+ *   [prefix prevhop_AS]
+ *   
+ * - A file per collector giving each BGP peer of the collector, and the number of valid prefixes
+ *   it contributed, in the format (see print_bgp_peer):
+ *   [collector peer_asn peer_ip n_prefixes]
+ *
+ * - A file per collector giving all the prefixes advertized by a given AS in the format (gathered
+ *   for all collectors by parse_ribs):
+ *   [origin_AS prefix_1 prefix_2 ... prefix_n]
+ *
+ * - A file per collector giving the additional paths of a peer for a prefix (ADD-PATH), and those
+ *   identical to another path of the peer (dropped), in the format (gathered by parse_ribs):
+ *   [collector n_add_path n_identical]
+ *
+ * - A file per collector giving the overlays (new-line separated)
+ *
+ * - With -per-peer, a forwarding table and its overlays per BGP peer of the collector (see rib_per_peer.go)
+ *
+ * - With -keep-records, the records of the collector, replayed by add_as (see rib_records.go)
+ *
+ * Once they are all written, the collector is marked as done (see rib_checkpoint.go).
+ */
+func generate_RIB_parser (ases_interest []string, output_dir, start, end string, heuristic int) func (string) {
+    return collector_attempts (func (collector_name string) bool {
+
+        /* ----------------------- *\
+                RIB Processing
+        \* ----------------------- */
+        writers := new_rib_writers (output_dir, collector_name, ases_interest) // Best entry of each prefix, according to heuristic, written as soon as selected
+        writers.open ()
+        pending := new_pending_prefixes (writers.write, ases_interest, heuristic, g_args.rib_window) // ALL BGP entries of the prefixes not selected yet
+        peers := new_peer_tables (output_dir, collector_name) // -per-peer only (nil otherwise)
+        if peers != nil {
+            pending.on_group = peers.write
+        }
+        collector_peers_set := create_safeset () // Record BGP peers of current collector (peer_ASN@peer_IP -> nb of prefixes)
+        collector_origin_set := create_safeset () // Origin AS -> prefixes of the collector
+        records := new_rib_records (output_dir, collector_name) // -keep-records only (nil otherwise)
+        stats := &Path_sanitation_stats{}
+        ok := read_rib_records (collector_name, start, end, nil, func (line string) { // No filtering on AS path
+            records.write (line)
+            parse_bgp_record_multi (pending, line, collector_origin_set, collector_peers_set, ases_interest, collector_name, stats)
+        })
+        if !ok {
+            writers.abort ()
+            peers.abort ()
+            records.abort ()
+            return false
+        }
+        records.close ()
+        // Trigger processing for the last prefixes in table
+        pending.flush_all ()
+        writers.close ()
+        peers.close ()
+        if pending.scattered != 0 {
+            log.Printf ("[generate_RIB_parser]: %s: %d prefixes with scattered entries (see -rib_window)", collector_name, pending.scattered)
+        }
+        if len (pending.late) != 0 {
+            log.Printf ("[generate_RIB_parser]: %s: WARNING: %d prefixes seen again after their selection, beyond -rib_window: their later entries are ignored (increase -rib_window)", collector_name, len (pending.late))
+        }
+        if pending.add_path != 0 {
+            log.Printf ("[generate_RIB_parser]: %s: %d additional paths of a peer for a prefix (ADD-PATH), %d identical to another path of the peer (dropped)", collector_name, pending.add_path, pending.add_path_identical)
+        }
+        if pending.heuristic_stats.pivot_at_start != 0 {
+            log.Printf ("[generate_RIB_parser]: %s: %d prefixes with a pivot node starting one of their paths (entries skipped, see select_entry)", collector_name, pending.heuristic_stats.pivot_at_start)
+        }
+
+        /* ----------------------- *\
+               Post Processing
+        \* ----------------------- */
+
+        if g_args.ipv6 {
+            log.Printf ("[generate_RIB_parser]: %s: %d IPv4 and %d IPv6 prefixes", collector_name, writers.nb_ipv4, writers.nb_ipv6)
+        }
+        log.Printf ("[generate_RIB_parser]: %s: AS paths: %d stripped of reserved ASNs, %d dropped (reserved ASN), %d dropped (longer than %d)", collector_name, stats.stripped, stats.dropped_bogon, stats.dropped_long, g_args.max_as_path_length)
+
+        /* --- Save BGP peers and origin ASes to file (gathered by parse_ribs, not compressed) --- */
+        collector_peers_set.write_to_file (collector_peers_file (output_dir, collector_name), print_bgp_peer (collector_name))
+        collector_origin_set.write_to_file (collector_origins_file (output_dir, collector_name))
+        add_path_line := collector_name + " " + strconv.Itoa (pending.add_path) + " " + strconv.Itoa (pending.add_path_identical) + "\n"
+        if err := os.WriteFile (collector_add_path_file (output_dir, collector_name), []byte (add_path_line), 0644); err != nil {
+            log.Print ("[generate_RIB_parser]: ", err)
+        }
+
+        /* --- Overlay processing (from the forwarding table just written) --- */
+        overlays := process_overlays (writers.forwarding_table)
+        overlays_file := output_filename (output_dir + "/overlays/overlays_" + collector_name + ".txt")
+        overlays.write_to_file (overlays_file)
+        peers.write_overlays ()
+
+        done_files := []string{collector_peers_file (output_dir, collector_name), collector_origins_file (output_dir, collector_name), collector_add_path_file (output_dir, collector_name), overlays_file,
+            writers.forwarding_table, writers.next_hop, writers.prev_hop}
+        collector_done (output_dir, collector_name, append (done_files, records.names (output_dir, collector_name)...)...)
+        summary_unit ("collectors", unit_processed)
+        return true
+    })
+}
+
+/**
+ * Records a RIB entry in the current_routing_entries_set. Once all entries for a given prefix
+ * have been read, trigger the BGP selection process according to provided heuristic.
+ * Other information are also recorded for each valid prefix.
+ */
+func parse_bgp_record_multi(pending *pending_prefixes, record string, origin_set, collector_peers_set *SafeSet, ases_interest []string, collector_name string, stats *Path_sanitation_stats) {
+    defer recovery_function ()
+
+    curr_prefix, bgp_peer, routing_entry, origin_as, ok := parse_rib_record (record, ases_interest, stats)
+    if !ok {
+        return
+    }
+
+    /* --- Record current RIB entry (the BGP decision process is triggered by pending_prefixes) --- */
+    first := pending.add (curr_prefix, bgp_peer, routing_entry)
+
+    // We record everything, irrespective of best path.
+    /* --- Origin AS of prefix --- */
+    origin_set.append (origin_as, curr_prefix) //Origin AS -> All prefixes announced by that AS
+
+    /* --- BGP peer of collector --- */
+    n, _ := collector_peers_set.unsafe_get (bgp_peer)
+    count, _ := n.(int)
+    if first { // Not an additional path of the peer (ADD-PATH)
+        count++
+    }
+    collector_peers_set.unsafe_add (bgp_peer, count) // Peer -> Nb of valid prefixes it contributed
+}
+
+/**
+ * Parses a RIB record ('bgpreader -t ribs' format): returns its prefix, its BGP peer (peer ASN and IP,
+ * an AS may have several sessions with the collector), its RIB entry (nil if dropped, see get_Rib_entry)
+ * and its origin AS. ok is false if the record is not a RIB entry of a valid prefix.
+ */
+func parse_rib_record (record string, ases_interest []string, stats *Path_sanitation_stats) (prefix, peer string, entry *Rib_entry, origin string, ok bool) {
+    s := strings.Split(record, "|")
+    if len (s) < 2 || s[1] != "R" { // Only care about RIB content
+        return
+    }
+    if len (s) < 13 {
+        log.Print ("[parse_rib_record]: truncated record: ", record)
+        return
+    }
+    network, valid := check_prefix_validity (s[9])
+    if !valid {
+        return
+    }
+    entry = get_Rib_entry (s[11], ases_interest, stats)
+    if entry != nil {
+        entry.ipv6 = is_ipv6_network (network)
+        entry.peer = s[7] + "@" + s[8]
+    }
+    return network.String (), s[7] + "@" + s[8], entry, s[12], true
+}
+
+/**
+ * RIB entries of the prefixes whose best entry is not selected yet.
+ * The entries of a prefix are gathered until the prefix has not been seen for 'window' records, and
+ * only then is the heuristic applied to all of them: entries scattered within the window give the
+ * same selection as grouped entries, for both heuristics. The selected entry is handed to on_select
+ * at once (see rib_writers), so that only the groups of the last 'window' records are held in
+ * memory, not the whole table (but the set of the prefixes already selected).
+ * A prefix reappearing after its selection (scattered beyond the window) is not selected again: its
+ * best entry is already written, so its later entries are ignored and the prefix is counted in
+ * 'late' (a larger window gathers them).
+ * A peer may announce several paths for a prefix (ADD-PATH): they are entries of the prefix like
+ * the others, except that a path identical to another of the same peer is dropped, so that it
+ * does not weigh twice in the heuristic.
+ */
+type pending_prefixes struct {
+    groups map[string]*pending_group; // Prefix -> its entries so far
+    selected map[string]struct{};     // Prefixes whose best entry was already selected
+    late map[string]struct{};         // Selected prefixes seen again beyond the window (entries ignored)
+    on_select func (string, *Rib_entry);     // Receives the best entry of each prefix
+    on_group func (string, []*Rib_entry);    // If set, receives all the entries of each prefix first (see peer_tables)
+    current_routing_entries_set *SafeSet; // All entries of the prefix being selected (input of the heuristic)
+    ases_interest []string;
+    heuristic int;
+    window int;
+    records int;   // Nb of records read
+    last string;   // Prefix of the previous record
+    scattered int; // Nb of prefixes whose entries were not contiguous
+    add_path int;  // Nb of entries of a peer already seen for the prefix (ADD-PATH)
+    add_path_identical int; // Among them, nb of entries with the AS path of another entry of the peer (dropped)
+    heuristic_stats Heuristic_stats;
+}
+
+type pending_group struct {
+    entries []*Rib_entry;
+    last_seen int; // Index of the last record of the prefix
+    scattered bool;
+    peers map[string]struct{}; // Peers seen for the prefix
+}
+
+func new_pending_prefixes (on_select func (string, *Rib_entry), ases_interest []string, heuristic, window int) *pending_prefixes {
+    if window <= 0 {
+        window = 1
+    }
+    return &pending_prefixes{
+        groups: make (map[string]*pending_group),
+        selected: make (map[string]struct{}),
+        late: make (map[string]struct{}),
+        on_select: on_select,
+        current_routing_entries_set: create_safeset (),
+        ases_interest: ases_interest,
+        heuristic: heuristic,
+        window: window,
+    }
+}
+
+/**
+ * Records an entry of the prefix announced by the peer (nil if the entry was dropped, see get_Rib_entry).
+ * Returns false if the peer already announced the prefix (ADD-PATH), or if the prefix was already
+ * selected (the entry is ignored).
+ */
+func (p *pending_prefixes) add (prefix, peer string, entry *Rib_entry) bool {
+    p.records++
+    group, ok := p.groups[prefix]
+    if !ok {
+        if _, done := p.selected[prefix]; done { // Seen again beyond the window: its best entry is already written
+            p.late[prefix] = struct{}{}
+            p.next (prefix)
+            return false
+        }
+        group = &pending_group{peers: make (map[string]struct{})}
+        p.groups[prefix] = group
+    }
+    _, seen := group.peers[peer]
+    if ok && prefix != p.last && !group.scattered && !seen { // The additional paths of a peer (ADD-PATH) may come apart
+        group.scattered = true
+        p.scattered++
+    }
+    group.last_seen = p.records
+    if seen {
+        p.add_path++
+        if entry != nil && group.has_path (peer, entry.as_path) {
+            p.add_path_identical++
+            entry = nil
+        }
+    } else {
+        group.peers[peer] = struct{}{}
+    }
+    if entry != nil {
+        group.entries = append (group.entries, entry)
+    }
+    p.next (prefix)
+    return !seen
+}
+
+/**
+ * Ends the current record: every 'window' records, the prefixes not seen for a window are selected.
+ */
+func (p *pending_prefixes) next (prefix string) {
+    p.last = prefix
+    if p.records % p.window == 0 {
+        p.flush (p.records - p.window)
+    }
+}
+
+/**
+ * Returns true if the peer already announced this AS path in the group.
+ */
+func (group *pending_group) has_path (peer string, as_path []string) bool {
+    for _, entry := range group.entries {
+        if entry.peer == peer && equal_paths (entry.as_path, as_path) {
+            return true
+        }
+    }
+    return false
+}
+
+func equal_paths (a, b []string) bool {
+    if len (a) != len (b) {
+        return false
+    }
+    for i := range a {
+        if a[i] != b[i] {
+            return false
+        }
+    }
+    return true
+}
+
+/**
+ * Applies the heuristic to the prefixes not seen since the record 'before' (included), in the order
+ * of their last record (the order of the dump if it is grouped by prefix).
+ */
+func (p *pending_prefixes) flush (before int) {
+    var prefixes []string
+    for prefix, group := range p.groups {
+        if group.last_seen <= before {
+            prefixes = append (prefixes, prefix)
+        }
+    }
+    sort.Slice (prefixes, func (i, j int) bool { return p.groups[prefixes[i]].last_seen < p.groups[prefixes[j]].last_seen })
+    for _, prefix := range prefixes {
+        p.flush_group (prefix, p.groups[prefix])
+    }
+}
+
+/**
+ * Applies the heuristic to the entries of a pending prefix.
+ */
+func (p *pending_prefixes) flush_group (prefix string, group *pending_group) {
+    delete (p.groups, prefix)
+    p.selected[prefix] = struct{}{}
+    if p.on_group != nil && len (group.entries) != 0 { // Before the heuristic, which may reverse the AS paths
+        p.on_group (prefix, group.entries)
+    }
+    p.select_best (prefix, group.entries)
+}
+
+/**
+ * Applies the heuristic to the entries of a prefix, and hands the best one to on_select.
+ */
+func (p *pending_prefixes) select_best (prefix string, entries []*Rib_entry) {
+    if len (entries) == 0 {
+        return
+    }
+    for i, entry := range entries {
+        p.current_routing_entries_set.unsafe_add (prefix + "_" + strconv.Itoa (i), entry)
+    }
+    selected_prefix, best := apply_heuristic_fc[p.heuristic] (p.current_routing_entries_set, p.ases_interest, &p.heuristic_stats)
+    if best != nil {
+        p.on_select (selected_prefix, best)
+    }
+}
+
+/**
+ * Applies the heuristic to all the pending prefixes (end of the table).
+ */
+func (p *pending_prefixes) flush_all () {
+    p.flush (p.records)
+}
+
+/* ------------------------------------------------- *\
+            BGP entries counter
+\* ------------------------------------------------- */
+
+/**
+ * Generate a function to count BGP dump entries.
+ * - set: where to store the results for all collectors
+ */
+func generate_dump_counter (set *SafeSet, start, end string) func (string) {
+
+    return collector_attempts (func (collector_name string) bool {
+        /* ----------------------- *\
+                RIB Processing
+        \* ----------------------- */
+        // Store all prefixes of a table (no duplicate)
+        memory_set := create_safeset ()
+        if ! read_rib_records (collector_name, start, end, nil, func (line string) { count_bgp_record (line, memory_set) }) {
+            return false
+        }
+
+        /* ----------------------- *\
+               Post Processing
+        \* ----------------------- */
+        set.add (collector_name, len (memory_set.set))
+        return true
+    })
+}
+
+func count_bgp_record (record string, memory_set *SafeSet) {
+    s := strings.Split(record, "|")
+    prefix := s[9]
+    network, valid := check_prefix_validity (prefix)
+    if s[1] == "R" && valid { // Only care about RIB content
+        memory_set.unsafe_add (network.String ())
+    }
+}
+
+/* ------------------------------------------------- *\
+             BGP dependent prefix parser
+\* ------------------------------------------------- */
+
+/**
+ * Generate a function to parse BGP dump files
+ * Closure to have all that we need, self-contained in the function (and not have to passe them as args) 
+ * - set: where to store the results for all collectors
+ * - ases: the ASes of interest
+ * - collectors_to_index: a mapping between a collector and its assigned number.
+ *
+ * Outputs a file of prefixes for which an AS of interest was seen in the AS path, and indicate if this prefix is a
+ * dependent prefix or an up/down prefix.
+ * 
+ * Note: The cmd.Start as well as a goroutine are used to process the output of the command concurrently, as RIB tables
+ * can be quite long.
+ */
+func generate_RIB_parser_dependent (set *SafeSet, ases []string, collectors_to_index map[string]int, break_len int, start, end string) func (string) {
+    ases_set := make (map[string]struct{}, len (ases))
+    for _, as := range ases {
+        ases_set[asn_plain (as)] = struct{}{}
+    }
+
+    return collector_attempts (func (collector_name string) bool {
+
+        /* ----------------------- *\
+               RIB Processing
+        \* ----------------------- */
+        memory_set := create_safeset ()
+        collector_set := create_safeset () // Merged into set once the table is read completely (see collector_attempts)
+        index := collectors_to_index[collector_name]
+        var prefilter []string
+        if g_args.aspath_prefilter {
+            prefilter = ases
+        }
+        if !read_rib_records (collector_name, start, end, prefilter, func (line string) { // Filtering on specific ASes in the AS path
+            parse_bgp_record (line, collector_set, memory_set, ases_set, index, break_len)
+        }) {
+            return false
+        }
+        for subnet := range collector_set.set {
+            add_to_set (set, subnet, index)
+        }
+        return true
+    })
+}
+
+/**
+ * Output format of 'bgpreader': <dump-type>|<elem-type>|<record-ts>|<project>|<collector>|<router-name>|<router-ip>|<peer-ASn>|<peer-IP>|<prefix>|<next-hop-IP>|<AS-path>|<origin-AS>|<communities>|<old-state>|<new-state>
+ * - set: global set where all results are stored
+ * - memory_set: set for a single collector to not redo previous operations
+ * - ases: the ASes of interest (asplain), at least one of which must be in the AS path (see as_path_contains)
+ * - collector_index: the number assigned to current collector
+ */
+func parse_bgp_record (record string, set *SafeSet, memory_set *SafeSet, ases map[string]struct{}, collector_index int, break_len int) {
+    defer recovery_function ()
+
+    s := strings.Split(record, "|")
+    if s[1] != "R" || !as_path_contains (s[11], ases) { // Only care about RIB content, through an AS of interest
+        return
+    }
+    prefix := s[9]
+    network, valid := check_prefix_validity (prefix)
+    if valid {
+        /* --- That prefix was already seen for current collector --- */
+        if memory_set.unsafe_contains (network.String ()) {
+            return
+        }
+        memory_set.unsafe_add (network.String ())
+
+        /* --- Transform subnet into /24 (IPv4) or /48 (IPv6) subnets (or not, depending on prefix_length) ---*/
+        var prefix_length int
+        if break_len > 0 {
+            prefix_length = break_length (network)
+        } else {
+            l,_ := network.Mask.Size ()
+            prefix_length = l
+        }
+        for_each_subnet (network, prefix_length, func (subnet net.IPNet) bool {
+            s := subnet.String ()
+            add_to_set (set, s, collector_index) 
+            memory_set.unsafe_add (s)
+            return true
+        })
+    }
+}
+
+/**
+ * Adds the subnet to the set, and/or updates the BGP collector for
+ * which it was seen.
+ */
+func add_to_set (set *SafeSet, subnet string, index int) {
+    c, ok := set.get (subnet)
+    if ok {
+        current_state, t := c.(uint64) // Type assertion
+        if !t {
+            log.Fatal ("[add_to_set]: type assertion failed")
+        }
+        set.add (subnet, current_state | uint64 (1<<index))
+    } else {
+        set.add (subnet, uint64 (1<<index))
+    }
+}
+
+/* ------------------------------------------------- *\
+            AS PATH and Tier1 analyser
+\* ------------------------------------------------- */
+
+/**
+ * Generate a function to analyze the AS path of BGP dump entries.
+ * - set: where to store the results for all collectors
+ * - tier1: set containing all ASes that are Tiers 1 (0 providers).
+ * 
+ * First result: There are 20% of roads that contain at least two successive Tiers 1.
+ */
+func generate_RIB_as_path_analyser (set *SafeSet, tiers1 map[string]interface{}, start, end string) func (string) {
+
+    return func (collector_name string) {
+
+        /* ----------------------- *\
+                RIB Processing
+        \* ----------------------- */
+        nb_path := 0 // How many paths where the last hop was a Tier1
+        nb_entries := 0 // How many path where the last two hops were Tiers1.
+        ok := read_rib_records (collector_name, start, end, nil, func (line string) {
+            r1,r2 := analyse_bgp_record (line, tiers1)
+            if r1 != -1 {
+                nb_entries += r2
+                nb_path += r1
+            }
+        })
+        if !ok {
+            return
+        }
+
+        /* ----------------------- *\
+               Post Processing
+        \* ----------------------- */
+
+        set.unsafe_append (collector_name, strconv.Itoa (nb_path))
+        set.unsafe_append (collector_name, strconv.Itoa (nb_entries))
+    }
+}
+
+/**
+ * Returns if the last hop was a Tier1 and if the last two hops were Tier1
+ */
+func analyse_bgp_record (record string, tiers1 map[string]interface{}) (last, before_last int) {
+    last, before_last = -1,-1
+    s := strings.Split(record, "|")
+    prefix := s[9]
+    _, valid := check_prefix_validity (prefix)
+    if s[1] == "R" && valid { // Only care about RIB content
+        /* --- Analyze AS path --- */
+        as_path := s[11]
+        ases := strings.Split (as_path, " ")
+        last, before_last = analyse_aspath (ases, tiers1)
+    }
+    return
+}
+
+func analyse_aspath (as_path []string, tiers1 map[string]interface{}) (last, before_last int){
+    last, before_last = -1,-1
+
+    if len (as_path) >= 2 {
+        last_as := as_path[len (as_path) - 1]
+        before_last_as := as_path[len(as_path) - 2]
+        last, before_last = 0,0
+        if _, ok := tiers1[last_as]; ok { // The last hop is a Tier1.
+            last = 1
+            if _, ok2 := tiers1[before_last_as]; ok2 { // The before last hop is also a Tier1.
+                before_last = 1;
+            }
+        }
+    }
+    return
+}
+
+/* --------------------------------------- *\
+ *                  MISC.
+\* --------------------------------------- */
+
+/** 
+ * From a file of prefixes, output a file of addresses
+ */
+func from_prefixes_to_addresses (filename, output_filename string) {
+    new_set := create_safeset ()
+    prefix_parser := generate_prefix_parser (new_set)
+    pool.Launch_pool (cpu_jobs (), filename, prefix_parser)
+    log.Print ("Done parsing file")
+    new_set.write_to_file (output_filename)
+}
+
+/**
+ * Generate a function to parse prefixes and return a random IP address out of it.
+ */
+func generate_prefix_parser (set *SafeSet) func (string){
+    return func (prefix string) {
+        _, network, err := net.ParseCIDR (prefix)
+        if err != nil {
+            log.Print ("[parse_prefix]: " + err.Error())
+            return
+        }
+        ip_address := get_random_ip (rng, network).String () 
+        set.add (ip_address)
+    }
+}
+
+/**
+ * Generates the argument to bgpreader
+ * -k: only records containing that prefix
+ * -A: only records whose AS path matches the regex
+ */
+func generate_args (regex string, prefixes *SafeSet) []string {
+    args := make ([]string, 2 + 2*len (prefixes.set)) // *2 because of the letter -k. +2 because of the single arg for AS path
+    i := 0
+    for prefix, _ := range prefixes.set {
+        args[i*2] = "-k"
+        args[i*2+1] = prefix
+        i++
+    }
+    args[i*2] = "-A"
+    args[i*2+1] = regex
+    return args
+}
+
+/**
+ * Generate a regex that will match any AS path that contains one of the ASes contained in ases
+ * The regex: (^|[^0-9]+)(701|3549)([^0-9]+|$)
+ * The 32-bit ASNs are matched in both notations (asplain and asdot, see asn_dot).
+ * Only a pre-filter (see -aspath-prefilter): the entries are then matched exactly (see as_path_contains).
+ */
+func generate_aspath_regex (ases []string) string {
+    alternatives := make ([]string, 0, len (ases))
+    for _, as := range ases {
+        plain := asn_plain (as)
+        alternatives = append (alternatives, regexp.QuoteMeta (plain))
+        if dot := asn_dot (plain); dot != plain {
+            alternatives = append (alternatives, regexp.QuoteMeta (dot))
+        }
+    }
+    return "(^|[^0-9]+)(" + strings.Join(alternatives, "|") + ")([^0-9]+|$)"
+}
+
+/**
+ * Returns the ASNs of an AS path in the bgpreader format: space separated, AS sets as {AS1,AS2},
+ * confederation segments as (AS1 AS2) or [AS1,AS2]. The 32-bit ASNs in asdot notation (1.10) are
+ * converted to asplain (65546).
+ */
+func as_path_tokens (as_path string) []string {
+    tokens := strings.FieldsFunc (as_path, func (r rune) bool {
+        switch r {
+            case ' ', '\t', '{', '}', ',', '(', ')', '[', ']':
+                return true
+        }
+        return false
+    })
+    for i, token := range tokens {
+        tokens[i] = asn_plain (token)
+    }
+    return tokens
+}
+
+/**
+ * Returns the ASN in asplain notation (unchanged if it is not in asdot notation).
+ */
+func asn_plain (asn string) string {
+    i := strings.Index (asn, ".")
+    if i < 0 {
+        return asn
+    }
+    high, err_high := strconv.ParseUint (asn[:i], 10, 16)
+    low, err_low := strconv.ParseUint (asn[i+1:], 10, 16)
+    if err_high != nil || err_low != nil {
+        return asn
+    }
+    return strconv.FormatUint (high << 16 | low, 10)
+}
+
+/**
+ * Returns the 32-bit ASN in asdot notation (unchanged if it fits in 16 bits or is not an ASN).
+ */
+func asn_dot (asn string) string {
+    n, err := strconv.ParseUint (asn, 10, 32)
+    if err != nil || n < 1 << 16 {
+        return asn
+    }
+    return strconv.FormatUint (n >> 16, 10) + "." + strconv.FormatUint (n & 0xffff, 10)
+}
+
+/**
+ * Returns true if one of the ASes (asplain, see asn_plain) is an ASN of the AS path (exact match,
+ * AS sets included).
+ */
+func as_path_contains (as_path string, ases map[string]struct{}) bool {
+    for _, token := range as_path_tokens (as_path) {
+        if _, ok := ases[token]; ok {
+            return true
+        }
+    }
+    return false
+}
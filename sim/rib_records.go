@@ -0,0 +1,188 @@
+/* ==================================================================================== *\
+     rib_records.go
+
+     RIB records kept for add_as (-keep-records):
+     --------------------------------------------
+     The best route of a prefix depends on the ASes of interest (most_ases_interest
+     tie-break), so the forwarding tables alone cannot give the outputs of a run with more
+     ASes of interest. With -keep-records, ribs_multi keeps the records of each collector
+     (records/<collector>.txt.gz, in the format of 'bgpreader -t ribs' and in the order of
+     the dump), and the parameters of the run (records/ribs_multi.json). add_as replays
+     them (see read_rib_records) with the new list of ASes of interest: its outputs are
+     those of ribs_multi run again on the same dumps, without bgpreader.
+\* ==================================================================================== */
+
+package sim
+
+import (
+    "encoding/json"
+    "fmt"
+    "log"
+    "os"
+    "path/filepath"
+    )
+
+const rib_records_dir = "records"
+
+func rib_records_file (dir, collector_name string) string {
+    return filepath.Join (dir, rib_records_dir, collector_name + ".txt.gz")
+}
+
+func rib_parameters_file (dir string) string {
+    return filepath.Join (dir, rib_records_dir, "ribs_multi.json")
+}
+
+/**
+ * Records of a collector, written as they are read.
+ */
+type rib_records struct {
+    file *CompressedWriter;
+}
+
+/**
+ * Returns the records of the collector (nil without -keep-records). The records of a previous
+ * attempt are truncated.
+ */
+func new_rib_records (output_dir, collector_name string) *rib_records {
+    if !g_args.keep_records {
+        return nil
+    }
+    f := NewCompressedWriter (rib_records_file (output_dir, collector_name), false)
+    if err := f.Open (); err != nil {
+        log.Print ("[rib_records]: ", err) // The collector is not marked as done (see collector_done)
+        return &rib_records{}
+    }
+    return &rib_records{file: f}
+}
+
+func (r *rib_records) write (record string) {
+    if r == nil || r.file == nil {
+        return
+    }
+    r.file.WriteString (record + "\n")
+}
+
+/**
+ * Closes the records. Records whose writing failed are removed (see CompressedWriter).
+ */
+func (r *rib_records) close () {
+    if r == nil || r.file == nil {
+        return
+    }
+    if err := r.file.Close (); err != nil {
+        log.Print ("[rib_records]: ", err)
+    }
+    r.file = nil
+}
+
+/**
+ * Removes the records (failed reading of the collector).
+ */
+func (r *rib_records) abort () {
+    if r == nil || r.file == nil {
+        return
+    }
+    r.file.Abort ()
+    r.close ()
+}
+
+/**
+ * Returns the files of the collector to be written before it is marked as done.
+ */
+func (r *rib_records) names (output_dir, collector_name string) []string {
+    if r == nil {
+        return nil
+    }
+    return []string{rib_records_file (output_dir, collector_name)}
+}
+
+/**
+ * Calls process with each record kept by ribs_multi for the collector (see read_rib_records).
+ */
+func replay_rib_records (records_dir, collector_name string, process func (string)) bool {
+    reader := NewCompressedReader (rib_records_file (records_dir, collector_name))
+    if err := reader.Open (); err != nil {
+        log.Print ("[replay_rib_records]: ", err)
+        return false
+    }
+    defer reader.Close ()
+    scanner := reader.Scanner ()
+    for scanner.Scan () {
+        process (scanner.Text ())
+    }
+    if err := scanner.Err (); err != nil {
+        log.Print ("[replay_rib_records]: ", collector_name, ": ", err)
+        return false
+    }
+    return true
+}
+
+/**
+ * Parameters of ribs_multi on which its outputs depend, besides the records and the ASes of interest.
+ */
+type rib_parameters struct {
+    Heuristic string `json:"heuristic"`;
+    Tiebreaks []string `json:"tiebreaks"`;
+    As_rel_file string `json:"asrel"`;             // Read again by add_as (valley free heuristic)
+    Bogon_asn_policy string `json:"bogon_asn"`;
+    Max_as_path_length int `json:"max_path_len"`;
+    Keep_prepending bool `json:"keep_prepending"`;
+    Ipv6 bool `json:"ipv6"`;
+    Rib_window int `json:"rib_window"`;
+    Compress bool `json:"compress"`;
+    Per_peer bool `json:"per_peer"`;
+    Overlay_max_fraction float64 `json:"overlay_warn"`;
+}
+
+/**
+ * Writes the parameters of the run next to the records of the collectors.
+ */
+func write_rib_parameters (output_dir string, heuristic int) {
+    p := rib_parameters{
+        Heuristic: heuristic_names[heuristic],
+        Tiebreaks: g_args.tiebreaks,
+        As_rel_file: g_args.as_rel_file,
+        Bogon_asn_policy: g_args.bogon_asn_policy,
+        Max_as_path_length: g_args.max_as_path_length,
+        Keep_prepending: g_args.keep_prepending,
+        Ipv6: g_args.ipv6,
+        Rib_window: g_args.rib_window,
+        Compress: g_args.compress,
+        Per_peer: g_args.per_peer,
+        Overlay_max_fraction: g_args.overlay_max_fraction,
+    }
+    content, _ := json.MarshalIndent (p, "", "  ")
+    if err := os.WriteFile (rib_parameters_file (output_dir), append (content, '\n'), 0644); err != nil {
+        log.Fatal ("[write_rib_parameters]: ", err) // The records could not be replayed
+    }
+}
+
+/**
+ * Reads the parameters of the ribs_multi run of dir and sets them in g_args.
+ * Returns the heuristic of the run.
+ */
+func apply_rib_parameters (dir string) (int, error) {
+    content, err := os.ReadFile (rib_parameters_file (dir))
+    if err != nil {
+        return 0, err
+    }
+    var p rib_parameters
+    if err := json.Unmarshal (content, &p); err != nil {
+        return 0, fmt.Errorf ("%s: %v", rib_parameters_file (dir), err)
+    }
+    heuristic, err := parse_heuristic (p.Heuristic)
+    if err != nil {
+        return 0, fmt.Errorf ("%s: %v", rib_parameters_file (dir), err)
+    }
+    g_args.tiebreaks = p.Tiebreaks
+    g_args.as_rel_file = p.As_rel_file
+    g_args.bogon_asn_policy = p.Bogon_asn_policy
+    g_args.max_as_path_length = p.Max_as_path_length
+    g_args.keep_prepending = p.Keep_prepending
+    g_args.ipv6 = p.Ipv6
+    g_args.rib_window = p.Rib_window
+    g_args.compress = p.Compress
+    g_args.per_peer = p.Per_peer
+    g_args.overlay_max_fraction = p.Overlay_max_fraction
+    return heuristic, nil
+}
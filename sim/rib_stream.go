@@ -0,0 +1,138 @@
+/* ==================================================================================== *\
+     rib_stream.go
+
+     Streaming the forwarding table of a collector:
+     ----------------------------------------------
+     The best entry of a prefix is written as soon as the heuristic selects it (see
+     pending_prefixes): to the forwarding table, to the next-hop and previous-hop files of
+     the collector, and to the files of the ASes of interest. The whole table is never held
+     in memory, only the pending groups of entries and the prefixes already selected.
+     The overlays, which need the whole table, are computed afterwards from the forwarding
+     table just written (see process_overlays).
+     A prefix seen again after its selection (its entries are scattered beyond -rib_window)
+     keeps the line written: its later entries are ignored.
+\* ==================================================================================== */
+
+package sim
+
+import (
+    "log"
+    "os"
+    "path/filepath"
+    )
+
+/**
+ * Output files of a collector, fed with the best entry of each prefix.
+ */
+type rib_writers struct {
+    ases_interest []string;
+    forwarding_table string;            // File names (see output_filename)
+    next_hop, prev_hop string;
+    next_hop_ases, prev_hop_ases map[string]string; // AS of interest -> its next-hop (previous-hop) file
+    files map[string]*CompressedWriter; // File name -> its writer, while open
+    nb_ipv4, nb_ipv6 int;               // Prefixes written
+}
+
+func new_rib_writers (output_dir, collector_name string, ases_interest []string) *rib_writers {
+    next_hop := output_dir + "/next-hop_AS/" + collector_name + "/next_hop_AS_" + collector_name + ".txt"
+    prev_hop := output_dir + "/prev-hop_AS/" + collector_name + "/prev_hop_AS_" + collector_name + ".txt"
+    w := &rib_writers{
+        ases_interest: ases_interest,
+        forwarding_table: output_filename (output_dir + "/forwarding_tables/" + collector_name + ".txt"),
+        next_hop: output_filename (next_hop),
+        prev_hop: output_filename (prev_hop),
+        next_hop_ases: make (map[string]string, len (ases_interest)),
+        prev_hop_ases: make (map[string]string, len (ases_interest)),
+    }
+    for _, as := range ases_interest {
+        w.next_hop_ases[as] = output_filename (trim_suffix (next_hop, ".txt") + "_" + as + ".txt")
+        w.prev_hop_ases[as] = output_filename (trim_suffix (prev_hop, ".txt") + "_" + as + ".txt")
+    }
+    return w
+}
+
+/**
+ * Returns the names of all the files.
+ */
+func (w *rib_writers) names () []string {
+    names := []string{w.forwarding_table, w.next_hop, w.prev_hop}
+    for _, as := range w.ases_interest {
+        names = append (names, w.next_hop_ases[as], w.prev_hop_ases[as])
+    }
+    return names
+}
+
+/**
+ * Opens all the files. A file that cannot be opened is logged and
+ * left out: the collector is then not marked as done (see collector_done).
+ */
+func (w *rib_writers) open () {
+    for _, dir := range []string{filepath.Dir (w.next_hop), filepath.Dir (w.prev_hop)} {
+        if err := os.MkdirAll (dir, 0755); err != nil {
+            log.Print ("[rib_writers]: ", err)
+        }
+    }
+    w.files = make (map[string]*CompressedWriter)
+    for _, name := range w.names () {
+        f := NewCompressedWriter (name, false)
+        if err := f.Open (); err != nil {
+            log.Print ("[rib_writers]: ", err)
+            continue
+        }
+        w.files[name] = f
+    }
+}
+
+/**
+ * Writes the best entry of a prefix in all the files (the on_select of pending_prefixes).
+ * Errors are sticky, reported by close.
+ */
+func (w *rib_writers) write (prefix string, entry *Rib_entry) {
+    if entry.ipv6 {
+        w.nb_ipv6++
+    } else {
+        w.nb_ipv4++
+    }
+    w.print (w.forwarding_table, prefix, entry, print_rib_entry)
+    w.print (w.next_hop, prefix, entry, print_next_as)
+    w.print (w.prev_hop, prefix, entry, print_prev_as)
+    for as, hop_AS := range entry.as_to_next_hop_AS {
+        if f, ok := w.files[w.next_hop_ases[as]]; ok {
+            f.WriteString (prefix + "  " + hop_AS + "\n")
+        }
+    }
+    for as, hop_AS := range entry.as_to_prev_hop_AS {
+        if f, ok := w.files[w.prev_hop_ases[as]]; ok {
+            f.WriteString (prefix + "  " + hop_AS + "\n")
+        }
+    }
+}
+
+func (w *rib_writers) print (name, prefix string, entry *Rib_entry, print PrintFn) {
+    if f, ok := w.files[name]; ok {
+        print (f.Writer, prefix, entry)
+    }
+}
+
+/**
+ * Closes all the files. A file whose writing failed is removed (see CompressedWriter).
+ */
+func (w *rib_writers) close () {
+    for _, f := range w.files {
+        if err := f.Close (); err != nil {
+            log.Print ("[rib_writers]: ", err)
+        }
+    }
+    w.files = nil
+}
+
+/**
+ * Closes and removes all the files (failed reading of the collector).
+ */
+func (w *rib_writers) abort () {
+    for _, f := range w.files {
+        f.Abort ()
+        f.Close ()
+    }
+    w.files = nil
+}
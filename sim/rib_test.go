@@ -0,0 +1,427 @@
+package sim
+
+import (
+    "bytes"
+    "log"
+    "net/http"
+    "net/http/httptest"
+    "os"
+    "path/filepath"
+    "reflect"
+    "regexp"
+    "sort"
+    "strconv"
+    "strings"
+    "testing"
+    "time"
+    )
+
+/**
+ * A download that stalls is interrupted and kept in filename.part, then resumed from there.
+ */
+func TestDownloadFileStallResume (t *testing.T) {
+    content := bytes.Repeat ([]byte ("rib dump "), 4096)
+    stalled := true
+    server := httptest.NewServer (http.HandlerFunc (func (w http.ResponseWriter, r *http.Request) {
+        if stalled && r.Header.Get ("Range") == "" {
+            w.Header ().Set ("Content-Length", "36864")
+            w.Write (content[:1000])
+            w.(http.Flusher).Flush ()
+            <-r.Context ().Done () // Until the client gives up
+            return
+        }
+        http.ServeContent (w, r, "rib.bz2", time.Time{}, bytes.NewReader (content))
+    }))
+    defer server.Close ()
+    saved := download_stall_timeout
+    download_stall_timeout = 100*time.Millisecond
+    defer func () { download_stall_timeout = saved }()
+
+    filename := filepath.Join (t.TempDir (), "rib.bz2")
+    if err := download_file (server.URL, filename); err == nil {
+        t.Fatal ("no error on a stalled download")
+    }
+    if info, err := os.Stat (filename + ".part"); err != nil || info.Size () != 1000 {
+        t.Fatalf ("partial download: %v, %v", info, err)
+    }
+    stalled = false
+    if err := download_file (server.URL, filename); err != nil {
+        t.Fatal (err)
+    }
+    if got, err := os.ReadFile (filename); err != nil || !bytes.Equal (got, content) {
+        t.Errorf ("%d bytes (%v), want %d", len (got), err, len (content))
+    }
+}
+
+func TestDownloadFileNotFound (t *testing.T) {
+    server := httptest.NewServer (http.NotFoundHandler ())
+    defer server.Close ()
+    filename := filepath.Join (t.TempDir (), "rib.bz2")
+    if err := download_file (server.URL, filename); err == nil {
+        t.Error ("no error on 404")
+    }
+    if _, err := os.Stat (filename); err == nil {
+        t.Error ("file created on 404")
+    }
+}
+
+/**
+ * Entries of a prefix scattered within the window form a single group; a prefix seen again after
+ * its selection keeps it, its later entries being ignored.
+ */
+func TestPendingPrefixesWindow (t *testing.T) {
+    groups := make (map[string]int) // Prefix -> nb of entries handed to on_group
+    selected := make (map[string]int)
+    p := new_pending_prefixes (func (prefix string, entry *Rib_entry) { selected[prefix]++ }, nil, 0, 3)
+    p.on_group = func (prefix string, entries []*Rib_entry) { groups[prefix] += len (entries) }
+    entry := func (peer string) *Rib_entry { return &Rib_entry{as_path: []string{peer, "900"}, peer: peer} }
+    for _, record := range [][2]string {
+        {"10.0.0.0/8", "1"}, {"20.0.0.0/8", "1"}, {"10.0.0.0/8", "2"}, // Scattered within the window
+        {"30.0.0.0/8", "1"}, {"30.0.0.0/8", "2"}, {"30.0.0.0/8", "3"}, {"30.0.0.0/8", "4"}, {"30.0.0.0/8", "5"},
+        {"20.0.0.0/8", "2"}, // Seen again after its selection
+    } {
+        p.add (record[0], record[1], entry (record[1]))
+    }
+    p.flush_all ()
+    if groups["10.0.0.0/8"] != 2 || groups["20.0.0.0/8"] != 1 || groups["30.0.0.0/8"] != 5 {
+        t.Errorf ("groups: %v", groups)
+    }
+    for prefix, n := range selected {
+        if n != 1 {
+            t.Errorf ("%s selected %d times", prefix, n)
+        }
+    }
+    if len (selected) != 3 || p.scattered != 1 || len (p.late) != 1 {
+        t.Errorf ("%d selected, %d scattered, late %v", len (selected), p.scattered, p.late)
+    }
+}
+
+/**
+ * A path of reserved ASNs only is counted once, as a reserved ASN drop, with the strip policy too.
+ */
+func TestSanitizeASPathAllReserved (t *testing.T) {
+    defer func (policy string) { g_args.bogon_asn_policy = policy }(g_args.bogon_asn_policy)
+    for _, policy := range []string{"strip", "drop"} {
+        g_args.bogon_asn_policy = policy
+        stats := &Path_sanitation_stats{}
+        if path, keep := sanitize_as_path ([]string{"64512", "23456", "65535"}, stats); keep {
+            t.Errorf ("%s: kept %v", policy, path)
+        }
+        if *stats != (Path_sanitation_stats{dropped_bogon: 1}) {
+            t.Errorf ("%s: %+v, want a single reserved ASN drop", policy, *stats)
+        }
+    }
+    g_args.bogon_asn_policy = "strip"
+    stats := &Path_sanitation_stats{}
+    if path, keep := sanitize_as_path ([]string{"3356", "64512", "100"}, stats); !keep || len (path) != 2 || *stats != (Path_sanitation_stats{stripped: 1}) {
+        t.Errorf ("%v (%v), %+v", path, keep, *stats)
+    }
+}
+
+/**
+ * The next and previous-hop ASes of AS 100 are taken on the path with prepending collapsed (as in
+ * testdata/prepending), or on the raw path with -keep-prepending. An AS of interest first or last in
+ * the path is its own previous or next hop. The AS path of the entry is left untouched.
+ */
+func TestRibEntryPrepending (t *testing.T) {
+    defer func (keep bool) { g_args.keep_prepending = keep }(g_args.keep_prepending)
+    for _, c := range []struct {
+        name string;
+        path string;
+        keep_prepending bool;
+        hops string;  // hop_path
+        next string;  // "" if 100 is not in the path
+        prev string;
+    } {
+        {"prepended at the origin", "1 2 100 100 100", false, "1 2 100", "100", "2"},
+        {"prepended in the middle", "1 100 100 100 200", false, "1 100 200", "200", "1"},
+        {"first, prepended", "100 100 300", false, "100 300", "300", "100"},
+        {"last", "1 100", false, "1 100", "100", "1"},
+        {"absent", "1 2 3", false, "1 2 3", "", ""},
+        {"keep-prepending", "1 100 100 100 200", true, "1 100 100 100 200", "100", "1"},
+    } {
+        g_args.keep_prepending = c.keep_prepending
+        ases := strings.Fields (c.path)
+        if hops := hop_path (ases); strings.Join (hops, " ") != c.hops {
+            t.Errorf ("%s: hop path %v, want %s", c.name, hops, c.hops)
+        }
+        if next, prev := get_prev_or_next_as ("100", hop_path (ases), 1), get_prev_or_next_as ("100", hop_path (ases), -1); next != c.next || prev != c.prev {
+            t.Errorf ("%s: next %q, prev %q, want %q and %q", c.name, next, prev, c.next, c.prev)
+        }
+
+        entry := new_Rib_entry (ases, []string{"100", "900"})
+        if strings.Join (entry.as_path, " ") != c.path {
+            t.Errorf ("%s: AS path %v, want it untouched", c.name, entry.as_path)
+        }
+        next, prev := map[string]string{}, map[string]string{}
+        if c.next != "" {
+            next["100"], prev["100"] = c.next, c.prev
+        }
+        if !reflect.DeepEqual (entry.as_to_next_hop_AS, next) || !reflect.DeepEqual (entry.as_to_prev_hop_AS, prev) {
+            t.Errorf ("%s: next %v, prev %v, want %v and %v", c.name, entry.as_to_next_hop_AS, entry.as_to_prev_hop_AS, next, prev)
+        }
+    }
+}
+
+func TestASNNotations (t *testing.T) {
+    for _, c := range []struct {
+        asn string;
+        plain string; // asn_plain
+        dot string;   // asn_dot of the asplain
+    } {
+        {"3356", "3356", "3356"},
+        {"65535", "65535", "65535"},
+        {"65536", "65536", "1.0"},
+        {"4200000001", "4200000001", "64086.59905"},
+        {"64086.59905", "4200000001", "64086.59905"},
+        {"1.10", "65546", "1.10"},
+        {"65536.1", "65536.1", "65536.1"}, // Not asdot (more than 16 bits): unchanged
+        {"1.2.3", "1.2.3", "1.2.3"},
+        {"AS3356", "AS3356", "AS3356"},
+    } {
+        if plain, dot := asn_plain (c.asn), asn_dot (asn_plain (c.asn)); plain != c.plain || dot != c.dot {
+            t.Errorf ("%s: asplain %s, asdot %s, want %s and %s", c.asn, plain, dot, c.plain, c.dot)
+        }
+    }
+}
+
+/**
+ * The pre-filter (generate_aspath_regex) lets through every path the exact match (as_path_contains)
+ * keeps, and possibly more; neither matches an ASN that only contains the AS of interest.
+ */
+func TestASPathMatch (t *testing.T) {
+    for _, c := range []struct {
+        path string;
+        as string;
+        regex bool;
+        exact bool;
+    } {
+        {"1 {3356,174}", "3356", true, true},
+        {"1 {174,3356}", "3356", true, true},
+        {"1 174 {33560,1742}", "3356", false, false},
+        {"1 3356 3356 3356 2", "3356", true, true},
+        {"1 13356 2", "3356", false, false},
+        {"3356", "3356", true, true},
+        {"1 4200000001 3356", "4200000001", true, true},
+        {"1 64086.59905", "4200000001", true, true},
+        {"1 4200000001 3356", "64086.59905", true, true},
+        {"1 64086.59905", "64086.59905", true, true},
+        {"1 64086.599050", "4200000001", false, false},
+        {"(65001 65002) 3356", "65002", true, true},
+        {"1 [65001,3356]", "3356", true, true},
+    } {
+        regex := regexp.MustCompile (generate_aspath_regex ([]string{c.as}))
+        exact := as_path_contains (c.path, map[string]struct{}{asn_plain (c.as): {}})
+        if regex.MatchString (c.path) != c.regex || exact != c.exact {
+            t.Errorf ("%s in %q: regex %t, exact %t, want %t and %t", c.as, c.path, regex.MatchString (c.path), exact, c.regex, c.exact)
+        }
+    }
+    if regex := generate_aspath_regex ([]string{"3356", "4200000001"}); regex != `(^|[^0-9]+)(3356|4200000001|64086\.59905)([^0-9]+|$)` {
+        t.Errorf ("regex %s", regex)
+    }
+}
+
+/**
+ * The dependent prefixes of the records of testdata/aspath_match (in the format of bgpreader): exact
+ * ASNs, inside AS sets, with prepending, and 32-bit ASNs in both notations. With the pre-filter, the
+ * records are first matched by the regex (as bgpreader -A does): 27.0.0.0/16, whose peer is AS 3356,
+ * goes through the regex but not through the exact match.
+ */
+func TestDependentPrefixesASPathMatch (t *testing.T) {
+    content, err := os.ReadFile (filepath.Join ("..", "testdata", "aspath_match", "records.txt"))
+    if err != nil {
+        t.Fatal (err)
+    }
+    records := strings.Split (strings.TrimSpace (string (content)), "\n")
+    for _, prefilter := range []bool{false, true} {
+        for as, want := range map[string]string {
+            "3356": "20.0.0.0/16 22.0.0.0/16 24.0.0.0/16 26.0.0.0/16",
+            "4200000001": "24.0.0.0/16 25.0.0.0/16",
+            "64086.59905": "24.0.0.0/16 25.0.0.0/16",
+        } {
+            regex := regexp.MustCompile (generate_aspath_regex ([]string{as}))
+            set, memory_set := create_safeset (), create_safeset ()
+            for _, record := range records {
+                if !prefilter || regex.MatchString (record) {
+                    parse_bgp_record (record, set, memory_set, map[string]struct{}{asn_plain (as): {}}, 0, 0)
+                }
+            }
+            prefixes := make ([]string, 0, len (set.set))
+            for prefix := range set.set {
+                prefixes = append (prefixes, prefix)
+            }
+            sort.Strings (prefixes)
+            if got := strings.Join (prefixes, " "); got != want {
+                t.Errorf ("AS %s (pre-filter %t): %s, want %s", as, prefilter, got, want)
+            }
+        }
+    }
+}
+
+/**
+ * Returns the log of the package until the end of the test (written to stderr again afterwards).
+ */
+func capture_log (t *testing.T) *bytes.Buffer {
+    var logs bytes.Buffer
+    log.SetOutput (&logs)
+    t.Cleanup (func () { log.SetOutput (os.Stderr) })
+    return &logs
+}
+
+/**
+ * Runs 'rib_parsing ribs_multi' in the test process, with the given heuristic, on the ASes, collectors and
+ * dumps (dump, one directory per collector) of testdata/<dir>, and the AS relationships of the golden
+ * universe. Returns the output directory and the log of the run. The arguments and the package variables
+ * set by the run are restored with the test.
+ */
+func run_ribs_multi (t *testing.T, dir, dump string, heuristic int, flags ...string) (string, string) {
+    t.Helper ()
+    saved, saved_neighbors := g_args, as_neighbors
+    logs := capture_log (t)
+    t.Cleanup (func () {
+        g_args, as_neighbors = saved, saved_neighbors
+        summary.mux.Lock ()
+        summary.path, summary.run, summary.stage = "", run_summary{}, ""
+        summary.mux.Unlock ()
+        failed_collectors.mux.Lock ()
+        failed_collectors.names = nil
+        failed_collectors.mux.Unlock ()
+    })
+    d, out := filepath.Join ("..", "testdata", dir), t.TempDir ()
+    args := []string{"ribs_multi", "-quiet", "-a", filepath.Join (d, "ases.txt"), "-c", filepath.Join (d, "collectors.txt"), "-h", strconv.Itoa (heuristic),
+        "-asrel", filepath.Join ("..", "testdata", "golden", "universe", "as_rel.txt"), "-mrt-dir", filepath.Join (d, dump), "-o", out}
+    parse_ribs (handle_args_rib_parsing_multi (append (args, flags...)))
+    return out, logs.String ()
+}
+
+/**
+ * Returns the lines of a file of a ribs_multi output directory, sorted.
+ */
+func sorted_lines (t *testing.T, filename string) []string {
+    t.Helper ()
+    content, err := os.ReadFile (filename)
+    if err != nil {
+        t.Fatal (err)
+    }
+    lines := strings.Split (strings.TrimRight (string (content), "\n"), "\n")
+    sort.Strings (lines)
+    return lines
+}
+
+/**
+ * Compares the sorted lines of a file with those of an expected file of testdata.
+ */
+func check_sorted_lines (t *testing.T, expected string, lines []string) {
+    t.Helper ()
+    if want := sorted_lines (t, expected); !reflect.DeepEqual (lines, want) {
+        t.Errorf ("%q, want %q (%s)", lines, want, expected)
+    }
+}
+
+/**
+ * ribs_multi collapses prepending before extracting the next and previous-hop ASes, for both heuristics
+ * (AS of interest 100, one route per prefix):
+ *   20.0.0.0/16  1 100 100 100 200    prepended in the middle
+ *   21.0.0.0/16  1 2 100 100 100      prepended at the origin (last)
+ *   22.0.0.0/16  100 100 300          first (peer AS), prepended
+ *   23.0.0.0/16  1 1 1 100 400 400    prepending around the AS of interest
+ *   24.0.0.0/16  1 100                last, no prepending
+ * Also -keep-prepending (outputs of older versions), and add_as (AS 1, prepended on 23.0.0.0/16).
+ */
+func TestRibsMultiPrepending (t *testing.T) {
+    d := filepath.Join ("..", "testdata", "prepending")
+    for heuristic := 0; heuristic < 2; heuristic++ {
+        out, _ := run_ribs_multi (t, "prepending", "dump", heuristic, "-keep-records")
+        next_hop := filepath.Join (out, "next-hop_AS", "rrc00", "next_hop_AS_rrc00.txt")
+        check_sorted_lines (t, filepath.Join (d, "expected_next_hop.txt"), sorted_lines (t, next_hop))
+        check_sorted_lines (t, filepath.Join (d, "expected_prev_hop.txt"), sorted_lines (t, filepath.Join (out, "prev-hop_AS", "rrc00", "prev_hop_AS_rrc00.txt")))
+
+        keep, _ := run_ribs_multi (t, "prepending", "dump", heuristic, "-keep-prepending")
+        check_sorted_lines (t, filepath.Join (d, "expected_next_hop_keep_prepending.txt"), sorted_lines (t, filepath.Join (keep, "next-hop_AS", "rrc00", "next_hop_AS_rrc00.txt")))
+
+        add_ases_to_ribs (handle_args_rib_parsing_add_as ([]string{"add_as", "-quiet", "-d", out, "-as", "1"}))
+        added := make ([]string, 0)
+        for _, line := range sorted_lines (t, next_hop) {
+            if strings.Contains (line, " 1 ") {
+                added = append (added, line)
+            }
+        }
+        check_sorted_lines (t, filepath.Join (d, "expected_next_hop_add_as.txt"), added)
+    }
+}
+
+/**
+ * Several paths of a peer for a prefix (ADD-PATH) are normal input for ribs_multi, for both heuristics.
+ * AS of interest: 100. Peers 1@192.0.2.1 and 2@192.0.2.2. The dump of testdata/add_path holds, in this order:
+ *   20.0.0.0/16  1 100 300 | 2 100 300                     (RIB_IPV4_UNICAST)
+ *   21.0.0.0/16  1 100 400                                 (RIB_IPV4_UNICAST)
+ *   20.0.0.0/16  1 100 300 | 1 5 6 100 300                 (ADD-PATH, apart from the first paths of 1)
+ *   22.0.0.0/16  2 100 500 | 2 100 500 | 2 7 100 500       (ADD-PATH)
+ * 4 additional paths, 2 of them identical to another path of their peer (dropped); no scattered prefix;
+ * each peer contributed 2 prefixes.
+ */
+func TestRibsMultiAddPath (t *testing.T) {
+    for heuristic := 0; heuristic < 2; heuristic++ {
+        out, logs := run_ribs_multi (t, "add_path", "dump", heuristic, "-per-peer")
+        for _, c := range []struct {
+            file string;
+            want []string;
+        } {
+            {"collectors/add_path.txt", []string{"rrc00 4 2"}},
+            {"collectors/all_BGP_peers.txt", []string{"rrc00 1 192.0.2.1 2", "rrc00 2 192.0.2.2 2"}},
+            {"forwarding_tables/rrc00/1@192.0.2.1.txt", []string{"20.0.0.0/16 1 100 300", "21.0.0.0/16 1 100 400"}},
+            {"forwarding_tables/rrc00/2@192.0.2.2.txt", []string{"20.0.0.0/16 2 100 300", "22.0.0.0/16 2 100 500"}},
+        } {
+            if lines := sorted_lines (t, filepath.Join (out, c.file)); !reflect.DeepEqual (lines, c.want) {
+                t.Errorf ("heuristic %d: %s: %q, want %q", heuristic, c.file, lines, c.want)
+            }
+        }
+        prefixes := make ([]string, 0)
+        for _, line := range sorted_lines (t, filepath.Join (out, "forwarding_tables", "rrc00.txt")) {
+            prefixes = append (prefixes, strings.Fields (line)[0])
+        }
+        if want := []string{"20.0.0.0/16", "21.0.0.0/16", "22.0.0.0/16"}; !reflect.DeepEqual (prefixes, want) {
+            t.Errorf ("heuristic %d: prefixes %q, want %q", heuristic, prefixes, want)
+        }
+        if strings.Contains (logs, "scattered") {
+            t.Errorf ("heuristic %d: scattered prefixes:\n%s", heuristic, logs)
+        }
+    }
+}
+
+/**
+ * ribs_multi selects the same best entries when the RIB entries of a prefix are scattered across the
+ * dump (testdata/rib_interleaved/interleaved) as when they are grouped (grouped), for both heuristics.
+ * Both dumps hold the same entries; 11.0.0.0/16, 20.0.0.0/16 and 50.0.0.0/16 are split in two RIB records.
+ * With -rib_window 1, every scattered prefix is seen again after its selection (see pending_prefixes):
+ * its later entries are ignored, with a warning. 50.0.0.0/16 (1 10 30 40 900, 3 10 20 900 | 2 7 10 30 40 900
+ * later) then gets another best entry with the valley free heuristic, the other prefixes do not.
+ */
+func TestRibsMultiInterleaved (t *testing.T) {
+    files := []string{"forwarding_tables/rrc00.txt", "next-hop_AS/rrc00/next_hop_AS_rrc00.txt", "prev-hop_AS/rrc00/prev_hop_AS_rrc00.txt"}
+    without := func (lines []string, prefix string) []string {
+        kept := make ([]string, 0, len (lines))
+        for _, line := range lines {
+            if prefix == "" || !strings.HasPrefix (line, prefix + " ") {
+                kept = append (kept, line)
+            }
+        }
+        return kept
+    }
+    for heuristic := 0; heuristic < 2; heuristic++ {
+        grouped, _ := run_ribs_multi (t, "rib_interleaved", "grouped", heuristic)
+        interleaved, _ := run_ribs_multi (t, "rib_interleaved", "interleaved", heuristic)
+        window_1, logs := run_ribs_multi (t, "rib_interleaved", "interleaved", heuristic, "-rib_window", "1")
+        if !strings.Contains (logs, "seen again after their selection, beyond -rib_window") {
+            t.Errorf ("heuristic %d: no prefix seen again after its selection with -rib_window 1", heuristic)
+        }
+        for _, file := range files {
+            want := sorted_lines (t, filepath.Join (grouped, file))
+            for out, skipped := range map[string]string{interleaved: "", window_1: "50.0.0.0/16"} {
+                if lines := without (sorted_lines (t, filepath.Join (out, file)), skipped); !reflect.DeepEqual (lines, without (want, skipped)) {
+                    t.Errorf ("heuristic %d: %s (without %q): %q, want %q", heuristic, file, skipped, lines, without (want, skipped))
+                }
+            }
+        }
+    }
+}
@@ -3,27 +3,24 @@
 
      Partial RocketFuel simulator: Evaluation of the different Path 
      Reduction Techniques on TNT data:
-     - Ingress Reduction
+     - Ingress Reduction (and RTTs of the ingresses)
      - Next-Hop AS Reduction 
      - Directed Probing
      - Egress Reduction 
 \* ================================================================= */
 
-package main
+package sim
 
 import (
     "log"
-    "time"
     "strings"
     "bufio"
-    "math/rand"
     "sort"
     "os"
     "strconv"
     "fmt"
     "os/exec"
-    "math/bits"
-    pool "github.com/Emeline-1/pool")
+    "math/bits")
 
 /* --------------------------------------- *\
  *          Ingress Reduction
@@ -32,25 +29,23 @@ import (
 // a trace from the VP that I want. 
 // But it doesn't matter, as we already have this analysis. 
 func ingress_reduction (ases_file, output_dir string) {
-    traces,_,_,_,target_to_vp,_,_ := parse_warts ()
+    ds := parse_warts_args ()
     ases,_ := read_whitespace_delimited_file (ases_file)
 
-    /* --- Process traces --- */
+    /* --- Process traces (of every VP, not only the one kept per /24) --- */
     vp_as_ingresses := make (map[string]map[string]map[string]struct{})
     as_vpNextAs_egresses := make (map[string]map[string]map[string]struct{})
 
-    for dst, trace_i := range traces.set {
+    ds.vp_traces.Range (func (_ string, trace_i interface{}) bool {
         if trace, t := trace_i.(*Trace); t {
             /* -- Loop over hops -- */
             var ingress string
-            for i,hop := range (*trace) {
+            for i,hop := range trace.hops {
                 // Ingress reduction
                 if hop.ingress == true {
                     for _,as := range ases {
                         if as == hop.asn { // We have an ingress for one of the ASes of interest
-                            src_i,_ := target_to_vp.unsafe_get (dst)
-                            src,_ := src_i.(string)
-                            append_ingress (&vp_as_ingresses, src , as, hop.addr)
+                            append_ingress (&vp_as_ingresses, trace.vp, as, hop.addr)
                             ingress = hop.addr
                         }
                     }
@@ -58,8 +53,8 @@ func ingress_reduction (ases_file, output_dir string) {
                 // Next hop AS reduction
                 if hop.egress == true {
                     for _,as := range ases {
-                        if as == hop.asn { // We have an egress for one of the ASes of interest
-                            append_ingress (&as_vpNextAs_egresses, as, ingress + (*trace)[i+1].asn, hop.addr)
+                        if as == hop.asn { // We have an egress for one of the ASes of interest (followed by a public hop, see commit_trace)
+                            append_ingress (&as_vpNextAs_egresses, as, ingress + trace.hops[next_public_hop (trace.hops, i)].asn, hop.addr)
                         }
                     }
                 }
@@ -67,8 +62,8 @@ func ingress_reduction (ases_file, output_dir string) {
         } else {
             log.Fatal ("[parse_warts]: unexpected type:", fmt.Sprintf("%T", trace_i))
         }
-        
-    }
+        return true
+    })
 
     print_table_to_file (vp_as_ingresses, ases, output_dir + "/ingresses_per_vp.txt")
     // --- Global stat on Next-hop ASes --- //
@@ -97,6 +92,145 @@ func append_ingress (set *map[string]map[string]map[string]struct{}, src string,
     }
 }
 
+/**
+ * Ingress of each VP into the AS of interest, as observed in the traces of the warts data set (strategy_traces):
+ * a VP almost always enters an AS through the same ingress (see ingress_reduction). The ingress of a VP is the
+ * first hop of the AS of interest in most of its traces entering the AS (ties: lowest address).
+ * VPs whose traces never enter the AS of interest have no ingress.
+ */
+func vp_ingresses (as_interest string) map[string]string {
+    counts := make (map[string]map[string]int)
+    strategy_traces.Range (func (_ string, trace_i interface{}) bool {
+        trace, t := trace_i.(*Trace)
+        if !t {
+            log.Fatal ("[vp_ingresses]: unexpected type:", fmt.Sprintf("%T", trace_i))
+        }
+        for _, hop := range trace.hops {
+            if hop.ingress && hop.asn == as_interest {
+                if _, ok := counts[trace.vp]; !ok {
+                    counts[trace.vp] = make (map[string]int)
+                }
+                counts[trace.vp][hop.addr]++
+                break
+            }
+        }
+        return true
+    })
+
+    ingresses := make (map[string]string, len (counts))
+    for vp, addr_counts := range counts {
+        best := ""
+        for addr, count := range addr_counts {
+            if best == "" || count > addr_counts[best] || (count == addr_counts[best] && addr < best) {
+                best = addr
+            }
+        }
+        ingresses[vp] = best
+    }
+    return ingresses
+}
+
+/**
+ * VP_mapper giving, for a target, the ingresses into the AS of interest of the VPs that probed it (see vp_ingresses)
+ * rather than the VPs themselves. A VP without ingress stands for its own ingress.
+ */
+type ingress_mapper struct {
+    target_to_vp VP_mapper;
+    ingresses map[string]string; // VP -> ingress
+}
+
+func (mapper *ingress_mapper) ingress (vp string) string {
+    if ingress, ok := mapper.ingresses[vp]; ok {
+        return ingress
+    }
+    return "vp_" + vp
+}
+
+func (mapper *ingress_mapper) Get (target string) ([]string, bool) {
+    target_vps, present := mapper.target_to_vp.Get (target)
+    if !present {
+        return nil, false
+    }
+    set := make (map[string]struct{}, len (target_vps))
+    for _, vp := range target_vps {
+        set[mapper.ingress (vp)] = struct{}{}
+    }
+    target_ingresses := _get_keys (&set)
+    sort.Strings (target_ingresses)
+    return target_ingresses, true
+}
+
+/* --------------------------------------- *\
+ *          Ingress RTTs
+\* --------------------------------------- */
+
+/**
+ * RTT distribution of the ingress hops of each AS of interest, over the traces of every VP
+ * (the hops without RTT are left out). Outputs, in output_dir:
+ * - ingress_rtts_summary.txt: for each AS of interest, the number of ingress hops with an RTT, the number of
+ *   distinct ingresses, and the percentiles 10, 25, 50, 75 and 90 of their RTTs (ms);
+ * - ingress_rtts_<AS>.txt: for each ingress of the AS, the number of traces entering through it with an RTT,
+ *   and the minimum, median and 90th percentile of their RTTs (ms), by increasing median.
+ */
+func trace_rtt (ases_file, output_dir string) {
+    ds := parse_warts_args ()
+    ases,_ := read_whitespace_delimited_file (ases_file)
+    interest := slice_to_map (ases)
+
+    as_ingress_rtts := make (map[string]map[string]DataFloat64) // AS -> ingress -> RTTs
+    ds.vp_traces.Range (func (_ string, trace_i interface{}) bool {
+        trace, t := trace_i.(*Trace)
+        if !t {
+            log.Fatal ("[trace_rtt]: unexpected type:", fmt.Sprintf("%T", trace_i))
+        }
+        for _, hop := range trace.hops {
+            if _, ok := interest[hop.asn]; !ok || !hop.ingress || hop.rtt < 0 {
+                continue
+            }
+            if _, ok := as_ingress_rtts[hop.asn]; !ok {
+                as_ingress_rtts[hop.asn] = make (map[string]DataFloat64)
+            }
+            as_ingress_rtts[hop.asn][hop.addr] = append (as_ingress_rtts[hop.asn][hop.addr], hop.rtt)
+        }
+        return true
+    })
+
+    write := func (filename string, lines []string) {
+        content := ""
+        if len (lines) != 0 {
+            content = strings.Join (lines, "\n") + "\n"
+        }
+        if err := os.WriteFile (filename, []byte (content), 0644); err != nil {
+            log.Print ("[trace_rtt]: ", err)
+        }
+    }
+    summary := make ([]string, 0, len (ases))
+    for _, as := range ases {
+        all := make (DataFloat64, 0, 100)
+        lines := make ([]string, 0, len (as_ingress_rtts[as]))
+        medians := make (map[string]float64, len (as_ingress_rtts[as]))
+        for ingress, rtts := range as_ingress_rtts[as] {
+            all = append (all, rtts...)
+            medians[ingress] = rtts.Percentile (50)
+            lines = append (lines, ingress)
+        }
+        sort.Slice (lines, func (i, j int) bool {
+            if medians[lines[i]] != medians[lines[j]] {
+                return medians[lines[i]] < medians[lines[j]]
+            }
+            return lines[i] < lines[j]
+        })
+        for i, ingress := range lines {
+            rtts := as_ingress_rtts[as][ingress]
+            lines[i] = fmt.Sprintf ("%s %d %.3f %.3f %.3f", ingress, len (rtts), rtts.Percentile (0), medians[ingress], rtts.Percentile (90))
+        }
+        write (output_dir + "/ingress_rtts_" + as + ".txt", lines)
+        summary = append (summary, fmt.Sprintf ("%s %d %d %.3f %.3f %.3f %.3f %.3f", as, len (all), len (lines),
+            all.Percentile (10), all.Percentile (25), all.Percentile (50), all.Percentile (75), all.Percentile (90)))
+    }
+    write (output_dir + "/ingress_rtts_summary.txt", summary)
+}
+
 /* --------------------------------------- *\
  *          AS Next-Hop Reduction
 \* --------------------------------------- */
@@ -124,7 +258,7 @@ func analyse_next_hops (outdir, ases_file, collectors_file, dir string) {
         prefix_nextASes := make (map[string]map[string]interface{})
 
         for _, collector := range collectors {
-            file := dir + "/" + collector + "/next_hop_AS_" + collector + "_" + AS + ".txt" // (format: prefix next_as)
+            file := plain_or_gz (dir + "/" + collector + "/next_hop_AS_" + collector + "_" + AS + ".txt") // (format: prefix next_as)
             log.Println (file)
 
             reader := NewCompressedReader (file)
@@ -176,8 +310,12 @@ func analyse_next_hops (outdir, ases_file, collectors_file, dir string) {
  * - ases_file: the file containing the ases of interest (white space separated)
  * - collectors_file: the file containing the collectors (new line separated)
  * - dir: the directory where to find the 'next-hop_AS' parsing results of 'rib_multi'
+ * - disagreements: whether to also write the prefixes for which the collectors disagreed
+ *   (disagreements_next_AS_<AS>.txt, format: prefix next_as:nb_collectors ..., most frequent first)
+ *
+ * When the collectors disagree on the next-hop AS of a prefix, the most frequent one is kept (ties: lowest ASN).
  */
-func merge_next_hops (outdir, ases_file, collectors_file, dir string) {
+func merge_next_hops (outdir, ases_file, collectors_file, dir string, disagreements bool) {
 
     exec.Command("bash", "-c", "mkdir " + outdir).Run()
     ases,_ := read_whitespace_delimited_file (ases_file)
@@ -185,11 +323,12 @@ func merge_next_hops (outdir, ases_file, collectors_file, dir string) {
 
     for _, AS := range ases {
         // key: the prefix
-        // value: the next-hop AS
-        prefix_nextAS := make (map[string]interface{})
+        // value: key: a next-hop AS
+        //        value: the number of collectors that saw it
+        prefix_nextAS_counts := make (map[string]map[string]int)
 
         for _, collector := range collectors {
-            file := dir + "/" + collector + "/next_hop_AS_" + collector + "_" + AS + ".txt" // (format: prefix next_as)
+            file := plain_or_gz (dir + "/" + collector + "/next_hop_AS_" + collector + "_" + AS + ".txt") // (format: prefix next_as)
             log.Println (file)
 
             reader := NewCompressedReader (file)
@@ -203,17 +342,71 @@ func merge_next_hops (outdir, ases_file, collectors_file, dir string) {
                 prefix := line[0]
                 nextAS := line[1]
 
-                prefix_nextAS[prefix] = nextAS // Last one of the collectors to be read will be the one that is kept.
+                if _, ok := prefix_nextAS_counts[prefix]; !ok {
+                    prefix_nextAS_counts[prefix] = make (map[string]int)
+                }
+                prefix_nextAS_counts[prefix][nextAS]++
             }
             reader.Close ()
         }
 
+        // key: the prefix
+        // value: the next-hop AS
+        prefix_nextAS := make (map[string]interface{})
+        disagreeing := make (map[string]interface{})
+        for prefix, counts := range prefix_nextAS_counts {
+            ranked := rank_next_hops (counts)
+            prefix_nextAS[prefix] = ranked[0]
+            if len (ranked) > 1 {
+                distribution := make ([]string, 0, len (ranked))
+                for _, nextAS := range ranked {
+                    distribution = append (distribution, nextAS + ":" + strconv.Itoa (counts[nextAS]))
+                }
+                disagreeing[prefix] = strings.Join (distribution, " ")
+            }
+        }
+        log.Println ("[merge_next_hops]: AS", AS, ":", len (disagreeing), "prefixes out of", len (prefix_nextAS), "with collectors disagreeing on the next-hop AS")
+
         s := create_safeset ()
         s.set = prefix_nextAS
         s.write_to_file (outdir + "/merged_next_AS_" + AS + ".txt")
+        if disagreements {
+            s = create_safeset ()
+            s.set = disagreeing
+            s.write_to_file (outdir + "/disagreements_next_AS_" + AS + ".txt")
+        }
     }
 }
 
+/**
+ * Returns the next-hop ASes by decreasing number of collectors that saw them (ties: lowest ASN first).
+ */
+func rank_next_hops (counts map[string]int) []string {
+    ranked := make ([]string, 0, len (counts))
+    for nextAS := range counts {
+        ranked = append (ranked, nextAS)
+    }
+    sort.Slice (ranked, func (i, j int) bool {
+        if counts[ranked[i]] != counts[ranked[j]] {
+            return counts[ranked[i]] > counts[ranked[j]]
+        }
+        return asn_less (ranked[i], ranked[j])
+    })
+    return ranked
+}
+
+/**
+ * Compares two ASNs numerically (e.g., 701 < 3356), falling back on the string order for non numeric ASNs.
+ */
+func asn_less (a, b string) bool {
+    n_a, err_a := strconv.Atoi (a)
+    n_b, err_b := strconv.Atoi (b)
+    if err_a != nil || err_b != nil {
+        return a < b
+    }
+    return n_a < n_b
+}
+
 /* --------------------------------------- *\
  *  Directed Probing and Egress Reduction
 \* --------------------------------------- */
@@ -222,9 +415,7 @@ func merge_next_hops (outdir, ases_file, collectors_file, dir string) {
  * Read RIB tables and retrieve prefixes where the AS of interest was seen in the AS path.
  * The prefix is accompanied with a mention of whether it is a dependent or up/down prefix.
  */
-func parse_ribs_dependent (as, collectors_file, output_filename string, break_prefix bool, start, end string) {
-    rand.Seed(time.Now().UnixNano())
-    
+func parse_ribs_dependent (as, collectors_file, output_filename string, break_len int, start, end string) {
     set := create_safeset ()
     /* --- ASes of interest --- */
     ases := []string {as}
@@ -243,8 +434,11 @@ func parse_ribs_dependent (as, collectors_file, output_filename string, break_pr
     }
     
     collectors_to_index := assign_numbers (collectors)
-    bgp_dump_parser := generate_RIB_parser_dependent (set, ases, collectors_to_index, break_prefix, start, end)
-    pool.Launch_pool (32, collectors, bgp_dump_parser)
+    bgp_dump_parser := generate_RIB_parser_dependent (set, ases, collectors_to_index, break_len, start, end)
+    launch_pool_progress ("ribs", "collectors", ribs_jobs (), collectors, bgp_dump_parser)
+    if failed := get_failed_collectors (); len (failed) != 0 {
+        log.Print ("[parse_ribs_dependent]: WARNING: collectors left out (failed): ", strings.Join (failed, " "))
+    }
 
     log.Print ("Writing to file")
     set.write_to_file (output_filename, generate_print_collectors (len (collectors_to_index)))
@@ -260,7 +454,7 @@ func generate_print_collectors (count int) PrintFn {
                     _, err = w.WriteString(key + " u/d " + strconv.FormatUint (value,2) + "\n")
             }
         } else {
-            log.Fatal ("Unexpected type: %T\n", v)
+            log.Fatalf ("Unexpected type: %T\n", v)
         }
         return err
     }
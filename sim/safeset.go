@@ -0,0 +1,337 @@
+package sim
+
+import (
+    "fmt"
+    "log"
+    "sync"
+    "strings"
+    "strconv"
+    "bufio")
+
+/* --- Note on variable creation: ---
+ * The default zero value of a struct has all its fields zeroed. 
+ *      So when you declara a variable of type struct, the struct has been allocated, and all its fields are zeroed (0 for int, nil for a map, etc)
+ * Two ways to create and initialize a new struct:
+ * - The 'new' keyword can be used to create a new struct. It returns a pointer to the newly created struct.
+ * - With a struct literal
+ */
+
+/**
+ * A set that is protetcted by a sync.RWMutex (the readers do not wait for each other)
+ * Implementation using a map
+ */
+type SafeSet struct {
+    mux sync.RWMutex
+    //set map[string]struct{} // struct{} takes no memory space
+    set map[string]interface{}
+    sealed bool // Read-only from now on (see seal)
+}
+
+func create_safeset () *SafeSet {
+    new_set := new (SafeSet) // Returns a pointer to the newly allocated struct
+    new_set.set = make (map[string]interface{})
+    return new_set
+}
+
+/**
+ * Makes the set read-only: any later write panics, instead of racing with the concurrent readers
+ * that were given the set (see NewSafeSetVPMapper).
+ */
+func (set *SafeSet) seal () {
+    set.mux.Lock ()
+    set.sealed = true
+    set.mux.Unlock ()
+}
+
+func (set *SafeSet) check_not_sealed () {
+    if set.sealed {
+        panic ("[SafeSet]: write to a sealed set")
+    }
+}
+
+func (set *SafeSet) add (key string, arg ...interface{}) {
+    set.mux.Lock ()
+    defer set.mux.Unlock ()
+    set.check_not_sealed ()
+    switch len (arg) {
+        case 0: set.set[key] = struct{}{}
+        case 1: set.set[key] = arg[0]
+        default: log.Fatal ("Wrong number of arguments to function [add]")
+    }
+}
+
+/**
+ * Adds the value under key if the key is absent, or if 'keep' returns true
+ * when called with the current and the new value. 'on_store' is called, still
+ * under the lock, when the value is stored.
+ * Returns true if the value was stored.
+ */
+func (set *SafeSet) add_if (key string, value interface{}, keep func (interface{}, interface{}) bool, on_store func ()) bool {
+    set.mux.Lock ()
+    defer set.mux.Unlock ()
+    set.check_not_sealed ()
+    if old, present := set.set[key]; present && !keep (old, value) {
+        return false
+    }
+    set.set[key] = value
+    if on_store != nil {
+        on_store ()
+    }
+    return true
+}
+
+func (set *SafeSet) unsafe_add (key string, arg ...interface{}) {
+    set.check_not_sealed ()
+    switch len (arg) {
+        case 0: set.set[key] = struct{}{}
+        case 1: set.set[key] = arg[0]
+        default: log.Fatal ("Wrong number of arguments to function [unsafe_add]'")
+    }
+}
+
+func (set *SafeSet) unsafe_append (key, value string) {
+    set._append_to_set (key, value)
+}
+
+func (set *SafeSet) append (key, value string) {
+    set.mux.Lock ()
+    defer set.mux.Unlock ()
+    set._append_to_set (key, value)
+}
+
+func (set *SafeSet) _append_to_set (key, value string) {
+    set.check_not_sealed ()
+    p, ok := set.unsafe_get (key)
+    if ok {
+        peers, t := p.(map[string]struct{}) // Type assertion
+        if !t {
+            log.Fatal ("[append_to_set: type assertion failed")
+        }
+        peers[value] = struct{}{}
+        set.unsafe_add (key, peers)
+    } else {
+        set.unsafe_add (key, map[string]struct{}{value: struct{}{}})
+    }
+}
+
+func (set *SafeSet) contains (key string) bool {
+    set.mux.RLock ()
+    _, present := set.set[key]
+    set.mux.RUnlock ()
+    return present
+}
+
+func (set *SafeSet) unsafe_contains (key string) bool {
+    _, present := set.set[key]
+    return present
+}
+
+func (set *SafeSet) get (key string) (v interface{}, ok bool) {
+    set.mux.RLock ()
+    v, ok = set.set[key]
+    set.mux.RUnlock ()
+    return
+}
+
+func (set *SafeSet) unsafe_get (key string) (v interface{}, ok bool) {
+    v, ok = set.set[key]
+    return 
+}
+
+func (set *SafeSet) unsafe_get_keys () []string {
+    return get_keys (&(set.set))
+}
+
+/**
+ * Calls f on each key and value of the set, in no particular order, until f returns false.
+ * The set is read-locked meanwhile: f must not add to the set (deadlock).
+ */
+func (set *SafeSet) Range (f func (key string, v interface{}) bool) {
+    set.mux.RLock ()
+    defer set.mux.RUnlock ()
+    for key, v := range set.set {
+        if !f (key, v) {
+            return
+        }
+    }
+}
+
+/**
+ * Returns the keys of the set, in no particular order.
+ */
+func (set *SafeSet) Keys () []string {
+    set.mux.RLock ()
+    defer set.mux.RUnlock ()
+    return get_keys (&(set.set))
+}
+
+/**
+ * Returns the number of keys of the set.
+ */
+func (set *SafeSet) Len () int {
+    set.mux.RLock ()
+    defer set.mux.RUnlock ()
+    return len (set.set)
+}
+
+func (set *SafeSet) String () string {
+    var str strings.Builder
+    str.WriteString ("\n")
+    set.mux.RLock ()
+    for key, s := range set.set {
+        switch v := s.(type) {
+            case struct{}:
+                str.WriteString(key+"\n")
+            case int:
+                str.WriteString(key + " " + strconv.Itoa (v) + "\n")
+            case string:
+                str.WriteString(key + " " + v + "\n")
+            case map[string]struct{}:
+                str.WriteString(key + " " + strings.Join (_get_keys (&v), " ") + "\n")
+            case []string:
+                str.WriteString(key + " " + strings.Join (v, " ") + "\n")
+            default:
+                log.Fatalf ("No custom print function defined for type: %T\n", v)
+                
+        }
+    }
+    set.mux.RUnlock ()
+    return str.String ()
+}
+
+/**
+ * A set split in shards (SafeSets), the shard of a key being given by a hash of the key.
+ * Same API as SafeSet, but the goroutines adding different keys mostly lock different shards:
+ * used for the sets written by every hop of the warts parsing. Once written, merge () gives
+ * back a single SafeSet.
+ */
+type ShardedSet struct {
+    shards []*SafeSet
+}
+
+const nb_shards = 64 // Power of 2
+
+func create_sharded_set () *ShardedSet {
+    new_set := &ShardedSet{shards: make ([]*SafeSet, nb_shards)}
+    for i := range new_set.shards {
+        new_set.shards[i] = create_safeset ()
+    }
+    return new_set
+}
+
+func (set *ShardedSet) shard (key string) *SafeSet {
+    h := uint32 (2166136261) // FNV-1a, inlined to avoid converting the key to []byte
+    for i := 0; i < len (key); i++ {
+        h ^= uint32 (key[i])
+        h *= 16777619
+    }
+    return set.shards[h & (nb_shards - 1)]
+}
+
+func (set *ShardedSet) add (key string, arg ...interface{}) {
+    set.shard (key).add (key, arg...)
+}
+
+func (set *ShardedSet) add_if (key string, value interface{}, keep func (interface{}, interface{}) bool, on_store func ()) bool {
+    return set.shard (key).add_if (key, value, keep, on_store)
+}
+
+func (set *ShardedSet) append (key, value string) {
+    set.shard (key).append (key, value)
+}
+
+func (set *ShardedSet) contains (key string) bool {
+    return set.shard (key).contains (key)
+}
+
+func (set *ShardedSet) get (key string) (v interface{}, ok bool) {
+    return set.shard (key).get (key)
+}
+
+/**
+ * Returns the content of all the shards in a single SafeSet (the shards have no key in common).
+ * Not to be called while the set is still written.
+ */
+func (set *ShardedSet) merge () *SafeSet {
+    size := 0
+    for _, shard := range set.shards {
+        size += len (shard.set)
+    }
+    merged := &SafeSet{set: make (map[string]interface{}, size)}
+    for _, shard := range set.shards {
+        for key, value := range shard.set {
+            merged.set[key] = value
+        }
+    }
+    return merged
+}
+
+type PrintFn func(w *bufio.Writer, key string, v interface{}) error
+
+/**
+ * Writes the set in filename, gzip-compressed if filename ends with ".gz" (see CompressedWriter).
+ * On error, the file is removed and the error is logged.
+ */
+func (set *SafeSet) write_to_file (filename string, printfn ...PrintFn) {
+    if err := set.save (filename, printfn...); err != nil {
+        log.Print ("[write_to_file]: " + err.Error())
+    }
+}
+
+/**
+ * Same as write_to_file, but returns the error (opening, writing, flushing or closing the file)
+ * instead of logging it.
+ */
+func (set *SafeSet) save (filename string, printfn ...PrintFn) (err error) {
+    f := NewCompressedWriter (filename, false)
+    if err = f.Open (); err != nil {
+        return err
+    }
+    defer func () {
+        if close_err := f.Close (); err == nil {
+            err = close_err
+        }
+    } ()
+
+    w := f.Writer
+    for key, s := range set.set {
+        /* custom print function */
+        if len (printfn) != 0 {
+            err = printfn[0] (w, key, s)
+        /* generic print function */
+        } else {
+            switch v := s.(type) {
+                case struct{}:
+                    _, err = w.WriteString(key+"\n")
+                case int:
+                    _, err = w.WriteString(key + " " + strconv.Itoa (v) + "\n")
+                case string:
+                    _, err = w.WriteString(key + " " + v + "\n")
+                case map[string]struct{}:
+                    _, err = w.WriteString(key + " " + strings.Join (_get_keys (&v), " ") + "\n")
+                case []string:
+                    _, err = w.WriteString(key + " " + strings.Join (v, " ") + "\n")
+                default:
+                    if len (printfn) == 0 {
+                        log.Fatalf ("No custom print function defined for type: %T\n", v)
+                    }
+                    err = printfn[0] (w, key, s)
+            }
+        }
+        if err != nil {
+            f.Abort ()
+            return fmt.Errorf ("%s: %w", filename, err)
+        }
+    }
+    return nil
+}
+
+func _get_keys (mymap *map[string]struct{}) []string {
+    keys := make([]string, len(*mymap))
+    i := 0
+    for k := range (*mymap) {
+        keys[i] = k
+        i++
+    }
+    return keys
+}
@@ -0,0 +1,91 @@
+package sim
+
+import (
+    "fmt"
+    "reflect"
+    "strconv"
+    "sync"
+    "testing"
+    )
+
+/**
+ * Written concurrently, a ShardedSet gives back, once merged, the same content as a SafeSet.
+ */
+func TestShardedSetMerge (t *testing.T) {
+    sharded, single := create_sharded_set (), create_safeset ()
+    var wg sync.WaitGroup
+    for w := 0; w < 32; w++ {
+        wg.Add (1)
+        go func (w int) {
+            defer wg.Done ()
+            for i := 0; i < 1000; i++ {
+                key := "192.0." + strconv.Itoa (i % 256) + "." + strconv.Itoa (i / 256)
+                for _, set := range []interface{ append (string, string) }{sharded, single} {
+                    set.append (key, strconv.Itoa (w))
+                }
+            }
+        }(w)
+    }
+    wg.Wait ()
+    merged := sharded.merge ()
+    if !reflect.DeepEqual (merged.set, single.set) {
+        t.Fatalf ("merged: %d keys, want %d", merged.Len (), single.Len ())
+    }
+    if peers, _ := sharded.get ("192.0.1.2"); len (peers.(map[string]struct{})) != 32 {
+        t.Errorf ("192.0.1.2: %d values, want 32", len (peers.(map[string]struct{})))
+    }
+    if !sharded.contains ("192.0.255.2") || sharded.contains ("192.0.0.4") {
+        t.Error ("contains")
+    }
+}
+
+func TestShardedSetAddIf (t *testing.T) {
+    set := create_sharded_set ()
+    stored := 0
+    lower := func (old, new interface{}) bool { return new.(int) < old.(int) }
+    for _, value := range []int{5, 8, 3, 4} {
+        set.add_if ("198.51.100.0/24", value, lower, func () { stored++ })
+    }
+    if v, _ := set.get ("198.51.100.0/24"); v != 3 || stored != 2 {
+        t.Errorf ("value %v stored %d times, want 3 stored twice", v, stored)
+    }
+}
+
+/**
+ * The insertions of the warts parsing (an address and an adjacency per hop, about 1M hops)
+ * by 8 and by 32 workers, in a single SafeSet and in a ShardedSet:
+ *     go test -run - -bench ParseSets -cpu 8,32
+ */
+func BenchmarkParseSets (b *testing.B) {
+    const nb_hops, nb_workers = 1 << 20, 32
+    addrs := make ([]string, 65536)
+    for i := range addrs {
+        addrs[i] = fmt.Sprintf ("20.%d.%d.1", i / 256, i % 256)
+    }
+    for _, c := range []struct {
+        name string;
+        create func () interface{ add (string, ...interface{}) };
+    } {
+        {"safeset", func () interface{ add (string, ...interface{}) } { return create_safeset () }},
+        {"sharded", func () interface{ add (string, ...interface{}) } { return create_sharded_set () }},
+    } {
+        b.Run (c.name, func (b *testing.B) {
+            for n := 0; n < b.N; n++ {
+                addresses, adjs := c.create (), c.create ()
+                var wg sync.WaitGroup
+                for w := 0; w < nb_workers; w++ {
+                    wg.Add (1)
+                    go func (w int) {
+                        defer wg.Done ()
+                        for i := w; i < nb_hops; i += nb_workers {
+                            addr, next := addrs[(i * 7919) % len (addrs)], addrs[(i * 7919 + 1) % len (addrs)]
+                            addresses.add (addr)
+                            adjs.add (addr + "_" + next)
+                        }
+                    }(w)
+                }
+                wg.Wait ()
+            }
+        })
+    }
+}
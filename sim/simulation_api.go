@@ -0,0 +1,521 @@
+/* ==================================================================================== *\
+     simulation_api.go
+
+     Simulation API:
+     ---------------
+     The simulation as a sequence of calls returning Go values, rather than a command
+     writing files, for the programs importing this package:
+       - a Config (the inputs of the simulation, in place of the flags of g_args),
+       - LoadDatasets (cfg): parses the traces and bdrmapit, reads the VPs, the ASes of
+         interest and the AS relationships,
+       - LoadStrategy (ds, as_interest): reads the targets of an AS of interest,
+       - Simulate (ds, as_interest, strategy, opts): simulates the strategy (with the
+         scheduler of the Options) and returns the discovery curve and the statistics of
+         the AS.
+     These calls take their parameters from their arguments, not from g_args, and fill no
+     global dataset, so that several data sets can be simulated by the same program (they
+     log as the commands do, and -deadline only applies to the commands). The simulation
+     command is a wrapper over these calls (write_result writes the files of an AS).
+\* ==================================================================================== */
+
+package sim
+
+import (
+    "bufio"
+    "context"
+    "fmt"
+    "log"
+    "os"
+    "path/filepath"
+    "strconv"
+    "strings"
+    "time"
+    )
+
+/**
+ * Inputs of a simulation. The empty fields take the default of their flag.
+ */
+type Config struct {
+    WartsDirectory string;
+    BdrmapitFile string;
+    VpsFile string;               // Optional
+    AsesInterestFile string;
+    AsRelFile string;             // Optional: the groups of ASes of Result.Groups (see -asrel)
+    Ip2asFile string;             // Optional: with PpdcFile, the customer cones of the cc_size weighting
+    PpdcFile string;              // Optional: customer cones (see -ppdc), read with Ip2asFile
+    StrategyDir string;           // Output directory of the strategy step
+    BreakLen int;                 // Length of the IPv4 targets (see -break-len, /24 if 0)
+    DuplicateDestinations string; // See -dup_dest ("keep_last" if empty)
+    TraceFormat string;           // See -trace-format ("auto" if empty)
+    Border string;                // See -border ("asn" if empty)
+    Loops string;                 // See -loops ("truncate-at-loop" if empty)
+    TraceVp string;               // See -trace_vp ("any" if empty)
+    NoCache bool;                 // See -no-cache
+    Jobs int;                     // Nb of trace files parsed at the same time (see -j-warts, default_external_jobs if 0)
+    Seed int64;                   // 0: chosen from the clock (the seed actually used is kept in the Datasets)
+}
+
+/**
+ * Schedulers of the probes of an AS of interest (Options.Scheduler, -m of the simulation command).
+ */
+const (
+    SchedulerSequential = "sequential" // The groups one after the other (see anaximander_sequential.go)
+    SchedulerParallel = "parallel"     // All the groups at once, in batches (see anaximander_parallel.go)
+    SchedulerGreedy = "greedy"         // All the groups at once, leaving a group at its first misses (see anaximander_greedy.go)
+)
+
+var scheduler_names = []string{SchedulerSequential, SchedulerParallel, SchedulerGreedy} // Values of -m
+
+/**
+ * Parameters of the simulation of an AS.
+ */
+type Options struct {
+    Scheduler string;          // SchedulerSequential if empty
+    Threshold float64;         // tau: a group stops after a plateau longer than this fraction of its targets
+    PlateauMetric string;      // Which discoveries reset the plateau (see -plateau_metric, "any" if empty)
+    Attribution bool;          // Record the probe that first discovered each element (sequential scheduler, memory hungry)
+    RouterK int;               // A router is discovered once this many of its addresses are seen (2 if 0)
+    Budget ProbeBudget;        // Maximum number of probes (see -budget, none if zero)
+    MissingTraces string;      // Policy for the targets without trace (see -missing-traces, "count" if empty)
+    CreditMode string;         // Credit of the targets without trace (see -credit_mode, "pessimistic" if empty)
+    SiblingDistance int;       // In nearest_sibling credit mode, see -sibling_distance
+    WeightFunction string;     // Parallel scheduler: the weighting function, by name (see weight_functions, -w)
+    WeightParameters []float64; // Parallel scheduler: the parameters of the weighting function
+    GreedyPatience int;        // Greedy scheduler: misses in a row before leaving a group (1 if 0, see -greedy-patience)
+    Marker string;             // Appended to the names of the statistics files of the commands (see output_msg_marked)
+    CostModel *Cost_model;     // Packet ledger of the launched targets (see get_cost_model, none if nil)
+    Ctx context.Context;       // Once cancelled, the probing stops at the next probe (never if nil)
+}
+
+/**
+ * Datasets shared by the simulations of all the ASes of interest.
+ */
+type Datasets struct {
+    Traces *SafeSet;      // Destination /24 -> trace
+    Adjs *SafeSet;        // Ground truth: IP adjacencies
+    MultiAdjs *SafeSet;   // Ground truth: multi-hop adjacencies
+    Addresses *SafeSet;   // Ground truth: addresses
+    AddrToAsn *SafeSet;
+    RouterToAsn *SafeSet;
+    TargetToVp VP_mapper;
+    Vps []string;
+    AsesInterest []string;
+    cfg Config;                 // With its defaults (see with_defaults)
+    vp_traces *SafeSet;         // "vp_dest_24" -> Trace{}: the trace of every VP that probed a /24
+    addr_to_conn_asn *SafeSet;  // Address -> conn_asn, for the addresses that bdrmapit puts on an inter-AS link
+    index *AS_index;            // Ground truth per AS (see filterAS)
+    as_neighbors map[string]map[string]interface{}; // AS relationships (nil without Config.AsRelFile)
+    as_conesize map[string]int; // Customer cone sizes (nil without Config.PpdcFile)
+    max_conesize int;
+}
+
+/**
+ * Targets of an AS of interest, in probing order, delimited by groups.
+ */
+type Strategy struct {
+    Targets []string;
+    Limits []*AS_limit;            // End of each group in Targets
+    RawPrefixes map[string]string; // Target -> raw prefix (fractional credit)
+}
+
+/**
+ * Discovery levels (fractions of the ground truth of the AS) after a probe.
+ */
+type DiscoveryPoint struct {
+    Probe int; // Number of probes launched before this one
+    Adjs float64;
+    MultiAdjs float64;
+    Addresses float64;
+    Routers float64;
+}
+
+/**
+ * Statistics of the simulation of an AS.
+ */
+type AsStats struct {
+    Adjs int;             // Ground truth of the AS
+    MultiAdjs int;
+    Addresses int;
+    Routers int;
+    Targets int;          // Targets of the strategy
+    Probes int;           // Probes launched
+    UsefulProbes int;     // Probes that discovered something new
+    MissingTraces int;
+    MissingRemoved int;   // Targets without trace skipped or dropped (Options.MissingTraces)
+    FalsePositives int;
+    BudgetExhausted bool; // The probing was stopped by the budget
+    Interrupted bool;     // The probing was stopped by the cancellation of Options.Ctx: the results are partial
+}
+
+/**
+ * Discoveries of the probes of a group of ASes (see as_groups).
+ */
+type GroupContribution struct {
+    Group string;
+    Probes int;     // Probes launched towards the ASes of the group
+    Addresses int;  // Elements first discovered by these probes
+    Adjs int;
+    MultiAdjs int;
+    Routers int;
+}
+
+/**
+ * Result of the simulation of an AS.
+ */
+type Result struct {
+    AsInterest string;
+    Curve []DiscoveryPoint;      // One point per probe with a discovery
+    GroupLimits []int;           // Probes launched at the end of each group (cumulative; nil for the parallel and greedy schedulers)
+    Launched []string;           // Targets in launching order
+    Final DiscoveryPoint;        // Discovery levels at the end (Probe: number of probes launched)
+    Stats AsStats;
+    Groups []*GroupContribution; // Per group of ASes, in probing order (nil without AS relationships)
+    Duration time.Duration;      // Wall-clock time of the simulation
+    successful_traces *SafeSet;  // nil for the parallel and greedy schedulers
+    discovery_log *SafeSet;      // "<kind> <element>" -> "<destination> <probe number>" (Options.Attribution)
+    credit *fractional_credit;
+    marker string;               // Options.Marker
+    cost_model *Cost_model;      // Options.CostModel
+}
+
+/**
+ * Returns the Config given by the flags, with the seed actually used (see seed_random).
+ */
+func config_from_args (seed int64) *Config {
+    return &Config{
+        WartsDirectory: g_args.warts_directory,
+        BdrmapitFile: g_args.bdrmapit_file,
+        VpsFile: g_args.vps_file,
+        AsesInterestFile: g_args.ases_interest_file,
+        AsRelFile: g_args.as_rel_file,
+        StrategyDir: g_args.strategy,
+        BreakLen: g_args.break_len,
+        DuplicateDestinations: g_args.duplicate_destinations,
+        TraceFormat: g_args.trace_format,
+        Border: g_args.border,
+        Loops: g_args.loops,
+        TraceVp: g_args.trace_vp,
+        NoCache: g_args.no_cache,
+        Jobs: warts_jobs (),
+        Seed: seed,
+    }
+}
+
+/**
+ * Parses the warts given by the flags, for the commands other than simulation (exits on error).
+ */
+func parse_warts_args () *Datasets {
+    cfg, err := config_from_args (g_args.seed).with_defaults ()
+    if err != nil {
+        log.Fatal ("[parse_warts]: ", err)
+    }
+    ds, err := parse_warts (&cfg)
+    if err != nil {
+        log.Fatal ("[parse_warts]: ", err)
+    }
+    return ds
+}
+
+/**
+ * Returns the Options given by the flags, for the simulation mode (-m) and the threshold (-t) and
+ * credit mode simulated.
+ */
+func options_from_args (simulation_mode int, threshold float64, credit_mode string) Options {
+    opts := Options{Scheduler: scheduler_names[simulation_mode], Threshold: threshold, PlateauMetric: g_args.plateau_metric, Attribution: discovery_attribution_on, RouterK: g_args.router_k, Budget: g_args.budget,
+        MissingTraces: g_args.missing_traces, CreditMode: credit_mode, SiblingDistance: g_args.sibling_distance, GreedyPatience: g_args.greedy_patience}
+    if len (g_args.weight_parameters) != 0 {
+        opts.WeightFunction, opts.WeightParameters = weight_functions[int (g_args.weight_parameters[0])].name, g_args.weight_parameters[1:]
+    }
+    return opts
+}
+
+/**
+ * Returns an error if the plateau metric is unknown.
+ */
+func check_plateau_metric (metric string) error {
+    switch metric {
+        case "any", "adjs", "addresses", "routers", "addresses+routers":
+            return nil
+    }
+    return fmt.Errorf ("unknown plateau metric: %s (any, adjs, addresses, routers or addresses+routers)", metric)
+}
+
+/**
+ * Returns the Config with the defaults of its empty fields, or an error if one of its values is unknown.
+ */
+func (cfg Config) with_defaults () (Config, error) {
+    if cfg.DuplicateDestinations == "" {
+        cfg.DuplicateDestinations = "keep_last"
+    }
+    if cfg.TraceFormat == "" {
+        cfg.TraceFormat = trace_format_auto
+    }
+    if cfg.Border == "" {
+        cfg.Border = border_asn
+    }
+    if cfg.Loops == "" {
+        cfg.Loops = loops_truncate
+    }
+    if cfg.TraceVp == "" {
+        cfg.TraceVp = trace_vp_any
+    }
+    if cfg.Jobs <= 0 {
+        cfg.Jobs = default_external_jobs
+    }
+    for _, field := range []struct{ name, value string; known []string } {
+        {"DuplicateDestinations", cfg.DuplicateDestinations, []string{"keep_last", "keep_lowest_rtt"}},
+        {"TraceFormat", cfg.TraceFormat, []string{trace_format_auto, trace_format_tnt, trace_format_json}},
+        {"Border", cfg.Border, []string{border_asn, border_conn_asn}},
+        {"Loops", cfg.Loops, []string{loops_truncate, loops_remove, loops_keep}},
+        {"TraceVp", cfg.TraceVp, []string{trace_vp_any, trace_vp_assigned}},
+    } {
+        if find_index (field.known, field.value) == -1 {
+            return cfg, fmt.Errorf ("unknown %s: %s (%s)", field.name, field.value, strings.Join (field.known, ", "))
+        }
+    }
+    if cfg.BreakLen != 0 && (cfg.BreakLen < 8 || cfg.BreakLen > 24) {
+        return cfg, fmt.Errorf ("BreakLen: %d (0, or between 8 and 24)", cfg.BreakLen)
+    }
+    if (cfg.PpdcFile == "") != (cfg.Ip2asFile == "") {
+        return cfg, fmt.Errorf ("PpdcFile and Ip2asFile go together (the customer cones are counted in prefixes of ip2as)")
+    }
+    return cfg, nil
+}
+
+/**
+ * Returns the Options with the defaults of their empty fields, or an error if one of their values
+ * is unknown or needs a dataset that was not loaded.
+ */
+func (opts Options) with_defaults (ds *Datasets) (Options, error) {
+    if opts.Scheduler == "" {
+        opts.Scheduler = SchedulerSequential
+    }
+    if find_index (scheduler_names, opts.Scheduler) == -1 {
+        return opts, fmt.Errorf ("unknown scheduler: %s (%s)", opts.Scheduler, strings.Join (scheduler_names, ", "))
+    }
+    if opts.PlateauMetric == "" {
+        opts.PlateauMetric = "any"
+    }
+    if err := check_plateau_metric (opts.PlateauMetric); err != nil {
+        return opts, err
+    }
+    if opts.RouterK == 0 {
+        opts.RouterK = 2
+    }
+    if opts.RouterK < 0 {
+        return opts, fmt.Errorf ("the number of addresses of a discovered router must be >= 1 (%d)", opts.RouterK)
+    }
+    if opts.MissingTraces == "" {
+        opts.MissingTraces = missing_count
+    }
+    if err := check_missing_traces_policy (opts.MissingTraces); err != nil {
+        return opts, err
+    }
+    if err := check_credit_mode (opts.CreditMode); err != nil {
+        return opts, err
+    }
+    if opts.Budget.probes < 0 || opts.Budget.fraction < 0 || opts.Budget.fraction > 1 {
+        return opts, fmt.Errorf ("Budget: a number of probes must be >= 0, a fraction of the targets in (0, 1] (%s)", opts.Budget.String ())
+    }
+    if opts.GreedyPatience == 0 {
+        opts.GreedyPatience = 1
+    }
+    if opts.GreedyPatience < 0 {
+        return opts, fmt.Errorf ("the patience of the greedy scheduler must be >= 1 (%d)", opts.GreedyPatience)
+    }
+    if opts.Scheduler == SchedulerParallel {
+        spec, err := weight_function_by_name (opts.WeightFunction, opts.WeightParameters)
+        if err != nil {
+            return opts, err
+        }
+        if spec.needs_cones && ds.as_conesize == nil {
+            return opts, fmt.Errorf ("weighting function %s: the customer cones are needed (Config.PpdcFile and Ip2asFile)", spec.name)
+        }
+    }
+    return opts, nil
+}
+
+/**
+ * Returns whether the context of the Options (if any) was cancelled.
+ */
+func (opts *Options) cancelled () bool {
+    return opts.Ctx != nil && opts.Ctx.Err () != nil
+}
+
+/**
+ * Returns the mask length of the IPv4 targets (see target_length).
+ */
+func (cfg *Config) target_length () int {
+    if cfg.BreakLen > 0 {
+        return cfg.BreakLen
+    }
+    return 24
+}
+
+/**
+ * Reads the datasets of a simulation: the traces (see parse_warts), the VPs, the ASes of interest,
+ * the AS relationships and the customer cones. Only the Config is read, so that several data sets can be loaded by the
+ * same program.
+ */
+func LoadDatasets (cfg *Config) (*Datasets, error) {
+    c, err := cfg.with_defaults ()
+    if err != nil {
+        return nil, err
+    }
+    for _, file := range []string{c.WartsDirectory, c.BdrmapitFile, c.AsesInterestFile, c.VpsFile, c.AsRelFile, c.Ip2asFile, c.PpdcFile} {
+        if file == "" {
+            continue
+        }
+        if _, err := os.Stat (file); err != nil {
+            return nil, err
+        }
+    }
+    c.Seed = seed_random (c.Seed)
+
+    ds, err := parse_warts (&c)
+    if err != nil {
+        return nil, err
+    }
+    if c.VpsFile != "" {
+        if ds.Vps, err = read_vps_file (c.VpsFile); err != nil {
+            return nil, err
+        }
+    }
+    if ds.AsesInterest, err = read_whitespace_delimited_file (c.AsesInterestFile); err != nil {
+        return nil, err
+    }
+    if c.AsRelFile != "" {
+        ds.as_neighbors = read_as_rel (c.AsRelFile)
+    }
+    if c.PpdcFile != "" {
+        prefixes, _, _ := read_ip2as (c.Ip2asFile)
+        prefixes.length = c.target_length ()
+        ds.as_conesize, ds.max_conesize = customer_cone_sizes (c.PpdcFile, prefixes)
+    }
+    return ds, nil
+}
+
+/**
+ * Reads the targets of an AS of interest (from the strategy directory of the Config).
+ */
+func LoadStrategy (ds *Datasets, as_interest string) (Strategy, error) {
+    targets, limits, raw_prefixes, err := read_strategy (ds.cfg.StrategyDir, as_interest, ds.cfg.target_length ())
+    if err != nil {
+        return Strategy{}, fmt.Errorf ("AS %s: %v", as_interest, err)
+    }
+    return Strategy{Targets: targets, Limits: limits, RawPrefixes: raw_prefixes}, nil
+}
+
+/**
+ * Simulates the strategy of an AS of interest on the traces, with the scheduler of the Options,
+ * and returns the discovery curve and the statistics of the AS.
+ */
+func Simulate (ds *Datasets, as_interest string, strategy Strategy, opts Options) (*Result, error) {
+    opts, err := opts.with_defaults (ds)
+    if err != nil {
+        return nil, err
+    }
+    var result *Result
+    switch opts.Scheduler {
+        case SchedulerParallel:
+            result = simulate_parallel (ds, as_interest, strategy, opts)
+        case SchedulerGreedy:
+            result = simulate_greedy (ds, as_interest, strategy, opts)
+        default:
+            result = simulate_sequential (ds, as_interest, strategy, opts)
+    }
+    result.marker, result.cost_model = opts.Marker, opts.CostModel
+    return result, nil
+}
+
+/**
+ * Writes the files of the simulation of an AS (same files as before the API). Returns an error if
+ * any of them could not be written: the AS is then counted as failed.
+ */
+func write_result (ds *Datasets, r *Result, output_file string) error {
+    output_msg_marked (r.marker, "raw.txt", r.AsInterest, r.Stats.Adjs, r.Stats.MultiAdjs, r.Stats.Addresses, r.Stats.Routers)
+
+    /* --- Limits between groups (sequential scheduler) --- */
+    if r.GroupLimits != nil {
+        err := write_file (trim_suffix (output_file, ".txt") + "_limits_reduction.txt", func (w *bufio.Writer) {
+            w.WriteString (r.AsInterest + " ")
+            for _, limit := range r.GroupLimits {
+                w.WriteString (strconv.Itoa (limit) + " ")
+            }
+            w.WriteString ("\n")
+        })
+        if err != nil {
+            return err
+        }
+    }
+
+    /* --- Simulation result --- */
+    dir, filename := filepath.Split (output_file)
+    err := write_file (dir + "sorted_" + filename, func (w *bufio.Writer) {
+        for _, point := range r.Curve {
+            discovered := []string {
+                strconv.FormatFloat (point.Adjs, 'f', 4, 32),
+                strconv.FormatFloat (point.MultiAdjs, 'f', 4, 32),
+                strconv.FormatFloat (point.Addresses, 'f', 4, 32),
+                strconv.FormatFloat (point.Routers, 'f', 4, 32),
+            }
+            w.WriteString (strconv.Itoa (point.Probe) + " " + strings.Join (discovered, " ") + "\n")
+        }
+    })
+    if err != nil {
+        return err
+    }
+
+    /* --- Packet ledger --- */
+    if err := write_simulation_ledger (r.cost_model, r.Launched, ds.TargetToVp, ds.Traces, output_file); err != nil {
+        return err
+    }
+
+    /* --- Successful traces --- */
+    if succesfull_traces_on && r.successful_traces != nil {
+        if err := r.successful_traces.save (dir + "successful_traces_" + r.AsInterest + ".txt"); err != nil {
+            return err
+        }
+    }
+
+    /* --- Discovery attribution --- */
+    if r.discovery_log != nil {
+        if err := r.discovery_log.save (dir + "discovery_attribution_" + filename); err != nil {
+            return err
+        }
+    }
+
+    /* --- Contribution of each group of ASes --- */
+    if r.Groups != nil {
+        err := write_file (dir + "group_contribution_" + filename, func (w *bufio.Writer) {
+            for _, g := range r.Groups {
+                fmt.Fprintln (w, g.Group, g.Probes, g.Addresses, g.Adjs, g.MultiAdjs, g.Routers)
+            }
+        })
+        if err != nil {
+            return err
+        }
+    }
+
+    /* --- Counters of the AS --- */
+    err = write_probing_summary (&probing_summary{
+        targets: r.Stats.Targets,
+        launched: r.Stats.Probes,
+        useful: r.Stats.UsefulProbes,
+        missing_traces: r.Stats.MissingTraces,
+        missing_removed: r.Stats.MissingRemoved,
+        false_positives: r.Stats.FalsePositives,
+        final: r.Final,
+        budget_exhausted: r.Stats.BudgetExhausted,
+        interrupted: r.Stats.Interrupted,
+        duration: r.Duration,
+    }, r.AsInterest, output_file)
+    if err != nil {
+        return err
+    }
+
+    output_msg_marked (r.marker, "missing_traces.txt", r.AsInterest, r.Stats.MissingTraces)
+    output_msg_marked (r.marker, "false_positives.txt", r.AsInterest, r.Stats.FalsePositives)
+    r.credit.report (r.AsInterest, r.marker)
+    return nil
+}
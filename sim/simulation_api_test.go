@@ -0,0 +1,363 @@
+package sim
+
+import (
+    "context"
+    "bytes"
+    "database/sql"
+    "os"
+    "path/filepath"
+    "testing"
+    )
+
+/**
+ * Returns the configuration of the data set of testdata/concurrent_simulation (ASes 100 and 200),
+ * its bdrmapit database being built from its SQL dump. The warts are parsed (-no-cache).
+ */
+func test_datasets_config (t testing.TB) *Config {
+    t.Helper ()
+    return fixture_datasets_config (t, "concurrent_simulation")
+}
+
+/**
+ * Returns the configuration of the data set of testdata/<fixture> (ases.txt, bdrmapit.sql, traces/ and
+ * strategy/), its bdrmapit database being built from its SQL dump. The warts are parsed (-no-cache).
+ */
+func fixture_datasets_config (t testing.TB, fixture string) *Config {
+    t.Helper ()
+    t.Setenv ("XDG_CACHE_HOME", t.TempDir ()) // Not the cache of the user (see warts_cache.go)
+    dir := filepath.Join ("..", "testdata", fixture)
+    dump, err := os.ReadFile (filepath.Join (dir, "bdrmapit.sql"))
+    if err != nil {
+        t.Fatal (err)
+    }
+    bdrmapit := filepath.Join (t.TempDir (), "bdrmapit.db")
+    db, err := sql.Open ("sqlite3", bdrmapit)
+    if err != nil {
+        t.Fatal (err)
+    }
+    if _, err := db.Exec (string (dump)); err != nil {
+        t.Fatal (err)
+    }
+    db.Close ()
+    return &Config{WartsDirectory: filepath.Join (dir, "traces"), BdrmapitFile: bdrmapit, AsesInterestFile: filepath.Join (dir, "ases.txt"),
+        StrategyDir: filepath.Join (dir, "strategy"), NoCache: true, Seed: 1}
+}
+
+func load_test_datasets (t *testing.T) *Datasets {
+    t.Helper ()
+    ds, err := LoadDatasets (test_datasets_config (t))
+    if err != nil {
+        t.Fatal (err)
+    }
+    return ds
+}
+
+func simulate_test_as (t *testing.T, ds *Datasets, as_interest string, opts Options) *Result {
+    t.Helper ()
+    strategy, err := LoadStrategy (ds, as_interest)
+    if err != nil {
+        t.Fatal (err)
+    }
+    result, err := Simulate (ds, as_interest, strategy, opts)
+    if err != nil {
+        t.Fatal (err)
+    }
+    return result
+}
+
+func TestSimulateSequential (t *testing.T) {
+    ds := load_test_datasets (t)
+    r := simulate_test_as (t, ds, "100", Options{Threshold: 1})
+
+    /* --- Same statistics as the simulation command (see testdata/sim_api/expected_stats_0.txt) --- */
+    s := r.Stats
+    got := []int{s.Adjs, s.MultiAdjs, s.Addresses, s.Routers, s.Targets, s.Probes, s.UsefulProbes, s.MissingTraces, s.FalsePositives}
+    want := []int{36, 0, 17, 6, 8, 8, 8, 0, 0}
+    for i := range want {
+        if got[i] != want[i] {
+            t.Fatalf ("stats: got %v, want %v", got, want)
+        }
+    }
+    if len (r.Curve) != s.UsefulProbes || len (r.Launched) != s.Probes || r.Final.Probe != s.Probes {
+        t.Errorf ("curve of %d points, %d launched, final probe %d for %d probes", len (r.Curve), len (r.Launched), r.Final.Probe, s.Probes)
+    }
+    if len (r.GroupLimits) == 0 || r.GroupLimits[len (r.GroupLimits) - 1] != s.Probes {
+        t.Errorf ("group limits %v for %d probes", r.GroupLimits, s.Probes)
+    }
+    for i := 1; i < len (r.Curve); i++ {
+        if r.Curve[i].Probe <= r.Curve[i-1].Probe || r.Curve[i].Addresses < r.Curve[i-1].Addresses {
+            t.Errorf ("curve not increasing at point %d: %+v", i, r.Curve[i])
+        }
+    }
+}
+
+func TestSimulateSchedulers (t *testing.T) {
+    ds := load_test_datasets (t)
+    sequential := simulate_test_as (t, ds, "100", Options{Threshold: 1})
+    for _, opts := range []Options {
+        {Scheduler: SchedulerParallel, Threshold: 1, WeightFunction: "constant", WeightParameters: []float64{2}},
+        {Scheduler: SchedulerParallel, Threshold: 1, WeightFunction: "inverse", WeightParameters: []float64{0.05}},
+        {Scheduler: SchedulerGreedy, Threshold: 1},
+        {Scheduler: SchedulerGreedy, Threshold: 1, GreedyPatience: 3},
+    } {
+        r := simulate_test_as (t, ds, "100", opts)
+        if r.GroupLimits != nil {
+            t.Errorf ("%s: group limits %v, want none", opts.Scheduler, r.GroupLimits)
+        }
+        /* --- With a threshold of 1, every target is probed whatever the scheduler (no multi-hop adjacency: NaN) --- */
+        f, g := r.Final, sequential.Final
+        if r.Stats.Probes != sequential.Stats.Probes || f.Adjs != g.Adjs || f.Addresses != g.Addresses || f.Routers != g.Routers {
+            t.Errorf ("%s %s: %d probes, final %+v; sequential: %d probes, final %+v", opts.Scheduler, opts.WeightFunction, r.Stats.Probes, r.Final, sequential.Stats.Probes, sequential.Final)
+        }
+    }
+}
+
+func TestSimulateBudget (t *testing.T) {
+    ds := load_test_datasets (t)
+    for _, scheduler := range scheduler_names {
+        opts := Options{Scheduler: scheduler, Threshold: 1, Budget: BudgetProbes (3), WeightFunction: "constant", WeightParameters: []float64{1}}
+        r := simulate_test_as (t, ds, "100", opts)
+        if r.Stats.Probes != 3 || !r.Stats.BudgetExhausted {
+            t.Errorf ("%s: %d probes (budget exhausted: %v), want 3", scheduler, r.Stats.Probes, r.Stats.BudgetExhausted)
+        }
+        opts.Budget = BudgetFraction (0.5)
+        if r = simulate_test_as (t, ds, "100", opts); r.Stats.Probes != 4 {
+            t.Errorf ("%s: %d probes for half of %d targets", scheduler, r.Stats.Probes, r.Stats.Targets)
+        }
+    }
+}
+
+func TestSimulateCancelled (t *testing.T) {
+    ds := load_test_datasets (t)
+    ctx, cancel := context.WithCancel (context.Background ())
+    cancel ()
+    for _, scheduler := range scheduler_names {
+        r := simulate_test_as (t, ds, "100", Options{Scheduler: scheduler, Threshold: 1, Ctx: ctx, WeightFunction: "constant", WeightParameters: []float64{1}})
+        if !r.Stats.Interrupted || r.Stats.Probes != 0 {
+            t.Errorf ("%s: interrupted %v after %d probes, want interrupted before probing", scheduler, r.Stats.Interrupted, r.Stats.Probes)
+        }
+    }
+}
+
+func TestSimulateOptionErrors (t *testing.T) {
+    ds := load_test_datasets (t)
+    strategy, err := LoadStrategy (ds, "100")
+    if err != nil {
+        t.Fatal (err)
+    }
+    for _, opts := range []Options {
+        {Scheduler: "random"},
+        {PlateauMetric: "probes"},
+        {RouterK: -1},
+        {CreditMode: "generous"},
+        {Scheduler: SchedulerGreedy, GreedyPatience: -1},
+        {Scheduler: SchedulerParallel},                                                             // No weighting function
+        {Scheduler: SchedulerParallel, WeightFunction: "inverse", WeightParameters: []float64{2}},  // Out of range
+        {Scheduler: SchedulerParallel, WeightFunction: "cc_size", WeightParameters: []float64{0.1}}, // No customer cones loaded
+    } {
+        if _, err := Simulate (ds, "100", strategy, opts); err == nil {
+            t.Errorf ("%+v: no error", opts)
+        }
+    }
+}
+
+/**
+ * An AS whose files cannot be written fails, and its outcome is not recorded in the checkpoint.
+ */
+func TestWriteResultErrors (t *testing.T) {
+    ds := load_test_datasets (t)
+    r := simulate_test_as (t, ds, "100", Options{Threshold: 1})
+    dir := t.TempDir ()
+    if err := write_result (ds, r, filepath.Join (dir, "missing", "simulation_100.txt")); err == nil {
+        t.Error ("no error without the output directory")
+    }
+
+    sim_checkpoint.path, sim_checkpoint.pending = filepath.Join (dir, "checkpoint.json"), make (map[string]*as_outcome)
+    defer func () { sim_checkpoint.path, sim_checkpoint.pending = "", nil }()
+    if err := os.Mkdir (filepath.Join (dir, "summary_simulation_100.txt"), 0755); err != nil { // The summary cannot be created
+        t.Fatal (err)
+    }
+    if err := write_result (ds, r, filepath.Join (dir, "simulation_100.txt")); err == nil {
+        t.Error ("no error without the summary of the AS")
+    }
+    if len (sim_checkpoint.pending) != 0 {
+        t.Errorf ("outcome recorded in the checkpoint: %v", sim_checkpoint.pending)
+    }
+    os.Remove (filepath.Join (dir, "summary_simulation_100.txt"))
+    if err := write_result (ds, r, filepath.Join (dir, "simulation_100.txt")); err != nil || len (sim_checkpoint.pending) != 1 {
+        t.Errorf ("%v, %d outcomes recorded", err, len (sim_checkpoint.pending))
+    }
+}
+
+/**
+ * The AS also fails if its discovery attribution or the contribution of its groups cannot be written.
+ */
+func TestWriteResultSideFiles (t *testing.T) {
+    ds := load_test_datasets (t)
+    r := simulate_test_as (t, ds, "100", Options{Threshold: 1, Attribution: true})
+    r.Groups = []*GroupContribution{{Group: "others"}}
+    for _, file := range []string{"discovery_attribution_simulation_100.txt", "group_contribution_simulation_100.txt"} {
+        dir := t.TempDir ()
+        if err := os.Mkdir (filepath.Join (dir, file), 0755); err != nil { // The file cannot be created
+            t.Fatal (err)
+        }
+        if err := write_result (ds, r, filepath.Join (dir, "simulation_100.txt")); err == nil {
+            t.Errorf ("no error without %s", file)
+        }
+    }
+}
+
+/**
+ * With a cost model, the ledger of the launched targets is written next to the simulation, and the AS
+ * fails if it cannot be.
+ */
+func TestWriteResultLedger (t *testing.T) {
+    ds := load_test_datasets (t)
+    r := simulate_test_as (t, ds, "100", Options{Threshold: 1, CostModel: &Cost_model{attempts: 2, max_ttl: 30, caps: map[string]int{}}})
+    dir := t.TempDir ()
+    if err := write_result (ds, r, filepath.Join (dir, "simulation_100.txt")); err != nil {
+        t.Fatal (err)
+    }
+    if ledger, err := os.ReadFile (filepath.Join (dir, "simulation_100_packet_ledger.txt")); err != nil || len (ledger) == 0 {
+        t.Errorf ("ledger: %q %v", ledger, err)
+    }
+
+    dir = t.TempDir ()
+    if err := os.Mkdir (filepath.Join (dir, "simulation_100_packet_ledger.txt"), 0755); err != nil {
+        t.Fatal (err)
+    }
+    if err := write_result (ds, r, filepath.Join (dir, "simulation_100.txt")); err == nil {
+        t.Error ("no error without the ledger")
+    }
+}
+
+/**
+ * An AS without strategy, or that cannot be simulated, fails with an error instead of stopping the
+ * simulation of the other ASes, and no results are written for it.
+ */
+func TestSimulateASErrors (t *testing.T) {
+    ds := load_test_datasets (t)
+    dir := t.TempDir ()
+    for as_interest, opts := range map[string]Options {
+        "999": {Threshold: 1},          // No strategy
+        "100": {Scheduler: "random"},   // Unknown scheduler
+    } {
+        output_file := filepath.Join (dir, "simulation_" + as_interest + ".txt")
+        if err := simulate_as (ds, as_interest, output_file, opts); err == nil {
+            t.Errorf ("AS %s: no error", as_interest)
+        }
+        if _, err := os.Stat (output_file); err == nil {
+            t.Errorf ("AS %s: results written", as_interest)
+        }
+    }
+    if err := simulate_as (ds, "200", filepath.Join (dir, "simulation_200.txt"), Options{Threshold: 1}); err != nil {
+        t.Error ("AS 200: ", err)
+    }
+}
+
+/**
+ * The ASes 100 and 200 (same targets, different ground truths) simulated concurrently through the
+ * pool, as by the simulation command with -j 2, give the same results as one AS at a time, in both
+ * credit modes. Run under the race detector (go test -race) to check the concurrent simulate_as.
+ */
+func TestSimulateASConcurrent (t *testing.T) {
+    ds := load_test_datasets (t)
+    if len (ds.AsesInterest) != 2 {
+        t.Fatalf ("ASes of interest: %v", ds.AsesInterest)
+    }
+    for _, mode := range []string{credit_pessimistic, credit_fractional} {
+        opts := Options{Threshold: 1, CreditMode: mode}
+        dirs := map[int]string{1: t.TempDir (), 2: t.TempDir ()}
+        for nb_workers, dir := range dirs {
+            dir := dir
+            launch_pool_progress ("simulation", "ASes", nb_workers, ds.AsesInterest, func (as_interest string) {
+                if err := simulate_as (ds, as_interest, filepath.Join (dir, "simulation_" + as_interest + ".txt"), opts); err != nil {
+                    t.Error (mode, ": AS ", as_interest, ": ", err)
+                }
+            })
+        }
+        for _, as_interest := range ds.AsesInterest {
+            file := "sorted_simulation_" + as_interest + ".txt"
+            one, err1 := os.ReadFile (filepath.Join (dirs[1], file))
+            two, err2 := os.ReadFile (filepath.Join (dirs[2], file))
+            if err1 != nil || err2 != nil || len (one) == 0 || !bytes.Equal (one, two) {
+                t.Errorf ("%s: %s differs with 2 workers (%v, %v)", mode, file, err1, err2)
+            }
+        }
+    }
+}
+
+/**
+ * The greedy scheduling of an AS whose neighbors alternate useless and useful targets (testdata/greedy_patience):
+ * with a patience of 1 (the default), it moves on at the first useless probe (expected/sorted_patience_1.txt,
+ * the results before -greedy-patience); with a larger patience, it probes each AS to the end, as the
+ * sequential scheduling.
+ */
+func TestGreedyPatience (t *testing.T) {
+    cfg := fixture_datasets_config (t, "greedy_patience")
+    u := filepath.Join ("..", "testdata", "golden", "universe")
+    cfg.AsRelFile, cfg.PpdcFile, cfg.Ip2asFile = filepath.Join (u, "as_rel.txt"), filepath.Join (u, "ppdc.txt"), filepath.Join (u, "ip2as.txt")
+    ds, err := LoadDatasets (cfg)
+    if err != nil {
+        t.Fatal (err)
+    }
+    sorted := func (opts Options) string {
+        output_file := filepath.Join (t.TempDir (), "simulation_100.txt")
+        if err := simulate_as (ds, "100", output_file, opts); err != nil {
+            t.Fatal (err)
+        }
+        content, err := os.ReadFile (filepath.Join (filepath.Dir (output_file), "sorted_simulation_100.txt"))
+        if err != nil {
+            t.Fatal (err)
+        }
+        return string (content)
+    }
+    expected, err := os.ReadFile (filepath.Join ("..", "testdata", "greedy_patience", "expected", "sorted_patience_1.txt"))
+    if err != nil {
+        t.Fatal (err)
+    }
+    sequential := sorted (Options{Scheduler: SchedulerSequential, Threshold: 1})
+    for patience, want := range map[int]string{0: string (expected), 1: string (expected), 2: sequential, 100: sequential} {
+        if got := sorted (Options{Scheduler: SchedulerGreedy, Threshold: 1, GreedyPatience: patience}); got != want {
+            t.Errorf ("patience %d:\n%s\nwant:\n%s", patience, got, want)
+        }
+    }
+    if sequential == string (expected) {
+        t.Error ("a patience of 1 should differ from the sequential scheduling")
+    }
+}
+
+/**
+ * Under the routers metric, a probe that only discovers addresses lengthens the plateau.
+ */
+func TestPlateauStopperMetric (t *testing.T) {
+    routers := new_plateau_stopper (Options{PlateauMetric: "routers", Threshold: 0.5}, 4)
+    for i, want := range []bool{false, false, true} {
+        if discovery, stop := routers.Observe (true, true, false); discovery || stop != want {
+            t.Errorf ("routers, address-only probe %d: discovery %v, stop %v", i, discovery, stop)
+        }
+    }
+    if discovery, stop := routers.Observe (false, false, true); !discovery || stop {
+        t.Errorf ("routers, new router: discovery %v, stop %v", discovery, stop)
+    }
+    any := new_plateau_stopper (Options{PlateauMetric: "any", Threshold: 0.5}, 4)
+    if discovery, stop := any.Observe (false, true, false); !discovery || stop {
+        t.Errorf ("any, address-only probe: discovery %v, stop %v", discovery, stop)
+    }
+}
+
+/**
+ * Every scheduler stops a group according to the plateau metric (AS 100: the plateau on routers
+ * stops a group one probe earlier).
+ */
+func TestSimulatePlateauMetric (t *testing.T) {
+    ds := load_test_datasets (t)
+    for _, scheduler := range scheduler_names {
+        for metric, want := range map[string]int{"any": 8, "routers": 7} {
+            r := simulate_test_as (t, ds, "100", Options{Scheduler: scheduler, Threshold: 0.2, PlateauMetric: metric, WeightFunction: "constant", WeightParameters: []float64{1}})
+            if r.Stats.Probes != want {
+                t.Errorf ("%s, %s: %d probes, want %d", scheduler, metric, r.Stats.Probes, want)
+            }
+        }
+    }
+}
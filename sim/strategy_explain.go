@@ -0,0 +1,116 @@
+/* ==================================================================================== *\
+     strategy_explain.go
+
+     Answers the question "where does AS X sit in the probing order for AS Y, and why":
+     the group of X (neighbor, one hop neighbor, other), its relationship with Y, its
+     customer cone size, its rank within its group, and the range of probe indexes its
+     prefixes would occupy.
+
+     The strategy is applied (nothing is written), and the position of X is counted in
+     the groups of ASes it returns (see strategy_group), with the number of directed
+     probes of each AS before any reduction.
+\* ==================================================================================== */
+
+package sim
+
+import (
+    "fmt"
+    "log"
+    )
+
+/**
+ * The position of an AS in the probing order of a strategy.
+ */
+type AS_explanation struct {
+    asn string;
+    classification string; // internal, neighbor, one-hop, other or absent (from directed probes)
+    relationship string;   // customer, peer, provider, or none
+    cone_size int;
+    nb_probes int;         // Nb of probes of the AS (before any reduction)
+    group string;          // The group in which the AS is probed ("" if the AS is not probed)
+    rank int;              // Rank of the AS within its group (1-based)
+    group_size int;        // Nb of ASes in the group
+    start int;             // Index of the first probe of the AS
+    end int;               // Index following the last probe of the AS
+}
+
+/**
+ * Computes the position of 'asn' in the probing order of the strategy for the AS of interest, from
+ * the groups returned by the strategy. Only the strategies probing groups of ASes can be explained.
+ */
+func explain_as (strategy int, ds *strategy_datasets, seed int64, as_interest, asn string, target_to_vp VP_mapper, destinations []string) *AS_explanation {
+    AS_probes, neighbors_map, one_hop_neighbors_map, other_AS_map, _ := get_directed_probes_and_groups (as_interest, new_strategy_run (ds, seed, as_interest))
+
+    e := &AS_explanation{asn: asn, relationship: "none", cone_size: ds.as_conesize[asn], nb_probes: len (AS_probes[asn]), start: -1, end: -1}
+    switch {
+        case asn == as_interest:
+            e.classification = "internal"
+        case neighbors_map[asn] != nil:
+            e.classification = "neighbor"
+        case one_hop_neighbors_map[asn] != nil:
+            e.classification = "one-hop"
+        case other_AS_map[asn] != nil:
+            e.classification = "other"
+        default:
+            e.classification = "absent"
+    }
+    if rel, ok := ds.as_rel.neighbors[as_interest][asn]; ok {
+        e.relationship = []string{"customer", "peer", "provider"}[rel.(int)]
+    }
+
+    /* --- Count the probes preceding the AS --- */
+    s := make ([]string, len (destinations)) // Some strategies sort the destinations in place.
+    copy (s, destinations)
+    _, _, groups := strategies[strategy].fn (s, as_interest, target_to_vp, new_strategy_run (ds, seed, as_interest))
+    if groups == nil {
+        log.Fatal ("[explain_as]: strategy ", strategy, " (", strategies[strategy].name, ") cannot be explained (it does not probe groups of ASes)")
+    }
+    offset := 0
+    for _, group := range groups {
+        for i, as := range group.ases {
+            if as != asn {
+                continue
+            }
+            e.group, e.rank, e.group_size = group.name, i+1, len (group.ases)
+            if group.name == group_internal { // The internal prefixes are probed as a whole
+                e.start, e.end = 0, group.size
+                if asn == as_interest {
+                    e.nb_probes = group.size
+                }
+                return e
+            }
+            e.start, e.end = offset, offset + e.nb_probes
+            return e
+        }
+        if group.name == group_internal {
+            offset += group.size
+            continue
+        }
+        for _, as := range group.ases {
+            offset += len (AS_probes[as])
+        }
+    }
+    return e
+}
+
+/**
+ * Prints the explanation of the position of 'asn' in the probing order.
+ */
+func launch_strategy_explain (break_len int, strategy int, as_interest, asn string) {
+    if strategy < 0 || strategy >= len (strategies) {
+        log.Fatal ("[launch_strategy_explain]: unknown strategy ", strategy)
+    }
+    seed := seed_random (g_args.seed)
+    _, target_to_vp, destinations, ds := read_strategy_data (break_len)
+    output_on = false
+
+    e := explain_as (strategy, ds, seed, as_interest, asn, target_to_vp, destinations)
+    fmt.Printf ("Strategy %d (%s), AS of interest %s\n", strategy, strategies[strategy].name, as_interest)
+    fmt.Printf ("AS %s: %s (relationship: %s, customer cone: %d, probes: %d)\n", e.asn, e.classification, e.relationship, e.cone_size, e.nb_probes)
+    if e.group == "" {
+        fmt.Printf ("AS %s is not probed by this strategy\n", e.asn)
+        return
+    }
+    fmt.Printf ("Group: %s, rank %d/%d\n", e.group, e.rank, e.group_size)
+    fmt.Printf ("Approximate probe indexes: [%d, %d) (before any reduction)\n", e.start, e.end)
+}
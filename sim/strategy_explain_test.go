@@ -0,0 +1,58 @@
+package sim
+
+import (
+    "path/filepath"
+    "testing"
+    )
+
+/**
+ * Reads the synthetic universe of testdata/golden (AS of interest 100: neighbors 200, 300 and 400,
+ * one hop neighbors 500 and 700, other 600), without warts data set.
+ */
+func load_strategy_universe (t *testing.T) (*strategy_datasets, VP_mapper, []string) {
+    t.Helper ()
+    saved, saved_output := g_args, output_on
+    t.Cleanup (func () { g_args, output_on = saved, saved_output })
+    u := filepath.Join ("..", "testdata", "golden", "universe")
+    g_args.ip2as_file, g_args.as_rel_file, g_args.ppdc_file = filepath.Join (u, "ip2as.txt"), filepath.Join (u, "as_rel.txt"), filepath.Join (u, "ppdc.txt")
+    g_args.ases_interest_file, g_args.directed_prefixes_dir = filepath.Join (u, "ases.txt"), filepath.Join (u, "directed_prefixes")
+    g_args.overlays_global_file, g_args.nexthop_as_dir_global = filepath.Join (u, "overlays.txt"), filepath.Join (u, "next_hop_AS")
+    g_args.warts_directory, g_args.vps_file = "", ""
+    _, target_to_vp, destinations, ds := read_strategy_data (0)
+    output_on = false
+    return ds, target_to_vp, destinations
+}
+
+func TestExplainClassifications (t *testing.T) {
+    ds, target_to_vp, destinations := load_strategy_universe (t)
+    strategy, err := strategy_index ("directed_probing_internal_neighbors_onehopneighbors_others")
+    if err != nil {
+        t.Fatal (err)
+    }
+    for _, c := range []struct {
+        asn, classification, relationship, group string;
+        rank int;
+    }{
+        {"100", "internal", "none", group_internal, 1},
+        {"300", "neighbor", "peer", group_neighbor, 1}, // Neighbors by increasing customer cone: 300, 200, 400
+        {"200", "neighbor", "customer", group_neighbor, 2},
+        {"400", "neighbor", "provider", group_neighbor, 3},
+        {"700", "one-hop", "none", group_one_hop, 2},
+        {"600", "other", "none", group_other, 1},
+        {"999", "absent", "none", "", 0},
+    } {
+        e := explain_as (strategy, ds, 42, "100", c.asn, target_to_vp, destinations)
+        if e.classification != c.classification || e.relationship != c.relationship || e.group != c.group || e.rank != c.rank {
+            t.Errorf ("AS %s: %+v, want %+v", c.asn, e, c)
+        }
+        if e.group != "" && e.end - e.start != e.nb_probes {
+            t.Errorf ("AS %s: probe indexes [%d, %d) for %d probes", c.asn, e.start, e.end, e.nb_probes)
+        }
+    }
+
+    /* --- The first neighbor comes right after the internal prefixes (same /24 picked in each prefix) --- */
+    internal := explain_as (strategy, ds, 42, "100", "100", target_to_vp, destinations)
+    if first := explain_as (strategy, ds, 42, "100", "300", target_to_vp, destinations); first.start != internal.end {
+        t.Errorf ("first neighbor at %d, internal prefixes end at %d", first.start, internal.end)
+    }
+}
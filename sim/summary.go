@@ -0,0 +1,199 @@
+/* ==================================================================================== *\
+     summary.go
+
+     End-of-run summary:
+     -------------------
+     The top-level commands (ribs_multi, build_best_directed_probes, strategy, simulation)
+     write a machine-readable summary of their run (-summary_out), so that a pipeline can
+     decide whether to go on without parsing the logs:
+       - status: ok, warnings (some units skipped or failed, deadline reached...), or failed
+         (no unit processed, or the run did not complete),
+       - the number of processed, skipped and failed units of each kind (collectors, ASes, files),
+       - the paths of the primary artifacts produced,
+       - the duration of each stage, and the times of the phases of the simulation (see profile.go).
+     The summary is first written with the status "failed" and "completed": false, and
+     rewritten at the end of the run: a run that crashed keeps the status "failed".
+\* ==================================================================================== */
+
+package sim
+
+import (
+    "encoding/json"
+    "log"
+    "os"
+    "sync"
+    "time"
+    )
+
+const (
+    status_ok = "ok"
+    status_warnings = "warnings"
+    status_failed = "failed"
+)
+
+/* --- Outcomes of a unit --- */
+const (
+    unit_processed = "processed"
+    unit_skipped = "skipped"
+    unit_failed = "failed"
+)
+
+type unit_counts struct {
+    Processed int `json:"processed"`;
+    Skipped int `json:"skipped"`;
+    Failed int `json:"failed"`;
+}
+
+type stage_duration struct {
+    Stage string `json:"stage"`;
+    Seconds float64 `json:"seconds"`;
+}
+
+type run_summary struct {
+    Command string `json:"command"`;
+    Status string `json:"status"`;
+    Completed bool `json:"completed"`;
+    Started string `json:"started"`;
+    Finished string `json:"finished,omitempty"`;
+    Units map[string]*unit_counts `json:"units"`; // Kind of unit -> counts
+    Artifacts []string `json:"artifacts"`;
+    Stages []stage_duration `json:"stages"`;
+    Timings []phase_timing `json:"timings,omitempty"`; // See Timer
+    Warnings []string `json:"warnings,omitempty"`;
+    Build map[string]string `json:"build"`;
+}
+
+var summary = struct {
+    mux sync.Mutex;
+    run run_summary;
+    path string;          // Empty: no summary for this command (all the functions below are no-ops)
+    stage string;         // Current stage
+    stage_start time.Time;
+}{}
+
+/**
+ * Starts the summary of a command, written in 'path'.
+ */
+func summary_begin (command, path string) {
+    summary.mux.Lock ()
+    defer summary.mux.Unlock ()
+    summary.path = path
+    summary.run = run_summary{
+        Command: command,
+        Status: status_failed, // Until the run completes
+        Started: time.Now ().Format (time.RFC3339),
+        Units: make (map[string]*unit_counts),
+        Artifacts: make ([]string, 0),
+        Stages: make ([]stage_duration, 0),
+        Build: build_info (),
+    }
+    summary.stage = ""
+    write_summary ()
+}
+
+/**
+ * Ends the current stage (if any), and starts the given one ("" to only end the current one).
+ */
+func summary_stage (stage string) {
+    summary.mux.Lock ()
+    profile_stage_end (summary.stage)
+    defer summary.mux.Unlock ()
+    if summary.path == "" {
+        return
+    }
+    if summary.stage != "" {
+        summary.run.Stages = append (summary.run.Stages, stage_duration{summary.stage, time.Since (summary.stage_start).Seconds ()})
+    }
+    summary.stage, summary.stage_start = stage, time.Now ()
+}
+
+/**
+ * Records the outcome of a unit (unit_processed, unit_skipped or unit_failed) of the given kind.
+ */
+func summary_unit (kind, outcome string) {
+    summary.mux.Lock ()
+    defer summary.mux.Unlock ()
+    if summary.path == "" {
+        return
+    }
+    counts, ok := summary.run.Units[kind]
+    if !ok {
+        counts = &unit_counts{}
+        summary.run.Units[kind] = counts
+    }
+    switch outcome {
+        case unit_processed:
+            counts.Processed++
+        case unit_skipped:
+            counts.Skipped++
+        default:
+            counts.Failed++
+    }
+}
+
+/**
+ * Records the path of an artifact produced by the run.
+ */
+func summary_artifact (path string) {
+    summary.mux.Lock ()
+    defer summary.mux.Unlock ()
+    if summary.path == "" {
+        return
+    }
+    summary.run.Artifacts = append (summary.run.Artifacts, path)
+}
+
+/**
+ * Records a warning: the status of the run is at best "warnings".
+ */
+func summary_warning (warning string) {
+    summary.mux.Lock ()
+    defer summary.mux.Unlock ()
+    if summary.path == "" {
+        return
+    }
+    summary.run.Warnings = append (summary.run.Warnings, warning)
+}
+
+/**
+ * Ends the run: computes its status, writes the summary, and returns the status
+ * (status_ok without summary).
+ */
+func summary_end () string {
+    summary_stage ("")
+    summary.mux.Lock ()
+    defer summary.mux.Unlock ()
+    if summary.path == "" {
+        return status_ok
+    }
+    run := &summary.run
+    run.Completed = true
+    run.Finished = time.Now ().Format (time.RFC3339)
+    run.Timings = timing_report ()
+    run.Status = status_ok
+    if len (run.Warnings) != 0 {
+        run.Status = status_warnings
+    }
+    for _, counts := range run.Units {
+        if counts.Processed == 0 && counts.Failed != 0 {
+            run.Status = status_failed
+            break
+        }
+        if counts.Skipped != 0 || counts.Failed != 0 {
+            run.Status = status_warnings
+        }
+    }
+    write_summary ()
+    log.Println ("[summary]: status", run.Status, "(see", summary.path + ")")
+    return run.Status
+}
+
+/**
+ * Writes the summary (lock held).
+ */
+func write_summary () {
+    content, _ := json.MarshalIndent (summary.run, "", "  ")
+    if err := os.WriteFile (summary.path, append (content, '\n'), 0644); err != nil {
+        log.Print ("[summary]: ", err)
+    }
+}
@@ -0,0 +1,132 @@
+package sim
+
+import (
+    "encoding/json"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+    "testing"
+    )
+
+/**
+ * Starts a summary in a temporary directory, ended (without summary) with the test.
+ */
+func begin_test_summary (t *testing.T) string {
+    t.Helper ()
+    path := filepath.Join (t.TempDir (), "summary.json")
+    summary_begin ("simulation", path)
+    t.Cleanup (func () {
+        summary.mux.Lock ()
+        summary.path, summary.run, summary.stage = "", run_summary{}, ""
+        summary.mux.Unlock ()
+    })
+    return path
+}
+
+func read_test_summary (t *testing.T, path string) map[string]interface{} {
+    t.Helper ()
+    content, err := os.ReadFile (path)
+    if err != nil {
+        t.Fatal (err)
+    }
+    var run map[string]interface{}
+    if err := json.Unmarshal (content, &run); err != nil {
+        t.Fatalf ("%s: %v", path, err)
+    }
+    return run
+}
+
+/**
+ * The summary is written as soon as the run starts, as failed and not completed, so that a run
+ * that crashed is not mistaken for a successful one.
+ */
+func TestSummarySchema (t *testing.T) {
+    path := begin_test_summary (t)
+    run := read_test_summary (t, path)
+    if run["status"] != status_failed || run["completed"] != false {
+        t.Errorf ("summary of a run in progress: %v %v", run["status"], run["completed"])
+    }
+
+    summary_stage ("parse")
+    summary_unit ("ASes", unit_processed)
+    summary_artifact ("out/targets.txt")
+    summary_stage ("simulate")
+    if status := summary_end (); status != status_ok {
+        t.Errorf ("status %s, want ok", status)
+    }
+    run = read_test_summary (t, path)
+    keys := make ([]string, 0, len (run))
+    for key := range run {
+        if key != "timings" { // Only with the phases of the simulation timed (see profile.go)
+            keys = append (keys, key)
+        }
+    }
+    sort.Strings (keys)
+    if strings.Join (keys, " ") != "artifacts build command completed finished stages started status units" {
+        t.Errorf ("keys of the summary: %v", keys)
+    }
+    if run["status"] != status_ok || run["completed"] != true || run["command"] != "simulation" {
+        t.Errorf ("summary: %v", run)
+    }
+    units := run["units"].(map[string]interface{})["ASes"].(map[string]interface{})
+    if units["processed"] != 1.0 || units["skipped"] != 0.0 || units["failed"] != 0.0 {
+        t.Errorf ("units: %v", units)
+    }
+    if artifacts := run["artifacts"].([]interface{}); len (artifacts) != 1 || artifacts[0] != "out/targets.txt" {
+        t.Errorf ("artifacts: %v", artifacts)
+    }
+    stages := run["stages"].([]interface{})
+    if len (stages) != 2 || stages[0].(map[string]interface{})["stage"] != "parse" || stages[1].(map[string]interface{})["stage"] != "simulate" {
+        t.Errorf ("stages: %v", stages)
+    }
+}
+
+/**
+ * The status under partial failures: some units skipped or failed, or a warning, give
+ * "warnings"; a kind of units with failures and none processed gives "failed".
+ */
+func TestSummaryStatus (t *testing.T) {
+    for name, c := range map[string]struct {
+        units [][2]string; // Kind, outcome
+        warning string;
+        want string;
+    } {
+        "ok": {[][2]string{{"collectors", unit_processed}, {"files", unit_processed}}, "", status_ok},
+        "skipped": {[][2]string{{"collectors", unit_processed}, {"collectors", unit_skipped}}, "", status_warnings},
+        "some failed": {[][2]string{{"files", unit_processed}, {"files", unit_failed}}, "", status_warnings},
+        "all failed": {[][2]string{{"collectors", unit_processed}, {"files", unit_failed}, {"files", unit_failed}}, "", status_failed},
+        "warning": {[][2]string{{"ASes", unit_processed}}, "deadline reached", status_warnings},
+        "nothing": {nil, "", status_ok},
+    } {
+        t.Run (name, func (t *testing.T) {
+            path := begin_test_summary (t)
+            for _, unit := range c.units {
+                summary_unit (unit[0], unit[1])
+            }
+            if c.warning != "" {
+                summary_warning (c.warning)
+            }
+            if status := summary_end (); status != c.want {
+                t.Errorf ("status %s, want %s", status, c.want)
+            }
+            if run := read_test_summary (t, path); run["status"] != c.want {
+                t.Errorf ("status written %v, want %s", run["status"], c.want)
+            }
+        })
+    }
+}
+
+/**
+ * A command without summary writes nothing, and is ok.
+ */
+func TestSummaryDisabled (t *testing.T) {
+    summary_unit ("files", unit_failed)
+    summary_warning ("ignored")
+    if status := summary_end (); status != status_ok {
+        t.Errorf ("status %s without summary", status)
+    }
+    if summary.run.Units != nil || summary.run.Warnings != nil {
+        t.Errorf ("summary recorded without path: %v", summary.run)
+    }
+}
@@ -0,0 +1,51 @@
+/* ==================================================================================== *\
+     version.go
+
+     Build metadata:
+     ---------------
+     The version, commit and build date are set at build time, e.g.:
+       go build -ldflags "-X $SIM.version=v1.2.0 -X $SIM.commit=$(git rev-parse --short HEAD) -X $SIM.build_date=$(date -u +%Y-%m-%d)"
+     with SIM=github.com/Emeline-1/anaximander_simulator/sim (the package of these variables)
+     so that results produced months apart can be attributed to a code version.
+\* ==================================================================================== */
+
+package sim
+
+import (
+    "fmt"
+    "log"
+    "runtime"
+    )
+
+var ( // Set with -ldflags "-X github.com/Emeline-1/anaximander_simulator/sim.<name>=<value>"
+    version = "dev"
+    commit = "unknown"
+    build_date = "unknown"
+)
+
+/**
+ * Returns the build metadata, in a stable order (also used in the run manifest).
+ */
+func build_info () map[string]string {
+    return map[string]string {
+        "version": version,
+        "commit": commit,
+        "build_date": build_date,
+        "go_version": runtime.Version (),
+        "platform": runtime.GOOS + "/" + runtime.GOARCH,
+    }
+}
+
+/**
+ * Returns a one-line description of the binary.
+ */
+func version_string () string {
+    return fmt.Sprintf ("anaximander %s (commit %s, built %s, %s %s/%s)", version, commit, build_date, runtime.Version (), runtime.GOOS, runtime.GOARCH)
+}
+
+/**
+ * Logs the version of the binary at the start of a run.
+ */
+func log_version () {
+    log.Println ("[start]:", version_string ())
+}
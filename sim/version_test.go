@@ -0,0 +1,53 @@
+package sim
+
+import (
+    "encoding/json"
+    "os"
+    "path/filepath"
+    "runtime"
+    "strings"
+    "testing"
+    )
+
+/**
+ * Sets the build metadata as -ldflags would, until the end of the test.
+ */
+func set_build (t *testing.T, v, c, date string) {
+    old_version, old_commit, old_date := version, commit, build_date
+    version, commit, build_date = v, c, date
+    t.Cleanup (func () { version, commit, build_date = old_version, old_commit, old_date })
+}
+
+func TestVersionString (t *testing.T) {
+    set_build (t, "v1.2.0", "abc1234", "2026-10-17")
+    s := version_string ()
+    for _, want := range []string{"v1.2.0", "abc1234", "2026-10-17", runtime.Version (), runtime.GOOS + "/" + runtime.GOARCH} {
+        if !strings.Contains (s, want) {
+            t.Errorf ("%q: no %s", s, want)
+        }
+    }
+    info := build_info ()
+    if len (info) != 5 || info["version"] != "v1.2.0" || info["commit"] != "abc1234" || info["build_date"] != "2026-10-17" || info["go_version"] != runtime.Version () {
+        t.Errorf ("build info: %v", info)
+    }
+}
+
+/**
+ * The run manifest records the build of the binary that produced the results.
+ */
+func TestManifestBuild (t *testing.T) {
+    set_build (t, "v1.2.0", "abc1234", "2026-10-17")
+    dir := t.TempDir ()
+    write_manifest (dir, &run_manifest{Command: "strategy"}, false)
+    content, err := os.ReadFile (filepath.Join (dir, manifest_file))
+    if err != nil {
+        t.Fatal (err)
+    }
+    var m run_manifest
+    if err := json.Unmarshal (content, &m); err != nil {
+        t.Fatal (err)
+    }
+    if m.Build["version"] != "v1.2.0" || m.Build["commit"] != "abc1234" || m.Build["platform"] != runtime.GOOS + "/" + runtime.GOARCH {
+        t.Errorf ("build: %v", m.Build)
+    }
+}
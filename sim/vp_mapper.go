@@ -0,0 +1,69 @@
+/* ==================================================================================== *\
+     vp_mapper.go
+
+     Mapping between a target (/24) and the VPs that probed it:
+     ----------------------------------------------------------
+     The mapping is built while parsing the warts (a SafeSet of /24 -> set of VPs), then
+     only read, concurrently, by the strategy and simulation workers. Without warts data
+     set, every target is probed by a single fake VP.
+
+     Contract: a VP_mapper is read-only. Both implementations are safe for concurrent use
+     once built: the SafeSet-backed mapper seals its set (a write panics) and takes its
+     lock, and the fake mapper cannot be modified after its creation.
+\* ==================================================================================== */
+
+package sim
+
+import (
+    "log"
+    "sort"
+    )
+
+/**
+ * Read-only mapping between a target and the VPs that probed it.
+ */
+type VP_mapper interface {
+    Get (target string) ([]string, bool) // The VPs (sorted) that probed the target
+}
+
+/* --- Without warts data set: every target is probed by the same VP --- */
+
+type FakeVPMapper struct {
+    vp string;
+}
+
+func NewFakeVPMapper (vp string) *FakeVPMapper {
+    return &FakeVPMapper{vp: vp}
+}
+
+func (mapper *FakeVPMapper) Get (target string) ([]string, bool) {
+    return []string{mapper.vp}, true
+}
+
+/* --- From the warts data set --- */
+
+type SafeSetVPMapper struct {
+    set *SafeSet; // /24 -> map[string]struct{} (VPs), see commit_trace
+}
+
+/**
+ * Wraps the target_to_vp set built by parse_warts, and seals it: any later write to the set panics.
+ */
+func NewSafeSetVPMapper (set *SafeSet) *SafeSetVPMapper {
+    set.seal ()
+    return &SafeSetVPMapper{set: set}
+}
+
+func (mapper *SafeSetVPMapper) Get (target string) ([]string, bool) {
+    vps_i, present := mapper.set.get (target)
+    if !present {
+        return nil, false
+    }
+    v, ok := vps_i.(map[string]struct{})
+    if !ok {
+        log.Fatalf ("[SafeSetVPMapper.Get]: unexpected type: %T", vps_i)
+    }
+    target_vps := _get_keys (&v)
+    sort.Strings (target_vps)
+    return target_vps, true
+}
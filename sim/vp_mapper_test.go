@@ -0,0 +1,80 @@
+package sim
+
+import (
+    "fmt"
+    "reflect"
+    "sync"
+    "testing"
+    )
+
+/**
+ * Reads the mapper from several goroutines (run with -race).
+ */
+func read_concurrently (t *testing.T, mapper VP_mapper, targets []string, want map[string][]string) {
+    t.Helper ()
+    var wg sync.WaitGroup
+    errors := make (chan string, 8*len (targets))
+    for w := 0; w < 8; w++ {
+        wg.Add (1)
+        go func () {
+            defer wg.Done ()
+            for _, target := range targets {
+                if vps, ok := mapper.Get (target); !ok || !reflect.DeepEqual (vps, want[target]) {
+                    errors <- fmt.Sprintf ("%s: %v (%v), want %v", target, vps, ok, want[target])
+                }
+            }
+        }()
+    }
+    wg.Wait ()
+    close (errors)
+    for err := range errors {
+        t.Error (err)
+    }
+}
+
+func TestSafeSetVPMapperConcurrent (t *testing.T) {
+    set := create_safeset ()
+    want := make (map[string][]string)
+    targets := make ([]string, 0, 256)
+    for i := 0; i < 256; i++ {
+        target := fmt.Sprintf ("10.0.%d.0/24", i)
+        set.append (target, "192.0.2.2")
+        set.append (target, "192.0.2.1")
+        targets = append (targets, target)
+        want[target] = []string{"192.0.2.1", "192.0.2.2"}
+    }
+    mapper := NewSafeSetVPMapper (set)
+    read_concurrently (t, mapper, targets, want)
+    if _, ok := mapper.Get ("10.1.0.0/24"); ok {
+        t.Error ("target not probed found")
+    }
+}
+
+func TestFakeVPMapperConcurrent (t *testing.T) {
+    want := map[string][]string{"10.0.0.0/24": {"vp"}, "10.0.1.0/24": {"vp"}}
+    read_concurrently (t, NewFakeVPMapper ("vp"), []string{"10.0.0.0/24", "10.0.1.0/24"}, want)
+}
+
+/**
+ * Once wrapped in a mapper, the set cannot be written anymore.
+ */
+func TestSafeSetVPMapperSealed (t *testing.T) {
+    set := create_safeset ()
+    set.append ("10.0.0.0/24", "192.0.2.1")
+    NewSafeSetVPMapper (set)
+    for name, write := range map[string]func () {
+        "add": func () { set.add ("10.0.1.0/24") },
+        "append": func () { set.append ("10.0.0.0/24", "192.0.2.2") },
+        "unsafe_append": func () { set.unsafe_append ("10.0.0.0/24", "192.0.2.2") },
+        "add_if": func () { set.add_if ("10.0.1.0/24", nil, func (interface{}, interface{}) bool { return true }, nil) },
+    } {
+        func () {
+            defer func () {
+                if recover () == nil {
+                    t.Errorf ("%s: no panic on a sealed set", name)
+                }
+            }()
+            write ()
+        }()
+    }
+}
@@ -0,0 +1,247 @@
+/* ==================================================================================== *\
+     warts_cache.go
+
+     Cache of the parsed warts:
+     --------------------------
+     Parsing the warts (and reading the bdrmapit annotations) takes most of the time of
+     the strategy and the simulation, and gives the same result for the same inputs. Once
+     parsed, the traces, the ground truth (adjs, multi_adjs, addresses), the VPs of each
+     target and the annotations used afterwards are written to a single gob file in the
+     user cache directory (e.g., ~/.cache/anaximander/warts_<key>.gob).
+
+     The key is the SHA-256 of the listing of the warts directory (name, size and
+     modification time of each file), of the size and modification time of the bdrmapit
+     file, and of the options changing the parsed traces (-dup_dest, -trace-format, -loops,
+     -trace_vp, -border, the length of the targets, the ASes of interest). A run with the
+     same key loads the cache instead of parsing; -no-cache parses again, and neither reads
+     nor writes the cache. A parse where some warts files failed is not cached. The cache
+     files are never removed: each set of inputs adds one (removing the directory clears it).
+\* ==================================================================================== */
+
+package sim
+
+import (
+    "bufio"
+    "crypto/sha256"
+    "encoding/gob"
+    "encoding/hex"
+    "fmt"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+    )
+
+const warts_cache_version = 2 // To change whenever the parsing or the format below changes
+
+/* --- Gob only encodes exported fields: copies of Trace and of its hops --- */
+
+/**
+ * The hops of a trace, by columns. The strings (addresses, ASes, routers, VPs) are indexes in
+ * the string table of the cache: each string is decoded once, and shared by the traces.
+ */
+type cached_trace struct {
+    Vp int;
+    Addrs, Asns, Conn_asns, Routers, Ttls []int;
+    Rtts []float64;
+    Flags []byte; // hop_ingress, hop_egress, hop_private
+}
+
+const (
+    hop_ingress = 1 << iota
+    hop_egress
+    hop_private
+)
+
+/**
+ * Statistics of the parsing, logged again when the cache is loaded.
+ */
+type warts_stats struct {
+    Files int;
+    Failed_files, Skipped_traces, Private_traces, Looped_traces int64;
+}
+
+type cached_warts struct {
+    Version int;
+    Strings []string;             // String table of Trace_list
+    Trace_list []cached_trace;    // Each trace once (shared by Traces and Vp_traces)
+    Traces map[string]int;        // dest_24 -> index in Trace_list
+    Vp_traces map[string]int;     // vp_dest_24 -> index in Trace_list
+    Adjs, Multi_adjs, Addresses []string;
+    Target_to_vp map[string][]string;
+    Addr_to_asn, Router_to_asn, Addr_to_conn_asn map[string]string;
+    Stats warts_stats;
+}
+
+/**
+ * Returns the path of the cache of the parsed warts for the given files, or an error if it cannot be computed.
+ */
+func warts_cache_path (cfg *Config, files []string, ases_interest []string) (string, error) {
+    dir, err := os.UserCacheDir ()
+    if err != nil {
+        return "", err
+    }
+    h := sha256.New ()
+    fmt.Fprintln (h, "version", warts_cache_version)
+    sorted := append ([]string{}, files...)
+    sort.Strings (sorted)
+    for _, file := range append (sorted, cfg.BdrmapitFile) {
+        info, err := os.Stat (file)
+        if err != nil {
+            return "", err
+        }
+        abs, _ := filepath.Abs (file)
+        fmt.Fprintln (h, abs, info.Size (), info.ModTime ().UnixNano ())
+    }
+    interest := append ([]string{}, ases_interest...)
+    sort.Strings (interest)
+    fmt.Fprintln (h, cfg.DuplicateDestinations, cfg.TraceFormat, cfg.Loops, cfg.TraceVp, cfg.Border, cfg.target_length (), strings.Join (interest, " "))
+    return filepath.Join (dir, "anaximander", "warts_" + hex.EncodeToString (h.Sum (nil)) + ".gob"), nil
+}
+
+/**
+ * Writes the parsed warts to the cache. The file is replaced atomically, so that an interrupted
+ * run never leaves a truncated cache.
+ */
+func save_warts_cache (path string, traces, vp_traces, adjs, multi_adjs, addresses, target_to_vp, addr_to_asn, router_to_asn, addr_to_conn_asn *SafeSet, stats warts_stats) error {
+    c := cached_warts{Version: warts_cache_version, Traces: make (map[string]int, len (traces.set)), Vp_traces: make (map[string]int, len (vp_traces.set)), Stats: stats}
+    strings_index := make (map[string]int)
+    str := func (s string) int {
+        i, ok := strings_index[s]
+        if !ok {
+            i = len (c.Strings)
+            strings_index[s] = i
+            c.Strings = append (c.Strings, s)
+        }
+        return i
+    }
+    index := make (map[*Trace]int, len (vp_traces.set))
+    trace_index := func (trace_i interface{}) int {
+        trace, _ := trace_i.(*Trace)
+        if i, ok := index[trace]; ok {
+            return i
+        }
+        n := len (trace.hops)
+        cached := cached_trace{Vp: str (trace.vp), Addrs: make ([]int, n), Asns: make ([]int, n), Conn_asns: make ([]int, n), Routers: make ([]int, n), Ttls: make ([]int, n), Rtts: make ([]float64, n), Flags: make ([]byte, n)}
+        for i, hop := range trace.hops {
+            cached.Addrs[i], cached.Asns[i], cached.Conn_asns[i], cached.Routers[i] = str (hop.addr), str (hop.asn), str (hop.conn_asn), str (hop.router)
+            cached.Ttls[i], cached.Rtts[i] = hop.probe_ttl, hop.rtt
+            if hop.ingress {
+                cached.Flags[i] |= hop_ingress
+            }
+            if hop.egress {
+                cached.Flags[i] |= hop_egress
+            }
+            if hop.private {
+                cached.Flags[i] |= hop_private
+            }
+        }
+        index[trace] = len (c.Trace_list)
+        c.Trace_list = append (c.Trace_list, cached)
+        return index[trace]
+    }
+    for key, trace := range traces.set {
+        c.Traces[key] = trace_index (trace)
+    }
+    for key, trace := range vp_traces.set {
+        c.Vp_traces[key] = trace_index (trace)
+    }
+    c.Adjs, c.Multi_adjs, c.Addresses = get_keys (&adjs.set), get_keys (&multi_adjs.set), get_keys (&addresses.set)
+    c.Target_to_vp = make (map[string][]string, len (target_to_vp.set))
+    for target, vps_i := range target_to_vp.set {
+        vps, _ := vps_i.(map[string]struct{})
+        c.Target_to_vp[target] = _get_keys (&vps)
+    }
+    c.Addr_to_asn, c.Router_to_asn, c.Addr_to_conn_asn = string_values (addr_to_asn), string_values (router_to_asn), string_values (addr_to_conn_asn)
+
+    if err := os.MkdirAll (filepath.Dir (path), 0755); err != nil {
+        return err
+    }
+    tmp := path + ".tmp"
+    f, err := os.Create (tmp)
+    if err != nil {
+        return err
+    }
+    w := bufio.NewWriter (f)
+    err = gob.NewEncoder (w).Encode (&c)
+    if err == nil {
+        err = w.Flush ()
+    }
+    if cerr := f.Close (); err == nil {
+        err = cerr
+    }
+    if err != nil {
+        os.Remove (tmp)
+        return err
+    }
+    return os.Rename (tmp, path)
+}
+
+/**
+ * Reads the parsed warts from the cache, in the same sets as the parsing.
+ * Returns traces, vp_traces, adjs, multi_adjs, addresses, target_to_vp, addr_to_asn, router_to_asn, addr_to_conn_asn.
+ */
+func load_warts_cache (path string) ([]*SafeSet, warts_stats, error) {
+    f, err := os.Open (path)
+    if err != nil {
+        return nil, warts_stats{}, err
+    }
+    defer f.Close ()
+    var c cached_warts
+    if err := gob.NewDecoder (bufio.NewReader (f)).Decode (&c); err != nil {
+        return nil, warts_stats{}, fmt.Errorf ("%s: %v", path, err)
+    }
+    if c.Version != warts_cache_version {
+        return nil, warts_stats{}, fmt.Errorf ("%s: version %d instead of %d", path, c.Version, warts_cache_version)
+    }
+
+    trace_list := make ([]*Trace, len (c.Trace_list))
+    for i, cached := range c.Trace_list {
+        trace := &Trace{vp: c.Strings[cached.Vp], hops: make ([]Hop, len (cached.Addrs))}
+        for j := range cached.Addrs {
+            flags := cached.Flags[j]
+            trace.hops[j] = Hop{addr: c.Strings[cached.Addrs[j]], asn: c.Strings[cached.Asns[j]], conn_asn: c.Strings[cached.Conn_asns[j]], router: c.Strings[cached.Routers[j]],
+                probe_ttl: cached.Ttls[j], rtt: cached.Rtts[j], ingress: flags & hop_ingress != 0, egress: flags & hop_egress != 0, private: flags & hop_private != 0}
+        }
+        trace.compute_entry_rtts () // As in commit_trace
+        trace_list[i] = trace
+    }
+    traces, vp_traces := create_safeset (), create_safeset ()
+    for key, i := range c.Traces {
+        traces.set[key] = trace_list[i]
+    }
+    for key, i := range c.Vp_traces {
+        vp_traces.set[key] = trace_list[i]
+    }
+    adjs, multi_adjs, addresses := create_safeset (), create_safeset (), create_safeset ()
+    for _, s := range []struct{ set *SafeSet; keys []string }{{adjs, c.Adjs}, {multi_adjs, c.Multi_adjs}, {addresses, c.Addresses}} {
+        for _, key := range s.keys {
+            s.set.set[key] = struct{}{}
+        }
+    }
+    target_to_vp := create_safeset ()
+    for target, vps := range c.Target_to_vp {
+        set := make (map[string]struct{}, len (vps))
+        for _, vp := range vps {
+            set[vp] = struct{}{}
+        }
+        target_to_vp.set[target] = set
+    }
+    return []*SafeSet{traces, vp_traces, adjs, multi_adjs, addresses, target_to_vp, string_safeset (c.Addr_to_asn), string_safeset (c.Router_to_asn), string_safeset (c.Addr_to_conn_asn)}, c.Stats, nil
+}
+
+func string_values (set *SafeSet) map[string]string {
+    values := make (map[string]string, len (set.set))
+    for key, value_i := range set.set {
+        values[key], _ = value_i.(string)
+    }
+    return values
+}
+
+func string_safeset (values map[string]string) *SafeSet {
+    set := create_safeset ()
+    for key, value := range values {
+        set.set[key] = value
+    }
+    return set
+}
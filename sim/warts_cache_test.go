@@ -0,0 +1,124 @@
+package sim
+
+import (
+    "io"
+    "log"
+    "os"
+    "path/filepath"
+    "reflect"
+    "testing"
+    )
+
+/**
+ * The sets of the data set read from the warts (or from their cache), by name.
+ */
+func datasets_sets (ds *Datasets) map[string]map[string]interface{} {
+    return map[string]map[string]interface{} {
+        "traces": ds.Traces.set,
+        "vp_traces": ds.vp_traces.set,
+        "adjs": ds.Adjs.set,
+        "multi_adjs": ds.MultiAdjs.set,
+        "addresses": ds.Addresses.set,
+        "target_to_vp": ds.TargetToVp.(*SafeSetVPMapper).set.set,
+        "addr_to_asn": ds.AddrToAsn.set,
+        "router_to_asn": ds.RouterToAsn.set,
+        "addr_to_conn_asn": ds.addr_to_conn_asn.set,
+    }
+}
+
+/**
+ * Five runs on the same warts load the cache written by the parse, and give the same sets and
+ * the same simulation as the parse.
+ */
+func TestWartsCacheRepeatedRuns (t *testing.T) {
+    cfg := test_datasets_config (t)
+    cfg.NoCache = false
+    parsed, err := LoadDatasets (cfg) // No cache yet: parsed, and cached
+    if err != nil {
+        t.Fatal (err)
+    }
+    files, err := filepath.Glob (filepath.Join (os.Getenv ("XDG_CACHE_HOME"), "anaximander", "warts_*.gob"))
+    if err != nil || len (files) != 1 {
+        t.Fatalf ("cache files: %v %v", files, err)
+    }
+    want := simulate_test_as (t, parsed, "100", Options{Threshold: 1}).Stats
+
+    for run := 0; run < 5; run++ {
+        ds, err := LoadDatasets (cfg)
+        if err != nil {
+            t.Fatal (err)
+        }
+        for name, set := range datasets_sets (ds) {
+            if parsed_set := datasets_sets (parsed)[name]; !reflect.DeepEqual (set, parsed_set) {
+                t.Errorf ("run %d: %s: %d keys loaded from the cache, %d parsed", run, name, len (set), len (parsed_set))
+            }
+        }
+        if stats := simulate_test_as (t, ds, "100", Options{Threshold: 1}).Stats; !reflect.DeepEqual (stats, want) {
+            t.Errorf ("run %d: %+v, want %+v", run, stats, want)
+        }
+    }
+}
+
+/**
+ * With -no-cache, the cache is neither read nor written.
+ */
+func TestWartsNoCache (t *testing.T) {
+    cfg := test_datasets_config (t)
+    if _, err := LoadDatasets (cfg); err != nil {
+        t.Fatal (err)
+    }
+    if files, _ := filepath.Glob (filepath.Join (os.Getenv ("XDG_CACHE_HOME"), "anaximander", "*")); len (files) != 0 {
+        t.Errorf ("cache files written with -no-cache: %v", files)
+    }
+}
+
+/**
+ * A corrupt cache is ignored: the warts are parsed again.
+ */
+func TestWartsCacheCorrupt (t *testing.T) {
+    cfg := test_datasets_config (t)
+    cfg.NoCache = false
+    parsed, err := LoadDatasets (cfg)
+    if err != nil {
+        t.Fatal (err)
+    }
+    files, _ := filepath.Glob (filepath.Join (os.Getenv ("XDG_CACHE_HOME"), "anaximander", "warts_*.gob"))
+    if len (files) != 1 {
+        t.Fatalf ("cache files: %v", files)
+    }
+    if err := os.WriteFile (files[0], []byte ("not a gob"), 0644); err != nil {
+        t.Fatal (err)
+    }
+    ds, err := LoadDatasets (cfg)
+    if err != nil {
+        t.Fatal (err)
+    }
+    if !reflect.DeepEqual (ds.Adjs.set, parsed.Adjs.set) || len (ds.Traces.set) != len (parsed.Traces.set) {
+        t.Errorf ("with a corrupt cache: %d adjs, %d traces, want %d and %d", ds.Adjs.Len (), ds.Traces.Len (), parsed.Adjs.Len (), parsed.Traces.Len ())
+    }
+}
+
+/**
+ * Parsing the warts against loading them from the cache:
+ *     go test -run - -bench WartsCache
+ */
+func BenchmarkWartsCache (b *testing.B) {
+    log.SetOutput (io.Discard)
+    defer log.SetOutput (os.Stderr)
+    cfg := test_datasets_config (b)
+    cfg.NoCache = false
+    if _, err := LoadDatasets (cfg); err != nil { // Writes the cache
+        b.Fatal (err)
+    }
+    for _, no_cache := range []bool{true, false} {
+        name := map[bool]string{true: "parse", false: "cache"}[no_cache]
+        b.Run (name, func (b *testing.B) {
+            cfg.NoCache = no_cache
+            for n := 0; n < b.N; n++ {
+                if _, err := LoadDatasets (cfg); err != nil {
+                    b.Fatal (err)
+                }
+            }
+        })
+    }
+}
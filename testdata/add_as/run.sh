@@ -0,0 +1,102 @@
+#!/bin/bash
+# Checks that add_as gives the outputs of a full ribs_multi run on all the ASes of interest, for both
+# heuristics: ribs_multi -keep-records on AS 100, then add_as 300, against ribs_multi on ASes 100 and 300.
+# The dump (generated) holds prefixes whose best route depends on the ASes of interest (peers 1 and 2):
+#   20.0.0.0/16  1 300 900 | 2 400 900        (tie on AS 100: AS 300 breaks it, next-hop 900)
+#   21.0.0.0/16  1 100 300 900
+#   22.0.0.0/16  1 100 500 | 2 300 600 500    (shortest path, whatever the ASes of interest)
+#   23.0.0.0/16  2 400 700
+# Also checks that the directory left by an interrupted add_as is not moved into the output.
+# Usage (from the repository root): testdata/add_as/run.sh
+OUT=$(mktemp -d)
+STATUS=0
+mkdir -p $OUT/dump/rrc00
+echo rrc00 > $OUT/collectors.txt
+echo 100 > $OUT/ases_100.txt
+echo 100 300 > $OUT/ases_100_300.txt
+python3 - $OUT << 'EOF_PY' || { rm -rf "${OUT:?}"; exit 1; }
+import gzip, ipaddress, struct, sys
+T = 1618876800
+PEERS = [1, 2]
+ROUTES = {'20.0.0.0/16': [[1, 300, 900], [2, 400, 900]],
+          '21.0.0.0/16': [[1, 100, 300, 900]],
+          '22.0.0.0/16': [[1, 100, 500], [2, 300, 600, 500]],
+          '23.0.0.0/16': [[2, 400, 700]]}
+def rec(sub, body): return struct.pack('>IHHI', T, 13, sub, len(body)) + body
+pit = struct.pack('>IHH', 0x0a000001, 0, len(PEERS))
+for i, asn in enumerate(PEERS):
+    pit += bytes([0x02]) + struct.pack('>I', 0x0a000001) + bytes([192, 0, 2, i + 1]) + struct.pack('>I', asn)
+def attrs(path):
+    seg = bytes([2, len(path)]) + b''.join(struct.pack('>I', a) for a in path)
+    return bytes([0x50, 2]) + struct.pack('>H', len(seg)) + seg + bytes([0x40, 3, 4]) + bytes([192, 0, 2, 254])
+dump = [rec(1, pit)]
+for seq, (prefix, paths) in enumerate(ROUTES.items()):
+    network = ipaddress.ip_network(prefix)
+    body = struct.pack('>IB', seq + 1, network.prefixlen) + network.network_address.packed[:2] + struct.pack('>H', len(paths))
+    for path in paths:
+        a = attrs(path)
+        body += struct.pack('>HIH', PEERS.index(path[0]), T, len(a)) + a
+    dump.append(rec(2, body))
+with gzip.GzipFile(sys.argv[1] + '/dump/rrc00/bview.20210420.0000.gz', 'wb', mtime=0) as f:
+    f.write(b''.join(dump))
+EOF_PY
+go build -o $OUT/anaximander . || exit 1
+
+lines () { # <file>: its lines, sorted (the prefixes of an origin AS in any order)
+  case $1 in
+    */origin_ases.txt) while read -r origin prefixes; do echo $origin $(tr ' ' '\n' <<< "$prefixes" | sort); done < $1 | sort ;;
+    *) zcat -f $1 | sort ;;
+  esac
+}
+
+ribs_multi () { # <ases_file> <output_dir> [options]
+  local ases=$1 dir=$2
+  mkdir -p $(dirname $dir)
+  shift 2
+  $OUT/anaximander rib_parsing ribs_multi -a $ases -c $OUT/collectors.txt -asrel testdata/golden/universe/as_rel.txt \
+    -mrt-dir $OUT/dump -o $dir "$@" > $dir.log 2>&1
+}
+
+for h in shortest valley_free; do
+  for compress in "" -compress; do
+    run=$h$compress
+    if ! ribs_multi $OUT/ases_100.txt $OUT/$run/added -h $h $compress -keep-records || ! ribs_multi $OUT/ases_100_300.txt $OUT/$run/full -h $h $compress; then
+      echo "add_as: $run: ribs_multi failed"
+      STATUS=1
+      continue
+    fi
+    mkdir -p $OUT/$run/added_bdp $OUT/$run/full_bdp $OUT/$run/added/.add_as/forwarding_tables && echo stale > $OUT/$run/added/.add_as/forwarding_tables/stale.txt # Interrupted run
+    if ! $OUT/anaximander rib_parsing add_as -d $OUT/$run/added -as 300 -o $OUT/$run/added_bdp > $OUT/$run/add_as.log 2>&1; then
+      echo "add_as: $run: add_as failed"
+      tail -3 $OUT/$run/add_as.log
+      STATUS=1
+      continue
+    fi
+    $OUT/anaximander rib_parsing build_best_directed_probes -a $OUT/ases_100_300.txt -c $OUT/collectors.txt -d $OUT/$run/full -o $OUT/$run/full_bdp > /dev/null 2>&1
+    for f in $(cd $OUT/$run/full && find forwarding_tables next-hop_AS prev-hop_AS overlays collectors ases_used.txt -type f | sort); do
+      if ! diff -u <(lines $OUT/$run/full/$f) <(lines $OUT/$run/added/$f); then
+        echo "add_as: $run: $f differs from the full run"
+        STATUS=1
+      fi
+    done
+    if ! diff -u <(sort $OUT/$run/full_bdp/directed_prefixes_300.txt) <(sort $OUT/$run/added_bdp/directed_prefixes_300.txt); then
+      echo "add_as: $run: directed prefixes of AS 300 differ from the full run"
+      STATUS=1
+    fi
+    if ! zcat -f $OUT/$run/added/next-hop_AS/rrc00/next_hop_AS_rrc00_300.txt* | grep -q "^20.0.0.0/16  900$"; then
+      echo "add_as: $run: the route of 20.0.0.0/16 through AS 300 is not selected"
+      STATUS=1
+    fi
+    if [ -e $OUT/$run/added/.add_as ] || [ -e $OUT/$run/added/forwarding_tables/stale.txt ]; then
+      echo "add_as: $run: files of the interrupted run left"
+      STATUS=1
+    fi
+  done
+done
+if ribs_multi $OUT/ases_100.txt $OUT/no_records && $OUT/anaximander rib_parsing add_as -d $OUT/no_records -as 300 > $OUT/no_records_add_as.log 2>&1; then
+  echo "add_as: accepted a directory without records"
+  STATUS=1
+fi
+[ $STATUS -eq 0 ] && echo "add_as: ok" || echo "add_as: FAILED"
+rm -rf "${OUT:?}"
+exit $STATUS
@@ -0,0 +1,47 @@
+#!/bin/bash
+# Checks that ribs_multi -compress writes the same content as the plain outputs, and that the
+# readers downstream (build_best_directed_probes, add_as on the kept records, merge_nextAS) give the same results
+# on both. Uses the dump of testdata/overlays.
+# Usage (from the repository root): testdata/compress/run.sh
+D=testdata/overlays
+OUT=$(mktemp -d)
+STATUS=0
+
+normalize () { # Lines and tokens sorted (sets are written in any order)
+  while read -r line; do
+    echo $(tr ' ' '\n' <<< "$line" | sort)
+  done | sort
+}
+
+for v in plain gz; do
+  opt=""
+  [ $v = gz ] && opt=-compress
+  mkdir -p $OUT/bdp_$v $OUT/merge_$v
+  go run . rib_parsing ribs_multi -a $D/ases.txt -c $D/collectors.txt -asrel testdata/golden/universe/as_rel.txt \
+    -mrt-dir $D/dump -overlay_warn 1 -keep-records $opt -o $OUT/$v > /dev/null 2>&1 &&
+  go run . rib_parsing build_best_directed_probes -a $D/ases.txt -c $D/collectors.txt -d $OUT/$v -o $OUT/bdp_$v > /dev/null 2>&1 &&
+  go run . rib_parsing add_as -d $OUT/$v -as 1 > /dev/null 2>&1 &&
+  go run . rocketfuel_simulation merge_nextAS $OUT/merge_$v <(echo "100 1") $D/collectors.txt $OUT/$v/next-hop_AS > /dev/null 2>&1 || STATUS=1
+done
+
+if [ -n "$(find $OUT/gz/forwarding_tables $OUT/gz/overlays $OUT/gz/next-hop_AS $OUT/gz/prev-hop_AS -name '*.txt' ! -name all_overlays.txt)" ]; then
+  echo "uncompressed per-collector files with -compress"
+  STATUS=1
+fi
+for f in $(cd $OUT/plain && find . -name '*.txt'); do
+  gz=$OUT/gz/$f.gz
+  [ -f $gz ] || gz=$OUT/gz/$f
+  if ! cmp -s <(normalize < $OUT/plain/$f) <(gzip -dcf $gz | normalize); then
+    echo "$f differs"
+    STATUS=1
+  fi
+done
+for f in bdp_PLAIN/directed_prefixes_100.txt merge_PLAIN/merged_next_AS_100.txt merge_PLAIN/merged_next_AS_1.txt; do
+  if [ ! -s $OUT/${f/PLAIN/plain} ] || ! cmp -s <(sort $OUT/${f/PLAIN/plain}) <(sort $OUT/${f/PLAIN/gz}); then
+    echo "${f/PLAIN/gz} differs"
+    STATUS=1
+  fi
+done
+[ $STATUS -eq 0 ] && echo "compress: ok" || echo "compress: FAILED"
+rm -rf $OUT
+exit $STATUS
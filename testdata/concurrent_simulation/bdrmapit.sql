@@ -0,0 +1,34 @@
+CREATE TABLE annotation(addr text, router text, asn int, org text, conn_asn int, conn_org text, rtype int, itype int);
+INSERT INTO annotation VALUES('50.0.0.3','N8',100,'o',100,'o',1,1);
+INSERT INTO annotation VALUES('50.1.3.4','N2',100,'o',100,'o',1,1);
+INSERT INTO annotation VALUES('50.1.0.4','N10',300,'o',300,'o',1,1);
+INSERT INTO annotation VALUES('50.0.3.3','N10',100,'o',100,'o',1,1);
+INSERT INTO annotation VALUES('50.0.2.1','N1',100,'o',100,'o',1,1);
+INSERT INTO annotation VALUES('50.2.0.4','N7',100,'o',100,'o',1,1);
+INSERT INTO annotation VALUES('50.2.0.2','N8',300,'o',300,'o',1,1);
+INSERT INTO annotation VALUES('50.2.1.3','N11',100,'o',100,'o',1,1);
+INSERT INTO annotation VALUES('50.0.3.1','N11',100,'o',100,'o',1,1);
+INSERT INTO annotation VALUES('50.2.2.1','N15',200,'o',200,'o',1,1);
+INSERT INTO annotation VALUES('50.2.3.2','N5',200,'o',200,'o',1,1);
+INSERT INTO annotation VALUES('50.2.3.4','N8',100,'o',100,'o',1,1);
+INSERT INTO annotation VALUES('50.0.3.4','N6',100,'o',100,'o',1,1);
+INSERT INTO annotation VALUES('50.1.0.2','N6',300,'o',300,'o',1,1);
+INSERT INTO annotation VALUES('50.0.2.4','N3',100,'o',100,'o',1,1);
+INSERT INTO annotation VALUES('50.2.1.1','N9',100,'o',100,'o',1,1);
+INSERT INTO annotation VALUES('50.2.1.4','N14',200,'o',200,'o',1,1);
+INSERT INTO annotation VALUES('50.2.2.4','N11',200,'o',200,'o',1,1);
+INSERT INTO annotation VALUES('50.2.1.2','N1',300,'o',300,'o',1,1);
+INSERT INTO annotation VALUES('50.1.2.2','N8',300,'o',300,'o',1,1);
+INSERT INTO annotation VALUES('50.1.3.3','N9',100,'o',100,'o',1,1);
+INSERT INTO annotation VALUES('50.2.0.1','N8',100,'o',100,'o',1,1);
+INSERT INTO annotation VALUES('50.0.2.2','N2',200,'o',200,'o',1,1);
+INSERT INTO annotation VALUES('50.2.2.3','N12',300,'o',300,'o',1,1);
+INSERT INTO annotation VALUES('50.1.2.4','N5',100,'o',100,'o',1,1);
+INSERT INTO annotation VALUES('50.2.3.1','N1',100,'o',100,'o',1,1);
+INSERT INTO annotation VALUES('50.1.1.1','N8',100,'o',100,'o',1,1);
+INSERT INTO annotation VALUES('50.1.1.4','N3',200,'o',200,'o',1,1);
+INSERT INTO annotation VALUES('50.0.0.2','N3',300,'o',300,'o',1,1);
+INSERT INTO annotation VALUES('50.0.3.2','N2',300,'o',300,'o',1,1);
+INSERT INTO annotation VALUES('50.2.3.3','N1',300,'o',300,'o',1,1);
+INSERT INTO annotation VALUES('50.0.1.2','N13',200,'o',200,'o',1,1);
+INSERT INTO annotation VALUES('50.1.1.3','N14',100,'o',100,'o',1,1);
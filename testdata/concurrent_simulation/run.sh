@@ -0,0 +1,35 @@
+#!/bin/bash
+# Checks that the ASes of interest can be simulated concurrently: the simulation of two ASes
+# (same targets, different ground truths) runs under the race detector with -j 2, in both
+# credit bounds, and gives the same results as the simulation of one AS at a time.
+# Usage (from the repository root): testdata/concurrent_simulation/run.sh
+D=testdata/concurrent_simulation
+OUT=$(mktemp -d)
+python3 -c "import sqlite3, sys; db = sqlite3.connect (sys.argv[1]); db.executescript (open (sys.argv[2]).read ()); db.commit ()" $OUT/bdrmapit.db $D/bdrmapit.sql
+go build -race -o $OUT/anaximander . || exit 1
+STATUS=0
+for j in 1 2; do
+  mkdir $OUT/j$j
+  $OUT/anaximander simulation -j $j -credit_mode fractional -seed 1 \
+    -ases $D/ases.txt \
+    -bdr $OUT/bdrmapit.db \
+    -warts $D/traces \
+    -strategy $D/strategy \
+    -o $OUT/j$j/simulation.txt > $OUT/j$j/output.txt 2> $OUT/j$j/log
+  if [ $? -ne 0 ] || grep -q "DATA RACE" $OUT/j$j/log; then
+    echo "concurrent_simulation: -j $j failed (see the log below)"
+    grep -A20 "DATA RACE" $OUT/j$j/log | head -40
+    STATUS=1
+  fi
+done
+if [ $STATUS -eq 0 ]; then
+  for f in $(cd $OUT/j1 && ls sorted_*); do
+    diff -u $OUT/j1/$f $OUT/j2/$f || STATUS=1
+  done
+  for f in output.txt all_reduction.txt; do # Lines in completion order
+    diff -u <(sort $OUT/j1/$f) <(sort $OUT/j2/$f) || STATUS=1
+  done
+fi
+[ $STATUS -eq 0 ] && echo "concurrent_simulation: ok" || echo "concurrent_simulation: FAILED"
+rm -rf $OUT
+exit $STATUS
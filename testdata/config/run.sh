@@ -0,0 +1,33 @@
+#!/bin/bash
+# Checks the configuration files (-config): the simulation of concurrent_simulation described by
+# simulation.json, with integers of 1e6 and more (-seed 1234567, -greedy-patience 1000000), must give
+# the files of the same flags on the command line, and dump these values as written.
+# Usage (from the repository root): testdata/config/run.sh
+D=testdata/config
+S=testdata/concurrent_simulation
+OUT=$(mktemp -d)
+python3 -c "import sqlite3, sys; db = sqlite3.connect (sys.argv[1]); db.executescript (open (sys.argv[2]).read ()); db.commit ()" $OUT/bdrmapit.db $S/bdrmapit.sql
+go build -o $OUT/anaximander . || exit 1
+STATUS=0
+mkdir $OUT/config $OUT/flags
+# -bdr on the command line, along with the file
+$OUT/anaximander simulation -config $D/simulation.json -dump-config -bdr $OUT/bdrmapit.db \
+  -o $OUT/config/simulation.txt > $OUT/config/output.txt 2> $OUT/config/log || { echo "config: simulation with -config failed"; tail -3 $OUT/config/log; STATUS=1; }
+$OUT/anaximander simulation -ases $S/ases.txt -warts $S/traces -strategy $S/strategy -seed 1234567 -greedy-patience 1000000 \
+  -no-cache -credit_mode fractional -bdr $OUT/bdrmapit.db \
+  -o $OUT/flags/simulation.txt > $OUT/flags/output.txt 2> $OUT/flags/log || { echo "config: simulation with flags failed"; STATUS=1; }
+if [ $STATUS -eq 0 ]; then
+  for f in $(cd $OUT/flags && ls sorted_*); do
+    diff -u $OUT/flags/$f $OUT/config/$f || STATUS=1
+  done
+  python3 - $OUT/config/simulation.txt_run_config.json <<'PY' || STATUS=1
+import json, sys
+dumped = json.load (open (sys.argv[1]))
+for name, value in (("seed", "1234567"), ("greedy-patience", "1000000"), ("no-cache", "true"), ("credit_mode", "fractional")):
+    if dumped[name] != value:
+        sys.exit ("config: dumped %s is %r, expected %r" % (name, dumped[name], value))
+PY
+fi
+[ $STATUS -eq 0 ] && echo "config: ok" || echo "config: FAILED"
+rm -rf "${OUT:?}"
+exit $STATUS
@@ -0,0 +1,12 @@
+CREATE TABLE annotation(addr text, router text, asn int, org text, conn_asn int, conn_org text, rtype int, itype int);
+INSERT INTO annotation VALUES('60.9.0.1','R9',400,'o',400,'o',1,1);
+INSERT INTO annotation VALUES('60.9.0.2','R8',300,'o',300,'o',1,1);
+INSERT INTO annotation VALUES('60.0.0.1','R1',100,'o',100,'o',1,1);
+INSERT INTO annotation VALUES('60.0.0.2','R2',100,'o',100,'o',1,1);
+INSERT INTO annotation VALUES('60.0.1.1','R3',100,'o',100,'o',1,1);
+INSERT INTO annotation VALUES('60.2.0.1','R20',200,'o',200,'o',1,1);
+INSERT INTO annotation VALUES('60.3.0.1','R30',300,'o',300,'o',1,1);
+INSERT INTO annotation VALUES('60.4.0.1','R40',400,'o',400,'o',1,1);
+INSERT INTO annotation VALUES('60.5.0.1','R50',500,'o',500,'o',1,1);
+INSERT INTO annotation VALUES('60.6.0.1','R60',600,'o',600,'o',1,1);
+INSERT INTO annotation VALUES('60.7.0.1','R70',700,'o',700,'o',1,1);
@@ -0,0 +1,13 @@
+CREATE TABLE annotation(addr text, router text, asn int, org text, conn_asn int, conn_org text, rtype int, itype int);
+INSERT INTO annotation VALUES('50.0.0.1','N0',100,'o',100,'o',1,1);
+INSERT INTO annotation VALUES('50.0.0.2','N0',100,'o',100,'o',1,1);
+INSERT INTO annotation VALUES('50.9.0.1','N1',400,'o',400,'o',1,1);
+INSERT INTO annotation VALUES('50.9.0.2','N1',400,'o',400,'o',1,1);
+INSERT INTO annotation VALUES('50.0.1.1','N2',100,'o',100,'o',1,1);
+INSERT INTO annotation VALUES('50.0.1.2','N2',100,'o',100,'o',1,1);
+INSERT INTO annotation VALUES('50.0.2.1','N3',100,'o',100,'o',1,1);
+INSERT INTO annotation VALUES('50.0.2.2','N3',100,'o',100,'o',1,1);
+INSERT INTO annotation VALUES('50.9.1.1','N4',400,'o',400,'o',1,1);
+INSERT INTO annotation VALUES('50.9.1.2','N4',400,'o',400,'o',1,1);
+INSERT INTO annotation VALUES('50.0.3.1','N5',100,'o',100,'o',1,1);
+INSERT INTO annotation VALUES('50.0.3.2','N5',100,'o',100,'o',1,1);
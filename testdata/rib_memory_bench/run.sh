@@ -0,0 +1,74 @@
+#!/bin/bash
+# Measures the peak memory (maximum RSS) of ribs_multi on a dump grouped by prefix. The dump is
+# generated: NB_PREFIXES prefixes (default 300000), each announced by 20 peers through 2 transit ASes
+# (1 for one of the peers, whose path must be selected).
+# A prefix is selected once it has not been seen for -rib_window records (see pending_prefixes), so
+# the peak memory is that of the entries of the last -rib_window records, not of the whole table:
+# the run is done with the default window and with -rib_window 1, and on a grouped dump the
+# forwarding tables must be identical.
+# With BASE=<revision>, that revision is built and measured as well (e.g., BASE=HEAD~1).
+# Usage (from the repository root): [BASE=<revision>] [NB_PREFIXES=n] testdata/rib_memory_bench/run.sh
+OUT=$(mktemp -d)
+STATUS=0
+mkdir -p $OUT/dump/rrc00
+echo 45000 > $OUT/ases.txt
+echo rrc00 > $OUT/collectors.txt
+python3 - $OUT ${NB_PREFIXES:-300000} << 'EOF_PY' || { rm -rf "${OUT:?}"; exit 1; }
+import gzip, ipaddress, random, struct, sys
+out_dir, nb_prefixes = sys.argv[1], int(sys.argv[2])
+T = 1618876800
+ORIGINS, PEERS, POOL = list(range(45000, 45100)), list(range(1000, 1020)), list(range(40000, 40200))
+def rec(sub, body): return struct.pack('>IHHI', T, 13, sub, len(body)) + body
+pit = struct.pack('>IHH', 0x0a000001, 0, len(PEERS))
+for i, asn in enumerate(PEERS):
+    pit += bytes([0x02]) + struct.pack('>I', 0x0a000001) + bytes([192, 0, 2, i + 1]) + struct.pack('>I', asn)
+def attrs(path):
+    seg = bytes([2, len(path)]) + b''.join(struct.pack('>I', a) for a in path)
+    return bytes([0x50, 2]) + struct.pack('>H', len(seg)) + seg + bytes([0x40, 3, 4]) + bytes([192, 0, 2, 254])
+random.seed(826)
+with gzip.GzipFile(out_dir + '/dump/rrc00/bview.20210420.0000.gz', 'wb', mtime=0, compresslevel=1) as f:
+    f.write(rec(1, pit))
+    for p in range(nb_prefixes):
+        prefix = ipaddress.ip_network('%d.%d.%d.0/24' % (20 + p // 65536, p // 256 % 256, p % 256))
+        origin = random.choice(ORIGINS)
+        body = struct.pack('>IB', p + 1, 24) + prefix.network_address.packed[:3] + struct.pack('>H', len(PEERS))
+        for i, peer in enumerate(PEERS):
+            a = attrs([peer] + random.sample(POOL, 1 if i == p % len(PEERS) else 2) + [origin]) # One shortest path
+            body += struct.pack('>HIH', i, T, len(a)) + a
+        f.write(rec(2, body))
+EOF_PY
+
+run () { # <name> <binary> [options]: prints the maximum RSS of ribs_multi
+  local name=$1 bin=$2
+  shift 2
+  python3 - $OUT/$name.log $bin rib_parsing ribs_multi -quiet -a $OUT/ases.txt -c $OUT/collectors.txt -h shortest \
+    -mrt-dir $OUT/dump -o $OUT/$name "$@" << 'EOF_PY' || return 1
+import resource, subprocess, sys
+with open(sys.argv[1], 'w') as log:
+    code = subprocess.call(sys.argv[2:], stdout=log, stderr=log)
+print('%s: maximum RSS %.1f MB' % (sys.argv[1].rsplit('/', 1)[1][:-4], resource.getrusage(resource.RUSAGE_CHILDREN).ru_maxrss / 1024))
+sys.exit(code)
+EOF_PY
+  sort $OUT/$name/forwarding_tables/rrc00.txt > $OUT/$name.txt
+}
+
+go build -o $OUT/current.bin . || exit 1
+run current $OUT/current.bin || STATUS=1
+run current_window_1 $OUT/current.bin -rib_window 1 || STATUS=1
+if ! cmp -s $OUT/current.txt $OUT/current_window_1.txt; then
+  echo "-rib_window 1: different forwarding table"
+  STATUS=1
+fi
+if [ -n "$BASE" ]; then
+  mkdir $OUT/base_src
+  git archive "$BASE" | tar -x -C $OUT/base_src
+  (cd $OUT/base_src && go build -o $OUT/base.bin .) || STATUS=1
+  run base $OUT/base.bin || STATUS=1
+  if ! cmp -s $OUT/base.txt $OUT/current.txt; then
+    echo "$BASE: different forwarding table"
+    STATUS=1
+  fi
+fi
+[ $STATUS -eq 0 ] && echo "rib_memory_bench: ok" || echo "rib_memory_bench: FAILED"
+[ -n "$KEEP" ] && echo $OUT || rm -rf "${OUT:?}"
+exit $STATUS
@@ -0,0 +1,65 @@
+#!/bin/bash
+# Checks that ribs_multi -resume does not parse again the collectors completed by a previous run,
+# and that the gathered outputs are the same as those of a single run over all the collectors.
+# rrc00 and rrc01 read the dumps of testdata/rib_interleaved. The first run fails on rrc01 (no
+# dump yet); the second run (-resume) finds the dump of rrc01, and no longer the one of rrc00:
+# parsing rrc00 again would fail.
+# Usage (from the repository root): testdata/rib_resume/run.sh
+D=testdata/rib_interleaved
+OUT=$(mktemp -d)
+STATUS=0
+go build -o $OUT/anaximander . || exit 1
+printf "rrc00\nrrc01\n" > $OUT/collectors.txt
+ribs_multi () { # <mrt_dir> <output_dir> [flags]
+  $OUT/anaximander rib_parsing ribs_multi -quiet -a $D/ases.txt -c $OUT/collectors.txt -asrel testdata/golden/universe/as_rel.txt \
+    -collector-retries 0 -mrt-dir $1 -o $2 "${@:3}" > /dev/null 2> $2.log
+}
+normalize () { # Lines and words of a file in lexical order
+  python3 -c "import sys; [print (' '.join (sorted (l.split ()))) for l in sorted (open (sys.argv[1]))]" $1
+}
+
+# --- Reference: both collectors in a single run ---
+mkdir -p $OUT/mrt_all
+ln -s $PWD/$D/grouped/rrc00 $OUT/mrt_all/rrc00
+ln -s $PWD/$D/interleaved/rrc00 $OUT/mrt_all/rrc01
+ribs_multi $OUT/mrt_all $OUT/reference
+
+# --- First run: rrc01 fails ---
+mkdir -p $OUT/mrt
+ln -s $PWD/$D/grouped/rrc00 $OUT/mrt/rrc00
+ribs_multi $OUT/mrt $OUT/resumed
+if [ ! -e $OUT/resumed/.done_rrc00 ] || [ -e $OUT/resumed/.done_rrc01 ] || [ "$(cat $OUT/resumed/failed_collectors.txt)" != rrc01 ]; then
+  echo "first run: rrc00 should be done, and rrc01 failed"
+  STATUS=1
+fi
+BEFORE=$(stat -c %Y.%s $OUT/resumed/forwarding_tables/rrc00.txt)
+
+# --- Second run (-resume): only rrc01 is parsed ---
+rm $OUT/mrt/rrc00
+ln -s $PWD/$D/interleaved/rrc00 $OUT/mrt/rrc01
+sleep 1 # A table written again would get a new modification time
+if ! ribs_multi $OUT/mrt $OUT/resumed -resume; then
+  echo "second run failed"
+  STATUS=1
+fi
+if ! grep -q "collector rrc00 already parsed" $OUT/resumed.log; then
+  echo "second run: rrc00 not skipped"
+  STATUS=1
+fi
+if [ "$(stat -c %Y.%s $OUT/resumed/forwarding_tables/rrc00.txt)" != "$BEFORE" ]; then
+  echo "second run: the forwarding table of rrc00 was written again"
+  STATUS=1
+fi
+if [ ! -e $OUT/resumed/.done_rrc01 ] || [ -s $OUT/resumed/failed_collectors.txt ]; then
+  echo "second run: rrc01 should be done"
+  STATUS=1
+fi
+for f in collectors/origin_ases.txt collectors/all_BGP_peers.txt overlays/all_overlays.txt forwarding_tables/rrc00.txt forwarding_tables/rrc01.txt; do
+  if ! diff -u <(normalize $OUT/reference/$f) <(normalize $OUT/resumed/$f); then
+    echo "$f differs from a single run"
+    STATUS=1
+  fi
+done
+[ $STATUS -eq 0 ] && echo "rib_resume: ok" || echo "rib_resume: FAILED"
+rm -rf $OUT
+exit $STATUS
@@ -0,0 +1,57 @@
+#!/bin/bash
+# Benchmark of the warts parsing (the 32 workers of parse_warts write the same sets of traces,
+# adjs and addresses): parses a synthetic dataset of about 1M hops (64 JSON trace files of 2000
+# traces of 8 hops) with 8 and with 32 threads (GOMAXPROCS), through 'analysis trace_rtt', and
+# prints the time of each. Checks that both give the same traces, adjs, addresses and RTTs.
+# The scaling from 8 to 32 threads only shows on a machine with at least 32 cores.
+# Usage (from the repository root): testdata/sharded_parse/run.sh
+OUT=$(mktemp -d)
+STATUS=0
+mkdir -p $OUT/warts
+python3 -c "
+import random
+random.seed (1)
+addrs = ['20.%d.%d.1' % (i // 256, i % 256) for i in range (65536)]
+with open ('$OUT/annotations.csv', 'w') as f:
+    f.write ('addr,router,asn\n')
+    for i, addr in enumerate (addrs):
+        f.write ('%s,N%d,%d\n' % (addr, i // 2, 100 + i % 4))
+k = 0
+for n in range (64):
+    with open ('$OUT/warts/traces_%02d.json' % n, 'w') as f:
+        for t in range (2000):
+            hops = ', '.join ('{\"addr\": \"%s\", \"probe_ttl\": %d, \"rtt\": %.3f}' % (random.choice (addrs), ttl, random.uniform (1, 50)) for ttl in range (1, 9))
+            f.write ('{\"type\": \"trace\", \"src\": \"1.1.%d.%d\", \"dst\": \"%d.%d.%d.9\", \"hops\": [%s]}\n' % (n, t % 8, 60 + k // 65536, (k // 256) % 256, k % 256, hops))
+            k += 1
+"
+echo "100 101 102 103" > $OUT/ases.txt
+
+if ! go build -o $OUT/anaximander .; then
+  STATUS=1
+fi
+for P in 8 32; do
+  mkdir -p $OUT/out_$P
+  start=$(date +%s.%N)
+  # A cache directory of its own: the warts are parsed, not loaded from the cache of an earlier run
+  if ! XDG_CACHE_HOME=$OUT/cache_$P GOMAXPROCS=$P $OUT/anaximander analysis trace_rtt $OUT/ases.txt $OUT/annotations.csv $OUT/warts $OUT/out_$P > $OUT/log_$P.txt 2>&1; then
+    tail -n 3 $OUT/log_$P.txt
+    STATUS=1
+  fi
+  end=$(date +%s.%N)
+  awk "BEGIN { printf \"GOMAXPROCS=$P: %.2f s\n\", $end - $start }"
+  grep -o "Number of.*" $OUT/log_$P.txt > $OUT/stats_$P.txt
+done
+if ! cmp -s $OUT/stats_8.txt $OUT/stats_32.txt || ! cmp -s $OUT/out_8/ingress_rtts_summary.txt $OUT/out_32/ingress_rtts_summary.txt; then
+  echo "The parse with 8 and with 32 threads differ:"
+  diff $OUT/stats_8.txt $OUT/stats_32.txt
+  diff $OUT/out_8/ingress_rtts_summary.txt $OUT/out_32/ingress_rtts_summary.txt
+  STATUS=1
+fi
+if ! grep -q "Number of traces:  128000" $OUT/stats_8.txt; then
+  echo "Expected 128000 traces:"
+  grep "Number of traces:" $OUT/stats_8.txt
+  STATUS=1
+fi
+[ $STATUS -eq 0 ] && echo "sharded_parse: ok" || echo "sharded_parse: FAILED"
+rm -rf $OUT
+exit $STATUS
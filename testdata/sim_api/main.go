@@ -0,0 +1,87 @@
+/* ==================================================================================== *\
+     testdata/sim_api/main.go
+
+     A program embedding the simulator through its API (package sim), as an analysis
+     program would: all the data sets given are loaded first, then their ASes of interest
+     are simulated one data set after the other, so that a data set leaking into another
+     (through a global) would change the results. For each data set i, the discovery curve
+     of each AS goes to <output_dir>/<i>/sorted_simulation_<AS>.txt (the format of the
+     simulation command), and its statistics to <output_dir>/<i>/stats.txt.
+
+     Usage (from the repository root):
+       go run ./testdata/sim_api <output_dir> <warts_dir>,<bdrmapit>,<ases>,<strategy_dir>[,<as_rel>] ...
+\* ==================================================================================== */
+
+package main
+
+import (
+    "fmt"
+    "log"
+    "os"
+    "path/filepath"
+    "strconv"
+    "strings"
+    "github.com/Emeline-1/anaximander_simulator/sim"
+    )
+
+func main () {
+    if len (os.Args) < 3 {
+        log.Fatal ("usage: sim_api <output_dir> <warts_dir>,<bdrmapit>,<ases>,<strategy_dir>[,<as_rel>] ...")
+    }
+    output_dir := os.Args[1]
+
+    /* --- Load every data set before simulating --- */
+    datasets := make ([]*sim.Datasets, 0)
+    for _, arg := range os.Args[2:] {
+        files := strings.Split (arg, ",")
+        cfg := &sim.Config{WartsDirectory: files[0], BdrmapitFile: files[1], AsesInterestFile: files[2], StrategyDir: files[3], NoCache: true, Seed: 1}
+        if len (files) > 4 {
+            cfg.AsRelFile = files[4]
+        }
+        ds, err := sim.LoadDatasets (cfg)
+        if err != nil {
+            log.Fatal (arg, ": ", err)
+        }
+        datasets = append (datasets, ds)
+    }
+
+    for i, ds := range datasets {
+        dir := filepath.Join (output_dir, strconv.Itoa (i))
+        if err := os.MkdirAll (dir, 0755); err != nil {
+            log.Fatal (err)
+        }
+        stats, err := os.Create (filepath.Join (dir, "stats.txt"))
+        if err != nil {
+            log.Fatal (err)
+        }
+        for _, as := range ds.AsesInterest {
+            strategy, err := sim.LoadStrategy (ds, as)
+            if err != nil {
+                log.Fatal (err)
+            }
+            result, err := sim.Simulate (ds, as, strategy, sim.Options{Threshold: 1})
+            if err != nil {
+                log.Fatal (err)
+            }
+            curve, err := os.Create (filepath.Join (dir, "sorted_simulation_" + as + ".txt"))
+            if err != nil {
+                log.Fatal (err)
+            }
+            for _, point := range result.Curve {
+                levels := []string{strconv.Itoa (point.Probe)}
+                for _, level := range []float64{point.Adjs, point.MultiAdjs, point.Addresses, point.Routers} {
+                    levels = append (levels, strconv.FormatFloat (level, 'f', 4, 32))
+                }
+                fmt.Fprintln (curve, strings.Join (levels, " "))
+            }
+            if err := curve.Close (); err != nil {
+                log.Fatal (err)
+            }
+            s := result.Stats
+            fmt.Fprintln (stats, as, s.Adjs, s.MultiAdjs, s.Addresses, s.Routers, s.Targets, s.Probes, s.UsefulProbes, s.MissingTraces, s.FalsePositives, len (result.Groups))
+        }
+        if err := stats.Close (); err != nil {
+            log.Fatal (err)
+        }
+    }
+}
@@ -0,0 +1,39 @@
+#!/bin/bash
+# Checks the simulation API (package sim) from a program importing it (main.go): two data sets
+# (those of concurrent_simulation and of greedy_patience, both with an AS 100) are loaded in the same
+# process, and the discovery curve of each AS must be the one of the simulation command on that data set
+# alone. The statistics of the ASes, written by the program only, are compared with expected_stats_<i>.txt.
+# Usage (from the repository root): testdata/sim_api/run.sh
+D=testdata/sim_api
+U=testdata/golden/universe
+OUT=$(mktemp -d)
+export XDG_CACHE_HOME=$OUT/cache # The warts cache of the runs
+STATUS=0
+go build -o $OUT/anaximander . || exit 1
+sets=""
+for i in 0 1; do
+  S=$([ $i -eq 0 ] && echo testdata/concurrent_simulation || echo testdata/greedy_patience)
+  ASREL=$([ $i -eq 1 ] && echo $U/as_rel.txt)
+  python3 -c "import sqlite3, sys; db = sqlite3.connect (sys.argv[1]); db.executescript (open (sys.argv[2]).read ()); db.commit ()" $OUT/bdrmapit_$i.db $S/bdrmapit.sql
+  mkdir $OUT/cli_$i
+  $OUT/anaximander simulation -seed 1 -no-cache -ases $S/ases.txt -bdr $OUT/bdrmapit_$i.db -warts $S/traces -strategy $S/strategy \
+    ${ASREL:+-asrel $ASREL} -o $OUT/cli_$i/simulation.txt > $OUT/cli_$i/output.txt 2> $OUT/cli_$i/log || { echo "sim_api: simulation of data set $i failed"; STATUS=1; }
+  sets="$sets $S/traces,$OUT/bdrmapit_$i.db,$S/ases.txt,$S/strategy${ASREL:+,$ASREL}"
+done
+if ! go run ./$D $OUT/api $sets 2> $OUT/api.log; then
+  echo "sim_api: the API program failed"
+  tail -3 $OUT/api.log
+  STATUS=1
+fi
+for i in 0 1; do
+  for f in $(cd $OUT/cli_$i && ls sorted_simulation_*.txt); do
+    diff -u $OUT/cli_$i/$f $OUT/api/$i/$f || STATUS=1
+  done
+  if [ -n "$ANAXIMANDER_UPDATE_GOLDEN" ]; then
+    cp $OUT/api/$i/stats.txt $D/expected_stats_$i.txt
+  fi
+  diff -u $D/expected_stats_$i.txt $OUT/api/$i/stats.txt || STATUS=1
+done
+[ $STATUS -eq 0 ] && echo "sim_api: ok" || echo "sim_api: FAILED"
+rm -rf "${OUT:?}"
+exit $STATUS
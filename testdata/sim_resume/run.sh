@@ -0,0 +1,66 @@
+#!/bin/bash
+# Checks the checkpoint of the simulation (-checkpoint, -resume), on the data of
+# testdata/concurrent_simulation:
+#   - without -checkpoint, no checkpoint.json is written;
+#   - a run resumed after the simulation of an AS was lost (its partial limits file left in the
+#     output directory) gives the results of a run in one go, with one line per AS in all_reduction.txt;
+#   - -resume is refused if a parameter of the simulation (-router-k) or the listing of -warts changed.
+# Usage (from the repository root): testdata/sim_resume/run.sh
+D=testdata/concurrent_simulation
+OUT=$(mktemp -d)
+STATUS=0
+python3 -c "import sqlite3, sys; db = sqlite3.connect (sys.argv[1]); db.executescript (open (sys.argv[2]).read ()); db.commit ()" $OUT/bdrmapit.db $D/bdrmapit.sql
+cp -r $D/traces $OUT/traces
+go build -o $OUT/anaximander . || exit 1
+
+simulation () { # <dir> [flags...]
+  dir=$1
+  shift
+  mkdir -p $dir
+  $OUT/anaximander simulation -seed 1 -ases $D/ases.txt -bdr $OUT/bdrmapit.db -warts $OUT/traces \
+    -strategy $D/strategy -o $dir/simulation.txt "$@" > $dir/output.txt 2>> $dir/log
+}
+
+simulation $OUT/plain || { echo "sim_resume: the simulation failed"; STATUS=1; }
+[ -f $OUT/plain/checkpoint.json ] && { echo "sim_resume: checkpoint.json written without -checkpoint"; STATUS=1; }
+simulation $OUT/ref -checkpoint || STATUS=1
+grep -q '"router-k": "2"' $OUT/ref/checkpoint.json || { echo "sim_resume: parameters not in checkpoint.json"; STATUS=1; }
+
+# --- An AS lost by a crash ---
+cp -r $OUT/ref $OUT/crash
+python3 - $OUT/crash <<'PY'
+import json, os, sys
+d = sys.argv[1]
+c = json.load (open (d + "/checkpoint.json"))
+del c["done"]["simulation_200.txt"]
+json.dump (c, open (d + "/checkpoint.json", "w"))
+lines = [l for l in open (d + "/all_reduction.txt") if l.split ()[0] != "200"]
+open (d + "/all_reduction.txt", "w").writelines (lines)
+open (d + "/simulation_200_limits_reduction.txt", "w").write ("200 1 \n")
+os.remove (d + "/sorted_simulation_200.txt")
+PY
+simulation $OUT/crash -resume || { echo "sim_resume: -resume failed"; STATUS=1; }
+for f in $(cd $OUT/ref && ls sorted_*); do
+  cmp -s $OUT/ref/$f $OUT/crash/$f || { echo "sim_resume: $f differs after -resume"; STATUS=1; }
+done
+cmp -s <(sort $OUT/ref/all_reduction.txt) <(sort $OUT/crash/all_reduction.txt) || { echo "sim_resume: all_reduction.txt differs after -resume"; STATUS=1; }
+ls $OUT/crash/*limits_reduction.txt > /dev/null 2>&1 && { echo "sim_resume: limits files left"; STATUS=1; }
+for f in $(cd $OUT/plain && ls sorted_*); do
+  cmp -s $OUT/plain/$f $OUT/ref/$f || { echo "sim_resume: $f differs with -checkpoint"; STATUS=1; }
+done
+
+# --- Refused resumes ---
+cp -r $OUT/ref $OUT/params
+if simulation $OUT/params -resume -router-k 1 || ! grep -q "router-k is 1, it was 2" $OUT/params/log; then
+  echo "sim_resume: -resume accepted with another -router-k"
+  STATUS=1
+fi
+touch -d "2001-01-01" $OUT/traces/traces.json
+if simulation $OUT/ref -resume || ! grep -q "input -warts changed" $OUT/ref/log; then
+  echo "sim_resume: -resume accepted with other warts"
+  STATUS=1
+fi
+
+[ $STATUS -eq 0 ] && echo "sim_resume: ok" || echo "sim_resume: FAILED"
+rm -rf "${OUT:?}"
+exit $STATUS
@@ -0,0 +1,51 @@
+#!/bin/bash
+# Checks the end-of-run summaries (summary.json): their fields, and their status when some units
+# fail. rrc99 has no dump in testdata/rib_interleaved, so it fails in ribs_multi and in
+# build_best_directed_probes (partial failure: warnings), and alone makes the run fail (exit 1).
+# Usage (from the repository root): testdata/summary/run.sh
+U=testdata/golden/universe
+R=testdata/rib_interleaved
+D=testdata/summary
+OUT=$(mktemp -d)
+STATUS=0
+
+# expect <summary> <pattern>...: each pattern must match a line of the summary
+expect () {
+  local summary=$1
+  shift
+  for pattern in "$@"; do
+    if ! grep -qE "$pattern" $summary; then
+      echo "$summary: no line matching '$pattern'"
+      STATUS=1
+    fi
+  done
+}
+SCHEMA=('"command": ' '"status": ' '"completed": true' '"started": ' '"finished": ' '"units": ' '"processed": ' '"skipped": ' '"failed": ' '"artifacts": ' '"stages": ' '"seconds": ' '"build": ')
+
+go run . rib_parsing ribs_multi -a $R/ases.txt -c $D/collectors_partial.txt -h 0 -mrt-dir $R/grouped -o $OUT/ribs > /dev/null 2>&1 || STATUS=1
+expect $OUT/ribs/summary.json "${SCHEMA[@]}" '"status": "warnings"' '"collectors": \{' '"processed": 1' '"failed": 1' 'forwarding_tables"'
+
+mkdir $OUT/bdp
+go run . rib_parsing build_best_directed_probes -a $R/ases.txt -c $D/collectors_partial.txt -d $OUT/ribs -o $OUT/bdp > /dev/null 2>&1 || STATUS=1
+expect $OUT/bdp/summary.json "${SCHEMA[@]}" '"status": "warnings"' '"failed": 1' 'directed_prefixes_100.txt"'
+
+if go run . rib_parsing build_best_directed_probes -a $R/ases.txt -c $D/collectors_missing.txt -d $OUT/ribs -o $OUT/bdp -summary_out $OUT/failed.json > /dev/null 2>&1; then
+  echo "build_best_directed_probes: exit status 0 without any collector"
+  STATUS=1
+fi
+expect $OUT/failed.json '"status": "failed"' '"completed": true'
+
+mkdir $OUT/strategy
+go run . strategy -s overlays_reduction_global_relationships -seed 1 \
+  -ases $U/ases.txt \
+  -asrel $U/as_rel.txt \
+  -ppdc $U/ppdc.txt \
+  -ip2as $U/ip2as.txt \
+  -dp_dir $U/directed_prefixes \
+  -overlays_file $U/overlays.txt \
+  -o $OUT/strategy > $OUT/strategy/output.txt 2> /dev/null || STATUS=1
+expect $OUT/strategy/summary.json "${SCHEMA[@]}" '"status": "ok"' '"ASes": \{' '"processed": 1' 'strategy_metadata.json"'
+
+[ $STATUS -eq 0 ] && echo "summary: ok" || echo "summary: FAILED"
+rm -rf $OUT
+exit $STATUS
@@ -0,0 +1,74 @@
+#!/bin/bash
+# Times the valley free heuristic on prefixes with 100 paths each (-h valley_free). The dump is
+# generated: NB_PREFIXES prefixes (default 10000), each announced by 100 peers. Two thirds of the paths
+# cross 1 to 4 ASes of a pool of 30 transit ASes, so that they meet at many pivot nodes; the other paths
+# cross a single AS of their own, through no pivot node. One path of each prefix goes directly from its
+# peer to the origin: it is the shortest path, and must be selected.
+# With BASE=<revision>, that revision is built and timed as well, and its forwarding table must be
+# identical (e.g., BASE=HEAD~1 to measure a change of the heuristic). The times include the parsing of
+# the dump: the heuristic alone is timed by BenchmarkValleyFree (cd sim && go test -run - -bench ValleyFree).
+# Usage (from the repository root): [BASE=<revision>] [NB_PREFIXES=n] testdata/valley_free_bench/run.sh
+OUT=$(mktemp -d)
+STATUS=0
+mkdir -p $OUT/dump/rrc00
+echo 45000 > $OUT/ases.txt
+echo rrc00 > $OUT/collectors.txt
+python3 - $OUT ${NB_PREFIXES:-10000} << 'EOF' || { rm -rf "${OUT:?}"; exit 1; }
+import gzip, ipaddress, random, struct, sys
+out_dir, nb_prefixes = sys.argv[1], int(sys.argv[2])
+T = 1618876800
+ORIGIN, PEERS, POOL = 45000, list(range(1000, 1100)), list(range(40000, 40030))
+def rec(sub, body): return struct.pack('>IHHI', T, 13, sub, len(body)) + body
+pit = struct.pack('>IHH', 0x0a000001, 0, len(PEERS))
+for i, asn in enumerate(PEERS):
+    pit += bytes([0x02]) + struct.pack('>I', 0x0a000001) + ipaddress.ip_address('192.0.2.1').packed[:3] + bytes([i + 1]) + struct.pack('>I', asn)
+def attrs(path):
+    seg = bytes([2, len(path)]) + b''.join(struct.pack('>I', a) for a in path)
+    return bytes([0x50, 2]) + struct.pack('>H', len(seg)) + seg + bytes([0x40, 3, 4]) + ipaddress.ip_address('192.0.2.254').packed
+random.seed(831)
+dump, expected = [rec(1, pit)], []
+for p in range(nb_prefixes):
+    prefix = ipaddress.ip_network('20.%d.%d.0/24' % (p // 256, p % 256))
+    paths = [[PEERS[0], ORIGIN]]
+    for i, asn in enumerate(PEERS[1:]):
+        transit = [41000 + i] if i % 3 == 0 else random.sample(POOL, random.randint(1, 4))
+        paths.append([asn] + transit + [ORIGIN])
+    random.shuffle(paths)
+    body = struct.pack('>IB', p + 1, 24) + prefix.network_address.packed[:3] + struct.pack('>H', len(paths))
+    for i, path in enumerate(paths):
+        a = attrs(path)
+        body += struct.pack('>HIH', PEERS.index(path[0]), T, len(a)) + a
+    dump.append(rec(2, body))
+    shortest = min(paths, key=len)
+    expected.append('%s %s' % (prefix, ' '.join(str(a) for a in reversed(shortest)))) # Stored from the destination AS
+with gzip.GzipFile(out_dir + '/dump/rrc00/bview.20210420.0000.gz', 'wb', mtime=0) as f:
+    f.write(b''.join(dump))
+open(out_dir + '/expected.txt', 'w').write('\n'.join(sorted(expected)) + '\n')
+EOF
+
+run () { # <name> <source_dir>
+  (cd $2 && go build -o $OUT/$1.bin .) || return 1
+  local start=$(date +%s.%N)
+  $OUT/$1.bin rib_parsing ribs_multi -quiet -a $OUT/ases.txt -c $OUT/collectors.txt -h valley_free \
+    -asrel testdata/golden/universe/as_rel.txt -mrt-dir $OUT/dump -o $OUT/$1 > $OUT/$1.log 2>&1 || return 1
+  echo "$1: $(awk "BEGIN { printf \"%.2f\", $(date +%s.%N) - $start }") s"
+  sort $OUT/$1/forwarding_tables/rrc00.txt > $OUT/$1.txt
+}
+
+run current . || STATUS=1
+if ! diff -q $OUT/expected.txt $OUT/current.txt > /dev/null; then
+  echo "current: not the shortest paths"
+  STATUS=1
+fi
+if [ -n "$BASE" ]; then
+  mkdir $OUT/base_src
+  git archive "$BASE" | tar -x -C $OUT/base_src
+  run base $OUT/base_src || STATUS=1
+  if ! diff -q $OUT/base.txt $OUT/current.txt > /dev/null; then
+    echo "$BASE: different forwarding table"
+    STATUS=1
+  fi
+fi
+[ $STATUS -eq 0 ] && echo "valley_free_bench: ok" || echo "valley_free_bench: FAILED"
+[ -n "$KEEP" ] && echo $OUT || rm -rf "${OUT:?}"
+exit $STATUS